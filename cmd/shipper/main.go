@@ -1,11 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -15,6 +17,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
@@ -22,6 +25,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	kubecache "k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	kuberestmetrics "k8s.io/client-go/tools/metrics"
 	"k8s.io/client-go/tools/record"
@@ -33,13 +37,18 @@ import (
 	shipperscheme "github.com/bookingcom/shipper/pkg/client/clientset/versioned/scheme"
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
 	"github.com/bookingcom/shipper/pkg/clusterclientstore"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	"github.com/bookingcom/shipper/pkg/controller/application"
+	"github.com/bookingcom/shipper/pkg/controller/applicationset"
 	"github.com/bookingcom/shipper/pkg/controller/capacity"
+	"github.com/bookingcom/shipper/pkg/controller/cluster"
 	"github.com/bookingcom/shipper/pkg/controller/clustersecret"
 	"github.com/bookingcom/shipper/pkg/controller/installation"
 	"github.com/bookingcom/shipper/pkg/controller/janitor"
 	"github.com/bookingcom/shipper/pkg/controller/release"
+	"github.com/bookingcom/shipper/pkg/controller/rolloutgroup"
 	"github.com/bookingcom/shipper/pkg/controller/traffic"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
 	"github.com/bookingcom/shipper/pkg/metrics/instrumentedclient"
 	shippermetrics "github.com/bookingcom/shipper/pkg/metrics/prometheus"
 	"github.com/bookingcom/shipper/pkg/webhook"
@@ -47,8 +56,11 @@ import (
 
 var controllers = []string{
 	"application",
+	"applicationset",
 	"clustersecret",
+	"cluster",
 	"release",
+	"rolloutgroup",
 	"installation",
 	"capacity",
 	"traffic",
@@ -69,6 +81,8 @@ var (
 	disabledControllers = flag.String("disable", "", "comma-seperated list of controllers to disable")
 	workers             = flag.Int("workers", 2, "Number of workers to start for each controller.")
 	metricsAddr         = flag.String("metrics-addr", ":8889", "Addr to expose /metrics on.")
+	healthAddr          = flag.String("health-addr", ":8890", "Addr to expose /healthz on.")
+	healthThreshold     = flag.Duration("health-threshold", 5*time.Minute, "Maximum age of a controller's last successful sync before /healthz reports unhealthy.")
 	chartCacheDir       = flag.String("cachedir", filepath.Join(os.TempDir(), "chart-cache"), "location for the local cache of downloaded charts")
 	resync              = flag.Duration("resync", defaultResync, "Informer's cache re-sync in Go's duration format.")
 	restTimeout         = flag.Duration("rest-timeout", defaultRESTTimeout, "Timeout value for management and target REST clients. Does not affect informer watches.")
@@ -76,14 +90,116 @@ var (
 	webhookKeyPath      = flag.String("webhook-key", "", "Path to the TLS private key for the webhook controller.")
 	webhookBindAddr     = flag.String("webhook-addr", "0.0.0.0", "Addr to bind the webhook controller.")
 	webhookBindPort     = flag.String("webhook-port", "9443", "Port to bind the webhook controller.")
+	debugReconcileDiff  = flag.Bool("debug-reconcile-diff", false, "Stamp a debug annotation on updated objects summarizing what each reconcile changed. Causes annotation churn; not recommended in production.")
+	requiredChartLabels = flag.String("required-chart-labels", "", "Comma-separated list of labels that every rendered chart object must carry (after label propagation). Empty disables the check.")
+	appHistoryLimit     = flag.Int("app-history-limit", application.DefaultHistoryLimit, "Maximum number of entries kept in Application.Status.History. Oldest entries are trimmed first; the incumbent is always kept.")
+	chartRepoMigrations = flag.String("chart-repo-migrations", "", "Comma-separated oldURL=newURL pairs. Applications whose chart repo URL matches an old URL are rewritten to the new one, after verifying the chart resolves there.")
+	defaultChartRepo    = flag.String("default-chart-repo", "", "Fallback chart repo URL, used when an Application's chart names no repo or its repo definitively doesn't serve the chart. Empty disables the fallback.")
+	namespaceQuota      = flag.String("namespace-quota", "", "Comma-separated resource=quantity pairs (e.g. cpu=16,memory=64Gi) for the default ResourceQuota stamped on target namespaces. Empty disables namespace management.")
+	namespaceLimits     = flag.String("namespace-limits", "", "Comma-separated resource=quantity pairs for the default container limits in the LimitRange stamped on target namespaces. Empty disables the LimitRange.")
+	defaultValuesJSON   = flag.String("default-chart-values", "", "JSON object of chart values merged beneath every Application's values during rendering; Application values override key by key. Empty disables the defaults.")
+	workersPerCtrl      = flag.String("workers-per-controller", "", "Comma-separated controller=N pairs overriding --workers for specific controllers (e.g. installation=8,capacity=4).")
+	chartConcurrency    = flag.Int("chart-fetch-concurrency", 4, "Maximum number of chart downloads in flight at once; excess fetches queue. Values below 1 disable the limit.")
+	installClusters     = flag.Int("install-cluster-concurrency", 1, "Maximum number of target clusters one InstallationTarget installs into concurrently. Values below 2 keep cluster installs serial.")
+	installApplies      = flag.Int("install-apply-concurrency", 4, "Maximum number of rendered objects applied concurrently within one target cluster. Only objects of equal install-order weight apply in parallel; values below 2 keep applies serial.")
+	forceDeletePods     = flag.Duration("force-delete-stuck-pods-after", 0, "Force-delete pods that have been terminating for longer than this, so orphaned pods from a scale-down can't block a rollout. Zero disables force deletion.")
+	approvedRegistries  = flag.String("approved-registries", "", "Comma-separated list of registry/prefix strings every container image in rendered charts must come from. Empty disables the check.")
+	driftCheckPeriod    = flag.Duration("drift-check-period", 0, "Re-examine installed objects on this period and report configuration drift from out-of-band edits through the ConfigurationDrift condition. Zero disables drift detection.")
+	autoCorrectDrift    = flag.Bool("auto-correct-drift", false, "Re-apply drifted objects back to the rendered desired state instead of only reporting them. Only takes effect with -drift-check-period set.")
+	changeApprovalURL   = flag.String("change-approval-webhook", "", "URL of a change-management webhook consulted before each target step advance on gated tiers. Empty disables the gate.")
+	changeApprovalTiers = flag.String("change-approval-tiers", "production", "Comma-separated shipper-tier label values whose step advances require webhook approval. Only takes effect with -change-approval-webhook set.")
 )
 
+func workerOverridesMap() map[string]int {
+	if *workersPerCtrl == "" {
+		return nil
+	}
+	overrides := map[string]int{}
+	for _, pair := range strings.Split(*workersPerCtrl, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			glog.Fatalf("malformed --workers-per-controller entry %q: want controller=N", pair)
+		}
+
+		known := false
+		for _, controller := range controllers {
+			if controller == parts[0] {
+				known = true
+				break
+			}
+		}
+		if !known {
+			glog.Fatalf("--workers-per-controller names unknown controller %q", parts[0])
+		}
+
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n < 1 {
+			glog.Fatalf("--workers-per-controller entry %q: worker count must be a positive integer", pair)
+		}
+
+		overrides[parts[0]] = n
+	}
+	return overrides
+}
+
+func repoURLMigrationsMap() map[string]string {
+	if *chartRepoMigrations == "" {
+		return nil
+	}
+	migrations := map[string]string{}
+	for _, pair := range strings.Split(*chartRepoMigrations, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			glog.Fatalf("invalid -chart-repo-migrations entry %q, expected oldURL=newURL", pair)
+		}
+		migrations[parts[0]] = parts[1]
+	}
+	return migrations
+}
+
+func namespaceResourceList(flagName, spec string) corev1.ResourceList {
+	if spec == "" {
+		return nil
+	}
+	rl, err := installation.ParseResourceList(spec)
+	if err != nil {
+		glog.Fatalf("invalid -%s value %q: %s", flagName, spec, err)
+	}
+	return rl
+}
+
+func defaultChartValues() shipper.ChartValues {
+	if *defaultValuesJSON == "" {
+		return nil
+	}
+	var values shipper.ChartValues
+	if err := json.Unmarshal([]byte(*defaultValuesJSON), &values); err != nil {
+		glog.Fatalf("invalid -default-chart-values value %q: %s", *defaultValuesJSON, err)
+	}
+	return values
+}
+
+func requiredLabelsList() []string {
+	if *requiredChartLabels == "" {
+		return nil
+	}
+	return strings.Split(*requiredChartLabels, ",")
+}
+
+func approvedRegistriesList() []string {
+	if *approvedRegistries == "" {
+		return nil
+	}
+	return strings.Split(*approvedRegistries, ",")
+}
+
 type metricsCfg struct {
 	readyCh chan struct{}
 
 	wqMetrics   *shippermetrics.PrometheusWorkqueueProvider
 	restLatency *shippermetrics.RESTLatencyMetric
 	restResult  *shippermetrics.RESTResultMetric
+	rollouts    *shippermetrics.RolloutMetrics
 }
 
 type cfg struct {
@@ -100,10 +216,12 @@ type cfg struct {
 
 	store          *clusterclientstore.Store
 	chartFetchFunc chart.FetchFunc
+	chartIndexes   *chart.IndexFetcher
 
 	certPath, keyPath string
 	ns                string
 	workers           int
+	workerOverrides   map[string]int
 
 	webhookCertPath, webhookKeyPath  string
 	webhookBindAddr, webhookBindPort string
@@ -114,9 +232,21 @@ type cfg struct {
 	metrics *metricsCfg
 }
 
+// workersFor returns the worker count for the named controller: the
+// --workers-per-controller override if one was given, the global --workers
+// value otherwise.
+func (c *cfg) workersFor(controller string) int {
+	if n, ok := c.workerOverrides[controller]; ok {
+		return n
+	}
+	return c.workers
+}
+
 func main() {
 	flag.Parse()
 
+	shippercontroller.DebugReconcileDiff = *debugReconcileDiff
+
 	baseRestCfg, err := clientcmd.BuildConfigFromFlags(*masterURL, *kubeconfig)
 	if err != nil {
 		glog.Fatal(err)
@@ -191,6 +321,8 @@ func main() {
 	glog.V(1).Infof("Chart cache stored at %q", *chartCacheDir)
 	glog.V(1).Infof("REST client timeout is %s", *restTimeout)
 
+	chartIndexes := chart.NewIndexFetcher()
+
 	cfg := &cfg{
 		enabledControllers: enabledControllers,
 		restCfg:            baseRestCfg,
@@ -203,12 +335,14 @@ func main() {
 		recorder: recorder,
 
 		store:          store,
-		chartFetchFunc: chart.FetchRemoteWithCache(*chartCacheDir, chart.DefaultCacheLimit),
+		chartFetchFunc: chart.WithConcurrencyLimit(chart.FetchRemoteWithCacheAndIndexes(*chartCacheDir, chart.DefaultCacheLimit, chartIndexes), *chartConcurrency),
+		chartIndexes:   chartIndexes,
 
-		certPath: *certPath,
-		keyPath:  *keyPath,
-		ns:       *ns,
-		workers:  *workers,
+		certPath:        *certPath,
+		keyPath:         *keyPath,
+		ns:              *ns,
+		workers:         *workers,
+		workerOverrides: workerOverridesMap(),
 
 		webhookCertPath: *webhookCertPath,
 		webhookKeyPath:  *webhookKeyPath,
@@ -223,6 +357,7 @@ func main() {
 			wqMetrics:   shippermetrics.NewProvider(),
 			restLatency: shippermetrics.NewRESTLatencyMetric(),
 			restResult:  shippermetrics.NewRESTResultMetric(),
+			rollouts:    shippermetrics.NewRolloutMetrics(),
 		},
 	}
 
@@ -236,6 +371,12 @@ func main() {
 		runMetrics(cfg.metrics)
 	}()
 
+	go func() {
+		glog.V(1).Infof("Health endpoint will listen on %s", *healthAddr)
+
+		runHealth(cfg.chartIndexes)
+	}()
+
 	runControllers(cfg)
 }
 
@@ -250,6 +391,7 @@ func (glogStdLogger) Println(v ...interface{}) {
 func runMetrics(cfg *metricsCfg) {
 	prometheus.MustRegister(cfg.wqMetrics.GetMetrics()...)
 	prometheus.MustRegister(cfg.restLatency.Summary, cfg.restResult.Counter)
+	prometheus.MustRegister(cfg.rollouts.GetMetrics()...)
 	prometheus.MustRegister(instrumentedclient.GetMetrics()...)
 
 	srv := http.Server{
@@ -268,6 +410,23 @@ func runMetrics(cfg *metricsCfg) {
 	}
 }
 
+func runHealth(chartIndexes *chart.IndexFetcher) {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", shipperhealth.Default.Handler(*healthThreshold))
+	// Read-only view of the cached chart repository indexes, for debugging
+	// chart version resolution; see IndexFetcher.DebugHandler.
+	mux.Handle("/debug/chart-index", chartIndexes.DebugHandler())
+
+	srv := http.Server{
+		Addr:    *healthAddr,
+		Handler: mux,
+	}
+	err := srv.ListenAndServe()
+	if err != nil {
+		glog.Fatalf("could not start /healthz endpoint: %s", err)
+	}
+}
+
 func buildEnabledControllers(enabledControllers, disabledControllers string) map[string]bool {
 	willRun := map[string]bool{}
 	for _, controller := range controllers {
@@ -331,6 +490,11 @@ func runControllers(cfg *cfg) {
 	go cfg.kubeInformerFactory.Start(cfg.stopCh)
 	go cfg.shipperInformerFactory.Start(cfg.stopCh)
 
+	// Refresh the index of every chart repo referenced by an existing
+	// Application, so that heavy reconciliation right after startup doesn't
+	// pay index download latency one repo at a time.
+	go warmChartIndexes(cfg)
+
 	doneCh := make(chan struct{})
 
 	go func() {
@@ -342,6 +506,27 @@ func runControllers(cfg *cfg) {
 	glog.Info("Controllers have shut down")
 }
 
+func warmChartIndexes(cfg *cfg) {
+	applicationInformer := cfg.shipperInformerFactory.Shipper().V1alpha1().Applications()
+	if !kubecache.WaitForCacheSync(cfg.stopCh, applicationInformer.Informer().HasSynced) {
+		return
+	}
+
+	apps, err := applicationInformer.Lister().List(labels.Everything())
+	if err != nil {
+		glog.Warningf("could not list Applications to warm chart repo indexes: %s", err)
+		return
+	}
+
+	repoURLs := chart.ApplicationRepoURLs(apps)
+	if len(repoURLs) == 0 {
+		return
+	}
+
+	glog.V(2).Infof("Warming the indexes of %d chart repos", len(repoURLs))
+	cfg.chartIndexes.WarmIndexes(repoURLs, *chartConcurrency)
+}
+
 func setupSignalHandler() <-chan struct{} {
 	stopCh := make(chan struct{})
 
@@ -363,8 +548,11 @@ type initFunc func(*cfg) (bool, error)
 func buildInitializers() map[string]initFunc {
 	controllers := map[string]initFunc{}
 	controllers["application"] = startApplicationController
+	controllers["applicationset"] = startApplicationSetController
 	controllers["clustersecret"] = startClusterSecretController
+	controllers["cluster"] = startClusterController
 	controllers["release"] = startReleaseController
+	controllers["rolloutgroup"] = startRolloutGroupController
 	controllers["installation"] = startInstallationController
 	controllers["capacity"] = startCapacityController
 	controllers["traffic"] = startTrafficController
@@ -383,11 +571,57 @@ func startApplicationController(cfg *cfg) (bool, error) {
 		buildShipperClient(cfg.restCfg, application.AgentName, cfg.restTimeout),
 		cfg.shipperInformerFactory,
 		cfg.recorder(application.AgentName),
+		*appHistoryLimit,
+		cfg.chartFetchFunc,
+		repoURLMigrationsMap(),
+		*defaultChartRepo,
+	)
+
+	cfg.wg.Add(1)
+	go func() {
+		c.Run(cfg.workersFor("application"), cfg.stopCh)
+		cfg.wg.Done()
+	}()
+
+	return true, nil
+}
+
+func startRolloutGroupController(cfg *cfg) (bool, error) {
+	enabled := cfg.enabledControllers["rolloutgroup"]
+	if !enabled {
+		return false, nil
+	}
+
+	c := rolloutgroup.NewController(
+		buildShipperClient(cfg.restCfg, rolloutgroup.AgentName, cfg.restTimeout),
+		cfg.shipperInformerFactory,
+		cfg.recorder(rolloutgroup.AgentName),
+	)
+
+	cfg.wg.Add(1)
+	go func() {
+		c.Run(cfg.workersFor("rolloutgroup"), cfg.stopCh)
+		cfg.wg.Done()
+	}()
+
+	return true, nil
+}
+
+func startApplicationSetController(cfg *cfg) (bool, error) {
+	enabled := cfg.enabledControllers["applicationset"]
+	if !enabled {
+		return false, nil
+	}
+
+	c := applicationset.NewController(
+		buildShipperClient(cfg.restCfg, applicationset.AgentName, cfg.restTimeout),
+		cfg.shipperInformerFactory,
+		cfg.recorder(applicationset.AgentName),
 	)
 
 	cfg.wg.Add(1)
 	go func() {
-		c.Run(cfg.workers, cfg.stopCh)
+		c.Run(cfg.workersFor("applicationset"), cfg.stopCh)
 		cfg.wg.Done()
 	}()
 
@@ -412,7 +646,29 @@ func startClusterSecretController(cfg *cfg) (bool, error) {
 
 	cfg.wg.Add(1)
 	go func() {
-		c.Run(cfg.workers, cfg.stopCh)
+		c.Run(cfg.workersFor("clustersecret"), cfg.stopCh)
+		cfg.wg.Done()
+	}()
+
+	return true, nil
+}
+
+func startClusterController(cfg *cfg) (bool, error) {
+	enabled := cfg.enabledControllers["cluster"]
+	if !enabled {
+		return false, nil
+	}
+
+	c := cluster.NewController(
+		buildShipperClient(cfg.restCfg, cluster.AgentName, cfg.restTimeout),
+		cfg.shipperInformerFactory,
+		cfg.store,
+		cfg.recorder(cluster.AgentName),
+	)
+
+	cfg.wg.Add(1)
+	go func() {
+		c.Run(cfg.workersFor("cluster"), cfg.stopCh)
 		cfg.wg.Done()
 	}()
 
@@ -425,16 +681,25 @@ func startReleaseController(cfg *cfg) (bool, error) {
 		return false, nil
 	}
 
+	var changeApprover release.ChangeApprover
+	if *changeApprovalURL != "" {
+		changeApprover = release.NewWebhookChangeApprover(*changeApprovalURL)
+	}
+
 	c := release.NewController(
 		buildShipperClient(cfg.restCfg, release.AgentName, cfg.restTimeout),
 		cfg.shipperInformerFactory,
-		cfg.chartFetchFunc,
+		chart.WithDefaultRepo(cfg.chartFetchFunc, *defaultChartRepo),
 		cfg.recorder(release.AgentName),
+		cfg.metrics.rollouts,
+		changeApprover,
+		strings.Split(*changeApprovalTiers, ","),
+		nil,
 	)
 
 	cfg.wg.Add(1)
 	go func() {
-		c.Run(cfg.workers, cfg.stopCh)
+		c.Run(cfg.workersFor("release"), cfg.stopCh)
 		cfg.wg.Done()
 	}()
 
@@ -467,13 +732,22 @@ func startInstallationController(cfg *cfg) (bool, error) {
 		cfg.shipperInformerFactory,
 		cfg.store,
 		dynamicClientBuilderFunc,
-		cfg.chartFetchFunc,
+		chart.WithDependencyResolution(chart.WithDefaultRepo(cfg.chartFetchFunc, *defaultChartRepo)),
 		cfg.recorder(installation.AgentName),
+		requiredLabelsList(),
+		approvedRegistriesList(),
+		namespaceResourceList("namespace-quota", *namespaceQuota),
+		namespaceResourceList("namespace-limits", *namespaceLimits),
+		defaultChartValues(),
+		*installClusters,
+		*installApplies,
+		*driftCheckPeriod,
+		*autoCorrectDrift,
 	)
 
 	cfg.wg.Add(1)
 	go func() {
-		c.Run(cfg.workers, cfg.stopCh)
+		c.Run(cfg.workersFor("installation"), cfg.stopCh)
 		cfg.wg.Done()
 	}()
 
@@ -491,10 +765,11 @@ func startCapacityController(cfg *cfg) (bool, error) {
 		cfg.shipperInformerFactory,
 		cfg.store,
 		cfg.recorder(capacity.AgentName),
+		*forceDeletePods,
 	)
 	cfg.wg.Add(1)
 	go func() {
-		c.Run(cfg.workers, cfg.stopCh)
+		c.Run(cfg.workersFor("capacity"), cfg.stopCh)
 		cfg.wg.Done()
 	}()
 	return true, nil
@@ -511,11 +786,14 @@ func startTrafficController(cfg *cfg) (bool, error) {
 		cfg.shipperInformerFactory,
 		cfg.store,
 		cfg.recorder(traffic.AgentName),
+		// No cloud load balancer backends are linked into this binary;
+		// deployments that need them register implementations here.
+		nil,
 	)
 
 	cfg.wg.Add(1)
 	go func() {
-		c.Run(cfg.workers, cfg.stopCh)
+		c.Run(cfg.workersFor("traffic"), cfg.stopCh)
 		cfg.wg.Done()
 	}()
 
@@ -554,7 +832,7 @@ func startJanitorController(cfg *cfg) (bool, error) {
 
 	cfg.wg.Add(1)
 	go func() {
-		c.Run(cfg.workers, cfg.stopCh)
+		c.Run(cfg.workersFor("janitor"), cfg.stopCh)
 		cfg.wg.Done()
 	}()
 
@@ -35,6 +35,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Application{},
 		&ApplicationList{},
+		&ApplicationSet{},
+		&ApplicationSetList{},
 		&Release{},
 		&ReleaseList{},
 		&Cluster{},
@@ -45,6 +47,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CapacityTargetList{},
 		&TrafficTarget{},
 		&TrafficTargetList{},
+		&Strategy{},
+		&StrategyList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
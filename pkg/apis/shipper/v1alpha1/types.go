@@ -16,6 +16,10 @@ const (
 	AppLabel                    = "shipper-app"
 	ReleaseEnvironmentHashLabel = "shipper-release-hash"
 	PodTrafficStatusLabel       = "shipper-traffic-status"
+	// TierLabel marks an Application or Release with its environment
+	// tier (e.g. "production", "staging"); higher tiers reconcile ahead
+	// of lower ones when work queues up.
+	TierLabel = "shipper-tier"
 
 	ReleaseRecordWaitingForObject = "WaitingForObject"
 	ReleaseRecordObjectCreated    = "ReleaseCreated"
@@ -27,14 +31,111 @@ const (
 	ReleasePhaseSuperseded           = "Superseded"
 	ReleasePhaseAborted              = "Aborted"
 
-	InstallationStatusInstalled = "Installed"
-	InstallationStatusFailed    = "Failed"
+	InstallationStatusInstalled              = "Installed"
+	InstallationStatusFailed                 = "Failed"
+	InstallationStatusWaitingForDependencies = "WaitingForDependencies"
 
 	AppHighestObservedGenerationAnnotation = "shipper.booking.com/app.highestObservedGeneration"
+	// AppStrategyNormalizedAnnotation is stamped by the mutating webhook on
+	// Applications whose legacy strategy it upgraded to canonical form.
+	AppStrategyNormalizedAnnotation = "shipper.booking.com/app.strategyNormalized"
+
+	// AppQuiesceAnnotation scales all of an application's releases to zero
+	// capacity and traffic for maintenance when set to "true"; removing it
+	// restores the exact specs the targets had when they were quiesced.
+	AppQuiesceAnnotation = "shipper.booking.com/app.quiesce"
+
+	// AppRecreateGracePeriodAnnotation holds a Go duration. When set, the
+	// application controller waits that long before recreating a deleted
+	// release the application's history still references, so an accidental
+	// deletion can be undone by restoring the object instead of producing
+	// a brand new rollout. Absent means recreate immediately.
+	AppRecreateGracePeriodAnnotation = "shipper.booking.com/app.recreateGracePeriod"
+
+	// AppConfirmRecreateAnnotation set to "true" confirms a deliberate
+	// release deletion: the controller recreates right away instead of
+	// sitting out the remainder of the grace period. It is removed once
+	// the release has been recreated.
+	AppConfirmRecreateAnnotation = "shipper.booking.com/app.confirmRecreate"
+
+	// AppRecreatePendingSinceAnnotation records, as RFC 3339, when the
+	// controller first held back a recreation; the grace period counts
+	// from this stamp. It is removed once the release has been recreated.
+	AppRecreatePendingSinceAnnotation = "shipper.booking.com/app.recreatePendingSince"
+
+	// QuiescedCapacitySpecAnnotation and QuiescedTrafficSpecAnnotation hold
+	// a quiesced target's prior spec, serialized as JSON, so un-quiescing
+	// can restore it verbatim.
+	QuiescedCapacitySpecAnnotation = "shipper.booking.com/quiesce.capacitySpec"
+	QuiescedTrafficSpecAnnotation  = "shipper.booking.com/quiesce.trafficSpec"
 
 	ReleaseGenerationAnnotation        = "shipper.booking.com/release.generation"
 	ReleaseTemplateIterationAnnotation = "shipper.booking.com/release.template.iteration"
 	ReleaseClustersAnnotation          = "shipper.booking.com/release.clusters"
+	ReleaseFastRollbackAnnotation      = "shipper.booking.com/release.fastRollback"
+	ReleaseKnownGoodAnnotation         = "shipper.booking.com/release.knownGood"
+	ReleaseTargetStepAuditAnnotation   = "shipper.booking.com/release.targetStepAudit"
+	ReleaseChartRepoAnnotation         = "shipper.booking.com/release.chartRepo"
+	ReleaseChartDigestAnnotation       = "shipper.booking.com/release.chartDigest"
+	ReleaseApprovedTrafficAnnotation   = "shipper.booking.com/release.approvedTrafficStep"
+	// ReleaseEnvironmentDiffAnnotation carries a bounded, human-readable
+	// summary of how the release's chart and values differ from its
+	// predecessor's, stamped at creation time.
+	ReleaseEnvironmentDiffAnnotation = "shipper.booking.com/release.environmentDiff"
+
+	DebugLastReconcileDiffAnnotation = "shipper.booking.com/debug.lastReconcileDiff"
+
+	InstallationAdoptExistingObjectsAnnotation = "shipper.booking.com/installation.adoptExistingObjects"
+	InstallationResourceQuotaAnnotation        = "shipper.booking.com/installation.resourceQuota"
+	// InstallationFlagsHashAnnotation is stamped on installed objects
+	// with a fingerprint of the release's Flags, so a flag flip on the
+	// live release re-applies them.
+	InstallationFlagsHashAnnotation = "shipper.booking.com/installation.flagsHash"
+	InstallOrderWeightAnnotation    = "shipper.booking.com/install.weight"
+	// InstallationReplaceOnApplyAnnotation, when set to "true" on a
+	// rendered chart object, makes changes to it apply by deleting and
+	// recreating the object instead of updating it in place, for kinds
+	// with immutable fields. Jobs always apply this way; stateful kinds
+	// never do.
+	InstallationReplaceOnApplyAnnotation = "shipper.booking.com/installation.replaceOnApply"
+
+	// TrafficPreferWarmPodsAnnotation, when set to "true" on a
+	// TrafficTarget, makes partial traffic ramps enable the pods that
+	// have been ready longest first: freshly-ready pods may still be
+	// cold (JIT, empty caches), so warmed pods take the traffic first.
+	TrafficPreferWarmPodsAnnotation = "shipper.booking.com/traffic.preferWarmPods"
+
+	// CapacityRampStepSizeAnnotation, set on a CapacityTarget, rations
+	// scale-ups to this many replicas per increment instead of requesting
+	// a step's whole capacity jump at once, smoothing load on schedulers
+	// and dependencies. Absent or unparseable means instantaneous.
+	CapacityRampStepSizeAnnotation = "shipper.booking.com/capacity.rampStepSize"
+	// CapacityRampIntervalAnnotation holds a Go duration to wait between
+	// ramp increments. Without it every sync takes the next increment.
+	CapacityRampIntervalAnnotation = "shipper.booking.com/capacity.rampInterval"
+
+	// RolloutGroupAnnotation, set on an Application, names the rollout
+	// group it belongs to. The contender releases of a group's members
+	// advance in lockstep: no member may target a step until every member
+	// has achieved the step before it.
+	RolloutGroupAnnotation = "shipper.booking.com/rollout.group"
+	// RolloutGroupDesiredStepAnnotation records, on a held member release,
+	// the target step the operator asked for while the rest of the group
+	// catches up; the group controller restores it once the group is
+	// level.
+	RolloutGroupDesiredStepAnnotation = "shipper.booking.com/rollout.groupDesiredStep"
+	// RolloutGroupProgressAnnotation summarizes, on each member release,
+	// the step every member of the rollout group has achieved so far.
+	RolloutGroupProgressAnnotation = "shipper.booking.com/rollout.groupProgress"
+
+	// ClusterDecommissionFinalizer keeps a Cluster marked for decommission
+	// around until every release has vacated it.
+	ClusterDecommissionFinalizer = "shipper.booking.com/cluster.decommission"
+
+	// ClusterTrafficBackendAnnotation names the cloud load balancer backend
+	// the traffic controller should query for achieved traffic on this
+	// cluster, instead of trusting pod label membership alone.
+	ClusterTrafficBackendAnnotation = "shipper.booking.com/cluster.trafficBackend"
 
 	SecretChecksumAnnotation             = "shipper.booking.com/cluster-secret.checksum"
 	SecretClusterNameAnnotation          = "shipper.booking.com/cluster-secret.clusterName"
@@ -43,6 +144,14 @@ const (
 	LBLabel         = "shipper-lb"
 	LBForProduction = "production"
 
+	// LBTierLabel names the service tier of a production Service when an
+	// application exposes several of them (e.g. "internal", "external").
+	// Each tier's traffic is shifted independently through its own
+	// "<PodTrafficStatusLabel>-<tier>" pod label. Services without this
+	// label belong to the default tier, which keeps using
+	// PodTrafficStatusLabel.
+	LBTierLabel = "shipper-lb-tier"
+
 	Enabled  = "enabled"
 	Disabled = "disabled"
 
@@ -84,11 +193,21 @@ type ApplicationList struct {
 type ApplicationSpec struct {
 	RevisionHistoryLimit *int32             `json:"revisionHistoryLimit"`
 	Template             ReleaseEnvironment `json:"template"`
+	// ReleaseNameTemplate optionally replaces the application name portion
+	// of generated release names with a rendered Go template, so that
+	// history entries can embed metadata like the chart version or a git
+	// SHA from the chart values. The template hash and iteration suffix is
+	// always appended to keep names unique.
+	ReleaseNameTemplate string `json:"releaseNameTemplate,omitempty"`
 }
 
 type ApplicationStatus struct {
 	Conditions []ApplicationCondition `json:"conditions,omitempty"`
 	History    []string               `json:"history,omitempty"`
+	// Incumbent names the release selected as the incumbent: the most
+	// recent completed release other than the contender. Empty until a
+	// release other than the contender has completed.
+	Incumbent string `json:"incumbent,omitempty"`
 }
 
 type ApplicationConditionType string
@@ -108,6 +227,60 @@ type ApplicationCondition struct {
 	Message            string                   `json:"message,omitempty"`
 }
 
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// An ApplicationSet generates and owns a group of near-identical
+// Applications: one per entry its generator produces, each stamped out
+// from the set's template.
+type ApplicationSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ApplicationSetSpec `json:"spec"`
+
+	// Most recently observed status of the set
+	Status ApplicationSetStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ApplicationSetList is a list of ApplicationSets.
+type ApplicationSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ApplicationSet `json:"items"`
+}
+
+type ApplicationSetSpec struct {
+	Generator ApplicationSetGenerator `json:"generator"`
+	Template  ApplicationSpec         `json:"template"`
+}
+
+// ApplicationSetGenerator produces the entries the set manages Applications
+// for; each entry becomes one Application named "<set>-<entry>". Exactly one
+// of the fields should be set.
+type ApplicationSetGenerator struct {
+	// List names the entries statically.
+	List []string `json:"list,omitempty"`
+	// Clusters produces one entry per distinct region among the matching
+	// clusters.
+	Clusters *ClusterApplicationSetGenerator `json:"clusters,omitempty"`
+}
+
+// ClusterApplicationSetGenerator selects which clusters' regions the set
+// generates entries for; an empty selector matches every cluster.
+type ClusterApplicationSetGenerator struct {
+	ClusterSelector map[string]string `json:"clusterSelector,omitempty"`
+}
+
+type ApplicationSetStatus struct {
+	// Applications lists the names of the Applications the set currently
+	// owns, in the generator's order.
+	Applications []string `json:"applications,omitempty"`
+}
+
 type Chart struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
@@ -149,11 +322,48 @@ type ClusterList struct {
 	Items []Cluster `json:"items"`
 }
 
+// ClusterTLSVerificationMode controls how the API master's TLS certificate is
+// verified when building clients for a cluster.
+type ClusterTLSVerificationMode string
+
+const (
+	// ClusterTLSVerificationFull verifies the API master's certificate
+	// against the system trust store (or the optional tls.ca in the
+	// cluster secret). This is the default.
+	ClusterTLSVerificationFull ClusterTLSVerificationMode = "Full"
+	// ClusterTLSVerificationCABundle verifies the API master's certificate
+	// against the CA bundle in the cluster spec, for clusters signed by a
+	// private CA.
+	ClusterTLSVerificationCABundle ClusterTLSVerificationMode = "CABundle"
+	// ClusterTLSVerificationSkipVerify disables certificate verification
+	// entirely.
+	ClusterTLSVerificationSkipVerify ClusterTLSVerificationMode = "SkipVerify"
+)
+
 type ClusterSpec struct {
 	Capabilities []string                 `json:"capabilities"`
 	Region       string                   `json:"region"`
 	APIMaster    string                   `json:"apiMaster"`
 	Scheduler    ClusterSchedulerSettings `json:"scheduler"`
+
+	// TLSVerification selects how the API master's certificate is
+	// verified; empty means Full. CABundle is the PEM bundle to verify
+	// against when TLSVerification is CABundle, and must be empty
+	// otherwise.
+	TLSVerification ClusterTLSVerificationMode `json:"tlsVerification,omitempty"`
+	CABundle        []byte                     `json:"caBundle,omitempty"`
+
+	// VerifyOnly marks a cluster that receives every release's objects and
+	// capacity, but never any real traffic: it exists to verify the new
+	// version against production-like data. Its health still gates traffic
+	// progression in the other clusters.
+	VerifyOnly bool `json:"verifyOnly,omitempty"`
+
+	// Decommission marks a cluster that is being taken out of service: no
+	// new releases are scheduled on it, releases still targeting it are
+	// migrated to other clusters, and its decommission finalizer is
+	// released only once the last release has vacated.
+	Decommission bool `json:"decommission,omitempty"`
 }
 
 type ClusterSchedulerSettings struct {
@@ -166,6 +376,22 @@ type ClusterSchedulerSettings struct {
 // be collected by a cluster controller and stored in cluster.status
 type ClusterStatus struct {
 	InService bool `json:"inService"`
+	// Version is the Kubernetes version reported by the cluster the last
+	// time it was successfully reached.
+	Version    string             `json:"version,omitempty"`
+	Conditions []ClusterCondition `json:"conditions,omitempty"`
+	// DecommissionPendingReleases lists the namespace/name keys of releases
+	// still scheduled on this cluster while it is being decommissioned. The
+	// decommission finalizer is released once this list drains.
+	DecommissionPendingReleases []string `json:"decommissionPendingReleases,omitempty"`
+}
+
+type ClusterCondition struct {
+	Type               ClusterConditionType   `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
 }
 
 // +genclient
@@ -194,13 +420,57 @@ type ReleaseList struct {
 type ReleaseSpec struct {
 	TargetStep  int32              `json:"targetStep"`
 	Environment ReleaseEnvironment `json:"environment"`
+
+	// Flags are release-scoped feature flags injected into chart
+	// rendering under the top-level "flags" value, merged over whatever
+	// "flags" the environment's values provide. Unlike the environment,
+	// which is immutable for a release and changes only by rolling out a
+	// new one (as a chart version change does), Flags can be edited on
+	// the live release to re-render its objects in place.
+	Flags ChartValues `json:"flags,omitempty"`
+
+	// ValuesPatches are surgical, JSON-patch-style edits to the release's
+	// effective chart values, applied after platform defaults, the
+	// environment's values, and Flags are merged. Like Flags, they can be
+	// edited on the live release to re-render its objects in place
+	// without resubmitting the whole values map.
+	ValuesPatches []ValuesPatch `json:"valuesPatches,omitempty"`
+}
+
+// ValuesPatch is one JSON-patch-style operation on the release's merged
+// chart values: "add" or "replace" the value at a /-separated path, or
+// "remove" whatever is there. Value is parsed as a JSON scalar when it looks
+// like one ("5", "true"), and used as a plain string otherwise ("512Mi").
+type ValuesPatch struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value,omitempty"`
 }
 
 // this will likely grow into a struct with interesting fields
 type ReleaseStatus struct {
-	AchievedStep *AchievedStep          `json:"achievedStep,omitempty"`
+	AchievedStep *AchievedStep `json:"achievedStep,omitempty"`
+	// AchievingStep is set while the controller is mid-transition: the
+	// step it is working toward, before AchievedStep flips. It is cleared
+	// once the step is fully achieved.
+	AchievingStep *AchievingStep  `json:"achievingStep,omitempty"`
+	Clusters      []ChosenCluster `json:"clusters,omitempty"`
 	Strategy     *ReleaseStrategyStatus `json:"strategy,omitempty"`
 	Conditions   []ReleaseCondition     `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the release's metadata.generation the last
+	// time the controller fully achieved the target step; completed
+	// releases whose generation still matches are skipped on re-sync.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// A ChosenCluster records one cluster the scheduler picked for a release and
+// why, so that `kubectl get release -o yaml` is self-explanatory without
+// chasing down the target objects.
+type ChosenCluster struct {
+	Name   string `json:"name"`
+	Region string `json:"region"`
+	Reason string `json:"reason,omitempty"`
 }
 
 type AchievedStep struct {
@@ -208,12 +478,27 @@ type AchievedStep struct {
 	Name string `json:"name"`
 }
 
+// AchievingStep describes a step transition in flight: the step the
+// controller is moving the release toward and how far along the move is.
+type AchievingStep struct {
+	Step int32  `json:"step"`
+	Name string `json:"name"`
+	// Progress counts the strategy conditions already met for the step
+	// out of the total that must hold, e.g. "2/5".
+	Progress string `json:"progress,omitempty"`
+}
+
 type ReleaseConditionType string
 
 const (
 	ReleaseConditionTypeScheduled ReleaseConditionType = "Scheduled"
 	ReleaseConditionTypeInstalled ReleaseConditionType = "Installed"
 	ReleaseConditionTypeComplete  ReleaseConditionType = "Complete"
+
+	// ReleaseConditionTypeChangeApprovalDenied is set when an external
+	// change-management webhook denies the release's pending target step
+	// advance; the strategy holds the step until the webhook approves.
+	ReleaseConditionTypeChangeApprovalDenied ReleaseConditionType = "ChangeApprovalDenied"
 )
 
 type ReleaseCondition struct {
@@ -231,16 +516,106 @@ type ReleaseEnvironment struct {
 	// XXX pointer here means it's null-able, do we want that?
 	Values *ChartValues `json:"values"`
 
+	// ValuesFile optionally names a values file shipped inside the chart
+	// (like "values-prod.yaml") whose contents are merged beneath Values
+	// when rendering, so environment defaults can live in the chart while
+	// per-release overrides stay in Values. Rendering fails if the chart
+	// does not contain the named file.
+	ValuesFile string `json:"valuesFile,omitempty"`
+
 	// requirements for target clusters for the deployment
 	ClusterRequirements ClusterRequirements `json:"clusterRequirements"`
 
 	Strategy *RolloutStrategy `json:"strategy,omitempty"`
+
+	// StrategyName references a shared Strategy object in the same
+	// namespace instead of inlining one in Strategy. The application
+	// controller resolves the reference when it creates a release and
+	// snapshots the resolved strategy into the release's Strategy, so
+	// in-flight releases are immune to later edits of the shared object.
+	StrategyName string `json:"strategyName,omitempty"`
+
+	// StrategyTiers maps regions to alternative rollout strategies, so one
+	// application can roll out aggressively in staging clusters while
+	// staying cautious in production. Clusters in regions not covered by
+	// any tier use Strategy.
+	StrategyTiers []StrategyTier `json:"strategyTiers,omitempty"`
+
+	// dependencies that must be satisfied before the installation
+	// controller proceeds with this release; empty means no gating
+	DependsOn []DependencyReference `json:"dependsOn,omitempty"`
+
+	// PostRenderPatches are applied to named rendered chart objects after
+	// rendering and before applying them to clusters, for tweaks the chart
+	// doesn't parameterize.
+	PostRenderPatches []PostRenderPatch `json:"postRenderPatches,omitempty"`
+
+	// ImageOverrides maps container image repositories to replacement tags,
+	// applied to the rendered pod specs after rendering. This covers the
+	// tag-only hotfix without a chart version bump; an override whose
+	// repository matches no rendered container image is an error.
+	ImageOverrides map[string]string `json:"imageOverrides,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// A Strategy is a shared, named rollout strategy that release environments
+// can reference through StrategyName instead of inlining their own. Releases
+// snapshot the referenced strategy when they are created.
+type Strategy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RolloutStrategy `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type StrategyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Strategy `json:"items"`
+}
+
+// StrategyTier names a set of regions and the rollout strategy releases
+// should use on clusters in those regions.
+type StrategyTier struct {
+	Name     string           `json:"name"`
+	Regions  []string         `json:"regions"`
+	Strategy *RolloutStrategy `json:"strategy"`
+}
+
+// PostRenderPatch is a strategic merge patch, in YAML or JSON form, applied
+// to one rendered chart object identified by kind and name.
+type PostRenderPatch struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Patch string `json:"patch"`
+}
+
+// A DependencyReference names something that must be ready before a release
+// is installed: either another shipper Application in the same namespace
+// (ready once its contender release is complete) or an arbitrary readiness
+// probe URL that must answer with a 2xx status code.
+type DependencyReference struct {
+	Application string `json:"application,omitempty"`
+	URL         string `json:"url,omitempty"`
 }
 
 type ClusterRequirements struct {
 	// it is an error to not specify any regions
 	Regions      []RegionRequirement `json:"regions"`
 	Capabilities []string            `json:"capabilities,omitempty"`
+
+	// ClusterSelector narrows the candidate clusters further by their
+	// Cluster object labels, using standard Kubernetes selector syntax
+	// (e.g. tier=gold, or a matchExpressions clause). Only clusters
+	// matching the selector count towards each region's replicas. Nil
+	// means no label filtering.
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
 }
 
 type RegionRequirement struct {
@@ -250,12 +625,191 @@ type RegionRequirement struct {
 
 type RolloutStrategy struct {
 	Steps []RolloutStrategyStep `json:"steps"`
+
+	// Waves partitions the release's clusters into ordered groups that
+	// move to each new step one group at a time, with a bake period
+	// between groups during which health is observed before the next
+	// group starts. Clusters not named by any wave move with the last
+	// wave. An empty list means all clusters move together.
+	Waves []RolloutWave `json:"waves,omitempty"`
+	// Ordering controls whether capacity changes are driven before traffic
+	// changes (the default) or the other way around, for workloads that
+	// need to observe traffic before scaling.
+	Ordering RolloutStrategyOrdering `json:"ordering,omitempty"`
+
+	// FinalStepPolicy automates the last step's cutover: once the step's
+	// capacity and traffic are achieved, the release soaks under full
+	// traffic for SoakTime and is marked complete only if it stays
+	// healthy; an unhealthy soak rolls the target step back instead. Nil
+	// keeps the usual behavior of completing immediately.
+	FinalStepPolicy *FinalStepPolicy `json:"finalStepPolicy,omitempty"`
+
+	// StabilityPolicy pauses advancement when the contender's pods emit a
+	// burst of crash/restart/OOM events during a step, until the burst
+	// subsides. Nil disables the guard.
+	StabilityPolicy *StabilityPolicy `json:"stabilityPolicy,omitempty"`
+
+	// IncumbentRetention keeps the incumbent's installed objects in
+	// place, scaled to zero, for this long after the contender completes,
+	// so a rollback only has to scale the incumbent back up instead of
+	// reinstalling it from scratch. Once the retention elapses the usual
+	// garbage collection applies. Nil disables the retention.
+	IncumbentRetention *metav1.Duration `json:"incumbentRetention,omitempty"`
+
+	// CompletionGates lists additional requirements, beyond achieving the
+	// final step, that must hold before the release is declared complete.
+	// Each gate names a condition that external tooling reports on the
+	// Release; an empty list keeps the usual behavior of completing as
+	// soon as the final step is achieved.
+	CompletionGates []ReleaseCompletionGate `json:"completionGates,omitempty"`
+
+	// CanaryNamespace installs the contender's objects into an isolated
+	// namespace derived from the release name for every step but the
+	// last, so a broken chart can't disrupt the incumbent's objects in
+	// the application namespace. Reaching the final step moves the
+	// objects into the application namespace and removes the canary
+	// namespace. Ignored for a release with no incumbent.
+	CanaryNamespace bool `json:"canaryNamespace,omitempty"`
+
+	// Adaptive replaces each step's fixed percentages with a control
+	// loop: every Interval the contender's share grows by an increment
+	// sized from an SLI condition external monitoring posts on the
+	// Release, so a healthy release ramps fast and a marginal one crawls.
+	// The steps still bound how far the share may grow before the next
+	// command. Nil keeps plain fixed steps.
+	Adaptive *AdaptiveStrategy `json:"adaptive,omitempty"`
+
+	// InstallOnly skips capacity and traffic orchestration entirely: the
+	// release is complete as soon as its chart is installed on every
+	// cluster. Meant for workloads that don't serve rolling traffic, like
+	// batch jobs and one-shot installs; Steps are ignored and may be
+	// empty.
+	InstallOnly bool `json:"installOnly,omitempty"`
+}
+
+// A ReleaseCompletionGate names a release condition that must be True, in
+// addition to the final step being achieved, before the release is declared
+// complete. The condition is expected to be posted on the Release by
+// external tooling, e.g. a verification pipeline.
+type ReleaseCompletionGate struct {
+	ConditionType string `json:"conditionType"`
+}
+
+// An AdaptiveStrategy sizes each traffic and capacity increment from
+// observed service health instead of moving to a step's percentages in one
+// go. External monitoring posts a condition of type SLIConditionType on the
+// Release, in the same fashion as completion gates: while it reads True the
+// rollout adds MaxIncrement percent per Interval, and MinIncrement percent
+// otherwise -- False, Unknown or missing all count as degraded.
+type AdaptiveStrategy struct {
+	// MinIncrement is the percentage added per interval while the SLI
+	// condition reads anything but True.
+	MinIncrement int32 `json:"minIncrement"`
+	// MaxIncrement is the percentage added per interval while the SLI
+	// condition reads True.
+	MaxIncrement int32 `json:"maxIncrement"`
+	// Interval is how long each chosen increment is observed before the
+	// next one is computed.
+	Interval metav1.Duration `json:"interval"`
+	// SLIConditionType names the Release condition that carries the SLI
+	// verdict.
+	SLIConditionType string `json:"sliConditionType"`
 }
 
+type RolloutStrategyOrdering string
+
+const (
+	// StrategyOrderingCapacityFirst scales a release up before shifting
+	// traffic onto it, and drains traffic before scaling down. This is
+	// the default when no ordering is specified.
+	StrategyOrderingCapacityFirst RolloutStrategyOrdering = "CapacityFirst"
+	// StrategyOrderingTrafficFirst shifts traffic onto a release before
+	// scaling it up, and scales down before draining traffic.
+	StrategyOrderingTrafficFirst RolloutStrategyOrdering = "TrafficFirst"
+)
+
 type RolloutStrategyStep struct {
 	Name     string                   `json:"name"`
 	Capacity RolloutStrategyStepValue `json:"capacity"`
 	Traffic  RolloutStrategyStepValue `json:"traffic"`
+
+	// optional per-region overrides for capacity and/or traffic; regions
+	// not listed here get the step-wide values above
+	Overrides []RolloutStrategyStepOverride `json:"overrides,omitempty"`
+
+	// TrafficTiers gives named service tiers their own traffic values for
+	// this step, so e.g. an external Service can lag behind an internal
+	// one. Tiers not listed here follow Traffic.
+	TrafficTiers []RolloutStrategyServiceTier `json:"trafficTiers,omitempty"`
+
+	// FeatureFlags lists feature flags an external flag provider drives
+	// to this step's contender traffic percentage, so a flag rollout
+	// tracks the traffic rollout. The step isn't reported achieved until
+	// the provider accepts every change.
+	FeatureFlags []RolloutStrategyFeatureFlag `json:"featureFlags,omitempty"`
+
+	// ConfirmTraffic splits this step's command gate in two: capacity is
+	// adjusted as soon as the step is targeted, but traffic only moves
+	// once an operator approves it by annotating the release with this
+	// step's number. False keeps the usual single-command behavior.
+	ConfirmTraffic bool `json:"confirmTraffic,omitempty"`
+}
+
+// A RolloutStrategyServiceTier carries one service tier's traffic values for
+// a step. The name must match the LBTierLabel on the tier's production
+// Service.
+type RolloutStrategyServiceTier struct {
+	Name      string `json:"name"`
+	Incumbent int32  `json:"incumbent"`
+	Contender int32  `json:"contender"`
+}
+
+// A RolloutStrategyFeatureFlag names a feature flag in an external
+// feature-flag system that must follow the rollout's traffic percentage.
+type RolloutStrategyFeatureFlag struct {
+	// Name identifies the flag with the provider.
+	Name string `json:"name"`
+	// Segment optionally scopes the flag change to a provider-side user
+	// segment; empty means the provider's default segment.
+	Segment string `json:"segment,omitempty"`
+}
+
+// A RolloutStrategyStepOverride replaces the step-wide capacity and/or
+// traffic values for the clusters of a single region, so one step can for
+// example shift capacity everywhere but traffic only in a canary region. The
+// region name must match one of the regions in ClusterRequirements.
+type RolloutStrategyStepOverride struct {
+	Region   string                    `json:"region"`
+	Capacity *RolloutStrategyStepValue `json:"capacity,omitempty"`
+	Traffic  *RolloutStrategyStepValue `json:"traffic,omitempty"`
+}
+
+// A RolloutWave names a group of clusters that rolls out together. Once the
+// wave's clusters achieve the target step the rollout bakes for BakeTime
+// before the next wave starts moving.
+type RolloutWave struct {
+	Name     string          `json:"name"`
+	Clusters []string        `json:"clusters"`
+	BakeTime metav1.Duration `json:"bakeTime,omitempty"`
+}
+
+// A FinalStepPolicy describes how the last strategy step completes without
+// an operator: soak for SoakTime under full traffic, auto-complete if the
+// release stays healthy, auto-rollback to RollbackToStep otherwise.
+type FinalStepPolicy struct {
+	SoakTime metav1.Duration `json:"soakTime"`
+	// RollbackToStep is the target step the release is sent back to when
+	// the soak turns up an unhealthy release. Defaults to step 0.
+	RollbackToStep int32 `json:"rollbackToStep,omitempty"`
+}
+
+type StabilityPolicy struct {
+	// MaxEvents is how many recent instability events -- crash loops,
+	// restarts, OOM kills -- the contender's clusters may report in total
+	// before the rollout pauses with WaitingForStability. The counts come
+	// from the capacity controller, which watches events already present
+	// in the application clusters.
+	MaxEvents int32 `json:"maxEvents"`
 }
 
 type RolloutStrategyStepValue struct {
@@ -291,10 +845,23 @@ type InstallationTargetStatus struct {
 }
 
 type ClusterInstallationStatus struct {
-	Name       string                         `json:"name"`
-	Status     string                         `json:"status"`
-	Message    string                         `json:"message,omitempty"`
-	Conditions []ClusterInstallationCondition `json:"conditions,omitempty"`
+	Name         string                         `json:"name"`
+	Status       string                         `json:"status"`
+	Message      string                         `json:"message,omitempty"`
+	AppliedOrder []string                       `json:"appliedOrder,omitempty"`
+	AppliedQuota string                         `json:"appliedQuota,omitempty"`
+	// AppliedDefaults lists the top-level platform default value keys
+	// that were merged beneath the release's values during rendering.
+	AppliedDefaults string                         `json:"appliedDefaults,omitempty"`
+	// ReplacedObjects lists the objects that were deleted and recreated
+	// during the last installation because they can't be updated in
+	// place, as Kind/name entries.
+	ReplacedObjects []string                       `json:"replacedObjects,omitempty"`
+	// TargetNamespace is the namespace override the objects were
+	// installed into, e.g. a strategy's canary namespace. Empty means
+	// the release's own namespace.
+	TargetNamespace string                         `json:"targetNamespace,omitempty"`
+	Conditions      []ClusterInstallationCondition `json:"conditions,omitempty"`
 }
 
 type ClusterInstallationCondition struct {
@@ -307,6 +874,11 @@ type ClusterInstallationCondition struct {
 
 type InstallationTargetSpec struct {
 	Clusters []string `json:"clusters"`
+
+	// TargetNamespace, when set, overrides the namespace the release's
+	// objects install into on the target clusters, e.g. a strategy's
+	// canary namespace. Empty means the release's own namespace.
+	TargetNamespace string `json:"targetNamespace,omitempty"`
 }
 
 // +genclient
@@ -371,12 +943,39 @@ type ClusterCapacityReport struct {
 }
 
 type ClusterCapacityStatus struct {
-	Name              string                     `json:"name"`
-	AvailableReplicas int32                      `json:"availableReplicas"`
-	AchievedPercent   int32                      `json:"achievedPercent"`
-	SadPods           []PodStatus                `json:"sadPods,omitempty"`
-	Conditions        []ClusterCapacityCondition `json:"conditions,omitempty"`
-	Reports           []ClusterCapacityReport    `json:"reports,omitempty"`
+	Name              string `json:"name"`
+	AvailableReplicas int32  `json:"availableReplicas"`
+	AchievedPercent   int32  `json:"achievedPercent"`
+
+	// DesiredReplicas is the replica count the capacity controller asked
+	// the workload for; Current and Ready mirror the workload's own status
+	// so rollout progress is visible without access to the target cluster.
+	DesiredReplicas int32 `json:"desiredReplicas"`
+	CurrentReplicas int32 `json:"currentReplicas"`
+	ReadyReplicas   int32 `json:"readyReplicas"`
+
+	// UnavailableReasons summarizes why pods aren't ready, one
+	// "<Reason>: <count>" entry per distinct container waiting reason,
+	// e.g. "ImagePullBackOff: 3".
+	UnavailableReasons []string `json:"unavailableReasons,omitempty"`
+
+	// TerminatingReplicas counts the workload's pods that are shutting
+	// down on this cluster, typically left over from a scale-down. They
+	// don't count towards readiness or the fleet report.
+	TerminatingReplicas int32 `json:"terminatingReplicas,omitempty"`
+
+	SadPods    []PodStatus                `json:"sadPods,omitempty"`
+	Conditions []ClusterCapacityCondition `json:"conditions,omitempty"`
+	Reports    []ClusterCapacityReport    `json:"reports,omitempty"`
+
+	// InstabilityEvents counts the recent crash/restart/OOM events the
+	// workload's pods emitted on this cluster; strategies with a stability
+	// policy pause on a burst of these.
+	InstabilityEvents int32 `json:"instabilityEvents,omitempty"`
+
+	// LastRampTime records when a ramped scale-up took its last increment,
+	// so the configured interval between increments holds across syncs.
+	LastRampTime *metav1.Time `json:"lastRampTime,omitempty"`
 }
 
 type ClusterConditionType string
@@ -384,6 +983,14 @@ type ClusterConditionType string
 const (
 	ClusterConditionTypeOperational ClusterConditionType = "Operational"
 	ClusterConditionTypeReady       ClusterConditionType = "Ready"
+	// ClusterConditionTypeSingletonConflict reports that the chart
+	// renders a cluster-scoped object still owned by another running
+	// release, which installation refuses to clobber.
+	ClusterConditionTypeSingletonConflict ClusterConditionType = "SingletonConflict"
+	// ClusterConditionTypeConfigurationDrift reports that live objects on
+	// the cluster no longer match the rendered desired state, typically
+	// after an out-of-band edit. The message lists the drifted fields.
+	ClusterConditionTypeConfigurationDrift ClusterConditionType = "ConfigurationDrift"
 )
 
 type ClusterCapacityCondition struct {
@@ -408,12 +1015,24 @@ type PodStatus struct {
 
 type CapacityTargetSpec struct {
 	Clusters []ClusterCapacityTarget `json:"clusters"`
+
+	// MinReadySeconds is how long a pod must have been ready before it
+	// counts toward achieved capacity, with the same semantics as a
+	// Deployment's minReadySeconds, so flapping pods don't prematurely
+	// satisfy WaitingForCapacity. Zero counts pods as soon as they are
+	// ready.
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
 }
 
 type ClusterCapacityTarget struct {
 	Name              string `json:"name"`
 	Percent           int32  `json:"percent"`
 	TotalReplicaCount int32  `json:"totalReplicaCount"`
+	// Suspended temporarily scales the release to zero on this cluster
+	// while keeping it installed, so it can be restored by clearing the
+	// flag. The capacity controller pins suspended clusters at zero
+	// replicas regardless of Percent.
+	Suspended bool `json:"suspended,omitempty"`
 }
 
 // +genclient
@@ -445,10 +1064,13 @@ type TrafficTargetStatus struct {
 }
 
 type ClusterTrafficStatus struct {
-	Name            string                    `json:"name"`
-	AchievedTraffic uint32                    `json:"achievedTraffic"`
-	Status          string                    `json:"status"`
-	Conditions      []ClusterTrafficCondition `json:"conditions"`
+	Name            string `json:"name"`
+	AchievedTraffic uint32 `json:"achievedTraffic"`
+	// AchievedServiceTraffic breaks the achieved weight down per service
+	// tier when the spec carries ServiceWeights; empty otherwise.
+	AchievedServiceTraffic map[string]uint32         `json:"achievedServiceTraffic,omitempty"`
+	Status                 string                    `json:"status"`
+	Conditions             []ClusterTrafficCondition `json:"conditions"`
 }
 
 type ClusterTrafficCondition struct {
@@ -467,11 +1089,80 @@ type ClusterTrafficTarget struct {
 	Name string `json:"name"`
 	// apimachinery intstr for percentages?
 	Weight uint32 `json:"weight"`
+	// ServiceWeights overrides Weight per named service tier: keys match
+	// the LBTierLabel on the tier's production Service. Tiers not listed
+	// here fall back to Weight. Empty means the application has a single
+	// production Service and Weight applies to it.
+	ServiceWeights map[string]uint32 `json:"serviceWeights,omitempty"`
+	// VerifyOnly mirrors the cluster's spec: the strategy executor keeps
+	// the weight for such clusters pinned at zero.
+	VerifyOnly bool `json:"verifyOnly,omitempty"`
+	// Suspended temporarily stops serving this release from this cluster
+	// while keeping it installed: the traffic controller treats the
+	// cluster's weight as zero until the flag is cleared.
+	Suspended bool `json:"suspended,omitempty"`
 }
 
 type ReleaseStrategyStatus struct {
 	State      ReleaseStrategyState       `json:"state,omitempty"`
 	Conditions []ReleaseStrategyCondition `json:"conditions,omitempty"`
+
+	// Steps is a per-step breakdown of the rollout's progress: the
+	// installation, capacity and traffic sub-states each step has reached,
+	// plus the latest check result for whichever sub-state is pending.
+	// It is additive to State and Conditions, which stay as they are for
+	// compatibility.
+	Steps []ReleaseStrategyStepStatus `json:"steps,omitempty"`
+
+	// CurrentWave names the strategy wave currently moving toward the
+	// target step, when the strategy defines waves.
+	CurrentWave string `json:"currentWave,omitempty"`
+	// WaveBakeUntil is when the bake period holding back CurrentWave
+	// ends; the wave starts moving only after this time.
+	WaveBakeUntil *metav1.Time `json:"waveBakeUntil,omitempty"`
+
+	// SoakingUntil is when the final step's soak period ends; the release
+	// is marked complete (or rolled back) only after this time.
+	SoakingUntil *metav1.Time `json:"soakingUntil,omitempty"`
+
+	// Adaptive records where an adaptive strategy's ramp stands, when the
+	// strategy defines one.
+	Adaptive *AdaptiveStatus `json:"adaptive,omitempty"`
+
+	// CommandStatus is a one-line summary of where the operator's step
+	// command stands: "awaiting command" once a step is achieved, or
+	// "command accepted but held because: <reason>" while an issued
+	// targetStep change waits on an unmet precondition such as a baking
+	// wave, an instability pause, or superseded releases still draining.
+	// Empty while the step is actively transitioning.
+	CommandStatus string `json:"commandStatus,omitempty"`
+
+	// StateDurations accumulates how long the release has spent in each
+	// waiting state at each step, summed across syncs. It shows whether
+	// installs, capacity, traffic, or human approvals dominate rollout
+	// time.
+	StateDurations []StrategyStateDuration `json:"stateDurations,omitempty"`
+	// LastStateObservedAt is when the accounting in StateDurations last
+	// ticked.
+	LastStateObservedAt *metav1.Time `json:"lastStateObservedAt,omitempty"`
+}
+
+// StrategyStateDuration is one bucket of the per-state time accounting: the
+// total time the release has spent with the named state True while working
+// toward the given step.
+type StrategyStateDuration struct {
+	Step     int32           `json:"step"`
+	State    string          `json:"state"`
+	Duration metav1.Duration `json:"duration"`
+}
+
+// AdaptiveStatus records the state of an adaptive strategy's control loop:
+// the contender share the rollout is currently driving, the increment the
+// last interval chose for it, and when that choice was made.
+type AdaptiveStatus struct {
+	Percent           int32        `json:"percent"`
+	LastIncrement     int32        `json:"lastIncrement,omitempty"`
+	LastIncrementTime *metav1.Time `json:"lastIncrementTime,omitempty"`
 }
 
 type ReleaseStrategyState struct {
@@ -479,6 +1170,31 @@ type ReleaseStrategyState struct {
 	WaitingForCapacity     StrategyState `json:"waitingForCapacity"`
 	WaitingForTraffic      StrategyState `json:"waitingForTraffic"`
 	WaitingForCommand      StrategyState `json:"waitingForCommand"`
+
+	// WaitingForTrafficCommand is only set on steps with ConfirmTraffic:
+	// True while the step's traffic half awaits operator approval.
+	WaitingForTrafficCommand StrategyState `json:"waitingForTrafficCommand,omitempty"`
+
+	// WaitingForStability is only set on strategies with a stability
+	// policy: True while a burst of instability events pauses advancement.
+	WaitingForStability StrategyState `json:"waitingForStability,omitempty"`
+}
+
+// ReleaseStrategyStepStatus describes how far one strategy step has moved
+// through its installation, capacity and traffic sub-states. True means the
+// sub-state has been achieved for the step, False means it is actively being
+// worked on, and Unknown means the step hasn't been reached yet.
+type ReleaseStrategyStepStatus struct {
+	Step int32  `json:"step"`
+	Name string `json:"name,omitempty"`
+
+	Installation StrategyState `json:"installation"`
+	Capacity     StrategyState `json:"capacity"`
+	Traffic      StrategyState `json:"traffic"`
+
+	// Message carries the latest check result for a pending sub-state,
+	// e.g. which clusters are still adjusting capacity.
+	Message string `json:"message,omitempty"`
 }
 
 type ReleaseStrategyCondition struct {
@@ -22,6 +22,7 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -41,6 +42,63 @@ func (in *AchievedStep) DeepCopy() *AchievedStep {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AchievingStep) DeepCopyInto(out *AchievingStep) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AchievingStep.
+func (in *AchievingStep) DeepCopy() *AchievingStep {
+	if in == nil {
+		return nil
+	}
+	out := new(AchievingStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdaptiveStatus) DeepCopyInto(out *AdaptiveStatus) {
+	*out = *in
+	if in.LastIncrementTime != nil {
+		in, out := &in.LastIncrementTime, &out.LastIncrementTime
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdaptiveStatus.
+func (in *AdaptiveStatus) DeepCopy() *AdaptiveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AdaptiveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdaptiveStrategy) DeepCopyInto(out *AdaptiveStrategy) {
+	*out = *in
+	out.Interval = in.Interval
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdaptiveStrategy.
+func (in *AdaptiveStrategy) DeepCopy() *AdaptiveStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(AdaptiveStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Application) DeepCopyInto(out *Application) {
 	*out = *in
@@ -121,6 +179,138 @@ func (in *ApplicationList) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSet) DeepCopyInto(out *ApplicationSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSet.
+func (in *ApplicationSet) DeepCopy() *ApplicationSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ApplicationSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSetGenerator) DeepCopyInto(out *ApplicationSetGenerator) {
+	*out = *in
+	if in.List != nil {
+		in, out := &in.List, &out.List
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ClusterApplicationSetGenerator)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSetGenerator.
+func (in *ApplicationSetGenerator) DeepCopy() *ApplicationSetGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSetGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSetList) DeepCopyInto(out *ApplicationSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ApplicationSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSetList.
+func (in *ApplicationSetList) DeepCopy() *ApplicationSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ApplicationSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSetSpec) DeepCopyInto(out *ApplicationSetSpec) {
+	*out = *in
+	in.Generator.DeepCopyInto(&out.Generator)
+	in.Template.DeepCopyInto(&out.Template)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSetSpec.
+func (in *ApplicationSetSpec) DeepCopy() *ApplicationSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSetStatus) DeepCopyInto(out *ApplicationSetStatus) {
+	*out = *in
+	if in.Applications != nil {
+		in, out := &in.Applications, &out.Applications
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSetStatus.
+func (in *ApplicationSetStatus) DeepCopy() *ApplicationSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 	*out = *in
@@ -298,13 +488,29 @@ func (in *Chart) DeepCopy() *Chart {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChosenCluster) DeepCopyInto(out *ChosenCluster) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChosenCluster.
+func (in *ChosenCluster) DeepCopy() *ChosenCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(ChosenCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Cluster) DeepCopyInto(out *Cluster) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -327,6 +533,29 @@ func (in *Cluster) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterApplicationSetGenerator) DeepCopyInto(out *ClusterApplicationSetGenerator) {
+	*out = *in
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterApplicationSetGenerator.
+func (in *ClusterApplicationSetGenerator) DeepCopy() *ClusterApplicationSetGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterApplicationSetGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterCapacityCondition) DeepCopyInto(out *ClusterCapacityCondition) {
 	*out = *in
@@ -475,6 +704,11 @@ func (in *ClusterCapacityReportOwner) DeepCopy() *ClusterCapacityReportOwner {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterCapacityStatus) DeepCopyInto(out *ClusterCapacityStatus) {
 	*out = *in
+	if in.UnavailableReasons != nil {
+		in, out := &in.UnavailableReasons, &out.UnavailableReasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.SadPods != nil {
 		in, out := &in.SadPods, &out.SadPods
 		*out = make([]PodStatus, len(*in))
@@ -496,6 +730,10 @@ func (in *ClusterCapacityStatus) DeepCopyInto(out *ClusterCapacityStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastRampTime != nil {
+		in, out := &in.LastRampTime, &out.LastRampTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -525,6 +763,23 @@ func (in *ClusterCapacityTarget) DeepCopy() *ClusterCapacityTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCondition) DeepCopyInto(out *ClusterCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCondition.
+func (in *ClusterCondition) DeepCopy() *ClusterCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterInstallationCondition) DeepCopyInto(out *ClusterInstallationCondition) {
 	*out = *in
@@ -545,6 +800,16 @@ func (in *ClusterInstallationCondition) DeepCopy() *ClusterInstallationCondition
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterInstallationStatus) DeepCopyInto(out *ClusterInstallationStatus) {
 	*out = *in
+	if in.AppliedOrder != nil {
+		in, out := &in.AppliedOrder, &out.AppliedOrder
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReplacedObjects != nil {
+		in, out := &in.ReplacedObjects, &out.ReplacedObjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]ClusterInstallationCondition, len(*in))
@@ -614,6 +879,15 @@ func (in *ClusterRequirements) DeepCopyInto(out *ClusterRequirements) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(metav1.LabelSelector)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -670,6 +944,11 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		copy(*out, *in)
 	}
 	in.Scheduler.DeepCopyInto(&out.Scheduler)
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -686,6 +965,18 @@ func (in *ClusterSpec) DeepCopy() *ClusterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ClusterCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DecommissionPendingReleases != nil {
+		in, out := &in.DecommissionPendingReleases, &out.DecommissionPendingReleases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -719,6 +1010,13 @@ func (in *ClusterTrafficCondition) DeepCopy() *ClusterTrafficCondition {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterTrafficStatus) DeepCopyInto(out *ClusterTrafficStatus) {
 	*out = *in
+	if in.AchievedServiceTraffic != nil {
+		in, out := &in.AchievedServiceTraffic, &out.AchievedServiceTraffic
+		*out = make(map[string]uint32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]ClusterTrafficCondition, len(*in))
@@ -742,6 +1040,13 @@ func (in *ClusterTrafficStatus) DeepCopy() *ClusterTrafficStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterTrafficTarget) DeepCopyInto(out *ClusterTrafficTarget) {
 	*out = *in
+	if in.ServiceWeights != nil {
+		in, out := &in.ServiceWeights, &out.ServiceWeights
+		*out = make(map[string]uint32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -755,6 +1060,22 @@ func (in *ClusterTrafficTarget) DeepCopy() *ClusterTrafficTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DependencyReference) DeepCopyInto(out *DependencyReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DependencyReference.
+func (in *DependencyReference) DeepCopy() *DependencyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(DependencyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InstallationTarget) DeepCopyInto(out *InstallationTarget) {
 	*out = *in
@@ -898,6 +1219,22 @@ func (in *PodStatus) DeepCopy() *PodStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostRenderPatch) DeepCopyInto(out *PostRenderPatch) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostRenderPatch.
+func (in *PostRenderPatch) DeepCopy() *PostRenderPatch {
+	if in == nil {
+		return nil
+	}
+	out := new(PostRenderPatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegionRequirement) DeepCopyInto(out *RegionRequirement) {
 	*out = *in
@@ -953,6 +1290,22 @@ func (in *Release) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseCompletionGate) DeepCopyInto(out *ReleaseCompletionGate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is a deepcopy function, copying the receiver, creating a new ReleaseCompletionGate.
+func (in *ReleaseCompletionGate) DeepCopy() *ReleaseCompletionGate {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseCompletionGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is a deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReleaseCondition) DeepCopyInto(out *ReleaseCondition) {
 	*out = *in
 	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
@@ -992,12 +1345,36 @@ func (in *ReleaseEnvironment) DeepCopyInto(out *ReleaseEnvironment) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseEnvironment.
-func (in *ReleaseEnvironment) DeepCopy() *ReleaseEnvironment {
-	if in == nil {
+	if in.StrategyTiers != nil {
+		in, out := &in.StrategyTiers, &out.StrategyTiers
+		*out = make([]StrategyTier, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]DependencyReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.PostRenderPatches != nil {
+		in, out := &in.PostRenderPatches, &out.PostRenderPatches
+		*out = make([]PostRenderPatch, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImageOverrides != nil {
+		in, out := &in.ImageOverrides, &out.ImageOverrides
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseEnvironment.
+func (in *ReleaseEnvironment) DeepCopy() *ReleaseEnvironment {
+	if in == nil {
 		return nil
 	}
 	out := new(ReleaseEnvironment)
@@ -1043,6 +1420,14 @@ func (in *ReleaseList) DeepCopyObject() runtime.Object {
 func (in *ReleaseSpec) DeepCopyInto(out *ReleaseSpec) {
 	*out = *in
 	in.Environment.DeepCopyInto(&out.Environment)
+	if in.Flags != nil {
+		in.Flags.DeepCopyInto(&out.Flags)
+	}
+	if in.ValuesPatches != nil {
+		in, out := &in.ValuesPatches, &out.ValuesPatches
+		*out = make([]ValuesPatch, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1068,6 +1453,20 @@ func (in *ReleaseStatus) DeepCopyInto(out *ReleaseStatus) {
 			**out = **in
 		}
 	}
+	if in.AchievingStep != nil {
+		in, out := &in.AchievingStep, &out.AchievingStep
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(AchievingStep)
+			**out = **in
+		}
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ChosenCluster, len(*in))
+		copy(*out, *in)
+	}
 	if in.Strategy != nil {
 		in, out := &in.Strategy, &out.Strategy
 		if *in == nil {
@@ -1141,6 +1540,49 @@ func (in *ReleaseStrategyStatus) DeepCopyInto(out *ReleaseStrategyStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]ReleaseStrategyStepStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.WaveBakeUntil != nil {
+		in, out := &in.WaveBakeUntil, &out.WaveBakeUntil
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
+	if in.SoakingUntil != nil {
+		in, out := &in.SoakingUntil, &out.SoakingUntil
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
+	if in.Adaptive != nil {
+		in, out := &in.Adaptive, &out.Adaptive
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(AdaptiveStatus)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.StateDurations != nil {
+		in, out := &in.StateDurations, &out.StateDurations
+		*out = make([]StrategyStateDuration, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastStateObservedAt != nil {
+		in, out := &in.LastStateObservedAt, &out.LastStateObservedAt
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
 	return
 }
 
@@ -1154,14 +1596,96 @@ func (in *ReleaseStrategyStatus) DeepCopy() *ReleaseStrategyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseStrategyStepStatus) DeepCopyInto(out *ReleaseStrategyStepStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseStrategyStepStatus.
+func (in *ReleaseStrategyStepStatus) DeepCopy() *ReleaseStrategyStepStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseStrategyStepStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FinalStepPolicy) DeepCopyInto(out *FinalStepPolicy) {
+	*out = *in
+	out.SoakTime = in.SoakTime
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FinalStepPolicy.
+func (in *FinalStepPolicy) DeepCopy() *FinalStepPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(FinalStepPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
 	*out = *in
 	if in.Steps != nil {
 		in, out := &in.Steps, &out.Steps
 		*out = make([]RolloutStrategyStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([]RolloutWave, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FinalStepPolicy != nil {
+		in, out := &in.FinalStepPolicy, &out.FinalStepPolicy
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(FinalStepPolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.StabilityPolicy != nil {
+		in, out := &in.StabilityPolicy, &out.StabilityPolicy
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(StabilityPolicy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	if in.IncumbentRetention != nil {
+		in, out := &in.IncumbentRetention, &out.IncumbentRetention
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = (*in).DeepCopy()
+		}
+	}
+	if in.CompletionGates != nil {
+		in, out := &in.CompletionGates, &out.CompletionGates
+		*out = make([]ReleaseCompletionGate, len(*in))
 		copy(*out, *in)
 	}
+	if in.Adaptive != nil {
+		in, out := &in.Adaptive, &out.Adaptive
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(AdaptiveStrategy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -1175,14 +1699,97 @@ func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategyFeatureFlag) DeepCopyInto(out *RolloutStrategyFeatureFlag) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategyFeatureFlag.
+func (in *RolloutStrategyFeatureFlag) DeepCopy() *RolloutStrategyFeatureFlag {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategyFeatureFlag)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategyServiceTier) DeepCopyInto(out *RolloutStrategyServiceTier) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategyServiceTier.
+func (in *RolloutStrategyServiceTier) DeepCopy() *RolloutStrategyServiceTier {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategyServiceTier)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RolloutStrategyStep) DeepCopyInto(out *RolloutStrategyStep) {
 	*out = *in
 	out.Capacity = in.Capacity
 	out.Traffic = in.Traffic
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]RolloutStrategyStepOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TrafficTiers != nil {
+		in, out := &in.TrafficTiers, &out.TrafficTiers
+		*out = make([]RolloutStrategyServiceTier, len(*in))
+		copy(*out, *in)
+	}
+	if in.FeatureFlags != nil {
+		in, out := &in.FeatureFlags, &out.FeatureFlags
+		*out = make([]RolloutStrategyFeatureFlag, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategyStepOverride) DeepCopyInto(out *RolloutStrategyStepOverride) {
+	*out = *in
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RolloutStrategyStepValue)
+			**out = **in
+		}
+	}
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RolloutStrategyStepValue)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategyStepOverride.
+func (in *RolloutStrategyStepOverride) DeepCopy() *RolloutStrategyStepOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategyStepOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategyStep.
 func (in *RolloutStrategyStep) DeepCopy() *RolloutStrategyStep {
 	if in == nil {
@@ -1209,6 +1816,153 @@ func (in *RolloutStrategyStepValue) DeepCopy() *RolloutStrategyStepValue {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutWave) DeepCopyInto(out *RolloutWave) {
+	*out = *in
+	out.BakeTime = in.BakeTime
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutWave.
+func (in *RolloutWave) DeepCopy() *RolloutWave {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutWave)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StabilityPolicy) DeepCopyInto(out *StabilityPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StabilityPolicy.
+func (in *StabilityPolicy) DeepCopy() *StabilityPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(StabilityPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Strategy) DeepCopyInto(out *Strategy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Strategy.
+func (in *Strategy) DeepCopy() *Strategy {
+	if in == nil {
+		return nil
+	}
+	out := new(Strategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Strategy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StrategyList) DeepCopyInto(out *StrategyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Strategy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StrategyList.
+func (in *StrategyList) DeepCopy() *StrategyList {
+	if in == nil {
+		return nil
+	}
+	out := new(StrategyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StrategyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StrategyTier) DeepCopyInto(out *StrategyTier) {
+	*out = *in
+	if in.Regions != nil {
+		in, out := &in.Regions, &out.Regions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RolloutStrategy)
+			(*in).DeepCopyInto(*out)
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StrategyTier.
+func (in *StrategyTier) DeepCopy() *StrategyTier {
+	if in == nil {
+		return nil
+	}
+	out := new(StrategyTier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StrategyStateDuration) DeepCopyInto(out *StrategyStateDuration) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StrategyStateDuration.
+func (in *StrategyStateDuration) DeepCopy() *StrategyStateDuration {
+	if in == nil {
+		return nil
+	}
+	out := new(StrategyStateDuration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrafficTarget) DeepCopyInto(out *TrafficTarget) {
 	*out = *in
@@ -1278,7 +2032,9 @@ func (in *TrafficTargetSpec) DeepCopyInto(out *TrafficTargetSpec) {
 	if in.Clusters != nil {
 		in, out := &in.Clusters, &out.Clusters
 		*out = make([]ClusterTrafficTarget, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
@@ -1320,3 +2076,19 @@ func (in *TrafficTargetStatus) DeepCopy() *TrafficTargetStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesPatch) DeepCopyInto(out *ValuesPatch) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesPatch.
+func (in *ValuesPatch) DeepCopy() *ValuesPatch {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesPatch)
+	in.DeepCopyInto(out)
+	return out
+}
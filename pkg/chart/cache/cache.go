@@ -8,8 +8,13 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"syscall"
 )
 
+// lockFileName is the advisory lock file kept in each chart family
+// directory. It is excluded from size accounting and eviction.
+const lockFileName = ".lock"
+
 type fsCache struct {
 	dir                  string
 	chartFamilySizeLimit int
@@ -53,11 +58,22 @@ func (f *fsCache) Store(data []byte, repo, name, version string) error {
 		return err
 	}
 
+	// The cache directory might be a volume shared between several shipper
+	// replicas, so take an exclusive advisory lock on the chart family
+	// while writing and evicting. Reads don't need the lock: the atomic
+	// rename below guarantees readers never see partially-written charts.
+	lockFile, err := lockFamily(familyPath)
+	if err != nil {
+		return err
+	}
+	defer unlockFamily(lockFile)
+
 	// Check size of dir against limit, delete oldest versions if needed.
 	versions, err := ioutil.ReadDir(familyPath)
 	if err != nil {
 		return err
 	}
+	versions = filterOutLockFile(versions)
 
 	size := len(data)
 	if size > f.chartFamilySizeLimit {
@@ -116,6 +132,36 @@ func (f *fsCache) Clean() error {
 	return os.RemoveAll(f.dir)
 }
 
+func lockFamily(familyPath string) (*os.File, error) {
+	lockFile, err := os.OpenFile(filepath.Join(familyPath, lockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		lockFile.Close()
+		return nil, err
+	}
+
+	return lockFile, nil
+}
+
+func unlockFamily(lockFile *os.File) {
+	_ = syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN)
+	_ = lockFile.Close()
+}
+
+func filterOutLockFile(versions []os.FileInfo) []os.FileInfo {
+	filtered := versions[:0]
+	for _, fileinfo := range versions {
+		if fileinfo.Name() == lockFileName {
+			continue
+		}
+		filtered = append(filtered, fileinfo)
+	}
+	return filtered
+}
+
 func clean(names ...string) (string, string, string) {
 	for i := range names {
 		// These first two aren't required, but I think there's not much risk in
@@ -136,3 +136,56 @@ func TestCacheEviction(t *testing.T) {
 		)
 	}
 }
+
+// TestSharedCacheDirectory simulates two shipper replicas pointing their
+// caches at the same directory: what one instance stores the other must be
+// able to fetch, and concurrent writers must not corrupt each other.
+func TestSharedCacheDirectory(t *testing.T) {
+	storedData := "foobar"
+	cacheOne := NewFilesystemCache(testCacheDir, tenMb)
+	cacheTwo := NewFilesystemCache(testCacheDir, tenMb)
+	defer cacheOne.Clean()
+
+	err := cacheOne.Store([]byte(storedData), testChartRepo, testChartName, testChartVersion)
+	if err != nil {
+		t.Fatalf("failed to store %s/%s-%s: %q", testChartRepo, testChartName, testChartVersion, err)
+	}
+
+	fetchedData, err := cacheTwo.Fetch(testChartRepo, testChartName, testChartVersion)
+	if err != nil {
+		t.Fatalf("failed to fetch from the second cache instance: %q", err)
+	}
+	if fetchedData == nil || fetchedData.String() != storedData {
+		t.Fatalf("second cache instance fetched wrong contents: expected %q but got %v", storedData, fetchedData)
+	}
+
+	// Hammer the same family from both instances concurrently; flock
+	// serializes the writes so every version should come out intact.
+	done := make(chan error, 2)
+	writer := func(cache *fsCache, version string) {
+		var err error
+		for i := 0; i < 20; i++ {
+			if err = cache.Store([]byte(storedData), testChartRepo, testChartName, version); err != nil {
+				break
+			}
+		}
+		done <- err
+	}
+	go writer(cacheOne, "0.0.2")
+	go writer(cacheTwo, "0.0.3")
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("concurrent store failed: %q", err)
+		}
+	}
+
+	for _, version := range []string{"0.0.2", "0.0.3"} {
+		fetchedData, err := cacheOne.Fetch(testChartRepo, testChartName, version)
+		if err != nil {
+			t.Fatalf("failed to fetch %s after concurrent stores: %q", version, err)
+		}
+		if fetchedData == nil || fetchedData.String() != storedData {
+			t.Fatalf("fetched %s but got wrong contents after concurrent stores", version)
+		}
+	}
+}
@@ -14,12 +14,30 @@ import (
 )
 
 // Render renders a chart, with the given values. It returns a list of rendered
-// Kubernetes objects.
+// Kubernetes objects. The chart renders against helm's default capabilities;
+// use RenderWithCapabilities to render against a specific cluster's.
 func Render(chart *helmchart.Chart, name, ns string, shipperValues *shipper.ChartValues) ([]string, error) {
+	return RenderWithCapabilities(chart, name, ns, shipperValues, nil)
+}
+
+// RenderWithCapabilities renders a chart like Render, but against the given
+// cluster capabilities, so templates gated on .Capabilities.KubeVersion or on
+// the available API versions produce the right output for the target cluster.
+// Nil capabilities fall back to helm's defaults.
+func RenderWithCapabilities(chart *helmchart.Chart, name, ns string, shipperValues *shipper.ChartValues, caps *chartutil.Capabilities) ([]string, error) {
 	chartConfig := &helmchart.Config{}
 	if shipperValues != nil {
 		values := chartutil.Values(*shipperValues)
 
+		// Values sourced from ConfigMaps arrive as strings; recover the
+		// types the chart's own defaults declare before the templates
+		// assert on them.
+		if chart.Values != nil && chart.Values.Raw != "" {
+			if defaults, err := chartutil.ReadValues([]byte(chart.Values.Raw)); err == nil {
+				values = chartutil.Values(CoerceStringValues(values, defaults))
+			}
+		}
+
 		var yaml string
 		yaml, err := values.YAML()
 		if err != nil {
@@ -43,7 +61,13 @@ func Render(chart *helmchart.Chart, name, ns string, shipperValues *shipper.Char
 		IsInstall: true,
 	}
 
-	helmValues, err := chartutil.ToRenderValues(chart, chartConfig, chartOptions)
+	var helmValues chartutil.Values
+	var err error
+	if caps != nil {
+		helmValues, err = chartutil.ToRenderValuesCaps(chart, chartConfig, chartOptions, caps)
+	} else {
+		helmValues, err = chartutil.ToRenderValues(chart, chartConfig, chartOptions)
+	}
 	if err != nil {
 		return nil, err
 	}
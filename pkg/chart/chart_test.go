@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/any"
 	"k8s.io/helm/pkg/chartutil"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
@@ -43,6 +44,82 @@ func TestRender(t *testing.T) {
 	}
 }
 
+// TestRenderWithValuesFile renders with an in-chart values-prod.yaml
+// selected and checks that its defaults apply, while an explicit override
+// still wins over them.
+func TestRenderWithValuesFile(t *testing.T) {
+	cwd, _ := filepath.Abs(".")
+	chartFile, err := os.Open(filepath.Join(cwd, "testdata", "my-complex-app-0.2.0.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chart, err := chartutil.LoadArchive(chartFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chart.Files = append(chart.Files, &any.Any{
+		TypeUrl: "values-prod.yaml",
+		Value:   []byte("replicaCount: 3\n"),
+	})
+
+	env := &shipper.ReleaseEnvironment{
+		ValuesFile: "values-prod.yaml",
+	}
+
+	values, err := EnvironmentValues(chart, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := Render(chart, "my-complex-app", "my-complex-app", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replicas := extractReplicas(t, rendered); replicas != 3 {
+		t.Errorf("expected the file's default of 3 replicas but found %d", replicas)
+	}
+
+	// An explicit override still wins over the file's default.
+	env.Values = &shipper.ChartValues{"replicaCount": 5}
+
+	values, err = EnvironmentValues(chart, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err = Render(chart, "my-complex-app", "my-complex-app", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replicas := extractReplicas(t, rendered); replicas != 5 {
+		t.Errorf("expected the override of 5 replicas but found %d", replicas)
+	}
+
+	// A file the chart doesn't contain fails loudly.
+	env.ValuesFile = "values-nonexistent.yaml"
+	if _, err := EnvironmentValues(chart, env); err == nil {
+		t.Error("expected an error for a values file the chart does not contain")
+	}
+}
+
+func extractReplicas(t *testing.T, rendered []string) int {
+	deployments := GetDeployments(rendered)
+	if len(deployments) != 1 {
+		t.Fatalf("expected exactly 1 deployment, got %d", len(deployments))
+	}
+
+	replicas := deployments[0].Spec.Replicas
+	if replicas == nil {
+		t.Fatal("extracted nil replicas from deployment")
+	}
+
+	return int(*replicas)
+}
+
 func TestRenderZeroByteTemplates(t *testing.T) {
 	cwd, _ := filepath.Abs(".")
 	chartFile, err := os.Open(filepath.Join(cwd, "testdata", "my-complex-app-0.2.0.tgz"))
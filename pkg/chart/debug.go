@@ -0,0 +1,78 @@
+package chart
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	"k8s.io/helm/pkg/repo"
+)
+
+// RepoIndexEntry is one chart version as the index debug endpoint reports it.
+type RepoIndexEntry struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	AppVersion string   `json:"appVersion,omitempty"`
+	Digest     string   `json:"digest,omitempty"`
+	URLs       []string `json:"urls,omitempty"`
+}
+
+// CachedIndex returns the parsed index currently cached for the given
+// repository, without fetching anything. The second return reports whether
+// the fetcher has seen the repository at all.
+func (f *IndexFetcher) CachedIndex(repoURL string) (*repo.IndexFile, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cached, ok := f.indexes[repoURL]
+	if !ok {
+		return nil, false
+	}
+	return cached.index, true
+}
+
+// DebugHandler returns a read-only HTTP handler exposing the parsed
+// index.yaml entries cached for a repository, so an operator chasing a "why
+// didn't my chart version resolve" can confirm which versions and digests
+// shipper sees without curling the repository themselves. The repository is
+// selected with the repoURL query parameter; repositories the fetcher hasn't
+// seen yet report 404. Nothing is ever fetched on this path.
+func (f *IndexFetcher) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repoURL := r.URL.Query().Get("repoURL")
+		if repoURL == "" {
+			http.Error(w, "missing repoURL query parameter", http.StatusBadRequest)
+			return
+		}
+
+		index, ok := f.CachedIndex(repoURL)
+		if !ok {
+			http.Error(w, fmt.Sprintf("no cached index for %q", repoURL), http.StatusNotFound)
+			return
+		}
+
+		entries := make(map[string][]RepoIndexEntry, len(index.Entries))
+		for name, versions := range index.Entries {
+			list := make([]RepoIndexEntry, 0, len(versions))
+			for _, version := range versions {
+				entry := RepoIndexEntry{
+					Digest: version.Digest,
+					URLs:   version.URLs,
+				}
+				if version.Metadata != nil {
+					entry.Name = version.Name
+					entry.Version = version.Version
+					entry.AppVersion = version.AppVersion
+				}
+				list = append(list, entry)
+			}
+			entries[name] = list
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(entries); err != nil {
+			glog.V(2).Infof("error encoding chart index debug response: %s", err)
+		}
+	})
+}
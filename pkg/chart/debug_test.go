@@ -0,0 +1,80 @@
+package chart
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const indexYamlResp = `
+apiVersion: v1
+entries:
+  alpine:
+    - name: alpine
+      version: 1.0.0
+      appVersion: "3.3"
+      digest: sha256:3c31c8ae5c514d6531ab1e289cf03947ca33cea0f954b0d4b52f813bfc359f0e
+      urls:
+        - https://charts.example.com/alpine-1.0.0.tgz
+  nginx:
+    - name: nginx
+      version: 0.2.0
+      digest: sha256:f5f1d87ff6d1b4fb8b3faf60fb9e7a11c19db1bf80a3a442a9b9b670b0b5ae3f
+      urls:
+        - https://charts.example.com/nginx-0.2.0.tgz
+`
+
+func TestDebugHandlerReturnsCachedEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(indexYamlResp))
+	}))
+	defer server.Close()
+
+	fetcher := NewIndexFetcher()
+	if _, err := fetcher.RefreshIndex(server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/chart-index?repoURL="+url.QueryEscape(server.URL), nil)
+	rec := httptest.NewRecorder()
+	fetcher.DebugHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var entries map[string][]RepoIndexEntry
+	if err := json.NewDecoder(rec.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+
+	alpine, ok := entries["alpine"]
+	if !ok || len(alpine) != 1 {
+		t.Fatalf("expected exactly one alpine entry, got %v", entries["alpine"])
+	}
+	if alpine[0].Version != "1.0.0" || alpine[0].Digest == "" {
+		t.Errorf("expected alpine 1.0.0 with its digest, got %+v", alpine[0])
+	}
+
+	nginx, ok := entries["nginx"]
+	if !ok || len(nginx) != 1 {
+		t.Fatalf("expected exactly one nginx entry, got %v", entries["nginx"])
+	}
+	if nginx[0].Version != "0.2.0" || nginx[0].Digest == "" {
+		t.Errorf("expected nginx 0.2.0 with its digest, got %+v", nginx[0])
+	}
+}
+
+func TestDebugHandlerUnknownRepo(t *testing.T) {
+	fetcher := NewIndexFetcher()
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/chart-index?repoURL=https%3A%2F%2Fcharts.example.com", nil)
+	rec := httptest.NewRecorder()
+	fetcher.DebugHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a repository that was never fetched, got %d", rec.Code)
+	}
+}
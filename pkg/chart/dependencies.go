@@ -0,0 +1,148 @@
+package chart
+
+import (
+	"fmt"
+
+	"k8s.io/helm/pkg/chartutil"
+	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// maxDependencyDepth bounds how many levels of nested requirements.yaml files
+// dependency resolution will follow. Umbrella charts rarely nest more than a
+// level or two; a chain deeper than this is almost certainly a cycle, and
+// refusing it outright beats retrying forever.
+const maxDependencyDepth = 3
+
+// DependencyUnresolvableError means a chart declares a dependency that no
+// searched repo serves, or a dependency chain deeper than shipper is willing
+// to follow. Either way retrying won't help: the chart or the repos have to
+// change first.
+type DependencyUnresolvableError struct {
+	Parent        string
+	Name          string
+	Version       string
+	ReposSearched []string
+}
+
+func (e DependencyUnresolvableError) Error() string {
+	if len(e.ReposSearched) == 0 {
+		return fmt.Sprintf(
+			"chart %s depends on %s-%s more than %d levels deep; refusing to resolve further",
+			e.Parent, e.Name, e.Version, maxDependencyDepth)
+	}
+	return fmt.Sprintf(
+		"chart %s depends on %s-%s, which none of the searched repos serve: %v",
+		e.Parent, e.Name, e.Version, e.ReposSearched)
+}
+
+func (e DependencyUnresolvableError) ShouldRetry() bool {
+	return false
+}
+
+// IsDependencyUnresolvableError reports whether err is a definitive failure
+// to resolve a chart dependency, as opposed to a transient fetch problem.
+func IsDependencyUnresolvableError(err error) bool {
+	_, ok := err.(DependencyUnresolvableError)
+	return ok
+}
+
+// WithDependencyResolution wraps fetch so that charts declaring dependencies
+// in requirements.yaml get their missing subcharts fetched too: each one is
+// looked up in the repo the requirement names and then in the parent's repo.
+// A subchart that both answer not-found for yields a terminal
+// DependencyUnresolvableError, while transient fetch errors keep their type
+// so the sync is retried.
+func WithDependencyResolution(fetch FetchFunc) FetchFunc {
+	return func(chart shipper.Chart) (*helmchart.Chart, error) {
+		chrt, err := fetch(chart)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := resolveDependencies(fetch, chart.RepoURL, chrt, 0); err != nil {
+			return nil, err
+		}
+
+		return chrt, nil
+	}
+}
+
+func resolveDependencies(fetch FetchFunc, parentRepoURL string, chrt *helmchart.Chart, depth int) error {
+	reqs, err := chartutil.LoadRequirements(chrt)
+	if err == chartutil.ErrRequirementsNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Archives typically bundle their subcharts already; only fetch the
+	// ones that aren't there.
+	bundled := make(map[string]bool, len(chrt.Dependencies))
+	for _, dep := range chrt.Dependencies {
+		if dep.Metadata != nil {
+			bundled[dep.Metadata.Name] = true
+		}
+	}
+
+	for _, req := range reqs.Dependencies {
+		if bundled[req.Name] {
+			continue
+		}
+
+		if depth >= maxDependencyDepth {
+			return DependencyUnresolvableError{
+				Parent:  chrt.Metadata.Name,
+				Name:    req.Name,
+				Version: req.Version,
+			}
+		}
+
+		var repos []string
+		if req.Repository != "" {
+			repos = append(repos, req.Repository)
+		}
+		if parentRepoURL != "" && parentRepoURL != req.Repository {
+			repos = append(repos, parentRepoURL)
+		}
+
+		var subchart *helmchart.Chart
+		var subchartRepoURL string
+		for _, repoURL := range repos {
+			sub, err := fetch(shipper.Chart{
+				Name:    req.Name,
+				Version: req.Version,
+				RepoURL: repoURL,
+			})
+			if err == nil {
+				subchart = sub
+				subchartRepoURL = repoURL
+				break
+			}
+			if IsChartNotFoundError(err) {
+				continue
+			}
+			// The repo might just be unreachable right now; let the
+			// caller retry instead of condemning the dependency.
+			return err
+		}
+
+		if subchart == nil {
+			return DependencyUnresolvableError{
+				Parent:        chrt.Metadata.Name,
+				Name:          req.Name,
+				Version:       req.Version,
+				ReposSearched: repos,
+			}
+		}
+
+		if err := resolveDependencies(fetch, subchartRepoURL, subchart, depth+1); err != nil {
+			return err
+		}
+
+		chrt.Dependencies = append(chrt.Dependencies, subchart)
+	}
+
+	return nil
+}
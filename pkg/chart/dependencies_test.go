@@ -0,0 +1,149 @@
+package chart
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/any"
+	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+const (
+	umbrellaRepoURL = "umbrella.example.com"
+	subchartRepoURL = "subcharts.example.com"
+)
+
+func buildUmbrellaChart(name string, requirements string) *helmchart.Chart {
+	return &helmchart.Chart{
+		Metadata: &helmchart.Metadata{Name: name, Version: "0.1.0"},
+		Files: []*any.Any{
+			{TypeUrl: "requirements.yaml", Value: []byte(requirements)},
+		},
+	}
+}
+
+// TestWithDependencyResolutionFetchesSubchart tests that a missing subchart is
+// fetched from the repo its requirement names and attached to the umbrella.
+func TestWithDependencyResolutionFetchesSubchart(t *testing.T) {
+	umbrella := buildUmbrellaChart("umbrella", fmt.Sprintf(
+		"dependencies:\n- name: reviews-db\n  version: 0.0.1\n  repository: %s\n", subchartRepoURL))
+
+	var fetchedFrom []string
+	fetch := WithDependencyResolution(func(chart shipper.Chart) (*helmchart.Chart, error) {
+		fetchedFrom = append(fetchedFrom, chart.RepoURL)
+		if chart.Name == "umbrella" {
+			return umbrella, nil
+		}
+		return &helmchart.Chart{
+			Metadata: &helmchart.Metadata{Name: chart.Name, Version: chart.Version},
+		}, nil
+	})
+
+	chrt, err := fetch(shipper.Chart{Name: "umbrella", Version: "0.1.0", RepoURL: umbrellaRepoURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fetchedFrom, []string{umbrellaRepoURL, subchartRepoURL}) {
+		t.Fatalf("expected fetches from the umbrella repo then the subchart repo, got %v", fetchedFrom)
+	}
+
+	if len(chrt.Dependencies) != 1 || chrt.Dependencies[0].Metadata.Name != "reviews-db" {
+		t.Fatalf("expected the umbrella to gain the reviews-db subchart, got dependencies %v", chrt.Dependencies)
+	}
+}
+
+// TestWithDependencyResolutionUnresolvable tests that a subchart no searched
+// repo serves fails with a terminal DependencyUnresolvableError listing the
+// repos searched.
+func TestWithDependencyResolutionUnresolvable(t *testing.T) {
+	umbrella := buildUmbrellaChart("umbrella", fmt.Sprintf(
+		"dependencies:\n- name: no-such-chart\n  version: 9.9.9\n  repository: %s\n", subchartRepoURL))
+
+	fetch := WithDependencyResolution(func(chart shipper.Chart) (*helmchart.Chart, error) {
+		if chart.Name == "umbrella" {
+			return umbrella, nil
+		}
+		return nil, ChartNotFoundError{RepoURL: chart.RepoURL, Name: chart.Name, Version: chart.Version}
+	})
+
+	_, err := fetch(shipper.Chart{Name: "umbrella", Version: "0.1.0", RepoURL: umbrellaRepoURL})
+	if !IsDependencyUnresolvableError(err) {
+		t.Fatalf("expected a DependencyUnresolvableError, got %v", err)
+	}
+
+	depErr := err.(DependencyUnresolvableError)
+	if depErr.ShouldRetry() {
+		t.Error("expected DependencyUnresolvableError not to retry")
+	}
+
+	expectedRepos := []string{subchartRepoURL, umbrellaRepoURL}
+	if !reflect.DeepEqual(depErr.ReposSearched, expectedRepos) {
+		t.Errorf("expected repos searched %v, got %v", expectedRepos, depErr.ReposSearched)
+	}
+}
+
+// TestWithDependencyResolutionTransientError tests that a repo failing with
+// anything but a definitive not-found surfaces the original error, so the
+// caller retries instead of condemning the dependency.
+func TestWithDependencyResolutionTransientError(t *testing.T) {
+	umbrella := buildUmbrellaChart("umbrella", fmt.Sprintf(
+		"dependencies:\n- name: reviews-db\n  version: 0.0.1\n  repository: %s\n", subchartRepoURL))
+
+	transientErr := fmt.Errorf("connection refused")
+	fetch := WithDependencyResolution(func(chart shipper.Chart) (*helmchart.Chart, error) {
+		if chart.Name == "umbrella" {
+			return umbrella, nil
+		}
+		return nil, transientErr
+	})
+
+	_, err := fetch(shipper.Chart{Name: "umbrella", Version: "0.1.0", RepoURL: umbrellaRepoURL})
+	if err != transientErr {
+		t.Fatalf("expected the transient error to pass through unchanged, got %v", err)
+	}
+}
+
+// TestWithDependencyResolutionBundledSubchart tests that a subchart already
+// bundled in the archive is not fetched again.
+func TestWithDependencyResolutionBundledSubchart(t *testing.T) {
+	umbrella := buildUmbrellaChart("umbrella", fmt.Sprintf(
+		"dependencies:\n- name: reviews-db\n  version: 0.0.1\n  repository: %s\n", subchartRepoURL))
+	umbrella.Dependencies = []*helmchart.Chart{
+		{Metadata: &helmchart.Metadata{Name: "reviews-db", Version: "0.0.1"}},
+	}
+
+	var fetchedFrom []string
+	fetch := WithDependencyResolution(func(chart shipper.Chart) (*helmchart.Chart, error) {
+		fetchedFrom = append(fetchedFrom, chart.RepoURL)
+		return umbrella, nil
+	})
+
+	if _, err := fetch(shipper.Chart{Name: "umbrella", Version: "0.1.0", RepoURL: umbrellaRepoURL}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fetchedFrom, []string{umbrellaRepoURL}) {
+		t.Fatalf("expected a single fetch of the umbrella itself, got fetches from %v", fetchedFrom)
+	}
+}
+
+// TestWithDependencyResolutionDepthBound tests that a dependency chain deeper
+// than maxDependencyDepth fails terminally instead of looping.
+func TestWithDependencyResolutionDepthBound(t *testing.T) {
+	// Every chart requires itself again: a cycle that would resolve forever
+	// without the depth bound.
+	fetch := WithDependencyResolution(func(chart shipper.Chart) (*helmchart.Chart, error) {
+		return buildUmbrellaChart(chart.Name, fmt.Sprintf(
+			"dependencies:\n- name: %s\n  version: %s\n  repository: %s\n",
+			chart.Name, chart.Version, subchartRepoURL)), nil
+	})
+
+	_, err := fetch(shipper.Chart{Name: "umbrella", Version: "0.1.0", RepoURL: umbrellaRepoURL})
+	if !IsDependencyUnresolvableError(err) {
+		t.Fatalf("expected a DependencyUnresolvableError for an over-deep chain, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package chart
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -18,13 +19,44 @@ import (
 
 type FetchFunc func(shipper.Chart) (*helmchart.Chart, error)
 
+// ChartNotFoundError means a chart repo answered definitively that it doesn't
+// serve the requested chart, as opposed to being unreachable or broken.
+type ChartNotFoundError struct {
+	RepoURL string
+	Name    string
+	Version string
+}
+
+func (e ChartNotFoundError) Error() string {
+	return fmt.Sprintf("chart %s-%s not found in repo %s", e.Name, e.Version, e.RepoURL)
+}
+
+// IsChartNotFoundError reports whether err is a definitive not-found answer
+// from a chart repo.
+func IsChartNotFoundError(err error) bool {
+	_, ok := err.(ChartNotFoundError)
+	return ok
+}
+
 // 5mb limit per chart family (all versions of a given chart).
 // A large chart with many objects (but no big bundled files) is 20kb -> 256
 // versions.
 // This fits ~2k distinct charts into 10gb of disk.
 const DefaultCacheLimit = 5 * 1024 * 1024
 
+// maxDownloadBytes caps how much of a chart archive downloadChart will buffer
+// before giving up. Together with WithConcurrencyLimit this bounds the total
+// memory the fetch path can hold in flight.
+const maxDownloadBytes = 32 * 1024 * 1024
+
 func FetchRemoteWithCache(dir string, perChartFamilyByteLimit int) FetchFunc {
+	return FetchRemoteWithCacheAndIndexes(dir, perChartFamilyByteLimit, NewIndexFetcher())
+}
+
+// FetchRemoteWithCacheAndIndexes is FetchRemoteWithCache with a caller-owned
+// IndexFetcher, so the caller can share the repository index cache with other
+// consumers or warm it ahead of time.
+func FetchRemoteWithCacheAndIndexes(dir string, perChartFamilyByteLimit int, indexes *IndexFetcher) FetchFunc {
 	cache := chartcache.NewFilesystemCache(dir, perChartFamilyByteLimit)
 	return func(chart shipper.Chart) (*helmchart.Chart, error) {
 		cachedChart, err := cache.Fetch(chart.RepoURL, chart.Name, chart.Version)
@@ -47,6 +79,14 @@ func FetchRemoteWithCache(dir string, perChartFamilyByteLimit int) FetchFunc {
 			return nil, chartcache.DownloadChartError(err)
 		}
 
+		// Reject corrupt downloads before they make it into the cache.
+		if err := ValidateArchive(data); err != nil {
+			return nil, err
+		}
+		if err := VerifyDigest(data, declaredDigest(indexes, chart)); err != nil {
+			return nil, err
+		}
+
 		// We didn't find it in the cache earlier and had to fall through to
 		// downloading, so write it to the cache.
 		err = cache.Store(data, chart.RepoURL, chart.Name, chart.Version)
@@ -64,15 +104,88 @@ func FetchRemoteWithCache(dir string, perChartFamilyByteLimit int) FetchFunc {
 }
 
 func FetchRemote() FetchFunc {
+	indexes := NewIndexFetcher()
 	return func(chart shipper.Chart) (*helmchart.Chart, error) {
 		data, err := downloadChart(chart.RepoURL, chart.Name, chart.Version)
 		if err != nil {
 			return nil, err
 		}
+		if err := ValidateArchive(data); err != nil {
+			return nil, err
+		}
+		if err := VerifyDigest(data, declaredDigest(indexes, chart)); err != nil {
+			return nil, err
+		}
 		return chartutil.LoadArchive(bytes.NewReader(data))
 	}
 }
 
+// WithDefaultRepo wraps fetch with an org-wide fallback repo: charts that
+// name no repo at all are fetched from defaultRepoURL, and charts whose repo
+// answers a definitive not-found are retried against it. Explicit repos that
+// resolve stay authoritative. An empty defaultRepoURL returns fetch
+// unchanged.
+func WithDefaultRepo(fetch FetchFunc, defaultRepoURL string) FetchFunc {
+	if defaultRepoURL == "" {
+		return fetch
+	}
+
+	return func(chart shipper.Chart) (*helmchart.Chart, error) {
+		if chart.RepoURL == "" {
+			chart.RepoURL = defaultRepoURL
+			return fetch(chart)
+		}
+
+		chrt, err := fetch(chart)
+		if err != nil && IsChartNotFoundError(err) && chart.RepoURL != defaultRepoURL {
+			glog.V(2).Infof("chart %s-%s not found in %s, falling back to default repo %s",
+				chart.Name, chart.Version, chart.RepoURL, defaultRepoURL)
+			chart.RepoURL = defaultRepoURL
+			return fetch(chart)
+		}
+
+		return chrt, err
+	}
+}
+
+// WithConcurrencyLimit wraps fetch with a semaphore so that at most limit
+// fetches run at once; a burst of reconciles beyond that queues instead of
+// holding every chart archive in memory simultaneously. A limit below 1
+// returns fetch unchanged.
+func WithConcurrencyLimit(fetch FetchFunc, limit int) FetchFunc {
+	if limit < 1 {
+		return fetch
+	}
+
+	semaphore := make(chan struct{}, limit)
+	return func(chart shipper.Chart) (*helmchart.Chart, error) {
+		semaphore <- struct{}{}
+		defer func() { <-semaphore }()
+
+		return fetch(chart)
+	}
+}
+
+// declaredDigest returns the digest the repository index declares for the
+// given chart version, or "" when the index is unreachable or doesn't declare
+// one. Digest verification is best-effort: a repository without a usable
+// index shouldn't break fetching, but one with an index shouldn't serve
+// mismatched bytes.
+func declaredDigest(indexes *IndexFetcher, chart shipper.Chart) string {
+	index, err := indexes.RefreshIndex(chart.RepoURL)
+	if err != nil {
+		glog.V(4).Infof("could not refresh chart repo index for %s: %s", chart.RepoURL, err)
+		return ""
+	}
+
+	cv, err := index.Get(chart.Name, chart.Version)
+	if err != nil {
+		return ""
+	}
+
+	return cv.Digest
+}
+
 func downloadChart(repoURL, name, version string) ([]byte, error) {
 	u, err := url.Parse(repoURL)
 	if err != nil {
@@ -93,17 +206,24 @@ func downloadChart(repoURL, name, version string) ([]byte, error) {
 		}
 	}()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ChartNotFoundError{RepoURL: repoURL, Name: name, Version: version}
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		// TODO log body
 		return nil, fmt.Errorf("download %s: %d", u, resp.StatusCode)
 	}
 
-	data, err := ioutil.ReadAll(resp.Body)
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxDownloadBytes+1))
 	if err != nil {
 		return nil, err
 	}
 	if len(data) == 0 {
 		return nil, fmt.Errorf("0 byte response fetching %s-%s/%s", repoURL, name, version)
 	}
+	if len(data) > maxDownloadBytes {
+		return nil, fmt.Errorf("chart %s-%s from %s is larger than the %d byte download limit", name, version, repoURL, maxDownloadBytes)
+	}
 	return data, nil
 }
@@ -6,8 +6,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
+	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
 	"k8s.io/helm/pkg/repo/repotest"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
@@ -123,3 +126,126 @@ func TestFetchCacheRemoteGoneAway(t *testing.T) {
 		t.Fatalf("remote chart and cached chart are not the same: %v and %v", remoteChart, cachedChart)
 	}
 }
+
+// TestWithDefaultRepoEmptyRepoURL tests that a chart naming no repo at all is
+// fetched from the configured default repo.
+func TestWithDefaultRepoEmptyRepoURL(t *testing.T) {
+	const defaultRepoURL = "default.example.com"
+
+	var fetchedFrom []string
+	fetch := WithDefaultRepo(func(chart shipper.Chart) (*helmchart.Chart, error) {
+		fetchedFrom = append(fetchedFrom, chart.RepoURL)
+		return &helmchart.Chart{}, nil
+	}, defaultRepoURL)
+
+	inChart := shipper.Chart{
+		Name:    testFetchChartName,
+		Version: testFetchChartVersion,
+	}
+
+	if _, err := fetch(inChart); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fetchedFrom, []string{defaultRepoURL}) {
+		t.Fatalf("expected a single fetch from %q, got fetches from %v", defaultRepoURL, fetchedFrom)
+	}
+}
+
+// TestWithDefaultRepoExplicitRepoURL tests that an explicit repo that resolves
+// stays authoritative, and that a definitive not-found from an explicit repo
+// falls back to the default.
+func TestWithDefaultRepoExplicitRepoURL(t *testing.T) {
+	const (
+		defaultRepoURL = "default.example.com"
+		explicitRepo   = "charts.example.com"
+		brokenRepo     = "empty.example.com"
+	)
+
+	var fetchedFrom []string
+	fetch := WithDefaultRepo(func(chart shipper.Chart) (*helmchart.Chart, error) {
+		fetchedFrom = append(fetchedFrom, chart.RepoURL)
+		if chart.RepoURL == brokenRepo {
+			return nil, ChartNotFoundError{RepoURL: chart.RepoURL, Name: chart.Name, Version: chart.Version}
+		}
+		return &helmchart.Chart{}, nil
+	}, defaultRepoURL)
+
+	inChart := shipper.Chart{
+		Name:    testFetchChartName,
+		Version: testFetchChartVersion,
+		RepoURL: explicitRepo,
+	}
+
+	if _, err := fetch(inChart); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fetchedFrom, []string{explicitRepo}) {
+		t.Fatalf("expected a single fetch from %q, got fetches from %v", explicitRepo, fetchedFrom)
+	}
+
+	fetchedFrom = nil
+	inChart.RepoURL = brokenRepo
+
+	if _, err := fetch(inChart); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fetchedFrom, []string{brokenRepo, defaultRepoURL}) {
+		t.Fatalf("expected fetches from %q then %q, got fetches from %v", brokenRepo, defaultRepoURL, fetchedFrom)
+	}
+}
+
+// TestWithConcurrencyLimitQueuesFetches tests that fetches beyond the
+// concurrency limit queue instead of all running at once.
+func TestWithConcurrencyLimitQueuesFetches(t *testing.T) {
+	const (
+		limit   = 2
+		fetches = 5
+	)
+
+	var (
+		mut         sync.Mutex
+		inFlight    int
+		maxInFlight int
+	)
+
+	release := make(chan struct{})
+	fetch := WithConcurrencyLimit(func(chart shipper.Chart) (*helmchart.Chart, error) {
+		mut.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mut.Unlock()
+
+		<-release
+
+		mut.Lock()
+		inFlight--
+		mut.Unlock()
+
+		return &helmchart.Chart{}, nil
+	}, limit)
+
+	var wg sync.WaitGroup
+	for i := 0; i < fetches; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := fetch(shipper.Chart{Name: testFetchChartName, Version: testFetchChartVersion}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// Let the first fetches reach the stub, then open the gate and drain.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if maxInFlight > limit {
+		t.Fatalf("expected at most %d fetches in flight, observed %d", limit, maxInFlight)
+	}
+}
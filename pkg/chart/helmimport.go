@@ -0,0 +1,96 @@
+package chart
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/helm/pkg/chartutil"
+	hapirelease "k8s.io/helm/pkg/proto/hapi/release"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// helmStorageGzipMagic is the gzip magic number Helm's storage driver checks
+// for: release records written by recent Tiller versions are compressed,
+// older ones are plain protobuf.
+var helmStorageGzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// DecodeInstalledHelmRelease decodes a Helm release record as Tiller stores
+// it: the base64 encoded, optionally gzipped, protobuf payload found under
+// the "release" key of the release's storage ConfigMap or Secret.
+func DecodeInstalledHelmRelease(data string) (*hapirelease.Release, error) {
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode Helm release record: %s", err)
+	}
+
+	if len(b) > 3 && bytes.Equal(b[0:3], helmStorageGzipMagic) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress Helm release record: %s", err)
+		}
+		defer r.Close()
+		b, err = ioutil.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress Helm release record: %s", err)
+		}
+	}
+
+	var rls hapirelease.Release
+	if err := proto.Unmarshal(b, &rls); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal Helm release record: %s", err)
+	}
+
+	return &rls, nil
+}
+
+// ApplicationForHelmRelease builds the shipper Application that takes over an
+// existing Helm release: the template records the chart name, version and
+// override values from the release's metadata, and repoURL names the chart
+// repo to fetch it from, since Helm does not record where a chart came from.
+//
+// The Application carries the adopt-existing-objects annotation so that its
+// first shipper release takes ownership of the objects Helm deployed instead
+// of refusing to touch them. Cluster requirements and a rollout strategy are
+// shipper concepts with no Helm counterpart: the caller fills those in before
+// submitting the Application.
+func ApplicationForHelmRelease(rls *hapirelease.Release, repoURL string) (*shipper.Application, error) {
+	if rls.Chart == nil || rls.Chart.Metadata == nil {
+		return nil, fmt.Errorf("helm release %q carries no chart metadata", rls.Name)
+	}
+
+	var values *shipper.ChartValues
+	if rls.Config != nil && rls.Config.Raw != "" {
+		parsed, err := chartutil.ReadValues([]byte(rls.Config.Raw))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse values of helm release %q: %s", rls.Name, err)
+		}
+		chartValues := shipper.ChartValues(parsed)
+		values = &chartValues
+	}
+
+	return &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rls.Name,
+			Namespace: rls.Namespace,
+			Annotations: map[string]string{
+				shipper.InstallationAdoptExistingObjectsAnnotation: shipper.True,
+			},
+		},
+		Spec: shipper.ApplicationSpec{
+			Template: shipper.ReleaseEnvironment{
+				Chart: shipper.Chart{
+					Name:    rls.Chart.Metadata.Name,
+					Version: rls.Chart.Metadata.Version,
+					RepoURL: repoURL,
+				},
+				Values: values,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,97 @@
+package chart
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	hapichart "k8s.io/helm/pkg/proto/hapi/chart"
+	hapirelease "k8s.io/helm/pkg/proto/hapi/release"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// encodeHelmRelease mirrors Tiller's storage encoding: gzipped protobuf,
+// base64 encoded.
+func encodeHelmRelease(t *testing.T, rls *hapirelease.Release) string {
+	t.Helper()
+
+	b, err := proto.Marshal(rls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestImportHelmRelease(t *testing.T) {
+	helmRelease := &hapirelease.Release{
+		Name:      "reviews-api",
+		Namespace: "service",
+		Chart: &hapichart.Chart{
+			Metadata: &hapichart.Metadata{
+				Name:    "reviews-api",
+				Version: "0.0.1",
+			},
+		},
+		Config: &hapichart.Config{
+			Raw: "replicaCount: 3\nimage:\n  tag: latest\n",
+		},
+	}
+
+	decoded, err := DecodeInstalledHelmRelease(encodeHelmRelease(t, helmRelease))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repoURL := "https://charts.example.com"
+	app, err := ApplicationForHelmRelease(decoded, repoURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if app.Name != "reviews-api" || app.Namespace != "service" {
+		t.Errorf("expected application service/reviews-api, got %s/%s", app.Namespace, app.Name)
+	}
+
+	if app.Annotations[shipper.InstallationAdoptExistingObjectsAnnotation] != shipper.True {
+		t.Error("expected the imported application to opt into adopting existing objects")
+	}
+
+	expectedChart := shipper.Chart{
+		Name:    "reviews-api",
+		Version: "0.0.1",
+		RepoURL: repoURL,
+	}
+	if app.Spec.Template.Chart != expectedChart {
+		t.Errorf("expected chart %v, got %v", expectedChart, app.Spec.Template.Chart)
+	}
+
+	expectedValues := shipper.ChartValues{
+		"replicaCount": float64(3),
+		"image": map[string]interface{}{
+			"tag": "latest",
+		},
+	}
+	if app.Spec.Template.Values == nil || !reflect.DeepEqual(*app.Spec.Template.Values, expectedValues) {
+		t.Errorf("expected values %v, got %v", expectedValues, app.Spec.Template.Values)
+	}
+}
+
+func TestImportHelmReleaseWithoutChartMetadata(t *testing.T) {
+	helmRelease := &hapirelease.Release{Name: "reviews-api", Namespace: "service"}
+
+	if _, err := ApplicationForHelmRelease(helmRelease, "https://charts.example.com"); err == nil {
+		t.Error("expected an error importing a helm release without chart metadata")
+	}
+}
@@ -0,0 +1,113 @@
+package chart
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+	"k8s.io/helm/pkg/repo"
+
+	"github.com/bookingcom/shipper/pkg/metrics/instrumentedclient"
+)
+
+// IndexFetcher downloads chart repository indexes. It remembers the ETag and
+// Last-Modified headers from the previous fetch of each repository and issues
+// conditional GETs, so an unchanged index.yaml costs a 304 round trip instead
+// of a full download and re-parse. This matters for large repositories when
+// controllers refresh frequently.
+type IndexFetcher struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	indexes map[string]*cachedIndex
+}
+
+type cachedIndex struct {
+	etag         string
+	lastModified string
+	index        *repo.IndexFile
+}
+
+// NewIndexFetcher returns an IndexFetcher with an empty cache.
+func NewIndexFetcher() *IndexFetcher {
+	return &IndexFetcher{
+		client:  instrumentedclient.NewClient(),
+		indexes: make(map[string]*cachedIndex),
+	}
+}
+
+// RefreshIndex returns the index of the given chart repository, downloading
+// and re-parsing it only if it changed since the last call for the same
+// repository.
+func (f *IndexFetcher) RefreshIndex(repoURL string) (*repo.IndexFile, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = fmt.Sprintf("%s/index.yaml", u.Path)
+
+	f.mu.Lock()
+	cached := f.indexes[repoURL]
+	f.mu.Unlock()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	glog.V(10).Infof("trying to fetch %s", u)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err = resp.Body.Close()
+		if err != nil {
+			glog.V(2).Infof("error closing resp.Body from chart repo: %s", err)
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		glog.V(8).Infof("index %s unchanged, using cached copy", u)
+		return cached.index, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %d", u, resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &repo.IndexFile{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("parse %s: %s", u, err)
+	}
+	index.SortEntries()
+
+	f.mu.Lock()
+	f.indexes[repoURL] = &cachedIndex{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		index:        index,
+	}
+	f.mu.Unlock()
+
+	return index, nil
+}
@@ -0,0 +1,114 @@
+package chart
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const indexYaml = `
+apiVersion: v1
+entries:
+  reviews-api:
+    - name: reviews-api
+      version: 0.0.1
+      urls:
+        - https://charts.example.com/reviews-api-0.0.1.tgz
+`
+
+const updatedIndexYaml = `
+apiVersion: v1
+entries:
+  reviews-api:
+    - name: reviews-api
+      version: 0.0.1
+      urls:
+        - https://charts.example.com/reviews-api-0.0.1.tgz
+    - name: reviews-api
+      version: 0.0.2
+      urls:
+        - https://charts.example.com/reviews-api-0.0.2.tgz
+`
+
+func TestRefreshIndexNotModified(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			t.Errorf("unexpected request path %q", r.URL.Path)
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(indexYaml))
+	}))
+	defer server.Close()
+
+	fetcher := NewIndexFetcher()
+
+	index, err := fetcher.RefreshIndex(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !index.Has("reviews-api", "0.0.1") {
+		t.Fatal("expected index to contain reviews-api 0.0.1")
+	}
+
+	refreshed, err := fetcher.RefreshIndex(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if downloads != 1 {
+		t.Errorf("expected the index to be downloaded once, got %d downloads", downloads)
+	}
+
+	if refreshed != index {
+		t.Error("expected a 304 to return the cached index without re-parsing")
+	}
+}
+
+func TestRefreshIndexModified(t *testing.T) {
+	etag := `"v1"`
+	body := indexYaml
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	fetcher := NewIndexFetcher()
+
+	index, err := fetcher.RefreshIndex(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if index.Has("reviews-api", "0.0.2") {
+		t.Fatal("the first index should not contain reviews-api 0.0.2 yet")
+	}
+
+	// The repository publishes a new chart version, invalidating the old
+	// ETag.
+	etag = `"v2"`
+	body = updatedIndexYaml
+
+	refreshed, err := fetcher.RefreshIndex(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !refreshed.Has("reviews-api", "0.0.2") {
+		t.Fatal("expected the refreshed index to contain reviews-api 0.0.2")
+	}
+}
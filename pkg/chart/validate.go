@@ -0,0 +1,126 @@
+package chart
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/any"
+	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
+
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// ValidateArchive checks that data is a complete gzipped tar archive
+// containing a Chart.yaml. Repositories occasionally serve corrupt or
+// truncated .tgz files; rejecting them here with a precise error beats the
+// opaque failure they would otherwise cause during rendering.
+func ValidateArchive(data []byte) error {
+	gzr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return shippererrors.NewInvalidChartError(
+			fmt.Sprintf("corrupt chart archive: %s", err))
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	sawChartYaml := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return shippererrors.NewInvalidChartError(
+				fmt.Sprintf("corrupt chart archive: %s", err))
+		}
+
+		// Chart.yaml sits directly under the chart's top-level
+		// directory, e.g. my-complex-app/Chart.yaml.
+		parts := strings.Split(strings.Trim(hdr.Name, "/"), "/")
+		if len(parts) == 2 && parts[1] == "Chart.yaml" {
+			sawChartYaml = true
+		}
+	}
+
+	if !sawChartYaml {
+		return shippererrors.NewInvalidChartError(
+			"corrupt chart archive: no Chart.yaml in archive")
+	}
+
+	return nil
+}
+
+// ContentDigest returns a stable sha256 digest over a parsed chart's content:
+// its metadata identity, values, templates and bundled files, including those
+// of its dependencies. Two charts serving the same name and version but
+// different bytes produce different digests, which is what lets a release pin
+// exactly the chart it was created with.
+func ContentDigest(chart *helmchart.Chart) string {
+	h := sha256.New()
+	hashChartContent(h, chart)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func hashChartContent(h io.Writer, chart *helmchart.Chart) {
+	if chart.Metadata != nil {
+		fmt.Fprintf(h, "metadata:%s:%s\n", chart.Metadata.Name, chart.Metadata.Version)
+	}
+
+	if chart.Values != nil {
+		fmt.Fprintf(h, "values:%s\n", chart.Values.Raw)
+	}
+
+	templates := append([]*helmchart.Template(nil), chart.Templates...)
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	for _, template := range templates {
+		fmt.Fprintf(h, "template:%s:", template.Name)
+		h.Write(template.Data)
+		io.WriteString(h, "\n")
+	}
+
+	files := append([]*any.Any(nil), chart.Files...)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].TypeUrl < files[j].TypeUrl
+	})
+	for _, file := range files {
+		fmt.Fprintf(h, "file:%s:", file.TypeUrl)
+		h.Write(file.Value)
+		io.WriteString(h, "\n")
+	}
+
+	dependencies := append([]*helmchart.Chart(nil), chart.Dependencies...)
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].GetMetadata().GetName() < dependencies[j].GetMetadata().GetName()
+	})
+	for _, dependency := range dependencies {
+		io.WriteString(h, "dependency:\n")
+		hashChartContent(h, dependency)
+	}
+}
+
+// VerifyDigest checks data against the digest the repository index declares
+// for it. An empty declaredDigest means the index doesn't declare one, in
+// which case there is nothing to verify.
+func VerifyDigest(data []byte, declaredDigest string) error {
+	if declaredDigest == "" {
+		return nil
+	}
+
+	declared := strings.TrimPrefix(strings.ToLower(declaredDigest), "sha256:")
+	actual := fmt.Sprintf("%x", sha256.Sum256(data))
+	if actual != declared {
+		return shippererrors.NewInvalidChartError(fmt.Sprintf(
+			"corrupt chart archive (digest mismatch): index declares sha256 %s, archive has %s",
+			declared, actual))
+	}
+
+	return nil
+}
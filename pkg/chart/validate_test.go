@@ -0,0 +1,116 @@
+package chart
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
+
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+func loadTestArchive(t *testing.T) []byte {
+	filename := fmt.Sprintf("%s-%s.tgz", testFetchChartName, testFetchChartVersion)
+	data, err := ioutil.ReadFile(filepath.Join("testdata", filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestValidateArchiveAcceptsCompleteChart(t *testing.T) {
+	if err := ValidateArchive(loadTestArchive(t)); err != nil {
+		t.Errorf("expected a complete chart archive to validate, got: %s", err)
+	}
+}
+
+func TestValidateArchiveRejectsTruncatedChart(t *testing.T) {
+	data := loadTestArchive(t)
+	err := ValidateArchive(data[:len(data)/2])
+	if err == nil {
+		t.Fatal("expected a truncated chart archive to fail validation")
+	}
+
+	if !shippererrors.IsInvalidChartError(err) {
+		t.Errorf("expected an InvalidChartError, got %T: %s", err, err)
+	}
+
+	if !strings.Contains(err.Error(), "corrupt chart archive") {
+		t.Errorf("expected a corrupt chart archive error, got: %s", err)
+	}
+}
+
+func TestValidateArchiveRejectsNonGzipData(t *testing.T) {
+	err := ValidateArchive([]byte("this is not a gzip tar archive"))
+	if err == nil {
+		t.Fatal("expected garbage data to fail validation")
+	}
+
+	if !shippererrors.IsInvalidChartError(err) {
+		t.Errorf("expected an InvalidChartError, got %T: %s", err, err)
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	data := loadTestArchive(t)
+	digest := fmt.Sprintf("%x", sha256.Sum256(data))
+
+	if err := VerifyDigest(data, digest); err != nil {
+		t.Errorf("expected a matching digest to verify, got: %s", err)
+	}
+
+	if err := VerifyDigest(data, "sha256:"+digest); err != nil {
+		t.Errorf("expected a prefixed matching digest to verify, got: %s", err)
+	}
+
+	// An index without a digest has nothing to verify against.
+	if err := VerifyDigest(data, ""); err != nil {
+		t.Errorf("expected an empty declared digest to verify, got: %s", err)
+	}
+}
+
+func TestVerifyDigestMismatch(t *testing.T) {
+	data := loadTestArchive(t)
+	err := VerifyDigest(data, strings.Repeat("0", 64))
+	if err == nil {
+		t.Fatal("expected a mismatched digest to fail verification")
+	}
+
+	if !shippererrors.IsInvalidChartError(err) {
+		t.Errorf("expected an InvalidChartError, got %T: %s", err, err)
+	}
+
+	if !strings.Contains(err.Error(), "digest mismatch") {
+		t.Errorf("expected a digest mismatch error, got: %s", err)
+	}
+}
+
+func buildDigestChart(templateData string) *helmchart.Chart {
+	return &helmchart.Chart{
+		Metadata: &helmchart.Metadata{Name: "reviews-api", Version: "0.0.1"},
+		Values:   &helmchart.Config{Raw: "replicaCount: 1\n"},
+		Templates: []*helmchart.Template{
+			{Name: "templates/deployment.yaml", Data: []byte(templateData)},
+		},
+	}
+}
+
+// TestContentDigest checks that the content digest is stable for identical
+// charts and changes when a repository republishes the same name and version
+// with different bytes.
+func TestContentDigest(t *testing.T) {
+	original := buildDigestChart("kind: Deployment\n")
+	republished := buildDigestChart("kind: Deployment\nspec: {}\n")
+
+	if ContentDigest(original) != ContentDigest(buildDigestChart("kind: Deployment\n")) {
+		t.Error("expected identical charts to produce identical digests")
+	}
+
+	if ContentDigest(original) == ContentDigest(republished) {
+		t.Error("expected different chart bytes under the same version to produce different digests")
+	}
+}
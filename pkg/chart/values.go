@@ -0,0 +1,236 @@
+package chart
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/helm/pkg/chartutil"
+	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// EnvironmentValues resolves the effective values for rendering env's chart:
+// the in-chart values file env names, if any, merged beneath env's explicit
+// Values with the MergeValues semantics. With no values file named it returns
+// env.Values untouched, and with one named that the chart doesn't contain it
+// returns an error, so a typo'd file name fails the rollout instead of
+// silently dropping the environment's defaults.
+func EnvironmentValues(chart *helmchart.Chart, env *shipper.ReleaseEnvironment) (*shipper.ChartValues, error) {
+	if env.ValuesFile == "" {
+		return env.Values, nil
+	}
+
+	fileValues, err := FileValues(chart, env.ValuesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var override map[string]interface{}
+	if env.Values != nil {
+		override = *env.Values
+	}
+
+	merged := shipper.ChartValues(MergeValues(fileValues, override))
+
+	return &merged, nil
+}
+
+// FileValues returns the parsed contents of the named values file shipped
+// inside the chart, like "values-prod.yaml". The chart's own values.yaml is
+// not addressable this way: it already participates in rendering as the
+// chart's defaults.
+func FileValues(chart *helmchart.Chart, filename string) (map[string]interface{}, error) {
+	for _, f := range chart.Files {
+		if f.TypeUrl != filename {
+			continue
+		}
+
+		values, err := chartutil.ReadValues(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse values file %q in chart %q: %s", filename, chart.Metadata.Name, err)
+		}
+
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("chart %q contains no values file named %q", chart.Metadata.Name, filename)
+}
+
+// MergeValues deep-merges override into base and returns the result. Neither
+// input is mutated.
+//
+// Precedence is strictly override-wins:
+//
+//   - a key only in base is kept;
+//   - a key only in override is added;
+//   - if both values are maps they are merged recursively;
+//   - otherwise the override value replaces the base value wholesale,
+//     including a map being replaced by a scalar or vice versa;
+//   - an explicit null in override deletes the key from the result, which is
+//     the only way to remove a key a lower-priority source provides.
+//
+// These are the semantics for every place chart values are combined: overlay
+// files, value sources, and per-release overrides.
+func MergeValues(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, v := range override {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+
+		overrideMap, overrideOk := v.(map[string]interface{})
+		baseMap, baseOk := merged[k].(map[string]interface{})
+		if overrideOk && baseOk {
+			merged[k] = MergeValues(baseMap, overrideMap)
+			continue
+		}
+
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// CoerceStringValues returns a copy of values with stringy scalars converted
+// to the type the schema expects for the same key: "4" becomes the int 4 when
+// the schema value is an integer, "true"/"false" become bools when it is a
+// bool. Values whose key the schema doesn't know, whose string doesn't parse
+// cleanly as the expected type, or whose schema value is itself a string
+// (such as a "1.0" version) are left untouched. Nested maps are coerced
+// recursively. Neither input is mutated.
+//
+// The schema is typically the chart's own default values: values arriving
+// from ConfigMaps are always strings, and this recovers the type the chart's
+// templates assert on.
+func CoerceStringValues(values, schema map[string]interface{}) map[string]interface{} {
+	coerced := make(map[string]interface{}, len(values))
+
+	for k, v := range values {
+		expected, known := schema[k]
+		if !known {
+			coerced[k] = v
+			continue
+		}
+
+		valueMap, valueOk := v.(map[string]interface{})
+		schemaMap, schemaOk := expected.(map[string]interface{})
+		if valueOk && schemaOk {
+			coerced[k] = CoerceStringValues(valueMap, schemaMap)
+			continue
+		}
+
+		str, ok := v.(string)
+		if !ok {
+			coerced[k] = v
+			continue
+		}
+
+		coerced[k] = coerceString(str, expected)
+	}
+
+	return coerced
+}
+
+// coerceString converts str to the type of expected if it parses cleanly,
+// and returns it unchanged otherwise.
+func coerceString(str string, expected interface{}) interface{} {
+	switch expected.(type) {
+	case int, int32, int64:
+		if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return int(n)
+		}
+	case float64, float32:
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return f
+		}
+	case bool:
+		if str == "true" || str == "false" {
+			return str == "true"
+		}
+	}
+
+	return str
+}
+
+// ApplyValuesPatches applies JSON-patch-style edits to the merged values
+// document and returns the result; values is not mutated. "add" sets the
+// value at a /-separated path whose parent maps must already exist,
+// "replace" requires the full path to exist, and "remove" deletes an
+// existing value. Any violation returns an error naming the offending
+// operation, so a typo'd path fails the rollout instead of silently
+// rendering without the edit.
+func ApplyValuesPatches(values map[string]interface{}, patches []shipper.ValuesPatch) (map[string]interface{}, error) {
+	patched := MergeValues(values, nil)
+
+	for _, patch := range patches {
+		if !strings.HasPrefix(patch.Path, "/") {
+			return nil, fmt.Errorf("values patch %s %q: path must start with %q", patch.Op, patch.Path, "/")
+		}
+
+		segments := strings.Split(strings.TrimPrefix(patch.Path, "/"), "/")
+		for _, segment := range segments {
+			if segment == "" {
+				return nil, fmt.Errorf("values patch %s %q: path has an empty segment", patch.Op, patch.Path)
+			}
+		}
+
+		// Maps are shared with the input document, so copy each one on
+		// the way down before touching it.
+		parent := patched
+		for _, segment := range segments[:len(segments)-1] {
+			child, ok := parent[segment].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("values patch %s %q: %q is not a map in the values document", patch.Op, patch.Path, segment)
+			}
+
+			copied := make(map[string]interface{}, len(child))
+			for k, v := range child {
+				copied[k] = v
+			}
+			parent[segment] = copied
+			parent = copied
+		}
+
+		key := segments[len(segments)-1]
+		_, exists := parent[key]
+
+		switch patch.Op {
+		case "add":
+			parent[key] = parseValuesPatchValue(patch.Value)
+		case "replace":
+			if !exists {
+				return nil, fmt.Errorf("values patch replace %q: the values document has no such value", patch.Path)
+			}
+			parent[key] = parseValuesPatchValue(patch.Value)
+		case "remove":
+			if !exists {
+				return nil, fmt.Errorf("values patch remove %q: the values document has no such value", patch.Path)
+			}
+			delete(parent, key)
+		default:
+			return nil, fmt.Errorf("values patch %q: unknown op %q, want add, replace, or remove", patch.Path, patch.Op)
+		}
+	}
+
+	return patched, nil
+}
+
+// parseValuesPatchValue interprets a patch's value: anything that parses as
+// JSON ("5", "true", "[1,2]") is used as the parsed value, everything else
+// as a plain string ("512Mi").
+func parseValuesPatchValue(raw string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(raw), &v); err == nil {
+		return v
+	}
+
+	return raw
+}
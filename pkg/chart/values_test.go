@@ -0,0 +1,203 @@
+package chart
+
+import (
+	"reflect"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestMergeValuesNullDeletesKey(t *testing.T) {
+	base := map[string]interface{}{
+		"replicaCount": 3,
+		"nodeSelector": map[string]interface{}{
+			"worker-pool": "default",
+		},
+	}
+
+	override := map[string]interface{}{
+		"nodeSelector": nil,
+	}
+
+	expected := map[string]interface{}{
+		"replicaCount": 3,
+	}
+
+	merged := MergeValues(base, override)
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+
+	if _, ok := base["nodeSelector"]; !ok {
+		t.Error("MergeValues mutated the base map")
+	}
+}
+
+func TestMergeValuesDeepMergesNestedMaps(t *testing.T) {
+	base := map[string]interface{}{
+		"image": map[string]interface{}{
+			"repository": "registry.example.com/reviews-api",
+			"tag":        "0.0.1",
+			"pullPolicy": "IfNotPresent",
+		},
+	}
+
+	override := map[string]interface{}{
+		"image": map[string]interface{}{
+			"tag":        "0.0.2",
+			"pullPolicy": nil,
+		},
+	}
+
+	expected := map[string]interface{}{
+		"image": map[string]interface{}{
+			"repository": "registry.example.com/reviews-api",
+			"tag":        "0.0.2",
+		},
+	}
+
+	merged := MergeValues(base, override)
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+}
+
+func TestMergeValuesScalarReplacesMap(t *testing.T) {
+	base := map[string]interface{}{
+		"resources": map[string]interface{}{
+			"limits": map[string]interface{}{"cpu": "1"},
+		},
+	}
+
+	override := map[string]interface{}{
+		"resources": "unconstrained",
+	}
+
+	expected := map[string]interface{}{
+		"resources": "unconstrained",
+	}
+
+	merged := MergeValues(base, override)
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+}
+
+func TestCoerceStringValuesRecoversTypes(t *testing.T) {
+	schema := map[string]interface{}{
+		"replicaCount": 4,
+		"image": map[string]interface{}{
+			"tag":        "1.0",
+			"pullPolicy": "IfNotPresent",
+		},
+		"enableProbes": true,
+	}
+
+	values := map[string]interface{}{
+		"replicaCount": "4",
+		"image": map[string]interface{}{
+			"tag": "1.0",
+		},
+		"enableProbes": "true",
+		"unknownKey":   "7",
+	}
+
+	expected := map[string]interface{}{
+		"replicaCount": 4,
+		"image": map[string]interface{}{
+			"tag": "1.0",
+		},
+		"enableProbes": true,
+		"unknownKey":   "7",
+	}
+
+	coerced := CoerceStringValues(values, schema)
+	if !reflect.DeepEqual(coerced, expected) {
+		t.Errorf("expected %v, got %v", expected, coerced)
+	}
+
+	if v, _ := values["replicaCount"]; v != "4" {
+		t.Error("CoerceStringValues mutated the input values")
+	}
+}
+
+func TestCoerceStringValuesLeavesUnparsableStrings(t *testing.T) {
+	schema := map[string]interface{}{
+		"replicaCount": 4,
+		"enableProbes": true,
+	}
+
+	values := map[string]interface{}{
+		"replicaCount": "1.0",
+		"enableProbes": "yes",
+	}
+
+	expected := map[string]interface{}{
+		"replicaCount": "1.0",
+		"enableProbes": "yes",
+	}
+
+	coerced := CoerceStringValues(values, schema)
+	if !reflect.DeepEqual(coerced, expected) {
+		t.Errorf("expected %v, got %v", expected, coerced)
+	}
+}
+
+// TestApplyValuesPatches applies an add and a replace against a nested
+// document and checks the input is left untouched, while a replace of a
+// missing path and a path through a scalar are rejected.
+func TestApplyValuesPatches(t *testing.T) {
+	values := map[string]interface{}{
+		"replicaCount": 1,
+		"resources": map[string]interface{}{
+			"limits": map[string]interface{}{
+				"cpu": "100m",
+			},
+		},
+	}
+
+	patched, err := ApplyValuesPatches(values, []shipper.ValuesPatch{
+		{Op: "add", Path: "/resources/limits/memory", Value: "512Mi"},
+		{Op: "replace", Path: "/replicaCount", Value: "5"},
+	})
+	if err != nil {
+		t.Fatalf("patches should apply cleanly: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"replicaCount": float64(5),
+		"resources": map[string]interface{}{
+			"limits": map[string]interface{}{
+				"cpu":    "100m",
+				"memory": "512Mi",
+			},
+		},
+	}
+	if !reflect.DeepEqual(patched, expected) {
+		t.Errorf("expected %v, got %v", expected, patched)
+	}
+
+	untouched := map[string]interface{}{
+		"replicaCount": 1,
+		"resources": map[string]interface{}{
+			"limits": map[string]interface{}{
+				"cpu": "100m",
+			},
+		},
+	}
+	if !reflect.DeepEqual(values, untouched) {
+		t.Errorf("the input document was mutated: %v", values)
+	}
+
+	if _, err := ApplyValuesPatches(values, []shipper.ValuesPatch{
+		{Op: "replace", Path: "/resources/requests/cpu", Value: "50m"},
+	}); err == nil {
+		t.Error("expected an error replacing a value that doesn't exist")
+	}
+
+	if _, err := ApplyValuesPatches(values, []shipper.ValuesPatch{
+		{Op: "add", Path: "/replicaCount/max", Value: "10"},
+	}); err == nil {
+		t.Error("expected an error patching through a scalar")
+	}
+}
@@ -0,0 +1,64 @@
+package chart
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// WarmIndexes concurrently refreshes the indexes of the given chart
+// repositories so that the first reconcile touching each repo finds a warm
+// cache instead of paying the download latency one repo at a time. At most
+// concurrency refreshes run at once. Failures are logged and skipped: a
+// repository that is down at startup gets retried on first use anyway.
+func (f *IndexFetcher) WarmIndexes(repoURLs []string, concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	wg := &sync.WaitGroup{}
+	for _, repoURL := range repoURLs {
+		wg.Add(1)
+		go func(repoURL string) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if _, err := f.RefreshIndex(repoURL); err != nil {
+				glog.V(2).Infof("could not warm the index of chart repo %s: %s", repoURL, err)
+			}
+		}(repoURL)
+	}
+
+	wg.Wait()
+}
+
+// ApplicationRepoURLs gathers the distinct chart repository URLs referenced by
+// the given Applications, sorted for determinism. Applications that rely on
+// the default repo fallback name no repo and are skipped.
+func ApplicationRepoURLs(apps []*shipper.Application) []string {
+	seen := map[string]struct{}{}
+	repoURLs := make([]string, 0, len(apps))
+	for _, app := range apps {
+		repoURL := app.Spec.Template.Chart.RepoURL
+		if repoURL == "" {
+			continue
+		}
+
+		if _, ok := seen[repoURL]; ok {
+			continue
+		}
+
+		seen[repoURL] = struct{}{}
+		repoURLs = append(repoURLs, repoURL)
+	}
+
+	sort.Strings(repoURLs)
+
+	return repoURLs
+}
@@ -0,0 +1,84 @@
+package chart
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func buildApplicationWithRepo(repoURL string) *shipper.Application {
+	return &shipper.Application{
+		Spec: shipper.ApplicationSpec{
+			Template: shipper.ReleaseEnvironment{
+				Chart: shipper.Chart{
+					Name:    "reviews-api",
+					Version: "0.0.1",
+					RepoURL: repoURL,
+				},
+			},
+		},
+	}
+}
+
+// TestWarmIndexesRefreshesAllReferencedRepos gathers repos from a set of
+// Applications and checks that warming downloads every distinct referenced
+// index exactly once.
+func TestWarmIndexesRefreshesAllReferencedRepos(t *testing.T) {
+	var downloadsA, downloadsB int64
+
+	serverA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&downloadsA, 1)
+		w.Write([]byte(indexYaml))
+	}))
+	defer serverA.Close()
+
+	serverB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&downloadsB, 1)
+		w.Write([]byte(indexYaml))
+	}))
+	defer serverB.Close()
+
+	apps := []*shipper.Application{
+		buildApplicationWithRepo(serverA.URL),
+		buildApplicationWithRepo(serverB.URL),
+		// A second application on the same repo must not cause a second
+		// download, and one relying on the default repo fallback names
+		// no repo at all.
+		buildApplicationWithRepo(serverA.URL),
+		buildApplicationWithRepo(""),
+	}
+
+	repoURLs := ApplicationRepoURLs(apps)
+	if len(repoURLs) != 2 {
+		t.Fatalf("expected 2 distinct repo URLs, got %v", repoURLs)
+	}
+
+	fetcher := NewIndexFetcher()
+	fetcher.WarmIndexes(repoURLs, 2)
+
+	if downloadsA != 1 || downloadsB != 1 {
+		t.Errorf("expected each index to be downloaded once, got %d and %d", downloadsA, downloadsB)
+	}
+}
+
+// TestWarmIndexesToleratesBrokenRepos checks that one unreachable repository
+// doesn't keep the others from being warmed.
+func TestWarmIndexesToleratesBrokenRepos(t *testing.T) {
+	var downloads int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&downloads, 1)
+		w.Write([]byte(indexYaml))
+	}))
+	defer server.Close()
+
+	fetcher := NewIndexFetcher()
+	fetcher.WarmIndexes([]string{"http://127.0.0.1:1", server.URL}, 1)
+
+	if downloads != 1 {
+		t.Errorf("expected the healthy index to be downloaded once, got %d", downloads)
+	}
+}
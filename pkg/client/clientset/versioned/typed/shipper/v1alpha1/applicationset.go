@@ -0,0 +1,172 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	scheme "github.com/bookingcom/shipper/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ApplicationSetsGetter has a method to return a ApplicationSetInterface.
+// A group's client should implement this interface.
+type ApplicationSetsGetter interface {
+	ApplicationSets(namespace string) ApplicationSetInterface
+}
+
+// ApplicationSetInterface has methods to work with ApplicationSet resources.
+type ApplicationSetInterface interface {
+	Create(*v1alpha1.ApplicationSet) (*v1alpha1.ApplicationSet, error)
+	Update(*v1alpha1.ApplicationSet) (*v1alpha1.ApplicationSet, error)
+	UpdateStatus(*v1alpha1.ApplicationSet) (*v1alpha1.ApplicationSet, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1alpha1.ApplicationSet, error)
+	List(opts v1.ListOptions) (*v1alpha1.ApplicationSetList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.ApplicationSet, err error)
+	ApplicationSetExpansion
+}
+
+// applicationSets implements ApplicationSetInterface
+type applicationSets struct {
+	client rest.Interface
+	ns     string
+}
+
+// newApplicationSets returns a ApplicationSets
+func newApplicationSets(c *ShipperV1alpha1Client, namespace string) *applicationSets {
+	return &applicationSets{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the applicationSet, and returns the corresponding applicationSet object, and an error if there is any.
+func (c *applicationSets) Get(name string, options v1.GetOptions) (result *v1alpha1.ApplicationSet, err error) {
+	result = &v1alpha1.ApplicationSet{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("applicationsets").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ApplicationSets that match those selectors.
+func (c *applicationSets) List(opts v1.ListOptions) (result *v1alpha1.ApplicationSetList, err error) {
+	result = &v1alpha1.ApplicationSetList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("applicationsets").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested applicationSets.
+func (c *applicationSets) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("applicationsets").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a applicationSet and creates it.  Returns the server's representation of the applicationSet, and an error, if there is any.
+func (c *applicationSets) Create(applicationSet *v1alpha1.ApplicationSet) (result *v1alpha1.ApplicationSet, err error) {
+	result = &v1alpha1.ApplicationSet{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("applicationsets").
+		Body(applicationSet).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a applicationSet and updates it. Returns the server's representation of the applicationSet, and an error, if there is any.
+func (c *applicationSets) Update(applicationSet *v1alpha1.ApplicationSet) (result *v1alpha1.ApplicationSet, err error) {
+	result = &v1alpha1.ApplicationSet{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("applicationsets").
+		Name(applicationSet.Name).
+		Body(applicationSet).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *applicationSets) UpdateStatus(applicationSet *v1alpha1.ApplicationSet) (result *v1alpha1.ApplicationSet, err error) {
+	result = &v1alpha1.ApplicationSet{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("applicationsets").
+		Name(applicationSet.Name).
+		SubResource("status").
+		Body(applicationSet).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the applicationSet and deletes it. Returns an error if one occurs.
+func (c *applicationSets) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("applicationsets").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *applicationSets) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("applicationsets").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched applicationSet.
+func (c *applicationSets) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.ApplicationSet, err error) {
+	result = &v1alpha1.ApplicationSet{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("applicationsets").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -26,6 +26,10 @@ type FakeShipperV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeShipperV1alpha1) ApplicationSets(namespace string) v1alpha1.ApplicationSetInterface {
+	return &FakeApplicationSets{c, namespace}
+}
+
 func (c *FakeShipperV1alpha1) Applications(namespace string) v1alpha1.ApplicationInterface {
 	return &FakeApplications{c, namespace}
 }
@@ -46,6 +50,10 @@ func (c *FakeShipperV1alpha1) Releases(namespace string) v1alpha1.ReleaseInterfa
 	return &FakeReleases{c, namespace}
 }
 
+func (c *FakeShipperV1alpha1) Strategies(namespace string) v1alpha1.StrategyInterface {
+	return &FakeStrategies{c, namespace}
+}
+
 func (c *FakeShipperV1alpha1) TrafficTargets(namespace string) v1alpha1.TrafficTargetInterface {
 	return &FakeTrafficTargets{c, namespace}
 }
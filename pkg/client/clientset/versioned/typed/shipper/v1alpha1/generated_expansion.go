@@ -18,6 +18,8 @@ package v1alpha1
 
 type ApplicationExpansion interface{}
 
+type ApplicationSetExpansion interface{}
+
 type CapacityTargetExpansion interface{}
 
 type ClusterExpansion interface{}
@@ -26,4 +28,6 @@ type InstallationTargetExpansion interface{}
 
 type ReleaseExpansion interface{}
 
+type StrategyExpansion interface{}
+
 type TrafficTargetExpansion interface{}
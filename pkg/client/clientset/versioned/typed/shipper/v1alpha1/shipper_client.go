@@ -25,11 +25,13 @@ import (
 
 type ShipperV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	ApplicationSetsGetter
 	ApplicationsGetter
 	CapacityTargetsGetter
 	ClustersGetter
 	InstallationTargetsGetter
 	ReleasesGetter
+	StrategiesGetter
 	TrafficTargetsGetter
 }
 
@@ -38,6 +40,10 @@ type ShipperV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *ShipperV1alpha1Client) ApplicationSets(namespace string) ApplicationSetInterface {
+	return newApplicationSets(c, namespace)
+}
+
 func (c *ShipperV1alpha1Client) Applications(namespace string) ApplicationInterface {
 	return newApplications(c, namespace)
 }
@@ -58,6 +64,10 @@ func (c *ShipperV1alpha1Client) Releases(namespace string) ReleaseInterface {
 	return newReleases(c, namespace)
 }
 
+func (c *ShipperV1alpha1Client) Strategies(namespace string) StrategyInterface {
+	return newStrategies(c, namespace)
+}
+
 func (c *ShipperV1alpha1Client) TrafficTargets(namespace string) TrafficTargetInterface {
 	return newTrafficTargets(c, namespace)
 }
@@ -55,6 +55,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=shipper.booking.com, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("applications"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Shipper().V1alpha1().Applications().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("applicationsets"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Shipper().V1alpha1().ApplicationSets().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("capacitytargets"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Shipper().V1alpha1().CapacityTargets().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("clusters"):
@@ -63,6 +65,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Shipper().V1alpha1().InstallationTargets().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("releases"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Shipper().V1alpha1().Releases().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("strategies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Shipper().V1alpha1().Strategies().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("traffictargets"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Shipper().V1alpha1().TrafficTargets().Informer()}, nil
 
@@ -24,6 +24,8 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ApplicationSets returns a ApplicationSetInformer.
+	ApplicationSets() ApplicationSetInformer
 	// Applications returns a ApplicationInformer.
 	Applications() ApplicationInformer
 	// CapacityTargets returns a CapacityTargetInformer.
@@ -34,6 +36,8 @@ type Interface interface {
 	InstallationTargets() InstallationTargetInformer
 	// Releases returns a ReleaseInformer.
 	Releases() ReleaseInformer
+	// Strategies returns a StrategyInformer.
+	Strategies() StrategyInformer
 	// TrafficTargets returns a TrafficTargetInformer.
 	TrafficTargets() TrafficTargetInformer
 }
@@ -49,6 +53,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ApplicationSets returns a ApplicationSetInformer.
+func (v *version) ApplicationSets() ApplicationSetInformer {
+	return &applicationSetInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Applications returns a ApplicationInformer.
 func (v *version) Applications() ApplicationInformer {
 	return &applicationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -74,6 +83,11 @@ func (v *version) Releases() ReleaseInformer {
 	return &releaseInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// Strategies returns a StrategyInformer.
+func (v *version) Strategies() StrategyInformer {
+	return &strategyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // TrafficTargets returns a TrafficTargetInformer.
 func (v *version) TrafficTargets() TrafficTargetInformer {
 	return &trafficTargetInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -26,6 +26,14 @@ type ApplicationListerExpansion interface{}
 // ApplicationNamespaceLister.
 type ApplicationNamespaceListerExpansion interface{}
 
+// ApplicationSetListerExpansion allows custom methods to be added to
+// ApplicationSetLister.
+type ApplicationSetListerExpansion interface{}
+
+// ApplicationSetNamespaceListerExpansion allows custom methods to be added to
+// ApplicationSetNamespaceLister.
+type ApplicationSetNamespaceListerExpansion interface{}
+
 // CapacityTargetListerExpansion allows custom methods to be added to
 // CapacityTargetLister.
 type CapacityTargetListerExpansion interface{}
@@ -46,6 +54,14 @@ type InstallationTargetListerExpansion interface{}
 // InstallationTargetNamespaceLister.
 type InstallationTargetNamespaceListerExpansion interface{}
 
+// StrategyListerExpansion allows custom methods to be added to
+// StrategyLister.
+type StrategyListerExpansion interface{}
+
+// StrategyNamespaceListerExpansion allows custom methods to be added to
+// StrategyNamespaceLister.
+type StrategyNamespaceListerExpansion interface{}
+
 // TrafficTargetListerExpansion allows custom methods to be added to
 // TrafficTargetLister.
 type TrafficTargetListerExpansion interface{}
@@ -67,7 +67,14 @@ func (s releaseNamespaceLister) IncumbentForApplication(appName string) (*shippe
 		return nil, err
 	}
 	sort.Sort(releaseutil.ByGenerationDescending(rels))
-	return apputil.GetIncumbent(appName, rels)
+	contender, err := apputil.GetContender(appName, rels)
+	if err != nil {
+		return nil, err
+	}
+	if incumbent := apputil.SelectIncumbent(contender, rels); incumbent != nil {
+		return incumbent, nil
+	}
+	return nil, shippererrors.NewIncumbentNotFoundError(appName)
 }
 
 func (s releaseNamespaceLister) ReleaseForInstallationTarget(it *shipper.InstallationTarget) (*shipper.Release, error) {
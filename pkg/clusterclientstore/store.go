@@ -266,7 +266,7 @@ func (s *Store) create(cluster *shipper.Cluster, secret *corev1.Secret) error {
 		panic(fmt.Sprintf("Secret %q doesn't have a checksum annotation. this should be checked before calling 'create'", secret.Name))
 	}
 
-	config, err := buildConfig(cluster.Spec.APIMaster, secret, s.restTimeout)
+	config, err := buildConfig(cluster, secret, s.restTimeout)
 	if err != nil {
 		return shippererrors.NewClusterClientBuild(cluster.Name, err)
 	}
@@ -274,7 +274,7 @@ func (s *Store) create(cluster *shipper.Cluster, secret *corev1.Secret) error {
 	// These are only used in shared informers. Setting HTTP timeout here would
 	// affect watches which is undesirable. Instead, we leave it to client-go (see
 	// k8s.io/client-go/tools/cache) to govern watch durations.
-	informerConfig, err := buildConfig(cluster.Spec.APIMaster, secret, nil)
+	informerConfig, err := buildConfig(cluster, secret, nil)
 	if err != nil {
 		return shippererrors.NewClusterClientBuild(cluster.Name, err)
 	}
@@ -318,7 +318,8 @@ func (s *Store) create(cluster *shipper.Cluster, secret *corev1.Secret) error {
 
 // TODO(btyler): error here or let any invalid data get picked up by errors from
 // kube.NewForConfig or auth problems at connection time?
-func buildConfig(host string, secret *corev1.Secret, restTimeout *time.Duration) (*rest.Config, error) {
+func buildConfig(cluster *shipper.Cluster, secret *corev1.Secret, restTimeout *time.Duration) (*rest.Config, error) {
+	host := cluster.Spec.APIMaster
 	config := &rest.Config{
 		Host: host,
 	}
@@ -336,6 +337,10 @@ func buildConfig(host string, secret *corev1.Secret, restTimeout *time.Duration)
 
 		token := secret.Data["token"]
 		config.BearerToken = string(token)
+
+		if err := applyTLSVerification(config, cluster); err != nil {
+			return nil, err
+		}
 		return config, nil
 	}
 
@@ -367,5 +372,49 @@ func buildConfig(host string, secret *corev1.Secret, restTimeout *time.Duration)
 		}
 	}
 
+	if err := applyTLSVerification(config, cluster); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
+
+// applyTLSVerification applies the cluster's TLS verification mode to a config
+// whose secret-derived fields (CA, client cert, skip-verify annotation) have
+// already been filled in, and validates that the mode and the secret agree.
+func applyTLSVerification(config *rest.Config, cluster *shipper.Cluster) error {
+	mode := cluster.Spec.TLSVerification
+	switch mode {
+	case "", shipper.ClusterTLSVerificationFull:
+		if len(cluster.Spec.CABundle) > 0 {
+			return fmt.Errorf(
+				"cluster %q has a CA bundle but TLS verification mode %q; set mode to %q to use it",
+				cluster.Name, shipper.ClusterTLSVerificationFull, shipper.ClusterTLSVerificationCABundle)
+		}
+	case shipper.ClusterTLSVerificationCABundle:
+		if len(cluster.Spec.CABundle) == 0 {
+			return fmt.Errorf(
+				"cluster %q has TLS verification mode %q but an empty CA bundle",
+				cluster.Name, mode)
+		}
+		if config.Insecure {
+			return fmt.Errorf(
+				"cluster %q has TLS verification mode %q but its secret is annotated to skip TLS verification; remove one or the other",
+				cluster.Name, mode)
+		}
+		config.CAData = cluster.Spec.CABundle
+	case shipper.ClusterTLSVerificationSkipVerify:
+		if len(cluster.Spec.CABundle) > 0 {
+			return fmt.Errorf(
+				"cluster %q has a CA bundle but TLS verification mode %q; set mode to %q to use it",
+				cluster.Name, mode, shipper.ClusterTLSVerificationCABundle)
+		}
+		// client-go rejects configs with both Insecure and CA data set.
+		config.Insecure = true
+		config.CAData = nil
+	default:
+		return fmt.Errorf("cluster %q has unknown TLS verification mode %q", cluster.Name, mode)
+	}
+
+	return nil
+}
@@ -239,6 +239,128 @@ func TestConfigTimeout(t *testing.T) {
 	}
 }
 
+func TestBuildConfigTLSVerificationModes(t *testing.T) {
+	caBundle := []byte("-----BEGIN CERTIFICATE-----\nprivate-ca\n-----END CERTIFICATE-----")
+	secretCA := []byte("secret-ca")
+
+	newCluster := func(mode shipper.ClusterTLSVerificationMode, bundle []byte) *shipper.Cluster {
+		return &shipper.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: testClusterName,
+			},
+			Spec: shipper.ClusterSpec{
+				APIMaster:       testClusterHost,
+				TLSVerification: mode,
+				CABundle:        bundle,
+			},
+		}
+	}
+
+	withSecretCA := func(secret *corev1.Secret) *corev1.Secret {
+		secret.Data["tls.ca"] = secretCA
+		return secret
+	}
+
+	withSkipTlsVerify := func(secret *corev1.Secret) *corev1.Secret {
+		secret.Annotations[shipper.SecretClusterSkipTlsVerifyAnnotation] = "true"
+		return secret
+	}
+
+	tests := []struct {
+		name    string
+		cluster *shipper.Cluster
+		secret  *corev1.Secret
+
+		expectedErr      bool
+		expectedInsecure bool
+		expectedCAData   []byte
+	}{
+		{
+			name:           "default mode uses the secret's CA",
+			cluster:        newCluster("", nil),
+			secret:         withSecretCA(newValidSecret(testClusterName)),
+			expectedCAData: secretCA,
+		},
+		{
+			name:           "explicit Full mode uses the secret's CA",
+			cluster:        newCluster(shipper.ClusterTLSVerificationFull, nil),
+			secret:         withSecretCA(newValidSecret(testClusterName)),
+			expectedCAData: secretCA,
+		},
+		{
+			name:             "Full mode honors the skip-verify annotation",
+			cluster:          newCluster(shipper.ClusterTLSVerificationFull, nil),
+			secret:           withSkipTlsVerify(newValidSecret(testClusterName)),
+			expectedInsecure: true,
+		},
+		{
+			name:        "Full mode rejects a CA bundle",
+			cluster:     newCluster(shipper.ClusterTLSVerificationFull, caBundle),
+			secret:      newValidSecret(testClusterName),
+			expectedErr: true,
+		},
+		{
+			name:           "CABundle mode overrides the secret's CA",
+			cluster:        newCluster(shipper.ClusterTLSVerificationCABundle, caBundle),
+			secret:         withSecretCA(newValidSecret(testClusterName)),
+			expectedCAData: caBundle,
+		},
+		{
+			name:        "CABundle mode requires a bundle",
+			cluster:     newCluster(shipper.ClusterTLSVerificationCABundle, nil),
+			secret:      newValidSecret(testClusterName),
+			expectedErr: true,
+		},
+		{
+			name:        "CABundle mode conflicts with the skip-verify annotation",
+			cluster:     newCluster(shipper.ClusterTLSVerificationCABundle, caBundle),
+			secret:      withSkipTlsVerify(newValidSecret(testClusterName)),
+			expectedErr: true,
+		},
+		{
+			name:             "SkipVerify mode disables verification and drops the CA",
+			cluster:          newCluster(shipper.ClusterTLSVerificationSkipVerify, nil),
+			secret:           withSecretCA(newValidSecret(testClusterName)),
+			expectedInsecure: true,
+		},
+		{
+			name:        "SkipVerify mode rejects a CA bundle",
+			cluster:     newCluster(shipper.ClusterTLSVerificationSkipVerify, caBundle),
+			secret:      newValidSecret(testClusterName),
+			expectedErr: true,
+		},
+		{
+			name:        "unknown mode is rejected",
+			cluster:     newCluster("Sometimes", nil),
+			secret:      newValidSecret(testClusterName),
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := buildConfig(test.cluster, test.secret, nil)
+			if test.expectedErr {
+				if err == nil {
+					t.Fatalf("expected an error building the config, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected a config, got error: %s", err)
+			}
+
+			if config.Insecure != test.expectedInsecure {
+				t.Errorf("expected Insecure to be %t, got %t", test.expectedInsecure, config.Insecure)
+			}
+
+			if string(config.CAData) != string(test.expectedCAData) {
+				t.Errorf("expected CAData %q, got %q", test.expectedCAData, config.CAData)
+			}
+		})
+	}
+}
+
 type fixture struct {
 	t              *testing.T
 	s              *Store
@@ -0,0 +1,73 @@
+package conditions
+
+import (
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+var ClusterConditionsShouldDiscardTimestamps = false
+
+func IsClusterConditionTrue(
+	conditions []shipper.ClusterCondition,
+	typ shipper.ClusterConditionType,
+) bool {
+	for _, e := range conditions {
+		if e.Type == typ {
+			return e.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func SetClusterCondition(
+	conditions []shipper.ClusterCondition,
+	typ shipper.ClusterConditionType,
+	status corev1.ConditionStatus,
+	reason string,
+	message string,
+) []shipper.ClusterCondition {
+	conditionIndex := -1
+	for i, e := range conditions {
+		if e.Type == typ {
+			conditionIndex = i
+			break
+		}
+	}
+
+	if conditionIndex == -1 {
+		lastTransitionTime := metav1.Time{}
+		if !ClusterConditionsShouldDiscardTimestamps {
+			lastTransitionTime = metav1.NewTime(time.Now())
+		}
+		aCondition := shipper.ClusterCondition{
+			Type:               typ,
+			Status:             status,
+			LastTransitionTime: lastTransitionTime,
+			Reason:             reason,
+			Message:            message,
+		}
+		conditions = append(conditions, aCondition)
+		sort.Slice(conditions, func(i, j int) bool {
+			return conditions[i].Type < conditions[j].Type
+		})
+	} else {
+		aCondition := &conditions[conditionIndex]
+		if aCondition.Status != status {
+			if ClusterConditionsShouldDiscardTimestamps {
+				aCondition.LastTransitionTime = metav1.Time{}
+			} else {
+				aCondition.LastTransitionTime = metav1.NewTime(time.Now())
+			}
+		}
+		aCondition.Status = status
+		aCondition.Reason = reason
+		aCondition.Message = message
+	}
+
+	return conditions
+}
@@ -3,6 +3,23 @@ package conditions
 const (
 	ClustersNotOperational = "ClustersNotOperational"
 	ClustersNotReady       = "ClustersNotReady"
+
+	// WaitingForTrafficApproval marks a step whose traffic half awaits a
+	// separate operator confirmation (RolloutStrategyStep.ConfirmTraffic).
+	WaitingForTrafficApproval = "WaitingForTrafficApproval"
+
+	// WaitingForStability marks a rollout paused by its stability policy
+	// while the application's pods emit a burst of instability events.
+	WaitingForStability = "WaitingForStability"
+
+	// WaitingForCompletionGates marks a release holding at its achieved
+	// final step until every configured completion gate's condition is
+	// True.
+	WaitingForCompletionGates = "WaitingForCompletionGates"
+
+	// WaitingForFeatureFlags marks a step held back because the external
+	// feature-flag provider hasn't accepted the step's flag changes yet.
+	WaitingForFeatureFlags = "WaitingForFeatureFlags"
 )
 
 // TODO(asurikov): change NotFound to be a struct that implements error.
@@ -9,12 +9,19 @@ const (
 	TooManyDeployments = "TooManyDeployments"
 	PodsNotReady       = "PodsNotReady"
 	WrongPodCount      = "WrongPodCount"
+	WaitingForPDB      = "WaitingForPDB"
+	RampingUp          = "RampingUp"
 
 	MissingObjects = "MissingObjects"
 	InvalidObjects = "InvalidObjects"
 
 	MissingService = "MissingService"
 
+	// ClusterSuspended marks a cluster whose target spec entry carries the
+	// suspended flag: the release stays installed there but serves zero
+	// capacity and traffic until the flag is cleared.
+	ClusterSuspended = "ClusterSuspended"
+
 	UnknownError = "UnknownError"
 
 	InternalError = "InternalError"
@@ -22,10 +29,51 @@ const (
 	TargetClusterClientError = "TargetClusterClientError"
 
 	CreateReleaseFailed                 = "CreateReleaseFailed"
+	InvalidClusterRequirements          = "InvalidClusterRequirements"
 	FetchReleaseFailed                  = "FetchReleaseFailed"
 	BrokenReleaseGeneration             = "BrokenReleaseGeneration"
 	BrokenApplicationObservedGeneration = "BrokenApplicationObservedGeneration"
 
 	ChartError  = "ChartError"
 	ClientError = "ClientError"
+
+	// NamespaceTerminating means the target namespace is stuck in the
+	// Terminating phase and nothing can be installed into it until an
+	// operator unblocks its finalizers.
+	NamespaceTerminating = "NamespaceTerminating"
+
+	// ChartDigestMismatch means the chart repo now serves different bytes
+	// for the version a release has pinned.
+	ChartDigestMismatch = "ChartDigestMismatch"
+
+	// DependencyUnresolvable means the chart declares a dependency that no
+	// configured repo serves, so the release can never install as is.
+	DependencyUnresolvable = "DependencyUnresolvable"
+
+	// RecreationPending means a release the application's history still
+	// references was deleted, and the controller is waiting out the
+	// configured grace period before recreating it.
+	RecreationPending = "RecreationPending"
+
+	// ServiceOwnershipConflict means another application's production
+	// Service has a selector overlapping this one's, so shifting per-pod
+	// traffic labels could interfere with the other app's traffic.
+	ServiceOwnershipConflict = "ServiceOwnershipConflict"
+
+	// SingletonConflict means the chart renders a cluster-scoped object
+	// that another running release still owns, which installation refuses
+	// to clobber.
+	SingletonConflict = "SingletonConflict"
+
+	// DriftDetected means live objects on the cluster no longer match the
+	// rendered desired state, and the controller is only reporting it.
+	DriftDetected = "DriftDetected"
+
+	// DriftCorrected means drifted live objects were re-applied back to
+	// the rendered desired state.
+	DriftCorrected = "DriftCorrected"
+
+	// TrafficBackendError means the cloud load balancer backend a cluster
+	// is configured with could not report achieved traffic weights.
+	TrafficBackendError = "TrafficBackendError"
 )
@@ -222,6 +222,70 @@ func (sc StrategyConditionsMap) AsReleaseStrategyState(
 	return state
 }
 
+// AsReleaseStrategyStepStatuses breaks the rollout's progress down per
+// strategy step. Steps behind the target step have been fully achieved,
+// steps past it haven't been reached yet, and the target step's sub-states
+// are derived from the receiver's conditions, carrying along the latest
+// check result for whichever sub-state is pending.
+func (sc StrategyConditionsMap) AsReleaseStrategyStepStatuses(
+	steps []shipper.RolloutStrategyStep,
+	targetStep int32,
+	hasIncumbent bool,
+) []shipper.ReleaseStrategyStepStatus {
+	capacityTypes := []shipper.StrategyConditionType{shipper.StrategyConditionContenderAchievedCapacity}
+	trafficTypes := []shipper.StrategyConditionType{shipper.StrategyConditionContenderAchievedTraffic}
+	if hasIncumbent {
+		capacityTypes = append(capacityTypes, shipper.StrategyConditionIncumbentAchievedCapacity)
+		trafficTypes = append(trafficTypes, shipper.StrategyConditionIncumbentAchievedTraffic)
+	}
+
+	achieved := func(conditionTypes ...shipper.StrategyConditionType) shipper.StrategyState {
+		if sc.IsTrue(targetStep, conditionTypes...) {
+			return shipper.StrategyStateTrue
+		}
+		for _, conditionType := range conditionTypes {
+			if sc.IsFalse(targetStep, conditionType) {
+				return shipper.StrategyStateFalse
+			}
+		}
+		return shipper.StrategyStateUnknown
+	}
+
+	statuses := make([]shipper.ReleaseStrategyStepStatus, 0, len(steps))
+	for i, step := range steps {
+		status := shipper.ReleaseStrategyStepStatus{
+			Step: int32(i),
+			Name: step.Name,
+		}
+
+		switch {
+		case int32(i) < targetStep:
+			status.Installation = shipper.StrategyStateTrue
+			status.Capacity = shipper.StrategyStateTrue
+			status.Traffic = shipper.StrategyStateTrue
+		case int32(i) > targetStep:
+			status.Installation = shipper.StrategyStateUnknown
+			status.Capacity = shipper.StrategyStateUnknown
+			status.Traffic = shipper.StrategyStateUnknown
+		default:
+			status.Installation = achieved(shipper.StrategyConditionContenderAchievedInstallation)
+			status.Capacity = achieved(capacityTypes...)
+			status.Traffic = achieved(trafficTypes...)
+
+			for _, condition := range sc.AsReleaseStrategyConditions() {
+				if condition.Step == targetStep && condition.Status == corev1.ConditionFalse {
+					status.Message = condition.Message
+					break
+				}
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
 // GetStatus returns the status of condition from the receiver.
 func (sc StrategyConditionsMap) GetStatus(conditionType shipper.StrategyConditionType) (corev1.ConditionStatus, bool) {
 	if aCondition, ok := sc[conditionType]; !ok {
@@ -720,3 +720,84 @@ func testTransitionAndUpdateTimes(
 		t.Errorf("transition times are different")
 	}
 }
+
+// TestStepStatusesMatchStrategyStateMidRollout checks that the per-step
+// breakdown agrees with the legacy WaitingFor* booleans for a release in the
+// middle of its rollout.
+func TestStepStatusesMatchStrategyStateMidRollout(t *testing.T) {
+	step1 := int32(1)
+	trafficMessage := "clusters pending traffic adjustments: [minikube]"
+	sc := NewStrategyConditions(
+		shipper.ReleaseStrategyCondition{
+			Type:   shipper.StrategyConditionContenderAchievedInstallation,
+			Status: corev1.ConditionTrue,
+			Step:   step1,
+		},
+		shipper.ReleaseStrategyCondition{
+			Type:   shipper.StrategyConditionContenderAchievedCapacity,
+			Status: corev1.ConditionTrue,
+			Step:   step1,
+		},
+		shipper.ReleaseStrategyCondition{
+			Type:    shipper.StrategyConditionContenderAchievedTraffic,
+			Status:  corev1.ConditionFalse,
+			Reason:  ClustersNotReady,
+			Message: trafficMessage,
+			Step:    step1,
+		},
+	)
+
+	steps := []shipper.RolloutStrategyStep{
+		{Name: "staging"},
+		{Name: "50/50"},
+		{Name: "full on"},
+	}
+
+	const hasIncumbent = false
+	stepStatuses := sc.AsReleaseStrategyStepStatuses(steps, step1, hasIncumbent)
+
+	expected := []shipper.ReleaseStrategyStepStatus{
+		{
+			Step:         0,
+			Name:         "staging",
+			Installation: shipper.StrategyStateTrue,
+			Capacity:     shipper.StrategyStateTrue,
+			Traffic:      shipper.StrategyStateTrue,
+		},
+		{
+			Step:         1,
+			Name:         "50/50",
+			Installation: shipper.StrategyStateTrue,
+			Capacity:     shipper.StrategyStateTrue,
+			Traffic:      shipper.StrategyStateFalse,
+			Message:      trafficMessage,
+		},
+		{
+			Step:         2,
+			Name:         "full on",
+			Installation: shipper.StrategyStateUnknown,
+			Capacity:     shipper.StrategyStateUnknown,
+			Traffic:      shipper.StrategyStateUnknown,
+		},
+	}
+
+	if !reflect.DeepEqual(stepStatuses, expected) {
+		t.Fatalf(
+			"Step statuses are different\nDiff:\n %s",
+			diff.ObjectGoPrintDiff(stepStatuses, expected))
+	}
+
+	// The detailed target step must agree with the legacy booleans.
+	state := sc.AsReleaseStrategyState(step1, hasIncumbent, false)
+	target := stepStatuses[step1]
+
+	if waiting := state.WaitingForInstallation == shipper.StrategyStateTrue; waiting == (target.Installation == shipper.StrategyStateTrue) {
+		t.Errorf("WaitingForInstallation %q disagrees with step installation %q", state.WaitingForInstallation, target.Installation)
+	}
+	if waiting := state.WaitingForCapacity == shipper.StrategyStateTrue; waiting == (target.Capacity == shipper.StrategyStateTrue) {
+		t.Errorf("WaitingForCapacity %q disagrees with step capacity %q", state.WaitingForCapacity, target.Capacity)
+	}
+	if waiting := state.WaitingForTraffic == shipper.StrategyStateTrue; waiting == (target.Traffic == shipper.StrategyStateTrue) {
+		t.Errorf("WaitingForTraffic %q disagrees with step traffic %q", state.WaitingForTraffic, target.Traffic)
+	}
+}
@@ -16,11 +16,13 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperchart "github.com/bookingcom/shipper/pkg/chart"
 	clientset "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
 	informers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
 	listers "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
 	"github.com/bookingcom/shipper/pkg/conditions"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
 	apputil "github.com/bookingcom/shipper/pkg/util/application"
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
 )
@@ -31,6 +33,11 @@ const (
 	MinRevisionHistoryLimit     = 1
 	MaxRevisionHistoryLimit     = 1000
 
+	// DefaultHistoryLimit caps the number of entries kept in
+	// Application.Status.History. Unlike RevisionHistoryLimit it does not
+	// delete any Release objects; it only trims the status list.
+	DefaultHistoryLimit = 50
+
 	// maxRetries is the number of times an Application will be retried before we
 	// drop it out of the app workqueue. The number is chosen with the default rate
 	// limiter in mind. This results in the following backoff times: 5ms, 10ms,
@@ -50,7 +57,26 @@ type Controller struct {
 	relLister listers.ReleaseLister
 	relSynced cache.InformerSynced
 
+	strategyLister listers.StrategyLister
+	strategySynced cache.InformerSynced
+
+	clusterLister listers.ClusterLister
+	clusterSynced cache.InformerSynced
+
 	recorder record.EventRecorder
+
+	historyLimit int
+
+	chartFetchFunc shipperchart.FetchFunc
+
+	// repoURLMigrations maps old chart repo URLs to their replacements.
+	// Applications still pointing at an old URL are rewritten in place,
+	// after verifying the chart resolves at the new URL.
+	repoURLMigrations map[string]string
+
+	// defaultRepoURL is the fallback chart repo used when a template names
+	// no repo, or when its repo definitively doesn't serve the chart.
+	defaultRepoURL string
 }
 
 // NewController returns a new Application controller.
@@ -58,9 +84,15 @@ func NewController(
 	shipperClientset clientset.Interface,
 	shipperInformerFactory informers.SharedInformerFactory,
 	recorder record.EventRecorder,
+	historyLimit int,
+	chartFetchFunc shipperchart.FetchFunc,
+	repoURLMigrations map[string]string,
+	defaultRepoURL string,
 ) *Controller {
 	appInformer := shipperInformerFactory.Shipper().V1alpha1().Applications()
 	relInformer := shipperInformerFactory.Shipper().V1alpha1().Releases()
+	strategyInformer := shipperInformerFactory.Shipper().V1alpha1().Strategies()
+	clusterInformer := shipperInformerFactory.Shipper().V1alpha1().Clusters()
 
 	c := &Controller{
 		shipperClientset: shipperClientset,
@@ -72,7 +104,23 @@ func NewController(
 		relLister: relInformer.Lister(),
 		relSynced: relInformer.Informer().HasSynced,
 
+		strategyLister: strategyInformer.Lister(),
+		strategySynced: strategyInformer.Informer().HasSynced,
+
+		clusterLister: clusterInformer.Lister(),
+		clusterSynced: clusterInformer.Informer().HasSynced,
+
 		recorder: recorder,
+
+		historyLimit: historyLimit,
+
+		chartFetchFunc:    chartFetchFunc,
+		repoURLMigrations: repoURLMigrations,
+		defaultRepoURL:    defaultRepoURL,
+	}
+
+	if c.historyLimit < 1 {
+		c.historyLimit = DefaultHistoryLimit
 	}
 
 	appInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -110,7 +158,7 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 	glog.V(2).Info("Starting Application controller")
 	defer glog.V(2).Info("Shutting down Application controller")
 
-	if !cache.WaitForCacheSync(stopCh, c.appSynced, c.relSynced) {
+	if !cache.WaitForCacheSync(stopCh, c.appSynced, c.relSynced, c.strategySynced, c.clusterSynced) {
 		runtime.HandleError(fmt.Errorf("failed to sync caches for the Application controller"))
 		return
 	}
@@ -171,6 +219,7 @@ func (c *Controller) processNextWorkItem() bool {
 		return true
 	}
 
+	shipperhealth.RecordSuccess(AgentName)
 	glog.V(4).Infof("Successfully synced Application %q", key)
 	c.appWorkqueue.Forget(obj)
 
@@ -234,6 +283,17 @@ func (c *Controller) syncApplication(key string) error {
 		app.Annotations = map[string]string{}
 	}
 
+	if len(c.repoURLMigrations) > 0 && c.chartFetchFunc != nil {
+		if migrated, err := apputil.MigrateRepoURL(app, c.repoURLMigrations, c.chartFetchFunc); err != nil {
+			// The chart doesn't resolve at the new URL yet; keep the
+			// old URL and carry on syncing as usual.
+			c.recorder.Event(app, corev1.EventTypeWarning, "ChartRepoMigrationFailed", err.Error())
+		} else if migrated {
+			c.recorder.Eventf(app, corev1.EventTypeNormal, "ChartRepoMigrated",
+				"Chart repo URL rewritten to %q", app.Spec.Template.Chart.RepoURL)
+		}
+	}
+
 	if app.Spec.RevisionHistoryLimit == nil {
 		var i int32 = DefaultRevisionHistoryLimit
 		app.Spec.RevisionHistoryLimit = &i
@@ -355,18 +415,67 @@ func (c *Controller) processApplication(app *shipper.Application) error {
 
 	// clean up excessive releases regardless of exit path
 	defer func() {
-		app.Status.History = apputil.ReleasesToApplicationHistory(appReleases)
+		// A release may have been appended since the sort above.
+		sortedReleases := releaseutil.SortByGenerationDescending(appReleases)
+		contender, _ := apputil.GetContender(app.Name, sortedReleases)
+
+		// Record the selection on status so operators and the other
+		// controllers agree on which release is the incumbent, however
+		// tangled the history got.
+		incumbentName := ""
+		if incumbent := apputil.SelectIncumbent(contender, sortedReleases); incumbent != nil {
+			incumbentName = incumbent.GetName()
+		}
+		app.Status.Incumbent = incumbentName
+
+		history := apputil.ReleasesToApplicationHistory(appReleases)
+		app.Status.History = apputil.TrimApplicationHistory(history, c.historyLimit, incumbentName)
 		c.cleanUpReleasesForApplication(app, appReleases)
 	}()
 
+	// Reject templates asking for regions no registered Cluster serves:
+	// scheduling such a release can never succeed, so surface the problem
+	// here instead of on a release that silently gets no clusters.
+	if err := c.validateClusterRequirements(app); err != nil {
+		if shippererrors.IsUnknownRegionError(err) {
+			releaseSyncedCond := apputil.NewApplicationCondition(
+				shipper.ApplicationConditionTypeReleaseSynced,
+				corev1.ConditionFalse,
+				conditions.InvalidClusterRequirements,
+				err.Error())
+			apputil.SetApplicationCondition(&app.Status, *releaseSyncedCond)
+			// Nothing to retry: the template has to change before this
+			// application can make progress, and editing it re-enqueues.
+			return nil
+		}
+		return err
+	}
+
 	if contender, err = apputil.GetContender(app.Name, appReleases); err != nil {
 		if shippererrors.IsContenderNotFoundError(err) {
-			// Contender doesn't exist, so we are covering the case where Shipper
-			// is creating the first release for this application.
+			// Contender doesn't exist: either this is the application's
+			// first release, or an existing one has been deleted. In the
+			// latter case a configured grace period holds the recreation
+			// back, so an accidental deletion can be fixed by restoring
+			// the object instead of kicking off a fresh rollout.
+			if remaining, held := c.recreationHeldBack(app); held {
+				releaseSyncedCond := apputil.NewApplicationCondition(
+					shipper.ApplicationConditionTypeReleaseSynced,
+					corev1.ConditionFalse,
+					conditions.RecreationPending,
+					fmt.Sprintf("release recreation held back for %s; annotate with %s=%q to recreate now",
+						app.Annotations[shipper.AppRecreateGracePeriodAnnotation], shipper.AppConfirmRecreateAnnotation, shipper.True))
+				apputil.SetApplicationCondition(&app.Status, *releaseSyncedCond)
+				c.appWorkqueue.AddAfter(fmt.Sprintf("%s/%s", app.Namespace, app.Name), remaining)
+				return nil
+			}
+			delete(app.Annotations, shipper.AppRecreatePendingSinceAnnotation)
+			delete(app.Annotations, shipper.AppConfirmRecreateAnnotation)
+
 			var generation = 0
 			if releaseName, iteration, err := c.releaseNameForApplication(app); err != nil {
 				return err
-			} else if rel, err := c.createReleaseForApplication(app, releaseName, iteration, generation); err != nil {
+			} else if rel, err := c.createReleaseForApplication(app, nil, releaseName, iteration, generation); err != nil {
 				releaseSyncedCond := apputil.NewApplicationCondition(
 					shipper.ApplicationConditionTypeReleaseSynced,
 					corev1.ConditionFalse,
@@ -429,7 +538,7 @@ func (c *Controller) processApplication(app *shipper.Application) error {
 		highestObserved = highestObserved + 1
 		if releaseName, iteration, err := c.releaseNameForApplication(app); err != nil {
 			return err
-		} else if rel, err := c.createReleaseForApplication(app, releaseName, iteration, highestObserved); err != nil {
+		} else if rel, err := c.createReleaseForApplication(app, contender, releaseName, iteration, highestObserved); err != nil {
 			releaseSyncedCond := apputil.NewApplicationCondition(shipper.ApplicationConditionTypeReleaseSynced, corev1.ConditionFalse, conditions.CreateReleaseFailed, err.Error())
 			apputil.SetApplicationCondition(&app.Status, *releaseSyncedCond)
 			rollingOutCond := apputil.NewApplicationCondition(shipper.ApplicationConditionTypeRollingOut, corev1.ConditionFalse, conditions.CreateReleaseFailed, err.Error())
@@ -444,6 +553,42 @@ func (c *Controller) processApplication(app *shipper.Application) error {
 	return c.wrapUpApplicationConditions(app, appReleases)
 }
 
+// recreationHeldBack reports whether recreating a release should wait, and
+// for how long. It only ever holds when the history still references a
+// release that no longer exists (a first release is never held), the
+// application carries a recreation grace period annotation, and the deletion
+// has not been confirmed. The first held sync stamps the application with
+// the time the hold started; the grace period counts from that stamp.
+func (c *Controller) recreationHeldBack(app *shipper.Application) (time.Duration, bool) {
+	if len(app.Status.History) == 0 && app.Annotations[shipper.AppRecreatePendingSinceAnnotation] == "" {
+		return 0, false
+	}
+
+	grace, err := time.ParseDuration(app.Annotations[shipper.AppRecreateGracePeriodAnnotation])
+	if err != nil || grace <= 0 {
+		// No grace period (or a broken one): recreate immediately, as
+		// before the annotation existed.
+		return 0, false
+	}
+
+	if app.Annotations[shipper.AppConfirmRecreateAnnotation] == shipper.True {
+		return 0, false
+	}
+
+	since, err := time.Parse(time.RFC3339, app.Annotations[shipper.AppRecreatePendingSinceAnnotation])
+	if err != nil {
+		since = time.Now()
+		app.Annotations[shipper.AppRecreatePendingSinceAnnotation] = since.UTC().Format(time.RFC3339)
+	}
+
+	remaining := grace - time.Since(since)
+	if remaining <= 0 {
+		return 0, false
+	}
+
+	return remaining, true
+}
+
 // TODO(jgreff): wrap bare errors with shippererrors and actually return them
 // so they can be retried if needed, instead of relying on resyncs to do the
 // trick.
@@ -453,11 +598,28 @@ func (c *Controller) cleanUpReleasesForApplication(app *shipper.Application, rel
 	// Process releases by a predictable, ascending generation order.
 	releases = releaseutil.SortByGenerationAscending(releases)
 
+	// While the latest release's incumbent retention window is open, its
+	// direct predecessor is kept around -- installed, scaled to zero -- so
+	// a rollback only has to scale it back up. Once the window closes the
+	// usual collection below applies again.
+	incumbentRetained := len(releases) > 1 &&
+		releaseutil.RetainsIncumbent(releases[len(releases)-1], time.Now())
+
 	// Delete any releases that are not installed. Don't touch the latest release
 	// because a release that isn't installed and is the last release just means
 	// that the user is rolling out the application.
 	for i := 0; i < len(releases)-1; i++ {
 		rel := releases[i]
+		if releaseutil.IsKnownGood(rel) {
+			// Operators marked this release as the designated rollback
+			// target; it is never garbage collected.
+			continue
+		}
+
+		if incumbentRetained && i == len(releases)-2 {
+			continue
+		}
+
 		if releaseutil.ReleaseComplete(releases[i]) {
 			installedReleases = append(installedReleases, releases[i])
 			continue
@@ -3,12 +3,14 @@ package application
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
@@ -17,6 +19,7 @@ import (
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	"github.com/bookingcom/shipper/pkg/conditions"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
 	apputil "github.com/bookingcom/shipper/pkg/util/application"
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
@@ -93,12 +96,145 @@ func TestCreateFirstRelease(t *testing.T) {
 	expectedRelease.Labels[shipper.ReleaseEnvironmentHashLabel] = envHash
 	expectedRelease.Annotations[shipper.ReleaseTemplateIterationAnnotation] = "0"
 	expectedRelease.Annotations[shipper.ReleaseGenerationAnnotation] = "0"
+	expectedRelease.Annotations[shipper.ReleaseChartRepoAnnotation] = "127.0.0.1"
 
 	f.expectReleaseCreate(expectedRelease)
 	f.expectApplicationUpdate(expectedApp)
 	f.run()
 }
 
+// TestCreateFirstReleaseSnapshotsSharedStrategy checks that a template
+// referencing a shared Strategy by name gets the resolved strategy snapshotted
+// into the release it creates.
+func TestCreateFirstReleaseSnapshotsSharedStrategy(t *testing.T) {
+	f := newFixture(t)
+	app := newApplication(testAppName)
+	app.Spec.Template.Strategy = nil
+	app.Spec.Template.StrategyName = "vanguard"
+
+	sharedStrategy := &shipper.Strategy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vanguard",
+			Namespace: shippertesting.TestNamespace,
+		},
+		Spec: vanguard,
+	}
+
+	envHash := hashReleaseEnvironment(app.Spec.Template)
+	expectedRelName := fmt.Sprintf("%s-%s-0", testAppName, envHash)
+
+	f.objects = append(f.objects, app, sharedStrategy)
+	expectedApp := app.DeepCopy()
+	expectedApp.Annotations[shipper.AppHighestObservedGenerationAnnotation] = "0"
+	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
+		{
+			Type:   shipper.ApplicationConditionTypeAborting,
+			Status: corev1.ConditionFalse,
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeReleaseSynced,
+			Status: corev1.ConditionTrue,
+		},
+		{
+			Type:    shipper.ApplicationConditionTypeRollingOut,
+			Status:  corev1.ConditionTrue,
+			Message: fmt.Sprintf(InitialReleaseMessageFormat, expectedRelName),
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeValidHistory,
+			Status: corev1.ConditionTrue,
+		},
+	}
+	expectedApp.Status.History = []string{expectedRelName}
+
+	expectedRelease := newRelease(expectedRelName, app)
+	expectedRelease.Spec.Environment.Strategy = vanguard.DeepCopy()
+	expectedRelease.Labels[shipper.ReleaseEnvironmentHashLabel] = envHash
+	expectedRelease.Annotations[shipper.ReleaseTemplateIterationAnnotation] = "0"
+	expectedRelease.Annotations[shipper.ReleaseGenerationAnnotation] = "0"
+	expectedRelease.Annotations[shipper.ReleaseChartRepoAnnotation] = app.Spec.Template.Chart.RepoURL
+
+	f.expectReleaseCreate(expectedRelease)
+	f.expectApplicationUpdate(expectedApp)
+	f.run()
+}
+
+// TestSharedStrategyEditDoesNotAffectInFlightRelease checks that editing a
+// shared Strategy after a release snapshotted it neither touches the release
+// nor reads as a template change that would roll a new one.
+func TestSharedStrategyEditDoesNotAffectInFlightRelease(t *testing.T) {
+	f := newFixture(t)
+	app := newApplication(testAppName)
+	app.Spec.Template.Strategy = nil
+	app.Spec.Template.StrategyName = "vanguard"
+	app.Annotations[shipper.AppHighestObservedGenerationAnnotation] = "0"
+
+	envHash := hashReleaseEnvironment(app.Spec.Template)
+	relName := fmt.Sprintf("%s-%s-0", testAppName, envHash)
+	release := newRelease(relName, app)
+	// The release snapshotted the shared strategy as it was at creation
+	// time.
+	release.Spec.Environment.Strategy = vanguard.DeepCopy()
+	release.Labels[shipper.ReleaseEnvironmentHashLabel] = envHash
+	release.Annotations[shipper.ReleaseGenerationAnnotation] = "0"
+	release.Spec.TargetStep = 2
+	release.Status.AchievedStep = &shipper.AchievedStep{
+		Step: 2,
+		Name: release.Spec.Environment.Strategy.Steps[2].Name,
+	}
+	release.Status.Conditions = []shipper.ReleaseCondition{
+		{Type: shipper.ReleaseConditionTypeInstalled, Status: corev1.ConditionTrue},
+		{Type: shipper.ReleaseConditionTypeComplete, Status: corev1.ConditionTrue},
+	}
+
+	// The shared strategy has since been edited to roll out in a single
+	// step.
+	editedStrategy := &shipper.Strategy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vanguard",
+			Namespace: shippertesting.TestNamespace,
+		},
+		Spec: shipper.RolloutStrategy{
+			Steps: []shipper.RolloutStrategyStep{
+				{
+					Name:     "full on",
+					Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+					Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				},
+			},
+		},
+	}
+
+	f.objects = append(f.objects, app, release, editedStrategy)
+
+	expectedApp := app.DeepCopy()
+	expectedApp.Status.History = []string{relName}
+	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
+		{
+			Type:   shipper.ApplicationConditionTypeAborting,
+			Status: corev1.ConditionFalse,
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeReleaseSynced,
+			Status: corev1.ConditionTrue,
+		},
+		{
+			Type:    shipper.ApplicationConditionTypeRollingOut,
+			Status:  corev1.ConditionFalse,
+			Message: fmt.Sprintf(ReleaseActiveMessageFormat, relName),
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeValidHistory,
+			Status: corev1.ConditionTrue,
+		},
+	}
+
+	// Only the application's status moves: the strategy edit must not
+	// create a new release or touch the existing one.
+	f.expectApplicationUpdate(expectedApp)
+	f.run()
+}
+
 func TestStatusStableState(t *testing.T) {
 	f := newFixture(t)
 	app := newApplication(testAppName)
@@ -141,6 +277,7 @@ func TestStatusStableState(t *testing.T) {
 		expectedRelNameA,
 		expectedRelNameB,
 	}
+	expectedApp.Status.Incumbent = expectedRelNameA
 	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
 		{
 			Type:   shipper.ApplicationConditionTypeAborting,
@@ -206,6 +343,8 @@ func TestRevisionHistoryLimit(t *testing.T) {
 	expectedApp := app.DeepCopy()
 	expectedApp.Annotations[shipper.AppHighestObservedGenerationAnnotation] = "2"
 
+	expectedApp.Status.Incumbent = "bar"
+
 	// This ought to be true, but deletes don't filter through the kubetesting
 	// lister.
 
@@ -235,6 +374,117 @@ func TestRevisionHistoryLimit(t *testing.T) {
 	f.run()
 }
 
+// TestRevisionHistoryLimitKeepsKnownGood tests that a release marked as the
+// known-good rollback target is never garbage collected, even when it is the
+// oldest release beyond RevisionHistoryLimit. Without the mark this is the
+// TestRevisionHistoryLimit scenario and "foo" would be deleted; with it, no
+// release is collected, since known-good releases don't count against the
+// limit.
+func TestRevisionHistoryLimitKeepsKnownGood(t *testing.T) {
+	f := newFixture(t)
+	app := newApplication(testAppName)
+	one := int32(1)
+	app.Spec.RevisionHistoryLimit = &one
+	f.objects = append(f.objects, app)
+
+	names := []string{"foo", "bar", "baz"}
+	for i, name := range names {
+		rel := newRelease(name, app)
+		rel.Spec.TargetStep = 2
+		rel.Status.AchievedStep = &shipper.AchievedStep{
+			Step: 2,
+			Name: rel.Spec.Environment.Strategy.Steps[2].Name,
+		}
+		releaseutil.SetReleaseCondition(&rel.Status, *releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", ""))
+		releaseutil.SetGeneration(rel, i)
+		if name == "foo" {
+			rel.Annotations[shipper.ReleaseKnownGoodAnnotation] = shipper.True
+		}
+		f.objects = append(f.objects, rel)
+	}
+
+	app.Status.History = names
+
+	expectedApp := app.DeepCopy()
+	expectedApp.Annotations[shipper.AppHighestObservedGenerationAnnotation] = "2"
+	expectedApp.Status.Incumbent = "bar"
+	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
+		{
+			Type:   shipper.ApplicationConditionTypeAborting,
+			Status: corev1.ConditionFalse,
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeReleaseSynced,
+			Status: corev1.ConditionTrue,
+		},
+		{
+			Type:    shipper.ApplicationConditionTypeRollingOut,
+			Status:  corev1.ConditionFalse,
+			Message: fmt.Sprintf(ReleaseActiveMessageFormat, "baz"),
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeValidHistory,
+			Status: corev1.ConditionTrue,
+		},
+	}
+
+	f.expectApplicationUpdate(expectedApp)
+	f.run()
+}
+
+// TestHistoryLimitTrimsOldestEntries tests that Status.History is capped at
+// the controller's history limit by dropping the oldest entries, without
+// deleting any Release objects beyond what RevisionHistoryLimit asks for.
+func TestHistoryLimitTrimsOldestEntries(t *testing.T) {
+	f := newFixture(t)
+	f.historyLimit = 2
+
+	app := newApplication(testAppName)
+	f.objects = append(f.objects, app)
+
+	names := []string{"foo", "bar", "baz"}
+	for i, name := range names {
+		rel := newRelease(name, app)
+		rel.Spec.TargetStep = 2
+		rel.Status.AchievedStep = &shipper.AchievedStep{
+			Step: 2,
+			Name: rel.Spec.Environment.Strategy.Steps[2].Name,
+		}
+		releaseutil.SetReleaseCondition(&rel.Status, *releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", ""))
+		releaseutil.SetGeneration(rel, i)
+		f.objects = append(f.objects, rel)
+	}
+
+	app.Status.History = names
+
+	expectedApp := app.DeepCopy()
+	expectedApp.Annotations[shipper.AppHighestObservedGenerationAnnotation] = "2"
+	expectedApp.Status.History = []string{"bar", "baz"}
+	expectedApp.Status.Incumbent = "bar"
+	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
+		{
+			Type:   shipper.ApplicationConditionTypeAborting,
+			Status: corev1.ConditionFalse,
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeReleaseSynced,
+			Status: corev1.ConditionTrue,
+		},
+		{
+			Type:    shipper.ApplicationConditionTypeRollingOut,
+			Status:  corev1.ConditionFalse,
+			Message: fmt.Sprintf(ReleaseActiveMessageFormat, "baz"),
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeValidHistory,
+			Status: corev1.ConditionTrue,
+		},
+	}
+
+	f.expectApplicationUpdate(expectedApp)
+	f.run()
+}
+
 func TestCreateThirdRelease(t *testing.T) {
 	srv, hh, err := repotest.NewTempServer("testdata/*.tgz")
 	if err != nil {
@@ -281,13 +531,23 @@ func TestCreateThirdRelease(t *testing.T) {
 		Regions: []shipper.RegionRequirement{{Name: "foo"}},
 	}
 
-	f.objects = append(f.objects, app, firstRel, incumbentRel)
+	cluster := &shipper.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "minikube",
+		},
+		Spec: shipper.ClusterSpec{
+			Region: "foo",
+		},
+	}
+
+	f.objects = append(f.objects, app, firstRel, incumbentRel, cluster)
 
 	contenderEnvHash := hashReleaseEnvironment(app.Spec.Template)
 	expectedContenderRelName := fmt.Sprintf("%s-%s-0", testAppName, contenderEnvHash)
 
 	expectedContenderRel := newRelease(expectedContenderRelName, app)
 	expectedContenderRel.Labels[shipper.ReleaseEnvironmentHashLabel] = contenderEnvHash
+	expectedContenderRel.Annotations[shipper.ReleaseChartRepoAnnotation] = app.Spec.Template.Chart.RepoURL
 	releaseutil.SetIteration(expectedContenderRel, 0)
 	releaseutil.SetGeneration(expectedContenderRel, 2)
 
@@ -298,6 +558,7 @@ func TestCreateThirdRelease(t *testing.T) {
 		incumbentRelName,
 		expectedContenderRelName,
 	}
+	expectedApp.Status.Incumbent = incumbentRelName
 
 	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
 		{
@@ -363,11 +624,22 @@ func TestCreateSecondRelease(t *testing.T) {
 		Regions: []shipper.RegionRequirement{{Name: "foo"}},
 	}
 
+	cluster := &shipper.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "minikube",
+		},
+		Spec: shipper.ClusterSpec{
+			Region: "foo",
+		},
+	}
+	f.objects = append(f.objects, cluster)
+
 	contenderEnvHash := hashReleaseEnvironment(app.Spec.Template)
 	contenderRelName := fmt.Sprintf("%s-%s-0", testAppName, contenderEnvHash)
 
 	contenderRel := newRelease(contenderRelName, app)
 	contenderRel.Labels[shipper.ReleaseEnvironmentHashLabel] = contenderEnvHash
+	contenderRel.Annotations[shipper.ReleaseChartRepoAnnotation] = app.Spec.Template.Chart.RepoURL
 	releaseutil.SetIteration(contenderRel, 0)
 	releaseutil.SetGeneration(contenderRel, 1)
 
@@ -377,6 +649,90 @@ func TestCreateSecondRelease(t *testing.T) {
 		incumbentRelName,
 		contenderRelName,
 	}
+	expectedApp.Status.Incumbent = incumbentRelName
+
+	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
+		{
+			Type:   shipper.ApplicationConditionTypeAborting,
+			Status: corev1.ConditionFalse,
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeReleaseSynced,
+			Status: corev1.ConditionTrue,
+		},
+		{
+			Type:    shipper.ApplicationConditionTypeRollingOut,
+			Status:  corev1.ConditionTrue,
+			Message: fmt.Sprintf(TransitioningMessageFormat, incumbentRelName, contenderRelName),
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeValidHistory,
+			Status: corev1.ConditionTrue,
+		},
+	}
+
+	f.expectReleaseCreate(contenderRel)
+	f.expectApplicationUpdate(expectedApp)
+	f.run()
+}
+
+// TestEnvironmentDiffAnnotationOnNewRelease edits an application's chart
+// version and replicaCount value and checks that the release created for the
+// edit is stamped with a summary of both changes relative to its predecessor.
+func TestEnvironmentDiffAnnotationOnNewRelease(t *testing.T) {
+	srv, hh, err := repotest.NewTempServer("testdata/*.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		os.RemoveAll(hh.String())
+		srv.Stop()
+	}()
+
+	f := newFixture(t)
+	app := newApplication(testAppName)
+	apputil.SetHighestObservedGeneration(app, 0)
+	app.Spec.Template.Chart.RepoURL = srv.URL()
+	app.Spec.Template.Values = &shipper.ChartValues{"replicaCount": int64(1)}
+	f.objects = append(f.objects, app)
+
+	incumbentEnvHash := hashReleaseEnvironment(app.Spec.Template)
+	incumbentRelName := fmt.Sprintf("%s-%s-0", testAppName, incumbentEnvHash)
+
+	incumbentRel := newRelease(incumbentRelName, app)
+	releaseutil.SetGeneration(incumbentRel, 0)
+	releaseutil.SetIteration(incumbentRel, 0)
+	releaseutil.SetReleaseCondition(&incumbentRel.Status, *releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", ""))
+	incumbentRel.Spec.TargetStep = 2
+	incumbentRel.Status.AchievedStep = &shipper.AchievedStep{
+		Step: 2,
+		Name: incumbentRel.Spec.Environment.Strategy.Steps[2].Name,
+	}
+
+	f.objects = append(f.objects, incumbentRel)
+
+	app.Status.History = []string{incumbentRelName}
+	app.Spec.Template.Chart.Version = "0.0.2"
+	app.Spec.Template.Values = &shipper.ChartValues{"replicaCount": int64(4)}
+
+	contenderEnvHash := hashReleaseEnvironment(app.Spec.Template)
+	contenderRelName := fmt.Sprintf("%s-%s-0", testAppName, contenderEnvHash)
+
+	contenderRel := newRelease(contenderRelName, app)
+	contenderRel.Labels[shipper.ReleaseEnvironmentHashLabel] = contenderEnvHash
+	releaseutil.SetIteration(contenderRel, 0)
+	releaseutil.SetGeneration(contenderRel, 1)
+	contenderRel.Annotations[shipper.ReleaseChartRepoAnnotation] = app.Spec.Template.Chart.RepoURL
+	contenderRel.Annotations[shipper.ReleaseEnvironmentDiffAnnotation] =
+		"chart.version: 0.0.1 -> 0.0.2; values.replicaCount: 1 -> 4"
+
+	expectedApp := app.DeepCopy()
+	apputil.SetHighestObservedGeneration(expectedApp, 1)
+	expectedApp.Status.History = []string{
+		incumbentRelName,
+		contenderRelName,
+	}
+	expectedApp.Status.Incumbent = incumbentRelName
 
 	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
 		{
@@ -426,7 +782,16 @@ func TestAbort(t *testing.T) {
 		Regions: []shipper.RegionRequirement{{Name: "foo"}},
 	}
 
-	f.objects = append(f.objects, app)
+	clusterFoo := &shipper.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-foo"},
+		Spec:       shipper.ClusterSpec{Region: "foo"},
+	}
+	clusterBar := &shipper.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-bar"},
+		Spec:       shipper.ClusterSpec{Region: "bar"},
+	}
+
+	f.objects = append(f.objects, app, clusterFoo, clusterBar)
 
 	envHash := hashReleaseEnvironment(app.Spec.Template)
 	relName := fmt.Sprintf("%s-%s-0", testAppName, envHash)
@@ -516,6 +881,7 @@ func TestStateRollingOut(t *testing.T) {
 	f.objects = append(f.objects, contender)
 
 	appRollingOut := app.DeepCopy()
+	appRollingOut.Status.Incumbent = incumbentName
 
 	appRollingOut.Status.Conditions = []shipper.ApplicationCondition{
 		{
@@ -665,6 +1031,9 @@ type fixture struct {
 	client  *shipperfake.Clientset
 	actions []kubetesting.Action
 	objects []runtime.Object
+
+	// historyLimit overrides the controller's history cap when positive.
+	historyLimit int
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -677,7 +1046,12 @@ func (f *fixture) newController() (*Controller, shipperinformers.SharedInformerF
 	const noResyncPeriod time.Duration = 0
 	shipperInformerFactory := shipperinformers.NewSharedInformerFactory(f.client, noResyncPeriod)
 
-	c := NewController(f.client, shipperInformerFactory, record.NewFakeRecorder(42))
+	historyLimit := f.historyLimit
+	if historyLimit < 1 {
+		historyLimit = DefaultHistoryLimit
+	}
+
+	c := NewController(f.client, shipperInformerFactory, record.NewFakeRecorder(42), historyLimit, nil, nil, "")
 
 	return c, shipperInformerFactory
 }
@@ -723,3 +1097,231 @@ func (f *fixture) expectApplicationUpdate(app *shipper.Application) {
 
 	f.actions = append(f.actions, action)
 }
+
+// TestRecreateDeletedReleaseHeldByGracePeriod checks that with a recreation
+// grace period configured, deleting a release the history still references
+// does not immediately produce a new one: the application reports
+// RecreationPending instead, so an accidental deletion can be undone by
+// restoring the object.
+func TestRecreateDeletedReleaseHeldByGracePeriod(t *testing.T) {
+	f := newFixture(t)
+	app := newApplication(testAppName)
+	app.Annotations[shipper.AppHighestObservedGenerationAnnotation] = "0"
+	app.Annotations[shipper.AppRecreateGracePeriodAnnotation] = "1h"
+	// A fixed stamp keeps the controller from writing a fresh one, so the
+	// expected update below is deterministic.
+	app.Annotations[shipper.AppRecreatePendingSinceAnnotation] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	app.Status.History = []string{"test-app-deadbeef-0"}
+
+	f.objects = append(f.objects, app)
+
+	expectedApp := app.DeepCopy()
+	expectedApp.Status.History = []string{}
+	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
+		{
+			Type:   shipper.ApplicationConditionTypeReleaseSynced,
+			Status: corev1.ConditionFalse,
+			Reason: conditions.RecreationPending,
+			Message: fmt.Sprintf("release recreation held back for %s; annotate with %s=%q to recreate now",
+				"1h", shipper.AppConfirmRecreateAnnotation, shipper.True),
+		},
+	}
+
+	// No release create: only the status update reporting the hold.
+	f.expectApplicationUpdate(expectedApp)
+	f.run()
+}
+
+// TestRecreateDeletedReleaseConfirmSkipsGracePeriod checks that confirming
+// the deletion recreates the release right away, without waiting out the
+// rest of the grace period, and that the bookkeeping annotations are removed
+// once it has.
+func TestRecreateDeletedReleaseConfirmSkipsGracePeriod(t *testing.T) {
+	f := newFixture(t)
+	app := newApplication(testAppName)
+	app.Annotations[shipper.AppRecreateGracePeriodAnnotation] = "1h"
+	app.Annotations[shipper.AppRecreatePendingSinceAnnotation] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	app.Annotations[shipper.AppConfirmRecreateAnnotation] = shipper.True
+	app.Status.History = []string{"test-app-deadbeef-0"}
+
+	f.objects = append(f.objects, app)
+
+	envHash := hashReleaseEnvironment(app.Spec.Template)
+	expectedRelName := fmt.Sprintf("%s-%s-0", testAppName, envHash)
+
+	expectedApp := app.DeepCopy()
+	delete(expectedApp.Annotations, shipper.AppRecreatePendingSinceAnnotation)
+	delete(expectedApp.Annotations, shipper.AppConfirmRecreateAnnotation)
+	expectedApp.Annotations[shipper.AppHighestObservedGenerationAnnotation] = "0"
+	expectedApp.Status.History = []string{expectedRelName}
+	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
+		{
+			Type:   shipper.ApplicationConditionTypeAborting,
+			Status: corev1.ConditionFalse,
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeReleaseSynced,
+			Status: corev1.ConditionTrue,
+		},
+		{
+			Type:    shipper.ApplicationConditionTypeRollingOut,
+			Status:  corev1.ConditionTrue,
+			Message: fmt.Sprintf(InitialReleaseMessageFormat, expectedRelName),
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeValidHistory,
+			Status: corev1.ConditionTrue,
+		},
+	}
+
+	expectedRelease := newRelease(expectedRelName, app)
+	expectedRelease.Labels[shipper.ReleaseEnvironmentHashLabel] = envHash
+	expectedRelease.Annotations[shipper.ReleaseTemplateIterationAnnotation] = "0"
+	expectedRelease.Annotations[shipper.ReleaseGenerationAnnotation] = "0"
+	expectedRelease.Annotations[shipper.ReleaseChartRepoAnnotation] = app.Spec.Template.Chart.RepoURL
+
+	f.expectReleaseCreate(expectedRelease)
+	f.expectApplicationUpdate(expectedApp)
+	f.run()
+}
+
+// TestGenerateReleaseNameFromTemplate checks that an application's release
+// name template can embed metadata like the chart version while keeping the
+// uniqueness suffix.
+func TestGenerateReleaseNameFromTemplate(t *testing.T) {
+	app := newApplication(testAppName)
+	app.Spec.ReleaseNameTemplate = "{{.AppName}}-{{.ChartVersion}}"
+
+	name, err := generateReleaseName(app, "deadbeef", 1)
+	if err != nil {
+		t.Fatalf("expected the release name template to render, got: %s", err)
+	}
+
+	expected := fmt.Sprintf("%s-0.0.1-deadbeef-1", testAppName)
+	if name != expected {
+		t.Errorf("expected release name %q, got %q", expected, name)
+	}
+}
+
+// TestGenerateReleaseNameTruncatesOverlongTemplate checks that a template
+// rendering to an over-length base gets truncated so the whole name still
+// fits in a label value, without losing the uniqueness suffix.
+func TestGenerateReleaseNameTruncatesOverlongTemplate(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+
+	app := newApplication(testAppName)
+	app.Spec.Template.Values = &shipper.ChartValues{"gitSHA": sha}
+	app.Spec.ReleaseNameTemplate = "{{.AppName}}-{{.Values.gitSHA}}-{{.Values.gitSHA}}"
+
+	name, err := generateReleaseName(app, "deadbeef", 0)
+	if err != nil {
+		t.Fatalf("expected the release name template to render, got: %s", err)
+	}
+
+	suffix := "-deadbeef-0"
+	rendered := fmt.Sprintf("%s-%s-%s", testAppName, sha, sha)
+	expected := rendered[:validation.LabelValueMaxLength-len(suffix)] + suffix
+	if name != expected {
+		t.Errorf("expected release name %q, got %q", expected, name)
+	}
+
+	if msgs := validation.IsValidLabelValue(name); len(msgs) > 0 {
+		t.Errorf("expected the truncated name to be a valid label value, got: %v", msgs)
+	}
+}
+
+// TestIncumbentRetainedDuringRetention tests that the contender's direct
+// predecessor survives garbage collection while the strategy's incumbent
+// retention window is open, even when it was aborted mid-rollout and would
+// otherwise be collected as in TestDeletingAbortedReleases. The retained
+// release keeps its installed objects at zero capacity, so a fast rollback
+// only has to scale it back up.
+func TestIncumbentRetainedDuringRetention(t *testing.T) {
+	f := newFixture(t)
+	app := newApplication(testAppName)
+	app.Spec.Template.Strategy.IncumbentRetention = &metav1.Duration{Duration: time.Hour}
+	f.objects = append(f.objects, app)
+
+	releaseFoo := newRelease("foo", app)
+	releaseFoo.Annotations[shipper.ReleaseGenerationAnnotation] = "0"
+	releaseFoo.Annotations[shipper.ReleaseFastRollbackAnnotation] = shipper.True
+
+	releaseBar := newRelease("bar", app)
+	releaseutil.SetGeneration(releaseBar, 1)
+	releaseutil.SetReleaseCondition(&releaseBar.Status, *releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", ""))
+	releaseBar.Spec.TargetStep = 2
+	releaseBar.Status.AchievedStep = &shipper.AchievedStep{
+		Step: 2,
+		Name: releaseBar.Spec.Environment.Strategy.Steps[2].Name,
+	}
+
+	f.objects = append(f.objects, releaseFoo, releaseBar)
+
+	app.Status.History = []string{"foo", "bar"}
+
+	expectedApp := app.DeepCopy()
+	expectedApp.Annotations[shipper.AppHighestObservedGenerationAnnotation] = "1"
+
+	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
+		{
+			Type:   shipper.ApplicationConditionTypeAborting,
+			Status: corev1.ConditionFalse,
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeReleaseSynced,
+			Status: corev1.ConditionTrue,
+		},
+		{
+			Type:    shipper.ApplicationConditionTypeRollingOut,
+			Status:  corev1.ConditionFalse,
+			Message: fmt.Sprintf(ReleaseActiveMessageFormat, releaseBar.Name),
+		},
+		{
+			Type:   shipper.ApplicationConditionTypeValidHistory,
+			Status: corev1.ConditionTrue,
+		},
+	}
+
+	// No release delete is expected: "foo" is inside the retention window.
+	f.expectApplicationUpdate(expectedApp)
+	f.run()
+}
+
+// TestUnknownRegionSetsCondition checks that an application requesting a
+// region for which no Cluster is registered is rejected up front with a
+// ReleaseSynced condition naming the available regions, rather than creating
+// a release that can never be scheduled.
+func TestUnknownRegionSetsCondition(t *testing.T) {
+	f := newFixture(t)
+
+	cluster := &shipper.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "minikube",
+		},
+		Spec: shipper.ClusterSpec{
+			Region: "local",
+		},
+	}
+
+	app := newApplication(testAppName)
+	app.Spec.Template.ClusterRequirements = shipper.ClusterRequirements{
+		Regions: []shipper.RegionRequirement{{Name: "eu-west"}},
+	}
+
+	f.objects = append(f.objects, app, cluster)
+
+	expectedApp := app.DeepCopy()
+	expectedApp.Status.History = []string{}
+	expectedApp.Status.Conditions = []shipper.ApplicationCondition{
+		{
+			Type:    shipper.ApplicationConditionTypeReleaseSynced,
+			Status:  corev1.ConditionFalse,
+			Reason:  conditions.InvalidClusterRequirements,
+			Message: `no clusters in region "eu-west"; available: [local]`,
+		},
+	}
+
+	// No release create is expected: the requested region does not exist.
+	f.expectApplicationUpdate(expectedApp)
+	f.run()
+}
@@ -1,15 +1,21 @@
 package application
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"hash/fnv"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"text/template"
 
 	"github.com/golang/glog"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	"github.com/bookingcom/shipper/pkg/controller"
@@ -17,12 +23,17 @@ import (
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 )
 
-func (c *Controller) createReleaseForApplication(app *shipper.Application, releaseName string, iteration, generation int) (*shipper.Release, error) {
+func (c *Controller) createReleaseForApplication(app *shipper.Application, predecessor *shipper.Release, releaseName string, iteration, generation int) (*shipper.Release, error) {
 	// Label releases with their hash; select by that label and increment if needed
 	// appname-hash-of-template-iteration.
 
 	glog.V(4).Infof("Generated Release name for Application %q: %q", controller.MetaKey(app), releaseName)
 
+	env, err := c.resolveEnvironmentStrategy(app)
+	if err != nil {
+		return nil, err
+	}
+
 	newRelease := &shipper.Release{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      releaseName,
@@ -41,7 +52,7 @@ func (c *Controller) createReleaseForApplication(app *shipper.Application, relea
 			},
 		},
 		Spec: shipper.ReleaseSpec{
-			Environment: *(app.Spec.Template.DeepCopy()),
+			Environment: *env,
 		},
 		Status: shipper.ReleaseStatus{},
 	}
@@ -50,6 +61,25 @@ func (c *Controller) createReleaseForApplication(app *shipper.Application, relea
 		newRelease.Labels[k] = v
 	}
 
+	// Applications imported from plain Helm opt into adopting the objects
+	// Helm deployed; the annotation rides along on every release so the
+	// installation targets inherit it.
+	if app.GetAnnotations()[shipper.InstallationAdoptExistingObjectsAnnotation] == shipper.True {
+		newRelease.Annotations[shipper.InstallationAdoptExistingObjectsAnnotation] = shipper.True
+	}
+
+	if repoURL := c.resolveChartRepo(env.Chart); repoURL != "" {
+		newRelease.Annotations[shipper.ReleaseChartRepoAnnotation] = repoURL
+	}
+
+	// A glanceable record of what this rollout actually changes, relative
+	// to the release it supersedes.
+	if predecessor != nil {
+		if diff := environmentDiff(&predecessor.Spec.Environment, env); diff != "" {
+			newRelease.Annotations[shipper.ReleaseEnvironmentDiffAnnotation] = diff
+		}
+	}
+
 	glog.V(4).Infof("Release %q labels: %v", controller.MetaKey(app), newRelease.Labels)
 	glog.V(4).Infof("Release %q annotations: %v", controller.MetaKey(app), newRelease.Annotations)
 
@@ -61,6 +91,40 @@ func (c *Controller) createReleaseForApplication(app *shipper.Application, relea
 	return rel, nil
 }
 
+// resolveChartRepo returns the repo URL the release's chart will be fetched
+// from first: the template's repo when it names one, or the configured
+// default. An explicit repo answering a definitive not-found falls back to
+// the default lazily at fetch time (chart.WithDefaultRepo), not here — a
+// remote probe doesn't belong on the reconcile path.
+func (c *Controller) resolveChartRepo(chart shipper.Chart) string {
+	if chart.RepoURL == "" {
+		return c.defaultRepoURL
+	}
+
+	return chart.RepoURL
+}
+
+// resolveEnvironmentStrategy returns a copy of the application's template
+// with any shared strategy reference resolved. The resolved strategy is
+// snapshotted into the copy's Strategy, so the release created from it keeps
+// rolling out the same way even if the shared Strategy object is edited
+// later.
+func (c *Controller) resolveEnvironmentStrategy(app *shipper.Application) (*shipper.ReleaseEnvironment, error) {
+	env := app.Spec.Template.DeepCopy()
+	if env.StrategyName == "" {
+		return env, nil
+	}
+
+	strategy, err := c.strategyLister.Strategies(app.Namespace).Get(env.StrategyName)
+	if err != nil {
+		return nil, shippererrors.NewKubeclientGetError(app.Namespace, env.StrategyName, err).
+			WithShipperKind("Strategy")
+	}
+
+	env.Strategy = strategy.Spec.DeepCopy()
+	return env, nil
+}
+
 func (c *Controller) releaseNameForApplication(app *shipper.Application) (string, int, error) {
 	hash := hashReleaseEnvironment(app.Spec.Template)
 	// TODO(asurikov): move the hash to annotations.
@@ -78,7 +142,11 @@ func (c *Controller) releaseNameForApplication(app *shipper.Application) (string
 
 	if len(releases) == 0 {
 		glog.V(3).Infof("No Releases with template %q for Application %q", hash, controller.MetaKey(app))
-		return fmt.Sprintf("%s-%s-%d", app.GetName(), hash, 0), 0, nil
+		name, err := generateReleaseName(app, hash, 0)
+		if err != nil {
+			return "", 0, err
+		}
+		return name, 0, nil
 	}
 
 	highestObserved := 0
@@ -99,7 +167,181 @@ func (c *Controller) releaseNameForApplication(app *shipper.Application) (string
 	}
 
 	newIteration := highestObserved + 1
-	return fmt.Sprintf("%s-%s-%d", app.GetName(), hash, newIteration), newIteration, nil
+	name, err := generateReleaseName(app, hash, newIteration)
+	if err != nil {
+		return "", 0, err
+	}
+	return name, newIteration, nil
+}
+
+// releaseNameTemplateContext is the data an Application's release name
+// template is rendered against. Values gives templates access to user-set
+// chart values, e.g. a git SHA stamped there by CI.
+type releaseNameTemplateContext struct {
+	AppName      string
+	ChartName    string
+	ChartVersion string
+	Values       shipper.ChartValues
+}
+
+// generateReleaseName builds the name for the iteration-th release of the
+// given template hash. The application name is used as the base unless the
+// application declares a release name template, in which case the rendered
+// template takes its place. The hash and iteration suffix is always appended
+// so templated names stay unique, and an over-length base is truncated so the
+// whole name remains usable as a label value.
+func generateReleaseName(app *shipper.Application, hash string, iteration int) (string, error) {
+	base := app.GetName()
+
+	if tmplStr := app.Spec.ReleaseNameTemplate; tmplStr != "" {
+		tmpl, err := template.New("releaseName").Option("missingkey=error").Parse(tmplStr)
+		if err != nil {
+			return "", shippererrors.NewInvalidReleaseNameTemplateError(app.GetName(), tmplStr, err)
+		}
+
+		context := releaseNameTemplateContext{
+			AppName:      app.GetName(),
+			ChartName:    app.Spec.Template.Chart.Name,
+			ChartVersion: app.Spec.Template.Chart.Version,
+		}
+		if app.Spec.Template.Values != nil {
+			context.Values = *app.Spec.Template.Values
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, context); err != nil {
+			return "", shippererrors.NewInvalidReleaseNameTemplateError(app.GetName(), tmplStr, err)
+		}
+
+		base = buf.String()
+	}
+
+	suffix := fmt.Sprintf("-%s-%d", hash, iteration)
+	if len(base)+len(suffix) > validation.LabelValueMaxLength {
+		base = strings.TrimRight(base[:validation.LabelValueMaxLength-len(suffix)], "-._")
+	}
+
+	name := base + suffix
+
+	// Release names double as the value of the release label, so they have
+	// to satisfy both object name and label value rules.
+	msgs := validation.IsDNS1123Subdomain(name)
+	msgs = append(msgs, validation.IsValidLabelValue(name)...)
+	if len(msgs) > 0 {
+		return "", shippererrors.NewInvalidReleaseNameTemplateError(
+			app.GetName(),
+			app.Spec.ReleaseNameTemplate,
+			fmt.Errorf("%q is not a valid release name: %s", name, strings.Join(msgs, ", ")))
+	}
+
+	return name, nil
+}
+
+// validateClusterRequirements checks that every region requested by the
+// application's template is served by at least one registered Cluster, and
+// returns an UnknownRegionError naming the regions that do exist otherwise.
+func (c *Controller) validateClusterRequirements(app *shipper.Application) error {
+	regions := app.Spec.Template.ClusterRequirements.Regions
+	if len(regions) == 0 {
+		return nil
+	}
+
+	selector := labels.Everything()
+	clusters, err := c.clusterLister.List(selector)
+	if err != nil {
+		return shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Cluster"),
+			"", selector, err)
+	}
+
+	registeredRegions := make(map[string]struct{})
+	for _, cluster := range clusters {
+		registeredRegions[cluster.Spec.Region] = struct{}{}
+	}
+
+	for _, region := range regions {
+		if _, ok := registeredRegions[region.Name]; !ok {
+			available := make([]string, 0, len(registeredRegions))
+			for name := range registeredRegions {
+				available = append(available, name)
+			}
+			sort.Strings(available)
+			return shippererrors.NewUnknownRegionError(region.Name, available)
+		}
+	}
+
+	return nil
+}
+
+// environmentDiffSizeLimit bounds the diff annotation: the point is a
+// glanceable summary, not a complete record, and annotation values shouldn't
+// balloon with huge values blocks.
+const environmentDiffSizeLimit = 1024
+
+// environmentDiff summarizes how a release's environment differs from its
+// predecessor's in "<field>: <old> -> <new>" lines, covering the chart
+// coordinates and the top-level chart values. An empty string means neither
+// changed.
+func environmentDiff(old, new *shipper.ReleaseEnvironment) string {
+	var changes []string
+
+	if old.Chart.Name != new.Chart.Name {
+		changes = append(changes, fmt.Sprintf("chart.name: %s -> %s", old.Chart.Name, new.Chart.Name))
+	}
+	if old.Chart.Version != new.Chart.Version {
+		changes = append(changes, fmt.Sprintf("chart.version: %s -> %s", old.Chart.Version, new.Chart.Version))
+	}
+	if old.Chart.RepoURL != new.Chart.RepoURL {
+		changes = append(changes, fmt.Sprintf("chart.repoUrl: %s -> %s", old.Chart.RepoURL, new.Chart.RepoURL))
+	}
+
+	var oldValues, newValues shipper.ChartValues
+	if old.Values != nil {
+		oldValues = *old.Values
+	}
+	if new.Values != nil {
+		newValues = *new.Values
+	}
+
+	keys := make([]string, 0, len(oldValues)+len(newValues))
+	for k := range oldValues {
+		keys = append(keys, k)
+	}
+	for k := range newValues {
+		if _, ok := oldValues[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		oldValue, inOld := oldValues[k]
+		newValue, inNew := newValues[k]
+		switch {
+		case !inOld:
+			changes = append(changes, fmt.Sprintf("values.%s: <none> -> %s", k, renderChartValue(newValue)))
+		case !inNew:
+			changes = append(changes, fmt.Sprintf("values.%s: %s -> <none>", k, renderChartValue(oldValue)))
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, fmt.Sprintf("values.%s: %s -> %s", k, renderChartValue(oldValue), renderChartValue(newValue)))
+		}
+	}
+
+	diff := strings.Join(changes, "; ")
+	if len(diff) > environmentDiffSizeLimit {
+		diff = diff[:environmentDiffSizeLimit] + "... (truncated)"
+	}
+	return diff
+}
+
+// renderChartValue renders a chart value compactly for the diff annotation.
+// JSON keeps nested values readable and unambiguous.
+func renderChartValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
 }
 
 func identicalEnvironments(envs ...shipper.ReleaseEnvironment) bool {
@@ -121,6 +363,14 @@ func identicalEnvironments(envs ...shipper.ReleaseEnvironment) bool {
 
 func hashReleaseEnvironment(env shipper.ReleaseEnvironment) string {
 	copy := env.DeepCopy()
+	// When the environment references a shared Strategy by name the inlined
+	// strategy is a derived snapshot: hashing only the reference keeps
+	// release environments comparable with the application template they
+	// came from, and keeps edits to the shared object from looking like a
+	// template change.
+	if copy.StrategyName != "" {
+		copy.Strategy = nil
+	}
 	b, err := json.Marshal(copy)
 	if err != nil {
 		// TODO(btyler) ???
@@ -0,0 +1,394 @@
+package applicationset
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	clientset "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
+	informers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	listers "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
+)
+
+const (
+	AgentName = "applicationset-controller"
+
+	// maxRetries is the number of times an ApplicationSet will be retried
+	// before we drop it out of the workqueue. The number is chosen with the
+	// default rate limiter in mind. This results in the following backoff
+	// times: 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s,
+	// 5.1s, 10.2s.
+	maxRetries = 11
+)
+
+// Controller is a Kubernetes controller that generates Applications from
+// ApplicationSets.
+type Controller struct {
+	shipperClientset clientset.Interface
+
+	appSetLister listers.ApplicationSetLister
+	appSetSynced cache.InformerSynced
+
+	appLister listers.ApplicationLister
+	appSynced cache.InformerSynced
+
+	clusterLister listers.ClusterLister
+	clusterSynced cache.InformerSynced
+
+	workqueue workqueue.RateLimitingInterface
+
+	recorder record.EventRecorder
+}
+
+// NewController returns a new ApplicationSet controller.
+func NewController(
+	shipperClientset clientset.Interface,
+	shipperInformerFactory informers.SharedInformerFactory,
+	recorder record.EventRecorder,
+) *Controller {
+	appSetInformer := shipperInformerFactory.Shipper().V1alpha1().ApplicationSets()
+	appInformer := shipperInformerFactory.Shipper().V1alpha1().Applications()
+	clusterInformer := shipperInformerFactory.Shipper().V1alpha1().Clusters()
+
+	c := &Controller{
+		shipperClientset: shipperClientset,
+
+		appSetLister: appSetInformer.Lister(),
+		appSetSynced: appSetInformer.Informer().HasSynced,
+
+		appLister: appInformer.Lister(),
+		appSynced: appInformer.Informer().HasSynced,
+
+		clusterLister: clusterInformer.Lister(),
+		clusterSynced: clusterInformer.Informer().HasSynced,
+
+		workqueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "applicationset_controller_applicationsets"),
+
+		recorder: recorder,
+	}
+
+	appSetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueueAppSet,
+		UpdateFunc: func(_, new interface{}) {
+			c.enqueueAppSet(new)
+		},
+		DeleteFunc: c.enqueueAppSet,
+	})
+
+	// Changes to generated Applications (including deletions behind our
+	// back) re-sync the owning set.
+	appInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueueOwningAppSet,
+		UpdateFunc: func(_, new interface{}) {
+			c.enqueueOwningAppSet(new)
+		},
+		DeleteFunc: c.enqueueOwningAppSet,
+	})
+
+	return c
+}
+
+// Run starts ApplicationSet controller workers and blocks until stopCh is
+// closed.
+func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
+	defer runtime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	glog.V(2).Info("Starting ApplicationSet controller")
+	defer glog.V(2).Info("Shutting down ApplicationSet controller")
+
+	if !cache.WaitForCacheSync(stopCh, c.appSetSynced, c.appSynced, c.clusterSynced) {
+		runtime.HandleError(fmt.Errorf("failed to sync caches for the ApplicationSet controller"))
+		return
+	}
+
+	for i := 0; i < threadiness; i++ {
+		go wait.Until(c.applicationSetWorker, time.Second, stopCh)
+	}
+
+	glog.V(2).Info("Started ApplicationSet controller")
+
+	<-stopCh
+}
+
+func (c *Controller) applicationSetWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *Controller) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+	if shutdown {
+		return false
+	}
+
+	defer c.workqueue.Done(obj)
+
+	var (
+		key string
+		ok  bool
+	)
+
+	if key, ok = obj.(string); !ok {
+		c.workqueue.Forget(obj)
+		runtime.HandleError(fmt.Errorf("invalid object key (will retry: false): %#v", obj))
+		return true
+	}
+
+	shouldRetry := false
+	err := c.syncApplicationSet(key)
+
+	if err != nil {
+		shouldRetry = shippererrors.ShouldRetry(err)
+		runtime.HandleError(fmt.Errorf("error syncing ApplicationSet %q (will retry: %t): %s", key, shouldRetry, err.Error()))
+	}
+
+	if shouldRetry {
+		if c.workqueue.NumRequeues(key) >= maxRetries {
+			// Drop the ApplicationSet's key out of the workqueue and thus
+			// reset its backoff. This limits the time a "broken" object can
+			// hog a worker.
+			glog.Warningf("ApplicationSet %q has been retried too many times, dropping from the queue", key)
+			c.workqueue.Forget(key)
+
+			return true
+		}
+
+		c.workqueue.AddRateLimited(key)
+
+		return true
+	}
+
+	shipperhealth.RecordSuccess(AgentName)
+	glog.V(4).Infof("Successfully synced ApplicationSet %q", key)
+	c.workqueue.Forget(obj)
+
+	return true
+}
+
+func (c *Controller) enqueueAppSet(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	c.workqueue.Add(key)
+}
+
+func (c *Controller) enqueueOwningAppSet(obj interface{}) {
+	app, ok := obj.(*shipper.Application)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Application: %#v", obj))
+		return
+	}
+
+	if owner := metav1.GetControllerOf(app); owner != nil && owner.Kind == "ApplicationSet" {
+		c.workqueue.Add(fmt.Sprintf("%s/%s", app.Namespace, owner.Name))
+	}
+}
+
+func (c *Controller) syncApplicationSet(key string) error {
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return shippererrors.NewUnrecoverableError(err)
+	}
+
+	appSet, err := c.appSetLister.ApplicationSets(ns).Get(name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			glog.V(3).Infof("ApplicationSet %q has been deleted", key)
+			return nil
+		}
+
+		return shippererrors.NewKubeclientGetError(ns, name, err).
+			WithShipperKind("ApplicationSet")
+	}
+
+	entries, err := c.generateEntries(appSet)
+	if err != nil {
+		return err
+	}
+
+	desired := make(map[string]struct{}, len(entries))
+	appNames := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		appName := fmt.Sprintf("%s-%s", appSet.Name, entry)
+		desired[appName] = struct{}{}
+		appNames = append(appNames, appName)
+	}
+
+	owned, err := c.listOwnedApplications(appSet)
+	if err != nil {
+		return err
+	}
+
+	for _, app := range owned {
+		if _, ok := desired[app.Name]; ok {
+			continue
+		}
+
+		err := c.shipperClientset.ShipperV1alpha1().Applications(ns).Delete(app.Name, nil)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return shippererrors.NewKubeclientDeleteError(ns, app.Name, err).
+				WithShipperKind("Application")
+		}
+
+		c.recorder.Eventf(appSet, corev1.EventTypeNormal, "ApplicationDeleted",
+			"Deleted Application %q: no longer produced by the generator", app.Name)
+	}
+
+	for _, appName := range appNames {
+		existing, ok := owned[appName]
+		if !ok {
+			app := newApplicationForSet(appSet, appName)
+			_, err := c.shipperClientset.ShipperV1alpha1().Applications(ns).Create(app)
+			if err != nil {
+				return shippererrors.NewKubeclientCreateError(app, err).
+					WithShipperKind("Application")
+			}
+
+			c.recorder.Eventf(appSet, corev1.EventTypeNormal, "ApplicationCreated",
+				"Created Application %q from template", appName)
+			continue
+		}
+
+		if reflect.DeepEqual(existing.Spec, appSet.Spec.Template) {
+			continue
+		}
+
+		appCopy := existing.DeepCopy()
+		appCopy.Spec = *appSet.Spec.Template.DeepCopy()
+		_, err := c.shipperClientset.ShipperV1alpha1().Applications(ns).Update(appCopy)
+		if err != nil {
+			return shippererrors.NewKubeclientUpdateError(appCopy, err).
+				WithShipperKind("Application")
+		}
+
+		c.recorder.Eventf(appSet, corev1.EventTypeNormal, "ApplicationUpdated",
+			"Updated Application %q to match template", appName)
+	}
+
+	if reflect.DeepEqual(appSet.Status.Applications, appNames) {
+		return nil
+	}
+
+	appSetCopy := appSet.DeepCopy()
+	appSetCopy.Status.Applications = appNames
+	_, err = c.shipperClientset.ShipperV1alpha1().ApplicationSets(ns).Update(appSetCopy)
+	if err != nil {
+		return shippererrors.NewKubeclientUpdateError(appSetCopy, err).
+			WithShipperKind("ApplicationSet")
+	}
+
+	return nil
+}
+
+// generateEntries runs the set's generator and returns the entries it
+// produces, one Application each.
+func (c *Controller) generateEntries(appSet *shipper.ApplicationSet) ([]string, error) {
+	generator := appSet.Spec.Generator
+
+	if len(generator.List) > 0 {
+		return append([]string(nil), generator.List...), nil
+	}
+
+	if generator.Clusters != nil {
+		selector := labels.Everything()
+		if len(generator.Clusters.ClusterSelector) > 0 {
+			selector = labels.Set(generator.Clusters.ClusterSelector).AsSelector()
+		}
+
+		clusters, err := c.clusterLister.List(selector)
+		if err != nil {
+			return nil, shippererrors.NewKubeclientListError(
+				shipper.SchemeGroupVersion.WithKind("Cluster"),
+				"", selector, err)
+		}
+
+		regionSet := make(map[string]struct{})
+		regions := make([]string, 0)
+		for _, cluster := range clusters {
+			region := cluster.Spec.Region
+			if _, ok := regionSet[region]; ok || region == "" {
+				continue
+			}
+			regionSet[region] = struct{}{}
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+
+		return regions, nil
+	}
+
+	err := fmt.Errorf("ApplicationSet %q defines no generator", fmt.Sprintf("%s/%s", appSet.Namespace, appSet.Name))
+	return nil, shippererrors.NewUnrecoverableError(err)
+}
+
+// listOwnedApplications returns the Applications in the set's namespace that
+// the set controls, keyed by name.
+func (c *Controller) listOwnedApplications(appSet *shipper.ApplicationSet) (map[string]*shipper.Application, error) {
+	apps, err := c.appLister.Applications(appSet.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Application"),
+			appSet.Namespace, labels.Everything(), err)
+	}
+
+	owned := make(map[string]*shipper.Application)
+	for _, app := range apps {
+		if metav1.IsControlledBy(app, appSet) {
+			owned[app.Name] = app
+		}
+	}
+
+	return owned, nil
+}
+
+// newApplicationForSet stamps out one Application from the set's template,
+// owned (and thus garbage-collected) by the set.
+func newApplicationForSet(appSet *shipper.ApplicationSet, appName string) *shipper.Application {
+	app := &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: appSet.Namespace,
+			Labels:    make(map[string]string, len(appSet.Labels)),
+			OwnerReferences: []metav1.OwnerReference{
+				createOwnerRefFromApplicationSet(appSet),
+			},
+		},
+		Spec: *appSet.Spec.Template.DeepCopy(),
+	}
+
+	for k, v := range appSet.Labels {
+		app.Labels[k] = v
+	}
+
+	return app
+}
+
+func createOwnerRefFromApplicationSet(appSet *shipper.ApplicationSet) metav1.OwnerReference {
+	controlled := true
+	return metav1.OwnerReference{
+		APIVersion: shipper.SchemeGroupVersion.String(),
+		Kind:       "ApplicationSet",
+		Name:       appSet.Name,
+		UID:        appSet.UID,
+		Controller: &controlled,
+	}
+}
@@ -0,0 +1,193 @@
+package applicationset
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+// TestGenerateApplicationPerRegion drives an ApplicationSet with a cluster
+// generator over a fleet spanning three regions and checks that exactly one
+// Application is stamped out per region, plus the set's status update.
+func TestGenerateApplicationPerRegion(t *testing.T) {
+	f := newFixture(t)
+
+	appSet := buildApplicationSet()
+	appSet.Spec.Generator = shipper.ApplicationSetGenerator{
+		Clusters: &shipper.ClusterApplicationSetGenerator{},
+	}
+
+	f.objects = append(f.objects,
+		appSet,
+		buildCluster("cluster-eu-1", "eu-west"),
+		buildCluster("cluster-eu-2", "eu-west"),
+		buildCluster("cluster-us-1", "us-east"),
+		buildCluster("cluster-apac-1", "apac"),
+	)
+
+	f.expectApplicationCreate(buildExpectedApplication(appSet, "test-set-apac"))
+	f.expectApplicationCreate(buildExpectedApplication(appSet, "test-set-eu-west"))
+	f.expectApplicationCreate(buildExpectedApplication(appSet, "test-set-us-east"))
+
+	updatedAppSet := appSet.DeepCopy()
+	updatedAppSet.Status.Applications = []string{"test-set-apac", "test-set-eu-west", "test-set-us-east"}
+	f.expectApplicationSetUpdate(updatedAppSet)
+
+	f.run()
+}
+
+// TestStaleApplicationDeleted checks that an Application the generator no
+// longer produces is deleted, while the still-generated one is left alone.
+func TestStaleApplicationDeleted(t *testing.T) {
+	f := newFixture(t)
+
+	appSet := buildApplicationSet()
+	appSet.Spec.Generator = shipper.ApplicationSetGenerator{
+		List: []string{"foo"},
+	}
+	appSet.Status.Applications = []string{"test-set-foo"}
+
+	f.objects = append(f.objects,
+		appSet,
+		buildExpectedApplication(appSet, "test-set-foo"),
+		buildExpectedApplication(appSet, "test-set-bar"),
+	)
+
+	f.expectApplicationDelete(buildExpectedApplication(appSet, "test-set-bar"))
+
+	f.run()
+}
+
+func buildApplicationSet() *shipper.ApplicationSet {
+	return &shipper.ApplicationSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-set",
+			Namespace: shippertesting.TestNamespace,
+			UID:       "foobarbaz",
+			Labels: map[string]string{
+				"team": "test-team",
+			},
+		},
+		Spec: shipper.ApplicationSetSpec{
+			Template: shipper.ApplicationSpec{
+				Template: shipper.ReleaseEnvironment{
+					Chart: shipper.Chart{
+						Name:    "simple",
+						Version: "0.0.1",
+						RepoURL: "http://127.0.0.1:8879/charts",
+					},
+					ClusterRequirements: shipper.ClusterRequirements{},
+					Values:              &shipper.ChartValues{},
+				},
+			},
+		},
+	}
+}
+
+func buildCluster(name, region string) *shipper.Cluster {
+	return &shipper.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: shipper.ClusterSpec{
+			Region: region,
+		},
+	}
+}
+
+func buildExpectedApplication(appSet *shipper.ApplicationSet, name string) *shipper.Application {
+	controlled := true
+	return &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: appSet.Namespace,
+			Labels: map[string]string{
+				"team": "test-team",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: shipper.SchemeGroupVersion.String(),
+					Kind:       "ApplicationSet",
+					Name:       appSet.Name,
+					UID:        appSet.UID,
+					Controller: &controlled,
+				},
+			},
+		},
+		Spec: *appSet.Spec.Template.DeepCopy(),
+	}
+}
+
+type fixture struct {
+	t       *testing.T
+	client  *shipperfake.Clientset
+	actions []kubetesting.Action
+	objects []runtime.Object
+}
+
+func newFixture(t *testing.T) *fixture {
+	return &fixture{t: t}
+}
+
+func (f *fixture) newController() (*Controller, shipperinformers.SharedInformerFactory) {
+	f.client = shipperfake.NewSimpleClientset(f.objects...)
+
+	const noResyncPeriod time.Duration = 0
+	shipperInformerFactory := shipperinformers.NewSharedInformerFactory(f.client, noResyncPeriod)
+
+	c := NewController(f.client, shipperInformerFactory, record.NewFakeRecorder(42))
+
+	return c, shipperInformerFactory
+}
+
+func (f *fixture) run() {
+	c, i := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	i.Start(stopCh)
+	i.WaitForCacheSync(stopCh)
+
+	wait.PollUntil(
+		10*time.Millisecond,
+		func() (bool, error) { return c.workqueue.Len() >= 1, nil },
+		stopCh,
+	)
+
+	c.processNextWorkItem()
+
+	actual := shippertesting.FilterActions(f.client.Actions())
+	shippertesting.CheckActions(f.actions, actual, f.t)
+}
+
+func (f *fixture) expectApplicationCreate(app *shipper.Application) {
+	gvr := shipper.SchemeGroupVersion.WithResource("applications")
+	action := kubetesting.NewCreateAction(gvr, app.GetNamespace(), app)
+
+	f.actions = append(f.actions, action)
+}
+
+func (f *fixture) expectApplicationDelete(app *shipper.Application) {
+	gvr := shipper.SchemeGroupVersion.WithResource("applications")
+	action := kubetesting.NewDeleteAction(gvr, app.GetNamespace(), app.GetName())
+
+	f.actions = append(f.actions, action)
+}
+
+func (f *fixture) expectApplicationSetUpdate(appSet *shipper.ApplicationSet) {
+	gvr := shipper.SchemeGroupVersion.WithResource("applicationsets")
+	action := kubetesting.NewUpdateAction(gvr, appSet.GetNamespace(), appSet)
+
+	f.actions = append(f.actions, action)
+}
@@ -3,12 +3,14 @@ package capacity
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/runtime"
@@ -17,6 +19,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
@@ -25,7 +28,9 @@ import (
 	listers "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
 	"github.com/bookingcom/shipper/pkg/clusterclientstore"
 	"github.com/bookingcom/shipper/pkg/conditions"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
 	"github.com/bookingcom/shipper/pkg/util/replicas"
 )
 
@@ -49,8 +54,13 @@ type Controller struct {
 	releasesLister          listers.ReleaseLister
 	releasesListerSynced    cache.InformerSynced
 	capacityTargetWorkqueue workqueue.RateLimitingInterface
-	deploymentWorkqueue     workqueue.RateLimitingInterface
+	workloadWorkqueue       workqueue.RateLimitingInterface
 	recorder                record.EventRecorder
+
+	// forceDeleteStuckPodsAfter is how long a pod may stay terminating
+	// before the controller force-deletes it so a scale-down can't block
+	// a rollout indefinitely. Zero disables force deletion.
+	forceDeleteStuckPodsAfter time.Duration
 }
 
 // NewController returns a new CapacityTarget controller.
@@ -59,6 +69,7 @@ func NewController(
 	shipperInformerFactory informers.SharedInformerFactory,
 	store clusterClientStoreInterface,
 	recorder record.EventRecorder,
+	forceDeleteStuckPodsAfter time.Duration,
 ) *Controller {
 
 	capacityTargetInformer := shipperInformerFactory.Shipper().V1alpha1().CapacityTargets()
@@ -72,9 +83,11 @@ func NewController(
 		releasesLister:          releaseInformer.Lister(),
 		releasesListerSynced:    releaseInformer.Informer().HasSynced,
 		capacityTargetWorkqueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "capacity_controller_capacitytargets"),
-		deploymentWorkqueue:     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "capacity_controller_deployments"),
+		workloadWorkqueue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "capacity_controller_workloads"),
 		recorder:                recorder,
 		clusterClientStore:      store,
+
+		forceDeleteStuckPodsAfter: forceDeleteStuckPodsAfter,
 	}
 
 	glog.Info("Setting up event handlers")
@@ -98,7 +111,7 @@ func NewController(
 func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 	defer runtime.HandleCrash()
 	defer c.capacityTargetWorkqueue.ShutDown()
-	defer c.deploymentWorkqueue.ShutDown()
+	defer c.workloadWorkqueue.ShutDown()
 
 	glog.V(2).Info("Starting Capacity controller")
 	defer glog.V(2).Info("Shutting down Capacity controller")
@@ -110,7 +123,7 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 
 	for i := 0; i < threadiness; i++ {
 		go wait.Until(c.runCapacityTargetWorker, time.Second, stopCh)
-		go wait.Until(c.runDeploymentWorker, time.Second, stopCh)
+		go wait.Until(c.runWorkloadWorker, time.Second, stopCh)
 	}
 
 	glog.V(4).Info("Started Capacity controller")
@@ -165,6 +178,7 @@ func (c *Controller) processNextCapacityTargetWorkItem() bool {
 		return true
 	}
 
+	shipperhealth.RecordSuccess(AgentName)
 	glog.V(4).Infof("Successfully synced CapacityTarget %q", key)
 	c.capacityTargetWorkqueue.Forget(obj)
 
@@ -194,11 +208,15 @@ func (c *Controller) capacityTargetSyncHandler(key string) error {
 	selector := labels.Set(ct.Labels).AsSelector()
 	clusterErrors := shippererrors.NewMultiError()
 
+	// Human-readable summary of what this reconcile changed, stamped as a
+	// debug annotation when the debug flag is on.
+	var reconcileDiff []string
+
 	for _, clusterSpec := range ct.Spec.Clusters {
 		// clusterStatus will be modified by functions called in this loop as a side
 		// effect.
 		var clusterStatus *shipper.ClusterCapacityStatus
-		var targetDeployment *appsv1.Deployment
+		var workload *targetWorkload
 
 		if ct.Status.Clusters == nil {
 			ct.Status.Clusters = []shipper.ClusterCapacityStatus{}
@@ -222,7 +240,7 @@ func (c *Controller) capacityTargetSyncHandler(key string) error {
 
 		// all the below functions add conditions to the clusterStatus as they do
 		// their business, hence we're passing them a pointer.
-		targetDeployment, err := c.findTargetDeploymentForClusterSpec(clusterSpec, targetNamespace, selector, clusterStatus)
+		workload, err := c.findTargetWorkloadForClusterSpec(clusterSpec, targetNamespace, selector, clusterStatus)
 		if err != nil {
 			clusterErrors.Append(err)
 			continue
@@ -232,32 +250,135 @@ func (c *Controller) capacityTargetSyncHandler(key string) error {
 		// only set by the scheduler.
 		replicaCount := int32(replicas.CalculateDesiredReplicaCount(uint(clusterSpec.TotalReplicaCount), float64(clusterSpec.Percent)))
 
-		// Patch the deployment if it doesn't match the cluster spec.
-		if targetDeployment.Spec.Replicas == nil || replicaCount != *targetDeployment.Spec.Replicas {
-			_, err = c.patchDeploymentWithReplicaCount(targetDeployment, clusterSpec.Name, replicaCount, clusterStatus)
+		// A suspended cluster keeps the release installed but runs zero
+		// replicas until the flag is cleared.
+		if clusterSpec.Suspended {
+			replicaCount = 0
+		}
+
+		// Patch the workload if it doesn't match the cluster spec. DaemonSets
+		// size themselves to schedulable nodes, so they're never patched.
+		// Scale-downs happen at most as fast as the workload's
+		// PodDisruptionBudgets allow, and scale-ups at most as fast as the
+		// target's ramp annotations allow, stepping towards the target over
+		// several syncs if necessary.
+		pdbLimited := false
+		rampLimited := false
+		if workload.scalable && (workload.specReplicas == nil || replicaCount != *workload.specReplicas) {
+			var oldReplicaCount int32
+			if workload.specReplicas != nil {
+				oldReplicaCount = *workload.specReplicas
+			}
+
+			stepReplicaCount, err := c.limitScaleDownForPDBs(workload, clusterSpec.Name, oldReplicaCount, replicaCount, clusterStatus)
+			if err != nil {
+				clusterErrors.Append(err)
+			} else {
+				pdbLimited = stepReplicaCount != replicaCount
+
+				rampReplicaCount, rampRemaining := c.limitScaleUpForRamp(ct, oldReplicaCount, stepReplicaCount, clusterStatus)
+				rampLimited = rampReplicaCount != stepReplicaCount
+				stepReplicaCount = rampReplicaCount
+				if rampRemaining > 0 {
+					c.capacityTargetWorkqueue.AddAfter(key, rampRemaining)
+				}
+
+				if stepReplicaCount != oldReplicaCount {
+					err = c.patchWorkloadWithReplicaCount(workload, clusterSpec.Name, stepReplicaCount, clusterStatus)
+					if err != nil {
+						clusterErrors.Append(err)
+					} else {
+						reconcileDiff = append(reconcileDiff, fmt.Sprintf("scaled %s from %d to %d", clusterSpec.Name, oldReplicaCount, stepReplicaCount))
+					}
+				}
+			}
+		}
+
+		clusterStatus.AvailableReplicas = workload.readiness.AvailableReplicas
+
+		// With MinReadySeconds set, the workload's own availability count
+		// isn't precise enough: recount from the pods so one only counts
+		// once it has been ready for the required duration.
+		if ct.Spec.MinReadySeconds > 0 {
+			available, nextMature, err := c.availableForMinReadySeconds(workload, clusterSpec.Name, ct.Spec.MinReadySeconds)
 			if err != nil {
 				clusterErrors.Append(err)
+			} else {
+				clusterStatus.AvailableReplicas = available
+				if nextMature > 0 {
+					c.capacityTargetWorkqueue.AddAfter(key, nextMature)
+				}
 			}
 		}
 
-		clusterStatus.AvailableReplicas = targetDeployment.Status.AvailableReplicas
-		clusterStatus.AchievedPercent = c.calculatePercentageFromAmount(clusterSpec.TotalReplicaCount, clusterStatus.AvailableReplicas)
+		if workload.scalable {
+			clusterStatus.AchievedPercent = c.calculatePercentageFromAmount(clusterSpec.TotalReplicaCount, clusterStatus.AvailableReplicas)
+			clusterStatus.DesiredReplicas = replicaCount
+		} else {
+			// The workload's own desired count is the whole truth here: for a
+			// DaemonSet it is however many nodes can schedule it.
+			clusterStatus.DesiredReplicas = workload.readiness.DesiredReplicas
+			if workload.readiness.DesiredReplicas > 0 {
+				clusterStatus.AchievedPercent = c.calculatePercentageFromAmount(workload.readiness.DesiredReplicas, clusterStatus.AvailableReplicas)
+			} else {
+				clusterStatus.AchievedPercent = 100
+			}
+		}
+		clusterStatus.CurrentReplicas = workload.readiness.CurrentReplicas
+		clusterStatus.ReadyReplicas = workload.readiness.ReadyReplicas
 
-		report, err := c.getReport(targetDeployment, clusterStatus)
+		report, err := c.getReport(workload, clusterStatus)
 		if err != nil {
 			clusterErrors.Append(err)
 		} else {
 			clusterStatus.Reports = append(clusterStatus.Reports, *report)
 		}
 
-		sadPods, clusterOk, err := c.getSadPods(targetDeployment, clusterStatus)
+		sadPods, clusterOk, err := c.getSadPods(workload, clusterStatus)
 		if err != nil {
 			clusterErrors.Append(err)
 		} else {
 			clusterStatus.SadPods = sadPods
+			clusterStatus.UnavailableReasons = summarizeUnavailableReasons(sadPods)
+		}
+
+		instabilityEvents, err := c.countInstabilityEvents(workload, clusterSpec.Name, clusterStatus)
+		if err != nil {
+			clusterErrors.Append(err)
+		} else {
+			clusterStatus.InstabilityEvents = instabilityEvents
+		}
+
+		// Pods left terminating after a scale-down are reported
+		// separately, and force-deleted if they've been at it past the
+		// configured timeout so they can't block the rollout forever.
+		terminating, nextStuck, err := c.handleTerminatingPods(workload, clusterSpec.Name)
+		if err != nil {
+			clusterErrors.Append(err)
+		} else {
+			clusterStatus.TerminatingReplicas = terminating
+			if nextStuck > 0 {
+				c.capacityTargetWorkqueue.AddAfter(key, nextStuck)
+			}
+		}
+
+		// A suspended cluster reports why it is serving nothing, whatever
+		// state its drain is in.
+		if clusterSpec.Suspended {
+			clusterStatus.Conditions = conditions.SetCapacityCondition(
+				clusterStatus.Conditions,
+				shipper.ClusterConditionTypeReady,
+				corev1.ConditionFalse,
+				conditions.ClusterSuspended,
+				"cluster is suspended; running zero replicas")
+			ct.Status.Clusters = append(ct.Status.Clusters, *clusterStatus)
+			continue
 		}
 
-		if clusterOk {
+		// A PDB-limited scale-down keeps its WaitingForPDB condition, and a
+		// ramped scale-up its RampingUp condition: the cluster isn't ready
+		// until the workload reaches the target count.
+		if clusterOk && !pdbLimited && !rampLimited {
 			clusterStatus.Conditions = conditions.SetCapacityCondition(
 				clusterStatus.Conditions,
 				shipper.ClusterConditionTypeReady,
@@ -288,14 +409,49 @@ func (c *Controller) capacityTargetSyncHandler(key string) error {
 
 	sort.Sort(byClusterName(ct.Status.Clusters))
 
-	_, err = c.shipperclientset.ShipperV1alpha1().CapacityTargets(namespace).Update(ct)
-	if err != nil {
+	if shippercontroller.DebugReconcileDiff {
+		diff := "no changes"
+		if len(reconcileDiff) > 0 {
+			diff = strings.Join(reconcileDiff, "; ")
+		}
+		if ct.Annotations == nil {
+			ct.Annotations = map[string]string{}
+		}
+		ct.Annotations[shipper.DebugLastReconcileDiffAnnotation] = diff
+	}
+
+	if err := c.updateCapacityTargetStatus(ct); err != nil {
 		clusterErrors.Append(shippererrors.NewKubeclientUpdateError(ct, err))
 	}
 
 	return clusterErrors.Flatten()
 }
 
+// updateCapacityTargetStatus writes a capacity target's computed status,
+// retrying resource version conflicts by re-applying the status to a fresh
+// copy of the object.
+func (c *Controller) updateCapacityTargetStatus(ct *shipper.CapacityTarget) error {
+	status := ct.Status
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		_, updateErr := c.shipperclientset.ShipperV1alpha1().CapacityTargets(ct.Namespace).Update(ct)
+		if updateErr == nil || !kerrors.IsConflict(updateErr) {
+			return updateErr
+		}
+
+		fresh, getErr := c.shipperclientset.ShipperV1alpha1().CapacityTargets(ct.Namespace).Get(ct.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+
+		fresh = fresh.DeepCopy()
+		fresh.Status = status
+		ct = fresh
+
+		return updateErr
+	})
+}
+
 func (c *Controller) enqueueCapacityTarget(obj interface{}) {
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	if err != nil {
@@ -307,12 +463,17 @@ func (c *Controller) enqueueCapacityTarget(obj interface{}) {
 }
 
 func (c *Controller) registerEventHandlers(informerFactory kubeinformers.SharedInformerFactory, clusterName string) {
-	informerFactory.Apps().V1().Deployments().Informer().AddEventHandler(c.NewDeploymentResourceEventHandler(clusterName))
+	informerFactory.Apps().V1().Deployments().Informer().AddEventHandler(c.NewWorkloadResourceEventHandler("Deployment", clusterName))
+	informerFactory.Apps().V1().StatefulSets().Informer().AddEventHandler(c.NewWorkloadResourceEventHandler("StatefulSet", clusterName))
+	informerFactory.Apps().V1().DaemonSets().Informer().AddEventHandler(c.NewWorkloadResourceEventHandler("DaemonSet", clusterName))
 }
 
 func (c *Controller) subscribe(informerFactory kubeinformers.SharedInformerFactory) {
 	informerFactory.Apps().V1().Deployments().Informer()
+	informerFactory.Apps().V1().StatefulSets().Informer()
+	informerFactory.Apps().V1().DaemonSets().Informer()
 	informerFactory.Core().V1().Pods().Informer()
+	informerFactory.Policy().V1beta1().PodDisruptionBudgets().Informer()
 }
 
 type clusterClientStoreInterface interface {
@@ -322,8 +483,8 @@ type clusterClientStoreInterface interface {
 	GetInformerFactory(string) (kubeinformers.SharedInformerFactory, error)
 }
 
-func (c *Controller) getSadPods(targetDeployment *appsv1.Deployment, clusterStatus *shipper.ClusterCapacityStatus) ([]shipper.PodStatus, bool, error) {
-	podCount, sadPodsCount, sadPods, err := c.getSadPodsForDeploymentOnCluster(targetDeployment, clusterStatus.Name)
+func (c *Controller) getSadPods(workload *targetWorkload, clusterStatus *shipper.ClusterCapacityStatus) ([]shipper.PodStatus, bool, error) {
+	podCount, sadPodsCount, sadPods, err := c.getSadPodsForWorkloadOnCluster(workload, clusterStatus.Name)
 	if err != nil {
 		clusterStatus.Conditions = conditions.SetCapacityCondition(
 			clusterStatus.Conditions,
@@ -335,13 +496,13 @@ func (c *Controller) getSadPods(targetDeployment *appsv1.Deployment, clusterStat
 		return nil, false, err
 	}
 
-	if targetDeployment.Spec.Replicas == nil || int(*targetDeployment.Spec.Replicas) != podCount {
+	if int(workload.readiness.DesiredReplicas) != podCount {
 		clusterStatus.Conditions = conditions.SetCapacityCondition(
 			clusterStatus.Conditions,
 			shipper.ClusterConditionTypeReady,
 			corev1.ConditionFalse,
 			conditions.WrongPodCount,
-			fmt.Sprintf("expected %d replicas but have %d", *targetDeployment.Spec.Replicas, int32(podCount)))
+			fmt.Sprintf("expected %d replicas but have %d", workload.readiness.DesiredReplicas, int32(podCount)))
 
 		return sadPods, false, nil
 	}
@@ -358,7 +519,112 @@ func (c *Controller) getSadPods(targetDeployment *appsv1.Deployment, clusterStat
 	return sadPods, sadPodsCount == 0, nil
 }
 
-func (c *Controller) getReport(targetDeployment *appsv1.Deployment, clusterStatus *shipper.ClusterCapacityStatus) (*shipper.ClusterCapacityReport, error) {
+// availableForMinReadySeconds recounts the workload's available replicas
+// from its pods, honoring the target's MinReadySeconds precisely. The
+// returned duration says when the next ready-but-young pod matures, so the
+// caller can re-sync exactly then instead of waiting for a resync.
+func (c *Controller) availableForMinReadySeconds(workload *targetWorkload, clusterName string, minReadySeconds int32) (int32, time.Duration, error) {
+	informer, err := c.clusterClientStore.GetInformerFactory(clusterName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(workload.selector)
+	if err != nil {
+		return 0, 0, shippererrors.NewUnrecoverableError(fmt.Errorf("failed to transform label selector %v into a selector: %s", workload.selector, err))
+	}
+
+	pods, err := informer.Core().V1().Pods().Lister().Pods(workload.namespace).List(selector)
+	if err != nil {
+		return 0, 0, shippererrors.NewKubeclientListError(
+			corev1.SchemeGroupVersion.WithKind("Pod"),
+			workload.namespace, selector, err)
+	}
+
+	available, nextMature := availableFromPods(pods, minReadySeconds, time.Now())
+
+	return available, nextMature, nil
+}
+
+// handleTerminatingPods counts the workload's pods that are shutting down on
+// the cluster and, when force deletion is enabled, force-deletes the ones
+// that have been terminating past the configured timeout — typically pods
+// held back by a stuck finalizer or an overlong grace period after a
+// scale-down. It also returns how long until the next terminating pod counts
+// as stuck, so the caller can re-sync exactly then.
+func (c *Controller) handleTerminatingPods(workload *targetWorkload, clusterName string) (int32, time.Duration, error) {
+	informer, err := c.clusterClientStore.GetInformerFactory(clusterName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(workload.selector)
+	if err != nil {
+		return 0, 0, shippererrors.NewUnrecoverableError(fmt.Errorf("failed to transform label selector %v into a selector: %s", workload.selector, err))
+	}
+
+	pods, err := informer.Core().V1().Pods().Lister().Pods(workload.namespace).List(selector)
+	if err != nil {
+		return 0, 0, shippererrors.NewKubeclientListError(
+			corev1.SchemeGroupVersion.WithKind("Pod"),
+			workload.namespace, selector, err)
+	}
+
+	terminating, stuck, nextStuck := terminatingFromPods(pods, c.forceDeleteStuckPodsAfter, time.Now())
+
+	if len(stuck) == 0 {
+		return terminating, nextStuck, nil
+	}
+
+	client, err := c.clusterClientStore.GetClient(clusterName, AgentName)
+	if err != nil {
+		return terminating, nextStuck, err
+	}
+
+	for _, pod := range stuck {
+		glog.Infof("Force-deleting pod %s/%s on cluster %q: terminating since %s",
+			pod.Namespace, pod.Name, clusterName, pod.DeletionTimestamp)
+
+		err := client.CoreV1().Pods(pod.Namespace).Delete(pod.Name, metav1.NewDeleteOptions(0))
+		if err != nil && !kerrors.IsNotFound(err) {
+			return terminating, nextStuck, shippererrors.
+				NewKubeclientDeleteError(pod.Namespace, pod.Name, err).
+				WithCoreV1Kind("Pod")
+		}
+	}
+
+	return terminating, nextStuck, nil
+}
+
+// summarizeUnavailableReasons aggregates the waiting reasons of sad pods'
+// containers into "<Reason>: <count>" lines, so that e.g. a fleet stuck in
+// ImagePullBackOff is visible straight from the capacity target status.
+func summarizeUnavailableReasons(sadPods []shipper.PodStatus) []string {
+	counts := make(map[string]int)
+	for _, pod := range sadPods {
+		for _, containers := range [][]corev1.ContainerStatus{pod.InitContainers, pod.Containers} {
+			for _, container := range containers {
+				if container.State.Waiting != nil && container.State.Waiting.Reason != "" {
+					counts[container.State.Waiting.Reason]++
+				}
+			}
+		}
+	}
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	reasons := make([]string, 0, len(counts))
+	for reason, count := range counts {
+		reasons = append(reasons, fmt.Sprintf("%s: %d", reason, count))
+	}
+	sort.Strings(reasons)
+
+	return reasons
+}
+
+func (c *Controller) getReport(workload *targetWorkload, clusterStatus *shipper.ClusterCapacityStatus) (*shipper.ClusterCapacityReport, error) {
 	targetClusterInformer, err := c.clusterClientStore.GetInformerFactory(clusterStatus.Name)
 	if err != nil {
 		// Not sure if each method should report operational conditions for
@@ -366,20 +632,29 @@ func (c *Controller) getReport(targetDeployment *appsv1.Deployment, clusterStatu
 		return nil, err
 	}
 
-	selector := labels.Set(targetDeployment.Spec.Template.Labels).AsSelector()
-	podsList, err := targetClusterInformer.Core().V1().Pods().Lister().Pods(targetDeployment.Namespace).List(selector)
+	selector := labels.Set(workload.templateLabels).AsSelector()
+	podsList, err := targetClusterInformer.Core().V1().Pods().Lister().Pods(workload.namespace).List(selector)
 	if err != nil {
 		return nil, shippererrors.NewKubeclientListError(
 			corev1.SchemeGroupVersion.WithKind("Pod"),
-			targetDeployment.Namespace, selector, err)
+			workload.namespace, selector, err)
 	}
 
-	report := buildReport(targetDeployment.Name, podsList)
+	// Terminating pods show up in TerminatingReplicas, not in the fleet
+	// report.
+	running := make([]*corev1.Pod, 0, len(podsList))
+	for _, pod := range podsList {
+		if pod.DeletionTimestamp == nil {
+			running = append(running, pod)
+		}
+	}
+
+	report := buildReport(workload.name, running)
 
 	return report, nil
 }
 
-func (c *Controller) findTargetDeploymentForClusterSpec(clusterSpec shipper.ClusterCapacityTarget, targetNamespace string, selector labels.Selector, clusterStatus *shipper.ClusterCapacityStatus) (*appsv1.Deployment, error) {
+func (c *Controller) findTargetWorkloadForClusterSpec(clusterSpec shipper.ClusterCapacityTarget, targetNamespace string, selector labels.Selector, clusterStatus *shipper.ClusterCapacityStatus) (*targetWorkload, error) {
 	targetClusterInformer, err := c.clusterClientStore.GetInformerFactory(clusterSpec.Name)
 	if err != nil {
 		clusterStatus.Conditions = conditions.SetCapacityCondition(
@@ -393,6 +668,8 @@ func (c *Controller) findTargetDeploymentForClusterSpec(clusterSpec shipper.Clus
 		return nil, err
 	}
 
+	var workloads []*targetWorkload
+
 	deploymentsList, err := targetClusterInformer.Apps().V1().Deployments().Lister().Deployments(targetNamespace).List(selector)
 	if err != nil {
 		clusterStatus.Conditions = conditions.SetCapacityCondition(
@@ -407,10 +684,49 @@ func (c *Controller) findTargetDeploymentForClusterSpec(clusterSpec shipper.Clus
 			appsv1.SchemeGroupVersion.WithKind("Deployment"),
 			targetNamespace, selector, err)
 	}
+	for _, deployment := range deploymentsList {
+		workloads = append(workloads, deploymentWorkload(deployment))
+	}
+
+	statefulSetsList, err := targetClusterInformer.Apps().V1().StatefulSets().Lister().StatefulSets(targetNamespace).List(selector)
+	if err != nil {
+		clusterStatus.Conditions = conditions.SetCapacityCondition(
+			clusterStatus.Conditions,
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionFalse,
+			conditions.ServerError,
+			err.Error(),
+		)
+
+		return nil, shippererrors.NewKubeclientListError(
+			appsv1.SchemeGroupVersion.WithKind("StatefulSet"),
+			targetNamespace, selector, err)
+	}
+	for _, statefulSet := range statefulSetsList {
+		workloads = append(workloads, statefulSetWorkload(statefulSet))
+	}
+
+	daemonSetsList, err := targetClusterInformer.Apps().V1().DaemonSets().Lister().DaemonSets(targetNamespace).List(selector)
+	if err != nil {
+		clusterStatus.Conditions = conditions.SetCapacityCondition(
+			clusterStatus.Conditions,
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionFalse,
+			conditions.ServerError,
+			err.Error(),
+		)
 
-	if l := len(deploymentsList); l != 1 {
+		return nil, shippererrors.NewKubeclientListError(
+			appsv1.SchemeGroupVersion.WithKind("DaemonSet"),
+			targetNamespace, selector, err)
+	}
+	for _, daemonSet := range daemonSetsList {
+		workloads = append(workloads, daemonSetWorkload(daemonSet))
+	}
+
+	if l := len(workloads); l != 1 {
 		err = fmt.Errorf(
-			"expected exactly 1 deployment on cluster %s, namespace %s, with label %s, but %d deployments exist",
+			"expected exactly 1 workload on cluster %s, namespace %s, with label %s, but %d workloads exist",
 			clusterSpec.Name, targetNamespace, selector.String(), l)
 
 		clusterStatus.Conditions = conditions.SetCapacityCondition(
@@ -424,12 +740,10 @@ func (c *Controller) findTargetDeploymentForClusterSpec(clusterSpec shipper.Clus
 		return nil, shippererrors.NewRecoverableError(err)
 	}
 
-	targetDeployment := deploymentsList[0]
-
-	return targetDeployment, nil
+	return workloads[0], nil
 }
 
-func (c *Controller) patchDeploymentWithReplicaCount(targetDeployment *appsv1.Deployment, clusterName string, replicaCount int32, clusterStatus *shipper.ClusterCapacityStatus) (*appsv1.Deployment, error) {
+func (c *Controller) patchWorkloadWithReplicaCount(workload *targetWorkload, clusterName string, replicaCount int32, clusterStatus *shipper.ClusterCapacityStatus) error {
 	targetClusterClient, err := c.clusterClientStore.GetClient(clusterName, AgentName)
 	if err != nil {
 		clusterStatus.Conditions = conditions.SetCapacityCondition(
@@ -440,12 +754,19 @@ func (c *Controller) patchDeploymentWithReplicaCount(targetDeployment *appsv1.De
 			err.Error(),
 		)
 
-		return nil, err
+		return err
 	}
 
 	patchString := fmt.Sprintf(`{"spec": {"replicas": %d}}`, replicaCount)
 
-	updatedDeployment, err := targetClusterClient.AppsV1().Deployments(targetDeployment.Namespace).Patch(targetDeployment.Name, types.StrategicMergePatchType, []byte(patchString))
+	switch workload.kind {
+	case "Deployment":
+		_, err = targetClusterClient.AppsV1().Deployments(workload.namespace).Patch(workload.name, types.StrategicMergePatchType, []byte(patchString))
+	case "StatefulSet":
+		_, err = targetClusterClient.AppsV1().StatefulSets(workload.namespace).Patch(workload.name, types.StrategicMergePatchType, []byte(patchString))
+	default:
+		err = fmt.Errorf("cannot scale workload of kind %q", workload.kind)
+	}
 	if err != nil {
 		clusterStatus.Conditions = conditions.SetCapacityCondition(
 			clusterStatus.Conditions,
@@ -455,8 +776,9 @@ func (c *Controller) patchDeploymentWithReplicaCount(targetDeployment *appsv1.De
 			err.Error(),
 		)
 
-		return nil, shippererrors.NewKubeclientUpdateError(targetDeployment, err)
+		return shippererrors.NewKubeclientError(shippererrors.KubeclientVerbPatch, workload.namespace, workload.name, err).
+			WithKind(appsv1.SchemeGroupVersion.WithKind(workload.kind))
 	}
 
-	return updatedDeployment, nil
+	return nil
 }
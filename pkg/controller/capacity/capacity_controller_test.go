@@ -8,9 +8,12 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	kubeinformers "k8s.io/client-go/informers"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
@@ -20,8 +23,10 @@ import (
 	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
 	"github.com/bookingcom/shipper/pkg/conditions"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	"github.com/bookingcom/shipper/pkg/controller/capacity/builder"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+	"github.com/bookingcom/shipper/pkg/util/replicas"
 )
 
 func init() {
@@ -94,6 +99,7 @@ func TestCapacityTargetStatusReturnsCorrectFleetReportWithSinglePod(t *testing.T
 		Reports:           []shipper.ClusterCapacityReport{*c.Build()},
 		AchievedPercent:   100,
 		AvailableReplicas: 1,
+		DesiredReplicas:   1,
 		Conditions: []shipper.ClusterCapacityCondition{
 			{Type: shipper.ClusterConditionTypeOperational, Status: corev1.ConditionTrue},
 			{Type: shipper.ClusterConditionTypeReady, Status: corev1.ConditionTrue},
@@ -145,6 +151,7 @@ func TestCapacityTargetStatusReturnsCorrectFleetReportWithSinglePodCompletedCont
 		Reports:           []shipper.ClusterCapacityReport{*c.Build()},
 		AchievedPercent:   100,
 		AvailableReplicas: 1,
+		DesiredReplicas:   1,
 		Conditions: []shipper.ClusterCapacityCondition{
 			{Type: shipper.ClusterConditionTypeOperational, Status: corev1.ConditionTrue},
 			{Type: shipper.ClusterConditionTypeReady, Status: corev1.ConditionTrue},
@@ -196,6 +203,7 @@ func TestCapacityTargetStatusReturnsCorrectFleetReportWithSinglePodTerminatedCon
 		Reports:           []shipper.ClusterCapacityReport{*c.Build()},
 		AchievedPercent:   100,
 		AvailableReplicas: 1,
+		DesiredReplicas:   1,
 		Conditions: []shipper.ClusterCapacityCondition{
 			{Type: shipper.ClusterConditionTypeOperational, Status: corev1.ConditionTrue},
 			{Type: shipper.ClusterConditionTypeReady, Status: corev1.ConditionTrue},
@@ -247,6 +255,7 @@ func TestCapacityTargetStatusReturnsCorrectFleetReportWithSinglePodRestartedCont
 		Reports:           []shipper.ClusterCapacityReport{*c.Build()},
 		AchievedPercent:   100,
 		AvailableReplicas: 1,
+		DesiredReplicas:   1,
 		Conditions: []shipper.ClusterCapacityCondition{
 			{Type: shipper.ClusterConditionTypeOperational, Status: corev1.ConditionTrue},
 			{Type: shipper.ClusterConditionTypeReady, Status: corev1.ConditionTrue},
@@ -298,6 +307,7 @@ func TestCapacityTargetStatusReturnsCorrectFleetReportWithSinglePodRestartedCont
 		Reports:           []shipper.ClusterCapacityReport{*c.Build()},
 		AchievedPercent:   100,
 		AvailableReplicas: 1,
+		DesiredReplicas:   1,
 		Conditions: []shipper.ClusterCapacityCondition{
 			{Type: shipper.ClusterConditionTypeOperational, Status: corev1.ConditionTrue},
 			{Type: shipper.ClusterConditionTypeReady, Status: corev1.ConditionTrue},
@@ -373,6 +383,7 @@ func TestCapacityTargetStatusReturnsCorrectFleetReportWithMultiplePods(t *testin
 		Reports:           []shipper.ClusterCapacityReport{*c.Build()},
 		AchievedPercent:   100,
 		AvailableReplicas: 2,
+		DesiredReplicas:   2,
 		Conditions: []shipper.ClusterCapacityCondition{
 			{Type: shipper.ClusterConditionTypeOperational, Status: corev1.ConditionTrue},
 			{Type: shipper.ClusterConditionTypeReady, Status: corev1.ConditionTrue},
@@ -513,10 +524,12 @@ func TestCapacityTargetStatusReturnsCorrectFleetReportWithMultiplePodsWithDiffer
 		Reports:           []shipper.ClusterCapacityReport{*c.Build()},
 		AchievedPercent:   100,
 		AvailableReplicas: 3,
+		DesiredReplicas:   3,
 		Conditions: []shipper.ClusterCapacityCondition{
 			{Type: shipper.ClusterConditionTypeReady, Status: corev1.ConditionFalse, Reason: conditions.PodsNotReady, Message: "there are 3 sad pods"},
 		},
-		SadPods: sadPodsStatuses,
+		SadPods:            sadPodsStatuses,
+		UnavailableReasons: []string{"ContainerCreating: 2"},
 	})
 
 	sort.Slice(capacityTarget.Status.Clusters[0].SadPods, func(i, j int) bool {
@@ -563,6 +576,56 @@ func TestUpdatingDeploymentsUpdatesTheCapacityTargetStatus(t *testing.T) {
 	f.runCapacityTargetSyncHandler()
 }
 
+// TestCapacityTargetStatusUpdateConflictRetried injects a resource version
+// conflict into the first status update and checks that the controller
+// refetches the CapacityTarget and retries the write instead of surfacing the
+// conflict as a sync error.
+func TestCapacityTargetStatusUpdateConflictRetried(t *testing.T) {
+	f := NewFixture(t)
+
+	capacityTarget := newCapacityTarget(10, 50)
+	f.managementObjects = append(f.managementObjects, capacityTarget.DeepCopy())
+
+	deployment := newDeployment(5, 5)
+	f.targetClusterObjects = append(f.targetClusterObjects, deployment)
+
+	controller := f.runInternal()
+
+	gvr := shipper.SchemeGroupVersion.WithResource("capacitytargets")
+	conflicted := false
+	f.managementClientset.PrependReactor("update", "capacitytargets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		if conflicted {
+			return false, nil, nil
+		}
+		conflicted = true
+		return true, nil, kerrors.NewConflict(gvr.GroupResource(), capacityTarget.Name, fmt.Errorf("the object has been modified"))
+	})
+
+	if err := controller.capacityTargetSyncHandler("reviewsapi/capacity-v0.0.1"); err != nil {
+		t.Errorf("expected the sync to absorb the conflict, got: %v", err)
+	}
+
+	clusterConditions := []shipper.ClusterCapacityCondition{
+		{
+			Type:    shipper.ClusterConditionTypeReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  conditions.WrongPodCount,
+			Message: "expected 5 replicas but have 0",
+		},
+	}
+	f.expectCapacityTargetStatusUpdate(capacityTarget, 5, 50, clusterConditions, []shipper.ClusterCapacityReport{*builder.NewReport("nginx").Build()})
+
+	// The conflicted update is followed by a refetch and a second update
+	// carrying the same computed status.
+	f.managementClusterActions = append(f.managementClusterActions,
+		kubetesting.NewGetAction(gvr, capacityTarget.GetNamespace(), capacityTarget.GetName()),
+		kubetesting.NewUpdateAction(gvr, capacityTarget.GetNamespace(), capacityTarget),
+	)
+
+	managementClusterActual := shippertesting.FilterActions(f.managementClientset.Actions())
+	shippertesting.CheckActions(f.managementClusterActions, managementClusterActual, f.t)
+}
+
 // TestSadPodsAreReflectedInCapacityTargetStatus tests a case where
 // the deployment should have 5 available pods, but it has 4 happy
 // pods and 1 sad pod.
@@ -597,6 +660,312 @@ func TestSadPodsAreReflectedInCapacityTargetStatus(t *testing.T) {
 	f.runCapacityTargetSyncHandler()
 }
 
+// TestImagePullBackOffSurfacesInUnavailableReasons checks that pods stuck
+// pulling their image show up as a summarized unavailable reason in the
+// capacity target status, instead of just an opaque "sad pods" count.
+func TestImagePullBackOffSurfacesInUnavailableReasons(t *testing.T) {
+	f := NewFixture(t)
+
+	capacityTarget := newCapacityTarget(1, 100)
+	f.managementObjects = append(f.managementObjects, capacityTarget.DeepCopy())
+
+	deployment := newDeployment(1, 0)
+	sadPod := createSadPodForDeployment(deployment)
+	sadPod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{
+			Name: "app",
+			State: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{
+					Reason:  "ImagePullBackOff",
+					Message: `Back-off pulling image "no-such-image"`,
+				},
+			},
+		},
+	}
+	f.targetClusterObjects = append(f.targetClusterObjects, deployment, sadPod)
+
+	clusterConditions := []shipper.ClusterCapacityCondition{
+		{
+			Type:    shipper.ClusterConditionTypeReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  conditions.PodsNotReady,
+			Message: "there are 1 sad pods",
+		},
+	}
+
+	c := builder.NewReport("nginx").
+		AddPodConditionBreakdownBuilder(
+			builder.NewPodConditionBreakdown(1, string(corev1.PodReady), string(corev1.ConditionFalse), "ExpectedFail").
+				AddOrIncrementContainerState("app", sadPod.Name, "Waiting", "ImagePullBackOff", ""))
+
+	expectedSadPod := createSadPodConditionFromPod(sadPod)
+	expectedSadPod.Containers = sadPod.Status.ContainerStatuses
+
+	f.expectCapacityTargetStatusUpdate(capacityTarget, 0, 0, clusterConditions, []shipper.ClusterCapacityReport{*c.Build()}, expectedSadPod)
+
+	f.runCapacityTargetSyncHandler()
+
+	updated := f.latestCapacityTarget()
+	reasons := updated.Status.Clusters[0].UnavailableReasons
+	if len(reasons) != 1 || reasons[0] != "ImagePullBackOff: 1" {
+		t.Errorf(`expected unavailable reasons ["ImagePullBackOff: 1"], got %v`, reasons)
+	}
+}
+
+// TestInstabilityEventsReported feeds a burst of pod restart events into the
+// target cluster and checks that the recent ones are counted into the
+// cluster's capacity status, while events outside the window age out.
+func TestInstabilityEventsReported(t *testing.T) {
+	f := NewFixture(t)
+
+	capacityTarget := newCapacityTarget(1, 100)
+	f.managementObjects = append(f.managementObjects, capacityTarget.DeepCopy())
+
+	deployment := newDeployment(1, 1)
+	happyPod := createHappyPodForDeployment(deployment)
+	f.targetClusterObjects = append(f.targetClusterObjects, deployment, happyPod)
+
+	for i, age := range []time.Duration{0, time.Minute, 2 * time.Minute, 2 * time.Hour} {
+		f.targetClusterObjects = append(f.targetClusterObjects, &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("nginx-burst-%d", i),
+				Namespace: "reviewsapi",
+			},
+			InvolvedObject: corev1.ObjectReference{
+				Kind:      "Pod",
+				Namespace: "reviewsapi",
+				Name:      happyPod.Name,
+			},
+			Type:          corev1.EventTypeWarning,
+			Reason:        "BackOff",
+			LastTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		})
+	}
+
+	clusterConditions := []shipper.ClusterCapacityCondition{
+		{Type: shipper.ClusterConditionTypeOperational, Status: corev1.ConditionTrue},
+		{Type: shipper.ClusterConditionTypeReady, Status: corev1.ConditionTrue},
+	}
+
+	c := builder.NewReport("nginx").
+		AddPodConditionBreakdownBuilder(
+			builder.NewPodConditionBreakdown(1, string(corev1.PodReady), string(corev1.ConditionTrue), ""))
+
+	f.expectCapacityTargetStatusUpdate(capacityTarget, 1, 100, clusterConditions, []shipper.ClusterCapacityReport{*c.Build()})
+
+	// The two-hour-old event has aged out; only the burst counts.
+	capacityTarget.Status.Clusters[0].InstabilityEvents = 3
+
+	f.runCapacityTargetSyncHandler()
+}
+
+// TestStuckTerminatingPodForceDeleted checks that, with force deletion
+// enabled, a pod that has been terminating past the configured timeout gets
+// force-deleted so it can't block the rollout, while being reported in
+// TerminatingReplicas rather than dragging down readiness or the fleet
+// report.
+func TestStuckTerminatingPodForceDeleted(t *testing.T) {
+	f := NewFixture(t)
+	f.forceDeleteStuckPodsAfter = 5 * time.Minute
+
+	capacityTarget := newCapacityTarget(1, 100)
+	f.managementObjects = append(f.managementObjects, capacityTarget.DeepCopy())
+
+	deployment := newDeployment(1, 1)
+	happyPod := createHappyPodForDeployment(deployment)
+
+	stuckPod := createHappyPodForDeployment(deployment)
+	stuckPod.Name = "nginx-1a93Y2-stuck"
+	deletionStarted := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	stuckPod.DeletionTimestamp = &deletionStarted
+
+	f.targetClusterObjects = append(f.targetClusterObjects, deployment, happyPod, stuckPod)
+
+	deleteAction := kubetesting.NewDeleteAction(
+		schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		stuckPod.Namespace,
+		stuckPod.Name,
+	)
+	f.targetClusterActions = append(f.targetClusterActions, deleteAction)
+
+	clusterConditions := []shipper.ClusterCapacityCondition{
+		{Type: shipper.ClusterConditionTypeOperational, Status: corev1.ConditionTrue},
+		{Type: shipper.ClusterConditionTypeReady, Status: corev1.ConditionTrue},
+	}
+
+	c := builder.NewReport("nginx").
+		AddPodConditionBreakdownBuilder(
+			builder.NewPodConditionBreakdown(1, string(corev1.PodReady), string(corev1.ConditionTrue), ""))
+
+	f.expectCapacityTargetStatusUpdate(capacityTarget, 1, 100, clusterConditions, []shipper.ClusterCapacityReport{*c.Build()})
+
+	capacityTarget.Status.Clusters[0].TerminatingReplicas = 1
+
+	f.runCapacityTargetSyncHandler()
+}
+
+// TestPDBLimitsIncumbentScaleDown checks that a chart-defined
+// PodDisruptionBudget forces a scale-down to happen stepwise: with 3
+// available pods and minAvailable 2, only one pod may go at a time, so the
+// deployment is scaled to 2 instead of straight to the target of 0 and the
+// cluster reports WaitingForPDB.
+func TestPDBLimitsIncumbentScaleDown(t *testing.T) {
+	f := NewFixture(t)
+
+	capacityTarget := newCapacityTarget(3, 0)
+	f.managementObjects = append(f.managementObjects, capacityTarget.DeepCopy())
+
+	deployment := newDeployment(3, 3)
+	f.targetClusterObjects = append(f.targetClusterObjects, deployment)
+
+	for i := 0; i < 3; i++ {
+		pod := createHappyPodForDeployment(deployment)
+		pod.Name = fmt.Sprintf("nginx-%d-happy", i)
+		f.targetClusterObjects = append(f.targetClusterObjects, pod)
+	}
+
+	minAvailable := intstr.FromInt(2)
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-pdb",
+			Namespace: "reviewsapi",
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					shipper.ReleaseLabel: "0.0.1",
+				},
+			},
+		},
+	}
+	f.targetClusterObjects = append(f.targetClusterObjects, pdb)
+
+	f.ExpectDeploymentPatchWithReplicas(deployment, 2)
+
+	clusterConditions := []shipper.ClusterCapacityCondition{
+		{
+			Type:    shipper.ClusterConditionTypeReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  conditions.WaitingForPDB,
+			Message: `PodDisruptionBudget "nginx-pdb" allows removing only 1 more pod(s) right now; scaling down to 2 instead of 0`,
+		},
+	}
+
+	c := builder.NewReport("nginx").
+		AddPodConditionBreakdownBuilder(
+			builder.NewPodConditionBreakdown(3, string(corev1.PodReady), string(corev1.ConditionTrue), ""))
+
+	f.expectCapacityTargetStatusUpdate(capacityTarget, 3, 100, clusterConditions, []shipper.ClusterCapacityReport{*c.Build()})
+
+	f.runCapacityTargetSyncHandler()
+}
+
+// TestRampedScaleUpStepsTowardsTarget ramps a contender from 0 to 4 replicas
+// with a step size of 1 and checks that each sync requests only the next
+// increment, reporting a RampingUp condition until the last increment
+// requests the full target.
+func TestRampedScaleUpStepsTowardsTarget(t *testing.T) {
+	for replicaCount := int32(0); replicaCount < 4; replicaCount++ {
+		f := NewFixture(t)
+
+		capacityTarget := newCapacityTarget(4, 100)
+		capacityTarget.Annotations = map[string]string{
+			shipper.CapacityRampStepSizeAnnotation: "1",
+		}
+		f.managementObjects = append(f.managementObjects, capacityTarget.DeepCopy())
+
+		deployment := newDeployment(replicaCount, replicaCount)
+		f.targetClusterObjects = append(f.targetClusterObjects, deployment)
+
+		for i := int32(0); i < replicaCount; i++ {
+			pod := createHappyPodForDeployment(deployment)
+			pod.Name = fmt.Sprintf("nginx-%d-happy", i)
+			f.targetClusterObjects = append(f.targetClusterObjects, pod)
+		}
+
+		f.ExpectDeploymentPatchWithReplicas(deployment, replicaCount+1)
+
+		var clusterConditions []shipper.ClusterCapacityCondition
+		if replicaCount+1 < 4 {
+			clusterConditions = []shipper.ClusterCapacityCondition{
+				{
+					Type:    shipper.ClusterConditionTypeReady,
+					Status:  corev1.ConditionFalse,
+					Reason:  conditions.RampingUp,
+					Message: fmt.Sprintf("capacity ramp allows adding only 1 more pod(s) at a time; scaling up to %d instead of 4", replicaCount+1),
+				},
+			}
+		} else {
+			clusterConditions = []shipper.ClusterCapacityCondition{
+				{
+					Type:   shipper.ClusterConditionTypeOperational,
+					Status: corev1.ConditionTrue,
+				},
+				{
+					Type:   shipper.ClusterConditionTypeReady,
+					Status: corev1.ConditionTrue,
+				},
+			}
+		}
+
+		report := builder.NewReport("nginx")
+		if replicaCount > 0 {
+			report.AddPodConditionBreakdownBuilder(
+				builder.NewPodConditionBreakdown(uint32(replicaCount), string(corev1.PodReady), string(corev1.ConditionTrue), ""))
+		}
+
+		f.expectCapacityTargetStatusUpdate(capacityTarget, replicaCount, replicaCount*25, clusterConditions, []shipper.ClusterCapacityReport{*report.Build()})
+
+		f.runCapacityTargetSyncHandler()
+	}
+}
+
+// TestSuspendedClusterScalesToZero suspends a cluster whose spec asks for 50%
+// capacity and checks that the workload is scaled to zero anyway, with the
+// cluster reporting a ClusterSuspended condition instead of Ready.
+func TestSuspendedClusterScalesToZero(t *testing.T) {
+	f := NewFixture(t)
+
+	capacityTarget := newCapacityTarget(10, 50)
+	capacityTarget.Spec.Clusters[0].Suspended = true
+	f.managementObjects = append(f.managementObjects, capacityTarget.DeepCopy())
+
+	deployment := newDeployment(5, 5)
+	f.targetClusterObjects = append(f.targetClusterObjects, deployment)
+
+	f.ExpectDeploymentPatchWithReplicas(deployment, 0)
+
+	capacityTarget.Status.Clusters = append(capacityTarget.Status.Clusters, shipper.ClusterCapacityStatus{
+		Name:              "minikube",
+		AvailableReplicas: 5,
+		AchievedPercent:   50,
+		DesiredReplicas:   0,
+		Reports:           []shipper.ClusterCapacityReport{*builder.NewReport("nginx").Build()},
+		Conditions: []shipper.ClusterCapacityCondition{
+			{
+				Type:    shipper.ClusterConditionTypeReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  conditions.ClusterSuspended,
+				Message: "cluster is suspended; running zero replicas",
+			},
+		},
+	})
+
+	updateAction := kubetesting.NewUpdateAction(
+		schema.GroupVersionResource{
+			Group:    shipper.SchemeGroupVersion.Group,
+			Version:  shipper.SchemeGroupVersion.Version,
+			Resource: "capacitytargets",
+		},
+		capacityTarget.GetNamespace(),
+		capacityTarget,
+	)
+	f.managementClusterActions = append(f.managementClusterActions, updateAction)
+
+	f.runCapacityTargetSyncHandler()
+}
+
 func NewFixture(t *testing.T) *fixture {
 	return &fixture{
 		t: t,
@@ -616,6 +985,8 @@ type fixture struct {
 
 	store *shippertesting.FakeClusterClientStore
 
+	forceDeleteStuckPodsAfter time.Duration
+
 	targetClusterActions     []kubetesting.Action
 	managementClusterActions []kubetesting.Action
 }
@@ -637,6 +1008,7 @@ func (f *fixture) newController() *Controller {
 		f.managementInformerFactory,
 		f.store,
 		record.NewFakeRecorder(10),
+		f.forceDeleteStuckPodsAfter,
 	)
 
 	return controller
@@ -684,14 +1056,29 @@ func (f *fixture) ExpectDeploymentPatchWithReplicas(deployment *appsv1.Deploymen
 	f.targetClusterActions = append(f.targetClusterActions, patchAction)
 }
 
+func (f *fixture) latestCapacityTarget() *shipper.CapacityTarget {
+	actions := f.managementClientset.Actions()
+	for i := len(actions) - 1; i >= 0; i-- {
+		if update, ok := actions[i].(kubetesting.UpdateAction); ok {
+			return update.GetObject().(*shipper.CapacityTarget)
+		}
+	}
+
+	f.t.Fatal("no CapacityTarget update action found")
+	return nil
+}
+
 func (f *fixture) expectCapacityTargetStatusUpdate(capacityTarget *shipper.CapacityTarget, availableReplicas, achievedPercent int32, clusterConditions []shipper.ClusterCapacityCondition, reports []shipper.ClusterCapacityReport, sadPods ...shipper.PodStatus) {
+	clusterSpec := capacityTarget.Spec.Clusters[0]
 	clusterStatus := shipper.ClusterCapacityStatus{
-		Name:              capacityTarget.Spec.Clusters[0].Name,
-		AvailableReplicas: availableReplicas,
-		AchievedPercent:   achievedPercent,
-		Conditions:        clusterConditions,
-		SadPods:           sadPods,
-		Reports:           reports,
+		Name:               clusterSpec.Name,
+		AvailableReplicas:  availableReplicas,
+		AchievedPercent:    achievedPercent,
+		DesiredReplicas:    int32(replicas.CalculateDesiredReplicaCount(uint(clusterSpec.TotalReplicaCount), float64(clusterSpec.Percent))),
+		UnavailableReasons: summarizeUnavailableReasons(sadPods),
+		Conditions:         clusterConditions,
+		SadPods:            sadPods,
+		Reports:            reports,
 	}
 
 	capacityTarget.Status.Clusters = append(capacityTarget.Status.Clusters, clusterStatus)
@@ -837,3 +1224,34 @@ func createSadPodConditionFromPod(sadPod *corev1.Pod) shipper.PodStatus {
 		Condition: sadPod.Status.Conditions[0],
 	}
 }
+
+// TestReconcileDiffDebugAnnotation checks that, with the debug flag on, the
+// capacity target gets stamped with a summary of the scaling the reconcile
+// performed on the target cluster.
+func TestReconcileDiffDebugAnnotation(t *testing.T) {
+	shippercontroller.DebugReconcileDiff = true
+	defer func() { shippercontroller.DebugReconcileDiff = false }()
+
+	f := NewFixture(t)
+
+	capacityTarget := newCapacityTarget(10, 50)
+	f.managementObjects = append(f.managementObjects, capacityTarget.DeepCopy())
+
+	deployment := newDeployment(0, 0)
+	f.targetClusterObjects = append(f.targetClusterObjects, deployment)
+
+	controller := f.runInternal()
+	if err := controller.capacityTargetSyncHandler("reviewsapi/capacity-v0.0.1"); err != nil {
+		t.Fatalf("sync handler unexpectedly returned error: %v", err)
+	}
+
+	ct, err := f.managementClientset.ShipperV1alpha1().CapacityTargets(capacityTarget.Namespace).Get(capacityTarget.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "scaled minikube from 0 to 5"
+	if got := ct.Annotations[shipper.DebugLastReconcileDiffAnnotation]; got != expected {
+		t.Errorf("expected debug annotation %q, got %q", expected, got)
+	}
+}
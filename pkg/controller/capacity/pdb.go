@@ -0,0 +1,137 @@
+package capacity
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/conditions"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// limitScaleDownForPDBs caps a scale-down at what the workload's
+// PodDisruptionBudgets allow right now, so draining the incumbent never
+// removes more pods at once than the chart's budget permits. It returns the
+// replica count to patch to: the full target when no budget is in the way, a
+// smaller decrement when a budget forces stepping down, or the current count
+// when the budget allows no disruptions at all. Whenever a budget holds the
+// scale-down back, a WaitingForPDB condition is reported; subsequent syncs
+// keep stepping as pods drain.
+func (c *Controller) limitScaleDownForPDBs(workload *targetWorkload, clusterName string, oldReplicaCount, targetReplicaCount int32, clusterStatus *shipper.ClusterCapacityStatus) (int32, error) {
+	if targetReplicaCount >= oldReplicaCount {
+		return targetReplicaCount, nil
+	}
+
+	targetClusterInformer, err := c.clusterClientStore.GetInformerFactory(clusterName)
+	if err != nil {
+		clusterStatus.Conditions = conditions.SetCapacityCondition(
+			clusterStatus.Conditions,
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionFalse,
+			conditions.ServerError,
+			err.Error(),
+		)
+
+		return 0, err
+	}
+
+	pdbs, err := targetClusterInformer.Policy().V1beta1().PodDisruptionBudgets().Lister().PodDisruptionBudgets(workload.namespace).List(labels.Everything())
+	if err != nil {
+		clusterStatus.Conditions = conditions.SetCapacityCondition(
+			clusterStatus.Conditions,
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionFalse,
+			conditions.ServerError,
+			err.Error(),
+		)
+
+		return 0, shippererrors.NewKubeclientListError(
+			policyv1beta1.SchemeGroupVersion.WithKind("PodDisruptionBudget"),
+			workload.namespace, labels.Everything(), err)
+	}
+
+	allowed := oldReplicaCount - targetReplicaCount
+	var limitingPDB string
+	for _, pdb := range pdbs {
+		if !pdbMatchesWorkload(pdb, workload) {
+			continue
+		}
+
+		pdbAllowed, err := allowedDisruptions(pdb, workload, oldReplicaCount)
+		if err != nil {
+			return 0, err
+		}
+
+		if pdbAllowed < allowed {
+			allowed = pdbAllowed
+			limitingPDB = pdb.Name
+		}
+	}
+
+	if limitingPDB == "" {
+		return targetReplicaCount, nil
+	}
+
+	steppedReplicaCount := oldReplicaCount - allowed
+	clusterStatus.Conditions = conditions.SetCapacityCondition(
+		clusterStatus.Conditions,
+		shipper.ClusterConditionTypeReady,
+		corev1.ConditionFalse,
+		conditions.WaitingForPDB,
+		fmt.Sprintf("PodDisruptionBudget %q allows removing only %d more pod(s) right now; scaling down to %d instead of %d",
+			limitingPDB, allowed, steppedReplicaCount, targetReplicaCount),
+	)
+
+	return steppedReplicaCount, nil
+}
+
+// pdbMatchesWorkload reports whether the budget selects the workload's pods.
+func pdbMatchesWorkload(pdb *policyv1beta1.PodDisruptionBudget, workload *targetWorkload) bool {
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil || selector.Empty() {
+		return false
+	}
+
+	return selector.Matches(labels.Set(workload.templateLabels))
+}
+
+// allowedDisruptions computes how many of the workload's pods the budget
+// allows removing right now, from the budget's spec and the workload's
+// current availability. Percentages resolve against the workload's current
+// replica count, the way the disruption controller resolves them against the
+// expected pod count.
+func allowedDisruptions(pdb *policyv1beta1.PodDisruptionBudget, workload *targetWorkload, currentReplicaCount int32) (int32, error) {
+	available := workload.readiness.AvailableReplicas
+
+	var allowed int32
+	switch {
+	case pdb.Spec.MaxUnavailable != nil:
+		maxUnavailable, err := intstr.GetValueFromIntOrPercent(pdb.Spec.MaxUnavailable, int(currentReplicaCount), true)
+		if err != nil {
+			return 0, shippererrors.NewUnrecoverableError(err)
+		}
+
+		allowed = int32(maxUnavailable) - (currentReplicaCount - available)
+	case pdb.Spec.MinAvailable != nil:
+		minAvailable, err := intstr.GetValueFromIntOrPercent(pdb.Spec.MinAvailable, int(currentReplicaCount), true)
+		if err != nil {
+			return 0, shippererrors.NewUnrecoverableError(err)
+		}
+
+		allowed = available - int32(minAvailable)
+	default:
+		// A budget without either field doesn't constrain anything.
+		return currentReplicaCount, nil
+	}
+
+	if allowed < 0 {
+		allowed = 0
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,124 @@
+package capacity
+
+import (
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// workloadReadiness summarizes how many replicas a workload wants and how many
+// it actually has, in kind-neutral terms the capacity accounting can work
+// with. Each workload kind counts these differently, so every supported kind
+// gets its own pure translation function below.
+type workloadReadiness struct {
+	DesiredReplicas   int32
+	CurrentReplicas   int32
+	ReadyReplicas     int32
+	AvailableReplicas int32
+}
+
+// deploymentReadiness reads a Deployment's readiness straight from its spec
+// and status.
+func deploymentReadiness(deployment *appsv1.Deployment) workloadReadiness {
+	var desired int32
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	return workloadReadiness{
+		DesiredReplicas:   desired,
+		CurrentReplicas:   deployment.Status.Replicas,
+		ReadyReplicas:     deployment.Status.ReadyReplicas,
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+	}
+}
+
+// statefulSetReadiness reads a StatefulSet's readiness. StatefulSets don't
+// report availability separately from readiness: a pod counts once its
+// ordinal is up and passing readiness checks.
+func statefulSetReadiness(statefulSet *appsv1.StatefulSet) workloadReadiness {
+	var desired int32
+	if statefulSet.Spec.Replicas != nil {
+		desired = *statefulSet.Spec.Replicas
+	}
+
+	return workloadReadiness{
+		DesiredReplicas:   desired,
+		CurrentReplicas:   statefulSet.Status.Replicas,
+		ReadyReplicas:     statefulSet.Status.ReadyReplicas,
+		AvailableReplicas: statefulSet.Status.ReadyReplicas,
+	}
+}
+
+// daemonSetReadiness reads a DaemonSet's readiness. A DaemonSet's desired
+// count is however many nodes can schedule it, so everything comes from
+// status rather than spec.
+func daemonSetReadiness(daemonSet *appsv1.DaemonSet) workloadReadiness {
+	return workloadReadiness{
+		DesiredReplicas:   daemonSet.Status.DesiredNumberScheduled,
+		CurrentReplicas:   daemonSet.Status.CurrentNumberScheduled,
+		ReadyReplicas:     daemonSet.Status.NumberReady,
+		AvailableReplicas: daemonSet.Status.NumberAvailable,
+	}
+}
+
+// availableFromPods counts the pods that have been ready for at least
+// minReadySeconds, mirroring how a Deployment counts availability: a pod's
+// Ready condition must have held since at least that long ago. It also
+// returns how long until the next ready-but-young pod matures, so the caller
+// can re-sync exactly when the count changes; zero means no pod is waiting to
+// mature.
+func availableFromPods(pods []*corev1.Pod, minReadySeconds int32, now time.Time) (int32, time.Duration) {
+	var available int32
+	var nextMature time.Duration
+	minReady := time.Duration(minReadySeconds) * time.Second
+
+	for _, pod := range pods {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodReady || condition.Status != corev1.ConditionTrue {
+				continue
+			}
+
+			if age := now.Sub(condition.LastTransitionTime.Time); age >= minReady {
+				available++
+			} else if remaining := minReady - age; nextMature == 0 || remaining < nextMature {
+				nextMature = remaining
+			}
+		}
+	}
+
+	return available, nextMature
+}
+
+// terminatingFromPods splits the terminating pods out of a pod list: it
+// returns how many are shutting down, and which of them have been at it for
+// longer than stuckAfter — usually pods held back by a finalizer or an
+// overlong grace period. It also returns how long until the next terminating
+// pod counts as stuck, so the caller can re-check exactly then; zero means no
+// pod is on its way to being stuck. A zero stuckAfter means nothing counts as
+// stuck.
+func terminatingFromPods(pods []*corev1.Pod, stuckAfter time.Duration, now time.Time) (int32, []*corev1.Pod, time.Duration) {
+	var terminating int32
+	var stuck []*corev1.Pod
+	var nextStuck time.Duration
+
+	for _, pod := range pods {
+		if pod.DeletionTimestamp == nil {
+			continue
+		}
+
+		terminating++
+		if stuckAfter <= 0 {
+			continue
+		}
+
+		if age := now.Sub(pod.DeletionTimestamp.Time); age > stuckAfter {
+			stuck = append(stuck, pod)
+		} else if remaining := stuckAfter - age; nextStuck == 0 || remaining < nextStuck {
+			nextStuck = remaining
+		}
+	}
+
+	return terminating, stuck, nextStuck
+}
@@ -0,0 +1,174 @@
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeploymentReadinessWithUnsetReplicas(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		Status: appsv1.DeploymentStatus{
+			Replicas:          2,
+			ReadyReplicas:     1,
+			AvailableReplicas: 1,
+		},
+	}
+
+	expected := workloadReadiness{
+		DesiredReplicas:   0,
+		CurrentReplicas:   2,
+		ReadyReplicas:     1,
+		AvailableReplicas: 1,
+	}
+
+	if got := deploymentReadiness(deployment); got != expected {
+		t.Errorf("expected readiness %+v, got %+v", expected, got)
+	}
+}
+
+// TestStatefulSetReadinessPartial checks that a StatefulSet that has only
+// brought up some of its ordinals counts just the ready ones as available.
+func TestStatefulSetReadinessPartial(t *testing.T) {
+	replicas := int32(5)
+	statefulSet := &appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+		},
+		Status: appsv1.StatefulSetStatus{
+			Replicas:      5,
+			ReadyReplicas: 3,
+		},
+	}
+
+	expected := workloadReadiness{
+		DesiredReplicas:   5,
+		CurrentReplicas:   5,
+		ReadyReplicas:     3,
+		AvailableReplicas: 3,
+	}
+
+	if got := statefulSetReadiness(statefulSet); got != expected {
+		t.Errorf("expected readiness %+v, got %+v", expected, got)
+	}
+}
+
+// TestDaemonSetReadinessAcrossNodes checks that a DaemonSet's desired count
+// comes from how many nodes can schedule it, not from any replica spec: on a
+// three-node cluster with one pod still coming up, two of three are available.
+func TestDaemonSetReadinessAcrossNodes(t *testing.T) {
+	daemonSet := &appsv1.DaemonSet{
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 3,
+			CurrentNumberScheduled: 3,
+			NumberReady:            2,
+			NumberAvailable:        2,
+		},
+	}
+
+	expected := workloadReadiness{
+		DesiredReplicas:   3,
+		CurrentReplicas:   3,
+		ReadyReplicas:     2,
+		AvailableReplicas: 2,
+	}
+
+	if got := daemonSetReadiness(daemonSet); got != expected {
+		t.Errorf("expected readiness %+v, got %+v", expected, got)
+	}
+}
+
+// TestAvailableFromPodsHonorsMinReadySeconds counts three ready pods against
+// a 30-second minimum: one matured long ago, one turned ready 10 seconds ago
+// and shouldn't count yet, and one isn't ready at all. The young pod should
+// also drive a re-sync for the moment it matures.
+func TestAvailableFromPodsHonorsMinReadySeconds(t *testing.T) {
+	now := time.Now()
+
+	buildPod := func(status corev1.ConditionStatus, readySince time.Time) *corev1.Pod {
+		return &corev1.Pod{
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{
+						Type:               corev1.PodReady,
+						Status:             status,
+						LastTransitionTime: metav1.NewTime(readySince),
+					},
+				},
+			},
+		}
+	}
+
+	pods := []*corev1.Pod{
+		buildPod(corev1.ConditionTrue, now.Add(-5*time.Minute)),
+		buildPod(corev1.ConditionTrue, now.Add(-10*time.Second)),
+		buildPod(corev1.ConditionFalse, now.Add(-5*time.Minute)),
+	}
+
+	available, nextMature := availableFromPods(pods, 30, now)
+
+	if available != 1 {
+		t.Errorf("expected 1 available pod, got %d", available)
+	}
+
+	if expected := 20 * time.Second; nextMature != expected {
+		t.Errorf("expected the young pod to mature in %s, got %s", expected, nextMature)
+	}
+
+	// Without a minimum, readiness alone counts.
+	available, nextMature = availableFromPods(pods, 0, now)
+	if available != 2 || nextMature != 0 {
+		t.Errorf("expected 2 available pods and no pending maturation, got %d and %s", available, nextMature)
+	}
+}
+
+// TestTerminatingFromPods splits a pod list against a 5-minute stuck timeout:
+// a running pod doesn't count, a pod terminating for a minute counts as
+// terminating and drives a re-check for when it would become stuck, and a
+// pod terminating for 10 minutes is stuck.
+func TestTerminatingFromPods(t *testing.T) {
+	now := time.Now()
+
+	buildPod := func(name string, deletedSince *time.Time) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		}
+		if deletedSince != nil {
+			deletionStarted := metav1.NewTime(*deletedSince)
+			pod.DeletionTimestamp = &deletionStarted
+		}
+		return pod
+	}
+
+	recent := now.Add(-time.Minute)
+	old := now.Add(-10 * time.Minute)
+	pods := []*corev1.Pod{
+		buildPod("running", nil),
+		buildPod("leaving", &recent),
+		buildPod("stuck", &old),
+	}
+
+	terminating, stuck, nextStuck := terminatingFromPods(pods, 5*time.Minute, now)
+
+	if terminating != 2 {
+		t.Errorf("expected 2 terminating pods, got %d", terminating)
+	}
+
+	if len(stuck) != 1 || stuck[0].Name != "stuck" {
+		t.Errorf("expected only the old pod to be stuck, got %v", stuck)
+	}
+
+	if expected := 4 * time.Minute; nextStuck != expected {
+		t.Errorf("expected the young pod to become stuck in %s, got %s", expected, nextStuck)
+	}
+
+	// With force deletion disabled nothing is stuck, and there is no
+	// deadline to re-check.
+	terminating, stuck, nextStuck = terminatingFromPods(pods, 0, now)
+	if terminating != 2 || len(stuck) != 0 || nextStuck != 0 {
+		t.Errorf("expected 2 terminating pods and nothing stuck with force deletion disabled, got %d, %v and %s", terminating, stuck, nextStuck)
+	}
+}
@@ -0,0 +1,91 @@
+package capacity
+
+import (
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/conditions"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// instabilityEventWindow is how far back pod events still count as recent
+// instability. Events older than this have aged out of the count, so a
+// rollout paused on a burst resumes once the burst subsides.
+const instabilityEventWindow = 5 * time.Minute
+
+// instabilityEventReasons are the pod event reasons counted as instability:
+// crash loops, failed liveness probes, and OOM kills.
+var instabilityEventReasons = map[string]struct{}{
+	"BackOff":          {},
+	"CrashLoopBackOff": {},
+	"Unhealthy":        {},
+	"OOMKilling":       {},
+}
+
+// stabilityNow exists so tests can control time when aging out events.
+var stabilityNow = time.Now
+
+// countInstabilityEvents counts the recent crash/restart/OOM events emitted
+// for the workload's pods on the given cluster. The count lands in the
+// cluster's capacity status, where strategies with a stability policy read it
+// to decide whether to pause.
+func (c *Controller) countInstabilityEvents(workload *targetWorkload, clusterName string, clusterStatus *shipper.ClusterCapacityStatus) (int32, error) {
+	targetClusterClient, err := c.clusterClientStore.GetClient(clusterName, AgentName)
+	if err != nil {
+		clusterStatus.Conditions = conditions.SetCapacityCondition(
+			clusterStatus.Conditions,
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionFalse,
+			conditions.ServerError,
+			err.Error(),
+		)
+
+		return 0, err
+	}
+
+	eventList, err := targetClusterClient.CoreV1().Events(workload.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		clusterStatus.Conditions = conditions.SetCapacityCondition(
+			clusterStatus.Conditions,
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionFalse,
+			conditions.ServerError,
+			err.Error(),
+		)
+
+		return 0, shippererrors.NewKubeclientListError(
+			corev1.SchemeGroupVersion.WithKind("Event"),
+			workload.namespace, labels.Everything(), err)
+	}
+
+	horizon := stabilityNow().Add(-instabilityEventWindow)
+
+	var count int32
+	for _, event := range eventList.Items {
+		if _, unstable := instabilityEventReasons[event.Reason]; !unstable {
+			continue
+		}
+
+		if event.InvolvedObject.Kind != "Pod" || !strings.HasPrefix(event.InvolvedObject.Name, workload.name+"-") {
+			continue
+		}
+
+		if event.LastTimestamp.Time.Before(horizon) {
+			continue
+		}
+
+		// An aggregated event stands for Count occurrences.
+		if event.Count > 1 {
+			count += event.Count
+		} else {
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,327 @@
+package capacity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golang/glog"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// targetWorkload reduces whichever top-level workload object a chart deploys
+// — Deployment, StatefulSet or DaemonSet — to the pieces the capacity
+// controller needs to drive and report on it.
+type targetWorkload struct {
+	kind           string
+	name           string
+	namespace      string
+	selector       *metav1.LabelSelector
+	templateLabels map[string]string
+	// specReplicas is the replica count currently in the workload's spec,
+	// or nil when it is unset or the workload has no such field.
+	specReplicas *int32
+	// scalable is false for workloads that size themselves: DaemonSets run
+	// on every schedulable node, so the controller never patches their
+	// replica count.
+	scalable  bool
+	readiness workloadReadiness
+}
+
+func deploymentWorkload(deployment *appsv1.Deployment) *targetWorkload {
+	return &targetWorkload{
+		kind:           "Deployment",
+		name:           deployment.Name,
+		namespace:      deployment.Namespace,
+		selector:       deployment.Spec.Selector,
+		templateLabels: deployment.Spec.Template.Labels,
+		specReplicas:   deployment.Spec.Replicas,
+		scalable:       true,
+		readiness:      deploymentReadiness(deployment),
+	}
+}
+
+func statefulSetWorkload(statefulSet *appsv1.StatefulSet) *targetWorkload {
+	return &targetWorkload{
+		kind:           "StatefulSet",
+		name:           statefulSet.Name,
+		namespace:      statefulSet.Namespace,
+		selector:       statefulSet.Spec.Selector,
+		templateLabels: statefulSet.Spec.Template.Labels,
+		specReplicas:   statefulSet.Spec.Replicas,
+		scalable:       true,
+		readiness:      statefulSetReadiness(statefulSet),
+	}
+}
+
+func daemonSetWorkload(daemonSet *appsv1.DaemonSet) *targetWorkload {
+	return &targetWorkload{
+		kind:           "DaemonSet",
+		name:           daemonSet.Name,
+		namespace:      daemonSet.Namespace,
+		selector:       daemonSet.Spec.Selector,
+		templateLabels: daemonSet.Spec.Template.Labels,
+		scalable:       false,
+		readiness:      daemonSetReadiness(daemonSet),
+	}
+}
+
+type workloadWorkqueueItem struct {
+	Key         string
+	Kind        string
+	ClusterName string
+}
+
+func (c *Controller) runWorkloadWorker() {
+	for c.processNextWorkloadWorkItem() {
+	}
+}
+
+func (c *Controller) processNextWorkloadWorkItem() bool {
+	obj, shutdown := c.workloadWorkqueue.Get()
+	if shutdown {
+		return false
+	}
+
+	defer c.workloadWorkqueue.Done(obj)
+
+	var (
+		key workloadWorkqueueItem
+		ok  bool
+	)
+
+	if key, ok = obj.(workloadWorkqueueItem); !ok {
+		c.workloadWorkqueue.Forget(obj)
+		runtime.HandleError(fmt.Errorf("invalid object key (will retry: false): %#v", obj))
+		return true
+	}
+
+	shouldRetry := false
+	err := c.workloadSyncHandler(key)
+
+	if err != nil {
+		shouldRetry = shippererrors.ShouldRetry(err)
+		runtime.HandleError(fmt.Errorf("error syncing %s %q (will retry: %t): %s", key.Kind, key.Key, shouldRetry, err))
+	}
+
+	if shouldRetry {
+		if c.workloadWorkqueue.NumRequeues(key) >= maxRetries {
+			// Drop the workload's key out of the workqueue and thus reset its
+			// backoff. This limits the time a "broken" object can hog a worker.
+			glog.Warningf("%s %q has been retried too many times, dropping from the queue", key.Kind, key.Key)
+			c.workloadWorkqueue.Forget(key)
+
+			return true
+		}
+
+		c.workloadWorkqueue.AddRateLimited(key)
+
+		return true
+	}
+
+	glog.V(4).Infof("Successfully synced %s %q", key.Kind, key.Key)
+	c.workloadWorkqueue.Forget(key)
+
+	return true
+}
+
+func (c *Controller) enqueueWorkload(obj interface{}, kind, clusterName string) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	item := workloadWorkqueueItem{
+		Key:         key,
+		Kind:        kind,
+		ClusterName: clusterName,
+	}
+
+	c.workloadWorkqueue.Add(item)
+}
+
+func (c Controller) NewWorkloadResourceEventHandler(kind, clusterName string) cache.ResourceEventHandler {
+	return cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			workload, ok := obj.(metav1.Object)
+			if !ok {
+				glog.Warningf("Received something that's not a workload object: %v", obj)
+				return false
+			}
+
+			_, ok = workload.GetLabels()[shipper.ReleaseLabel]
+
+			return ok
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(old, new interface{}) {
+				c.enqueueWorkload(new, kind, clusterName)
+			},
+		},
+	}
+}
+
+func (c *Controller) workloadSyncHandler(item workloadWorkqueueItem) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(item.Key)
+	if err != nil {
+		return shippererrors.NewUnrecoverableError(err)
+	}
+
+	informerFactory, err := c.clusterClientStore.GetInformerFactory(item.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	var workloadLabels map[string]string
+	switch item.Kind {
+	case "Deployment":
+		targetDeployment, err := informerFactory.Apps().V1().Deployments().Lister().Deployments(namespace).Get(name)
+		if err != nil {
+			return shippererrors.NewKubeclientGetError(namespace, name, err).
+				WithKind(appsv1.SchemeGroupVersion.WithKind(item.Kind))
+		}
+		workloadLabels = targetDeployment.GetLabels()
+	case "StatefulSet":
+		targetStatefulSet, err := informerFactory.Apps().V1().StatefulSets().Lister().StatefulSets(namespace).Get(name)
+		if err != nil {
+			return shippererrors.NewKubeclientGetError(namespace, name, err).
+				WithKind(appsv1.SchemeGroupVersion.WithKind(item.Kind))
+		}
+		workloadLabels = targetStatefulSet.GetLabels()
+	case "DaemonSet":
+		targetDaemonSet, err := informerFactory.Apps().V1().DaemonSets().Lister().DaemonSets(namespace).Get(name)
+		if err != nil {
+			return shippererrors.NewKubeclientGetError(namespace, name, err).
+				WithKind(appsv1.SchemeGroupVersion.WithKind(item.Kind))
+		}
+		workloadLabels = targetDaemonSet.GetLabels()
+	default:
+		return shippererrors.NewUnrecoverableError(fmt.Errorf("unknown workload kind %q", item.Kind))
+	}
+
+	// Using ReleaseLabel here instead of the full set of workload labels because
+	// we can't guarantee that there isn't extra stuff there that was put directly
+	// in the chart.
+	// Also not using ObjectReference here because it would go over cluster
+	// boundaries. While technically it's probably ok, I feel like it'd be abusing
+	// the feature.
+	release := workloadLabels[shipper.ReleaseLabel]
+	capacityTarget, err := c.getCapacityTargetForReleaseAndNamespace(release, namespace)
+	if err != nil {
+		return err
+	}
+
+	c.enqueueCapacityTarget(capacityTarget)
+
+	return nil
+}
+
+func (c Controller) getCapacityTargetForReleaseAndNamespace(release, namespace string) (*shipper.CapacityTarget, error) {
+	selector := labels.Set{shipper.ReleaseLabel: release}.AsSelector()
+	capacityTargets, err := c.capacityTargetsLister.CapacityTargets(namespace).List(selector)
+	if err != nil {
+		return nil, shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("CapacityTarget"),
+			namespace, selector, err)
+	}
+
+	if l := len(capacityTargets); l != 1 {
+		return nil, shippererrors.NewInvalidCapacityTargetError(release, l)
+	}
+
+	return capacityTargets[0], nil
+}
+
+func (c Controller) getSadPodsForWorkloadOnCluster(workload *targetWorkload, clusterName string) (numberOfPods, numberOfSadPods int, sadPodConditions []shipper.PodStatus, err error) {
+	var sadPods []shipper.PodStatus
+
+	informer, err := c.clusterClientStore.GetInformerFactory(clusterName)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(workload.selector)
+	if err != nil {
+		return 0, 0, nil, shippererrors.NewUnrecoverableError(fmt.Errorf("failed to transform label selector %v into a selector: %s", workload.selector, err))
+	}
+
+	pods, err := informer.Core().V1().Pods().Lister().Pods(workload.namespace).List(selector)
+	if err != nil {
+		return 0, 0, nil, shippererrors.NewKubeclientListError(
+			corev1.SchemeGroupVersion.WithKind("Pod"),
+			workload.namespace, selector, err)
+	}
+
+	// Pods already shutting down aren't sad, they're leaving; they're
+	// accounted for in TerminatingReplicas instead, and mustn't trip the
+	// wrong-pod-count check during a scale-down.
+	podCount := 0
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		podCount++
+
+		if len(sadPods) == SadPodLimit {
+			continue
+		}
+
+		if condition, ok := c.getFalsePodCondition(pod); ok {
+			sadPod := shipper.PodStatus{
+				Name:           pod.Name,
+				Condition:      *condition,
+				InitContainers: pod.Status.InitContainerStatuses,
+				Containers:     pod.Status.ContainerStatuses,
+			}
+
+			sadPods = append(sadPods, sadPod)
+		}
+	}
+
+	sort.Slice(sadPods, func(i, j int) bool {
+		return sadPods[i].Name < sadPods[j].Name
+	})
+
+	return podCount, len(sadPods), sadPods, nil
+}
+
+func (c Controller) getFalsePodCondition(pod *corev1.Pod) (*corev1.PodCondition, bool) {
+	var sadCondition *corev1.PodCondition
+
+	// The loop below finds a condition with the `status` set to "false", which
+	// means there is something wrong with the pod. The reason the loop is not
+	// returning as it finds the first condition with the status of "false" is that
+	// we're testing the assumption that there is only one condition with the
+	// status of "false" at a time. That's why there is a log there for now.
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status == corev1.ConditionFalse {
+			if sadCondition == nil {
+				c := condition
+				sadCondition = &c
+			} else {
+				glog.Errorf("Found 2 pod conditions with the status set to `false`. The first has a type of %s, and the second has a type of %s.", sadCondition.Type, condition.Type)
+			}
+		}
+	}
+
+	if sadCondition != nil {
+		return sadCondition, true
+	}
+
+	return nil, false
+}
+
+func (c Controller) calculatePercentageFromAmount(total, amount int32) int32 {
+	result := float64(amount) / float64(total) * 100
+
+	return int32(math.Ceil(result))
+}
@@ -0,0 +1,360 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperclient "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	shipperlisters "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/clusterclientstore"
+	"github.com/bookingcom/shipper/pkg/conditions"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
+)
+
+const (
+	AgentName = "cluster-controller"
+
+	// maxRetries is the number of times a Cluster will be retried before we
+	// drop it out of the workqueue. The number is chosen with the default
+	// rate limiter in mind. This results in the following backoff times:
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s.
+	maxRetries = 11
+)
+
+// Controller is a Kubernetes controller that validates Cluster objects: on
+// every create or update it builds a client for the cluster and performs a
+// discovery call, recording the outcome in an Operational condition and the
+// observed Kubernetes version in the Cluster's status. This surfaces
+// misconfigured API masters or secrets immediately, instead of midway through
+// a rollout.
+//
+// It also drives cluster decommissioning: a cluster whose spec marks it for
+// decommission keeps its finalizer, and reports the releases still scheduled
+// on it, until the release controller has moved the last of them elsewhere.
+type Controller struct {
+	shipperclientset   shipperclient.Interface
+	clusterClientStore clusterclientstore.ClientProvider
+
+	workqueue workqueue.RateLimitingInterface
+
+	clusterLister shipperlisters.ClusterLister
+	clusterSynced cache.InformerSynced
+
+	releaseLister shipperlisters.ReleaseLister
+	releaseSynced cache.InformerSynced
+
+	recorder record.EventRecorder
+}
+
+// NewController returns a new Cluster controller.
+func NewController(
+	shipperclientset shipperclient.Interface,
+	shipperInformerFactory shipperinformers.SharedInformerFactory,
+	store clusterclientstore.ClientProvider,
+	recorder record.EventRecorder,
+) *Controller {
+
+	clusterInformer := shipperInformerFactory.Shipper().V1alpha1().Clusters()
+	releaseInformer := shipperInformerFactory.Shipper().V1alpha1().Releases()
+
+	controller := &Controller{
+		shipperclientset:   shipperclientset,
+		clusterClientStore: store,
+		clusterLister:      clusterInformer.Lister(),
+		clusterSynced:      clusterInformer.Informer().HasSynced,
+		releaseLister:      releaseInformer.Lister(),
+		releaseSynced:      releaseInformer.Informer().HasSynced,
+		workqueue:          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "cluster_controller_clusters"),
+		recorder:           recorder,
+	}
+
+	clusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueCluster,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			controller.enqueueCluster(newObj)
+		},
+	})
+
+	// Decommissioning clusters wait on releases moving away, so changes to
+	// a release's cluster set have to wake up the clusters it names.
+	releaseInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueClustersForRelease,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			controller.enqueueClustersForRelease(newObj)
+		},
+		DeleteFunc: controller.enqueueClustersForRelease,
+	})
+
+	return controller
+}
+
+func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
+	defer runtime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	glog.V(2).Info("Starting Cluster controller")
+	defer glog.V(2).Info("Shutting down Cluster controller")
+
+	if !cache.WaitForCacheSync(stopCh, c.clusterSynced, c.releaseSynced) {
+		runtime.HandleError(fmt.Errorf("failed to wait for caches to sync"))
+		return
+	}
+
+	for i := 0; i < threadiness; i++ {
+		go wait.Until(c.runWorker, time.Second, stopCh)
+	}
+
+	glog.V(4).Info("Started Cluster controller")
+
+	<-stopCh
+}
+
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *Controller) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+	if shutdown {
+		return false
+	}
+
+	defer c.workqueue.Done(obj)
+
+	var (
+		key string
+		ok  bool
+	)
+
+	if key, ok = obj.(string); !ok {
+		c.workqueue.Forget(obj)
+		runtime.HandleError(fmt.Errorf("invalid object key (will retry: false): %#v", obj))
+		return true
+	}
+
+	shouldRetry := false
+	err := c.syncOne(key)
+
+	if err != nil {
+		shouldRetry = shippererrors.ShouldRetry(err)
+		runtime.HandleError(fmt.Errorf("error syncing Cluster %q (will retry: %t): %s", key, shouldRetry, err.Error()))
+	}
+
+	if shouldRetry {
+		if c.workqueue.NumRequeues(key) >= maxRetries {
+			// Drop the Cluster's key out of the workqueue and thus reset its
+			// backoff. This limits the time a "broken" object can hog a worker.
+			glog.Warningf("Cluster %q has been retried too many times, dropping from the queue", key)
+			c.workqueue.Forget(key)
+
+			return true
+		}
+
+		c.workqueue.AddRateLimited(key)
+
+		return true
+	}
+
+	c.workqueue.Forget(obj)
+	shipperhealth.RecordSuccess(AgentName)
+	glog.V(4).Infof("Successfully synced Cluster %q", key)
+
+	return true
+}
+
+func (c *Controller) syncOne(key string) error {
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return shippererrors.NewUnrecoverableError(err)
+	}
+
+	cluster, err := c.clusterLister.Get(name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			glog.V(3).Infof("Cluster %q has been deleted", key)
+			return nil
+		}
+
+		return shippererrors.NewKubeclientGetError("", name, err).
+			WithShipperKind("Cluster")
+	}
+
+	return c.processCluster(cluster.DeepCopy())
+}
+
+func (c *Controller) enqueueCluster(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	c.workqueue.Add(key)
+}
+
+// enqueueClustersForRelease adds every cluster named in the release's clusters
+// annotation to the workqueue.
+func (c *Controller) enqueueClustersForRelease(obj interface{}) {
+	rel, ok := obj.(*shipper.Release)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("not a shipper.Release: %#v", obj))
+			return
+		}
+
+		rel, ok = tombstone.Obj.(*shipper.Release)
+		if !ok {
+			runtime.HandleError(fmt.Errorf("not a shipper.Release: %#v", tombstone.Obj))
+			return
+		}
+	}
+
+	for _, clusterName := range releaseClusters(rel) {
+		c.workqueue.Add(clusterName)
+	}
+}
+
+// releaseClusters parses the release's clusters annotation into a list of
+// cluster names.
+func releaseClusters(rel *shipper.Release) []string {
+	annotation := rel.Annotations[shipper.ReleaseClustersAnnotation]
+	if annotation == "" {
+		return nil
+	}
+
+	return strings.Split(annotation, ",")
+}
+
+// processCluster checks that the cluster can actually be reached with the
+// configured API master and credentials, and records the outcome in the
+// cluster's status.
+func (c *Controller) processCluster(cluster *shipper.Cluster) error {
+	var checkErr error
+
+	client, err := c.clusterClientStore.GetClient(cluster.Name, AgentName)
+	if err != nil {
+		checkErr = err
+		cluster.Status.Conditions = conditions.SetClusterCondition(
+			cluster.Status.Conditions,
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionFalse,
+			conditions.TargetClusterClientError,
+			err.Error())
+	} else if version, err := client.Discovery().ServerVersion(); err != nil {
+		checkErr = shippererrors.NewKubeclientDiscoverError(shipper.SchemeGroupVersion, err)
+		cluster.Status.Conditions = conditions.SetClusterCondition(
+			cluster.Status.Conditions,
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionFalse,
+			conditions.ServerError,
+			err.Error())
+	} else {
+		cluster.Status.Version = version.GitVersion
+		cluster.Status.Conditions = conditions.SetClusterCondition(
+			cluster.Status.Conditions,
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionTrue,
+			"", "")
+	}
+
+	if cluster.Spec.Decommission {
+		if err := c.reconcileDecommission(cluster); err != nil {
+			return err
+		}
+	} else {
+		cluster.Status.DecommissionPendingReleases = nil
+	}
+
+	if _, err := c.shipperclientset.ShipperV1alpha1().Clusters().Update(cluster); err != nil {
+		return shippererrors.NewKubeclientUpdateError(cluster, err).
+			WithShipperKind("Cluster")
+	}
+
+	if checkErr != nil {
+		c.recorder.Eventf(
+			cluster,
+			corev1.EventTypeWarning,
+			"ClusterNotOperational",
+			"Cluster %q cannot be reached: %s",
+			cluster.Name,
+			checkErr.Error(),
+		)
+
+		return checkErr
+	}
+
+	return nil
+}
+
+// reconcileDecommission records which releases are still scheduled on a
+// decommissioning cluster and manages its decommission finalizer: the
+// finalizer is held while any release is pending, and released once the
+// release controller has moved the last of them elsewhere.
+func (c *Controller) reconcileDecommission(cluster *shipper.Cluster) error {
+	selector := labels.Everything()
+	releases, err := c.releaseLister.List(selector)
+	if err != nil {
+		return shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Release"),
+			"", selector, err)
+	}
+
+	var pending []string
+	for _, rel := range releases {
+		for _, clusterName := range releaseClusters(rel) {
+			if clusterName == cluster.Name {
+				pending = append(pending, fmt.Sprintf("%s/%s", rel.Namespace, rel.Name))
+				break
+			}
+		}
+	}
+	sort.Strings(pending)
+
+	cluster.Status.DecommissionPendingReleases = pending
+
+	if len(pending) > 0 {
+		ensureDecommissionFinalizer(cluster)
+	} else {
+		removeDecommissionFinalizer(cluster)
+	}
+
+	return nil
+}
+
+func ensureDecommissionFinalizer(cluster *shipper.Cluster) {
+	for _, finalizer := range cluster.Finalizers {
+		if finalizer == shipper.ClusterDecommissionFinalizer {
+			return
+		}
+	}
+
+	cluster.Finalizers = append(cluster.Finalizers, shipper.ClusterDecommissionFinalizer)
+}
+
+func removeDecommissionFinalizer(cluster *shipper.Cluster) {
+	finalizers := make([]string, 0, len(cluster.Finalizers))
+	for _, finalizer := range cluster.Finalizers {
+		if finalizer != shipper.ClusterDecommissionFinalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+
+	cluster.Finalizers = finalizers
+}
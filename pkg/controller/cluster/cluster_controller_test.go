@@ -0,0 +1,299 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	"github.com/bookingcom/shipper/pkg/conditions"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+func init() {
+	conditions.ClusterConditionsShouldDiscardTimestamps = true
+}
+
+// fakeClientProvider hands out a fixed fake client, or fails like the cluster
+// client store does for an unreachable master.
+type fakeClientProvider struct {
+	client              kubernetes.Interface
+	getClientShouldFail bool
+}
+
+func (f *fakeClientProvider) GetClient(clusterName string, ua string) (kubernetes.Interface, error) {
+	if f.getClientShouldFail {
+		return nil, shippererrors.NewClusterNotReadyError(clusterName)
+	}
+	return f.client, nil
+}
+
+func (f *fakeClientProvider) GetConfig(clusterName string) (*rest.Config, error) {
+	return &rest.Config{}, nil
+}
+
+func buildCluster(name string) *shipper.Cluster {
+	return &shipper.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: shipper.ClusterSpec{
+			APIMaster: "https://127.0.0.1",
+		},
+	}
+}
+
+// newController returns a cluster.Controller after it has started, waited for
+// informer caches to sync and there is something on the controller's
+// workqueue.
+func newController(provider *fakeClientProvider, cluster *shipper.Cluster, extra ...runtime.Object) (*Controller, *shipperfake.Clientset) {
+	objects := append([]runtime.Object{cluster}, extra...)
+	shipperclientset := shipperfake.NewSimpleClientset(objects...)
+	shipperInformerFactory := shipperinformers.NewSharedInformerFactory(shipperclientset, time.Second*0)
+
+	c := NewController(
+		shipperclientset, shipperInformerFactory, provider,
+		record.NewFakeRecorder(42),
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	shipperInformerFactory.Start(stopCh)
+	shipperInformerFactory.WaitForCacheSync(stopCh)
+
+	wait.PollUntil(
+		10*time.Millisecond,
+		func() (bool, error) { return c.workqueue.Len() >= 1, nil },
+		stopCh,
+	)
+
+	return c, shipperclientset
+}
+
+func updatedCluster(t *testing.T, clientset *shipperfake.Clientset) *shipper.Cluster {
+	for _, action := range clientset.Actions() {
+		if update, ok := action.(kubetesting.UpdateAction); ok {
+			return update.GetObject().(*shipper.Cluster)
+		}
+	}
+
+	t.Fatal("no Cluster update action found")
+	return nil
+}
+
+func TestUnreachableMasterSetsOperationalFalse(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	provider := &fakeClientProvider{getClientShouldFail: true}
+
+	c, clientset := newController(provider, cluster)
+	c.processNextWorkItem()
+
+	updated := updatedCluster(t, clientset)
+
+	expected := []shipper.ClusterCondition{
+		{
+			Type:    shipper.ClusterConditionTypeOperational,
+			Status:  corev1.ConditionFalse,
+			Reason:  conditions.TargetClusterClientError,
+			Message: shippererrors.NewClusterNotReadyError(cluster.Name).Error(),
+		},
+	}
+
+	if len(updated.Status.Conditions) != 1 || updated.Status.Conditions[0] != expected[0] {
+		t.Errorf("expected conditions %v, got %v", expected, updated.Status.Conditions)
+	}
+
+	if updated.Status.Version != "" {
+		t.Errorf("expected no version for an unreachable cluster, got %q", updated.Status.Version)
+	}
+}
+
+func TestReachableMasterSetsOperationalTrueAndVersion(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+
+	fakeClient := kubefake.NewSimpleClientset()
+	fakeDiscovery := fakeClient.Discovery().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.FakedServerVersion = &version.Info{GitVersion: "v1.11.3"}
+
+	provider := &fakeClientProvider{client: fakeClient}
+
+	c, clientset := newController(provider, cluster)
+	c.processNextWorkItem()
+
+	updated := updatedCluster(t, clientset)
+
+	if !conditions.IsClusterConditionTrue(updated.Status.Conditions, shipper.ClusterConditionTypeOperational) {
+		t.Errorf("expected an Operational=True condition, got %v", updated.Status.Conditions)
+	}
+
+	if updated.Status.Version != "v1.11.3" {
+		t.Errorf("expected version %q, got %q", "v1.11.3", updated.Status.Version)
+	}
+}
+
+func buildReleaseOnClusters(namespace, name string, clusterNames string) *shipper.Release {
+	return &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				shipper.ReleaseClustersAnnotation: clusterNames,
+			},
+		},
+	}
+}
+
+func hasDecommissionFinalizer(cluster *shipper.Cluster) bool {
+	for _, finalizer := range cluster.Finalizers {
+		if finalizer == shipper.ClusterDecommissionFinalizer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TestDecommissionWaitsForPendingReleases marks a cluster for decommission
+// while a release is still scheduled on it, and checks that the cluster
+// reports the pending release and holds its decommission finalizer.
+func TestDecommissionWaitsForPendingReleases(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	cluster.Spec.Decommission = true
+
+	release := buildReleaseOnClusters("service-directory", "frontend-deadbeef-0", cluster.Name)
+
+	fakeClient := kubefake.NewSimpleClientset()
+	fakeDiscovery := fakeClient.Discovery().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.FakedServerVersion = &version.Info{GitVersion: "v1.11.3"}
+
+	c, clientset := newController(&fakeClientProvider{client: fakeClient}, cluster, release)
+	c.processNextWorkItem()
+
+	updated := updatedCluster(t, clientset)
+
+	expected := []string{"service-directory/frontend-deadbeef-0"}
+	if len(updated.Status.DecommissionPendingReleases) != 1 ||
+		updated.Status.DecommissionPendingReleases[0] != expected[0] {
+		t.Errorf("expected pending releases %v, got %v", expected, updated.Status.DecommissionPendingReleases)
+	}
+
+	if !hasDecommissionFinalizer(updated) {
+		t.Error("expected the decommission finalizer to be held while a release is still scheduled on the cluster")
+	}
+}
+
+// TestDecommissionReleasesFinalizerOnceVacated checks that the decommission
+// finalizer comes off once the last release has moved to another cluster.
+func TestDecommissionReleasesFinalizerOnceVacated(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	cluster.Spec.Decommission = true
+	cluster.Finalizers = []string{shipper.ClusterDecommissionFinalizer}
+
+	// The release used to live on minikube-a, but the scheduler has since
+	// moved it.
+	release := buildReleaseOnClusters("service-directory", "frontend-deadbeef-0", "minikube-b")
+
+	fakeClient := kubefake.NewSimpleClientset()
+	fakeDiscovery := fakeClient.Discovery().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.FakedServerVersion = &version.Info{GitVersion: "v1.11.3"}
+
+	c, clientset := newController(&fakeClientProvider{client: fakeClient}, cluster, release)
+	c.processNextWorkItem()
+
+	updated := updatedCluster(t, clientset)
+
+	if len(updated.Status.DecommissionPendingReleases) != 0 {
+		t.Errorf("expected no pending releases, got %v", updated.Status.DecommissionPendingReleases)
+	}
+
+	if hasDecommissionFinalizer(updated) {
+		t.Error("expected the decommission finalizer to be released once the cluster has been vacated")
+	}
+}
+
+// blockingClientProvider lets each worker check in and then park until the
+// test releases them, so the test can observe how many clusters are being
+// processed at the same time.
+type blockingClientProvider struct {
+	entered chan string
+	proceed chan struct{}
+}
+
+func (b *blockingClientProvider) GetClient(clusterName string, ua string) (kubernetes.Interface, error) {
+	b.entered <- clusterName
+	<-b.proceed
+	return nil, shippererrors.NewClusterNotReadyError(clusterName)
+}
+
+func (b *blockingClientProvider) GetConfig(clusterName string) (*rest.Config, error) {
+	return &rest.Config{}, nil
+}
+
+// TestWorkersProcessIndependentClustersConcurrently runs the controller with
+// three workers and three clusters, and checks that all three clusters are in
+// flight at once: the workqueue hands each worker a distinct key, so no key
+// is ever processed by two workers at the same time.
+func TestWorkersProcessIndependentClustersConcurrently(t *testing.T) {
+	clusters := []*shipper.Cluster{
+		buildCluster("minikube-a"),
+		buildCluster("minikube-b"),
+		buildCluster("minikube-c"),
+	}
+
+	provider := &blockingClientProvider{
+		entered: make(chan string, 42),
+		proceed: make(chan struct{}),
+	}
+
+	shipperclientset := shipperfake.NewSimpleClientset(clusters[0], clusters[1], clusters[2])
+	shipperInformerFactory := shipperinformers.NewSharedInformerFactory(shipperclientset, time.Second*0)
+
+	c := NewController(
+		shipperclientset, shipperInformerFactory, provider,
+		record.NewFakeRecorder(42),
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	shipperInformerFactory.Start(stopCh)
+	shipperInformerFactory.WaitForCacheSync(stopCh)
+
+	go c.Run(3, stopCh)
+
+	inFlight := make(map[string]int)
+	for i := 0; i < 3; i++ {
+		select {
+		case name := <-provider.entered:
+			inFlight[name]++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of 3 clusters were in flight after 5s: %v", len(inFlight), inFlight)
+		}
+	}
+
+	// All three workers are parked inside GetClient now. If the workqueue
+	// handed the same key to two workers we would see a duplicate here
+	// instead of each cluster exactly once.
+	for _, cluster := range clusters {
+		if inFlight[cluster.Name] != 1 {
+			t.Errorf("expected cluster %q to be processed by exactly 1 worker, got %d (%v)",
+				cluster.Name, inFlight[cluster.Name], inFlight)
+		}
+	}
+
+	close(provider.proceed)
+}
@@ -21,6 +21,7 @@ import (
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
 	shipperlisters "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
 	"github.com/bookingcom/shipper/pkg/tls"
 )
 
@@ -153,6 +154,7 @@ func (c *Controller) processNextWorkItem() bool {
 
 	c.workqueue.Forget(obj)
 
+	shipperhealth.RecordSuccess(AgentName)
 	glog.V(6).Infof("Successfully synced Cluster %q", key)
 
 	return true
@@ -0,0 +1,7 @@
+package controller
+
+// DebugReconcileDiff gates the last-reconcile-diff debug annotation. When
+// enabled, controllers stamp a short human-readable summary of what their
+// last reconcile changed onto the objects they update. It is off by default
+// to avoid annotation churn in production.
+var DebugReconcileDiff = false
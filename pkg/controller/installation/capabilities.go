@@ -0,0 +1,54 @@
+package installation
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/helm/pkg/chartutil"
+)
+
+// clusterCapabilities discovers the chart-rendering capabilities of the
+// cluster behind the given client: its actual Kubernetes version and the API
+// versions its apiserver serves. Charts gated on .Capabilities render per
+// cluster with these instead of helm's compiled-in defaults.
+func clusterCapabilities(client kubernetes.Interface) (*chartutil.Capabilities, error) {
+	kubeVersion, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	versionSet := chartutil.DefaultVersionSet
+	if groups, err := client.Discovery().ServerGroups(); err != nil {
+		return nil, err
+	} else if groups.Size() > 0 {
+		versionSet = chartutil.NewVersionSet(metav1.ExtractGroupVersions(groups)...)
+	}
+
+	return &chartutil.Capabilities{
+		APIVersions: versionSet,
+		KubeVersion: kubeVersion,
+	}, nil
+}
+
+// capabilitiesForCluster returns the named cluster's rendering capabilities,
+// discovering them on first use and caching them afterwards: discovery costs
+// two apiserver round trips per render otherwise, and cluster versions change
+// rarely. An upgraded cluster is picked up when shipper restarts.
+func (c *Controller) capabilitiesForCluster(clusterName string, client kubernetes.Interface) (*chartutil.Capabilities, error) {
+	c.capabilitiesMut.Lock()
+	caps, ok := c.capabilities[clusterName]
+	c.capabilitiesMut.Unlock()
+	if ok {
+		return caps, nil
+	}
+
+	caps, err := clusterCapabilities(client)
+	if err != nil {
+		return nil, err
+	}
+
+	c.capabilitiesMut.Lock()
+	c.capabilities[clusterName] = caps
+	c.capabilitiesMut.Unlock()
+
+	return caps, nil
+}
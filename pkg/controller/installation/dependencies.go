@@ -0,0 +1,56 @@
+package installation
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// dependencyProbeTimeout bounds how long we're willing to wait on a single
+// readiness probe URL; the sync will be retried anyway, so keep it short.
+const dependencyProbeTimeout = 5 * time.Second
+
+var dependencyProbeClient = &http.Client{Timeout: dependencyProbeTimeout}
+
+// unmetDependencies checks every entry in the release environment's DependsOn
+// list and returns a human readable description for each dependency that is
+// not yet satisfied. An empty return value means installation can proceed.
+func (c *Controller) unmetDependencies(namespace string, env *shipper.ReleaseEnvironment) []string {
+	var unmet []string
+
+	for _, dep := range env.DependsOn {
+		switch {
+		case dep.Application != "":
+			if _, err := c.appLister.Applications(namespace).Get(dep.Application); err != nil {
+				unmet = append(unmet, fmt.Sprintf("application %q: %s", dep.Application, err))
+				continue
+			}
+
+			contender, err := c.releaseLister.Releases(namespace).ContenderForApplication(dep.Application)
+			if err != nil {
+				unmet = append(unmet, fmt.Sprintf("application %q: %s", dep.Application, err))
+				continue
+			}
+
+			if !releaseutil.ReleaseComplete(contender) {
+				unmet = append(unmet, fmt.Sprintf("application %q: contender release %q is not complete", dep.Application, contender.Name))
+			}
+		case dep.URL != "":
+			resp, err := dependencyProbeClient.Get(dep.URL)
+			if err != nil {
+				unmet = append(unmet, fmt.Sprintf("probe %q: %s", dep.URL, err))
+				continue
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				unmet = append(unmet, fmt.Sprintf("probe %q: status %d", dep.URL, resp.StatusCode))
+			}
+		}
+	}
+
+	return unmet
+}
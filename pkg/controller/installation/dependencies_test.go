@@ -0,0 +1,75 @@
+package installation
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+// TestInstallationHeldOnUnmetDependency checks that an InstallationTarget
+// whose release depends on another Application is not installed while that
+// Application's contender release is incomplete; the cluster statuses should
+// report WaitingForDependencies instead.
+func TestInstallationHeldOnUnmetDependency(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	appName := "reviews-api"
+	testNs := "reviews-api"
+	app := buildApplication(appName, testNs)
+
+	depApp := buildApplication("reviews-db", testNs)
+	// The dependency's contender release exists but has no Complete
+	// condition, so the dependency is not satisfied.
+	depRel := buildRelease("db-0.0.1", testNs, "0", "feedbeef", depApp.Name)
+
+	release := buildRelease("0.0.1", testNs, "0", "deadbeef", app.Name)
+	release.Spec.Environment.DependsOn = []shipper.DependencyReference{
+		{Application: depApp.Name},
+	}
+	installationTarget := buildInstallationTarget(release, testNs, appName, []string{cluster.Name})
+
+	clientsPerCluster, shipperclientset, fakeDynamicClientBuilder, shipperInformerFactory :=
+		initializeClients(
+			apiResourceList,
+			[]runtime.Object{app, depApp, depRel, cluster, release, installationTarget},
+			objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	clusterPair := clientsPerCluster[cluster.Name]
+	fakeClientProvider := &FakeClientProvider{
+		clientsPerCluster: clientsPerCluster,
+		restConfig:        &rest.Config{},
+	}
+
+	fakeRecorder := record.NewFakeRecorder(42)
+
+	c := newController(
+		shipperclientset, shipperInformerFactory, fakeClientProvider, fakeDynamicClientBuilder, fakeRecorder)
+
+	if !c.processNextWorkItem() {
+		t.Fatal("Could not process work item")
+	}
+
+	// Nothing should have been installed on the target cluster.
+	expectedActions := []kubetesting.Action{}
+	shippertesting.CheckActions(expectedActions, clusterPair.fakeDynamicClient.Actions(), t)
+
+	it, err := shipperclientset.ShipperV1alpha1().InstallationTargets(testNs).Get(installationTarget.Name, v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(it.Status.Clusters) != 1 {
+		t.Fatalf("expected 1 cluster status, got %d", len(it.Status.Clusters))
+	}
+
+	if it.Status.Clusters[0].Status != shipper.InstallationStatusWaitingForDependencies {
+		t.Errorf("expected cluster status %q, got %q",
+			shipper.InstallationStatusWaitingForDependencies, it.Status.Clusters[0].Status)
+	}
+}
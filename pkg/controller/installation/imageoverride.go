@@ -0,0 +1,111 @@
+package installation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// applyImageOverrides rewrites container image tags in the rendered manifests
+// according to the release environment's image overrides. Overrides are keyed
+// by image repository: every container or init container whose image
+// repository matches gets its tag replaced, wherever in the manifest the pod
+// spec lives. An override whose repository matches no rendered container is
+// an error: a hotfix tag that silently doesn't land is worse than a loud one.
+func (i *Installer) applyImageOverrides(manifests []string) ([]string, error) {
+	overrides := i.Release.Spec.Environment.ImageOverrides
+	if len(overrides) == 0 {
+		return manifests, nil
+	}
+
+	applied := make(map[string]bool, len(overrides))
+	rewritten := make([]string, len(manifests))
+
+	for mi, manifest := range manifests {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(manifest), &obj); err != nil {
+			return nil, shippererrors.NewDecodeManifestError("error decoding manifest: %s", err)
+		}
+
+		if overrideImagesInObject(obj, overrides, applied) {
+			out, err := yaml.Marshal(obj)
+			if err != nil {
+				return nil, shippererrors.NewDecodeManifestError("error re-encoding manifest after image override: %s", err)
+			}
+			rewritten[mi] = string(out)
+		} else {
+			rewritten[mi] = manifest
+		}
+	}
+
+	for repo := range overrides {
+		if !applied[repo] {
+			return nil, shippererrors.NewUnrecoverableError(fmt.Errorf(
+				"image override targets repository %q, but the chart renders no container with that image",
+				repo))
+		}
+	}
+
+	return rewritten, nil
+}
+
+// overrideImagesInObject walks an unstructured manifest looking for container
+// lists and rewrites matching images in place. It returns whether anything
+// changed, and records each repository it rewrote in applied.
+func overrideImagesInObject(node interface{}, overrides map[string]string, applied map[string]bool) bool {
+	changed := false
+
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		for key, value := range typed {
+			if key == "containers" || key == "initContainers" {
+				containers, ok := value.([]interface{})
+				if !ok {
+					continue
+				}
+
+				for _, c := range containers {
+					container, ok := c.(map[string]interface{})
+					if !ok {
+						continue
+					}
+
+					image, ok := container["image"].(string)
+					if !ok {
+						continue
+					}
+
+					repo := imageRepository(image)
+					if tag, ok := overrides[repo]; ok {
+						container["image"] = fmt.Sprintf("%s:%s", repo, tag)
+						applied[repo] = true
+						changed = true
+					}
+				}
+			} else if overrideImagesInObject(value, overrides, applied) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range typed {
+			if overrideImagesInObject(item, overrides, applied) {
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// imageRepository strips the tag from an image reference, being careful not
+// to mistake a registry port (registry:5000/app) for a tag.
+func imageRepository(image string) string {
+	i := strings.LastIndex(image, ":")
+	if i == -1 || strings.Contains(image[i+1:], "/") {
+		return image
+	}
+	return image[:i]
+}
@@ -3,18 +3,23 @@ package installation
 import (
 	"fmt"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/helm/pkg/chartutil"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shipperchart "github.com/bookingcom/shipper/pkg/chart"
@@ -25,6 +30,7 @@ import (
 	"github.com/bookingcom/shipper/pkg/conditions"
 	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
 )
 
 const (
@@ -56,6 +62,33 @@ type Controller struct {
 	dynamicClientBuilderFunc  DynamicClientBuilderFunc
 	chartFetchFunc            shipperchart.FetchFunc
 	recorder                  record.EventRecorder
+	requiredLabels            []string
+	allowedRegistries         []string
+	defaultNamespaceQuota     corev1.ResourceList
+	defaultNamespaceLimits    corev1.ResourceList
+	defaultChartValues        shipper.ChartValues
+
+	// maxConcurrentClusters bounds how many target clusters one
+	// InstallationTarget installs into at the same time, and
+	// maxConcurrentApplies bounds how many rendered objects apply
+	// concurrently within each of those clusters. Values below 2 keep
+	// the respective stage serial.
+	maxConcurrentClusters int
+	maxConcurrentApplies  int
+
+	// driftCheckPeriod, when positive, makes the controller re-examine
+	// each InstallationTarget on that period and compare the live objects
+	// against the rendered desired state, reporting drift through the
+	// ConfigurationDrift condition. With autoCorrectDrift set, drifted
+	// objects are additionally re-applied back to the desired state.
+	driftCheckPeriod time.Duration
+	autoCorrectDrift bool
+
+	// capabilities caches each target cluster's discovered rendering
+	// capabilities (Kubernetes version and served API versions) by
+	// cluster name.
+	capabilities    map[string]*chartutil.Capabilities
+	capabilitiesMut sync.Mutex
 }
 
 // NewController returns a new Installation controller.
@@ -66,6 +99,15 @@ func NewController(
 	dynamicClientBuilderFunc DynamicClientBuilderFunc,
 	chartFetchFunc shipperchart.FetchFunc,
 	recorder record.EventRecorder,
+	requiredLabels []string,
+	allowedRegistries []string,
+	defaultNamespaceQuota corev1.ResourceList,
+	defaultNamespaceLimits corev1.ResourceList,
+	defaultChartValues shipper.ChartValues,
+	maxConcurrentClusters int,
+	maxConcurrentApplies int,
+	driftCheckPeriod time.Duration,
+	autoCorrectDrift bool,
 ) *Controller {
 
 	installationTargetInformer := shipperInformerFactory.Shipper().V1alpha1().InstallationTargets()
@@ -88,6 +130,16 @@ func NewController(
 		workqueue:                 workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "installation_controller_installationtargets"),
 		chartFetchFunc:            chartFetchFunc,
 		recorder:                  recorder,
+		requiredLabels:            requiredLabels,
+		allowedRegistries:         allowedRegistries,
+		defaultNamespaceQuota:     defaultNamespaceQuota,
+		defaultNamespaceLimits:    defaultNamespaceLimits,
+		defaultChartValues:        defaultChartValues,
+		maxConcurrentClusters:     maxConcurrentClusters,
+		maxConcurrentApplies:      maxConcurrentApplies,
+		driftCheckPeriod:          driftCheckPeriod,
+		autoCorrectDrift:          autoCorrectDrift,
+		capabilities:              map[string]*chartutil.Capabilities{},
 	}
 
 	installationTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -169,6 +221,7 @@ func (c *Controller) processNextWorkItem() bool {
 	}
 
 	c.workqueue.Forget(obj)
+	shipperhealth.RecordSuccess(AgentName)
 	glog.V(4).Infof("Successfully synced InstallationTarget %q", key)
 
 	return true
@@ -195,6 +248,13 @@ func (c *Controller) syncOne(key string) error {
 		return err
 	}
 
+	// With drift detection on, come back on the configured period even if
+	// no object change wakes us up: out-of-band edits on the application
+	// cluster don't generate events here.
+	if c.driftCheckPeriod > 0 {
+		c.workqueue.AddAfter(key, c.driftCheckPeriod)
+	}
+
 	return nil
 }
 
@@ -233,7 +293,77 @@ func (c *Controller) processInstallation(it *shipper.InstallationTarget) error {
 		return nil
 	}
 
-	installer := NewInstaller(c.chartFetchFunc, release, it)
+	if unmet := c.unmetDependencies(it.Namespace, &release.Spec.Environment); len(unmet) > 0 {
+		for i, dep := range unmet {
+			glog.V(3).Infof("InstallationTarget %q waiting for dependency %d: %s", shippercontroller.MetaKey(it), i, dep)
+		}
+
+		newClusterStatuses := make([]*shipper.ClusterInstallationStatus, 0, len(it.Spec.Clusters))
+		for _, name := range it.Spec.Clusters {
+			newClusterStatuses = append(newClusterStatuses, &shipper.ClusterInstallationStatus{
+				Name:    name,
+				Status:  shipper.InstallationStatusWaitingForDependencies,
+				Message: fmt.Sprintf("waiting for dependencies: %v", unmet),
+			})
+		}
+
+		sort.Sort(byClusterName(newClusterStatuses))
+		it.Status.Clusters = newClusterStatuses
+
+		if err := c.updateInstallationTargetStatus(it); err != nil {
+			return shippererrors.NewKubeclientUpdateError(it, err).
+				WithShipperKind("InstallationTarget")
+		}
+
+		c.recorder.Eventf(
+			it,
+			corev1.EventTypeNormal,
+			"InstallationWaitingForDependencies",
+			"InstallationTarget %q is waiting for dependencies: %v",
+			shippercontroller.MetaKey(it),
+			unmet,
+		)
+
+		return shippererrors.NewRecoverableError(fmt.Errorf("dependencies not satisfied: %v", unmet))
+	}
+
+	// Pin the resolved chart's digest into the Release the first time we
+	// see it, and refuse to install different bytes under the same version
+	// afterwards: a repository republishing a version must not change what
+	// an existing release installs.
+	if err := c.pinChartDigest(release); err != nil {
+		if !shippererrors.IsChartDigestMismatchError(err) {
+			return err
+		}
+
+		newClusterStatuses := make([]*shipper.ClusterInstallationStatus, 0, len(it.Spec.Clusters))
+		for _, name := range it.Spec.Clusters {
+			status := &shipper.ClusterInstallationStatus{
+				Name:    name,
+				Status:  shipper.InstallationStatusFailed,
+				Message: err.Error(),
+			}
+			status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeReady, corev1.ConditionFalse, conditions.ChartDigestMismatch, err.Error())
+			newClusterStatuses = append(newClusterStatuses, status)
+		}
+
+		sort.Sort(byClusterName(newClusterStatuses))
+		it.Status.Clusters = newClusterStatuses
+
+		if updateErr := c.updateInstallationTargetStatus(it); updateErr != nil {
+			return shippererrors.NewKubeclientUpdateError(it, updateErr).
+				WithShipperKind("InstallationTarget")
+		}
+
+		c.recorder.Eventf(
+			it,
+			corev1.EventTypeWarning,
+			"ChartDigestMismatch",
+			err.Error(),
+		)
+
+		return err
+	}
 
 	// Build .status over based on the current .spec.clusters.
 	newClusterStatuses := make([]*shipper.ClusterInstallationStatus, 0, len(it.Spec.Clusters))
@@ -250,63 +380,48 @@ func (c *Controller) processInstallation(it *shipper.InstallationTarget) error {
 
 	clusterErrors := shippererrors.NewMultiError()
 
-	for _, name := range it.Spec.Clusters {
+	// Each cluster gets its own status entry, so the installations proceed
+	// concurrently up to the configured limit, and their errors aggregate
+	// into clusterErrors once all of them are done.
+	limit := c.maxConcurrentClusters
+	if limit < 1 {
+		limit = 1
+	}
 
-		// IMPORTANT: Since we keep existing conditions from previous syncing
-		// points (as in existingConditionsPerCluster[name]), one needs to
-		// adjust all the dependent conditions. For example, whenever we
-		// transition "Operational" to "False", "Ready" *MUST* be transitioned
-		// to "Unknown" since we can't verify if it is actually "Ready".
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, limit)
+	)
+
+	for _, name := range it.Spec.Clusters {
 		status := &shipper.ClusterInstallationStatus{
 			Name:       name,
 			Conditions: existingConditionsPerCluster[name],
 		}
 		newClusterStatuses = append(newClusterStatuses, status)
 
-		var cluster *shipper.Cluster
-		if cluster, err = c.clusterLister.Get(name); err != nil {
-			err = shippererrors.NewKubeclientGetError("", name, err).WithShipperKind("Cluster")
-			clusterErrors.Append(err)
-			status.Status = shipper.InstallationStatusFailed
-			status.Message = err.Error()
-			status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeOperational, corev1.ConditionFalse, reasonForOperationalCondition(err), err.Error())
-			status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeReady, corev1.ConditionUnknown, reasonForReadyCondition(err), err.Error())
-			continue
-		}
-
-		var client kubernetes.Interface
-		var restConfig *rest.Config
-		client, restConfig, err = c.GetClusterAndConfig(name)
-		if err != nil {
-			clusterErrors.Append(err)
-			status.Status = shipper.InstallationStatusFailed
-			status.Message = err.Error()
-			status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeOperational, corev1.ConditionFalse, reasonForOperationalCondition(err), err.Error())
-			status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeReady, corev1.ConditionUnknown, reasonForReadyCondition(err), err.Error())
-			continue
-		}
-
-		// At this point, we got a hold in a connection to the target cluster,
-		// so we assume it's operational until some other signal saying
-		// otherwise arrives.
-		status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeOperational, corev1.ConditionTrue, "", "")
+		wg.Add(1)
+		go func(name string, status *shipper.ClusterInstallationStatus) {
+			defer wg.Done()
 
-		if err = installer.installRelease(cluster, client, restConfig, c.dynamicClientBuilderFunc); err != nil {
-			clusterErrors.Append(err)
-			status.Status = shipper.InstallationStatusFailed
-			status.Message = err.Error()
-			status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeReady, corev1.ConditionFalse, reasonForReadyCondition(err), err.Error())
-			continue
-		}
+			sem <- struct{}{}
+			defer func() { <-sem }()
 
-		status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeReady, corev1.ConditionTrue, "", "")
-		status.Status = shipper.InstallationStatusInstalled
+			if err := c.installIntoCluster(release, it, name, status); err != nil {
+				mu.Lock()
+				clusterErrors.Append(err)
+				mu.Unlock()
+			}
+		}(name, status)
 	}
 
+	wg.Wait()
+
 	sort.Sort(byClusterName(newClusterStatuses))
 	it.Status.Clusters = newClusterStatuses
 
-	_, err = c.shipperclientset.ShipperV1alpha1().InstallationTargets(it.Namespace).Update(it)
+	err = c.updateInstallationTargetStatus(it)
 	if err != nil {
 		err = shippererrors.NewKubeclientUpdateError(it, err).
 			WithShipperKind("InstallationTarget")
@@ -340,6 +455,168 @@ func (c *Controller) processInstallation(it *shipper.InstallationTarget) error {
 	return clusterErrors.Flatten()
 }
 
+// updateInstallationTargetStatus writes an installation target's computed
+// status, retrying resource version conflicts by re-applying the status to a
+// fresh copy of the object.
+func (c *Controller) updateInstallationTargetStatus(it *shipper.InstallationTarget) error {
+	status := it.Status
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		_, updateErr := c.shipperclientset.ShipperV1alpha1().InstallationTargets(it.Namespace).Update(it)
+		if updateErr == nil || !kerrors.IsConflict(updateErr) {
+			return updateErr
+		}
+
+		fresh, getErr := c.shipperclientset.ShipperV1alpha1().InstallationTargets(it.Namespace).Get(it.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+
+		fresh = fresh.DeepCopy()
+		fresh.Status = status
+		it = fresh
+
+		return updateErr
+	})
+}
+
+// installIntoCluster runs one cluster's share of an InstallationTarget,
+// recording the outcome on the given status entry.
+func (c *Controller) installIntoCluster(
+	release *shipper.Release,
+	it *shipper.InstallationTarget,
+	name string,
+	status *shipper.ClusterInstallationStatus,
+) error {
+	// IMPORTANT: Since we keep existing conditions from previous syncing
+	// points (as in existingConditionsPerCluster[name]), one needs to
+	// adjust all the dependent conditions. For example, whenever we
+	// transition "Operational" to "False", "Ready" *MUST* be transitioned
+	// to "Unknown" since we can't verify if it is actually "Ready".
+	cluster, err := c.clusterLister.Get(name)
+	if err != nil {
+		err = shippererrors.NewKubeclientGetError("", name, err).WithShipperKind("Cluster")
+		status.Status = shipper.InstallationStatusFailed
+		status.Message = err.Error()
+		status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeOperational, corev1.ConditionFalse, reasonForOperationalCondition(err), err.Error())
+		status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeReady, corev1.ConditionUnknown, reasonForReadyCondition(err), err.Error())
+		return err
+	}
+
+	var client kubernetes.Interface
+	var restConfig *rest.Config
+	client, restConfig, err = c.GetClusterAndConfig(name)
+	if err != nil {
+		status.Status = shipper.InstallationStatusFailed
+		status.Message = err.Error()
+		status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeOperational, corev1.ConditionFalse, reasonForOperationalCondition(err), err.Error())
+		status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeReady, corev1.ConditionUnknown, reasonForReadyCondition(err), err.Error())
+		return err
+	}
+
+	// At this point, we got a hold in a connection to the target cluster,
+	// so we assume it's operational until some other signal saying
+	// otherwise arrives.
+	status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeOperational, corev1.ConditionTrue, "", "")
+
+	// Each cluster gets its own Installer: appliedOrder and appliedQuota
+	// record per-install state.
+	installer := NewInstaller(c.chartFetchFunc, release, it)
+	installer.requiredLabels = c.requiredLabels
+	installer.allowedRegistries = c.allowedRegistries
+
+	// Render against the cluster's actual version and API list, so
+	// version-gated templates come out right for this cluster. Discovery
+	// trouble falls back to helm's defaults rather than blocking the
+	// installation.
+	if caps, err := c.capabilitiesForCluster(name, client); err != nil {
+		glog.Warningf("Could not discover capabilities of cluster %q, rendering with defaults: %s", name, err)
+	} else {
+		installer.capabilities = caps
+	}
+
+	installer.defaultQuota = c.defaultNamespaceQuota
+	installer.defaultLimits = c.defaultNamespaceLimits
+	installer.defaultValues = c.defaultChartValues
+	installer.maxConcurrentApplies = c.maxConcurrentApplies
+	installer.detectDrift = c.driftCheckPeriod > 0
+	installer.autoCorrectDrift = c.autoCorrectDrift
+
+	if err := installer.installRelease(cluster, client, restConfig, c.dynamicClientBuilderFunc); err != nil {
+		status.Status = shipper.InstallationStatusFailed
+		status.Message = err.Error()
+		if shippererrors.IsSingletonConflictError(err) {
+			status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeSingletonConflict, corev1.ConditionTrue, conditions.SingletonConflict, err.Error())
+		}
+		status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeReady, corev1.ConditionFalse, reasonForReadyCondition(err), err.Error())
+		return err
+	}
+
+	if c.driftCheckPeriod > 0 {
+		if drifted := installer.driftedFields; len(drifted) > 0 {
+			reason := conditions.DriftDetected
+			message := fmt.Sprintf("configuration drift detected on: %s", strings.Join(drifted, "; "))
+			if c.autoCorrectDrift {
+				reason = conditions.DriftCorrected
+				message = fmt.Sprintf("configuration drift corrected on: %s", strings.Join(drifted, "; "))
+			}
+			status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeConfigurationDrift, corev1.ConditionTrue, reason, message)
+			c.recorder.Event(it, corev1.EventTypeWarning, "ConfigurationDrift", message)
+		} else {
+			status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeConfigurationDrift, corev1.ConditionFalse, "", "")
+		}
+	}
+
+	// Report the namespace override the objects went into, and clean up
+	// the namespace a previous sync used once the installation has moved
+	// on — reaching the final step of a canary-namespace strategy moves
+	// the objects back into the application namespace.
+	status.TargetNamespace = it.Spec.TargetNamespace
+	if prev := previousTargetNamespace(it, name); prev != "" && prev != it.Spec.TargetNamespace {
+		err := client.CoreV1().Namespaces().Delete(prev, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			err = shippererrors.NewKubeclientDeleteError("", prev, err).
+				WithCoreV1Kind("Namespace")
+			status.Status = shipper.InstallationStatusFailed
+			status.Message = err.Error()
+			return err
+		}
+		current := it.Spec.TargetNamespace
+		if current == "" {
+			current = it.Namespace
+		}
+		c.recorder.Eventf(
+			it,
+			corev1.EventTypeNormal,
+			"CanaryNamespaceCleanedUp",
+			"Deleted namespace %q on cluster %q, the installation moved to %q",
+			prev,
+			name,
+			current,
+		)
+	}
+
+	status.Conditions = conditions.SetInstallationCondition(status.Conditions, shipper.ClusterConditionTypeReady, corev1.ConditionTrue, "", "")
+	status.Status = shipper.InstallationStatusInstalled
+	status.AppliedOrder = installer.appliedOrder
+	status.AppliedQuota = installer.appliedQuota
+	status.AppliedDefaults = installer.appliedDefaults
+	status.ReplacedObjects = installer.replacedObjects
+
+	return nil
+}
+
+// previousTargetNamespace returns the namespace override the last sync
+// reported for the named cluster, or the empty string when there was none.
+func previousTargetNamespace(it *shipper.InstallationTarget, clusterName string) string {
+	for _, s := range it.Status.Clusters {
+		if s.Name == clusterName {
+			return s.TargetNamespace
+		}
+	}
+	return ""
+}
+
 // extractExistingConditionsPerCluster builds a map with values being a list of conditions.
 func extractExistingConditionsPerCluster(it *shipper.InstallationTarget) map[string][]shipper.ClusterInstallationCondition {
 	existingConditionsPerCluster := map[string][]shipper.ClusterInstallationCondition{}
@@ -380,7 +657,59 @@ func reasonForOperationalCondition(err error) string {
 	return conditions.ServerError
 }
 
+// pinChartDigest resolves the release's chart and pins its content digest
+// into the release's annotations on first resolution. On later reconciles a
+// chart resolving to a different digest for the same version is refused with
+// a ChartDigestMismatchError.
+func (c *Controller) pinChartDigest(rel *shipper.Release) error {
+	chart, err := c.chartFetchFunc(rel.Spec.Environment.Chart)
+	if err != nil {
+		return shippererrors.NewChartFetchFailureError(
+			rel.Spec.Environment.Chart.Name,
+			rel.Spec.Environment.Chart.Version,
+			rel.Spec.Environment.Chart.RepoURL,
+			err,
+		)
+	}
+
+	digest := shipperchart.ContentDigest(chart)
+
+	pinned, ok := rel.Annotations[shipper.ReleaseChartDigestAnnotation]
+	if !ok {
+		rel = rel.DeepCopy()
+		if rel.Annotations == nil {
+			rel.Annotations = map[string]string{}
+		}
+		rel.Annotations[shipper.ReleaseChartDigestAnnotation] = digest
+
+		if _, err := c.shipperclientset.ShipperV1alpha1().Releases(rel.Namespace).Update(rel); err != nil {
+			return shippererrors.NewKubeclientUpdateError(rel, err)
+		}
+
+		return nil
+	}
+
+	if pinned != digest {
+		return shippererrors.NewChartDigestMismatchError(
+			rel.Spec.Environment.Chart.Name,
+			rel.Spec.Environment.Chart.Version,
+			pinned,
+			digest,
+		)
+	}
+
+	return nil
+}
+
 func reasonForReadyCondition(err error) string {
+	if shippererrors.IsNamespaceTerminatingError(err) {
+		return conditions.NamespaceTerminating
+	}
+
+	if shipperchart.IsDependencyUnresolvableError(err) {
+		return conditions.DependencyUnresolvable
+	}
+
 	if shippererrors.IsKubeclientError(err) {
 		return conditions.ServerError
 	}
@@ -393,5 +722,9 @@ func reasonForReadyCondition(err error) string {
 		return conditions.TargetClusterClientError
 	}
 
+	if shippererrors.IsSingletonConflictError(err) {
+		return conditions.SingletonConflict
+	}
+
 	return conditions.UnknownError
 }
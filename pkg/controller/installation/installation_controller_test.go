@@ -1,9 +1,11 @@
 package installation
 
 import (
+	"fmt"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -48,7 +50,7 @@ func TestInstallIncumbent(t *testing.T) {
 	}
 
 	expectedActions := []kubetesting.Action{}
-	shippertesting.CheckActions(expectedActions, clusterPair.fakeDynamicClient.Actions(), t)
+	shippertesting.CheckActions(expectedActions, withoutNamespaceProbe(clusterPair.fakeDynamicClient.Actions()), t)
 }
 
 // TestInstallOneCluster tests the installation process using the
@@ -94,7 +96,7 @@ func TestInstallOneCluster(t *testing.T) {
 		kubetesting.NewGetAction(schema.GroupVersionResource{Resource: "deployments", Version: "v1", Group: "apps"}, release.GetNamespace(), "0.0.1-reviews-api"),
 		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "deployments", Version: "v1", Group: "apps"}, release.GetNamespace(), nil),
 	}
-	shippertesting.ShallowCheckActions(expectedActions, clusterPair.fakeDynamicClient.Actions(), t)
+	shippertesting.ShallowCheckActions(expectedActions, withoutNamespaceProbe(clusterPair.fakeDynamicClient.Actions()), t)
 
 	// We are interested only in "update" actions here.
 	var filteredActions []kubetesting.Action
@@ -110,6 +112,7 @@ func TestInstallOneCluster(t *testing.T) {
 	it.Status.Clusters = []*shipper.ClusterInstallationStatus{
 		{
 			Name: "minikube-a", Status: shipper.InstallationStatusInstalled,
+			AppliedOrder: []string{"Service/0.0.1-reviews-api", "Deployment/0.0.1-reviews-api"},
 			Conditions: []shipper.ClusterInstallationCondition{
 				{
 					Type:   shipper.ClusterConditionTypeOperational,
@@ -123,6 +126,11 @@ func TestInstallOneCluster(t *testing.T) {
 		},
 	}
 	expectedActions = []kubetesting.Action{
+		kubetesting.NewUpdateAction(schema.GroupVersionResource{
+			Resource: "releases",
+			Version:  shipper.SchemeGroupVersion.Version,
+			Group:    shipper.SchemeGroupVersion.Group,
+		}, release.GetNamespace(), withPinnedChartDigest(release)),
 		kubetesting.NewUpdateAction(schema.GroupVersionResource{
 			Resource: "installationtargets",
 			Version:  shipper.SchemeGroupVersion.Version,
@@ -132,6 +140,96 @@ func TestInstallOneCluster(t *testing.T) {
 	shippertesting.CheckActions(expectedActions, filteredActions, t)
 }
 
+// TestInstallationTargetStatusUpdateConflictRetried injects a resource
+// version conflict into the first status update and checks that the
+// controller refetches the InstallationTarget and retries the write instead
+// of surfacing the conflict as a sync error.
+func TestInstallationTargetStatusUpdateConflictRetried(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	appName := "reviews-api"
+	testNs := "reviews-api"
+	app := buildApplication(appName, appName)
+	release := buildRelease("0.0.1", testNs, "0", "deadbeef", app.Name)
+	installationTarget := buildInstallationTarget(release, testNs, appName, []string{cluster.Name})
+
+	clientsPerCluster, shipperclientset, fakeDynamicClientBuilder, shipperInformerFactory :=
+		initializeClients(apiResourceList, []runtime.Object{app, cluster, release, installationTarget}, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakeClientProvider := &FakeClientProvider{
+		clientsPerCluster: clientsPerCluster,
+		restConfig:        &rest.Config{},
+	}
+
+	gvr := shipper.SchemeGroupVersion.WithResource("installationtargets")
+	conflicted := false
+	shipperclientset.PrependReactor("update", "installationtargets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		if conflicted {
+			return false, nil, nil
+		}
+		conflicted = true
+		return true, nil, kerrors.NewConflict(gvr.GroupResource(), installationTarget.Name, fmt.Errorf("the object has been modified"))
+	})
+
+	fakeRecorder := record.NewFakeRecorder(42)
+
+	c := newController(
+		shipperclientset, shipperInformerFactory, fakeClientProvider, fakeDynamicClientBuilder, fakeRecorder)
+
+	handleErrors := 0
+	runtimeutil.ErrorHandlers = []func(error){
+		func(err error) {
+			handleErrors = handleErrors + 1
+		},
+	}
+
+	if !c.processNextWorkItem() {
+		t.Fatal("Could not process work item")
+	}
+
+	if handleErrors != 0 {
+		t.Fatalf("expected the sync to absorb the conflict, got %d handled errors", handleErrors)
+	}
+
+	// We are interested only in the InstallationTarget's update and get
+	// actions here.
+	var filteredActions []kubetesting.Action
+	for _, a := range shipperclientset.Actions() {
+		if a.GetResource().Resource != "installationtargets" {
+			continue
+		}
+		if verb := a.GetVerb(); verb == "update" || verb == "get" {
+			filteredActions = append(filteredActions, a)
+		}
+	}
+
+	it := installationTarget.DeepCopy()
+	it.Status.Clusters = []*shipper.ClusterInstallationStatus{
+		{
+			Name: "minikube-a", Status: shipper.InstallationStatusInstalled,
+			AppliedOrder: []string{"Service/0.0.1-reviews-api", "Deployment/0.0.1-reviews-api"},
+			Conditions: []shipper.ClusterInstallationCondition{
+				{
+					Type:   shipper.ClusterConditionTypeOperational,
+					Status: corev1.ConditionTrue,
+				},
+				{
+					Type:   shipper.ClusterConditionTypeReady,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+	}
+
+	// The conflicted update is followed by a refetch and a second update
+	// carrying the same computed status.
+	expectedActions := []kubetesting.Action{
+		kubetesting.NewUpdateAction(gvr, release.GetNamespace(), it),
+		kubetesting.NewGetAction(gvr, release.GetNamespace(), installationTarget.GetName()),
+		kubetesting.NewUpdateAction(gvr, release.GetNamespace(), it),
+	}
+	shippertesting.CheckActions(expectedActions, filteredActions, t)
+}
+
 func TestInstallMultipleClusters(t *testing.T) {
 	clusterA := buildCluster("minikube-a")
 	clusterB := buildCluster("minikube-b")
@@ -178,7 +276,7 @@ func TestInstallMultipleClusters(t *testing.T) {
 	}
 
 	for _, fakePair := range clientsPerCluster {
-		shippertesting.ShallowCheckActions(expectedActions, fakePair.fakeDynamicClient.Actions(), t)
+		shippertesting.ShallowCheckActions(expectedActions, withoutNamespaceProbe(fakePair.fakeDynamicClient.Actions()), t)
 	}
 
 	// We are interested only in "update" actions here.
@@ -194,8 +292,9 @@ func TestInstallMultipleClusters(t *testing.T) {
 	it := installationTarget.DeepCopy()
 	it.Status.Clusters = []*shipper.ClusterInstallationStatus{
 		{
-			Name:   "minikube-a",
-			Status: shipper.InstallationStatusInstalled,
+			Name:         "minikube-a",
+			Status:       shipper.InstallationStatusInstalled,
+			AppliedOrder: []string{"Service/0.0.1-reviews-api", "Deployment/0.0.1-reviews-api"},
 			Conditions: []shipper.ClusterInstallationCondition{
 				{
 					Type:   shipper.ClusterConditionTypeOperational,
@@ -208,8 +307,9 @@ func TestInstallMultipleClusters(t *testing.T) {
 			},
 		},
 		{
-			Name:   "minikube-b",
-			Status: shipper.InstallationStatusInstalled,
+			Name:         "minikube-b",
+			Status:       shipper.InstallationStatusInstalled,
+			AppliedOrder: []string{"Service/0.0.1-reviews-api", "Deployment/0.0.1-reviews-api"},
 			Conditions: []shipper.ClusterInstallationCondition{
 				{
 					Type:   shipper.ClusterConditionTypeOperational,
@@ -223,6 +323,11 @@ func TestInstallMultipleClusters(t *testing.T) {
 		},
 	}
 	expectedActions = []kubetesting.Action{
+		kubetesting.NewUpdateAction(schema.GroupVersionResource{
+			Resource: "releases",
+			Version:  shipper.SchemeGroupVersion.Version,
+			Group:    shipper.SchemeGroupVersion.Group,
+		}, release.GetNamespace(), withPinnedChartDigest(release)),
 		kubetesting.NewUpdateAction(schema.GroupVersionResource{
 			Resource: "installationtargets",
 			Version:  shipper.SchemeGroupVersion.Version,
@@ -344,6 +449,11 @@ func TestClientError(t *testing.T) {
 		},
 	}
 	expectedActions := []kubetesting.Action{
+		kubetesting.NewUpdateAction(schema.GroupVersionResource{
+			Resource: "releases",
+			Version:  shipper.SchemeGroupVersion.Version,
+			Group:    shipper.SchemeGroupVersion.Group,
+		}, release.GetNamespace(), withPinnedChartDigest(release)),
 		kubetesting.NewUpdateAction(schema.GroupVersionResource{
 			Resource: "installationtargets",
 			Version:  shipper.SchemeGroupVersion.Version,
@@ -425,6 +535,11 @@ func TestTargetClusterMissesGVK(t *testing.T) {
 		},
 	}
 	expectedActions := []kubetesting.Action{
+		kubetesting.NewUpdateAction(schema.GroupVersionResource{
+			Resource: "releases",
+			Version:  shipper.SchemeGroupVersion.Version,
+			Group:    shipper.SchemeGroupVersion.Group,
+		}, release.GetNamespace(), withPinnedChartDigest(release)),
 		kubetesting.NewUpdateAction(schema.GroupVersionResource{
 			Resource: "installationtargets",
 			Version:  shipper.SchemeGroupVersion.Version,
@@ -507,6 +622,194 @@ func TestManagementServerMissesCluster(t *testing.T) {
 		},
 	}
 	expectedActions := []kubetesting.Action{
+		kubetesting.NewUpdateAction(schema.GroupVersionResource{
+			Resource: "releases",
+			Version:  shipper.SchemeGroupVersion.Version,
+			Group:    shipper.SchemeGroupVersion.Group,
+		}, release.GetNamespace(), withPinnedChartDigest(release)),
+		kubetesting.NewUpdateAction(schema.GroupVersionResource{
+			Resource: "installationtargets",
+			Version:  shipper.SchemeGroupVersion.Version,
+			Group:    shipper.SchemeGroupVersion.Group,
+		}, release.GetNamespace(), it),
+	}
+	var filteredActions []kubetesting.Action
+	for _, a := range shipperclientset.Actions() {
+		if a.GetVerb() == "update" {
+			filteredActions = append(filteredActions, a)
+		}
+	}
+
+	shippertesting.CheckActions(expectedActions, filteredActions, t)
+}
+
+// TestChartDigestMismatch tests a case where the repository has republished
+// the pinned chart version with different content: the release refuses the
+// new bytes and the installation target reports ChartDigestMismatch.
+func TestChartDigestMismatch(t *testing.T) {
+	var shipperclientset *shipperfake.Clientset
+
+	cluster := buildCluster("minikube-a")
+	appName := "reviews-api"
+	testNs := "reviews-api"
+	app := buildApplication(appName, testNs)
+	release := buildRelease("0.0.1", testNs, "0", "deadbeef", appName)
+	// The release was created against different chart bytes than the repo
+	// serves for this version now.
+	release.Annotations[shipper.ReleaseChartDigestAnnotation] = "digest-of-the-original-bytes"
+	installationTarget := buildInstallationTarget(release, testNs, appName, []string{cluster.Name})
+
+	clientsPerCluster, shipperclientset, fakeDynamicClientBuilder, shipperInformerFactory :=
+		initializeClients(apiResourceList, []runtime.Object{app, release, cluster, installationTarget}, objectsPerClusterMap{cluster.Name: nil})
+
+	fakeClientProvider := &FakeClientProvider{
+		clientsPerCluster: clientsPerCluster,
+		restConfig:        &rest.Config{},
+	}
+	fakeRecorder := record.NewFakeRecorder(42)
+
+	c := newController(
+		shipperclientset, shipperInformerFactory, fakeClientProvider, fakeDynamicClientBuilder, fakeRecorder)
+
+	handleErrors := 0
+	runtimeutil.ErrorHandlers = []func(error){
+		func(err error) {
+			handleErrors = handleErrors + 1
+		},
+	}
+
+	if !c.processNextWorkItem() {
+		t.Fatal("Could not process work item")
+	}
+
+	expectedHandleErrors := 1
+	if handleErrors != expectedHandleErrors {
+		t.Fatalf("expected %d handle errors, got %d instead", expectedHandleErrors, handleErrors)
+	}
+
+	resolved := withPinnedChartDigest(buildRelease("0.0.1", testNs, "0", "deadbeef", appName)).
+		Annotations[shipper.ReleaseChartDigestAnnotation]
+	message := fmt.Sprintf(
+		"chart reviews-api-0.0.1 resolved to digest %s but the release has pinned digest-of-the-original-bytes; refusing to install different bytes under the same version",
+		resolved)
+
+	it := installationTarget.DeepCopy()
+	it.Status.Clusters = []*shipper.ClusterInstallationStatus{
+		{
+			Name:    "minikube-a",
+			Status:  shipper.InstallationStatusFailed,
+			Message: message,
+			Conditions: []shipper.ClusterInstallationCondition{
+				{
+					Type:    shipper.ClusterConditionTypeReady,
+					Status:  corev1.ConditionFalse,
+					Reason:  conditions.ChartDigestMismatch,
+					Message: message,
+				},
+			},
+		},
+	}
+	expectedActions := []kubetesting.Action{
+		kubetesting.NewUpdateAction(schema.GroupVersionResource{
+			Resource: "installationtargets",
+			Version:  shipper.SchemeGroupVersion.Version,
+			Group:    shipper.SchemeGroupVersion.Group,
+		}, release.GetNamespace(), it),
+	}
+	var filteredActions []kubetesting.Action
+	for _, a := range shipperclientset.Actions() {
+		if a.GetVerb() == "update" {
+			filteredActions = append(filteredActions, a)
+		}
+	}
+
+	shippertesting.CheckActions(expectedActions, filteredActions, t)
+}
+
+// TestNamespaceTerminating tests a case where the target namespace on the
+// application cluster is stuck in the Terminating phase. The installation
+// must not attempt any apply, and instead report a NamespaceTerminating
+// Ready condition naming the finalizers blocking the namespace, so an
+// operator can unblock it.
+func TestNamespaceTerminating(t *testing.T) {
+	var shipperclientset *shipperfake.Clientset
+
+	cluster := buildCluster("minikube-a")
+	appName := "reviews-api"
+	testNs := "reviews-api"
+	app := buildApplication(appName, testNs)
+	release := buildRelease("0.0.1", testNs, "0", "deadbeef", appName)
+	installationTarget := buildInstallationTarget(release, testNs, appName, []string{cluster.Name})
+
+	terminatingNamespace := &corev1.Namespace{
+		ObjectMeta: v1.ObjectMeta{
+			Name:       testNs,
+			Finalizers: []string{"example.com/stuck-webhook"},
+		},
+		Spec: corev1.NamespaceSpec{
+			Finalizers: []corev1.FinalizerName{corev1.FinalizerKubernetes},
+		},
+		Status: corev1.NamespaceStatus{
+			Phase: corev1.NamespaceTerminating,
+		},
+	}
+
+	clientsPerCluster, shipperclientset, fakeDynamicClientBuilder, shipperInformerFactory :=
+		initializeClients(apiResourceList, []runtime.Object{app, release, cluster, installationTarget}, objectsPerClusterMap{cluster.Name: []runtime.Object{terminatingNamespace}})
+
+	fakeClientProvider := &FakeClientProvider{
+		clientsPerCluster: clientsPerCluster,
+		restConfig:        &rest.Config{},
+	}
+	fakeRecorder := record.NewFakeRecorder(42)
+
+	c := newController(
+		shipperclientset, shipperInformerFactory, fakeClientProvider, fakeDynamicClientBuilder, fakeRecorder)
+
+	handleErrors := 0
+	runtimeutil.ErrorHandlers = []func(error){
+		func(err error) {
+			handleErrors = handleErrors + 1
+		},
+	}
+
+	if !c.processNextWorkItem() {
+		t.Fatal("Could not process work item")
+	}
+
+	expectedHandleErrors := 1
+	if handleErrors != expectedHandleErrors {
+		t.Fatalf("expected %d handle errors, got %d instead", expectedHandleErrors, handleErrors)
+	}
+
+	message := `namespace "reviews-api" is terminating; blocked on finalizers [kubernetes example.com/stuck-webhook]`
+
+	it := installationTarget.DeepCopy()
+	it.Status.Clusters = []*shipper.ClusterInstallationStatus{
+		{
+			Name:    "minikube-a",
+			Status:  shipper.InstallationStatusFailed,
+			Message: message,
+			Conditions: []shipper.ClusterInstallationCondition{
+				{
+					Type:   shipper.ClusterConditionTypeOperational,
+					Status: corev1.ConditionTrue,
+				},
+				{
+					Type:    shipper.ClusterConditionTypeReady,
+					Status:  corev1.ConditionFalse,
+					Reason:  conditions.NamespaceTerminating,
+					Message: message,
+				},
+			},
+		},
+	}
+	expectedActions := []kubetesting.Action{
+		kubetesting.NewUpdateAction(schema.GroupVersionResource{
+			Resource: "releases",
+			Version:  shipper.SchemeGroupVersion.Version,
+			Group:    shipper.SchemeGroupVersion.Group,
+		}, release.GetNamespace(), withPinnedChartDigest(release)),
 		kubetesting.NewUpdateAction(schema.GroupVersionResource{
 			Resource: "installationtargets",
 			Version:  shipper.SchemeGroupVersion.Version,
@@ -521,4 +824,13 @@ func TestManagementServerMissesCluster(t *testing.T) {
 	}
 
 	shippertesting.CheckActions(expectedActions, filteredActions, t)
+
+	// Nothing may have been applied to the target cluster: no anchor, no
+	// namespace, none of the chart's objects.
+	fakePair := clientsPerCluster[cluster.Name]
+	for _, a := range fakePair.fakeDynamicClient.Actions() {
+		if a.GetVerb() == "create" || a.GetVerb() == "update" {
+			t.Fatalf("expected no apply on the target cluster, got %q on %q", a.GetVerb(), a.GetResource().Resource)
+		}
+	}
 }
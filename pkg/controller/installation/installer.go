@@ -1,15 +1,20 @@
 package installation
 
 import (
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/golang/glog"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -18,6 +23,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	kubescheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/helm/pkg/chartutil"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shipperchart "github.com/bookingcom/shipper/pkg/chart"
@@ -35,6 +41,73 @@ type Installer struct {
 	Release            *shipper.Release
 	InstallationTarget *shipper.InstallationTarget
 	Scheme             *runtime.Scheme
+
+	// capabilities, when set, renders the chart against the target
+	// cluster's actual Kubernetes version and API list instead of helm's
+	// defaults.
+	capabilities *chartutil.Capabilities
+
+	// requiredLabels is the set of labels every rendered object must
+	// carry, after the release's own labels have been propagated onto it.
+	// Empty means no enforcement.
+	requiredLabels []string
+
+	// allowedRegistries is the set of registry/prefix strings every
+	// container image in the rendered pod specs must match one of.
+	// Empty means no enforcement.
+	allowedRegistries []string
+
+	// appliedOrder records, for the last installManifests call, the order
+	// in which the rendered objects were applied.
+	appliedOrder []string
+
+	// maxConcurrentApplies bounds how many rendered objects apply
+	// concurrently within one cluster. Only objects of equal apply-order
+	// weight run in parallel; values below 2 keep applies serial.
+	maxConcurrentApplies int
+
+	// defaultQuota and defaultLimits, when non-empty, make the installer
+	// ensure the target namespace exists and stamp it with a ResourceQuota
+	// and LimitRange respectively.
+	defaultQuota  corev1.ResourceList
+	defaultLimits corev1.ResourceList
+
+	// appliedQuota records, for the last installRelease call, the quota
+	// stamped on the target namespace, in <resource>=<quantity> form.
+	appliedQuota string
+
+	// defaultValues, when non-empty, are platform-wide chart values
+	// merged beneath the release's own values during rendering; the
+	// release's values win key by key.
+	defaultValues shipper.ChartValues
+
+	// appliedDefaults records, for the last render, the top-level default
+	// value keys that were merged beneath the release's values.
+	appliedDefaults string
+
+	// replacedObjects records, for the last installManifests call, the
+	// objects that were deleted and recreated because they can't be
+	// updated in place, as Kind/name entries.
+	replacedObjects []string
+
+	// detectDrift makes the installer compare live objects that would
+	// otherwise be left alone against the rendered desired state, and
+	// autoCorrectDrift additionally re-applies the ones that drifted.
+	detectDrift      bool
+	autoCorrectDrift bool
+
+	// driftedFields records, for the last installManifests call, the
+	// fields on which live objects had drifted from the rendered desired
+	// state, as "Kind/name: path" entries.
+	driftedFields []string
+}
+
+// preparedObject is a decoded chart object waiting to be patched and applied,
+// together with the labels to inject and its apply-order weight.
+type preparedObject struct {
+	decoded runtime.Object
+	labels  map[string]string
+	weight  int
 }
 
 // NewInstaller returns a new Installer.
@@ -50,20 +123,96 @@ func NewInstaller(chartFetchFunc shipperchart.FetchFunc,
 	}
 }
 
+// targetNamespace returns the namespace this installation applies into: the
+// InstallationTarget's override when set, e.g. a strategy's canary
+// namespace, and the release's own namespace otherwise.
+func (i *Installer) targetNamespace() string {
+	if ns := i.InstallationTarget.Spec.TargetNamespace; ns != "" {
+		return ns
+	}
+	return i.Release.Namespace
+}
+
 // renderManifests returns a list of rendered manifests for the given release and
 // cluster, or an error.
 func (i *Installer) renderManifests(_ *shipper.Cluster) ([]string, error) {
 	rel := i.Release
 	chart, err := i.fetchChart(rel.Spec.Environment.Chart)
+	if err != nil {
+		if shippererrors.IsInvalidChartError(err) {
+			// Already precise, and keeping the type maps it to a
+			// ChartError Ready condition instead of UnknownError.
+			return nil, err
+		}
+		if shipperchart.IsDependencyUnresolvableError(err) {
+			// Definitive: no searched repo serves the dependency, so
+			// fail terminally instead of pending on retries.
+			return nil, err
+		}
+		return nil, shippererrors.NewRenderManifestError(err)
+	}
+
+	values, err := shipperchart.EnvironmentValues(chart, &rel.Spec.Environment)
 	if err != nil {
 		return nil, shippererrors.NewRenderManifestError(err)
 	}
 
-	rendered, err := shipperchart.Render(
+	// Platform-wide defaults sit beneath the environment's values, so
+	// every app picks them up without editing each Application; anything
+	// the Application sets overrides them key by key.
+	i.appliedDefaults = ""
+	if len(i.defaultValues) != 0 {
+		var override map[string]interface{}
+		if values != nil {
+			override = *values
+		}
+		merged := shipper.ChartValues(shipperchart.MergeValues(i.defaultValues, override))
+		values = &merged
+
+		keys := make([]string, 0, len(i.defaultValues))
+		for k := range i.defaultValues {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		i.appliedDefaults = strings.Join(keys, ",")
+	}
+
+	// Release-scoped feature flags render under the "flags" value, on top
+	// of any "flags" the environment provides, so flipping one on the
+	// live release re-renders without a new release.
+	if len(rel.Spec.Flags) != 0 {
+		var base map[string]interface{}
+		if values != nil {
+			base = *values
+		}
+		merged := shipper.ChartValues(shipperchart.MergeValues(base, map[string]interface{}{
+			"flags": map[string]interface{}(rel.Spec.Flags),
+		}))
+		values = &merged
+	}
+
+	// Surgical value edits apply last, on the document the templates
+	// actually see; like flags, editing them on the live release
+	// re-renders without a new release.
+	if len(rel.Spec.ValuesPatches) != 0 {
+		var base map[string]interface{}
+		if values != nil {
+			base = *values
+		}
+		patchedValues, err := shipperchart.ApplyValuesPatches(base, rel.Spec.ValuesPatches)
+		if err != nil {
+			return nil, shippererrors.NewInvalidChartError(err.Error())
+		}
+		merged := shipper.ChartValues(patchedValues)
+		values = &merged
+	}
+
+	rendered, err := shipperchart.RenderWithCapabilities(
 		chart,
 		rel.GetName(),
-		rel.GetNamespace(),
-		rel.Spec.Environment.Values,
+		i.targetNamespace(),
+		values,
+		i.capabilities,
 	)
 
 	if err != nil {
@@ -77,15 +226,40 @@ func (i *Installer) renderManifests(_ *shipper.Cluster) ([]string, error) {
 	return rendered, err
 }
 
+// flagsHash returns a stable fingerprint of the release's live-editable
+// rendering inputs — feature flags and values patches — or the empty string
+// when it has neither.
+func (i *Installer) flagsHash() string {
+	flags := i.Release.Spec.Flags
+	valuesPatches := i.Release.Spec.ValuesPatches
+	if len(flags) == 0 && len(valuesPatches) == 0 {
+		return ""
+	}
+
+	b, err := json.Marshal(struct {
+		Flags         shipper.ChartValues   `json:"flags,omitempty"`
+		ValuesPatches []shipper.ValuesPatch `json:"valuesPatches,omitempty"`
+	}{flags, valuesPatches})
+	if err != nil {
+		// ChartValues hold plain JSON values; this cannot fail.
+		panic(err)
+	}
+
+	hash := fnv.New32a()
+	hash.Write(b)
+	return fmt.Sprintf("%x", hash.Sum32())
+}
+
 // buildResourceClient returns a ResourceClient suitable to manipulate the kind
-// of resource represented by the given GroupVersionKind at the given Cluster.
+// of resource represented by the given GroupVersionKind at the given Cluster,
+// along with the discovered APIResource describing it.
 func (i *Installer) buildResourceClient(
 	cluster *shipper.Cluster,
 	client kubernetes.Interface,
 	restConfig *rest.Config,
 	dynamicClientBuilder DynamicClientBuilderFunc,
 	gvk *schema.GroupVersionKind,
-) (dynamic.ResourceInterface, error) {
+) (dynamic.ResourceInterface, *metav1.APIResource, error) {
 	dynamicClient := dynamicClientBuilder(gvk, restConfig, cluster)
 
 	// From the list of resources the target cluster knows about, find the resource for the
@@ -93,7 +267,7 @@ func (i *Installer) buildResourceClient(
 	var resource *metav1.APIResource
 	gv := gvk.GroupVersion()
 	if resources, err := client.Discovery().ServerResourcesForGroupVersion(gv.String()); err != nil {
-		return nil, shippererrors.NewKubeclientDiscoverError(gvk.GroupVersion(), err)
+		return nil, nil, shippererrors.NewKubeclientDiscoverError(gvk.GroupVersion(), err)
 	} else {
 		for _, e := range resources.APIResources {
 			if e.Kind == gvk.Kind {
@@ -104,15 +278,15 @@ func (i *Installer) buildResourceClient(
 
 		if resource == nil {
 			err := fmt.Errorf("resource %s not found", gvk.Kind)
-			return nil, shippererrors.NewUnrecoverableError(err)
+			return nil, nil, shippererrors.NewUnrecoverableError(err)
 		}
 	}
 
 	// If it gets to this point, it means we have a resource, so we can create a
 	// client for it scoping to the application's namespace. The namespace can be
 	// ignored if creating, for example, objects that aren't bound to a namespace.
-	resourceClient := dynamicClient.Resource(resource, i.Release.Namespace)
-	return resourceClient, nil
+	resourceClient := dynamicClient.Resource(resource, i.targetNamespace())
+	return resourceClient, resource, nil
 }
 
 func (i *Installer) patchDeployment(
@@ -289,7 +463,11 @@ func (i *Installer) installManifests(
 
 	if configMap, err = janitor.CreateConfigMapAnchor(i.InstallationTarget); err != nil {
 		return err
-	} else if existingConfigMap, err = client.CoreV1().ConfigMaps(i.Release.Namespace).Get(configMap.Name, metav1.GetOptions{}); err != nil && !errors.IsNotFound(err) {
+	}
+	// The anchor owns the installed objects, and owner references can't
+	// cross namespaces, so it lives wherever the objects install.
+	configMap.Namespace = i.targetNamespace()
+	if existingConfigMap, err = client.CoreV1().ConfigMaps(configMap.Namespace).Get(configMap.Name, metav1.GetOptions{}); err != nil && !errors.IsNotFound(err) {
 		return shippererrors.NewKubeclientGetError(i.Release.Name, configMap.Name, err).
 			WithCoreV1Kind("ConfigMap")
 	} else if err != nil { // errors.IsNotFound(err) == true
@@ -307,14 +485,13 @@ func (i *Installer) installManifests(
 	// We keep decoded objects and labels separately in order to perform
 	// some intermediate checks and decorate labels if needed before the
 	// actual patching happens.
-	preparedObjects := make([]struct {
-		decoded runtime.Object
-		labels  map[string]string
-	}, 0, len(manifests))
+	preparedObjects := make([]preparedObject, 0, len(manifests))
 
 	var (
 		productionLoadBalancerServices []*corev1.Service
 		allServices                    []*corev1.Service
+		labelViolations                []string
+		registryViolations             []string
 	)
 
 	// Try to install all the rendered objects in the target cluster. We should
@@ -326,7 +503,7 @@ func (i *Installer) installManifests(
 	// and convert it to unstructured in addition of keep tabs of the number of
 	// v1.Service manifests that have the lb label set to production.
 	for _, manifest := range manifests {
-		decodedObj, _, err :=
+		decodedObj, gvk, err :=
 			kubescheme.Codecs.
 				UniversalDeserializer().
 				Decode([]byte(manifest), nil, nil)
@@ -372,10 +549,67 @@ func (i *Installer) installManifests(
 			}
 		}
 
-		preparedObjects = append(preparedObjects, struct {
-			decoded runtime.Object
-			labels  map[string]string
-		}{decoded: decodedObj, labels: i.Release.Labels})
+		// Enforce the org-wide required label policy. The release's own
+		// labels are propagated onto every object during patching, so
+		// they count towards the requirement.
+		if len(i.requiredLabels) > 0 {
+			accessor, err := meta.Accessor(decodedObj)
+			if err != nil {
+				return shippererrors.NewDecodeManifestError("error accessing object metadata: %s", err)
+			}
+
+			objLabels := mergeLabels(accessor.GetLabels(), i.Release.Labels)
+
+			var missing []string
+			for _, label := range i.requiredLabels {
+				if _, ok := objLabels[label]; !ok {
+					missing = append(missing, label)
+				}
+			}
+
+			if len(missing) > 0 {
+				labelViolations = append(labelViolations, fmt.Sprintf("%T %q is missing labels %v", decodedObj, accessor.GetName(), missing))
+			}
+		}
+
+		// Enforce the approved-registry policy against every container
+		// image the object's pod spec would run.
+		if len(i.allowedRegistries) > 0 {
+			accessor, err := meta.Accessor(decodedObj)
+			if err != nil {
+				return shippererrors.NewDecodeManifestError("error accessing object metadata: %s", err)
+			}
+
+			for _, image := range podSpecImages(decodedObj) {
+				if !imageFromApprovedRegistry(image, i.allowedRegistries) {
+					registryViolations = append(registryViolations, fmt.Sprintf("%T %q runs image %q", decodedObj, accessor.GetName(), image))
+				}
+			}
+		}
+
+		var objAnnotations map[string]string
+		if accessor, err := meta.Accessor(decodedObj); err == nil {
+			objAnnotations = accessor.GetAnnotations()
+		}
+
+		preparedObjects = append(preparedObjects, preparedObject{
+			decoded: decodedObj,
+			labels:  i.Release.Labels,
+			weight:  installWeight(gvk.Kind, objAnnotations),
+		})
+	}
+
+	if len(labelViolations) > 0 {
+		return shippererrors.NewInvalidChartError(
+			fmt.Sprintf("chart objects do not satisfy the required label policy: %s",
+				strings.Join(labelViolations, "; ")))
+	}
+
+	if len(registryViolations) > 0 {
+		return shippererrors.NewInvalidChartError(
+			fmt.Sprintf("chart images do not come from an approved registry (approved: %s): %s",
+				strings.Join(i.allowedRegistries, ", "),
+				strings.Join(registryViolations, "; ")))
 	}
 
 	// If we have observed only 1 Service object and it was not marked
@@ -399,128 +633,407 @@ func (i *Installer) installManifests(
 	}
 	chosenService.Labels[shipper.LBLabel] = shipper.LBForProduction
 
-	// The second loop is meant to install all the decoded and transformed
-	// manifests once we assume it the Chart is in good shape.
-	for _, r := range preparedObjects {
-		decodedObj, err := i.patchObject(r.decoded, r.labels, &ownerReference)
-		if err != nil {
-			return err
-		}
+	// Apply order: explicit weights first, then the kind-based default,
+	// keeping the renderer's ordering for equal weights.
+	sort.SliceStable(preparedObjects, func(a, b int) bool {
+		return preparedObjects[a].weight < preparedObjects[b].weight
+	})
+
+	// The second pass is meant to install all the decoded and transformed
+	// manifests once we assume it the Chart is in good shape. Objects of
+	// equal weight carry no ordering constraints between them, so each
+	// weight group applies concurrently up to the configured limit; the
+	// next group only starts once the whole group succeeded, which keeps
+	// the CRDs-first and explicit install-order guarantees intact.
+	limit := i.maxConcurrentApplies
+	if limit < 1 {
+		limit = 1
+	}
 
-		// This is the Service object we picked as the production LB
-		if decodedObj == chosenService {
-			if svc, ok := decodedObj.(*corev1.Service); ok {
-				decodedObj, err = i.modifyServiceSelector(svc)
+	i.appliedOrder = make([]string, 0, len(preparedObjects))
+	i.replacedObjects = nil
+	i.driftedFields = nil
+	for start := 0; start < len(preparedObjects); {
+		end := start + 1
+		for end < len(preparedObjects) && preparedObjects[end].weight == preparedObjects[start].weight {
+			end++
+		}
+		group := preparedObjects[start:end]
+		start = end
+
+		var (
+			wg  sync.WaitGroup
+			mu  sync.Mutex
+			sem = make(chan struct{}, limit)
+		)
+
+		applied := make([]string, len(group))
+		replaced := make([]bool, len(group))
+		drifted := make([][]string, len(group))
+		groupErrors := shippererrors.NewMultiError()
+
+		for n, r := range group {
+			// Acquiring the slot before spawning admits objects in
+			// their sorted order, so a limit of one degrades to the
+			// old strictly sequential apply.
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(n int, r preparedObject) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				entry, wasReplaced, drift, err := i.applyPreparedObject(cluster, client, restConfig, dynamicClientBuilderFunc, r, &ownerReference, chosenService)
 				if err != nil {
-					return err
+					mu.Lock()
+					groupErrors.Append(err)
+					mu.Unlock()
+					return
 				}
-			} else {
-				// This is a weird situation and this check is kept
-				// here mostly for the sake of checking the world sanity
-				return shippererrors.NewInvalidChartError(
-					fmt.Sprintf("Object %#v is expected to be a Service."+
-						" Can not proceed forward", decodedObj))
+
+				applied[n] = entry
+				replaced[n] = wasReplaced
+				drifted[n] = drift
+			}(n, r)
+		}
+
+		wg.Wait()
+
+		// The weight-sorted order stays deterministic regardless of
+		// which goroutine finished first.
+		for n, entry := range applied {
+			if entry == "" {
+				continue
+			}
+			i.appliedOrder = append(i.appliedOrder, entry)
+			if replaced[n] {
+				i.replacedObjects = append(i.replacedObjects, entry)
 			}
+			i.driftedFields = append(i.driftedFields, drifted[n]...)
 		}
 
-		// ResourceClient.Create() requires an Unstructured object to work with, so we
-		// need to convert.
-		unstrObj := &unstructured.Unstructured{}
-		err = i.Scheme.Convert(decodedObj, unstrObj, nil)
-		if err != nil {
-			return shippererrors.NewConvertUnstructuredError("error converting object to unstructured: %s", err)
+		if groupErrors.Any() {
+			return groupErrors.Flatten()
+		}
+	}
+
+	return nil
+}
+
+// replaceableKinds are object kinds whose specs are immutable once created,
+// so changes to them can only apply by delete-and-recreate.
+var replaceableKinds = map[string]bool{
+	"Job": true,
+}
+
+// statefulKinds are object kinds that hold data or identity and must never be
+// replaced, not even when annotated for it: recreating them would disrupt the
+// running application.
+var statefulKinds = map[string]bool{
+	"StatefulSet":           true,
+	"PersistentVolume":      true,
+	"PersistentVolumeClaim": true,
+}
+
+// replaceOnApply reports whether a changed object of the given kind applies
+// by delete-and-recreate rather than update. Inherently immutable kinds
+// always do; other kinds can opt in through an annotation on the rendered
+// object; stateful kinds never do.
+func replaceOnApply(kind string, annotations map[string]string) bool {
+	if statefulKinds[kind] {
+		return false
+	}
+
+	if replaceableKinds[kind] {
+		return true
+	}
+
+	return annotations[shipper.InstallationReplaceOnApplyAnnotation] == shipper.True
+}
+
+// applyPreparedObject patches, converts and applies a single rendered object
+// on the target cluster, returning the Kind/name entry for the applied-order
+// record and whether the object had to be replaced rather than updated.
+func (i *Installer) applyPreparedObject(
+	cluster *shipper.Cluster,
+	client kubernetes.Interface,
+	restConfig *rest.Config,
+	dynamicClientBuilderFunc DynamicClientBuilderFunc,
+	r preparedObject,
+	ownerReference *metav1.OwnerReference,
+	chosenService *corev1.Service,
+) (string, bool, []string, error) {
+	decodedObj, err := i.patchObject(r.decoded, r.labels, ownerReference)
+	if err != nil {
+		return "", false, nil, err
+	}
+
+	// This is the Service object we picked as the production LB
+	if decodedObj == chosenService {
+		if svc, ok := decodedObj.(*corev1.Service); ok {
+			decodedObj, err = i.modifyServiceSelector(svc)
+			if err != nil {
+				return "", false, nil, err
+			}
+		} else {
+			// This is a weird situation and this check is kept
+			// here mostly for the sake of checking the world sanity
+			return "", false, nil, shippererrors.NewInvalidChartError(
+				fmt.Sprintf("Object %#v is expected to be a Service."+
+					" Can not proceed forward", decodedObj))
+		}
+	}
+
+	// ResourceClient.Create() requires an Unstructured object to work with, so we
+	// need to convert.
+	unstrObj := &unstructured.Unstructured{}
+	err = i.Scheme.Convert(decodedObj, unstrObj, nil)
+	if err != nil {
+		return "", false, nil, shippererrors.NewConvertUnstructuredError("error converting object to unstructured: %s", err)
+	}
+
+	// Stamp the flags fingerprint so a later flag flip on the live
+	// release is detected below and re-applied.
+	if hash := i.flagsHash(); hash != "" {
+		annotations := unstrObj.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
 		}
+		annotations[shipper.InstallationFlagsHashAnnotation] = hash
+		unstrObj.SetAnnotations(annotations)
+	}
+
+	name := unstrObj.GetName()
+	namespace := unstrObj.GetNamespace()
+	gvk := unstrObj.GroupVersionKind()
 
-		name := unstrObj.GetName()
-		namespace := unstrObj.GetNamespace()
-		gvk := unstrObj.GroupVersionKind()
+	entry := fmt.Sprintf("%s/%s", gvk.Kind, name)
 
-		// Once we've gathered enough information about the document we want to
-		// install, we're able to build a resource client to interact with the target
-		// cluster.
-		resourceClient, err := i.buildResourceClient(cluster, client, restConfig, dynamicClientBuilderFunc, &gvk)
+	// Once we've gathered enough information about the document we want to
+	// install, we're able to build a resource client to interact with the target
+	// cluster.
+	resourceClient, resource, err := i.buildResourceClient(cluster, client, restConfig, dynamicClientBuilderFunc, &gvk)
+	if err != nil {
+		return "", false, nil, err
+	}
+
+	// "fetch-and-create-or-update" strategy in here; this is required to
+	// overcome an issue in Kubernetes where a "create-or-update" strategy
+	// leads to exceeding quotas when those are enabled very quickly,
+	// since Kubernetes machinery first increase quota usage and then
+	// attempts to create the resource, taking some time to re-sync
+	// the quota information when objects can't be created since they
+	// already exist.
+	existingObj, err := resourceClient.Get(name, metav1.GetOptions{})
+
+	// Any error other than NotFound is not recoverable from this point on.
+	if err != nil && !errors.IsNotFound(err) {
+		return "", false, nil, shippererrors.
+			NewKubeclientGetError(namespace, name, err).
+			WithKind(gvk)
+	}
+
+	// If have an error here, it means it is NotFound, so proceed to
+	// create the object on the application cluster.
+	if err != nil {
+		_, err = resourceClient.Create(unstrObj)
 		if err != nil {
-			return err
+			return "", false, nil, shippererrors.
+				NewKubeclientCreateError(unstrObj, err).
+				WithKind(gvk)
 		}
+		return entry, false, nil, nil
+	}
+
+	// We inject a Namespace object in the objects to be installed for a
+	// particular Release; we don't want to continue if the Namespace already
+	// exists.
+	if gvk := existingObj.GroupVersionKind(); gvk.Kind == "Namespace" {
+		return entry, false, nil, nil
+	}
 
-		// "fetch-and-create-or-update" strategy in here; this is required to
-		// overcome an issue in Kubernetes where a "create-or-update" strategy
-		// leads to exceeding quotas when those are enabled very quickly,
-		// since Kubernetes machinery first increase quota usage and then
-		// attempts to create the resource, taking some time to re-sync
-		// the quota information when objects can't be created since they
-		// already exist.
-		existingObj, err := resourceClient.Get(name, metav1.GetOptions{})
+	// A cluster-scoped object can't be namespaced away per release: if the
+	// incumbent's chart rendered the same singleton, applying this copy
+	// would clobber it mid-rollout. Refuse, and leave the running
+	// release's copy alone.
+	if !resource.Namespaced {
+		if owner, ok := existingObj.GetLabels()[shipper.ReleaseLabel]; ok && owner != i.Release.Name {
+			return "", false, nil, shippererrors.NewSingletonConflictError(gvk.Kind, name, owner)
+		}
+	}
 
-		// Any error other than NotFound is not recoverable from this point on.
+	// A Deployment's replica count belongs to the capacity controller:
+	// patchDeployment renders it as zero, so carry the live value over
+	// before comparing or re-applying. Scaling is never drift, and
+	// re-applying the rendered zero would scale the release down.
+	if _, ok := decodedObj.(*appsv1.Deployment); ok {
+		if liveReplicas, ok := unstructured.NestedInt64(existingObj.UnstructuredContent(), "spec", "replicas"); ok {
+			newUnstructuredObj := unstrObj.UnstructuredContent()
+			unstructured.SetNestedField(newUnstructuredObj, liveReplicas, "spec", "replicas")
+			unstrObj.SetUnstructuredContent(newUnstructuredObj)
+		}
+	}
+
+	// If the existing object was stamped with the driving release,
+	// there's nothing left to do for this manifest — unless the release's
+	// flags changed since it was applied, which changes the rendered
+	// output and warrants an update, or drift detection finds an
+	// out-of-band edit that auto-correction should re-apply over.
+	var drift []string
+	if releaseLabelValue, ok := existingObj.GetLabels()[shipper.ReleaseLabel]; ok && releaseLabelValue == i.Release.Name {
+		if existingObj.GetAnnotations()[shipper.InstallationFlagsHashAnnotation] == i.flagsHash() {
+			if i.detectDrift {
+				drift = driftedFields(entry, unstrObj.UnstructuredContent(), existingObj.UnstructuredContent())
+			}
+			if len(drift) == 0 || !i.autoCorrectDrift {
+				return entry, false, drift, nil
+			}
+		}
+	} else if !ok {
+		// The object exists but isn't managed by shipper. If the
+		// InstallationTarget opted into adoption we take ownership of it
+		// below, just like an object handed over from a previous release:
+		// it matches the rendered set by construction, since we only ever
+		// fetch objects by the rendered name and kind.
+		if !i.adoptExistingObjects() {
+			return "", false, nil, shippererrors.NewIncompleteReleaseError(`Release "%s/%s" misses the required label %q`, existingObj.GetNamespace(), existingObj.GetName(), shipper.ReleaseLabel)
+		}
+		glog.Infof("Adopting existing unmanaged object %s %q in cluster %q", gvk.Kind, name, cluster.Name)
+	}
+
+	// The object exists and needs to change. Kinds that can't be updated
+	// in place apply by delete-and-recreate instead; updating them would
+	// fail on their immutable fields.
+	if replaceOnApply(gvk.Kind, unstrObj.GetAnnotations()) {
+		propagation := metav1.DeletePropagationBackground
+		err := resourceClient.Delete(name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
 		if err != nil && !errors.IsNotFound(err) {
-			return shippererrors.
-				NewKubeclientGetError(namespace, name, err).
+			return "", false, nil, shippererrors.
+				NewKubeclientDeleteError(namespace, name, err).
 				WithKind(gvk)
 		}
 
-		// If have an error here, it means it is NotFound, so proceed to
-		// create the object on the application cluster.
-		if err != nil {
-			_, err = resourceClient.Create(unstrObj)
-			if err != nil {
-				return shippererrors.
-					NewKubeclientCreateError(unstrObj, err).
-					WithKind(gvk)
-			}
-			continue
+		if _, err := resourceClient.Create(unstrObj); err != nil {
+			return "", false, nil, shippererrors.
+				NewKubeclientCreateError(unstrObj, err).
+				WithKind(gvk)
 		}
 
-		// We inject a Namespace object in the objects to be installed for a
-		// particular Release; we don't want to continue if the Namespace already
-		// exists.
-		if gvk := existingObj.GroupVersionKind(); gvk.Kind == "Namespace" {
-			continue
+		glog.Infof("Replaced immutable object %s %q in cluster %q", gvk.Kind, name, cluster.Name)
+		return entry, true, drift, nil
+	}
+
+	ownerReferenceFound := false
+	for _, o := range existingObj.GetOwnerReferences() {
+		if reflect.DeepEqual(o, *ownerReference) {
+			ownerReferenceFound = true
 		}
+	}
+	if !ownerReferenceFound {
+		ownerReferences := append(existingObj.GetOwnerReferences(), *ownerReference)
+		sort.Slice(ownerReferences, func(i, j int) bool {
+			return ownerReferences[i].Name < ownerReferences[j].Name
+		})
+		existingObj.SetOwnerReferences(ownerReferences)
+	}
+	existingObj.SetLabels(unstrObj.GetLabels())
+	existingObj.SetAnnotations(unstrObj.GetAnnotations())
+	existingUnstructuredObj := existingObj.UnstructuredContent()
+	newUnstructuredObj := unstrObj.UnstructuredContent()
+	switch decodedObj.(type) {
+	case *corev1.Service:
+		// Copy over clusterIP from existing object's .spec to the
+		// rendered one.
+		if clusterIP, ok := unstructured.NestedString(existingUnstructuredObj, "spec", "clusterIP"); ok {
+			unstructured.SetNestedField(newUnstructuredObj, clusterIP, "spec", "clusterIP")
+		}
+	}
+	unstructured.SetNestedField(existingUnstructuredObj, newUnstructuredObj["spec"], "spec")
+	// ConfigMap-style payloads live under "data" rather than "spec";
+	// carry them over the same way so flag-driven re-renders take effect.
+	if data, ok := newUnstructuredObj["data"]; ok {
+		unstructured.SetNestedField(existingUnstructuredObj, data, "data")
+	}
+	existingObj.SetUnstructuredContent(existingUnstructuredObj)
+	if _, clientErr := resourceClient.Update(existingObj); clientErr != nil {
+		return "", false, nil, shippererrors.
+			NewKubeclientUpdateError(unstrObj, err).
+			WithKind(gvk)
+	}
+
+	return entry, false, drift, nil
+}
 
-		// If the existing object was stamped with the driving release,
-		// continue to the next manifest.
-		if releaseLabelValue, ok := existingObj.GetLabels()[shipper.ReleaseLabel]; ok && releaseLabelValue == i.Release.Name {
+// adoptExistingObjects reports whether this InstallationTarget opted into
+// adopting pre-existing objects that aren't managed by shipper, as is the
+// case when migrating an application previously deployed with plain Helm.
+func (i *Installer) adoptExistingObjects() bool {
+	return i.InstallationTarget.Annotations[shipper.InstallationAdoptExistingObjectsAnnotation] == shipper.True
+}
+
+// driftedFields lists the paths on which the live object no longer matches
+// the rendered desired state, as "Kind/name: path" entries. Only the parts
+// the installer manages are compared — "spec" and "data" — and within them
+// only fields the rendered object actually sets, so server-side defaulting
+// on the live object doesn't read as drift.
+func driftedFields(entry string, desired, live map[string]interface{}) []string {
+	var drifted []string
+	for _, section := range []string{"spec", "data"} {
+		want, ok := desired[section]
+		if !ok {
 			continue
-		} else if !ok {
-			return shippererrors.NewIncompleteReleaseError(`Release "%s/%s" misses the required label %q`, existingObj.GetNamespace(), existingObj.GetName(), shipper.ReleaseLabel)
 		}
+		for _, path := range diffFields(want, live[section], section) {
+			drifted = append(drifted, fmt.Sprintf("%s: %s", entry, path))
+		}
+	}
+	return drifted
+}
 
-		ownerReferenceFound := false
-		for _, o := range existingObj.GetOwnerReferences() {
-			if reflect.DeepEqual(o, ownerReference) {
-				ownerReferenceFound = true
-			}
+// diffFields walks desired and returns the paths where live disagrees with
+// it. Maps compare key by key over desired's keys only; slices compare
+// element-wise and report the whole path on a length mismatch; everything
+// else compares by deep equality.
+func diffFields(desired, live interface{}, path string) []string {
+	switch want := desired.(type) {
+	case map[string]interface{}:
+		have, ok := live.(map[string]interface{})
+		if !ok {
+			return []string{path}
 		}
-		if !ownerReferenceFound {
-			ownerReferences := append(existingObj.GetOwnerReferences(), ownerReference)
-			sort.Slice(ownerReferences, func(i, j int) bool {
-				return ownerReferences[i].Name < ownerReferences[j].Name
-			})
-			existingObj.SetOwnerReferences(ownerReferences)
-		}
-		existingObj.SetLabels(unstrObj.GetLabels())
-		existingObj.SetAnnotations(unstrObj.GetAnnotations())
-		existingUnstructuredObj := existingObj.UnstructuredContent()
-		newUnstructuredObj := unstrObj.UnstructuredContent()
-		switch decodedObj.(type) {
-		case *corev1.Service:
-			// Copy over clusterIP from existing object's .spec to the
-			// rendered one.
-			if clusterIP, ok := unstructured.NestedString(existingUnstructuredObj, "spec", "clusterIP"); ok {
-				unstructured.SetNestedField(newUnstructuredObj, clusterIP, "spec", "clusterIP")
+
+		keys := make([]string, 0, len(want))
+		for k := range want {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var drifted []string
+		for _, k := range keys {
+			v, ok := have[k]
+			if !ok {
+				drifted = append(drifted, path+"."+k)
+				continue
 			}
+			drifted = append(drifted, diffFields(want[k], v, path+"."+k)...)
 		}
-		unstructured.SetNestedField(existingUnstructuredObj, newUnstructuredObj["spec"], "spec")
-		existingObj.SetUnstructuredContent(existingUnstructuredObj)
-		if _, clientErr := resourceClient.Update(existingObj); clientErr != nil {
-			return shippererrors.
-				NewKubeclientUpdateError(unstrObj, err).
-				WithKind(gvk)
+		return drifted
+	case []interface{}:
+		have, ok := live.([]interface{})
+		if !ok || len(have) != len(want) {
+			return []string{path}
 		}
-	}
 
-	return nil
+		var drifted []string
+		for n := range want {
+			drifted = append(drifted, diffFields(want[n], have[n], fmt.Sprintf("%s[%d]", path, n))...)
+		}
+		return drifted
+	default:
+		if !reflect.DeepEqual(desired, live) {
+			return []string{path}
+		}
+		return nil
+	}
 }
 
 // installRelease attempts to install the given release on the given cluster.
@@ -531,14 +1044,77 @@ func (i *Installer) installRelease(
 	dynamicClientBuilder DynamicClientBuilderFunc,
 ) error {
 
+	if err := i.checkNamespaceNotTerminating(client); err != nil {
+		return err
+	}
+
+	if err := i.ensureNamespace(cluster, client); err != nil {
+		return err
+	}
+
 	renderedManifests, err := i.renderManifests(cluster)
 	if err != nil {
 		return err
 	}
 
+	renderedManifests, err = i.applyPostRenderPatches(renderedManifests)
+	if err != nil {
+		return err
+	}
+
+	renderedManifests, err = i.applyImageOverrides(renderedManifests)
+	if err != nil {
+		return err
+	}
+
 	return i.installManifests(cluster, client, restConfig, dynamicClientBuilder, renderedManifests)
 }
 
+// podSpecImages lists every container image the decoded object would run,
+// covering the workload kinds charts commonly render. Objects without a pod
+// template yield nothing.
+func podSpecImages(obj runtime.Object) []string {
+	var spec *corev1.PodSpec
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		spec = &o.Spec
+	case *appsv1.Deployment:
+		spec = &o.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		spec = &o.Spec.Template.Spec
+	case *appsv1.DaemonSet:
+		spec = &o.Spec.Template.Spec
+	case *appsv1.ReplicaSet:
+		spec = &o.Spec.Template.Spec
+	case *batchv1.Job:
+		spec = &o.Spec.Template.Spec
+	default:
+		return nil
+	}
+
+	images := make([]string, 0, len(spec.InitContainers)+len(spec.Containers))
+	for _, container := range spec.InitContainers {
+		images = append(images, container.Image)
+	}
+	for _, container := range spec.Containers {
+		images = append(images, container.Image)
+	}
+
+	return images
+}
+
+// imageFromApprovedRegistry reports whether the image reference matches one
+// of the approved registry/prefix strings.
+func imageFromApprovedRegistry(image string, approved []string) bool {
+	for _, prefix := range approved {
+		if strings.HasPrefix(image, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // mergeLabels takes to sets of labels and merge them into another set.
 //
 // Values of the second set overwrite values from the first one.
@@ -1,11 +1,15 @@
 package installation
 
 import (
+	"fmt"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -13,6 +17,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	kubescheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	kubetesting "k8s.io/client-go/testing"
@@ -48,6 +56,18 @@ var apiResourceList = []*metav1.APIResourceList{
 				Name:       "pods",
 				Group:      "",
 			},
+			{
+				Kind:       "Secret",
+				Namespaced: true,
+				Name:       "secrets",
+				Group:      "",
+			},
+			{
+				Kind:       "ConfigMap",
+				Namespaced: true,
+				Name:       "configmaps",
+				Group:      "",
+			},
 		},
 	},
 	{
@@ -72,6 +92,26 @@ var apiResourceList = []*metav1.APIResourceList{
 			},
 		},
 	},
+	{
+		GroupVersion: "batch/v1",
+		APIResources: []metav1.APIResource{
+			{
+				Kind:       "Job",
+				Namespaced: true,
+				Name:       "jobs",
+			},
+		},
+	},
+	{
+		GroupVersion: "rbac.authorization.k8s.io/v1",
+		APIResources: []metav1.APIResource{
+			{
+				Kind:       "ClusterRole",
+				Namespaced: false,
+				Name:       "clusterroles",
+			},
+		},
+	},
 }
 
 // TestInstaller tests the installation process using a Installer directly.
@@ -120,7 +160,7 @@ func ImplTestInstaller(t *testing.T, shipperObjects []runtime.Object, kubeObject
 		t.Fatal(err)
 	}
 
-	shippertesting.ShallowCheckActions(expectedActions, fakePair.fakeDynamicClient.Actions(), t)
+	shippertesting.ShallowCheckActions(expectedActions, withoutNamespaceProbe(fakePair.fakeDynamicClient.Actions()), t)
 
 	filteredActions := filterActions(fakePair.fakeDynamicClient.Actions(), "create")
 	validateAction(t, filteredActions[0], "ConfigMap")
@@ -206,36 +246,638 @@ func validateDeploymentCreateAction(t *testing.T, obj runtime.Object, validateLa
 		t.Fatal("deployment .spec.selector.matchLabels doesn't contain shipperV1.ReleaseLabel")
 	}
 
-	if _, ok := deployment.Spec.Template.Labels[shipper.ReleaseLabel]; !ok {
-		t.Fatal("deployment .spec.template.labels doesn't contain shipperV1.ReleaseLabel")
+	if _, ok := deployment.Spec.Template.Labels[shipper.ReleaseLabel]; !ok {
+		t.Fatal("deployment .spec.template.labels doesn't contain shipperV1.ReleaseLabel")
+	}
+
+	// If provided, we ensure the labels from the list present in the
+	// produced deployment object.
+	for labelName, labelValue := range validateLabels {
+		actualChartLabelValue, ok := deployment.Spec.Template.Labels[labelName]
+		if !ok {
+			t.Fatalf("deployment .spec.template.labels doesn't contain a label (%q) which was present in the chart", labelName)
+		}
+
+		if actualChartLabelValue != labelValue {
+			t.Fatalf(
+				"deployment .spec.template.labels has the right previously-existing label (%q) but wrong value. Expected %q but got %q",
+				labelName, labelValue, actualChartLabelValue,
+			)
+		}
+	}
+
+}
+
+func filterActions(actions []kubetesting.Action, verb string) []kubetesting.Action {
+	var filteredActions []kubetesting.Action
+	for _, a := range actions {
+		if a.GetVerb() == verb {
+			filteredActions = append(filteredActions, a)
+		}
+	}
+	return filteredActions
+}
+
+// withoutNamespaceProbe drops the read-only namespace phase check every
+// installRelease starts with, so expectations keep describing only the
+// rendered chart objects. The kube and dynamic fakes share one action
+// recorder, which is why the probe shows up in the dynamic client's stream.
+func withoutNamespaceProbe(actions []kubetesting.Action) []kubetesting.Action {
+	var filteredActions []kubetesting.Action
+	for _, a := range actions {
+		if a.GetVerb() == "get" && a.GetResource().Resource == "namespaces" {
+			continue
+		}
+		filteredActions = append(filteredActions, a)
+	}
+	return filteredActions
+}
+
+// TestInstallerWeightedSecret tests that an object carrying an explicit
+// install-weight annotation is applied at its annotated position rather than
+// the kind-based default, and in particular that the Secret still applies
+// before the Deployment that consumes it.
+func TestInstallerWeightedSecret(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+
+	// The weighted-secret chart adds a Secret carrying the install-weight
+	// annotation to the baseline service-and-deployment chart.
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	release.Spec.Environment.Chart.Version = "weighted-secret"
+
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedActions := []kubetesting.Action{
+		kubetesting.NewGetAction(schema.GroupVersionResource{Resource: "configmaps", Version: "v1"}, release.GetNamespace(), "0.0.1-anchor"),
+		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "configmaps", Version: "v1"}, release.GetNamespace(), nil),
+		kubetesting.NewGetAction(schema.GroupVersionResource{Resource: "services", Version: "v1"}, release.GetNamespace(), "0.0.1-reviews-api"),
+		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "services", Version: "v1"}, release.GetNamespace(), nil),
+		kubetesting.NewGetAction(schema.GroupVersionResource{Resource: "secrets", Version: "v1"}, release.GetNamespace(), "0.0.1-reviews-api"),
+		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "secrets", Version: "v1"}, release.GetNamespace(), nil),
+		kubetesting.NewGetAction(schema.GroupVersionResource{Resource: "deployments", Version: "v1", Group: "apps"}, release.GetNamespace(), "0.0.1-reviews-api"),
+		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "deployments", Version: "v1", Group: "apps"}, release.GetNamespace(), nil),
+	}
+	shippertesting.ShallowCheckActions(expectedActions, withoutNamespaceProbe(fakePair.fakeDynamicClient.Actions()), t)
+
+	// The annotated weight (-10) moves the Secret after the Service
+	// (the default weight for Secrets would apply it first), while keeping
+	// it ahead of the consuming Deployment.
+	expectedOrder := []string{
+		"Service/0.0.1-reviews-api",
+		"Secret/0.0.1-reviews-api",
+		"Deployment/0.0.1-reviews-api",
+	}
+	if !reflect.DeepEqual(installer.appliedOrder, expectedOrder) {
+		t.Fatalf("wrong applied order: expected %v, got %v", expectedOrder, installer.appliedOrder)
+	}
+}
+
+// TestInstallerConcurrentApplies tests that objects of equal install-order
+// weight apply concurrently up to the configured limit, while objects of
+// different weights still apply strictly in weight order.
+func TestInstallerConcurrentApplies(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+
+	// The many-configmaps chart adds six ConfigMaps to the baseline
+	// service-and-deployment chart; they share the kind-based weight and
+	// therefore form one apply group.
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	release.Spec.Environment.Chart.Version = "many-configmaps"
+
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+	installer.maxConcurrentApplies = 3
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	arrived := make(chan struct{}, 16)
+	proceed := make(chan struct{})
+
+	// Hold ConfigMap applies at the door so the test can observe how many
+	// goroutines get there at once.
+	trackingClientBuilder := func(gvk *schema.GroupVersionKind, config *rest.Config, c *shipper.Cluster) dynamic.Interface {
+		if gvk.Kind == "ConfigMap" {
+			arrived <- struct{}{}
+			<-proceed
+		}
+		return fakeDynamicClientBuilder(gvk, config, c)
+	}
+
+	restConfig := &rest.Config{}
+
+	installErr := make(chan error)
+	go func() {
+		installErr <- installer.installRelease(cluster, fakePair.fakeClient, restConfig, trackingClientBuilder)
+	}()
+
+	// Exactly three ConfigMap applies start concurrently; the fourth only
+	// gets a slot once one of them finishes.
+	for n := 0; n < 3; n++ {
+		select {
+		case <-arrived:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d applies started concurrently, expected the limit of 3", n)
+		}
+	}
+
+	select {
+	case <-arrived:
+		t.Fatal("a fourth apply started while three were in flight, expected the limit of 3")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Release the gate; the remaining ConfigMaps flow through freely.
+	close(proceed)
+
+	if err := <-installErr; err != nil {
+		t.Fatal(err)
+	}
+
+	// The ConfigMap group applies, in some order, before the Service, which
+	// in turn applies before the Deployment.
+	if n := len(installer.appliedOrder); n != 8 {
+		t.Fatalf("expected 8 applied objects, got %d: %v", n, installer.appliedOrder)
+	}
+	for n, entry := range installer.appliedOrder[:6] {
+		if !strings.HasPrefix(entry, "ConfigMap/") {
+			t.Fatalf("expected entry %d to be a ConfigMap, got %q", n, entry)
+		}
+	}
+	if got := installer.appliedOrder[6]; got != "Service/0.0.1-reviews-api" {
+		t.Fatalf("expected the Service to apply after the ConfigMaps, got %q", got)
+	}
+	if got := installer.appliedOrder[7]; got != "Deployment/0.0.1-reviews-api" {
+		t.Fatalf("expected the Deployment to apply last, got %q", got)
+	}
+}
+
+// TestInstallerFlagsReRender tests that editing the release-scoped Flags on a
+// live release re-renders its objects in place: flipping a flag updates the
+// already-installed ConfigMap for the very same release, with no new release
+// involved.
+func TestInstallerFlagsReRender(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+
+	// The flags chart renders a ConfigMap exposing .Values.flags.newUI,
+	// which defaults to false in the chart's own values.
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	release.Spec.Environment.Chart.Version = "flags"
+	release.Spec.Flags = shipper.ChartValues{"newUI": true}
+
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	flagsConfigMapData := func(verb string) map[string]interface{} {
+		for _, a := range filterActions(fakePair.fakeDynamicClient.Actions(), verb) {
+			obj := a.(kubetesting.CreateAction).GetObject()
+			u, content := extractUnstructuredContent(kubescheme.Scheme, obj)
+			if u.GetKind() != "ConfigMap" || u.GetName() != "0.0.1-reviews-api-flags" {
+				continue
+			}
+			if releaseLabelValue := u.GetLabels()[shipper.ReleaseLabel]; releaseLabelValue != release.Name {
+				t.Fatalf("flags ConfigMap labeled with release %q, expected %q", releaseLabelValue, release.Name)
+			}
+			data, _ := content["data"].(map[string]interface{})
+			return data
+		}
+		t.Fatalf("no %q action for the flags ConfigMap", verb)
+		return nil
+	}
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	if data := flagsConfigMapData("create"); data["newUI"] != "true" {
+		t.Fatalf(`expected the flags ConfigMap to render newUI "true", got %v`, data["newUI"])
+	}
+
+	// Flip the flag on the live release and install again: the existing
+	// ConfigMap updates in place rather than being short-circuited as
+	// already owned by this release.
+	release.Spec.Flags["newUI"] = false
+	fakePair.fakeDynamicClient.Fake.ClearActions()
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	if data := flagsConfigMapData("update"); data["newUI"] != "false" {
+		t.Fatalf(`expected the flags ConfigMap to re-render newUI "false", got %v`, data["newUI"])
+	}
+}
+
+// TestInstallerValuesPatches tests that JSON-patch-style edits on the
+// release apply to the merged values before rendering: an add and a replace
+// both land in the rendered Deployment, and a patch against a path the
+// values document doesn't have fails with an InvalidChartError.
+func TestInstallerValuesPatches(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	release.Spec.Environment.Values = &shipper.ChartValues{
+		"image": map[string]interface{}{"tag": "stable"},
+	}
+	release.Spec.ValuesPatches = []shipper.ValuesPatch{
+		{Op: "replace", Path: "/image/tag", Value: "v9"},
+		{Op: "add", Path: "/image/pullPolicy", Value: "Always"},
+	}
+
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	var container map[string]interface{}
+	for _, a := range filterActions(fakePair.fakeDynamicClient.Actions(), "create") {
+		obj := a.(kubetesting.CreateAction).GetObject()
+		u, content := extractUnstructuredContent(kubescheme.Scheme, obj)
+		if u.GetKind() != "Deployment" {
+			continue
+		}
+		spec := content["spec"].(map[string]interface{})
+		template := spec["template"].(map[string]interface{})
+		podSpec := template["spec"].(map[string]interface{})
+		containers := podSpec["containers"].([]interface{})
+		container = containers[0].(map[string]interface{})
+	}
+	if container == nil {
+		t.Fatal("no create action for the Deployment")
+	}
+
+	if image := container["image"]; image != "nginx:v9" {
+		t.Fatalf(`expected the replace patch to render image "nginx:v9", got %v`, image)
+	}
+	if policy := container["imagePullPolicy"]; policy != "Always" {
+		t.Fatalf(`expected the add patch to render imagePullPolicy "Always", got %v`, policy)
+	}
+
+	// A patch against a path the values document doesn't have is a chart
+	// problem, not something retries can fix.
+	release.Spec.ValuesPatches = []shipper.ValuesPatch{
+		{Op: "replace", Path: "/resources/limits/memory", Value: "1Gi"},
+	}
+
+	err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder)
+	if err == nil {
+		t.Fatal("installRelease should fail, the patch path doesn't exist in the values document")
+	}
+	if _, ok := err.(shippererrors.InvalidChartError); !ok {
+		t.Fatalf("installRelease should fail with InvalidChartError, got %v instead", err)
+	}
+}
+
+// TestInstallerCanaryNamespace tests that an InstallationTarget carrying a
+// target-namespace override — as set by a canary-namespace strategy during
+// early steps — installs every object into the isolated namespace, creating
+// it on the way, instead of touching the application namespace.
+func TestInstallerCanaryNamespace(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+
+	canaryNamespace := "reviews-api-deadbeef-canary"
+
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	it.Spec.TargetNamespace = canaryNamespace
+	installer := newInstaller(release, it)
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	sawNamespace := false
+	namespacedCreates := 0
+	// The dynamic client shares its Fake with the kube client, so its
+	// actions cover both.
+	for _, a := range filterActions(fakePair.fakeDynamicClient.Actions(), "create") {
+		obj := a.(kubetesting.CreateAction).GetObject()
+		u, _ := extractUnstructuredContent(kubescheme.Scheme, obj)
+
+		// The typed kube fake records creates without TypeMeta, so go by
+		// the action's resource rather than the object's kind.
+		if a.GetResource().Resource == "namespaces" {
+			if u.GetName() != canaryNamespace {
+				t.Fatalf("expected only the canary namespace to be created, got %q", u.GetName())
+			}
+			sawNamespace = true
+			continue
+		}
+
+		namespacedCreates++
+		if ns := a.GetNamespace(); ns != canaryNamespace {
+			t.Errorf("%s %q was created in namespace %q, want %q", u.GetKind(), u.GetName(), ns, canaryNamespace)
+		}
+	}
+
+	if !sawNamespace {
+		t.Error("the canary namespace was never created")
+	}
+	if namespacedCreates == 0 {
+		t.Error("no objects were created at all")
+	}
+}
+
+// TestInstallerDriftDetection tests that with drift detection on, an
+// out-of-band edit to a live Deployment's image is reported field by field,
+// left alone by default, and re-applied back to the rendered desired state
+// once auto-correction is enabled — while the replica count, which the
+// capacity controller owns, is never treated as drift.
+func TestInstallerDriftDetection(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+	installer.detectDrift = true
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	fakePair.fakeDynamicClient.GroupVersion = schema.GroupVersion{Group: "apps", Version: "v1"}
+	deployments := fakePair.fakeDynamicClient.Resource(
+		&metav1.APIResource{Name: "deployments", Kind: "Deployment", Namespaced: true},
+		"reviews-api",
+	)
+
+	liveDeployment := func() *unstructured.Unstructured {
+		obj, err := deployments.Get("0.0.1-reviews-api", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+
+	// A pass over an untouched cluster sees no drift.
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+	if drifted := installer.driftedFields; len(drifted) != 0 {
+		t.Fatalf("expected no drift on an untouched cluster, got %v", drifted)
+	}
+
+	// Change the live Deployment's replicas and image out of band.
+	live := liveDeployment()
+	content := live.UnstructuredContent()
+	unstructured.SetNestedField(content, int64(42), "spec", "replicas")
+	containers, _ := unstructured.NestedSlice(content, "spec", "template", "spec", "containers")
+	containers[0].(map[string]interface{})["image"] = "nginx:hacked"
+	unstructured.SetNestedSlice(content, containers, "spec", "template", "spec", "containers")
+	live.SetUnstructuredContent(content)
+	if _, err := deployments.Update(live); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"Deployment/0.0.1-reviews-api: spec.template.spec.containers[0].image",
+	}
+
+	// Without auto-correction the image drift is reported but the edit
+	// stays; the replica edit isn't drift at all, since the capacity
+	// controller owns that field.
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(installer.driftedFields, expected) {
+		t.Fatalf("expected drifted fields %v, got %v", expected, installer.driftedFields)
+	}
+	if replicas, _ := unstructured.NestedInt64(liveDeployment().UnstructuredContent(), "spec", "replicas"); replicas != 42 {
+		t.Fatalf("expected the out-of-band edit to be left alone, got %d replicas", replicas)
+	}
+
+	// With auto-correction the image drift is reported once more and the
+	// live object goes back to the rendered desired state, except for the
+	// capacity-managed replica count.
+	installer.autoCorrectDrift = true
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(installer.driftedFields, expected) {
+		t.Fatalf("expected drifted fields %v, got %v", expected, installer.driftedFields)
+	}
+
+	content = liveDeployment().UnstructuredContent()
+	if replicas, _ := unstructured.NestedInt64(content, "spec", "replicas"); replicas != 42 {
+		t.Fatalf("expected auto-correction to leave the replica count alone, got %d", replicas)
+	}
+	containers, _ = unstructured.NestedSlice(content, "spec", "template", "spec", "containers")
+	if image := containers[0].(map[string]interface{})["image"]; image != "nginx:stable" {
+		t.Fatalf(`expected auto-correction to restore image "nginx:stable", got %v`, image)
+	}
+
+	// And the pass after the correction sees no drift again.
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+	if drifted := installer.driftedFields; len(drifted) != 0 {
+		t.Fatalf("expected no drift after auto-correction, got %v", drifted)
+	}
+}
+
+// TestInstallerReplacesImmutableJob tests that a chart change touching a
+// Job's immutable pod template applies by delete-and-recreate instead of
+// failing the in-place update, and that the replacement is recorded.
+func TestInstallerReplacesImmutableJob(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+
+	// The job chart adds a migration Job to the baseline chart, rendering
+	// its container image tag from .Values.flags.migrateTag.
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	release.Spec.Environment.Chart.Version = "job"
+	release.Spec.Flags = shipper.ChartValues{"migrateTag": "v1"}
+
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(installer.replacedObjects) != 0 {
+		t.Fatalf("first install should create, not replace, got %v", installer.replacedObjects)
+	}
+
+	// Flip the flag on the live release: the re-rendered Job differs from
+	// the installed one in its immutable pod template.
+	release.Spec.Flags["migrateTag"] = "v2"
+	fakePair.fakeDynamicClient.Fake.ClearActions()
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatalf("the changed Job should be replaced, not fail the apply: %s", err)
+	}
+
+	deleteActions := filterActions(fakePair.fakeDynamicClient.Actions(), "delete")
+	if len(deleteActions) != 1 || deleteActions[0].GetResource().Resource != "jobs" {
+		t.Fatalf("expected exactly 1 delete action for the Job, got %v", deleteActions)
+	}
+
+	var jobImage string
+	for _, a := range filterActions(fakePair.fakeDynamicClient.Actions(), "create") {
+		obj := a.(kubetesting.CreateAction).GetObject()
+		u, _ := extractUnstructuredContent(kubescheme.Scheme, obj)
+		if u.GetKind() != "Job" {
+			continue
+		}
+
+		job := &batchv1.Job{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, job); err != nil {
+			t.Fatalf("could not decode job from unstructured: %s", err)
+		}
+		jobImage = job.Spec.Template.Spec.Containers[0].Image
+	}
+	if jobImage != "nginx:v2" {
+		t.Fatalf("expected the recreated Job to render the new tag, got image %q", jobImage)
+	}
+
+	expectedReplaced := []string{"Job/0.0.1-reviews-api-migrate"}
+	if !reflect.DeepEqual(installer.replacedObjects, expectedReplaced) {
+		t.Fatalf("wrong replaced objects: expected %v, got %v", expectedReplaced, installer.replacedObjects)
+	}
+}
+
+// TestInstallerSingletonConflict tests that a contender whose chart renders
+// the same cluster-scoped object name as the still-running incumbent refuses
+// to clobber it, failing with a SingletonConflictError instead.
+func TestInstallerSingletonConflict(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+
+	// The cluster-role chart adds a ClusterRole with a fixed name to the
+	// baseline chart, so every release of the app renders the same
+	// cluster-scoped object.
+	incumbent := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	incumbent.Spec.Environment.Chart.Version = "cluster-role"
+	incumbentIt := buildInstallationTarget(incumbent, "reviews-api", "reviews-api", []string{cluster.Name})
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	if err := newInstaller(incumbent, incumbentIt).installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	contender := buildRelease("0.0.2", "reviews-api", "1", "cafebabe", "reviews-api")
+	contender.Spec.Environment.Chart.Version = "cluster-role"
+	contenderIt := buildInstallationTarget(contender, "reviews-api", "reviews-api", []string{cluster.Name})
+
+	fakePair.fakeDynamicClient.Fake.ClearActions()
+
+	err := newInstaller(contender, contenderIt).installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder)
+	if !shippererrors.IsSingletonConflictError(err) {
+		t.Fatalf("expected a SingletonConflictError for the shared ClusterRole, got %v", err)
+	}
+
+	// The incumbent's copy was left alone.
+	for _, verb := range []string{"update", "delete"} {
+		for _, a := range filterActions(fakePair.fakeDynamicClient.Actions(), verb) {
+			if a.GetResource().Resource == "clusterroles" {
+				t.Fatalf("the incumbent's ClusterRole must not see a %q action", verb)
+			}
+		}
+	}
+}
+
+// TestInstallerAdoptsExistingUnmanagedObjects tests that a pre-existing
+// object that isn't managed by shipper fails the installation by default,
+// and is adopted (stamped with shipper's labels and owner reference) when
+// the InstallationTarget opts into adoption.
+func TestInstallerAdoptsExistingUnmanagedObjects(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+
+	existingDeployment := &unstructured.Unstructured{}
+	existingDeployment.SetAPIVersion("apps/v1")
+	existingDeployment.SetKind("Deployment")
+	existingDeployment.SetName("0.0.1-reviews-api")
+	existingDeployment.SetNamespace("reviews-api")
+	existingDeployment.SetLabels(map[string]string{"app": "reviews-api", "heritage": "Tiller"})
+
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{existingDeployment.DeepCopy()}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder)
+	if !shippererrors.IsIncompleteReleaseError(err) {
+		t.Fatalf("installRelease should fail with IncompleteReleaseError for unmanaged existing objects, got %v instead", err)
+	}
+
+	// Opting into adoption takes ownership of the existing Deployment
+	// instead of erroring out.
+	it.Annotations = map[string]string{shipper.InstallationAdoptExistingObjectsAnnotation: shipper.True}
+	installer = newInstaller(release, it)
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ = initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{existingDeployment.DeepCopy()}})
+	fakePair = clientsPerCluster[cluster.Name]
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatalf("installRelease should adopt the existing Deployment: %s", err)
+	}
+
+	updateActions := filterActions(fakePair.fakeDynamicClient.Actions(), "update")
+	if len(updateActions) != 1 {
+		t.Fatalf("expected exactly 1 update action for the adopted Deployment, got %d", len(updateActions))
 	}
 
-	// If provided, we ensure the labels from the list present in the
-	// produced deployment object.
-	for labelName, labelValue := range validateLabels {
-		actualChartLabelValue, ok := deployment.Spec.Template.Labels[labelName]
-		if !ok {
-			t.Fatalf("deployment .spec.template.labels doesn't contain a label (%q) which was present in the chart", labelName)
-		}
-
-		if actualChartLabelValue != labelValue {
-			t.Fatalf(
-				"deployment .spec.template.labels has the right previously-existing label (%q) but wrong value. Expected %q but got %q",
-				labelName, labelValue, actualChartLabelValue,
-			)
-		}
+	updatedObj := updateActions[0].(kubetesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+	if relName := updatedObj.GetLabels()[shipper.ReleaseLabel]; relName != release.Name {
+		t.Fatalf("adopted Deployment should carry label %q=%q, got %q", shipper.ReleaseLabel, release.Name, relName)
 	}
 
-}
-
-func filterActions(actions []kubetesting.Action, verb string) []kubetesting.Action {
-	var filteredActions []kubetesting.Action
-	for _, a := range actions {
-		if a.GetVerb() == verb {
-			filteredActions = append(filteredActions, a)
-		}
+	if len(updatedObj.GetOwnerReferences()) == 0 {
+		t.Fatal("adopted Deployment should have shipper's owner reference")
 	}
-	return filteredActions
 }
 
 // TestInstallerBrokenChartTarball tests if the installation process fails when the
@@ -311,6 +953,90 @@ func TestInstallerChartTarballInvalidDeploymentName(t *testing.T) {
 	}
 }
 
+// TestInstallerRequiredLabels tests that the installation process fails with
+// an InvalidChartError when the installer is configured with a required label
+// that the rendered objects don't carry, and succeeds when the label is
+// satisfied through the release's propagated labels.
+func TestInstallerRequiredLabels(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+	installer.requiredLabels = []string{"team"}
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder)
+	if err == nil {
+		t.Fatal("installRelease should fail, objects are missing the required label")
+	}
+
+	if _, ok := err.(shippererrors.InvalidChartError); !ok {
+		t.Fatalf("installRelease should fail with InvalidChartError, got %v instead", err)
+	}
+
+	// The release's own labels are propagated onto rendered objects, so
+	// requiring one of them should pass.
+	installer = newInstaller(release, it)
+	installer.requiredLabels = []string{shipper.AppLabel}
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ = initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+	fakePair = clientsPerCluster[cluster.Name]
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatalf("installRelease should succeed, required label is propagated from the release: %s", err)
+	}
+}
+
+// TestInstallerApprovedRegistries tests that the installation process fails
+// with an InvalidChartError naming the offending image when the chart pulls
+// from a registry outside the configured allowlist, and succeeds when the
+// image matches an approved prefix.
+func TestInstallerApprovedRegistries(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+	// The chart renders image "nginx:stable", which is an implicit Docker
+	// Hub pull and so doesn't match the approved registry.
+	installer.allowedRegistries = []string{"registry.example.com/"}
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ := initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder)
+	if err == nil {
+		t.Fatal("installRelease should fail, the chart's image is not from an approved registry")
+	}
+
+	if _, ok := err.(shippererrors.InvalidChartError); !ok {
+		t.Fatalf("installRelease should fail with InvalidChartError, got %v instead", err)
+	}
+
+	if !strings.Contains(err.Error(), `"nginx:stable"`) {
+		t.Fatalf("the error should name the offending image, got: %s", err)
+	}
+
+	installer = newInstaller(release, it)
+	installer.allowedRegistries = []string{"registry.example.com/", "nginx"}
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ = initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: []runtime.Object{}})
+	fakePair = clientsPerCluster[cluster.Name]
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatalf("installRelease should succeed, the image matches an approved prefix: %s", err)
+	}
+}
+
 // TestInstallerBrokenChartContents tests if the installation process fails when the
 // release contains a valid chart tarball with invalid K8s object templates.
 func TestInstallerBrokenChartContents(t *testing.T) {
@@ -367,7 +1093,7 @@ func TestInstallerSingleServiceNoLB(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	shippertesting.ShallowCheckActions(expectedActions, fakePair.fakeDynamicClient.Actions(), t)
+	shippertesting.ShallowCheckActions(expectedActions, withoutNamespaceProbe(fakePair.fakeDynamicClient.Actions()), t)
 
 	filteredActions := filterActions(fakePair.fakeDynamicClient.Actions(), "create")
 	validateAction(t, filteredActions[0], "ConfigMap")
@@ -408,7 +1134,7 @@ func TestInstallerSingleServiceWithLB(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	shippertesting.ShallowCheckActions(expectedActions, fakePair.fakeDynamicClient.Actions(), t)
+	shippertesting.ShallowCheckActions(expectedActions, withoutNamespaceProbe(fakePair.fakeDynamicClient.Actions()), t)
 
 	filteredActions := filterActions(fakePair.fakeDynamicClient.Actions(), "create")
 	validateAction(t, filteredActions[0], "ConfigMap")
@@ -482,7 +1208,7 @@ func TestInstallerMultiServiceWithLB(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	shippertesting.ShallowCheckActions(expectedActions, fakePair.fakeDynamicClient.Actions(), t)
+	shippertesting.ShallowCheckActions(expectedActions, withoutNamespaceProbe(fakePair.fakeDynamicClient.Actions()), t)
 
 	filteredActions := filterActions(fakePair.fakeDynamicClient.Actions(), "create")
 	validateAction(t, filteredActions[0], "ConfigMap")
@@ -549,7 +1275,7 @@ func TestInstallerMultiServiceWithLBOffTheShelf(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	shippertesting.ShallowCheckActions(expectedActions, fakePair.fakeDynamicClient.Actions(), t)
+	shippertesting.ShallowCheckActions(expectedActions, withoutNamespaceProbe(fakePair.fakeDynamicClient.Actions()), t)
 
 	filteredActions := filterActions(fakePair.fakeDynamicClient.Actions(), "create")
 	validateAction(t, filteredActions[0], "ConfigMap")
@@ -592,3 +1318,332 @@ func TestInstallerServiceWithReleaseNoWorkaround(t *testing.T) {
 		t.Fatalf("Unexpected error: %s", err)
 	}
 }
+
+// TestInstallerStampsNamespaceQuota checks that a configured default quota
+// makes the installer ensure the target namespace exists and create the
+// ResourceQuota alongside it, before any chart object is applied.
+func TestInstallerStampsNamespaceQuota(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+
+	installer := newInstaller(release, it)
+	quota, err := ParseResourceList("cpu=16,memory=64Gi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	installer.defaultQuota = quota
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ :=
+		initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: nil})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	expectedActions := []kubetesting.Action{
+		kubetesting.NewRootCreateAction(schema.GroupVersionResource{Resource: "namespaces", Version: "v1"}, nil),
+		kubetesting.NewGetAction(schema.GroupVersionResource{Resource: "resourcequotas", Version: "v1"}, release.GetNamespace(), namespaceQuotaName),
+		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "resourcequotas", Version: "v1"}, release.GetNamespace(), nil),
+		kubetesting.NewGetAction(schema.GroupVersionResource{Resource: "configmaps", Version: "v1"}, release.GetNamespace(), "0.0.1-anchor"),
+		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "configmaps", Version: "v1"}, release.GetNamespace(), nil),
+		kubetesting.NewGetAction(schema.GroupVersionResource{Resource: "services", Version: "v1"}, release.GetNamespace(), "0.0.1-reviews-api"),
+		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "services", Version: "v1"}, release.GetNamespace(), nil),
+		kubetesting.NewGetAction(schema.GroupVersionResource{Resource: "deployments", Version: "v1", Group: "apps"}, release.GetNamespace(), "0.0.1-reviews-api"),
+		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "deployments", Version: "v1", Group: "apps"}, release.GetNamespace(), nil),
+	}
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	shippertesting.ShallowCheckActions(expectedActions, withoutNamespaceProbe(fakePair.fakeDynamicClient.Actions()), t)
+
+	createActions := filterActions(fakePair.fakeDynamicClient.Actions(), "create")
+	createdQuota := createActions[1].(kubetesting.CreateAction).GetObject().(*corev1.ResourceQuota)
+	if !reflect.DeepEqual(createdQuota.Spec.Hard, quota) {
+		t.Errorf("created ResourceQuota has hard limits %v, expected %v", createdQuota.Spec.Hard, quota)
+	}
+
+	if expected := "cpu=16,memory=64Gi"; installer.appliedQuota != expected {
+		t.Errorf("expected applied quota %q, got %q", expected, installer.appliedQuota)
+	}
+}
+
+// TestInstallerQuotaAnnotationOverride checks that the resourceQuota
+// annotation on the InstallationTarget takes precedence over the configured
+// default quota.
+func TestInstallerQuotaAnnotationOverride(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	it.Annotations = map[string]string{
+		shipper.InstallationResourceQuotaAnnotation: "pods=20",
+	}
+
+	installer := newInstaller(release, it)
+	quota, err := ParseResourceList("cpu=16,memory=64Gi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	installer.defaultQuota = quota
+
+	clientsPerCluster, _, fakeDynamicClientBuilder, _ :=
+		initializeClients(apiResourceList, nil, objectsPerClusterMap{cluster.Name: nil})
+
+	fakePair := clientsPerCluster[cluster.Name]
+
+	restConfig := &rest.Config{}
+
+	if err := installer.installRelease(cluster, fakePair.fakeClient, restConfig, fakeDynamicClientBuilder); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "pods=20"; installer.appliedQuota != expected {
+		t.Errorf("expected applied quota %q, got %q", expected, installer.appliedQuota)
+	}
+}
+
+// TestDefaultChartValuesMergeBeneathAppValues renders with platform-wide
+// default values: a default the chart parameterizes shows up in the rendered
+// output, and an Application-level value for the same key wins over the
+// default.
+func TestDefaultChartValuesMergeBeneathAppValues(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+	installer.defaultValues = shipper.ChartValues{"replicaCount": 5}
+
+	manifests, err := installer.renderManifests(cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replicas := findDeployment(t, manifests).Spec.Replicas; replicas == nil || *replicas != 5 {
+		t.Errorf("expected the platform default to render 5 replicas, got %v", replicas)
+	}
+	if installer.appliedDefaults != "replicaCount" {
+		t.Errorf("expected the applied defaults to record %q, got %q", "replicaCount", installer.appliedDefaults)
+	}
+
+	// The Application's own values override the platform default.
+	release.Spec.Environment.Values = &shipper.ChartValues{"replicaCount": 3}
+	manifests, err = installer.renderManifests(cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replicas := findDeployment(t, manifests).Spec.Replicas; replicas == nil || *replicas != 3 {
+		t.Errorf("expected the app-level value to win with 3 replicas, got %v", replicas)
+	}
+}
+
+// TestRenderPerClusterCapabilities checks that the same chart renders
+// differently on clusters running different Kubernetes versions: the
+// installer follows each cluster's discovered capabilities instead of helm's
+// compiled-in defaults, so version-gated templates emit the right apiVersion
+// per cluster.
+func TestRenderPerClusterCapabilities(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	release.Spec.Environment.Chart.Version = "version-gated"
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+
+	clusterVersions := []struct {
+		minor      string
+		apiVersion string
+	}{
+		{"8", "apiVersion: apps/v1beta2"},
+		{"16", "apiVersion: apps/v1"},
+	}
+
+	for _, cv := range clusterVersions {
+		fakeClient := kubefake.NewSimpleClientset()
+		fakeClient.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{
+			Major:      "1",
+			Minor:      cv.minor,
+			GitVersion: fmt.Sprintf("v1.%s.0", cv.minor),
+		}
+
+		caps, err := clusterCapabilities(fakeClient)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		installer := newInstaller(release, it)
+		installer.capabilities = caps
+
+		manifests, err := installer.renderManifests(cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		foundDeployment := false
+		for _, manifest := range manifests {
+			if !strings.Contains(manifest, "kind: Deployment") {
+				continue
+			}
+			foundDeployment = true
+			if !strings.Contains(manifest, cv.apiVersion) {
+				t.Errorf("cluster on 1.%s: expected the Deployment to render with %q, got:\n%s",
+					cv.minor, cv.apiVersion, manifest)
+			}
+		}
+		if !foundDeployment {
+			t.Errorf("cluster on 1.%s: the chart rendered no Deployment", cv.minor)
+		}
+	}
+}
+
+// TestPostRenderPatchAddsNodeSelector exercises a post-render patch adding a
+// nodeSelector to the chart's Deployment, which the chart itself doesn't
+// parameterize.
+func TestPostRenderPatchAddsNodeSelector(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	release.Spec.Environment.PostRenderPatches = []shipper.PostRenderPatch{
+		{
+			Kind:  "Deployment",
+			Name:  "0.0.1-reviews-api",
+			Patch: `{"spec": {"template": {"spec": {"nodeSelector": {"worker-pool": "canary"}}}}}`,
+		},
+	}
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+
+	manifests, err := installer.renderManifests(cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patched, err := installer.applyPostRenderPatches(manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deployment *appsv1.Deployment
+	for _, manifest := range patched {
+		decodedObj, _, err := kubescheme.Codecs.UniversalDeserializer().Decode([]byte(manifest), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d, ok := decodedObj.(*appsv1.Deployment); ok {
+			deployment = d
+		}
+	}
+
+	if deployment == nil {
+		t.Fatal("no Deployment found in the patched manifests")
+	}
+
+	if got := deployment.Spec.Template.Spec.NodeSelector["worker-pool"]; got != "canary" {
+		t.Errorf(`expected nodeSelector worker-pool="canary" on the patched Deployment, got %q`, got)
+	}
+
+	if deployment.Spec.Template.Spec.Containers[0].Image == "" {
+		t.Error("patching lost the Deployment's container spec")
+	}
+}
+
+// TestPostRenderPatchMissingTargetFails makes sure a patch naming an object
+// the chart doesn't render fails loudly instead of being skipped.
+func TestPostRenderPatchMissingTargetFails(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	release.Spec.Environment.PostRenderPatches = []shipper.PostRenderPatch{
+		{
+			Kind:  "Deployment",
+			Name:  "no-such-deployment",
+			Patch: `{"spec": {"template": {"spec": {"nodeSelector": {"worker-pool": "canary"}}}}}`,
+		},
+	}
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+
+	manifests, err := installer.renderManifests(cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = installer.applyPostRenderPatches(manifests)
+	if err == nil {
+		t.Fatal("expected an error for a post-render patch without a matching rendered object")
+	}
+
+	if !strings.Contains(err.Error(), "no-such-deployment") {
+		t.Errorf("expected the error to name the missing target, got %q", err)
+	}
+}
+
+// TestImageOverrideRewritesTag exercises an image override replacing one
+// container's tag in the rendered Deployment without touching the chart.
+func TestImageOverrideRewritesTag(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+
+	manifests, err := installer.renderManifests(cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := imageRepository(findDeployment(t, manifests).Spec.Template.Spec.Containers[0].Image)
+	release.Spec.Environment.ImageOverrides = map[string]string{
+		repo: "hotfix-tag",
+	}
+
+	overridden, err := installer.applyImageOverrides(manifests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image := findDeployment(t, overridden).Spec.Template.Spec.Containers[0].Image
+	if expected := repo + ":hotfix-tag"; image != expected {
+		t.Errorf("expected container image %q, got %q", expected, image)
+	}
+}
+
+// TestImageOverrideMissingImageFails makes sure an override for a repository
+// the chart never renders fails loudly instead of being skipped.
+func TestImageOverrideMissingImageFails(t *testing.T) {
+	cluster := buildCluster("minikube-a")
+	release := buildRelease("0.0.1", "reviews-api", "0", "deadbeef", "reviews-api")
+	release.Spec.Environment.ImageOverrides = map[string]string{
+		"no-such-registry/no-such-image": "hotfix-tag",
+	}
+	it := buildInstallationTarget(release, "reviews-api", "reviews-api", []string{cluster.Name})
+	installer := newInstaller(release, it)
+
+	manifests, err := installer.renderManifests(cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = installer.applyImageOverrides(manifests)
+	if err == nil {
+		t.Fatal("expected an error for an image override without a matching rendered container")
+	}
+
+	if !strings.Contains(err.Error(), "no-such-registry/no-such-image") {
+		t.Errorf("expected the error to name the missing repository, got %q", err)
+	}
+}
+
+func findDeployment(t *testing.T, manifests []string) *appsv1.Deployment {
+	t.Helper()
+
+	for _, manifest := range manifests {
+		decodedObj, _, err := kubescheme.Codecs.UniversalDeserializer().Decode([]byte(manifest), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d, ok := decodedObj.(*appsv1.Deployment); ok {
+			return d
+		}
+	}
+
+	t.Fatal("no Deployment found in the manifests")
+	return nil
+}
@@ -0,0 +1,213 @@
+package installation
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+const (
+	// namespaceQuotaName is the name of the ResourceQuota shipper stamps on
+	// target namespaces it ensures.
+	namespaceQuotaName = "shipper-quota"
+
+	// namespaceLimitRangeName is the name of the LimitRange shipper stamps
+	// on target namespaces it ensures.
+	namespaceLimitRangeName = "shipper-limits"
+)
+
+// ParseResourceList parses a comma-separated list of <resource>=<quantity>
+// pairs, e.g. "cpu=16,memory=64Gi", into a ResourceList.
+func ParseResourceList(spec string) (corev1.ResourceList, error) {
+	rl := make(corev1.ResourceList)
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected <resource>=<quantity>, got %q", pair)
+		}
+
+		quantity, err := resource.ParseQuantity(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q for resource %q: %s", parts[1], parts[0], err)
+		}
+
+		rl[corev1.ResourceName(parts[0])] = quantity
+	}
+
+	return rl, nil
+}
+
+// formatResourceList renders a ResourceList in the same comma-separated
+// <resource>=<quantity> form that ParseResourceList accepts, with resources
+// sorted by name so the output is stable.
+func formatResourceList(rl corev1.ResourceList) string {
+	names := make([]string, 0, len(rl))
+	for name := range rl {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		quantity := rl[corev1.ResourceName(name)]
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// checkNamespaceNotTerminating detects a target namespace stuck in the
+// Terminating phase before any apply is attempted. Such a namespace rejects
+// all object creation, so instead of retrying blindly we surface the
+// finalizers blocking its deletion for the operator to unblock. A namespace
+// that doesn't exist yet is fine: installation creates it when configured to.
+func (i *Installer) checkNamespaceNotTerminating(client kubernetes.Interface) error {
+	ns, err := client.CoreV1().Namespaces().Get(i.targetNamespace(), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return shippererrors.NewKubeclientGetError("", i.targetNamespace(), err).
+			WithCoreV1Kind("Namespace")
+	}
+
+	if ns.Status.Phase != corev1.NamespaceTerminating {
+		return nil
+	}
+
+	finalizers := make([]string, 0, len(ns.Spec.Finalizers)+len(ns.Finalizers))
+	for _, f := range ns.Spec.Finalizers {
+		finalizers = append(finalizers, string(f))
+	}
+	finalizers = append(finalizers, ns.Finalizers...)
+
+	return shippererrors.NewNamespaceTerminatingError(i.targetNamespace(), finalizers)
+}
+
+// ensureNamespace makes sure the release's target namespace exists on the
+// application cluster and, if a quota or default limits are configured, stamps
+// a ResourceQuota and LimitRange on it so runaway charts can't exhaust the
+// cluster. The InstallationTarget can override the default quota with the
+// resourceQuota annotation. It is a no-op when neither a quota nor limits are
+// configured, preserving the behavior for setups that manage namespaces
+// themselves.
+func (i *Installer) ensureNamespace(cluster *shipper.Cluster, client kubernetes.Interface) error {
+	hard := i.defaultQuota
+	if override, ok := i.InstallationTarget.Annotations[shipper.InstallationResourceQuotaAnnotation]; ok {
+		parsed, err := ParseResourceList(override)
+		if err != nil {
+			return shippererrors.NewUnrecoverableError(fmt.Errorf(
+				"invalid %q annotation on InstallationTarget %q: %s",
+				shipper.InstallationResourceQuotaAnnotation, i.InstallationTarget.Name, err))
+		}
+		hard = parsed
+	}
+
+	i.appliedQuota = ""
+	// With no quota or limits to stamp there's usually nothing to do, but
+	// a namespace override — a strategy's canary namespace — is created
+	// here since nobody else provisions it.
+	if len(hard) == 0 && len(i.defaultLimits) == 0 && i.targetNamespace() == i.Release.Namespace {
+		return nil
+	}
+
+	labels := map[string]string{
+		shipper.AppLabel: i.Release.Labels[shipper.AppLabel],
+	}
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   i.targetNamespace(),
+			Labels: labels,
+		},
+	}
+
+	if _, err := client.CoreV1().Namespaces().Get(namespace.Name, metav1.GetOptions{}); err != nil && !errors.IsNotFound(err) {
+		return shippererrors.NewKubeclientGetError("", namespace.Name, err).
+			WithCoreV1Kind("Namespace")
+	} else if err != nil { // errors.IsNotFound(err) == true
+		glog.Infof("Creating namespace %q in cluster %q", namespace.Name, cluster.Name)
+		if _, err := client.CoreV1().Namespaces().Create(namespace); err != nil {
+			return shippererrors.NewKubeclientCreateError(namespace, err).
+				WithCoreV1Kind("Namespace")
+		}
+	}
+
+	if len(hard) > 0 {
+		quota := &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      namespaceQuotaName,
+				Namespace: i.targetNamespace(),
+				Labels:    labels,
+			},
+			Spec: corev1.ResourceQuotaSpec{Hard: hard},
+		}
+
+		existingQuota, err := client.CoreV1().ResourceQuotas(quota.Namespace).Get(quota.Name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return shippererrors.NewKubeclientGetError(quota.Namespace, quota.Name, err).
+				WithCoreV1Kind("ResourceQuota")
+		} else if err != nil { // errors.IsNotFound(err) == true
+			if _, err := client.CoreV1().ResourceQuotas(quota.Namespace).Create(quota); err != nil {
+				return shippererrors.NewKubeclientCreateError(quota, err).
+					WithCoreV1Kind("ResourceQuota")
+			}
+		} else if !reflect.DeepEqual(existingQuota.Spec.Hard, hard) {
+			existingQuota.Spec.Hard = hard
+			if _, err := client.CoreV1().ResourceQuotas(quota.Namespace).Update(existingQuota); err != nil {
+				return shippererrors.NewKubeclientUpdateError(quota, err).
+					WithCoreV1Kind("ResourceQuota")
+			}
+		}
+
+		i.appliedQuota = formatResourceList(hard)
+	}
+
+	if len(i.defaultLimits) > 0 {
+		limitRange := &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      namespaceLimitRangeName,
+				Namespace: i.targetNamespace(),
+				Labels:    labels,
+			},
+			Spec: corev1.LimitRangeSpec{
+				Limits: []corev1.LimitRangeItem{
+					{
+						Type:    corev1.LimitTypeContainer,
+						Default: i.defaultLimits,
+					},
+				},
+			},
+		}
+
+		existingLimitRange, err := client.CoreV1().LimitRanges(limitRange.Namespace).Get(limitRange.Name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return shippererrors.NewKubeclientGetError(limitRange.Namespace, limitRange.Name, err).
+				WithCoreV1Kind("LimitRange")
+		} else if err != nil { // errors.IsNotFound(err) == true
+			if _, err := client.CoreV1().LimitRanges(limitRange.Namespace).Create(limitRange); err != nil {
+				return shippererrors.NewKubeclientCreateError(limitRange, err).
+					WithCoreV1Kind("LimitRange")
+			}
+		} else if !reflect.DeepEqual(existingLimitRange.Spec, limitRange.Spec) {
+			existingLimitRange.Spec = limitRange.Spec
+			if _, err := client.CoreV1().LimitRanges(limitRange.Namespace).Update(existingLimitRange); err != nil {
+				return shippererrors.NewKubeclientUpdateError(limitRange, err).
+					WithCoreV1Kind("LimitRange")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package installation
+
+import (
+	"strconv"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// kindInstallWeights is the default apply ordering for chart objects when no
+// explicit weight annotation is present: cluster-level prerequisites first,
+// then configuration, then workloads. Kinds not listed here install together
+// with the workloads, at weight zero.
+var kindInstallWeights = map[string]int{
+	"Namespace":                -100,
+	"CustomResourceDefinition": -90,
+	"ServiceAccount":           -80,
+	"Secret":                   -70,
+	"ConfigMap":                -60,
+	"Service":                  -50,
+}
+
+// installWeight returns the apply-order weight for a rendered chart object of
+// the given kind. Lower weights apply first. An explicit weight annotation on
+// the object takes precedence over the kind-based default; objects with equal
+// weights keep the renderer's ordering.
+func installWeight(kind string, annotations map[string]string) int {
+	if v, ok := annotations[shipper.InstallOrderWeightAnnotation]; ok {
+		if weight, err := strconv.Atoi(v); err == nil {
+			return weight
+		}
+	}
+
+	return kindInstallWeights[kind]
+}
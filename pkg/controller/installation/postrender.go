@@ -0,0 +1,89 @@
+package installation
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// applyPostRenderPatches applies the release environment's post-render
+// patches to the rendered manifests. Each patch is a strategic merge patch,
+// in YAML or JSON form, targeting a single rendered object by kind and name.
+// A patch whose target object isn't rendered by the chart is an error: a
+// silently skipped patch would mean silently missing configuration in the
+// target clusters.
+func (i *Installer) applyPostRenderPatches(manifests []string) ([]string, error) {
+	patches := i.Release.Spec.Environment.PostRenderPatches
+	if len(patches) == 0 {
+		return manifests, nil
+	}
+
+	applied := make([]bool, len(patches))
+	patched := make([]string, len(manifests))
+
+	for mi, manifest := range manifests {
+		patched[mi] = manifest
+
+		decodedObj, gvk, err :=
+			kubescheme.Codecs.
+				UniversalDeserializer().
+				Decode([]byte(manifest), nil, nil)
+
+		if err != nil {
+			return nil, shippererrors.NewDecodeManifestError("error decoding manifest: %s", err)
+		}
+
+		accessor, err := meta.Accessor(decodedObj)
+		if err != nil {
+			return nil, shippererrors.NewDecodeManifestError("error accessing object metadata: %s", err)
+		}
+
+		for pi, patch := range patches {
+			if patch.Kind != gvk.Kind || patch.Name != accessor.GetName() {
+				continue
+			}
+
+			originalJSON, err := yaml.YAMLToJSON([]byte(patched[mi]))
+			if err != nil {
+				return nil, shippererrors.NewDecodeManifestError("error converting manifest to JSON: %s", err)
+			}
+
+			patchJSON, err := yaml.YAMLToJSON([]byte(patch.Patch))
+			if err != nil {
+				return nil, shippererrors.NewUnrecoverableError(fmt.Errorf(
+					"post-render patch for %s %q is not valid YAML or JSON: %s",
+					patch.Kind, patch.Name, err))
+			}
+
+			patchedJSON, err := strategicpatch.StrategicMergePatch(originalJSON, patchJSON, decodedObj)
+			if err != nil {
+				return nil, shippererrors.NewUnrecoverableError(fmt.Errorf(
+					"error applying post-render patch to %s %q: %s",
+					patch.Kind, patch.Name, err))
+			}
+
+			patchedYAML, err := yaml.JSONToYAML(patchedJSON)
+			if err != nil {
+				return nil, shippererrors.NewDecodeManifestError("error converting patched manifest back to YAML: %s", err)
+			}
+
+			patched[mi] = string(patchedYAML)
+			applied[pi] = true
+		}
+	}
+
+	for pi, patch := range patches {
+		if !applied[pi] {
+			return nil, shippererrors.NewUnrecoverableError(fmt.Errorf(
+				"post-render patch targets %s %q, but the chart does not render such an object",
+				patch.Kind, patch.Name))
+		}
+	}
+
+	return patched, nil
+}
@@ -205,7 +205,7 @@ func newController(
 ) *Controller {
 	c := NewController(
 		shipperclientset, shipperInformerFactory, fakeClientProvider, fakeDynamicClientBuilder, chartFetchFunc,
-		fakeRecorder,
+		fakeRecorder, nil, nil, nil, nil, nil, 1, 1, 0, false,
 	)
 
 	stopCh := make(chan struct{})
@@ -253,6 +253,20 @@ func buildRelease(name, namespace, generation, uid, appName string) *shipper.Rel
 	}
 }
 
+// withPinnedChartDigest returns a copy of the release with its chart's
+// content digest pinned, the way the controller does on first resolution.
+func withPinnedChartDigest(release *shipper.Release) *shipper.Release {
+	chrt, err := chartFetchFunc(release.Spec.Environment.Chart)
+	if err != nil {
+		panic(err)
+	}
+
+	rel := release.DeepCopy()
+	rel.Annotations[shipper.ReleaseChartDigestAnnotation] = chart.ContentDigest(chrt)
+
+	return rel
+}
+
 func buildInstallationTargetWithOwner(ownerName, ownerUID, namespace, appName string, clusters []string) *shipper.InstallationTarget {
 	return &shipper.InstallationTarget{
 		ObjectMeta: v1.ObjectMeta{
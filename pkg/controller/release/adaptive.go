@@ -0,0 +1,101 @@
+package release
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// adaptiveStep replaces the target step's fixed percentages with the share
+// the adaptive control loop has ramped up to so far. Every interval the
+// share grows by MaxIncrement while the SLI condition on the release reads
+// True and by MinIncrement otherwise; the step's own percentages cap the
+// ramp, so a step still bounds how far the rollout may go before the next
+// command.
+func (s *Executor) adaptiveStep(strategyStep shipper.RolloutStrategyStep) shipper.RolloutStrategyStep {
+	adaptive := s.strategy.Adaptive
+
+	var percent, lastIncrement int32
+	var lastIncrementTime *metav1.Time
+	if prior := s.contender.release.Status.Strategy; prior != nil && prior.Adaptive != nil {
+		percent = prior.Adaptive.Percent
+		lastIncrement = prior.Adaptive.LastIncrement
+		lastIncrementTime = prior.Adaptive.LastIncrementTime
+	}
+
+	// The ramp tops out at the larger of the step's two shares; the
+	// smaller one simply stops growing at its own cap.
+	s.adaptiveCeiling = strategyStep.Capacity.Contender
+	if strategyStep.Traffic.Contender > s.adaptiveCeiling {
+		s.adaptiveCeiling = strategyStep.Traffic.Contender
+	}
+
+	now := waveNow()
+	due := lastIncrementTime == nil || !now.Before(lastIncrementTime.Time.Add(adaptive.Interval.Duration))
+	if due && percent < s.adaptiveCeiling {
+		increment := adaptive.MinIncrement
+		if s.sliHealthy() {
+			increment = adaptive.MaxIncrement
+		}
+		percent += increment
+		if percent > s.adaptiveCeiling {
+			percent = s.adaptiveCeiling
+		}
+		lastIncrement = increment
+		t := metav1.NewTime(now)
+		lastIncrementTime = &t
+
+		s.info("adaptive ramp chose an increment of %d%%, moving to %d%% of the step's %d%%", increment, percent, s.adaptiveCeiling)
+	}
+
+	s.adaptiveStatus = &shipper.AdaptiveStatus{
+		Percent:           percent,
+		LastIncrement:     lastIncrement,
+		LastIncrementTime: lastIncrementTime,
+	}
+
+	capacity := percent
+	if capacity > strategyStep.Capacity.Contender {
+		capacity = strategyStep.Capacity.Contender
+	}
+	traffic := percent
+	if traffic > strategyStep.Traffic.Contender {
+		traffic = strategyStep.Traffic.Contender
+	}
+	strategyStep.Capacity = shipper.RolloutStrategyStepValue{Incumbent: 100 - capacity, Contender: capacity}
+	strategyStep.Traffic = shipper.RolloutStrategyStepValue{Incumbent: 100 - traffic, Contender: traffic}
+
+	return strategyStep
+}
+
+// sliHealthy reports whether the SLI condition external monitoring posts on
+// the release reads True. Anything else -- False, Unknown, or the condition
+// missing entirely -- counts as degraded, so the ramp falls back to its
+// cautious minimum increment.
+func (s *Executor) sliHealthy() bool {
+	for _, cond := range s.contender.release.Status.Conditions {
+		if string(cond.Type) == s.strategy.Adaptive.SLIConditionType {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// adaptiveRemaining returns how long until the adaptive control loop is due
+// to choose its next increment, or zero when the ramp has reached the step's
+// percentages and no further increment is coming.
+func (s *Executor) adaptiveRemaining() time.Duration {
+	if s.adaptiveStatus == nil || s.adaptiveStatus.LastIncrementTime == nil ||
+		s.adaptiveStatus.Percent >= s.adaptiveCeiling {
+		return 0
+	}
+
+	remaining := s.adaptiveStatus.LastIncrementTime.Time.Add(s.strategy.Adaptive.Interval.Duration).Sub(waveNow())
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
@@ -0,0 +1,180 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+const sliConditionType = "SLOsHealthy"
+
+// buildAdaptiveExecutor returns an executor for a contender targeting the
+// full-on step of a two-step adaptive strategy, its single cluster still
+// sitting at the staging step's capacity and traffic.
+func buildAdaptiveExecutor() *Executor {
+	strategy := shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "staging",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+			},
+			{
+				Name:     "full on",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+			},
+		},
+		Adaptive: &shipper.AdaptiveStrategy{
+			MinIncrement:     5,
+			MaxIncrement:     25,
+			Interval:         metav1.Duration{Duration: time.Minute},
+			SLIConditionType: sliConditionType,
+		},
+	}
+
+	release := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: 1,
+			Environment: shipper.ReleaseEnvironment{
+				Strategy: &strategy,
+			},
+		},
+	}
+
+	installationTarget := &shipper.InstallationTarget{
+		Spec: shipper.InstallationTargetSpec{
+			Clusters: []string{"minikube"},
+		},
+		Status: shipper.InstallationTargetStatus{
+			Clusters: []*shipper.ClusterInstallationStatus{
+				{Name: "minikube", Status: shipper.ReleasePhaseInstalled},
+			},
+		},
+	}
+
+	capacityTarget := &shipper.CapacityTarget{
+		Spec: shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "minikube", Percent: 1, TotalReplicaCount: 10},
+			},
+		},
+		Status: shipper.CapacityTargetStatus{
+			Clusters: []shipper.ClusterCapacityStatus{
+				{Name: "minikube", AchievedPercent: 1, AvailableReplicas: 1},
+			},
+		},
+	}
+
+	trafficTarget := &shipper.TrafficTarget{
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "minikube", Weight: 0},
+			},
+		},
+		Status: shipper.TrafficTargetStatus{
+			Clusters: []*shipper.ClusterTrafficStatus{
+				{Name: "minikube", AchievedTraffic: 0},
+			},
+		},
+	}
+
+	return &Executor{
+		contender: &releaseInfo{
+			release:            release,
+			installationTarget: installationTarget,
+			capacityTarget:     capacityTarget,
+			trafficTarget:      trafficTarget,
+		},
+		recorder: record.NewFakeRecorder(42),
+		strategy: strategy,
+	}
+}
+
+// adaptiveStatusPatch digs the adaptive ramp state out of an executor's
+// release status patch.
+func adaptiveStatusPatch(t *testing.T, patches []ExecutorResult) *shipper.AdaptiveStatus {
+	t.Helper()
+
+	statusPatch := releaseStatusPatch(t, patches)
+	if statusPatch.NewStatus.Strategy == nil || statusPatch.NewStatus.Strategy.Adaptive == nil {
+		t.Fatal("expected the status patch to report the adaptive ramp's state")
+	}
+	return statusPatch.NewStatus.Strategy.Adaptive
+}
+
+// TestAdaptiveIncrementsTrackSLI drives an adaptive rollout through three
+// syncs: a healthy SLI ramps by the maximum increment, a degraded SLI falls
+// back to the minimum, and a sync before the interval elapses holds the ramp
+// where it stands. Each chosen increment is reported in the release status.
+func TestAdaptiveIncrementsTrackSLI(t *testing.T) {
+	// The SLI condition reads True: the first increment is the maximum.
+	executor := buildAdaptiveExecutor()
+	executor.contender.release.Status.Conditions = []shipper.ReleaseCondition{
+		{Type: sliConditionType, Status: corev1.ConditionTrue},
+	}
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adaptive := adaptiveStatusPatch(t, patches)
+	if adaptive.Percent != 25 || adaptive.LastIncrement != 25 {
+		t.Errorf("expected a healthy SLI to ramp to 25%% with an increment of 25, got %d%% with an increment of %d", adaptive.Percent, adaptive.LastIncrement)
+	}
+
+	// The SLI condition flips to False with the next increment due: the
+	// ramp continues from where it stood, by the minimum instead.
+	executor = buildAdaptiveExecutor()
+	past := metav1.NewTime(waveNow().Add(-2 * time.Minute))
+	executor.contender.release.Status.Strategy = &shipper.ReleaseStrategyStatus{
+		Adaptive: &shipper.AdaptiveStatus{Percent: 25, LastIncrement: 25, LastIncrementTime: &past},
+	}
+	executor.contender.release.Status.Conditions = []shipper.ReleaseCondition{
+		{Type: sliConditionType, Status: corev1.ConditionFalse},
+	}
+
+	patches, _, err = executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adaptive = adaptiveStatusPatch(t, patches)
+	if adaptive.Percent != 30 || adaptive.LastIncrement != 5 {
+		t.Errorf("expected a degraded SLI to ramp to 30%% with an increment of 5, got %d%% with an increment of %d", adaptive.Percent, adaptive.LastIncrement)
+	}
+
+	// A sync before the interval elapses chooses nothing new.
+	executor = buildAdaptiveExecutor()
+	recent := metav1.NewTime(waveNow())
+	executor.contender.release.Status.Strategy = &shipper.ReleaseStrategyStatus{
+		Adaptive: &shipper.AdaptiveStatus{Percent: 25, LastIncrement: 25, LastIncrementTime: &recent},
+	}
+	executor.contender.release.Status.Conditions = []shipper.ReleaseCondition{
+		{Type: sliConditionType, Status: corev1.ConditionTrue},
+	}
+
+	patches, _, err = executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adaptive = adaptiveStatusPatch(t, patches)
+	if adaptive.Percent != 25 || adaptive.LastIncrement != 25 {
+		t.Errorf("expected a mid-interval sync to hold the ramp at 25%%, got %d%% with an increment of %d", adaptive.Percent, adaptive.LastIncrement)
+	}
+
+	if remaining := executor.adaptiveRemaining(); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("expected the next increment to be due within the interval, got %s", remaining)
+	}
+}
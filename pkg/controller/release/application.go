@@ -15,6 +15,7 @@ import (
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	apputil "github.com/bookingcom/shipper/pkg/util/application"
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
 )
 
@@ -87,14 +88,52 @@ func (c *Controller) syncOneApplicationHandler(key string) error {
 			WithShipperKind("Application")
 	}
 
+	// A quiesced application is scaled down to zero wholesale, and the
+	// strategy machinery stays out of the way until it is restored;
+	// un-quiescing puts back exactly the specs the targets had when they
+	// were quiesced.
+	appReleases, err := c.sortedReleasesForApp(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if appQuiesced(app) {
+		return c.ensureQuiesced(app, appReleases)
+	}
+
+	if restored, err := c.ensureUnquiesced(app, appReleases); err != nil {
+		return err
+	} else if restored {
+		// Let the restored specs land in the listers before the strategy
+		// looks at them again.
+		return nil
+	}
+
 	glog.V(4).Infof("Fetching release pair for Application %q", key)
-	incumbent, contender, err := c.getWorkingReleasePair(app)
+	incumbent, contender, superseded, err := c.getWorkingReleasePair(app)
 	if err != nil {
 		return err
 	}
 
+	// Completed releases are re-enqueued by every informer re-sync; when
+	// the contender is terminal and nothing underneath it has moved, skip
+	// the strategy machinery (and its status patch) entirely.
+	if c.releaseSettled(contender) {
+		glog.V(4).Infof("Release %q is complete and settled, nothing to do for Application %q", contender.Name, key)
+		return nil
+	}
+
+	// An external change-management system may have to approve the pending
+	// step advance for gated tiers before the strategy acts on it.
+	if held, err := c.ensureChangeApproved(app, contender); err != nil {
+		return err
+	} else if held {
+		c.applicationWorkqueue.AddAfter(key, changeApprovalRecheckPeriod)
+		return nil
+	}
+
 	glog.V(4).Infof("Building a strategy excecutor for Application %q", key)
-	strategyExecutor, err := c.buildExecutor(incumbent, contender)
+	strategyExecutor, err := c.buildExecutor(incumbent, contender, superseded)
 	if err != nil {
 		return err
 	}
@@ -105,6 +144,24 @@ func (c *Controller) syncOneApplicationHandler(key string) error {
 		return err
 	}
 
+	// Nothing else will touch the release while a wave bakes, so come back
+	// when the bake period ends.
+	if remaining := strategyExecutor.bakeRemaining(); remaining > 0 {
+		c.applicationWorkqueue.AddAfter(key, remaining)
+	}
+
+	// The same goes for a final-step soak: its end changes no object, so
+	// schedule a re-sync for when it passes.
+	if remaining := strategyExecutor.soakRemaining(); remaining > 0 {
+		c.applicationWorkqueue.AddAfter(key, remaining)
+	}
+
+	// Likewise for an adaptive ramp: the next increment only becomes due
+	// with time, so schedule a re-sync for when the interval passes.
+	if remaining := strategyExecutor.adaptiveRemaining(); remaining > 0 {
+		c.applicationWorkqueue.AddAfter(key, remaining)
+	}
+
 	for _, t := range transitions {
 		c.recorder.Eventf(
 			strategyExecutor.contender.release,
@@ -147,7 +204,62 @@ func (c *Controller) syncOneApplicationHandler(key string) error {
 	return nil
 }
 
-func (c *Controller) buildExecutor(incumbentRelease, contenderRelease *shipper.Release) (*Executor, error) {
+// releaseSettled reports whether a release is terminal with nothing left to
+// reconcile: it is complete, its spec hasn't changed since the controller
+// last achieved its target step, and all of its target objects still report
+// that step's goal state. Rollbacks re-engage the full path because moving
+// the target step backward fails the rolling-back check, and deleted
+// releases never reach this point.
+func (c *Controller) releaseSettled(rel *shipper.Release) bool {
+	if !releaseutil.ReleaseComplete(rel) || releaseutil.ReleaseRollingBack(rel) {
+		return false
+	}
+
+	if rel.Generation != rel.Status.ObservedGeneration {
+		return false
+	}
+
+	strategy := rel.Spec.Environment.Strategy
+	if strategy == nil {
+		return false
+	}
+
+	// Install-only releases have no capacity or traffic to keep in their
+	// goal state: holding on to installation is all "settled" means.
+	if strategy.InstallOnly {
+		info, err := c.buildReleaseInfo(rel)
+		if err != nil {
+			return false
+		}
+		ready, _ := checkInstallation(info)
+		return ready
+	}
+
+	targetStep := rel.Spec.TargetStep
+	if targetStep >= int32(len(strategy.Steps)) {
+		return false
+	}
+	step := strategy.Steps[targetStep]
+
+	info, err := c.buildReleaseInfo(rel)
+	if err != nil {
+		return false
+	}
+
+	if ready, _ := checkInstallation(info); !ready {
+		return false
+	}
+	if achieved, _, _ := checkCapacity(info.capacityTarget, uint(step.Capacity.Contender)); !achieved {
+		return false
+	}
+	if achieved, _, _ := checkTraffic(info.trafficTarget, uint32(step.Traffic.Contender), trafficTierWeights(step.TrafficTiers, true), contenderTrafficComparison); !achieved {
+		return false
+	}
+
+	return true
+}
+
+func (c *Controller) buildExecutor(incumbentRelease, contenderRelease *shipper.Release, supersededReleases []*shipper.Release) (*Executor, error) {
 	if !releaseutil.ReleaseScheduled(contenderRelease) {
 		return nil, shippererrors.NewNotWorkingOnStrategyError(shippercontroller.MetaKey(contenderRelease))
 	}
@@ -159,12 +271,23 @@ func (c *Controller) buildExecutor(incumbentRelease, contenderRelease *shipper.R
 
 	strategy := *contenderReleaseInfo.release.Spec.Environment.Strategy
 
+	var supersededReleaseInfos []*releaseInfo
+	for _, rel := range supersededReleases {
+		info, err := c.buildReleaseInfo(rel)
+		if err != nil {
+			return nil, err
+		}
+		supersededReleaseInfos = append(supersededReleaseInfos, info)
+	}
+
 	// No incumbent, only this contender: a new application.
 	if incumbentRelease == nil {
 		return &Executor{
-			contender: contenderReleaseInfo,
-			recorder:  c.recorder,
-			strategy:  strategy,
+			contender:    contenderReleaseInfo,
+			superseded:   supersededReleaseInfos,
+			recorder:     c.recorder,
+			strategy:     strategy,
+			flagProvider: c.flagProvider,
 		}, nil
 	}
 
@@ -174,10 +297,12 @@ func (c *Controller) buildExecutor(incumbentRelease, contenderRelease *shipper.R
 	}
 
 	return &Executor{
-		contender: contenderReleaseInfo,
-		incumbent: incumbentReleaseInfo,
-		recorder:  c.recorder,
-		strategy:  strategy,
+		contender:    contenderReleaseInfo,
+		incumbent:    incumbentReleaseInfo,
+		superseded:   supersededReleaseInfos,
+		recorder:     c.recorder,
+		strategy:     strategy,
+		flagProvider: c.flagProvider,
 	}, nil
 }
 
@@ -201,17 +326,17 @@ func (c *Controller) sortedReleasesForApp(namespace, name string) ([]*shipper.Re
 	return sorted, nil
 }
 
-func (c *Controller) getWorkingReleasePair(app *shipper.Application) (*shipper.Release, *shipper.Release, error) {
+func (c *Controller) getWorkingReleasePair(app *shipper.Application) (*shipper.Release, *shipper.Release, []*shipper.Release, error) {
 	appReleases, err := c.sortedReleasesForApp(app.GetNamespace(), app.GetName())
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	if len(appReleases) == 0 {
 		err := fmt.Errorf(
 			"zero release records in app %q: will not execute strategy",
 			shippercontroller.MetaKey(app))
-		return nil, nil, shippererrors.NewRecoverableError(err)
+		return nil, nil, nil, shippererrors.NewRecoverableError(err)
 	}
 
 	// Walk backwards until we find a scheduled release. There may be pending
@@ -227,20 +352,29 @@ func (c *Controller) getWorkingReleasePair(app *shipper.Application) (*shipper.R
 	if contender == nil {
 		err := fmt.Errorf("couldn't find a contender for Application %q",
 			shippercontroller.MetaKey(app))
-		return nil, nil, shippererrors.NewRecoverableError(err)
+		return nil, nil, nil, shippererrors.NewRecoverableError(err)
 	}
 
-	var incumbent *shipper.Release
-	// Walk backwards until we find an installed release that isn't the head of
-	// history. For releases A -> B -> C, if B was never finished this allows C to
-	// ignore it and let it get deleted so the transition is A->C.
-	for i := len(appReleases) - 1; i >= 0; i-- {
-		if releaseutil.ReleaseComplete(appReleases[i]) && contender != appReleases[i] {
-			incumbent = appReleases[i]
-			break
+	// The incumbent comes out of the same deterministic selection the
+	// application controller records on the application's status: the most
+	// recent completed release that isn't the contender. For releases
+	// A -> B -> C, if B never finished this allows C to ignore it and let
+	// it get deleted so the transition is A->C.
+	incumbent := apputil.SelectIncumbent(contender, releaseutil.SortByGenerationDescending(appReleases))
+
+	// Anything else that is scheduled but never completed is a superseded
+	// release: an abandoned rollout that has to drain out of the contender's
+	// way before the strategy can proceed.
+	var superseded []*shipper.Release
+	for _, rel := range appReleases {
+		if rel == contender || rel == incumbent {
+			continue
+		}
+		if releaseutil.ReleaseScheduled(rel) && !releaseutil.ReleaseComplete(rel) {
+			superseded = append(superseded, rel)
 		}
 	}
 
 	// It is OK if incumbent is nil. It just means this is our first rollout.
-	return incumbent, contender, nil
+	return incumbent, contender, superseded, nil
 }
@@ -0,0 +1,45 @@
+package release
+
+import (
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// maxTotalWeight is the whole of an application's capacity or traffic budget
+// at any strategy step; the apportioned weights of all concurrent releases
+// never sum above it.
+const maxTotalWeight int32 = 100
+
+// supersededWeight is the share apportioned to a superseded release: an
+// abandoned rollout sitting between the incumbent and the contender. It is
+// always zero, because the only move left for a superseded release is
+// draining out of its successor's way.
+const supersededWeight int32 = 0
+
+// apportionStepValue generalizes a two-way incumbent/contender step value to
+// the N-way case where an application briefly has more than two releases.
+// The apportionment rules are:
+//
+//  1. The contender is granted its declared weight first, capped at
+//     maxTotalWeight.
+//  2. Every superseded release is granted supersededWeight, i.e. zero.
+//  3. The incumbent is granted its declared weight, reduced if granting it
+//     in full would push the combined total over maxTotalWeight.
+//
+// The result depends only on the declared step value, so repeated syncs over
+// any number of concurrent releases converge on the same split.
+func apportionStepValue(value shipper.RolloutStrategyStepValue) shipper.RolloutStrategyStepValue {
+	contender := value.Contender
+	if contender > maxTotalWeight {
+		contender = maxTotalWeight
+	}
+
+	incumbent := value.Incumbent
+	if remaining := maxTotalWeight - contender; incumbent > remaining {
+		incumbent = remaining
+	}
+
+	return shipper.RolloutStrategyStepValue{
+		Incumbent: incumbent,
+		Contender: contender,
+	}
+}
@@ -0,0 +1,119 @@
+package release
+
+import (
+	"testing"
+
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+const supersededName = "0.0.1-superseded"
+
+// TestApportionStepValueKeepsTotalWithinBudget checks the N-way apportionment
+// rules: the contender's declared weight is granted first, superseded
+// releases get zero, and the incumbent is squeezed into whatever budget
+// remains.
+func TestApportionStepValueKeepsTotalWithinBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    shipper.RolloutStrategyStepValue
+		expected shipper.RolloutStrategyStepValue
+	}{
+		{
+			name:     "declared split already within budget",
+			value:    shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+			expected: shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+		},
+		{
+			name:     "incumbent squeezed by the contender's share",
+			value:    shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+			expected: shipper.RolloutStrategyStepValue{Incumbent: 99, Contender: 1},
+		},
+		{
+			name:     "contender capped at the whole budget",
+			value:    shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 120},
+			expected: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+		},
+	}
+
+	for _, test := range tests {
+		got := apportionStepValue(test.value)
+		if got != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, got)
+		}
+
+		if total := got.Incumbent + supersededWeight + got.Contender; total > maxTotalWeight {
+			t.Errorf("%s: apportioned weights sum to %d, over the %d budget", test.name, total, maxTotalWeight)
+		}
+	}
+}
+
+// TestSupersededReleaseDrainsBeforeContender runs the executor over three
+// concurrent releases -- a complete incumbent, a superseded contender
+// abandoned mid-rollout, and a new contender -- and checks that the
+// superseded release is drained to zero weight before the new contender is
+// granted any share, so the combined weight stays within budget throughout.
+func TestSupersededReleaseDrainsBeforeContender(t *testing.T) {
+	totalReplicaCount := uint(10)
+
+	superseded := buildContender(totalReplicaCount)
+	superseded.release.Name = supersededName
+	superseded.capacityTarget.Name = supersededName
+	superseded.capacityTarget.Spec.Clusters[0].Percent = 50
+	superseded.capacityTarget.Status.Clusters[0].AchievedPercent = 50
+	superseded.trafficTarget.Name = supersededName
+	superseded.trafficTarget.Spec.Clusters[0].Weight = 50
+	superseded.trafficTarget.Status.Clusters[0].AchievedTraffic = 50
+
+	executor := &Executor{
+		contender:  buildContender(totalReplicaCount),
+		incumbent:  buildIncumbent(totalReplicaCount),
+		superseded: []*releaseInfo{superseded},
+		recorder:   record.NewFakeRecorder(42),
+		strategy:   vanguard,
+	}
+
+	executor.contender.release.Spec.TargetStep = 1
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One traffic patch and one capacity patch for the superseded release,
+	// plus the strategy conditions patch; nothing for the contender yet.
+	if len(patches) != 3 {
+		t.Fatalf("expected three patches, got %d", len(patches))
+	}
+
+	trafficPatch, ok := patches[0].(*TrafficTargetOutdatedResult)
+	if !ok || trafficPatch.Name != supersededName {
+		t.Fatalf("expected a traffic patch for %q, got %+v", supersededName, patches[0])
+	}
+	if weight := trafficPatch.NewSpec.Clusters[0].Weight; weight != uint32(supersededWeight) {
+		t.Errorf("expected the superseded release's traffic weight to drop to %d, got %d", supersededWeight, weight)
+	}
+
+	capacityPatch, ok := patches[1].(*CapacityTargetOutdatedResult)
+	if !ok || capacityPatch.Name != supersededName {
+		t.Fatalf("expected a capacity patch for %q, got %+v", supersededName, patches[1])
+	}
+	if percent := capacityPatch.NewSpec.Clusters[0].Percent; percent != supersededWeight {
+		t.Errorf("expected the superseded release's capacity to drop to %d, got %d", supersededWeight, percent)
+	}
+
+	// Mimic the traffic and capacity controllers draining the superseded
+	// release; with it out of the way the contender proceeds as usual.
+	superseded.trafficTarget.Spec = *trafficPatch.NewSpec
+	superseded.trafficTarget.Status.Clusters[0].AchievedTraffic = 0
+	superseded.capacityTarget.Spec = *capacityPatch.NewSpec
+	superseded.capacityTarget.Status.Clusters[0].AchievedPercent = 0
+	superseded.capacityTarget.Status.Clusters[0].AvailableReplicas = 0
+
+	if newSpec, err := ensureCapacityPatch(executor, contenderName, Contender); err != nil {
+		t.Fatal(err)
+	} else if newSpec.Clusters[0].Percent != 50 {
+		t.Errorf("expected the contender's capacity to move to 50, got %d", newSpec.Clusters[0].Percent)
+	}
+}
@@ -0,0 +1,199 @@
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// changeApprovalRecheckPeriod is how long the controller waits before asking
+// the approver about a held step again. Approval state lives in an external
+// system, so nothing in the cluster re-enqueues the application when the
+// decision flips from deny to approve.
+const changeApprovalRecheckPeriod = 30 * time.Second
+
+// ChangeApprovalRequest describes a pending target step advance to an
+// external change-management system.
+type ChangeApprovalRequest struct {
+	Application string `json:"application"`
+	Namespace   string `json:"namespace"`
+	Release     string `json:"release"`
+	Tier        string `json:"tier"`
+	TargetStep  int32  `json:"targetStep"`
+	Step        string `json:"step"`
+}
+
+// ChangeApprovalResponse is the change-management system's verdict on a
+// single step advance.
+type ChangeApprovalResponse struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+}
+
+// ChangeApprover decides synchronously whether a release may advance to its
+// target step. Denials are not errors: the strategy holds the step and asks
+// again later.
+type ChangeApprover interface {
+	ApproveStep(req ChangeApprovalRequest) (ChangeApprovalResponse, error)
+}
+
+// webhookChangeApprover POSTs each request as JSON to a configured endpoint
+// and expects a ChangeApprovalResponse back.
+type webhookChangeApprover struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewWebhookChangeApprover(endpoint string) ChangeApprover {
+	return &webhookChangeApprover{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (a *webhookChangeApprover) ApproveStep(req ChangeApprovalRequest) (ChangeApprovalResponse, error) {
+	var resp ChangeApprovalResponse
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+
+	httpResp, err := a.client.Post(a.endpoint, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return resp, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return resp, fmt.Errorf("approval webhook returned status %d", httpResp.StatusCode)
+	}
+
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// ensureChangeApproved asks the configured approver whether the contender may
+// advance to its target step, and reports whether the advance has to be held.
+// Releases outside the gated tiers, already-achieved steps and rollbacks pass
+// without a webhook call. A denial records the ChangeApprovalDenied condition
+// on the release; an approval clears it.
+func (c *Controller) ensureChangeApproved(app *shipper.Application, rel *shipper.Release) (bool, error) {
+	if c.changeApprover == nil {
+		return false, nil
+	}
+
+	tier := rel.Labels[shipper.TierLabel]
+	if _, ok := c.changeApprovalTiers[tier]; !ok {
+		return false, nil
+	}
+
+	targetStep := rel.Spec.TargetStep
+	achievedStep := rel.Status.AchievedStep
+	if achievedStep != nil && achievedStep.Step >= targetStep {
+		// The step was already achieved, or this is a rollback; change
+		// management only gates forward moves.
+		return false, nil
+	}
+
+	var stepName string
+	strategy := rel.Spec.Environment.Strategy
+	if strategy != nil && targetStep < int32(len(strategy.Steps)) {
+		stepName = strategy.Steps[targetStep].Name
+	}
+
+	resp, err := c.changeApprover.ApproveStep(ChangeApprovalRequest{
+		Application: app.Name,
+		Namespace:   rel.Namespace,
+		Release:     rel.Name,
+		Tier:        tier,
+		TargetStep:  targetStep,
+		Step:        stepName,
+	})
+	if err != nil {
+		err := fmt.Errorf("change approval webhook for Release \"%s/%s\": %s",
+			rel.Namespace, rel.Name, err)
+		return false, shippererrors.NewRecoverableError(err)
+	}
+
+	existing := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeChangeApprovalDenied)
+
+	if resp.Approved {
+		if existing == nil {
+			return false, nil
+		}
+
+		relCopy := rel.DeepCopy()
+		relCopy.Status.Conditions = withoutReleaseCondition(
+			relCopy.Status.Conditions, shipper.ReleaseConditionTypeChangeApprovalDenied)
+
+		if _, err := c.clientset.ShipperV1alpha1().Releases(rel.Namespace).Update(relCopy); err != nil {
+			return false, shippererrors.NewKubeclientUpdateError(relCopy, err)
+		}
+
+		c.recorder.Eventf(
+			rel,
+			corev1.EventTypeNormal,
+			"ChangeApproved",
+			"step %d advance approved by the change approval webhook",
+			targetStep,
+		)
+
+		return false, nil
+	}
+
+	reason := resp.Reason
+	if reason == "" {
+		reason = "denied by the change approval webhook"
+	}
+
+	// Only write (and broadcast) when the verdict actually changed, so a
+	// held step doesn't produce an update per re-check.
+	if existing == nil || existing.Message != reason {
+		relCopy := rel.DeepCopy()
+		condition := releaseutil.NewReleaseCondition(
+			shipper.ReleaseConditionTypeChangeApprovalDenied,
+			corev1.ConditionTrue,
+			"WebhookDenied",
+			reason,
+		)
+		releaseutil.SetReleaseCondition(&relCopy.Status, *condition)
+
+		if _, err := c.clientset.ShipperV1alpha1().Releases(rel.Namespace).Update(relCopy); err != nil {
+			return true, shippererrors.NewKubeclientUpdateError(relCopy, err)
+		}
+
+		c.recorder.Eventf(
+			rel,
+			corev1.EventTypeWarning,
+			"ChangeApprovalDenied",
+			"holding step %d: %s",
+			targetStep,
+			reason,
+		)
+	}
+
+	return true, nil
+}
+
+func withoutReleaseCondition(conditions []shipper.ReleaseCondition, condType shipper.ReleaseConditionType) []shipper.ReleaseCondition {
+	var filtered []shipper.ReleaseCondition
+	for _, c := range conditions {
+		if c.Type == condType {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
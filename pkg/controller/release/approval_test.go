@@ -0,0 +1,210 @@
+package release
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// approvalWebhookServer serves canned verdicts and records every request the
+// controller sends.
+func approvalWebhookServer(t *testing.T, resp ChangeApprovalResponse, received *[]ChangeApprovalRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChangeApprovalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode approval request: %s", err)
+		}
+		*received = append(*received, req)
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Errorf("failed to encode approval response: %s", err)
+		}
+	}))
+}
+
+func buildApprovalRelease(tier string, targetStep int32, achievedStep *int32) *shipper.Release {
+	rel := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-app-1234",
+			Namespace: "test-namespace",
+			Labels: map[string]string{
+				shipper.AppLabel:  "test-app",
+				shipper.TierLabel: tier,
+			},
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: targetStep,
+			Environment: shipper.ReleaseEnvironment{
+				Strategy: &shipper.RolloutStrategy{
+					Steps: []shipper.RolloutStrategyStep{
+						{
+							Name:     "staging",
+							Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+							Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+						},
+						{
+							Name:     "full on",
+							Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+							Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if achievedStep != nil {
+		rel.Status.AchievedStep = &shipper.AchievedStep{
+			Step: *achievedStep,
+			Name: rel.Spec.Environment.Strategy.Steps[*achievedStep].Name,
+		}
+	}
+
+	return rel
+}
+
+func buildApprovalController(rel *shipper.Release, approver ChangeApprover) (*Controller, *shipperfake.Clientset) {
+	clientset := shipperfake.NewSimpleClientset(rel)
+	c := &Controller{
+		clientset:           clientset,
+		recorder:            record.NewFakeRecorder(42),
+		changeApprover:      approver,
+		changeApprovalTiers: map[string]struct{}{"production": {}},
+	}
+	return c, clientset
+}
+
+// TestChangeApprovalDeniedHoldsAdvance checks that a denying webhook holds a
+// gated release's step advance and records the ChangeApprovalDenied
+// condition with the webhook's reason.
+func TestChangeApprovalDeniedHoldsAdvance(t *testing.T) {
+	var received []ChangeApprovalRequest
+	server := approvalWebhookServer(t,
+		ChangeApprovalResponse{Approved: false, Reason: "change window closed"},
+		&received)
+	defer server.Close()
+
+	achieved := int32(0)
+	rel := buildApprovalRelease("production", 1, &achieved)
+	app := &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-app", Namespace: rel.Namespace},
+	}
+
+	c, clientset := buildApprovalController(rel, NewWebhookChangeApprover(server.URL))
+
+	held, err := c.ensureChangeApproved(app, rel)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !held {
+		t.Fatal("expected a denied advance to be held")
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 webhook call, got %d", len(received))
+	}
+	req := received[0]
+	if req.Application != "test-app" || req.Tier != "production" || req.TargetStep != 1 || req.Step != "full on" {
+		t.Errorf("unexpected approval request: %+v", req)
+	}
+
+	updated, err := clientset.ShipperV1alpha1().Releases(rel.Namespace).Get(rel.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cond := releaseutil.GetReleaseCondition(updated.Status, shipper.ReleaseConditionTypeChangeApprovalDenied)
+	if cond == nil {
+		t.Fatal("expected the ChangeApprovalDenied condition to be set")
+	}
+	if cond.Status != corev1.ConditionTrue || cond.Message != "change window closed" {
+		t.Errorf("unexpected condition: %+v", cond)
+	}
+}
+
+// TestChangeApprovalApprovedProceeds checks that an approving webhook lets
+// the advance through and clears a ChangeApprovalDenied condition left over
+// from an earlier denial.
+func TestChangeApprovalApprovedProceeds(t *testing.T) {
+	var received []ChangeApprovalRequest
+	server := approvalWebhookServer(t,
+		ChangeApprovalResponse{Approved: true},
+		&received)
+	defer server.Close()
+
+	achieved := int32(0)
+	rel := buildApprovalRelease("production", 1, &achieved)
+	denied := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeChangeApprovalDenied,
+		corev1.ConditionTrue,
+		"WebhookDenied",
+		"change window closed",
+	)
+	releaseutil.SetReleaseCondition(&rel.Status, *denied)
+
+	app := &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-app", Namespace: rel.Namespace},
+	}
+
+	c, clientset := buildApprovalController(rel, NewWebhookChangeApprover(server.URL))
+
+	held, err := c.ensureChangeApproved(app, rel)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if held {
+		t.Fatal("expected an approved advance to proceed")
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("expected 1 webhook call, got %d", len(received))
+	}
+
+	updated, err := clientset.ShipperV1alpha1().Releases(rel.Namespace).Get(rel.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if cond := releaseutil.GetReleaseCondition(updated.Status, shipper.ReleaseConditionTypeChangeApprovalDenied); cond != nil {
+		t.Errorf("expected the ChangeApprovalDenied condition to be cleared, got %+v", cond)
+	}
+}
+
+// TestChangeApprovalSkipsUngatedTiers checks that releases outside the gated
+// tiers never reach the webhook.
+func TestChangeApprovalSkipsUngatedTiers(t *testing.T) {
+	var received []ChangeApprovalRequest
+	server := approvalWebhookServer(t,
+		ChangeApprovalResponse{Approved: false, Reason: "should not be asked"},
+		&received)
+	defer server.Close()
+
+	achieved := int32(0)
+	rel := buildApprovalRelease("staging", 1, &achieved)
+	app := &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-app", Namespace: rel.Namespace},
+	}
+
+	c, _ := buildApprovalController(rel, NewWebhookChangeApprover(server.URL))
+
+	held, err := c.ensureChangeApproved(app, rel)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if held {
+		t.Fatal("expected an ungated tier to pass without approval")
+	}
+
+	if len(received) != 0 {
+		t.Errorf("expected no webhook calls, got %d", len(received))
+	}
+}
@@ -1,6 +1,8 @@
 package release
 
 import (
+	"sort"
+
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	replicasutil "github.com/bookingcom/shipper/pkg/util/replicas"
 )
@@ -81,7 +83,9 @@ func checkCapacity(
 	clusterCapacityData := make(map[string]capacityState)
 
 	specs := capacityTarget.Spec.Clusters
+	suspended := make(map[string]bool, len(specs))
 	for _, spec := range specs {
+		suspended[spec.Name] = spec.Suspended
 		clusterCapacityData[spec.Name] = capacityState{
 			stepCapacity:      stepCapacity,
 			desiredCapacity:   uint(spec.Percent),
@@ -109,16 +113,20 @@ func checkCapacity(
 
 	clustersNotReady := make([]string, 0)
 	canProceed := true
+	anyOutdated := false
+	// As with traffic, the spec is replaced wholesale when patched, so it
+	// has to carry every cluster, not just the outdated ones.
 	newSpec := &shipper.CapacityTargetSpec{}
 
 	for clusterName, v := range clusterCapacityData {
+		r := shipper.ClusterCapacityTarget{Name: clusterName, Percent: int32(v.stepCapacity), TotalReplicaCount: v.totalReplicaCount, Suspended: suspended[clusterName]}
+		newSpec.Clusters = append(newSpec.Clusters, r)
+
 		// Now we can check whether or not the desired target step replicas have
 		// been achieved. If this isn't the case, it means that we need to update
 		// this cluster's desired capacity.
 		if v.desiredCapacity != v.stepCapacity {
-			// Patch capacityTarget .spec to attempt to achieve the desired state.
-			r := shipper.ClusterCapacityTarget{Name: clusterName, Percent: int32(v.stepCapacity), TotalReplicaCount: v.totalReplicaCount}
-			newSpec.Clusters = append(newSpec.Clusters, r)
+			anyOutdated = true
 			canProceed = false
 			clustersNotReady = append(clustersNotReady, clusterName)
 		} else if !replicasutil.AchievedDesiredReplicaPercentage(uint(v.totalReplicaCount), uint(v.currentReplicaCount), float64(v.desiredCapacity)) {
@@ -127,7 +135,9 @@ func checkCapacity(
 		}
 	}
 
-	if len(newSpec.Clusters) > 0 {
+	sortCapacityClusters(newSpec)
+
+	if anyOutdated {
 		return canProceed, newSpec, clustersNotReady
 	} else {
 		return canProceed, nil, clustersNotReady
@@ -137,12 +147,46 @@ func checkCapacity(
 type trafficState struct {
 	achievedTrafficWeight uint32
 	desiredTrafficWeight  uint32
+	desiredServiceWeights map[string]uint32
 	stepTrafficWeight     uint32
 }
 
+// trafficTierWeights flattens a step's per-service tiers into the weight map
+// for one side of the rollout; nil when the step has no tiers.
+func trafficTierWeights(tiers []shipper.RolloutStrategyServiceTier, contender bool) map[string]uint32 {
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	weights := make(map[string]uint32, len(tiers))
+	for _, tier := range tiers {
+		if contender {
+			weights[tier.Name] = uint32(tier.Contender)
+		} else {
+			weights[tier.Name] = uint32(tier.Incumbent)
+		}
+	}
+	return weights
+}
+
+// tierWeightsEqual compares two per-service-tier weight maps; nil and empty
+// are the same thing (no tiers).
+func tierWeightsEqual(a, b map[string]uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for tier, weight := range a {
+		if b[tier] != weight {
+			return false
+		}
+	}
+	return true
+}
+
 func checkTraffic(
 	trafficTarget *shipper.TrafficTarget,
 	stepTrafficWeight uint32,
+	tierWeights map[string]uint32,
 	compFn func(achieved uint32, desired uint32) bool,
 ) (
 	bool,
@@ -153,10 +197,21 @@ func checkTraffic(
 	clusterTrafficData := make(map[string]trafficState)
 
 	specs := trafficTarget.Spec.Clusters
+	verifyOnly := make(map[string]bool, len(specs))
+	suspended := make(map[string]bool, len(specs))
 	for _, spec := range specs {
+		suspended[spec.Name] = spec.Suspended
+		clusterStepWeight := stepTrafficWeight
+		if spec.VerifyOnly {
+			// Verify-only clusters run the release but never take real
+			// traffic, no matter what the strategy step says.
+			clusterStepWeight = 0
+		}
+		verifyOnly[spec.Name] = spec.VerifyOnly
 		clusterTrafficData[spec.Name] = trafficState{
-			desiredTrafficWeight: spec.Weight,
-			stepTrafficWeight:    stepTrafficWeight,
+			desiredTrafficWeight:  spec.Weight,
+			desiredServiceWeights: spec.ServiceWeights,
+			stepTrafficWeight:     clusterStepWeight,
 		}
 	}
 
@@ -180,12 +235,24 @@ func checkTraffic(
 
 	clustersNotReady := make([]string, 0)
 	canProceed := true
+	anyOutdated := false
+	// The spec is replaced wholesale when patched, so it has to carry every
+	// cluster, not just the outdated ones: with verify-only clusters in the
+	// mix, clusters no longer all share the same target weight.
 	newSpec := &shipper.TrafficTargetSpec{}
 
 	for clusterName, trafficData := range clusterTrafficData {
-		if trafficData.desiredTrafficWeight != trafficData.stepTrafficWeight {
-			t := shipper.ClusterTrafficTarget{Name: clusterName, Weight: trafficData.stepTrafficWeight}
-			newSpec.Clusters = append(newSpec.Clusters, t)
+		t := shipper.ClusterTrafficTarget{Name: clusterName, Weight: trafficData.stepTrafficWeight, VerifyOnly: verifyOnly[clusterName], Suspended: suspended[clusterName]}
+		// Verify-only clusters serve zero traffic on every tier, so
+		// per-tier weights would be noise there.
+		if len(tierWeights) > 0 && !t.VerifyOnly {
+			t.ServiceWeights = tierWeights
+		}
+		newSpec.Clusters = append(newSpec.Clusters, t)
+
+		if trafficData.desiredTrafficWeight != trafficData.stepTrafficWeight ||
+			!tierWeightsEqual(trafficData.desiredServiceWeights, t.ServiceWeights) {
+			anyOutdated = true
 			canProceed = false
 			clustersNotReady = append(clustersNotReady, clusterName)
 		} else if !compFn(trafficData.achievedTrafficWeight, trafficData.desiredTrafficWeight) {
@@ -194,9 +261,23 @@ func checkTraffic(
 		}
 	}
 
-	if len(newSpec.Clusters) > 0 {
+	sortTrafficClusters(newSpec)
+
+	if anyOutdated {
 		return canProceed, newSpec, clustersNotReady
 	} else {
 		return canProceed, nil, clustersNotReady
 	}
 }
+
+func sortCapacityClusters(spec *shipper.CapacityTargetSpec) {
+	sort.Slice(spec.Clusters, func(i, j int) bool {
+		return spec.Clusters[i].Name < spec.Clusters[j].Name
+	})
+}
+
+func sortTrafficClusters(spec *shipper.TrafficTargetSpec) {
+	sort.Slice(spec.Clusters, func(i, j int) bool {
+		return spec.Clusters[i].Name < spec.Clusters[j].Name
+	})
+}
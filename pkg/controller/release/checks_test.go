@@ -0,0 +1,113 @@
+package release
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func buildTrafficTargetForChecks(clusters []shipper.ClusterTrafficTarget, statuses []*shipper.ClusterTrafficStatus) *shipper.TrafficTarget {
+	return &shipper.TrafficTarget{
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: clusters,
+		},
+		Status: shipper.TrafficTargetStatus{
+			Clusters: statuses,
+		},
+	}
+}
+
+// TestCheckTrafficVerifyOnlyClusterStaysAtZero checks that when the strategy
+// step asks for traffic, a verify-only cluster is kept at weight zero while
+// the other clusters get the step's weight.
+func TestCheckTrafficVerifyOnlyClusterStaysAtZero(t *testing.T) {
+	tt := buildTrafficTargetForChecks(
+		[]shipper.ClusterTrafficTarget{
+			{Name: "production", Weight: 0},
+			{Name: "verify", Weight: 0, VerifyOnly: true},
+		},
+		[]*shipper.ClusterTrafficStatus{
+			{Name: "production", AchievedTraffic: 0},
+			{Name: "verify", AchievedTraffic: 0},
+		},
+	)
+
+	canProceed, newSpec, clustersNotReady := checkTraffic(tt, 50, nil, contenderTrafficComparison)
+	if canProceed {
+		t.Error("expected checkTraffic to not proceed while the production cluster's weight is outdated")
+	}
+
+	if newSpec == nil {
+		t.Fatal("expected checkTraffic to produce a new spec")
+	}
+
+	expectedClusters := []shipper.ClusterTrafficTarget{
+		{Name: "production", Weight: 50},
+		{Name: "verify", Weight: 0, VerifyOnly: true},
+	}
+
+	if !reflect.DeepEqual(newSpec.Clusters, expectedClusters) {
+		t.Errorf("expected new spec clusters %v, got %v", expectedClusters, newSpec.Clusters)
+	}
+
+	sort.Strings(clustersNotReady)
+	if !reflect.DeepEqual(clustersNotReady, []string{"production"}) {
+		t.Errorf("expected only the production cluster to be not ready, got %v", clustersNotReady)
+	}
+}
+
+// TestCheckTrafficVerifyOnlyClusterAchieved checks that a verify-only cluster
+// counts as achieved at zero traffic, so it doesn't hold the strategy back
+// once the real clusters have their weight.
+func TestCheckTrafficVerifyOnlyClusterAchieved(t *testing.T) {
+	tt := buildTrafficTargetForChecks(
+		[]shipper.ClusterTrafficTarget{
+			{Name: "production", Weight: 50},
+			{Name: "verify", Weight: 0, VerifyOnly: true},
+		},
+		[]*shipper.ClusterTrafficStatus{
+			{Name: "production", AchievedTraffic: 50},
+			{Name: "verify", AchievedTraffic: 0},
+		},
+	)
+
+	canProceed, newSpec, clustersNotReady := checkTraffic(tt, 50, nil, contenderTrafficComparison)
+	if !canProceed {
+		t.Errorf("expected checkTraffic to proceed, but clusters %v are not ready", clustersNotReady)
+	}
+
+	if newSpec != nil {
+		t.Errorf("expected no new spec, got %v", newSpec)
+	}
+}
+
+// TestCheckCapacityGatesOnVerifyOnlyCluster checks that a verify-only cluster
+// still gates capacity: its pods must come up before the strategy can move
+// traffic anywhere.
+func TestCheckCapacityGatesOnVerifyOnlyCluster(t *testing.T) {
+	ct := &shipper.CapacityTarget{
+		Spec: shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "production", Percent: 50, TotalReplicaCount: 10},
+				{Name: "verify", Percent: 50, TotalReplicaCount: 10},
+			},
+		},
+		Status: shipper.CapacityTargetStatus{
+			Clusters: []shipper.ClusterCapacityStatus{
+				{Name: "production", AchievedPercent: 50, AvailableReplicas: 5},
+				{Name: "verify", AchievedPercent: 0, AvailableReplicas: 0},
+			},
+		},
+	}
+
+	canProceed, _, clustersNotReady := checkCapacity(ct, 50)
+	if canProceed {
+		t.Error("expected checkCapacity to gate on the verify cluster's missing pods")
+	}
+
+	if !reflect.DeepEqual(clustersNotReady, []string{"verify"}) {
+		t.Errorf("expected the verify cluster to be not ready, got %v", clustersNotReady)
+	}
+}
@@ -0,0 +1,141 @@
+package release
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/conditions"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+const verificationConditionType = "VerificationPassed"
+
+// buildGatedExecutor returns an executor for a contender sitting at the last
+// step of a two-step strategy with a verification completion gate, its
+// single cluster already at full capacity and traffic.
+func buildGatedExecutor() *Executor {
+	strategy := shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "staging",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+			},
+			{
+				Name:     "full on",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+			},
+		},
+		CompletionGates: []shipper.ReleaseCompletionGate{
+			{ConditionType: verificationConditionType},
+		},
+	}
+
+	release := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: 1,
+			Environment: shipper.ReleaseEnvironment{
+				Strategy: &strategy,
+			},
+		},
+	}
+
+	installationTarget := &shipper.InstallationTarget{
+		Spec: shipper.InstallationTargetSpec{
+			Clusters: []string{"minikube"},
+		},
+		Status: shipper.InstallationTargetStatus{
+			Clusters: []*shipper.ClusterInstallationStatus{
+				{Name: "minikube", Status: shipper.ReleasePhaseInstalled},
+			},
+		},
+	}
+
+	capacityTarget := &shipper.CapacityTarget{
+		Spec: shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "minikube", Percent: 100, TotalReplicaCount: 10},
+			},
+		},
+		Status: shipper.CapacityTargetStatus{
+			Clusters: []shipper.ClusterCapacityStatus{
+				{Name: "minikube", AchievedPercent: 100, AvailableReplicas: 10},
+			},
+		},
+	}
+
+	trafficTarget := &shipper.TrafficTarget{
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "minikube", Weight: 100},
+			},
+		},
+		Status: shipper.TrafficTargetStatus{
+			Clusters: []*shipper.ClusterTrafficStatus{
+				{Name: "minikube", AchievedTraffic: 100},
+			},
+		},
+	}
+
+	return &Executor{
+		contender: &releaseInfo{
+			release:            release,
+			installationTarget: installationTarget,
+			capacityTarget:     capacityTarget,
+			trafficTarget:      trafficTarget,
+		},
+		recorder: record.NewFakeRecorder(42),
+		strategy: strategy,
+	}
+}
+
+// TestCompletionGateKeepsReleaseIncomplete puts a contender at its achieved
+// final step with a verification gate still unmet, and checks that the
+// release is held incomplete with a condition naming the pending gate; once
+// external tooling posts the verification condition, the next sync completes
+// the release.
+func TestCompletionGateKeepsReleaseIncomplete(t *testing.T) {
+	executor := buildGatedExecutor()
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusPatch := releaseStatusPatch(t, patches)
+
+	complete := releaseutil.GetReleaseCondition(*statusPatch.NewStatus, shipper.ReleaseConditionTypeComplete)
+	if complete == nil || complete.Status != corev1.ConditionFalse {
+		t.Fatalf("expected a Complete condition with status False, got %v", complete)
+	}
+
+	if complete.Reason != conditions.WaitingForCompletionGates {
+		t.Errorf("expected reason %q, got %q", conditions.WaitingForCompletionGates, complete.Reason)
+	}
+
+	// The verification pipeline reports its condition: the gate is
+	// satisfied and the next sync completes the release.
+	executor = buildGatedExecutor()
+	executor.contender.release.Status.Conditions = []shipper.ReleaseCondition{
+		{Type: verificationConditionType, Status: corev1.ConditionTrue},
+	}
+
+	patches, _, err = executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusPatch = releaseStatusPatch(t, patches)
+	if !hasCompleteCondition(statusPatch.NewStatus) {
+		t.Error("expected the release to be complete once the gate passed")
+	}
+}
@@ -0,0 +1,161 @@
+package release
+
+import (
+	"strconv"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// buildConfirmTrafficExecutor returns an executor for a contender on a
+// one-step strategy whose traffic half requires a separate approval. The
+// arguments describe how far the single cluster has come.
+func buildConfirmTrafficExecutor(achievedPercent int32, specWeight, achievedTraffic uint32) *Executor {
+	strategy := shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:           "full on",
+				Capacity:       shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:        shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				ConfirmTraffic: true,
+			},
+		},
+	}
+
+	release := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: 0,
+			Environment: shipper.ReleaseEnvironment{
+				Strategy: &strategy,
+			},
+		},
+	}
+
+	installationTarget := &shipper.InstallationTarget{
+		Spec: shipper.InstallationTargetSpec{
+			Clusters: []string{"minikube"},
+		},
+		Status: shipper.InstallationTargetStatus{
+			Clusters: []*shipper.ClusterInstallationStatus{
+				{Name: "minikube", Status: shipper.ReleasePhaseInstalled},
+			},
+		},
+	}
+
+	capacityTarget := &shipper.CapacityTarget{
+		Spec: shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "minikube", Percent: achievedPercent, TotalReplicaCount: 10},
+			},
+		},
+		Status: shipper.CapacityTargetStatus{
+			Clusters: []shipper.ClusterCapacityStatus{
+				{Name: "minikube", AchievedPercent: achievedPercent, AvailableReplicas: achievedPercent / 10},
+			},
+		},
+	}
+
+	trafficTarget := &shipper.TrafficTarget{
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "minikube", Weight: specWeight},
+			},
+		},
+		Status: shipper.TrafficTargetStatus{
+			Clusters: []*shipper.ClusterTrafficStatus{
+				{Name: "minikube", AchievedTraffic: achievedTraffic},
+			},
+		},
+	}
+
+	return &Executor{
+		contender: &releaseInfo{
+			release:            release,
+			installationTarget: installationTarget,
+			capacityTarget:     capacityTarget,
+			trafficTarget:      trafficTarget,
+		},
+		recorder: record.NewFakeRecorder(42),
+		strategy: strategy,
+	}
+}
+
+// TestConfirmTrafficHoldsTrafficUntilApproved drives a ConfirmTraffic step
+// through both halves: capacity moves immediately and, once the pods are up,
+// traffic stays untouched until the approval annotation lands, after which
+// the traffic spec is patched.
+func TestConfirmTrafficHoldsTrafficUntilApproved(t *testing.T) {
+	// First half: capacity is patched up without any approval.
+	executor := buildConfirmTrafficExecutor(0, 0, 0)
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var capacityPatch *CapacityTargetOutdatedResult
+	for _, patch := range patches {
+		if p, ok := patch.(*CapacityTargetOutdatedResult); ok {
+			capacityPatch = p
+		}
+	}
+
+	if capacityPatch == nil {
+		t.Fatal("expected a capacity target patch before any approval")
+	}
+
+	// Pods are up, but traffic isn't approved yet: only a status patch
+	// reporting WaitingForTrafficCommand, no traffic target patch.
+	executor = buildConfirmTrafficExecutor(100, 0, 0)
+
+	patches, _, err = executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patches) != 1 {
+		t.Fatalf("expected only a release status patch while awaiting approval, got %d patches", len(patches))
+	}
+
+	releasePatch, ok := patches[0].(*ReleaseUpdateResult)
+	if !ok {
+		t.Fatalf("expected a release status patch, got %T", patches[0])
+	}
+
+	if state := releasePatch.NewStatus.Strategy.State.WaitingForTrafficCommand; state != shipper.StrategyStateTrue {
+		t.Errorf("expected WaitingForTrafficCommand %q, got %q", shipper.StrategyStateTrue, state)
+	}
+
+	// The operator approves the step's traffic: the traffic spec moves.
+	executor = buildConfirmTrafficExecutor(100, 0, 0)
+	executor.contender.release.Annotations = map[string]string{
+		shipper.ReleaseApprovedTrafficAnnotation: strconv.Itoa(0),
+	}
+
+	patches, _, err = executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var trafficPatch *TrafficTargetOutdatedResult
+	for _, patch := range patches {
+		if p, ok := patch.(*TrafficTargetOutdatedResult); ok {
+			trafficPatch = p
+		}
+	}
+
+	if trafficPatch == nil {
+		t.Fatal("expected a traffic target patch after approval")
+	}
+
+	if len(trafficPatch.NewSpec.Clusters) != 1 || trafficPatch.NewSpec.Clusters[0].Weight != 100 {
+		t.Errorf("expected the cluster's traffic weight patched to 100, got %v", trafficPatch.NewSpec.Clusters)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 
@@ -22,6 +23,48 @@ type Executor struct {
 	incumbent *releaseInfo
 	recorder  record.EventRecorder
 	strategy  shipper.RolloutStrategy
+
+	// superseded holds abandoned rollouts sitting between the incumbent and
+	// the contender; they are drained to zero before the contender moves so
+	// the concurrent releases' combined weight stays within the step's
+	// budget.
+	superseded []*releaseInfo
+
+	// gate restricts which clusters may move while the strategy's waves
+	// roll out one at a time; nil when waves don't apply or are all done.
+	gate *waveGate
+
+	// trafficCommandPending is only set on steps with ConfirmTraffic:
+	// true while the step's traffic half still awaits operator approval.
+	// It is surfaced as the WaitingForTrafficCommand strategy state.
+	trafficCommandPending *bool
+
+	// soakingUntil is set while the final-step policy is holding completion
+	// back for its soak period; nothing moves until it ends.
+	soakingUntil *metav1.Time
+
+	// commandHoldReason explains which unmet precondition is holding an
+	// issued step command back this sync; empty when nothing is in the
+	// way. It is surfaced through the strategy status's CommandStatus.
+	commandHoldReason string
+
+	// adaptiveStatus is the state the adaptive control loop computed this
+	// sync: the share the contender is being driven toward and the
+	// increment the last interval chose. Nil without an adaptive strategy.
+	adaptiveStatus *shipper.AdaptiveStatus
+	// adaptiveCeiling is the target step's own share, which bounds how
+	// far the adaptive ramp may grow before the next command.
+	adaptiveCeiling int32
+
+	// stabilityPending is only set on strategies with a stability policy:
+	// true while a burst of instability events pauses advancement. It is
+	// surfaced as the WaitingForStability strategy state.
+	stabilityPending *bool
+
+	// flagProvider applies the steps' feature-flag changes to an external
+	// feature-flag system; a no-op provider stands in when none is
+	// configured.
+	flagProvider FlagProvider
 }
 
 func (s *Executor) info(format string, args ...interface{}) {
@@ -43,6 +86,12 @@ func (s *Executor) event(obj runtime.Object, format string, args ...interface{})
 // has happened. Currently if both values are nil it means that the operation was
 // successful but no modifications are required.
 func (s *Executor) Execute() ([]ExecutorResult, []ReleaseStrategyStateTransition, error) {
+	// Install-only strategies have no steps to walk through: the whole
+	// machinery below reduces to the installation check.
+	if s.strategy.InstallOnly {
+		return s.executeInstallOnly()
+	}
+
 	targetStep := s.contender.release.Spec.TargetStep
 
 	if targetStep >= int32(len(s.strategy.Steps)) {
@@ -53,6 +102,18 @@ func (s *Executor) Execute() ([]ExecutorResult, []ReleaseStrategyStateTransition
 	}
 	strategyStep := s.strategy.Steps[targetStep]
 
+	if len(s.superseded) > 0 {
+		// More than two releases are in play, so the two-way step value no
+		// longer covers everyone; apportion it before anything moves.
+		strategyStep.Capacity = apportionStepValue(strategyStep.Capacity)
+		strategyStep.Traffic = apportionStepValue(strategyStep.Traffic)
+	}
+
+	if strategyStep.ConfirmTraffic {
+		pending := !releaseutil.TrafficApprovedForStep(s.contender.release, targetStep)
+		s.trafficCommandPending = &pending
+	}
+
 	lastStepIndex := int32(len(s.strategy.Steps) - 1)
 	if lastStepIndex < 0 {
 		lastStepIndex = 0
@@ -70,6 +131,60 @@ func (s *Executor) Execute() ([]ExecutorResult, []ReleaseStrategyStateTransition
 
 	lastTransitionTime := time.Now()
 
+	// A backward targetStep move is a rollback. If the release opted into
+	// fast rollback, jump straight to the target step's capacity and
+	// traffic in one sync instead of waiting for each phase to be achieved
+	// in sequence; the regular path below takes over once all specs match.
+	achievedStep := s.contender.release.Status.AchievedStep
+	movingBackwards := achievedStep != nil && targetStep < achievedStep.Step
+	if movingBackwards && releaseutil.WantsFastRollback(s.contender.release) {
+		if patches := s.buildFastRollbackPatches(strategyStep); len(patches) > 0 {
+			s.info("rolling back fast: jumping straight to step %d", targetStep)
+
+			patches = append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
+			return patches, nil, nil
+		}
+	}
+
+	//////////////////////////////////////////////////////////////////////////
+	// Canary namespace
+	//
+	// With canary-namespace isolation on, the contender's objects install
+	// into an isolated namespace for every step but the last, so a broken
+	// chart can't disrupt the incumbent's objects in the application
+	// namespace. Reaching the final step moves them into the application
+	// namespace, and the installation controller cleans the canary
+	// namespace up.
+	if s.strategy.CanaryNamespace && s.incumbent != nil {
+		desired := ""
+		if !isLastStep {
+			desired = canaryNamespaceName(s.contender.release)
+		}
+
+		if it := s.contender.installationTarget; it.Spec.TargetNamespace != desired {
+			target := desired
+			if target == "" {
+				target = s.contender.release.Namespace
+			}
+			s.info("moving installation into namespace %q", target)
+
+			newSpec := it.Spec.DeepCopy()
+			newSpec.TargetNamespace = desired
+
+			strategyConditions.SetUnknown(
+				shipper.StrategyConditionContenderAchievedInstallation,
+				conditions.StrategyConditionsUpdate{
+					Step:               targetStep,
+					LastTransitionTime: lastTransitionTime,
+				})
+
+			return []ExecutorResult{
+				&InstallationTargetOutdatedResult{Name: it.Name, NewSpec: newSpec},
+				s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep),
+			}, nil, nil
+		}
+	}
+
 	//////////////////////////////////////////////////////////////////////////
 	// Installation
 	//
@@ -112,179 +227,142 @@ func (s *Executor) Execute() ([]ExecutorResult, []ReleaseStrategyStateTransition
 	}
 
 	//////////////////////////////////////////////////////////////////////////
-	// Contender
+	// Waves
 	//
-	{
+	// With waves defined, only clusters of completed waves plus the wave
+	// currently rolling out may move to the target step; while a bake
+	// period runs, nothing moves at all.
+	s.gate = s.buildWaveGate(strategyStep)
+	if s.gate != nil && s.gate.baking {
+		s.info("wave %q is baking until %s", s.gate.currentWave, s.gate.bakeUntil)
+		s.commandHoldReason = fmt.Sprintf("wave %q is baking until %s", s.gate.currentWave, s.gate.bakeUntil)
+
+		strategyConditions.SetFalse(
+			shipper.StrategyConditionContenderAchievedCapacity,
+			conditions.StrategyConditionsUpdate{
+				Reason:             conditions.ClustersNotReady,
+				Message:            fmt.Sprintf("wave %q baking until %s", s.gate.currentWave, s.gate.bakeUntil),
+				Step:               targetStep,
+				LastTransitionTime: lastTransitionTime,
+			})
 
-		//////////////////////////////////////////////////////////////////////////
-		// Contender Capacity
-		//
-		capacityWeight := strategyStep.Capacity.Contender
+		return []ExecutorResult{s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep)},
+			nil,
+			nil
+	}
 
-		if achieved, newSpec, clustersNotReady := checkCapacity(s.contender.capacityTarget, uint(capacityWeight)); !achieved {
-			s.info("contender %q hasn't achieved capacity yet", s.contender.release.Name)
+	//////////////////////////////////////////////////////////////////////////
+	// Stability
+	//
+	// With a stability policy, a burst of crash/restart/OOM events from
+	// the contender's pods pauses advancement until the burst subsides.
+	if s.strategy.StabilityPolicy != nil {
+		events, exceeded := s.stabilityExceeded()
+		s.stabilityPending = &exceeded
 
-			var patches []ExecutorResult
+		if exceeded {
+			s.info("pausing: %d instability events exceed the policy's %d", events, s.strategy.StabilityPolicy.MaxEvents)
+			s.commandHoldReason = fmt.Sprintf("%d recent instability events exceed the policy's %d", events, s.strategy.StabilityPolicy.MaxEvents)
 
 			strategyConditions.SetFalse(
 				shipper.StrategyConditionContenderAchievedCapacity,
 				conditions.StrategyConditionsUpdate{
-					Reason:             conditions.ClustersNotReady,
-					Message:            fmt.Sprintf("clusters pending capacity adjustments: %v", clustersNotReady),
+					Reason:             conditions.WaitingForStability,
+					Message:            fmt.Sprintf("%d recent instability events exceed the policy's %d; waiting for the burst to subside", events, s.strategy.StabilityPolicy.MaxEvents),
 					Step:               targetStep,
 					LastTransitionTime: lastTransitionTime,
 				})
 
-			if newSpec != nil {
-				patches = append(patches, &CapacityTargetOutdatedResult{
-					NewSpec: newSpec,
-					Name:    s.contender.release.Name,
-				})
-			}
-
-			patches = append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
-
-			return patches, nil, nil
-		} else {
-			s.info("contender %q has achieved capacity", s.contender.release.Name)
-
-			strategyConditions.SetTrue(
-				shipper.StrategyConditionContenderAchievedCapacity,
-				conditions.StrategyConditionsUpdate{
-					Step:               targetStep,
-					LastTransitionTime: lastTransitionTime,
-				})
-		}
-
-		//////////////////////////////////////////////////////////////////////////
-		// Contender Traffic
-		//
-		trafficWeight := strategyStep.Traffic.Contender
-
-		if achieved, newSpec, clustersNotReady := checkTraffic(s.contender.trafficTarget, uint32(trafficWeight), contenderTrafficComparison); !achieved {
-			s.info("contender %q hasn't achieved traffic yet", s.contender.release.Name)
-
-			var patches []ExecutorResult
-
-			strategyConditions.SetFalse(
-				shipper.StrategyConditionContenderAchievedTraffic,
-				conditions.StrategyConditionsUpdate{
-					Reason:             conditions.ClustersNotReady,
-					Message:            fmt.Sprintf("clusters pending traffic adjustments: %v", clustersNotReady),
-					Step:               targetStep,
-					LastTransitionTime: lastTransitionTime,
-				})
-
-			if newSpec != nil {
-				patches = append(patches, &TrafficTargetOutdatedResult{
-					NewSpec: newSpec,
-					Name:    s.contender.release.Name,
-				})
-			}
-
-			patches = append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
-
-			return patches, nil, nil
-		} else {
-			s.info("contender %q has achieved traffic", s.contender.release.Name)
-
-			strategyConditions.SetTrue(
-				shipper.StrategyConditionContenderAchievedTraffic,
-				conditions.StrategyConditionsUpdate{
-					Step:               targetStep,
-					LastTransitionTime: lastTransitionTime,
-				})
+			return []ExecutorResult{s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep)},
+				nil,
+				nil
 		}
 	}
 
 	//////////////////////////////////////////////////////////////////////////
-	// Incumbent
+	// Superseded releases
 	//
-	if s.incumbent != nil {
-
-		//////////////////////////////////////////////////////////////////////////
-		// Incumbent Traffic
-		//
-		trafficWeight := strategyStep.Traffic.Incumbent
-
-		if achieved, newSpec, clustersNotReady := checkTraffic(s.incumbent.trafficTarget, uint32(trafficWeight), incumbentTrafficComparison); !achieved {
-			s.info("incumbent %q hasn't achieved traffic yet", s.incumbent.release.Name)
-
-			var patches []ExecutorResult
-
-			strategyConditions.SetFalse(
-				shipper.StrategyConditionIncumbentAchievedTraffic,
-				conditions.StrategyConditionsUpdate{
-					Reason:             conditions.ClustersNotReady,
-					Message:            fmt.Sprintf("clusters pending traffic adjustments: %v", clustersNotReady),
-					Step:               targetStep,
-					LastTransitionTime: lastTransitionTime,
-				})
+	// Abandoned rollouts between the incumbent and this contender drain
+	// before the contender takes its share; otherwise the concurrent
+	// releases' combined weight could exceed the apportioned total.
+	if patches := s.ensureSupersededDrained(strategyConditions, targetStep, isLastStep, lastTransitionTime); patches != nil {
+		return patches, nil, nil
+	}
 
-			if newSpec != nil {
-				patches = append(patches, &TrafficTargetOutdatedResult{
-					NewSpec: newSpec,
-					Name:    s.incumbent.release.Name,
-				})
-			}
+	//////////////////////////////////////////////////////////////////////////
+	// Adaptive increments
+	//
+	// An adaptive strategy replaces the step's fixed percentages with a
+	// control loop: each interval the contender's share grows by an
+	// increment sized from the SLI condition external monitoring posts on
+	// the release, and the machinery below then drives the ramped share
+	// exactly like a fixed step.
+	if s.strategy.Adaptive != nil {
+		strategyStep = s.adaptiveStep(strategyStep)
+	}
 
-			patches = append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
+	//////////////////////////////////////////////////////////////////////////
+	// Contender and incumbent capacity and traffic
+	//
+	// The default ordering scales the contender up before handing it
+	// traffic, and drains the incumbent's traffic before scaling it down.
+	// A TrafficFirst strategy inverts both.
+	contenderPhases := []executorPhase{s.ensureContenderCapacity, s.ensureContenderTraffic}
+	incumbentPhases := []executorPhase{s.ensureIncumbentTraffic, s.ensureIncumbentCapacity}
+	if s.strategy.Ordering == shipper.StrategyOrderingTrafficFirst {
+		contenderPhases = []executorPhase{s.ensureContenderTraffic, s.ensureContenderCapacity}
+		incumbentPhases = []executorPhase{s.ensureIncumbentCapacity, s.ensureIncumbentTraffic}
+	}
 
+	for _, phase := range contenderPhases {
+		if patches := phase(strategyConditions, strategyStep, targetStep, isLastStep, lastTransitionTime); patches != nil {
 			return patches, nil, nil
-		} else {
-			s.info("incumbent %q has achieved traffic", s.incumbent.release.Name)
-
-			strategyConditions.SetTrue(
-				shipper.StrategyConditionIncumbentAchievedTraffic,
-				conditions.StrategyConditionsUpdate{
-					Step:               targetStep,
-					LastTransitionTime: lastTransitionTime,
-				})
 		}
+	}
 
-		//////////////////////////////////////////////////////////////////////////
-		// Incumbent Capacity
-		//
-		capacityWeight := strategyStep.Capacity.Incumbent
-
-		if achieved, newSpec, clustersNotReady := checkCapacity(s.incumbent.capacityTarget, uint(capacityWeight)); !achieved {
-			s.info("incumbent %q hasn't achieved capacity yet", s.incumbent.release.Name)
-
-			var patches []ExecutorResult
-
-			strategyConditions.SetFalse(
-				shipper.StrategyConditionIncumbentAchievedCapacity,
-				conditions.StrategyConditionsUpdate{
-					Reason:             conditions.ClustersNotReady,
-					Message:            fmt.Sprintf("clusters pending capacity adjustments: %v", clustersNotReady),
-					Step:               targetStep,
-					LastTransitionTime: lastTransitionTime,
-				})
-
-			if newSpec != nil {
-				patches = append(patches, &CapacityTargetOutdatedResult{
-					NewSpec: newSpec,
-					Name:    s.incumbent.release.Name,
-				})
+	if s.incumbent != nil {
+		for _, phase := range incumbentPhases {
+			if patches := phase(strategyConditions, strategyStep, targetStep, isLastStep, lastTransitionTime); patches != nil {
+				return patches, nil, nil
 			}
-
-			patches = append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
-
-			return patches, nil, nil
-		} else {
-			s.info("incumbent %q has achieved capacity", s.incumbent.release.Name)
-
-			strategyConditions.SetTrue(
-				shipper.StrategyConditionIncumbentAchievedCapacity,
-				conditions.StrategyConditionsUpdate{
-					Step:               targetStep,
-					LastTransitionTime: lastTransitionTime,
-				})
 		}
 	} else {
 		s.info("no incumbent, must be a new app")
 	}
 
+	//////////////////////////////////////////////////////////////////////////
+	// Adaptive ramp mid-flight
+	//
+	// Achieving the ramped share is not achieving the step: hold the wrap
+	// up -- and with it the achieved step and completion -- until the ramp
+	// reaches the step's own percentages.
+	if s.adaptiveStatus != nil && s.adaptiveStatus.Percent < s.adaptiveCeiling {
+		s.info("adaptive ramp at %d%% of the step's %d%%", s.adaptiveStatus.Percent, s.adaptiveCeiling)
+
+		strategyConditions.SetFalse(
+			shipper.StrategyConditionContenderAchievedCapacity,
+			conditions.StrategyConditionsUpdate{
+				Reason:             conditions.ClustersNotReady,
+				Message:            fmt.Sprintf("adaptive ramp at %d%% of the step's %d%%; the last increment was %d%%", s.adaptiveStatus.Percent, s.adaptiveCeiling, s.adaptiveStatus.LastIncrement),
+				Step:               targetStep,
+				LastTransitionTime: lastTransitionTime,
+			})
+
+		return []ExecutorResult{s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep)},
+			nil,
+			nil
+	}
+
+	//////////////////////////////////////////////////////////////////////////
+	// Feature flags
+	//
+	// Capacity and traffic are in place; drive the step's feature flags to
+	// the contender's traffic percentage before reporting the step
+	// achieved, so flag state never trails the traffic it gates.
+	if patches := s.ensureFeatureFlags(strategyConditions, strategyStep, targetStep, isLastStep, lastTransitionTime); patches != nil {
+		return patches, nil, nil
+	}
+
 	//////////////////////////////////////////////////////////////////////////
 	// Step wrap up
 	//
@@ -298,6 +376,8 @@ func (s *Executor) Execute() ([]ExecutorResult, []ReleaseStrategyStateTransition
 			s.contender.release.Spec.TargetStep,
 			s.incumbent != nil,
 			isLastStep)
+		s.applyTrafficCommandState(&newReleaseStrategyState)
+		s.applyStabilityState(&newReleaseStrategyState)
 
 		oldReleaseStrategyState := shipper.ReleaseStrategyState{}
 		if contenderStatus.Strategy != nil {
@@ -317,7 +397,25 @@ func (s *Executor) Execute() ([]ExecutorResult, []ReleaseStrategyStateTransition
 		contenderStatus.Strategy = &shipper.ReleaseStrategyStatus{
 			Conditions: strategyConditions.AsReleaseStrategyConditions(),
 			State:      newReleaseStrategyState,
+			Steps:      strategyConditions.AsReleaseStrategyStepStatuses(s.strategy.Steps, targetStep, s.incumbent != nil),
+		}
+		if s.gate != nil {
+			// Waves still mid-rollout when the gated clusters achieve
+			// the step: keep the wave fields watchers follow instead
+			// of blanking them until the next pending sync.
+			contenderStatus.Strategy.CurrentWave = s.gate.currentWave
+			contenderStatus.Strategy.WaveBakeUntil = s.gate.bakeUntil
 		}
+		if s.adaptiveStatus != nil {
+			contenderStatus.Strategy.Adaptive = s.adaptiveStatus
+		}
+		contenderStatus.Strategy.CommandStatus = s.commandStatus(newReleaseStrategyState)
+		s.accumulateStateDurations(contenderStatus.Strategy, targetStep, lastTransitionTime)
+		contenderStatus.ObservedGeneration = s.contender.release.Generation
+
+		// The target step is fully achieved: no transition is in flight
+		// anymore.
+		contenderStatus.AchievingStep = nil
 
 		previouslyAchievedStep := s.contender.release.Status.AchievedStep
 		if previouslyAchievedStep == nil || targetStep != previouslyAchievedStep.Step {
@@ -330,8 +428,51 @@ func (s *Executor) Execute() ([]ExecutorResult, []ReleaseStrategyStateTransition
 		}
 
 		if targetStep == lastStepIndex {
-			condition := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", "")
-			releaseutil.SetReleaseCondition(contenderStatus, *condition)
+			// With a final-step policy, completion is earned, not
+			// automatic: failing checks roll the release back, and
+			// passing ones still soak under full traffic first.
+			if policy := s.strategy.FinalStepPolicy; policy != nil {
+				if !s.finalStepChecksPass() {
+					s.info("final step checks failed, rolling back to step %d", policy.RollbackToStep)
+					s.event(s.contender.release, "final step checks failed, rolling back to step %d", policy.RollbackToStep)
+
+					return []ExecutorResult{&ReleaseRollbackResult{
+						Name:       s.contender.release.Name,
+						TargetStep: policy.RollbackToStep,
+					}}, nil, nil
+				}
+
+				soakingUntil := s.resolveSoakDeadline()
+				contenderStatus.Strategy.SoakingUntil = soakingUntil
+
+				if waveNow().Before(soakingUntil.Time) {
+					s.soakingUntil = soakingUntil
+					s.info("final step soaking until %s", soakingUntil)
+
+					releasePatches = append(releasePatches, &ReleaseUpdateResult{
+						NewStatus: contenderStatus,
+						Name:      s.contender.release.Name,
+					})
+					return releasePatches, releaseStrategyStateTransitions, nil
+				}
+			}
+
+			// Achieving the final step (and soaking, above) is only one of
+			// the completion requirements: every configured completion
+			// gate's condition must be True as well.
+			if pending := releaseutil.PendingCompletionGates(s.contender.release); len(pending) > 0 {
+				s.info("final step achieved, waiting for completion gates: %v", pending)
+
+				condition := releaseutil.NewReleaseCondition(
+					shipper.ReleaseConditionTypeComplete,
+					corev1.ConditionFalse,
+					conditions.WaitingForCompletionGates,
+					fmt.Sprintf("completion gates pending: %v", pending))
+				releaseutil.SetReleaseCondition(contenderStatus, *condition)
+			} else {
+				condition := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", "")
+				releaseutil.SetReleaseCondition(contenderStatus, *condition)
+			}
 		}
 
 		releasePatches = append(releasePatches, &ReleaseUpdateResult{
@@ -344,6 +485,406 @@ func (s *Executor) Execute() ([]ExecutorResult, []ReleaseStrategyStateTransition
 	}
 }
 
+// An executorPhase drives one capacity or traffic phase toward the target
+// step. It returns the patches to apply while the phase is still pending, or
+// nil once the phase has achieved the step and its condition has been set.
+type executorPhase func(
+	strategyConditions conditions.StrategyConditionsMap,
+	strategyStep shipper.RolloutStrategyStep,
+	targetStep int32,
+	isLastStep bool,
+	lastTransitionTime time.Time,
+) []ExecutorResult
+
+func (s *Executor) ensureContenderCapacity(
+	strategyConditions conditions.StrategyConditionsMap,
+	strategyStep shipper.RolloutStrategyStep,
+	targetStep int32,
+	isLastStep bool,
+	lastTransitionTime time.Time,
+) []ExecutorResult {
+	capacityWeight := strategyStep.Capacity.Contender
+
+	capacityTarget := s.contender.capacityTarget
+	if s.gate != nil {
+		capacityTarget = filterCapacityTarget(capacityTarget, s.gate.activeClusters)
+	}
+
+	achieved, newSpec, clustersNotReady := checkCapacity(capacityTarget, uint(capacityWeight))
+	if achieved {
+		s.info("contender %q has achieved capacity", s.contender.release.Name)
+
+		strategyConditions.SetTrue(
+			shipper.StrategyConditionContenderAchievedCapacity,
+			conditions.StrategyConditionsUpdate{
+				Step:               targetStep,
+				LastTransitionTime: lastTransitionTime,
+			})
+
+		return nil
+	}
+
+	s.info("contender %q hasn't achieved capacity yet", s.contender.release.Name)
+
+	var patches []ExecutorResult
+
+	strategyConditions.SetFalse(
+		shipper.StrategyConditionContenderAchievedCapacity,
+		conditions.StrategyConditionsUpdate{
+			Reason:             conditions.ClustersNotReady,
+			Message:            fmt.Sprintf("clusters pending capacity adjustments: %v", clustersNotReady),
+			Step:               targetStep,
+			LastTransitionTime: lastTransitionTime,
+		})
+
+	if newSpec != nil {
+		if s.gate != nil {
+			newSpec = mergeInactiveCapacityClusters(newSpec, s.contender.capacityTarget.Spec, s.gate.activeClusters)
+		}
+		patches = append(patches, &CapacityTargetOutdatedResult{
+			NewSpec: newSpec,
+			Name:    s.contender.release.Name,
+		})
+	}
+
+	return append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
+}
+
+func (s *Executor) ensureContenderTraffic(
+	strategyConditions conditions.StrategyConditionsMap,
+	strategyStep shipper.RolloutStrategyStep,
+	targetStep int32,
+	isLastStep bool,
+	lastTransitionTime time.Time,
+) []ExecutorResult {
+	trafficWeight := strategyStep.Traffic.Contender
+
+	if s.trafficCommandPending != nil && *s.trafficCommandPending {
+		s.info("contender %q is waiting for traffic approval for step %d", s.contender.release.Name, targetStep)
+
+		strategyConditions.SetFalse(
+			shipper.StrategyConditionContenderAchievedTraffic,
+			conditions.StrategyConditionsUpdate{
+				Reason:             conditions.WaitingForTrafficApproval,
+				Message:            fmt.Sprintf("step %d traffic is awaiting operator approval", targetStep),
+				Step:               targetStep,
+				LastTransitionTime: lastTransitionTime,
+			})
+
+		return []ExecutorResult{s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep)}
+	}
+
+	trafficTarget := s.contender.trafficTarget
+	if s.gate != nil {
+		trafficTarget = filterTrafficTarget(trafficTarget, s.gate.activeClusters)
+	}
+
+	achieved, newSpec, clustersNotReady := checkTraffic(trafficTarget, uint32(trafficWeight), trafficTierWeights(strategyStep.TrafficTiers, true), contenderTrafficComparison)
+	if achieved {
+		s.info("contender %q has achieved traffic", s.contender.release.Name)
+
+		strategyConditions.SetTrue(
+			shipper.StrategyConditionContenderAchievedTraffic,
+			conditions.StrategyConditionsUpdate{
+				Step:               targetStep,
+				LastTransitionTime: lastTransitionTime,
+			})
+
+		return nil
+	}
+
+	s.info("contender %q hasn't achieved traffic yet", s.contender.release.Name)
+
+	var patches []ExecutorResult
+
+	strategyConditions.SetFalse(
+		shipper.StrategyConditionContenderAchievedTraffic,
+		conditions.StrategyConditionsUpdate{
+			Reason:             conditions.ClustersNotReady,
+			Message:            fmt.Sprintf("clusters pending traffic adjustments: %v", clustersNotReady),
+			Step:               targetStep,
+			LastTransitionTime: lastTransitionTime,
+		})
+
+	if newSpec != nil {
+		if s.gate != nil {
+			newSpec = mergeInactiveTrafficClusters(newSpec, s.contender.trafficTarget.Spec, s.gate.activeClusters)
+		}
+		patches = append(patches, &TrafficTargetOutdatedResult{
+			NewSpec: newSpec,
+			Name:    s.contender.release.Name,
+		})
+	}
+
+	return append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
+}
+
+func (s *Executor) ensureIncumbentTraffic(
+	strategyConditions conditions.StrategyConditionsMap,
+	strategyStep shipper.RolloutStrategyStep,
+	targetStep int32,
+	isLastStep bool,
+	lastTransitionTime time.Time,
+) []ExecutorResult {
+	trafficWeight := strategyStep.Traffic.Incumbent
+
+	achieved, newSpec, clustersNotReady := checkTraffic(s.incumbent.trafficTarget, uint32(trafficWeight), trafficTierWeights(strategyStep.TrafficTiers, false), incumbentTrafficComparison)
+	if achieved {
+		s.info("incumbent %q has achieved traffic", s.incumbent.release.Name)
+
+		strategyConditions.SetTrue(
+			shipper.StrategyConditionIncumbentAchievedTraffic,
+			conditions.StrategyConditionsUpdate{
+				Step:               targetStep,
+				LastTransitionTime: lastTransitionTime,
+			})
+
+		return nil
+	}
+
+	s.info("incumbent %q hasn't achieved traffic yet", s.incumbent.release.Name)
+
+	var patches []ExecutorResult
+
+	strategyConditions.SetFalse(
+		shipper.StrategyConditionIncumbentAchievedTraffic,
+		conditions.StrategyConditionsUpdate{
+			Reason:             conditions.ClustersNotReady,
+			Message:            fmt.Sprintf("clusters pending traffic adjustments: %v", clustersNotReady),
+			Step:               targetStep,
+			LastTransitionTime: lastTransitionTime,
+		})
+
+	if newSpec != nil {
+		patches = append(patches, &TrafficTargetOutdatedResult{
+			NewSpec: newSpec,
+			Name:    s.incumbent.release.Name,
+		})
+	}
+
+	return append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
+}
+
+func (s *Executor) ensureIncumbentCapacity(
+	strategyConditions conditions.StrategyConditionsMap,
+	strategyStep shipper.RolloutStrategyStep,
+	targetStep int32,
+	isLastStep bool,
+	lastTransitionTime time.Time,
+) []ExecutorResult {
+	capacityWeight := strategyStep.Capacity.Incumbent
+
+	achieved, newSpec, clustersNotReady := checkCapacity(s.incumbent.capacityTarget, uint(capacityWeight))
+	if achieved {
+		s.info("incumbent %q has achieved capacity", s.incumbent.release.Name)
+
+		strategyConditions.SetTrue(
+			shipper.StrategyConditionIncumbentAchievedCapacity,
+			conditions.StrategyConditionsUpdate{
+				Step:               targetStep,
+				LastTransitionTime: lastTransitionTime,
+			})
+
+		return nil
+	}
+
+	s.info("incumbent %q hasn't achieved capacity yet", s.incumbent.release.Name)
+
+	var patches []ExecutorResult
+
+	strategyConditions.SetFalse(
+		shipper.StrategyConditionIncumbentAchievedCapacity,
+		conditions.StrategyConditionsUpdate{
+			Reason:             conditions.ClustersNotReady,
+			Message:            fmt.Sprintf("clusters pending capacity adjustments: %v", clustersNotReady),
+			Step:               targetStep,
+			LastTransitionTime: lastTransitionTime,
+		})
+
+	if newSpec != nil {
+		patches = append(patches, &CapacityTargetOutdatedResult{
+			NewSpec: newSpec,
+			Name:    s.incumbent.release.Name,
+		})
+	}
+
+	return append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
+}
+
+// ensureSupersededDrained drives every superseded release toward its
+// apportioned weight of zero, traffic first so no requests land on capacity
+// about to disappear. It returns the patches to apply while any superseded
+// release still holds weight, or nil once they are all drained.
+func (s *Executor) ensureSupersededDrained(
+	strategyConditions conditions.StrategyConditionsMap,
+	targetStep int32,
+	isLastStep bool,
+	lastTransitionTime time.Time,
+) []ExecutorResult {
+	var patches []ExecutorResult
+	var pending []string
+
+	for _, superseded := range s.superseded {
+		name := superseded.release.Name
+		drained := true
+
+		if achieved, newSpec, _ := checkTraffic(superseded.trafficTarget, uint32(supersededWeight), nil, incumbentTrafficComparison); !achieved {
+			drained = false
+			if newSpec != nil {
+				patches = append(patches, &TrafficTargetOutdatedResult{
+					NewSpec: newSpec,
+					Name:    name,
+				})
+			}
+		}
+
+		if achieved, newSpec, _ := checkCapacity(superseded.capacityTarget, uint(supersededWeight)); !achieved {
+			drained = false
+			if newSpec != nil {
+				patches = append(patches, &CapacityTargetOutdatedResult{
+					NewSpec: newSpec,
+					Name:    name,
+				})
+			}
+		}
+
+		if !drained {
+			pending = append(pending, name)
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	s.info("superseded releases pending drain: %v", pending)
+	s.commandHoldReason = fmt.Sprintf("superseded releases pending drain: %v", pending)
+
+	strategyConditions.SetFalse(
+		shipper.StrategyConditionContenderAchievedCapacity,
+		conditions.StrategyConditionsUpdate{
+			Reason:             conditions.ClustersNotReady,
+			Message:            fmt.Sprintf("superseded releases pending drain: %v", pending),
+			Step:               targetStep,
+			LastTransitionTime: lastTransitionTime,
+		})
+
+	return append(patches, s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep))
+}
+
+// buildFastRollbackPatches collects, in one pass, every capacity and traffic
+// spec patch needed to put both releases at the given strategy step. It
+// returns nil once all the specs already match the step.
+func (s *Executor) buildFastRollbackPatches(strategyStep shipper.RolloutStrategyStep) []ExecutorResult {
+	var patches []ExecutorResult
+
+	if achieved, newSpec, _ := checkCapacity(s.contender.capacityTarget, uint(strategyStep.Capacity.Contender)); !achieved && newSpec != nil {
+		patches = append(patches, &CapacityTargetOutdatedResult{
+			NewSpec: newSpec,
+			Name:    s.contender.release.Name,
+		})
+	}
+
+	if achieved, newSpec, _ := checkTraffic(s.contender.trafficTarget, uint32(strategyStep.Traffic.Contender), trafficTierWeights(strategyStep.TrafficTiers, true), contenderTrafficComparison); !achieved && newSpec != nil {
+		patches = append(patches, &TrafficTargetOutdatedResult{
+			NewSpec: newSpec,
+			Name:    s.contender.release.Name,
+		})
+	}
+
+	if s.incumbent != nil {
+		if achieved, newSpec, _ := checkTraffic(s.incumbent.trafficTarget, uint32(strategyStep.Traffic.Incumbent), trafficTierWeights(strategyStep.TrafficTiers, false), incumbentTrafficComparison); !achieved && newSpec != nil {
+			patches = append(patches, &TrafficTargetOutdatedResult{
+				NewSpec: newSpec,
+				Name:    s.incumbent.release.Name,
+			})
+		}
+
+		if achieved, newSpec, _ := checkCapacity(s.incumbent.capacityTarget, uint(strategyStep.Capacity.Incumbent)); !achieved && newSpec != nil {
+			patches = append(patches, &CapacityTargetOutdatedResult{
+				NewSpec: newSpec,
+				Name:    s.incumbent.release.Name,
+			})
+		}
+	}
+
+	return patches
+}
+
+// stateDurationsShouldDiscardTimestamps keeps the wall-clock observation
+// timestamp out of computed strategy statuses so tests can compare patches
+// byte for byte, like the conditions packages' discard flags.
+var stateDurationsShouldDiscardTimestamps = false
+
+// accumulateStateDurations carries the per-state time accounting from the
+// contender's old strategy status into the new one: when the waiting state
+// transitions, every state that was True since the last transition gets the
+// elapsed time added to its bucket for the step being worked toward. Summed
+// across syncs this shows whether installs, capacity, traffic, or human
+// approvals dominate rollout time. Syncs that leave the state as it stands
+// touch neither the buckets nor the observation timestamp, so an idle
+// release's computed status stays identical to the live object and produces
+// no patch; the time spent in the state preceding the first transition goes
+// unmeasured.
+func (s *Executor) accumulateStateDurations(newStrategy *shipper.ReleaseStrategyStatus, step int32, now time.Time) {
+	oldStrategy := s.contender.release.Status.Strategy
+	if oldStrategy != nil {
+		newStrategy.StateDurations = append(newStrategy.StateDurations, oldStrategy.StateDurations...)
+		newStrategy.LastStateObservedAt = oldStrategy.LastStateObservedAt
+	}
+
+	if oldStrategy == nil || stateDurationsShouldDiscardTimestamps {
+		return
+	}
+
+	if newStrategy.State == oldStrategy.State {
+		return
+	}
+
+	observedAt := metav1.NewTime(now)
+	newStrategy.LastStateObservedAt = &observedAt
+
+	if oldStrategy.LastStateObservedAt == nil {
+		return
+	}
+
+	elapsed := now.Sub(oldStrategy.LastStateObservedAt.Time)
+	if elapsed <= 0 {
+		return
+	}
+
+	for _, waiting := range []struct {
+		state string
+		value shipper.StrategyState
+	}{
+		{"WaitingForInstallation", oldStrategy.State.WaitingForInstallation},
+		{"WaitingForCapacity", oldStrategy.State.WaitingForCapacity},
+		{"WaitingForTraffic", oldStrategy.State.WaitingForTraffic},
+		{"WaitingForCommand", oldStrategy.State.WaitingForCommand},
+	} {
+		if waiting.value != shipper.StrategyStateTrue {
+			continue
+		}
+
+		newStrategy.StateDurations = addStateDuration(newStrategy.StateDurations, step, waiting.state, elapsed)
+	}
+}
+
+func addStateDuration(durations []shipper.StrategyStateDuration, step int32, state string, elapsed time.Duration) []shipper.StrategyStateDuration {
+	for i := range durations {
+		if durations[i].Step == step && durations[i].State == state {
+			durations[i].Duration.Duration += elapsed
+			return durations
+		}
+	}
+
+	return append(durations, shipper.StrategyStateDuration{
+		Step:     step,
+		State:    state,
+		Duration: metav1.Duration{Duration: elapsed},
+	})
+}
+
 func getReleaseStrategyStateTransitions(
 	oldState shipper.ReleaseStrategyState,
 	newState shipper.ReleaseStrategyState,
@@ -361,6 +902,12 @@ func getReleaseStrategyStateTransitions(
 	if oldState.WaitingForTraffic != newState.WaitingForTraffic {
 		stateTransitions = append(stateTransitions, ReleaseStrategyStateTransition{State: "WaitingForTraffic", New: newState.WaitingForTraffic, Previous: valueOrUnknown(oldState.WaitingForCapacity)})
 	}
+	if oldState.WaitingForTrafficCommand != newState.WaitingForTrafficCommand {
+		stateTransitions = append(stateTransitions, ReleaseStrategyStateTransition{State: "WaitingForTrafficCommand", New: newState.WaitingForTrafficCommand, Previous: valueOrUnknown(oldState.WaitingForTrafficCommand)})
+	}
+	if oldState.WaitingForStability != newState.WaitingForStability {
+		stateTransitions = append(stateTransitions, ReleaseStrategyStateTransition{State: "WaitingForStability", New: newState.WaitingForStability, Previous: valueOrUnknown(oldState.WaitingForStability)})
+	}
 	return stateTransitions
 }
 
@@ -371,6 +918,48 @@ func valueOrUnknown(v shipper.StrategyState) shipper.StrategyState {
 	return v
 }
 
+// applyTrafficCommandState stamps the split command gate's state onto a
+// computed strategy state. Steps without ConfirmTraffic leave the field
+// empty, so the regular single-command states are untouched.
+func (s *Executor) applyTrafficCommandState(state *shipper.ReleaseStrategyState) {
+	if s.trafficCommandPending == nil {
+		return
+	}
+	if *s.trafficCommandPending {
+		state.WaitingForTrafficCommand = shipper.StrategyStateTrue
+	} else {
+		state.WaitingForTrafficCommand = shipper.StrategyStateFalse
+	}
+}
+
+// applyStabilityState stamps the stability policy's state onto a computed
+// strategy state. Strategies without a stability policy leave the field
+// empty.
+func (s *Executor) applyStabilityState(state *shipper.ReleaseStrategyState) {
+	if s.stabilityPending == nil {
+		return
+	}
+	if *s.stabilityPending {
+		state.WaitingForStability = shipper.StrategyStateTrue
+	} else {
+		state.WaitingForStability = shipper.StrategyStateFalse
+	}
+}
+
+// commandStatus renders the operator-facing summary of where the step
+// command stands: an issued advance held by an unmet precondition names the
+// reason, an achieved step awaits the next command, and a step actively
+// transitioning reports nothing.
+func (s *Executor) commandStatus(state shipper.ReleaseStrategyState) string {
+	if s.commandHoldReason != "" {
+		return fmt.Sprintf("command accepted but held because: %s", s.commandHoldReason)
+	}
+	if state.WaitingForCommand == shipper.StrategyStateTrue {
+		return "awaiting command"
+	}
+	return ""
+}
+
 func (s *Executor) buildContenderStrategyConditionsPatch(
 	c conditions.StrategyConditionsMap,
 	step int32,
@@ -380,9 +969,65 @@ func (s *Executor) buildContenderStrategyConditionsPatch(
 	newStatus.Strategy = &shipper.ReleaseStrategyStatus{
 		Conditions: c.AsReleaseStrategyConditions(),
 		State:      c.AsReleaseStrategyState(step, s.incumbent != nil, isLastStep),
+		Steps:      c.AsReleaseStrategyStepStatuses(s.strategy.Steps, step, s.incumbent != nil),
+	}
+	if s.gate != nil {
+		newStatus.Strategy.CurrentWave = s.gate.currentWave
+		newStatus.Strategy.WaveBakeUntil = s.gate.bakeUntil
+	}
+
+	// A condition flapping mid-soak (say, a pod dying on the final step)
+	// routes the sync through here instead of the step wrap up; the
+	// persisted soak deadline has to survive that, or the soak would
+	// silently restart from scratch.
+	if isLastStep && s.contender.release.Status.Strategy != nil {
+		newStatus.Strategy.SoakingUntil = s.contender.release.Status.Strategy.SoakingUntil
+	}
+
+	// The adaptive ramp's state survives syncs that bail out before the
+	// control loop runs (waves baking, pending installation) the same way:
+	// carry the persisted status forward so the ramp doesn't restart.
+	if s.adaptiveStatus != nil {
+		newStatus.Strategy.Adaptive = s.adaptiveStatus
+	} else if s.contender.release.Status.Strategy != nil {
+		newStatus.Strategy.Adaptive = s.contender.release.Status.Strategy.Adaptive
 	}
+	s.applyTrafficCommandState(&newStatus.Strategy.State)
+	s.applyStabilityState(&newStatus.Strategy.State)
+	newStatus.Strategy.CommandStatus = s.commandStatus(newStatus.Strategy.State)
+	s.accumulateStateDurations(newStatus.Strategy, step, time.Now())
+
+	// While the target step differs from the achieved step the release is
+	// mid-transition: surface the step it is working toward and how many of
+	// the step's conditions hold so far.
+	achieved := s.contender.release.Status.AchievedStep
+	newStatus.AchievingStep = nil
+	if achieved == nil || achieved.Step != step {
+		total := 3
+		if s.incumbent != nil {
+			total = 5
+		}
+		met := 0
+		for _, cond := range newStatus.Strategy.Conditions {
+			if cond.Step == step && cond.Status == corev1.ConditionTrue {
+				met++
+			}
+		}
+		newStatus.AchievingStep = &shipper.AchievingStep{
+			Step:     step,
+			Name:     s.strategy.Steps[step].Name,
+			Progress: fmt.Sprintf("%d/%d", met, total),
+		}
+	}
+
 	return &ReleaseUpdateResult{
 		NewStatus: newStatus,
 		Name:      s.contender.release.Name,
 	}
 }
+
+// canaryNamespaceName returns the isolated namespace a canary-namespace
+// strategy installs the given release into during all but the final step.
+func canaryNamespaceName(rel *shipper.Release) string {
+	return fmt.Sprintf("%s-canary", rel.Name)
+}
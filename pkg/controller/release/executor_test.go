@@ -2,7 +2,9 @@ package release
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -631,3 +633,384 @@ func firstConditionsFromPatches(patches []ExecutorResult) conditions.StrategyCon
 	}
 	return strategyConditions
 }
+
+// TestFastRollbackJumpsToTargetStep checks that a backward targetStep move on
+// a release annotated for fast rollback emits every capacity and traffic
+// patch in a single Execute call, instead of dwelling on each phase of the
+// intermediate steps in sequence.
+func TestFastRollbackJumpsToTargetStep(t *testing.T) {
+	totalReplicaCount := uint(10)
+	executor := &Executor{
+		contender: buildContender(totalReplicaCount),
+		incumbent: buildIncumbent(totalReplicaCount),
+		recorder:  record.NewFakeRecorder(42),
+		strategy:  vanguard,
+	}
+
+	// Put the rollout at "full on": contender owns all capacity and
+	// traffic, incumbent is fully stepped down.
+	executor.contender.release.Status.AchievedStep = &shipper.AchievedStep{Step: 2, Name: "full on"}
+	executor.contender.capacityTarget.Spec.Clusters[0].Percent = 100
+	executor.contender.capacityTarget.Status.Clusters[0].AchievedPercent = 100
+	executor.contender.capacityTarget.Status.Clusters[0].AvailableReplicas = int32(totalReplicaCount)
+	executor.contender.trafficTarget.Spec.Clusters[0].Weight = 100
+	executor.contender.trafficTarget.Status.Clusters[0].AchievedTraffic = 100
+
+	executor.incumbent.capacityTarget.Spec.Clusters[0].Percent = 0
+	executor.incumbent.capacityTarget.Status.Clusters[0].AchievedPercent = 0
+	executor.incumbent.trafficTarget.Spec.Clusters[0].Weight = 0
+	executor.incumbent.trafficTarget.Status.Clusters[0].AchievedTraffic = 0
+
+	// Roll back to "staging" with fast rollback enabled.
+	executor.contender.release.Annotations[shipper.ReleaseFastRollbackAnnotation] = shipper.True
+	executor.contender.release.Spec.TargetStep = 0
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var capacityPatches, trafficPatches int
+	for _, patch := range patches {
+		switch p := patch.(type) {
+		case *CapacityTargetOutdatedResult:
+			capacityPatches++
+			switch p.Name {
+			case contenderName:
+				if got := p.NewSpec.Clusters[0].Percent; got != 1 {
+					t.Errorf("expected contender capacity jump to 1, got %d", got)
+				}
+			case incumbentName:
+				if got := p.NewSpec.Clusters[0].Percent; got != 100 {
+					t.Errorf("expected incumbent capacity jump to 100, got %d", got)
+				}
+			}
+		case *TrafficTargetOutdatedResult:
+			trafficPatches++
+		}
+	}
+
+	if capacityPatches != 2 || trafficPatches != 2 {
+		t.Fatalf(
+			"expected a single Execute call to patch both releases' capacity and traffic (2+2), got %d capacity and %d traffic patches",
+			capacityPatches, trafficPatches,
+		)
+	}
+}
+
+// TestTrafficFirstOrdering checks that a TrafficFirst strategy produces a
+// different intermediate strategy state than the default: from the same
+// starting point, the first patch of a step shifts the contender's traffic
+// instead of its capacity.
+func TestTrafficFirstOrdering(t *testing.T) {
+	totalReplicaCount := uint(10)
+
+	trafficFirst := vanguard
+	trafficFirst.Ordering = shipper.StrategyOrderingTrafficFirst
+
+	executor := &Executor{
+		contender: buildContender(totalReplicaCount),
+		incumbent: buildIncumbent(totalReplicaCount),
+		recorder:  record.NewFakeRecorder(42),
+		strategy:  trafficFirst,
+	}
+
+	// Mimic patch to .spec.targetStep.
+	executor.contender.release.Spec.TargetStep = 1
+
+	// The first patch must adjust the contender's traffic, not its
+	// capacity.
+	if newSpec, err := ensureTrafficPatch(executor, contenderName, Contender); err != nil {
+		t.Fatal(err)
+	} else {
+		executor.contender.trafficTarget.Spec = *newSpec
+	}
+
+	// Mimic Traffic Controller patch to contender's
+	// .status.clusters.*.achievedTraffic.
+	for i := range executor.contender.trafficTarget.Status.Clusters {
+		executor.contender.trafficTarget.Status.Clusters[i].AchievedTraffic = 50
+	}
+
+	// Capacity follows once traffic is achieved.
+	if newSpec, err := ensureCapacityPatch(executor, contenderName, Contender); err != nil {
+		t.Fatal(err)
+	} else {
+		executor.contender.capacityTarget.Spec = *newSpec
+	}
+
+	// Mimic Capacity Controller patch to contender's
+	// .status.clusters.*.achievedPercent.
+	for i := range executor.contender.capacityTarget.Status.Clusters {
+		executor.contender.capacityTarget.Status.Clusters[i].AchievedPercent = 50
+		executor.contender.capacityTarget.Status.Clusters[i].AvailableReplicas = int32(replicas.CalculateDesiredReplicaCount(totalReplicaCount, 50))
+	}
+
+	// On the incumbent side the order is mirrored: capacity steps down
+	// before traffic.
+	if newSpec, err := ensureCapacityPatch(executor, incumbentName, Incumbent); err != nil {
+		t.Fatal(err)
+	} else {
+		executor.incumbent.capacityTarget.Spec = *newSpec
+	}
+
+	// Mimic Capacity Controller patch to incumbent's
+	// .status.clusters.*.achievedPercent.
+	for i := range executor.incumbent.capacityTarget.Status.Clusters {
+		executor.incumbent.capacityTarget.Status.Clusters[i].AchievedPercent = 50
+		executor.incumbent.capacityTarget.Status.Clusters[i].AvailableReplicas = int32(replicas.CalculateDesiredReplicaCount(totalReplicaCount, 50))
+	}
+
+	if newSpec, err := ensureTrafficPatch(executor, incumbentName, Incumbent); err != nil {
+		t.Fatal(err)
+	} else {
+		executor.incumbent.trafficTarget.Spec = *newSpec
+	}
+
+	// Mimic Traffic Controller patch to incumbent's
+	// .status.clusters.*.achievedTraffic.
+	for i := range executor.incumbent.trafficTarget.Status.Clusters {
+		executor.incumbent.trafficTarget.Status.Clusters[i].AchievedTraffic = 50
+	}
+
+	// The step wraps up exactly as it does under the default ordering.
+	if _, err := ensureReleasePatch(executor, contenderName); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAchievingStepReportedDuringTransition drives the first step of the
+// vanguard strategy phase by phase and checks that every release patch
+// emitted mid-transition reports the step being worked toward, with a
+// progress count of the strategy conditions met so far, and that the field
+// is cleared once the step is fully achieved.
+func TestAchievingStepReportedDuringTransition(t *testing.T) {
+	totalReplicaCount := uint(10)
+	executor := &Executor{
+		contender: buildContender(totalReplicaCount),
+		incumbent: buildIncumbent(totalReplicaCount),
+		recorder:  record.NewFakeRecorder(42),
+		strategy:  vanguard,
+	}
+
+	// The contender achieved "staging" and was told to move to "50/50".
+	executor.contender.release.Status.AchievedStep = &shipper.AchievedStep{Step: 0, Name: "staging"}
+	executor.contender.release.Spec.TargetStep = 1
+
+	executeAndCheckAchievingStep := func(expectedProgress string) []ExecutorResult {
+		patches, _, err := executor.Execute()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var releasePatch *ReleaseUpdateResult
+		for _, patch := range patches {
+			if p, ok := patch.(*ReleaseUpdateResult); ok {
+				releasePatch = p
+			}
+		}
+		if releasePatch == nil {
+			t.Fatal("expected a ReleaseUpdateResult patch")
+		}
+
+		achieving := releasePatch.NewStatus.AchievingStep
+		if achieving == nil {
+			t.Fatal("expected an achieving step while mid-transition")
+		}
+		if achieving.Step != 1 || achieving.Name != "50/50" {
+			t.Fatalf("expected achieving step 1 (50/50), got %d (%s)", achieving.Step, achieving.Name)
+		}
+		if achieving.Progress != expectedProgress {
+			t.Fatalf("expected progress %q, got %q", expectedProgress, achieving.Progress)
+		}
+
+		return patches
+	}
+
+	// Contender capacity is the first pending phase: only the contender's
+	// installation condition holds so far.
+	patches := executeAndCheckAchievingStep("1/5")
+	if p, ok := patches[0].(*CapacityTargetOutdatedResult); !ok {
+		t.Fatal("expected a CapacityTargetOutdatedResult, got something else")
+	} else {
+		executor.contender.capacityTarget.Spec = *p.NewSpec
+	}
+
+	// Mimic Capacity Controller patch to contender's
+	// .status.clusters.*.achievedPercent.
+	for i := range executor.contender.capacityTarget.Status.Clusters {
+		executor.contender.capacityTarget.Status.Clusters[i].AchievedPercent = 50
+		executor.contender.capacityTarget.Status.Clusters[i].AvailableReplicas = int32(replicas.CalculateDesiredReplicaCount(totalReplicaCount, 50))
+	}
+
+	// Contender traffic: installation and contender capacity now hold.
+	patches = executeAndCheckAchievingStep("2/5")
+	if p, ok := patches[0].(*TrafficTargetOutdatedResult); !ok {
+		t.Fatal("expected a TrafficTargetOutdatedResult, got something else")
+	} else {
+		executor.contender.trafficTarget.Spec = *p.NewSpec
+	}
+
+	// Mimic Traffic Controller patch to contender's
+	// .status.clusters.*.achievedTraffic.
+	for i := range executor.contender.trafficTarget.Status.Clusters {
+		executor.contender.trafficTarget.Status.Clusters[i].AchievedTraffic = 50
+	}
+
+	// Incumbent traffic: three of the five conditions hold.
+	patches = executeAndCheckAchievingStep("3/5")
+	if p, ok := patches[0].(*TrafficTargetOutdatedResult); !ok {
+		t.Fatal("expected a TrafficTargetOutdatedResult, got something else")
+	} else {
+		executor.incumbent.trafficTarget.Spec = *p.NewSpec
+	}
+
+	// Mimic Traffic Controller patch to incumbent's
+	// .status.clusters.*.achievedTraffic.
+	for i := range executor.incumbent.trafficTarget.Status.Clusters {
+		executor.incumbent.trafficTarget.Status.Clusters[i].AchievedTraffic = 50
+	}
+
+	// Incumbent capacity: only one condition left.
+	patches = executeAndCheckAchievingStep("4/5")
+	if p, ok := patches[0].(*CapacityTargetOutdatedResult); !ok {
+		t.Fatal("expected a CapacityTargetOutdatedResult, got something else")
+	} else {
+		executor.incumbent.capacityTarget.Spec = *p.NewSpec
+	}
+
+	// Mimic Capacity Controller patch to incumbent's
+	// .status.clusters.*.achievedPercent.
+	for i := range executor.incumbent.capacityTarget.Status.Clusters {
+		executor.incumbent.capacityTarget.Status.Clusters[i].AchievedPercent = 50
+		executor.incumbent.capacityTarget.Status.Clusters[i].AvailableReplicas = int32(replicas.CalculateDesiredReplicaCount(totalReplicaCount, 50))
+	}
+
+	// All five conditions hold: the step is achieved and the transition is
+	// over.
+	newStatus, err := ensureReleasePatch(executor, contenderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newStatus.AchievedStep == nil || newStatus.AchievedStep.Step != 1 {
+		t.Fatalf("expected achieved step 1, got %v", newStatus.AchievedStep)
+	}
+	if newStatus.AchievingStep != nil {
+		t.Fatalf("expected achieving step to be cleared once the step is achieved, got %v", newStatus.AchievingStep)
+	}
+}
+
+// TestStateDurationsAccumulated drives a mid-rollout contender through state
+// transitions and checks that the time spent waiting lands in the right
+// per-state buckets: two minutes awaiting the operator's command, then a
+// minute waiting for capacity, with earlier buckets preserved. A sync that
+// leaves the state as it stands must touch neither the buckets nor the
+// observation timestamp.
+func TestStateDurationsAccumulated(t *testing.T) {
+	defer func(prev bool) { stateDurationsShouldDiscardTimestamps = prev }(stateDurationsShouldDiscardTimestamps)
+	stateDurationsShouldDiscardTimestamps = false
+
+	totalReplicaCount := uint(10)
+	executor := &Executor{
+		contender: buildContender(totalReplicaCount),
+		incumbent: buildIncumbent(totalReplicaCount),
+		recorder:  record.NewFakeRecorder(42),
+		strategy:  vanguard,
+	}
+
+	// The contender achieved "staging" and was told to move to "50/50".
+	executor.contender.release.Status.AchievedStep = &shipper.AchievedStep{Step: 0, Name: "staging"}
+	executor.contender.release.Spec.TargetStep = 1
+
+	findReleasePatch := func() *ReleaseUpdateResult {
+		patches, _, err := executor.Execute()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, patch := range patches {
+			if p, ok := patch.(*ReleaseUpdateResult); ok {
+				return p
+			}
+		}
+
+		t.Fatal("expected a ReleaseUpdateResult patch")
+		return nil
+	}
+
+	findDuration := func(durations []shipper.StrategyStateDuration, step int32, state string) time.Duration {
+		for _, d := range durations {
+			if d.Step == step && d.State == state {
+				return d.Duration.Duration
+			}
+		}
+		return 0
+	}
+
+	// The release sat at "staging" awaiting the operator's command for two
+	// minutes before being told to move on; this sync computes
+	// WaitingForCapacity, so the transition attributes those two minutes
+	// to the command bucket.
+	observedAt := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	executor.contender.release.Status.Strategy = &shipper.ReleaseStrategyStatus{
+		State: shipper.ReleaseStrategyState{
+			WaitingForInstallation: shipper.StrategyStateFalse,
+			WaitingForCapacity:     shipper.StrategyStateFalse,
+			WaitingForTraffic:      shipper.StrategyStateFalse,
+			WaitingForCommand:      shipper.StrategyStateTrue,
+		},
+		LastStateObservedAt: &observedAt,
+	}
+
+	releasePatch := findReleasePatch()
+	durations := releasePatch.NewStatus.Strategy.StateDurations
+
+	if got := findDuration(durations, 1, "WaitingForCommand"); got < 2*time.Minute {
+		t.Errorf("expected at least two minutes accumulated for WaitingForCommand at step 1, got %s", got)
+	}
+	for _, state := range []string{"WaitingForInstallation", "WaitingForCapacity", "WaitingForTraffic"} {
+		if got := findDuration(durations, 1, state); got != 0 {
+			t.Errorf("expected no time accumulated for %s, got %s", state, got)
+		}
+	}
+	if releasePatch.NewStatus.Strategy.LastStateObservedAt == nil ||
+		!releasePatch.NewStatus.Strategy.LastStateObservedAt.After(observedAt.Time) {
+		t.Error("expected the observation timestamp to move forward")
+	}
+
+	// Syncing again without any change keeps the computed status stable:
+	// no fresh timestamp, no extra duration, hence no patch churn.
+	executor.contender.release.Status = *releasePatch.NewStatus
+	stampedAt := *releasePatch.NewStatus.Strategy.LastStateObservedAt
+
+	releasePatch = findReleasePatch()
+	if got := releasePatch.NewStatus.Strategy.LastStateObservedAt; got == nil || !got.Equal(&stampedAt) {
+		t.Errorf("expected the observation timestamp to stay at %s while the state is unchanged, got %v", stampedAt, got)
+	}
+	if got, want := releasePatch.NewStatus.Strategy.StateDurations, durations; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the duration buckets to stay untouched while the state is unchanged, got %v, want %v", got, want)
+	}
+
+	// The capacity wait resolved a minute later; that time goes to its own
+	// bucket and the command bucket stays put.
+	executor.contender.release.Status = *releasePatch.NewStatus
+	commandWait := findDuration(durations, 1, "WaitingForCommand")
+
+	observedAt = metav1.NewTime(time.Now().Add(-time.Minute))
+	executor.contender.release.Status.Strategy.LastStateObservedAt = &observedAt
+	executor.contender.capacityTarget.Spec.Clusters[0].Percent = 50
+	for i := range executor.contender.capacityTarget.Status.Clusters {
+		executor.contender.capacityTarget.Status.Clusters[i].AchievedPercent = 50
+		executor.contender.capacityTarget.Status.Clusters[i].AvailableReplicas = int32(replicas.CalculateDesiredReplicaCount(totalReplicaCount, 50))
+	}
+
+	releasePatch = findReleasePatch()
+	durations = releasePatch.NewStatus.Strategy.StateDurations
+
+	if got := findDuration(durations, 1, "WaitingForCapacity"); got < time.Minute {
+		t.Errorf("expected at least a minute accumulated for WaitingForCapacity at step 1, got %s", got)
+	}
+	if got := findDuration(durations, 1, "WaitingForCommand"); got != commandWait {
+		t.Errorf("expected the WaitingForCommand bucket to stay at %s, got %s", commandWait, got)
+	}
+}
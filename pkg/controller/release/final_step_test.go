@@ -0,0 +1,206 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// buildFinalStepExecutor returns an executor for a contender sitting at the
+// last step of a two-step strategy with a one-hour final-step policy, its
+// single cluster already at full capacity and traffic.
+func buildFinalStepExecutor() *Executor {
+	strategy := shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "staging",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+			},
+			{
+				Name:     "full on",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+			},
+		},
+		FinalStepPolicy: &shipper.FinalStepPolicy{
+			SoakTime:       metav1.Duration{Duration: time.Hour},
+			RollbackToStep: 0,
+		},
+	}
+
+	release := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: 1,
+			Environment: shipper.ReleaseEnvironment{
+				Strategy: &strategy,
+			},
+		},
+	}
+
+	installationTarget := &shipper.InstallationTarget{
+		Spec: shipper.InstallationTargetSpec{
+			Clusters: []string{"minikube"},
+		},
+		Status: shipper.InstallationTargetStatus{
+			Clusters: []*shipper.ClusterInstallationStatus{
+				{Name: "minikube", Status: shipper.ReleasePhaseInstalled},
+			},
+		},
+	}
+
+	capacityTarget := &shipper.CapacityTarget{
+		Spec: shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "minikube", Percent: 100, TotalReplicaCount: 10},
+			},
+		},
+		Status: shipper.CapacityTargetStatus{
+			Clusters: []shipper.ClusterCapacityStatus{
+				{Name: "minikube", AchievedPercent: 100, AvailableReplicas: 10},
+			},
+		},
+	}
+
+	trafficTarget := &shipper.TrafficTarget{
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "minikube", Weight: 100},
+			},
+		},
+		Status: shipper.TrafficTargetStatus{
+			Clusters: []*shipper.ClusterTrafficStatus{
+				{Name: "minikube", AchievedTraffic: 100},
+			},
+		},
+	}
+
+	return &Executor{
+		contender: &releaseInfo{
+			release:            release,
+			installationTarget: installationTarget,
+			capacityTarget:     capacityTarget,
+			trafficTarget:      trafficTarget,
+		},
+		recorder: record.NewFakeRecorder(42),
+		strategy: strategy,
+	}
+}
+
+// releaseStatusPatch digs the release status patch out of an executor's
+// results, failing the test when there isn't exactly one.
+func releaseStatusPatch(t *testing.T, patches []ExecutorResult) *ReleaseUpdateResult {
+	t.Helper()
+
+	var result *ReleaseUpdateResult
+	for _, patch := range patches {
+		if p, ok := patch.(*ReleaseUpdateResult); ok {
+			if result != nil {
+				t.Fatal("expected a single release status patch, got several")
+			}
+			result = p
+		}
+	}
+
+	if result == nil {
+		t.Fatal("expected a release status patch, got none")
+	}
+	return result
+}
+
+// hasCompleteCondition reports whether a release status carries a Complete
+// condition set to True.
+func hasCompleteCondition(status *shipper.ReleaseStatus) bool {
+	for _, condition := range status.Conditions {
+		if condition.Type == shipper.ReleaseConditionTypeComplete && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// TestFinalStepPolicySoaksThenCompletes drives a healthy contender through
+// the final-step policy: the first sync at the last step only records the
+// soak deadline, and once that deadline passes the release is completed.
+func TestFinalStepPolicySoaksThenCompletes(t *testing.T) {
+	t0 := time.Now()
+	waveNow = func() time.Time { return t0 }
+	defer func() { waveNow = time.Now }()
+
+	executor := buildFinalStepExecutor()
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusPatch := releaseStatusPatch(t, patches)
+
+	soakingUntil := statusPatch.NewStatus.Strategy.SoakingUntil
+	if soakingUntil == nil || !soakingUntil.Time.Equal(t0.Add(time.Hour)) {
+		t.Errorf("expected the status to soak until %s, got %v", t0.Add(time.Hour), soakingUntil)
+	}
+
+	if hasCompleteCondition(statusPatch.NewStatus) {
+		t.Error("expected the release to stay incomplete while soaking")
+	}
+
+	if remaining := executor.soakRemaining(); remaining != time.Hour {
+		t.Errorf("expected an hour of soaking left, got %s", remaining)
+	}
+
+	// The soak period has passed with the checks still green: this sync
+	// marks the release complete.
+	executor = buildFinalStepExecutor()
+	soakedAt := metav1.NewTime(t0.Add(-time.Minute))
+	executor.contender.release.Status.Strategy = &shipper.ReleaseStrategyStatus{
+		SoakingUntil: &soakedAt,
+	}
+
+	patches, _, err = executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusPatch = releaseStatusPatch(t, patches)
+	if !hasCompleteCondition(statusPatch.NewStatus) {
+		t.Error("expected the release to be complete after the soak period")
+	}
+}
+
+// TestFinalStepPolicyRollsBackOnFailedChecks puts sad pods on the soaking
+// contender and checks that the executor answers with a target step rollback
+// instead of completing the release.
+func TestFinalStepPolicyRollsBackOnFailedChecks(t *testing.T) {
+	executor := buildFinalStepExecutor()
+	executor.contender.capacityTarget.Status.Clusters[0].SadPods = []shipper.PodStatus{
+		{Name: "test-release-deadbeef-broken"},
+	}
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patches) != 1 {
+		t.Fatalf("expected only a rollback patch, got %d patches", len(patches))
+	}
+
+	rollback, ok := patches[0].(*ReleaseRollbackResult)
+	if !ok {
+		t.Fatalf("expected a release rollback patch, got %T", patches[0])
+	}
+
+	if rollback.TargetStep != 0 {
+		t.Errorf("expected a rollback to step 0, got step %d", rollback.TargetStep)
+	}
+}
@@ -0,0 +1,43 @@
+package release
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// finalStepChecksPass reports whether the contender looks healthy enough for
+// the final-step policy to complete the release on its own: no cluster is
+// reporting sad pods, and no release condition has gone False.
+func (s *Executor) finalStepChecksPass() bool {
+	for _, cluster := range s.contender.capacityTarget.Status.Clusters {
+		if len(cluster.SadPods) > 0 {
+			return false
+		}
+	}
+
+	return !releaseutil.ReleaseFailed(s.contender.release)
+}
+
+// resolveSoakDeadline returns the end of the final step's soak period, either
+// the one already recorded in the release's status or a fresh one computed
+// from the policy's soak time.
+func (s *Executor) resolveSoakDeadline() *metav1.Time {
+	if s.contender.release.Status.Strategy != nil && s.contender.release.Status.Strategy.SoakingUntil != nil {
+		return s.contender.release.Status.Strategy.SoakingUntil
+	}
+
+	t := metav1.NewTime(waveNow().Add(s.strategy.FinalStepPolicy.SoakTime.Duration))
+	return &t
+}
+
+// soakRemaining returns how long the final step's soak period still has to
+// run, or zero when the release isn't soaking.
+func (s *Executor) soakRemaining() time.Duration {
+	if s.soakingUntil == nil {
+		return 0
+	}
+	return s.soakingUntil.Sub(waveNow())
+}
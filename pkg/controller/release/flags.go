@@ -0,0 +1,74 @@
+package release
+
+import (
+	"fmt"
+	"time"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/conditions"
+)
+
+// A FlagProvider applies a strategy step's feature-flag changes to an
+// external feature-flag system. Implementations must be idempotent: the
+// executor re-applies the step's flags every sync until all of them are
+// accepted.
+type FlagProvider interface {
+	// SetFlag drives the named flag (optionally scoped to a segment) to the
+	// given rollout percentage. A non-nil error means the provider hasn't
+	// accepted the change; the step is held back and the call retried on
+	// the next sync.
+	SetFlag(name, segment string, percent int32) error
+}
+
+// noopFlagProvider accepts every flag change without talking to anything. It
+// is the default, so strategies without feature flags behave exactly as
+// before.
+type noopFlagProvider struct{}
+
+func (noopFlagProvider) SetFlag(name, segment string, percent int32) error {
+	return nil
+}
+
+// ensureFeatureFlags drives the step's feature flags to the step's contender
+// traffic percentage through the configured provider. It returns the patches
+// to apply while any flag change remains unaccepted, or nil once every flag
+// is in place; the step is not reported achieved until then, so flag state
+// never trails the traffic it gates.
+func (s *Executor) ensureFeatureFlags(
+	strategyConditions conditions.StrategyConditionsMap,
+	strategyStep shipper.RolloutStrategyStep,
+	targetStep int32,
+	isLastStep bool,
+	lastTransitionTime time.Time,
+) []ExecutorResult {
+	if len(strategyStep.FeatureFlags) == 0 {
+		return nil
+	}
+
+	percent := strategyStep.Traffic.Contender
+
+	var pending []string
+	for _, flag := range strategyStep.FeatureFlags {
+		if err := s.flagProvider.SetFlag(flag.Name, flag.Segment, percent); err != nil {
+			s.info("feature flag %q not accepted by the provider: %s", flag.Name, err)
+			pending = append(pending, flag.Name)
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	s.commandHoldReason = fmt.Sprintf("feature flags pending: %v", pending)
+
+	strategyConditions.SetFalse(
+		shipper.StrategyConditionContenderAchievedTraffic,
+		conditions.StrategyConditionsUpdate{
+			Reason:             conditions.WaitingForFeatureFlags,
+			Message:            fmt.Sprintf("feature flags pending provider acceptance: %v", pending),
+			Step:               targetStep,
+			LastTransitionTime: lastTransitionTime,
+		})
+
+	return []ExecutorResult{s.buildContenderStrategyConditionsPatch(strategyConditions, targetStep, isLastStep)}
+}
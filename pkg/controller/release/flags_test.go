@@ -0,0 +1,169 @@
+package release
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/conditions"
+)
+
+type flagCall struct {
+	name    string
+	segment string
+	percent int32
+}
+
+// fakeFlagProvider records every SetFlag call and answers each with its
+// configured error.
+type fakeFlagProvider struct {
+	calls []flagCall
+	err   error
+}
+
+func (p *fakeFlagProvider) SetFlag(name, segment string, percent int32) error {
+	p.calls = append(p.calls, flagCall{name: name, segment: segment, percent: percent})
+	return p.err
+}
+
+// buildFlaggedExecutor returns an executor for a contender at the only step
+// of a single-step strategy carrying a feature flag, its single cluster
+// already at the step's capacity and traffic.
+func buildFlaggedExecutor(provider FlagProvider) *Executor {
+	strategy := shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "canary",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 10},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 10},
+				FeatureFlags: []shipper.RolloutStrategyFeatureFlag{
+					{Name: "new-checkout", Segment: "beta"},
+				},
+			},
+		},
+	}
+
+	release := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: 0,
+			Environment: shipper.ReleaseEnvironment{
+				Strategy: &strategy,
+			},
+		},
+	}
+
+	installationTarget := &shipper.InstallationTarget{
+		Spec: shipper.InstallationTargetSpec{
+			Clusters: []string{"minikube"},
+		},
+		Status: shipper.InstallationTargetStatus{
+			Clusters: []*shipper.ClusterInstallationStatus{
+				{Name: "minikube", Status: shipper.ReleasePhaseInstalled},
+			},
+		},
+	}
+
+	capacityTarget := &shipper.CapacityTarget{
+		Spec: shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "minikube", Percent: 10, TotalReplicaCount: 10},
+			},
+		},
+		Status: shipper.CapacityTargetStatus{
+			Clusters: []shipper.ClusterCapacityStatus{
+				{Name: "minikube", AchievedPercent: 10, AvailableReplicas: 1},
+			},
+		},
+	}
+
+	trafficTarget := &shipper.TrafficTarget{
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "minikube", Weight: 10},
+			},
+		},
+		Status: shipper.TrafficTargetStatus{
+			Clusters: []*shipper.ClusterTrafficStatus{
+				{Name: "minikube", AchievedTraffic: 10},
+			},
+		},
+	}
+
+	return &Executor{
+		contender: &releaseInfo{
+			release:            release,
+			installationTarget: installationTarget,
+			capacityTarget:     capacityTarget,
+			trafficTarget:      trafficTarget,
+		},
+		recorder:     record.NewFakeRecorder(42),
+		strategy:     strategy,
+		flagProvider: provider,
+	}
+}
+
+// TestFeatureFlagSetBeforeStepAchieved checks that the step's flag is driven
+// to the step's contender traffic percentage through the provider, and that
+// the step is reported achieved once the provider accepts the change.
+func TestFeatureFlagSetBeforeStepAchieved(t *testing.T) {
+	provider := &fakeFlagProvider{}
+	executor := buildFlaggedExecutor(provider)
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(provider.calls) != 1 {
+		t.Fatalf("expected exactly one SetFlag call, got %d", len(provider.calls))
+	}
+
+	expected := flagCall{name: "new-checkout", segment: "beta", percent: 10}
+	if provider.calls[0] != expected {
+		t.Errorf("expected SetFlag call %+v, got %+v", expected, provider.calls[0])
+	}
+
+	statusPatch := releaseStatusPatch(t, patches)
+	achieved := statusPatch.NewStatus.AchievedStep
+	if achieved == nil || achieved.Step != 0 || achieved.Name != "canary" {
+		t.Errorf(`expected achieved step 0 "canary", got %v`, achieved)
+	}
+}
+
+// TestFeatureFlagFailureHoldsStep checks that a flag change the provider
+// rejects keeps the step from being reported achieved, with a strategy
+// condition naming the pending flag.
+func TestFeatureFlagFailureHoldsStep(t *testing.T) {
+	provider := &fakeFlagProvider{err: errors.New("provider unavailable")}
+	executor := buildFlaggedExecutor(provider)
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusPatch := releaseStatusPatch(t, patches)
+	if statusPatch.NewStatus.AchievedStep != nil {
+		t.Errorf("expected no achieved step while the flag change is pending, got %v", statusPatch.NewStatus.AchievedStep)
+	}
+
+	var waiting bool
+	for _, condition := range statusPatch.NewStatus.Strategy.Conditions {
+		if condition.Type == shipper.StrategyConditionContenderAchievedTraffic &&
+			condition.Reason == conditions.WaitingForFeatureFlags {
+			waiting = true
+		}
+	}
+	if !waiting {
+		t.Errorf("expected a %s condition with reason %s, got %v",
+			shipper.StrategyConditionContenderAchievedTraffic, conditions.WaitingForFeatureFlags,
+			statusPatch.NewStatus.Strategy.Conditions)
+	}
+}
@@ -0,0 +1,65 @@
+package release
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/conditions"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// executeInstallOnly drives a release whose strategy is install-only: no
+// capacity or traffic targets exist for it, so nothing gates completion but
+// installation itself. The release is marked complete as soon as every
+// cluster reports the chart installed, which is what batch jobs and other
+// non-serving workloads want instead of a trivial single-step strategy.
+func (s *Executor) executeInstallOnly() ([]ExecutorResult, []ReleaseStrategyStateTransition, error) {
+	var releaseStrategyConditions []shipper.ReleaseStrategyCondition
+	if s.contender.release.Status.Strategy != nil {
+		releaseStrategyConditions = s.contender.release.Status.Strategy.Conditions
+	}
+	strategyConditions := conditions.NewStrategyConditions(releaseStrategyConditions...)
+
+	lastTransitionTime := time.Now()
+
+	newStatus := s.contender.release.Status.DeepCopy()
+
+	if ready, clusters := checkInstallation(s.contender); !ready {
+		s.info("install-only release is pending installation")
+
+		strategyConditions.SetFalse(
+			shipper.StrategyConditionContenderAchievedInstallation,
+			conditions.StrategyConditionsUpdate{
+				Reason:             conditions.ClustersNotReady,
+				Message:            fmt.Sprintf("clusters pending installation: %v", clusters),
+				Step:               0,
+				LastTransitionTime: lastTransitionTime,
+			})
+	} else {
+		s.info("install-only release has achieved installation, marking it complete")
+
+		strategyConditions.SetTrue(
+			shipper.StrategyConditionContenderAchievedInstallation,
+			conditions.StrategyConditionsUpdate{
+				Step:               0,
+				LastTransitionTime: lastTransitionTime,
+			})
+
+		condition := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", "")
+		releaseutil.SetReleaseCondition(newStatus, *condition)
+	}
+
+	newStatus.Strategy = &shipper.ReleaseStrategyStatus{
+		Conditions: strategyConditions.AsReleaseStrategyConditions(),
+		State:      strategyConditions.AsReleaseStrategyState(0, s.incumbent != nil, true),
+	}
+	newStatus.ObservedGeneration = s.contender.release.Generation
+
+	return []ExecutorResult{&ReleaseUpdateResult{
+		NewStatus: newStatus,
+		Name:      s.contender.release.Name,
+	}}, nil, nil
+}
@@ -0,0 +1,100 @@
+package release
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// buildInstallOnlyExecutor returns an executor for a release with an
+// install-only strategy: only an installation target exists, reporting the
+// given phase for the release's single cluster. No capacity or traffic
+// targets exist at all, mirroring what the scheduler creates for such
+// releases.
+func buildInstallOnlyExecutor(installationStatus string) *Executor {
+	strategy := shipper.RolloutStrategy{
+		InstallOnly: true,
+	}
+
+	release := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+		},
+		Spec: shipper.ReleaseSpec{
+			Environment: shipper.ReleaseEnvironment{
+				Strategy: &strategy,
+			},
+		},
+	}
+
+	installationTarget := &shipper.InstallationTarget{
+		Spec: shipper.InstallationTargetSpec{
+			Clusters: []string{"minikube"},
+		},
+		Status: shipper.InstallationTargetStatus{
+			Clusters: []*shipper.ClusterInstallationStatus{
+				{Name: "minikube", Status: installationStatus},
+			},
+		},
+	}
+
+	return &Executor{
+		contender: &releaseInfo{
+			release:            release,
+			installationTarget: installationTarget,
+		},
+		recorder: record.NewFakeRecorder(42),
+		strategy: strategy,
+	}
+}
+
+// TestInstallOnlyCompletesAfterInstallation drives an install-only release
+// through both halves of its life: while installation is pending the release
+// stays incomplete, and once every cluster reports the chart installed it is
+// marked complete. Neither sync touches capacity or traffic targets -- none
+// exist for the release, so any such patch would be a bug.
+func TestInstallOnlyCompletesAfterInstallation(t *testing.T) {
+	executor := buildInstallOnlyExecutor("Installing")
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, patch := range patches {
+		switch patch.(type) {
+		case *CapacityTargetOutdatedResult, *TrafficTargetOutdatedResult:
+			t.Fatalf("install-only release produced a capacity/traffic patch: %v", patch)
+		}
+	}
+
+	statusPatch := releaseStatusPatch(t, patches)
+	if hasCompleteCondition(statusPatch.NewStatus) {
+		t.Error("expected the release to stay incomplete while installation is pending")
+	}
+
+	// Every cluster reports the chart installed: the next sync completes
+	// the release, still without any capacity or traffic target churn.
+	executor = buildInstallOnlyExecutor(shipper.ReleasePhaseInstalled)
+
+	patches, _, err = executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, patch := range patches {
+		switch patch.(type) {
+		case *CapacityTargetOutdatedResult, *TrafficTargetOutdatedResult:
+			t.Fatalf("install-only release produced a capacity/traffic patch: %v", patch)
+		}
+	}
+
+	statusPatch = releaseStatusPatch(t, patches)
+	if !hasCompleteCondition(statusPatch.NewStatus) {
+		t.Error("expected the release to be complete once installation is achieved")
+	}
+}
@@ -0,0 +1,206 @@
+package release
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperchart "github.com/bookingcom/shipper/pkg/chart"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	"github.com/bookingcom/shipper/pkg/util/replicas"
+)
+
+// Plan action verbs, in the order they appear in a rollout.
+const (
+	PlanVerbCreateRelease = "CreateRelease"
+	PlanVerbInstall       = "Install"
+	PlanVerbSetCapacity   = "SetCapacity"
+	PlanVerbSetTraffic    = "SetTraffic"
+)
+
+// PlanStepPreRollout marks actions taken before the strategy's first step,
+// like creating the release itself.
+const PlanStepPreRollout int32 = -1
+
+// A RolloutPlan is an ordered, machine-readable description of the actions
+// shipper would take to roll out an application's current template: which
+// release gets created, onto which clusters, and what each strategy step
+// does to capacity and traffic. It is computed dry, without creating or
+// mutating anything.
+type RolloutPlan struct {
+	Application string              `json:"application"`
+	Release     string              `json:"release"`
+	Clusters    []string            `json:"clusters"`
+	Actions     []RolloutPlanAction `json:"actions"`
+}
+
+// A RolloutPlanAction is one action in a RolloutPlan. Step is the strategy
+// step the action belongs to, or PlanStepPreRollout for actions taken before
+// the strategy starts. CapacityPercent and Replicas are set for SetCapacity
+// actions (Replicas only when the pod count is known, i.e. for the new
+// release), and TrafficWeight for SetTraffic actions.
+type RolloutPlanAction struct {
+	Step     int32    `json:"step"`
+	StepName string   `json:"stepName,omitempty"`
+	Verb     string   `json:"verb"`
+	Release  string   `json:"release"`
+	Clusters []string `json:"clusters,omitempty"`
+
+	CapacityPercent *int32 `json:"capacityPercent,omitempty"`
+	Replicas        *int32 `json:"replicas,omitempty"`
+	TrafficWeight   *int32 `json:"trafficWeight,omitempty"`
+}
+
+// BuildRolloutPlan computes the RolloutPlan for rolling out app's current
+// template as releaseName. It reuses the scheduler's cluster selection and
+// the chart's replica count, and mirrors the executor's per-step ordering
+// (contender capacity, contender traffic, incumbent traffic, incumbent
+// capacity), but executes nothing: it is the rollout equivalent of a
+// "terraform plan". The incumbent may be nil for an application's first
+// release.
+func BuildRolloutPlan(
+	app *shipper.Application,
+	incumbent *shipper.Release,
+	releaseName string,
+	clusterList []*shipper.Cluster,
+	fetchChart shipperchart.FetchFunc,
+) (*RolloutPlan, error) {
+	// A synthetic release carrying the template stands in for the one the
+	// application controller would create; cluster selection and chart
+	// rendering only look at its environment, labels and owner.
+	rel := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      releaseName,
+			Namespace: app.Namespace,
+			Labels: map[string]string{
+				shipper.AppLabel:     app.Name,
+				shipper.ReleaseLabel: releaseName,
+			},
+			Annotations: map[string]string{},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: shipper.SchemeGroupVersion.String(),
+					Kind:       "Application",
+					Name:       app.Name,
+					UID:        app.UID,
+				},
+			},
+		},
+		Spec: shipper.ReleaseSpec{
+			Environment: *app.Spec.Template.DeepCopy(),
+		},
+	}
+
+	strategy := rel.Spec.Environment.Strategy
+	if strategy == nil || len(strategy.Steps) == 0 {
+		return nil, shippererrors.NewUnrecoverableError(fmt.Errorf(
+			"application %q has no strategy steps to plan", app.Name))
+	}
+
+	selectedClusters, err := computeTargetClusters(rel, clusterList)
+	if err != nil {
+		return nil, err
+	}
+	setReleaseClusters(rel, selectedClusters)
+	clusterNames := getReleaseClusters(rel)
+
+	chart, err := fetchChart(rel.Spec.Environment.Chart)
+	if err != nil {
+		return nil, shippererrors.NewChartFetchFailureError(
+			rel.Spec.Environment.Chart.Name,
+			rel.Spec.Environment.Chart.Version,
+			rel.Spec.Environment.Chart.RepoURL,
+			err,
+		)
+	}
+
+	totalReplicaCount, err := extractReplicasFromChartForRel(chart, rel)
+	if err != nil {
+		return nil, err
+	}
+
+	var incumbentClusters []string
+	if incumbent != nil {
+		incumbentClusters = getReleaseClusters(incumbent)
+	}
+
+	actions := []RolloutPlanAction{
+		{
+			Step:     PlanStepPreRollout,
+			Verb:     PlanVerbCreateRelease,
+			Release:  releaseName,
+			Clusters: clusterNames,
+		},
+	}
+
+	for i, step := range strategy.Steps {
+		stepIndex := int32(i)
+
+		if i == 0 {
+			actions = append(actions, RolloutPlanAction{
+				Step:     stepIndex,
+				StepName: step.Name,
+				Verb:     PlanVerbInstall,
+				Release:  releaseName,
+				Clusters: clusterNames,
+			})
+		}
+
+		contenderPercent := step.Capacity.Contender
+		contenderReplicas := int32(replicas.CalculateDesiredReplicaCount(
+			uint(totalReplicaCount), float64(contenderPercent)))
+		contenderWeight := step.Traffic.Contender
+
+		actions = append(actions,
+			RolloutPlanAction{
+				Step:            stepIndex,
+				StepName:        step.Name,
+				Verb:            PlanVerbSetCapacity,
+				Release:         releaseName,
+				Clusters:        clusterNames,
+				CapacityPercent: &contenderPercent,
+				Replicas:        &contenderReplicas,
+			},
+			RolloutPlanAction{
+				Step:          stepIndex,
+				StepName:      step.Name,
+				Verb:          PlanVerbSetTraffic,
+				Release:       releaseName,
+				Clusters:      clusterNames,
+				TrafficWeight: &contenderWeight,
+			},
+		)
+
+		if incumbent != nil {
+			incumbentPercent := step.Capacity.Incumbent
+			incumbentWeight := step.Traffic.Incumbent
+
+			actions = append(actions,
+				RolloutPlanAction{
+					Step:          stepIndex,
+					StepName:      step.Name,
+					Verb:          PlanVerbSetTraffic,
+					Release:       incumbent.Name,
+					Clusters:      incumbentClusters,
+					TrafficWeight: &incumbentWeight,
+				},
+				RolloutPlanAction{
+					Step:            stepIndex,
+					StepName:        step.Name,
+					Verb:            PlanVerbSetCapacity,
+					Release:         incumbent.Name,
+					Clusters:        incumbentClusters,
+					CapacityPercent: &incumbentPercent,
+				},
+			)
+		}
+	}
+
+	return &RolloutPlan{
+		Application: app.Name,
+		Release:     releaseName,
+		Clusters:    clusterNames,
+		Actions:     actions,
+	}, nil
+}
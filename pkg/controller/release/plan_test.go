@@ -0,0 +1,102 @@
+package release
+
+import (
+	"reflect"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperchart "github.com/bookingcom/shipper/pkg/chart"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+// TestBuildRolloutPlan tests that a vanguard rollout across two clusters
+// produces the expected ordered action sequence, without executing anything.
+func TestBuildRolloutPlan(t *testing.T) {
+	app := buildApplication(shippertesting.TestNamespace, "test-application")
+	two := int32(2)
+	app.Spec.Template = shipper.ReleaseEnvironment{
+		Chart: shipper.Chart{
+			Name:    "simple",
+			Version: "0.0.1",
+			RepoURL: chartRepoURL,
+		},
+		ClusterRequirements: shipper.ClusterRequirements{
+			Regions: []shipper.RegionRequirement{
+				{Name: shippertesting.TestRegion, Replicas: &two},
+			},
+		},
+		Strategy: &shipper.RolloutStrategy{
+			Steps: []shipper.RolloutStrategyStep{
+				{
+					Name:     "staging",
+					Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+					Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+				},
+				{
+					Name:     "50/50",
+					Capacity: shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+					Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+				},
+				{
+					Name:     "full on",
+					Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+					Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				},
+			},
+		},
+	}
+
+	clusterA := buildCluster("cluster-a")
+	clusterB := buildCluster("cluster-b")
+
+	incumbent := buildRelease()
+	incumbent.Name = "test-release-incumbent"
+	incumbent.Annotations[shipper.ReleaseClustersAnnotation] = "cluster-a,cluster-b"
+
+	plan, err := BuildRolloutPlan(
+		app,
+		incumbent,
+		"test-release-contender",
+		[]*shipper.Cluster{clusterA, clusterB},
+		shipperchart.FetchRemote(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusters := []string{"cluster-a", "cluster-b"}
+	i32 := func(v int32) *int32 { return &v }
+
+	// The simple chart pins 12 replicas, so 1% is 1 pod, 50% is 6 and
+	// 100% is all 12. Per step, actions mirror the executor's ordering:
+	// contender capacity, contender traffic, incumbent traffic, incumbent
+	// capacity.
+	expected := &RolloutPlan{
+		Application: "test-application",
+		Release:     "test-release-contender",
+		Clusters:    clusters,
+		Actions: []RolloutPlanAction{
+			{Step: PlanStepPreRollout, Verb: PlanVerbCreateRelease, Release: "test-release-contender", Clusters: clusters},
+
+			{Step: 0, StepName: "staging", Verb: PlanVerbInstall, Release: "test-release-contender", Clusters: clusters},
+			{Step: 0, StepName: "staging", Verb: PlanVerbSetCapacity, Release: "test-release-contender", Clusters: clusters, CapacityPercent: i32(1), Replicas: i32(1)},
+			{Step: 0, StepName: "staging", Verb: PlanVerbSetTraffic, Release: "test-release-contender", Clusters: clusters, TrafficWeight: i32(0)},
+			{Step: 0, StepName: "staging", Verb: PlanVerbSetTraffic, Release: "test-release-incumbent", Clusters: clusters, TrafficWeight: i32(100)},
+			{Step: 0, StepName: "staging", Verb: PlanVerbSetCapacity, Release: "test-release-incumbent", Clusters: clusters, CapacityPercent: i32(100)},
+
+			{Step: 1, StepName: "50/50", Verb: PlanVerbSetCapacity, Release: "test-release-contender", Clusters: clusters, CapacityPercent: i32(50), Replicas: i32(6)},
+			{Step: 1, StepName: "50/50", Verb: PlanVerbSetTraffic, Release: "test-release-contender", Clusters: clusters, TrafficWeight: i32(50)},
+			{Step: 1, StepName: "50/50", Verb: PlanVerbSetTraffic, Release: "test-release-incumbent", Clusters: clusters, TrafficWeight: i32(50)},
+			{Step: 1, StepName: "50/50", Verb: PlanVerbSetCapacity, Release: "test-release-incumbent", Clusters: clusters, CapacityPercent: i32(50)},
+
+			{Step: 2, StepName: "full on", Verb: PlanVerbSetCapacity, Release: "test-release-contender", Clusters: clusters, CapacityPercent: i32(100), Replicas: i32(12)},
+			{Step: 2, StepName: "full on", Verb: PlanVerbSetTraffic, Release: "test-release-contender", Clusters: clusters, TrafficWeight: i32(100)},
+			{Step: 2, StepName: "full on", Verb: PlanVerbSetTraffic, Release: "test-release-incumbent", Clusters: clusters, TrafficWeight: i32(0)},
+			{Step: 2, StepName: "full on", Verb: PlanVerbSetCapacity, Release: "test-release-incumbent", Clusters: clusters, CapacityPercent: i32(0)},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, plan) {
+		t.Fatalf("rollout plan differs from expected:\nexpected %+v\ngot      %+v", expected, plan)
+	}
+}
@@ -0,0 +1,210 @@
+package release
+
+import (
+	"encoding/json"
+
+	"github.com/golang/glog"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// appQuiesced reports whether an application asked to be scaled down to zero
+// for maintenance via the quiesce annotation.
+func appQuiesced(app *shipper.Application) bool {
+	return app.Annotations[shipper.AppQuiesceAnnotation] == shipper.True
+}
+
+// ensureQuiesced scales every release of the application down to zero
+// capacity and traffic across all of its clusters, stashing each target's
+// prior spec in an annotation so un-quiescing restores exactly the counts it
+// had. Rollout state -- target and achieved steps -- is left untouched, so
+// the strategy resumes where it stopped once the application is restored.
+// Traffic drains before capacity goes, so no requests land on pods about to
+// disappear.
+func (c *Controller) ensureQuiesced(app *shipper.Application, releases []*shipper.Release) error {
+	for _, rel := range releases {
+		tt, ct, err := c.targetsForRelease(rel)
+		if err != nil {
+			return err
+		}
+		if tt == nil || ct == nil {
+			// The release has no targets yet; nothing to quiesce.
+			continue
+		}
+
+		if err := c.quiesceTrafficTarget(tt); err != nil {
+			return err
+		}
+		if err := c.quiesceCapacityTarget(ct); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureUnquiesced restores the spec every quiesced target had when it was
+// scaled to zero, and reports whether any target was restored this sync.
+// Capacity comes back before traffic, mirroring the drain order in reverse.
+func (c *Controller) ensureUnquiesced(app *shipper.Application, releases []*shipper.Release) (bool, error) {
+	restored := false
+
+	for _, rel := range releases {
+		tt, ct, err := c.targetsForRelease(rel)
+		if err != nil {
+			return restored, err
+		}
+		if tt == nil || ct == nil {
+			continue
+		}
+
+		if r, err := c.restoreCapacityTarget(ct); err != nil {
+			return restored, err
+		} else if r {
+			restored = true
+		}
+
+		if r, err := c.restoreTrafficTarget(tt); err != nil {
+			return restored, err
+		} else if r {
+			restored = true
+		}
+	}
+
+	return restored, nil
+}
+
+// targetsForRelease fetches a release's traffic and capacity targets from
+// the listers, returning nils when they don't exist yet.
+func (c *Controller) targetsForRelease(rel *shipper.Release) (*shipper.TrafficTarget, *shipper.CapacityTarget, error) {
+	tt, err := c.trafficTargetLister.TrafficTargets(rel.Namespace).Get(rel.Name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, shippererrors.NewKubeclientGetError(rel.Namespace, rel.Name, err).
+			WithShipperKind("TrafficTarget")
+	}
+
+	ct, err := c.capacityTargetLister.CapacityTargets(rel.Namespace).Get(rel.Name)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, shippererrors.NewKubeclientGetError(rel.Namespace, rel.Name, err).
+			WithShipperKind("CapacityTarget")
+	}
+
+	return tt, ct, nil
+}
+
+func (c *Controller) quiesceTrafficTarget(tt *shipper.TrafficTarget) error {
+	if _, ok := tt.Annotations[shipper.QuiescedTrafficSpecAnnotation]; ok {
+		return nil
+	}
+
+	saved, err := json.Marshal(tt.Spec)
+	if err != nil {
+		return shippererrors.NewUnrecoverableError(err)
+	}
+
+	tt = tt.DeepCopy()
+	if tt.Annotations == nil {
+		tt.Annotations = map[string]string{}
+	}
+	tt.Annotations[shipper.QuiescedTrafficSpecAnnotation] = string(saved)
+	for i := range tt.Spec.Clusters {
+		tt.Spec.Clusters[i].Weight = 0
+	}
+
+	glog.V(4).Infof("Quiescing TrafficTarget %q", shippercontroller.MetaKey(tt))
+
+	if _, err := c.clientset.ShipperV1alpha1().TrafficTargets(tt.Namespace).Update(tt); err != nil {
+		return shippererrors.NewKubeclientUpdateError(tt, err).
+			WithShipperKind("TrafficTarget")
+	}
+
+	return nil
+}
+
+func (c *Controller) quiesceCapacityTarget(ct *shipper.CapacityTarget) error {
+	if _, ok := ct.Annotations[shipper.QuiescedCapacitySpecAnnotation]; ok {
+		return nil
+	}
+
+	saved, err := json.Marshal(ct.Spec)
+	if err != nil {
+		return shippererrors.NewUnrecoverableError(err)
+	}
+
+	ct = ct.DeepCopy()
+	if ct.Annotations == nil {
+		ct.Annotations = map[string]string{}
+	}
+	ct.Annotations[shipper.QuiescedCapacitySpecAnnotation] = string(saved)
+	for i := range ct.Spec.Clusters {
+		ct.Spec.Clusters[i].Percent = 0
+	}
+
+	glog.V(4).Infof("Quiescing CapacityTarget %q", shippercontroller.MetaKey(ct))
+
+	if _, err := c.clientset.ShipperV1alpha1().CapacityTargets(ct.Namespace).Update(ct); err != nil {
+		return shippererrors.NewKubeclientUpdateError(ct, err).
+			WithShipperKind("CapacityTarget")
+	}
+
+	return nil
+}
+
+func (c *Controller) restoreTrafficTarget(tt *shipper.TrafficTarget) (bool, error) {
+	saved, ok := tt.Annotations[shipper.QuiescedTrafficSpecAnnotation]
+	if !ok {
+		return false, nil
+	}
+
+	var spec shipper.TrafficTargetSpec
+	if err := json.Unmarshal([]byte(saved), &spec); err != nil {
+		return false, shippererrors.NewUnrecoverableError(err)
+	}
+
+	tt = tt.DeepCopy()
+	tt.Spec = spec
+	delete(tt.Annotations, shipper.QuiescedTrafficSpecAnnotation)
+
+	glog.V(4).Infof("Restoring quiesced TrafficTarget %q", shippercontroller.MetaKey(tt))
+
+	if _, err := c.clientset.ShipperV1alpha1().TrafficTargets(tt.Namespace).Update(tt); err != nil {
+		return false, shippererrors.NewKubeclientUpdateError(tt, err).
+			WithShipperKind("TrafficTarget")
+	}
+
+	return true, nil
+}
+
+func (c *Controller) restoreCapacityTarget(ct *shipper.CapacityTarget) (bool, error) {
+	saved, ok := ct.Annotations[shipper.QuiescedCapacitySpecAnnotation]
+	if !ok {
+		return false, nil
+	}
+
+	var spec shipper.CapacityTargetSpec
+	if err := json.Unmarshal([]byte(saved), &spec); err != nil {
+		return false, shippererrors.NewUnrecoverableError(err)
+	}
+
+	ct = ct.DeepCopy()
+	ct.Spec = spec
+	delete(ct.Annotations, shipper.QuiescedCapacitySpecAnnotation)
+
+	glog.V(4).Infof("Restoring quiesced CapacityTarget %q", shippercontroller.MetaKey(ct))
+
+	if _, err := c.clientset.ShipperV1alpha1().CapacityTargets(ct.Namespace).Update(ct); err != nil {
+		return false, shippererrors.NewKubeclientUpdateError(ct, err).
+			WithShipperKind("CapacityTarget")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,177 @@
+package release
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	kubetesting "k8s.io/client-go/testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+func expectTrafficTargetUpdate(f *fixture, tt *shipper.TrafficTarget) {
+	gvr := shipper.SchemeGroupVersion.WithResource("traffictargets")
+	f.actions = append(f.actions, kubetesting.NewUpdateAction(gvr, tt.GetNamespace(), tt))
+}
+
+func expectCapacityTargetUpdate(f *fixture, ct *shipper.CapacityTarget) {
+	gvr := shipper.SchemeGroupVersion.WithResource("capacitytargets")
+	f.actions = append(f.actions, kubetesting.NewUpdateAction(gvr, ct.GetNamespace(), ct))
+}
+
+// quiescedTrafficTarget returns a copy of the given traffic target scaled
+// down the way ensureQuiesced leaves it: weights zeroed, prior spec stashed
+// in the quiesce annotation.
+func quiescedTrafficTarget(tt *shipper.TrafficTarget) *shipper.TrafficTarget {
+	saved, _ := json.Marshal(tt.Spec)
+
+	tt = tt.DeepCopy()
+	if tt.Annotations == nil {
+		tt.Annotations = map[string]string{}
+	}
+	tt.Annotations[shipper.QuiescedTrafficSpecAnnotation] = string(saved)
+	for i := range tt.Spec.Clusters {
+		tt.Spec.Clusters[i].Weight = 0
+	}
+
+	return tt
+}
+
+// quiescedCapacityTarget is the capacity flavor of quiescedTrafficTarget.
+func quiescedCapacityTarget(ct *shipper.CapacityTarget) *shipper.CapacityTarget {
+	saved, _ := json.Marshal(ct.Spec)
+
+	ct = ct.DeepCopy()
+	if ct.Annotations == nil {
+		ct.Annotations = map[string]string{}
+	}
+	ct.Annotations[shipper.QuiescedCapacitySpecAnnotation] = string(saved)
+	for i := range ct.Spec.Clusters {
+		ct.Spec.Clusters[i].Percent = 0
+	}
+
+	return ct
+}
+
+// TestQuiesceScalesAppToZero checks that annotating an application with the
+// quiesce annotation scales every release's traffic and capacity to zero
+// while stashing the prior specs for later restoration.
+func TestQuiesceScalesAppToZero(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	app.Annotations = map[string]string{shipper.AppQuiesceAnnotation: shipper.True}
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	var replicaCount int32 = 10
+	incumbent := f.buildIncumbent(namespace, incumbentName, replicaCount)
+	contender := f.buildContender(namespace, contenderName, replicaCount)
+
+	contender.release.Annotations[shipper.ReleaseClustersAnnotation] = cluster.GetName()
+	cond := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
+	releaseutil.SetReleaseCondition(&contender.release.Status, *cond)
+
+	f.addObjects(
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+	)
+
+	f.filter = f.filter.Extend(actionfilter{
+		[]string{"update"},
+		[]string{"traffictargets", "capacitytargets"},
+	})
+
+	// Traffic drains before capacity goes, oldest generation first.
+	expectTrafficTargetUpdate(f, quiescedTrafficTarget(incumbent.trafficTarget))
+	expectCapacityTargetUpdate(f, quiescedCapacityTarget(incumbent.capacityTarget))
+	expectTrafficTargetUpdate(f, quiescedTrafficTarget(contender.trafficTarget))
+	expectCapacityTargetUpdate(f, quiescedCapacityTarget(contender.capacityTarget))
+
+	f.run()
+}
+
+// TestUnquiesceRestoresExactCounts checks that removing the quiesce
+// annotation puts back, verbatim, the capacity and traffic specs the
+// targets had when they were quiesced.
+func TestUnquiesceRestoresExactCounts(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	var replicaCount int32 = 10
+	incumbent := f.buildIncumbent(namespace, incumbentName, replicaCount)
+
+	// The contender finished its rollout before the app was quiesced, so
+	// nothing moves once the specs are restored.
+	contender := f.buildIncumbent(namespace, contenderName, replicaCount)
+	contender.release.Annotations[shipper.ReleaseGenerationAnnotation] = "1"
+
+	// Both releases are scheduled and settled at full capacity, so the
+	// strategy machinery has nothing to do once the specs are back.
+	for _, ri := range []*releaseInfo{incumbent, contender} {
+		cond := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
+		releaseutil.SetReleaseCondition(&ri.release.Status, *cond)
+		ri.capacityTarget.Status.Clusters[0].AvailableReplicas = replicaCount
+	}
+
+	quiescedTargets := []struct {
+		tt *shipper.TrafficTarget
+		ct *shipper.CapacityTarget
+	}{
+		{quiescedTrafficTarget(incumbent.trafficTarget), quiescedCapacityTarget(incumbent.capacityTarget)},
+		{quiescedTrafficTarget(contender.trafficTarget), quiescedCapacityTarget(contender.capacityTarget)},
+	}
+
+	f.addObjects(
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		quiescedTargets[0].ct.DeepCopy(),
+		quiescedTargets[0].tt.DeepCopy(),
+
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		quiescedTargets[1].ct.DeepCopy(),
+		quiescedTargets[1].tt.DeepCopy(),
+	)
+
+	f.filter = f.filter.Extend(actionfilter{
+		[]string{"update"},
+		[]string{"traffictargets", "capacitytargets"},
+	})
+
+	// Capacity comes back before traffic, oldest generation first, with the
+	// exact specs the targets had when they were quiesced.
+	restoredIncumbentCT := quiescedTargets[0].ct.DeepCopy()
+	restoredIncumbentCT.Spec = incumbent.capacityTarget.Spec
+	delete(restoredIncumbentCT.Annotations, shipper.QuiescedCapacitySpecAnnotation)
+	expectCapacityTargetUpdate(f, restoredIncumbentCT)
+
+	restoredIncumbentTT := quiescedTargets[0].tt.DeepCopy()
+	restoredIncumbentTT.Spec = incumbent.trafficTarget.Spec
+	delete(restoredIncumbentTT.Annotations, shipper.QuiescedTrafficSpecAnnotation)
+	expectTrafficTargetUpdate(f, restoredIncumbentTT)
+
+	restoredContenderCT := quiescedTargets[1].ct.DeepCopy()
+	restoredContenderCT.Spec = contender.capacityTarget.Spec
+	delete(restoredContenderCT.Annotations, shipper.QuiescedCapacitySpecAnnotation)
+	expectCapacityTargetUpdate(f, restoredContenderCT)
+
+	restoredContenderTT := quiescedTargets[1].tt.DeepCopy()
+	restoredContenderTT.Spec = contender.trafficTarget.Spec
+	delete(restoredContenderTT.Annotations, shipper.QuiescedTrafficSpecAnnotation)
+	expectTrafficTargetUpdate(f, restoredContenderTT)
+
+	f.run()
+}
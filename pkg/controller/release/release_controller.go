@@ -1,6 +1,7 @@
 package release
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -21,13 +22,28 @@ import (
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
 	shipperlisters "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
+	shippermetrics "github.com/bookingcom/shipper/pkg/metrics/prometheus"
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+	shipperworkqueue "github.com/bookingcom/shipper/pkg/workqueue"
 )
 
 const (
 	AgentName = "release-controller"
 
 	maxRetries = 11
+
+	// childUpdateDebounce is how long the controller waits after a target
+	// object's status changes before reconciling the owning Release. Keys
+	// already waiting in the delaying queue are not added again, so a burst
+	// of child status updates during a fast-moving rollout collapses into a
+	// single reconcile.
+	childUpdateDebounce = 500 * time.Millisecond
+
+	// childUpdateJitterFactor spreads the debounced enqueues out so that
+	// releases whose children update in lockstep don't all wake up at the
+	// same instant.
+	childUpdateJitterFactor = 0.25
 )
 
 // Controller is a Kubernetes controller whose role is to pick up a newly created
@@ -61,6 +77,19 @@ type Controller struct {
 
 	releaseWorkqueue     workqueue.RateLimitingInterface
 	applicationWorkqueue workqueue.RateLimitingInterface
+
+	// rolloutMetrics, when non-nil, is fed terminal rollout outcomes and
+	// target step patches observed on Release updates.
+	rolloutMetrics *shippermetrics.RolloutMetrics
+
+	// changeApprover, when non-nil, is consulted before a target step
+	// advance is honored for releases in changeApprovalTiers.
+	changeApprover      ChangeApprover
+	changeApprovalTiers map[string]struct{}
+
+	// flagProvider applies strategy steps' feature-flag changes to an
+	// external feature-flag system; defaults to a no-op provider.
+	flagProvider FlagProvider
 }
 
 type releaseInfo struct {
@@ -81,8 +110,21 @@ func NewController(
 	informerFactory shipperinformers.SharedInformerFactory,
 	chartFetchFunc chart.FetchFunc,
 	recorder record.EventRecorder,
+	rolloutMetrics *shippermetrics.RolloutMetrics,
+	changeApprover ChangeApprover,
+	changeApprovalTiers []string,
+	flagProvider FlagProvider,
 ) *Controller {
 
+	gatedTiers := make(map[string]struct{}, len(changeApprovalTiers))
+	for _, tier := range changeApprovalTiers {
+		gatedTiers[tier] = struct{}{}
+	}
+
+	if flagProvider == nil {
+		flagProvider = noopFlagProvider{}
+	}
+
 	applicationInformer := informerFactory.Shipper().V1alpha1().Applications()
 	releaseInformer := informerFactory.Shipper().V1alpha1().Releases()
 	clusterInformer := informerFactory.Shipper().V1alpha1().Clusters()
@@ -115,22 +157,32 @@ func NewController(
 		capacityTargetLister:  capacityTargetInformer.Lister(),
 		capacityTargetsSynced: capacityTargetInformer.Informer().HasSynced,
 
-		releaseWorkqueue: workqueue.NewNamedRateLimitingQueue(
-			workqueue.DefaultControllerRateLimiter(),
-			"release_controller_releases",
-		),
-		applicationWorkqueue: workqueue.NewNamedRateLimitingQueue(
-			workqueue.DefaultControllerRateLimiter(),
-			"release_controller_applications",
-		),
+		rolloutMetrics: rolloutMetrics,
+
+		changeApprover:      changeApprover,
+		changeApprovalTiers: gatedTiers,
+
+		flagProvider: flagProvider,
 	}
 
+	// Both workqueues dequeue in tier order, so a backlog of
+	// lower-environment work doesn't delay production rollouts.
+	controller.releaseWorkqueue = shipperworkqueue.NewPriorityRateLimitingQueue(
+		workqueue.DefaultControllerRateLimiter(),
+		controller.releasePriority,
+	)
+	controller.applicationWorkqueue = shipperworkqueue.NewPriorityRateLimitingQueue(
+		workqueue.DefaultControllerRateLimiter(),
+		controller.applicationPriority,
+	)
+
 	glog.Info("Setting up event handlers")
 
 	releaseInformer.Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: controller.enqueueRelease,
 			UpdateFunc: func(oldObj, newObj interface{}) {
+				controller.recordRolloutTransitions(oldObj, newObj)
 				controller.enqueueRelease(newObj)
 			},
 			DeleteFunc: controller.enqueueAppFromRelease,
@@ -140,7 +192,7 @@ func NewController(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: controller.enqueueInstallationTarget,
 			UpdateFunc: func(oldObj, newObj interface{}) {
-				controller.enqueueInstallationTarget(newObj)
+				controller.enqueueInstallationTargetDebounced(newObj)
 			},
 			DeleteFunc: controller.enqueueInstallationTarget,
 		})
@@ -149,7 +201,7 @@ func NewController(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: controller.enqueueCapacityTarget,
 			UpdateFunc: func(oldObj, newObj interface{}) {
-				controller.enqueueCapacityTarget(newObj)
+				controller.enqueueCapacityTargetDebounced(newObj)
 			},
 			DeleteFunc: controller.enqueueCapacityTarget,
 		})
@@ -158,7 +210,7 @@ func NewController(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: controller.enqueueTrafficTarget,
 			UpdateFunc: func(oldObj, newObj interface{}) {
-				controller.enqueueTrafficTarget(newObj)
+				controller.enqueueTrafficTargetDebounced(newObj)
 			},
 			DeleteFunc: controller.enqueueTrafficTarget,
 		})
@@ -250,6 +302,7 @@ func (c *Controller) processNextReleaseWorkItem() bool {
 		return true
 	}
 
+	shipperhealth.RecordSuccess(AgentName)
 	glog.V(4).Infof("Successfully synced Release %q", key)
 	c.releaseWorkqueue.Forget(obj)
 
@@ -313,6 +366,25 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 		return nil
 	}
 
+	// A cluster marked for decommission has to shed its releases before it
+	// can go away. Re-choosing clusters with force rewrites the annotation
+	// to a set that excludes decommissioning clusters; we return early just
+	// like the 1st round and let the informers deliver the updated release
+	// for rescheduling, at which point the target objects follow the new
+	// cluster set and capacity comes up elsewhere before the old cluster's
+	// objects are removed.
+	needsMigration, err := c.releaseNeedsMigration(rel)
+	if err != nil {
+		return err
+	}
+	if needsMigration {
+		if _, err := scheduler.ChooseClusters(rel.DeepCopy(), true); err != nil {
+			return shippererrors.NewRecoverableError(fmt.Errorf("failed to move release %q off decommissioning clusters (will retry): %s", key, err))
+		}
+
+		return nil
+	}
+
 	if _, err = scheduler.ScheduleRelease(rel.DeepCopy()); err != nil {
 		if shippererrors.ShouldBroadcast(err) {
 			c.recorder.Eventf(
@@ -356,6 +428,28 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 	return nil
 }
 
+// releaseNeedsMigration reports whether any of the clusters the release is
+// currently scheduled on has been marked for decommission.
+func (c *Controller) releaseNeedsMigration(rel *shipper.Release) (bool, error) {
+	for _, clusterName := range getReleaseClusters(rel) {
+		cluster, err := c.clusterLister.Get(clusterName)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+
+			return false, shippererrors.NewKubeclientGetError("", clusterName, err).
+				WithShipperKind("Cluster")
+		}
+
+		if cluster.Spec.Decommission {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 // getAssociatedApplicationKey returns an application key in the format:
 // <namespace>/<application name>
 func (c *Controller) getAssociatedApplicationKey(rel *shipper.Release) (string, error) {
@@ -395,6 +489,15 @@ func (c *Controller) buildReleaseInfo(rel *shipper.Release) (*releaseInfo, error
 			WithShipperKind("InstallationTarget")
 	}
 
+	// Install-only releases have no capacity or traffic targets at all;
+	// see Scheduler.ScheduleRelease.
+	if releaseutil.ReleaseInstallOnly(rel) {
+		return &releaseInfo{
+			release:            rel,
+			installationTarget: installationTarget,
+		}, nil
+	}
+
 	capacityTarget, err := c.capacityTargetLister.CapacityTargets(ns).Get(name)
 	if err != nil {
 		return nil, shippererrors.NewKubeclientGetError(ns, name, err).
@@ -415,6 +518,110 @@ func (c *Controller) buildReleaseInfo(rel *shipper.Release) (*releaseInfo, error
 	}, nil
 }
 
+// recordRolloutTransitions emits rollout outcome metrics for a Release
+// update. It only looks at boundaries — an outcome is counted the moment the
+// release crosses into it, so steady-state re-syncs of a finished release
+// don't inflate the counters.
+func (c *Controller) recordRolloutTransitions(oldObj, newObj interface{}) {
+	if c.rolloutMetrics == nil {
+		return
+	}
+
+	oldRel, oldOk := oldObj.(*shipper.Release)
+	newRel, newOk := newObj.(*shipper.Release)
+	if !oldOk || !newOk {
+		return
+	}
+
+	app, err := releaseutil.ApplicationNameForRelease(newRel)
+	if err != nil {
+		return
+	}
+
+	if oldRel.Spec.TargetStep != newRel.Spec.TargetStep {
+		c.rolloutMetrics.StepPatches.WithLabelValues(app).Inc()
+	}
+
+	if !releaseutil.ReleaseComplete(oldRel) && releaseutil.ReleaseComplete(newRel) {
+		c.rolloutMetrics.Outcomes.WithLabelValues(app, shippermetrics.OutcomeCompleted).Inc()
+		// Informer callbacks don't carry a request context yet, so no
+		// trace ID flows here; once reconcile tracing threads one
+		// through, the duration observation picks it up as an exemplar.
+		shippermetrics.ObserveWithTrace(
+			context.Background(),
+			c.rolloutMetrics.Duration.WithLabelValues(app),
+			time.Since(newRel.CreationTimestamp.Time).Seconds(),
+		)
+	}
+
+	if !releaseutil.ReleaseRollingBack(oldRel) && releaseutil.ReleaseRollingBack(newRel) {
+		c.rolloutMetrics.Outcomes.WithLabelValues(app, shippermetrics.OutcomeRolledBack).Inc()
+	}
+
+	if !releaseutil.ReleaseFailed(oldRel) && releaseutil.ReleaseFailed(newRel) {
+		c.rolloutMetrics.Outcomes.WithLabelValues(app, shippermetrics.OutcomeFailed).Inc()
+	}
+}
+
+// tierPriorities orders reconciliation across environment tiers when many
+// objects queue at once. Unlisted tiers, and objects with no tier label at
+// all, reconcile at the default priority.
+var tierPriorities = map[string]int{
+	"production": 2,
+	"staging":    1,
+}
+
+// releasePriority returns the dequeue priority for a queued release key. The
+// tier label on the Release itself wins, falling back to its Application's.
+func (c *Controller) releasePriority(item interface{}) int {
+	key, ok := item.(string)
+	if !ok {
+		return 0
+	}
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return 0
+	}
+
+	rel, err := c.releaseLister.Releases(ns).Get(name)
+	if err != nil {
+		return 0
+	}
+
+	if tier, ok := rel.Labels[shipper.TierLabel]; ok {
+		return tierPriorities[tier]
+	}
+
+	appName, err := releaseutil.ApplicationNameForRelease(rel)
+	if err != nil {
+		return 0
+	}
+
+	return c.applicationPriority(ns + "/" + appName)
+}
+
+// applicationPriority returns the dequeue priority for a queued application
+// key, from the Application's tier label.
+func (c *Controller) applicationPriority(item interface{}) int {
+	key, ok := item.(string)
+	if !ok {
+		return 0
+	}
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return 0
+	}
+
+	app, err := c.applicationLister.Applications(ns).Get(name)
+	if err != nil {
+		return 0
+	}
+
+	return tierPriorities[app.Labels[shipper.TierLabel]]
+}
+
 func (c *Controller) enqueueRelease(obj interface{}) {
 	rel, ok := obj.(*shipper.Release)
 	if !ok {
@@ -495,6 +702,62 @@ func (c *Controller) enqueueTrafficTarget(obj interface{}) {
 	c.releaseWorkqueue.Add(releaseKey)
 }
 
+// enqueueReleaseDebounced schedules a reconcile of the given release key
+// after a short jittered delay instead of immediately. The delaying queue
+// deduplicates keys that are already waiting, which coalesces rapid child
+// status updates into a bounded number of reconciles.
+func (c *Controller) enqueueReleaseDebounced(releaseKey string) {
+	c.releaseWorkqueue.AddAfter(releaseKey, wait.Jitter(childUpdateDebounce, childUpdateJitterFactor))
+}
+
+func (c *Controller) enqueueInstallationTargetDebounced(obj interface{}) {
+	it, ok := obj.(*shipper.InstallationTarget)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.InstallationTarget: %#v", obj))
+		return
+	}
+
+	releaseKey, err := c.getAssociatedReleaseKey(&it.ObjectMeta)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	c.enqueueReleaseDebounced(releaseKey)
+}
+
+func (c *Controller) enqueueCapacityTargetDebounced(obj interface{}) {
+	ct, ok := obj.(*shipper.CapacityTarget)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.CapacityTarget: %#v", obj))
+		return
+	}
+
+	releaseKey, err := c.getAssociatedReleaseKey(&ct.ObjectMeta)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	c.enqueueReleaseDebounced(releaseKey)
+}
+
+func (c *Controller) enqueueTrafficTargetDebounced(obj interface{}) {
+	tt, ok := obj.(*shipper.TrafficTarget)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.TrafficTarget: %#v", obj))
+		return
+	}
+
+	releaseKey, err := c.getAssociatedReleaseKey(&tt.ObjectMeta)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	c.enqueueReleaseDebounced(releaseKey)
+}
+
 func reasonForReleaseCondition(err error) string {
 	switch err.(type) {
 	case shippererrors.NoRegionsSpecifiedError:
@@ -32,6 +32,7 @@ import (
 func init() {
 	releaseutil.ConditionsShouldDiscardTimestamps = true
 	conditions.StrategyConditionsShouldDiscardTimestamps = true
+	stateDurationsShouldDiscardTimestamps = true
 }
 
 var vanguard = shipper.RolloutStrategy{
@@ -241,6 +242,10 @@ func (f *fixture) newController() *Controller {
 		f.informerFactory,
 		chart.FetchRemote(),
 		f.recorder,
+		nil,
+		nil,
+		nil,
+		nil,
 	)
 }
 
@@ -690,8 +695,68 @@ func addCluster(ri *releaseInfo, cluster *shipper.Cluster) {
 	)
 }
 
+// buildExpectedStepStatuses mirrors the per-step breakdown the executor
+// derives from its strategy conditions. The strategy fixtures in this file
+// always run with an incumbent.
+func buildExpectedStepStatuses(rel *shipper.Release, strategyConditions []shipper.ReleaseStrategyCondition, targetStep int32) []shipper.ReleaseStrategyStepStatus {
+	return conditions.NewStrategyConditions(strategyConditions...).
+		AsReleaseStrategyStepStatuses(rel.Spec.Environment.Strategy.Steps, targetStep, true)
+}
+
+// buildExpectedAchievingStep mirrors the transition progress the executor
+// reports while the target step hasn't been achieved yet: the count of
+// strategy conditions already met out of the five that must hold when an
+// incumbent is involved.
+func buildExpectedAchievingStep(rel *shipper.Release, strategyConditions []shipper.ReleaseStrategyCondition, achievedStep *shipper.AchievedStep, targetStep int32) *shipper.AchievingStep {
+	if achievedStep != nil && achievedStep.Step == targetStep {
+		return nil
+	}
+
+	met := 0
+	for _, condition := range strategyConditions {
+		if condition.Step == targetStep && condition.Status == corev1.ConditionTrue {
+			met++
+		}
+	}
+
+	return &shipper.AchievingStep{
+		Step:     targetStep,
+		Name:     rel.Spec.Environment.Strategy.Steps[targetStep].Name,
+		Progress: fmt.Sprintf("%d/5", met),
+	}
+}
+
 func (f *fixture) expectReleaseWaitingForCommand(rel *shipper.Release, step int32) {
 	gvr := shipper.SchemeGroupVersion.WithResource("releases")
+
+	strategyConditions := []shipper.ReleaseStrategyCondition{
+		{
+			Type:   shipper.StrategyConditionContenderAchievedCapacity,
+			Status: corev1.ConditionTrue,
+			Step:   step,
+		},
+		{
+			Type:   shipper.StrategyConditionContenderAchievedInstallation,
+			Status: corev1.ConditionTrue,
+			Step:   step,
+		},
+		{
+			Type:   shipper.StrategyConditionContenderAchievedTraffic,
+			Status: corev1.ConditionTrue,
+			Step:   step,
+		},
+		{
+			Type:   shipper.StrategyConditionIncumbentAchievedCapacity,
+			Status: corev1.ConditionTrue,
+			Step:   step,
+		},
+		{
+			Type:   shipper.StrategyConditionIncumbentAchievedTraffic,
+			Status: corev1.ConditionTrue,
+			Step:   step,
+		},
+	}
+
 	newStatus := map[string]interface{}{
 		"status": shipper.ReleaseStatus{
 			AchievedStep: &shipper.AchievedStep{
@@ -708,33 +773,9 @@ func (f *fixture) expectReleaseWaitingForCommand(rel *shipper.Release, step int3
 					WaitingForTraffic:      shipper.StrategyStateFalse,
 					WaitingForCapacity:     shipper.StrategyStateFalse,
 				},
-				Conditions: []shipper.ReleaseStrategyCondition{
-					{
-						Type:   shipper.StrategyConditionContenderAchievedCapacity,
-						Status: corev1.ConditionTrue,
-						Step:   step,
-					},
-					{
-						Type:   shipper.StrategyConditionContenderAchievedInstallation,
-						Status: corev1.ConditionTrue,
-						Step:   step,
-					},
-					{
-						Type:   shipper.StrategyConditionContenderAchievedTraffic,
-						Status: corev1.ConditionTrue,
-						Step:   step,
-					},
-					{
-						Type:   shipper.StrategyConditionIncumbentAchievedCapacity,
-						Status: corev1.ConditionTrue,
-						Step:   step,
-					},
-					{
-						Type:   shipper.StrategyConditionIncumbentAchievedTraffic,
-						Status: corev1.ConditionTrue,
-						Step:   step,
-					},
-				},
+				Conditions:    strategyConditions,
+				Steps:         buildExpectedStepStatuses(rel, strategyConditions, step),
+				CommandStatus: "awaiting command",
 			},
 		},
 	}
@@ -904,6 +945,20 @@ func (f *fixture) expectReleaseScheduled(release *shipper.Release, clusters []*s
 	expected := release.DeepCopy()
 	expected.Annotations[shipper.ReleaseClustersAnnotation] = clusterNamesStr
 
+	sortedClusters := append([]*shipper.Cluster(nil), clusters...)
+	sort.Slice(sortedClusters, func(i, j int) bool {
+		return sortedClusters[i].Name < sortedClusters[j].Name
+	})
+	chosenClusters := make([]shipper.ChosenCluster, 0, len(sortedClusters))
+	for _, cluster := range sortedClusters {
+		chosenClusters = append(chosenClusters, shipper.ChosenCluster{
+			Name:   cluster.Name,
+			Region: cluster.Spec.Region,
+			Reason: fmt.Sprintf("matched region %q", cluster.Spec.Region),
+		})
+	}
+	expected.Status.Clusters = chosenClusters
+
 	expectedWithConditions := expected.DeepCopy()
 	condition := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
 	releaseutil.SetReleaseCondition(&expectedWithConditions.Status, *condition)
@@ -991,9 +1046,11 @@ func (f *fixture) expectCapacityStatusPatch(ct *shipper.CapacityTarget, r *shipp
 		)
 	}
 
+	r.Status.AchievingStep = buildExpectedAchievingStep(r, strategyConditions.AsReleaseStrategyConditions(), r.Status.AchievedStep, step)
 	r.Status.Strategy = &shipper.ReleaseStrategyStatus{
 		Conditions: strategyConditions.AsReleaseStrategyConditions(),
 		State:      strategyConditions.AsReleaseStrategyState(r.Spec.TargetStep, true, false),
+		Steps:      buildExpectedStepStatuses(r, strategyConditions.AsReleaseStrategyConditions(), step),
 	}
 	newStatus := map[string]interface{}{
 		"status": r.Status,
@@ -1073,9 +1130,11 @@ func (f *fixture) expectTrafficStatusPatch(tt *shipper.TrafficTarget, r *shipper
 		)
 	}
 
+	r.Status.AchievingStep = buildExpectedAchievingStep(r, strategyConditions.AsReleaseStrategyConditions(), r.Status.AchievedStep, step)
 	r.Status.Strategy = &shipper.ReleaseStrategyStatus{
 		Conditions: strategyConditions.AsReleaseStrategyConditions(),
 		State:      strategyConditions.AsReleaseStrategyState(r.Spec.TargetStep, true, false),
+		Steps:      buildExpectedStepStatuses(r, strategyConditions.AsReleaseStrategyConditions(), step),
 	}
 	newStatus := map[string]interface{}{
 		"status": r.Status,
@@ -1093,6 +1152,36 @@ func (f *fixture) expectTrafficStatusPatch(tt *shipper.TrafficTarget, r *shipper
 
 func (f *fixture) expectReleaseReleased(rel *shipper.Release, targetStep int32) {
 	gvr := shipper.SchemeGroupVersion.WithResource("releases")
+
+	// The following conditions are sorted alphabetically by Type
+	strategyConditions := []shipper.ReleaseStrategyCondition{
+		{
+			Type:   shipper.StrategyConditionContenderAchievedCapacity,
+			Status: corev1.ConditionTrue,
+			Step:   targetStep,
+		},
+		{
+			Type:   shipper.StrategyConditionContenderAchievedInstallation,
+			Status: corev1.ConditionTrue,
+			Step:   targetStep,
+		},
+		{
+			Type:   shipper.StrategyConditionContenderAchievedTraffic,
+			Status: corev1.ConditionTrue,
+			Step:   targetStep,
+		},
+		{
+			Type:   shipper.StrategyConditionIncumbentAchievedCapacity,
+			Status: corev1.ConditionTrue,
+			Step:   targetStep,
+		},
+		{
+			Type:   shipper.StrategyConditionIncumbentAchievedTraffic,
+			Status: corev1.ConditionTrue,
+			Step:   targetStep,
+		},
+	}
+
 	newStatus := map[string]interface{}{
 		"status": shipper.ReleaseStatus{
 			AchievedStep: &shipper.AchievedStep{
@@ -1111,34 +1200,8 @@ func (f *fixture) expectReleaseReleased(rel *shipper.Release, targetStep int32)
 					WaitingForTraffic:      shipper.StrategyStateFalse,
 					WaitingForCapacity:     shipper.StrategyStateFalse,
 				},
-				// The following conditions are sorted alphabetically by Type
-				Conditions: []shipper.ReleaseStrategyCondition{
-					{
-						Type:   shipper.StrategyConditionContenderAchievedCapacity,
-						Status: corev1.ConditionTrue,
-						Step:   targetStep,
-					},
-					{
-						Type:   shipper.StrategyConditionContenderAchievedInstallation,
-						Status: corev1.ConditionTrue,
-						Step:   targetStep,
-					},
-					{
-						Type:   shipper.StrategyConditionContenderAchievedTraffic,
-						Status: corev1.ConditionTrue,
-						Step:   targetStep,
-					},
-					{
-						Type:   shipper.StrategyConditionIncumbentAchievedCapacity,
-						Status: corev1.ConditionTrue,
-						Step:   targetStep,
-					},
-					{
-						Type:   shipper.StrategyConditionIncumbentAchievedTraffic,
-						Status: corev1.ConditionTrue,
-						Step:   targetStep,
-					},
-				},
+				Conditions: strategyConditions,
+				Steps:      buildExpectedStepStatuses(rel, strategyConditions, targetStep),
 			},
 		},
 	}
@@ -1170,9 +1233,20 @@ func (f *fixture) expectInstallationNotReady(rel *shipper.Release, achievedStepI
 		}
 	}
 
+	strategyConditions := []shipper.ReleaseStrategyCondition{
+		{
+			Type:    shipper.StrategyConditionContenderAchievedInstallation,
+			Status:  corev1.ConditionFalse,
+			Reason:  conditions.ClustersNotReady,
+			Step:    targetStepIndex,
+			Message: "clusters pending installation: [broken-installation-cluster]",
+		},
+	}
+
 	newStatus := map[string]interface{}{
 		"status": shipper.ReleaseStatus{
-			AchievedStep: achievedStep,
+			AchievedStep:  achievedStep,
+			AchievingStep: buildExpectedAchievingStep(rel, strategyConditions, achievedStep, targetStepIndex),
 			Conditions: []shipper.ReleaseCondition{
 				{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue},
 			},
@@ -1183,15 +1257,8 @@ func (f *fixture) expectInstallationNotReady(rel *shipper.Release, achievedStepI
 					WaitingForTraffic:      shipper.StrategyStateFalse,
 					WaitingForCapacity:     shipper.StrategyStateFalse,
 				},
-				Conditions: []shipper.ReleaseStrategyCondition{
-					{
-						Type:    shipper.StrategyConditionContenderAchievedInstallation,
-						Status:  corev1.ConditionFalse,
-						Reason:  conditions.ClustersNotReady,
-						Step:    targetStepIndex,
-						Message: "clusters pending installation: [broken-installation-cluster]",
-					},
-				},
+				Conditions: strategyConditions,
+				Steps:      buildExpectedStepStatuses(rel, strategyConditions, targetStepIndex),
 			},
 		},
 	}
@@ -1217,85 +1284,75 @@ func (f *fixture) expectCapacityNotReady(rel *shipper.Release, targetStep, achie
 		}
 	}
 
+	var strategyConditions []shipper.ReleaseStrategyCondition
+
 	if role == Contender {
-		newStatus = map[string]interface{}{
-			"status": shipper.ReleaseStatus{
-				AchievedStep: achievedStep,
-				Conditions: []shipper.ReleaseCondition{
-					{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue},
-				},
-				Strategy: &shipper.ReleaseStrategyStatus{
-					State: shipper.ReleaseStrategyState{
-						WaitingForInstallation: shipper.StrategyStateFalse,
-						WaitingForCommand:      shipper.StrategyStateFalse,
-						WaitingForTraffic:      shipper.StrategyStateFalse,
-						WaitingForCapacity:     shipper.StrategyStateTrue,
-					},
-					Conditions: []shipper.ReleaseStrategyCondition{
-						{
-							Type:    shipper.StrategyConditionContenderAchievedCapacity,
-							Status:  corev1.ConditionFalse,
-							Reason:  conditions.ClustersNotReady,
-							Message: fmt.Sprintf("clusters pending capacity adjustments: [%s]", brokenClusterName),
-							Step:    targetStep,
-						},
-						{
-							Type:   shipper.StrategyConditionContenderAchievedInstallation,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-					},
-				},
+		strategyConditions = []shipper.ReleaseStrategyCondition{
+			{
+				Type:    shipper.StrategyConditionContenderAchievedCapacity,
+				Status:  corev1.ConditionFalse,
+				Reason:  conditions.ClustersNotReady,
+				Message: fmt.Sprintf("clusters pending capacity adjustments: [%s]", brokenClusterName),
+				Step:    targetStep,
+			},
+			{
+				Type:   shipper.StrategyConditionContenderAchievedInstallation,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
 			},
 		}
 	} else {
-		newStatus = map[string]interface{}{
-			"status": shipper.ReleaseStatus{
-				AchievedStep: achievedStep,
-				Conditions: []shipper.ReleaseCondition{
-					{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue},
-				},
-				Strategy: &shipper.ReleaseStrategyStatus{
-					State: shipper.ReleaseStrategyState{
-						WaitingForInstallation: shipper.StrategyStateFalse,
-						WaitingForCommand:      shipper.StrategyStateFalse,
-						WaitingForTraffic:      shipper.StrategyStateFalse,
-						WaitingForCapacity:     shipper.StrategyStateTrue,
-					},
-					Conditions: []shipper.ReleaseStrategyCondition{
-						{
-							Type:   shipper.StrategyConditionContenderAchievedCapacity,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-						{
-							Type:   shipper.StrategyConditionContenderAchievedInstallation,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-						{
-							Type:   shipper.StrategyConditionContenderAchievedTraffic,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-						{
-							Type:    shipper.StrategyConditionIncumbentAchievedCapacity,
-							Status:  corev1.ConditionFalse,
-							Reason:  conditions.ClustersNotReady,
-							Step:    targetStep,
-							Message: fmt.Sprintf("clusters pending capacity adjustments: [%s]", brokenClusterName),
-						},
-						{
-							Type:   shipper.StrategyConditionIncumbentAchievedTraffic,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-					},
-				},
+		strategyConditions = []shipper.ReleaseStrategyCondition{
+			{
+				Type:   shipper.StrategyConditionContenderAchievedCapacity,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
+			},
+			{
+				Type:   shipper.StrategyConditionContenderAchievedInstallation,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
+			},
+			{
+				Type:   shipper.StrategyConditionContenderAchievedTraffic,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
+			},
+			{
+				Type:    shipper.StrategyConditionIncumbentAchievedCapacity,
+				Status:  corev1.ConditionFalse,
+				Reason:  conditions.ClustersNotReady,
+				Step:    targetStep,
+				Message: fmt.Sprintf("clusters pending capacity adjustments: [%s]", brokenClusterName),
+			},
+			{
+				Type:   shipper.StrategyConditionIncumbentAchievedTraffic,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
 			},
 		}
 	}
 
+	newStatus = map[string]interface{}{
+		"status": shipper.ReleaseStatus{
+			AchievedStep:  achievedStep,
+			AchievingStep: buildExpectedAchievingStep(rel, strategyConditions, achievedStep, targetStep),
+			Conditions: []shipper.ReleaseCondition{
+				{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue},
+			},
+			Strategy: &shipper.ReleaseStrategyStatus{
+				State: shipper.ReleaseStrategyState{
+					WaitingForInstallation: shipper.StrategyStateFalse,
+					WaitingForCommand:      shipper.StrategyStateFalse,
+					WaitingForTraffic:      shipper.StrategyStateFalse,
+					WaitingForCapacity:     shipper.StrategyStateTrue,
+				},
+				Conditions: strategyConditions,
+				Steps:      buildExpectedStepStatuses(rel, strategyConditions, targetStep),
+			},
+		},
+	}
+
 	patch, _ := json.Marshal(newStatus)
 	action := kubetesting.NewPatchAction(gvr, rel.GetNamespace(), rel.GetName(), patch)
 
@@ -1316,85 +1373,75 @@ func (f *fixture) expectTrafficNotReady(rel *shipper.Release, targetStep, achiev
 		}
 	}
 
+	var strategyConditions []shipper.ReleaseStrategyCondition
+
 	if role == Contender {
-		newStatus = map[string]interface{}{
-			"status": shipper.ReleaseStatus{
-				AchievedStep: achievedStep,
-				Conditions: []shipper.ReleaseCondition{
-					{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue},
-				},
-				Strategy: &shipper.ReleaseStrategyStatus{
-					State: shipper.ReleaseStrategyState{
-						WaitingForInstallation: shipper.StrategyStateFalse,
-						WaitingForCommand:      shipper.StrategyStateFalse,
-						WaitingForTraffic:      shipper.StrategyStateTrue,
-						WaitingForCapacity:     shipper.StrategyStateFalse,
-					},
-					Conditions: []shipper.ReleaseStrategyCondition{
-						{
-							Type:   shipper.StrategyConditionContenderAchievedCapacity,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-						{
-							Type:   shipper.StrategyConditionContenderAchievedInstallation,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-						{
-							Type:    shipper.StrategyConditionContenderAchievedTraffic,
-							Status:  corev1.ConditionFalse,
-							Reason:  conditions.ClustersNotReady,
-							Message: fmt.Sprintf("clusters pending traffic adjustments: [%s]", brokenClusterName),
-							Step:    targetStep,
-						},
-					},
-				},
+		strategyConditions = []shipper.ReleaseStrategyCondition{
+			{
+				Type:   shipper.StrategyConditionContenderAchievedCapacity,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
+			},
+			{
+				Type:   shipper.StrategyConditionContenderAchievedInstallation,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
+			},
+			{
+				Type:    shipper.StrategyConditionContenderAchievedTraffic,
+				Status:  corev1.ConditionFalse,
+				Reason:  conditions.ClustersNotReady,
+				Message: fmt.Sprintf("clusters pending traffic adjustments: [%s]", brokenClusterName),
+				Step:    targetStep,
 			},
 		}
 	} else {
-		newStatus = map[string]interface{}{
-			"status": shipper.ReleaseStatus{
-				AchievedStep: achievedStep,
-				Conditions: []shipper.ReleaseCondition{
-					{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue},
-				},
-				Strategy: &shipper.ReleaseStrategyStatus{
-					State: shipper.ReleaseStrategyState{
-						WaitingForInstallation: shipper.StrategyStateFalse,
-						WaitingForCommand:      shipper.StrategyStateFalse,
-						WaitingForTraffic:      shipper.StrategyStateTrue,
-						WaitingForCapacity:     shipper.StrategyStateFalse,
-					},
-					Conditions: []shipper.ReleaseStrategyCondition{
-						{
-							Type:   shipper.StrategyConditionContenderAchievedCapacity,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-						{
-							Type:   shipper.StrategyConditionContenderAchievedInstallation,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-						{
-							Type:   shipper.StrategyConditionContenderAchievedTraffic,
-							Status: corev1.ConditionTrue,
-							Step:   targetStep,
-						},
-						{
-							Type:    shipper.StrategyConditionIncumbentAchievedTraffic,
-							Status:  corev1.ConditionFalse,
-							Reason:  conditions.ClustersNotReady,
-							Message: fmt.Sprintf("clusters pending traffic adjustments: [%s]", brokenClusterName),
-							Step:    targetStep,
-						},
-					},
-				},
+		strategyConditions = []shipper.ReleaseStrategyCondition{
+			{
+				Type:   shipper.StrategyConditionContenderAchievedCapacity,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
+			},
+			{
+				Type:   shipper.StrategyConditionContenderAchievedInstallation,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
+			},
+			{
+				Type:   shipper.StrategyConditionContenderAchievedTraffic,
+				Status: corev1.ConditionTrue,
+				Step:   targetStep,
+			},
+			{
+				Type:    shipper.StrategyConditionIncumbentAchievedTraffic,
+				Status:  corev1.ConditionFalse,
+				Reason:  conditions.ClustersNotReady,
+				Message: fmt.Sprintf("clusters pending traffic adjustments: [%s]", brokenClusterName),
+				Step:    targetStep,
 			},
 		}
 	}
 
+	newStatus = map[string]interface{}{
+		"status": shipper.ReleaseStatus{
+			AchievedStep:  achievedStep,
+			AchievingStep: buildExpectedAchievingStep(rel, strategyConditions, achievedStep, targetStep),
+			Conditions: []shipper.ReleaseCondition{
+				{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue},
+			},
+			Strategy: &shipper.ReleaseStrategyStatus{
+				State: shipper.ReleaseStrategyState{
+					WaitingForInstallation: shipper.StrategyStateFalse,
+					WaitingForCommand:      shipper.StrategyStateFalse,
+					WaitingForTraffic:      shipper.StrategyStateTrue,
+					WaitingForCapacity:     shipper.StrategyStateFalse,
+				},
+				Conditions: strategyConditions,
+				Steps:      buildExpectedStepStatuses(rel, strategyConditions, targetStep),
+			},
+		},
+	}
+
 	patch, _ := json.Marshal(newStatus)
 	action := kubetesting.NewPatchAction(gvr, rel.GetNamespace(), rel.GetName(), patch)
 
@@ -1505,6 +1552,8 @@ func TestContenderDoNothingClusterInstallationNotReady(t *testing.T) {
 		contender.installationTarget.Status.Clusters[1].Status = shipper.InstallationStatusFailed
 
 		f.addObjects(
+			brokenCluster.DeepCopy(),
+
 			contender.release.DeepCopy(),
 			contender.installationTarget.DeepCopy(),
 			contender.capacityTarget.DeepCopy(),
@@ -1632,6 +1681,8 @@ func TestContenderDoNothingClusterTrafficNotReady(t *testing.T) {
 		incumbent.capacityTarget.Status.Clusters[0].AvailableReplicas = int32(replicas.CalculateDesiredReplicaCount(uint(totalReplicaCount), 50))
 
 		f.addObjects(
+			brokenCluster.DeepCopy(),
+
 			contender.release.DeepCopy(),
 			contender.installationTarget.DeepCopy(),
 			contender.capacityTarget.DeepCopy(),
@@ -1914,6 +1965,59 @@ func TestContenderReleaseIsInstalled(t *testing.T) {
 	}
 }
 
+// TestCompletedReleaseResyncDoesNothing re-syncs a completed, fully settled
+// release and checks that the fast path performs no API writes at all.
+func TestCompletedReleaseResyncDoesNothing(t *testing.T) {
+	namespace := "test-namespace"
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	totalReplicaCount := int32(10)
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	contender := f.buildContender(namespace, contenderName, totalReplicaCount)
+	incumbent := f.buildIncumbent(namespace, incumbentName, totalReplicaCount)
+
+	contender.release.Annotations[shipper.ReleaseClustersAnnotation] = cluster.GetName()
+	cond := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
+	releaseutil.SetReleaseCondition(&contender.release.Status, *cond)
+	releaseutil.SetReleaseCondition(&contender.release.Status, shipper.ReleaseCondition{Type: shipper.ReleaseConditionTypeInstalled, Status: corev1.ConditionTrue})
+	releaseutil.SetReleaseCondition(&contender.release.Status, shipper.ReleaseCondition{Type: shipper.ReleaseConditionTypeComplete, Status: corev1.ConditionTrue})
+
+	// The contender achieved the final step and everything underneath it
+	// reports the goal state.
+	contender.release.Spec.TargetStep = 2
+	contender.release.Status.AchievedStep = &shipper.AchievedStep{Step: 2, Name: "full on"}
+	contender.capacityTarget.Spec.Clusters[0].Percent = 100
+	contender.capacityTarget.Spec.Clusters[0].TotalReplicaCount = totalReplicaCount
+	contender.capacityTarget.Status.Clusters[0].AchievedPercent = 100
+	contender.capacityTarget.Status.Clusters[0].AvailableReplicas = totalReplicaCount
+	contender.trafficTarget.Spec.Clusters[0].Weight = 100
+	contender.trafficTarget.Status.Clusters[0].AchievedTraffic = 100
+
+	incumbent.trafficTarget.Spec.Clusters[0].Weight = 0
+	incumbent.trafficTarget.Status.Clusters[0].AchievedTraffic = 0
+	incumbent.capacityTarget.Spec.Clusters[0].Percent = 0
+	incumbent.capacityTarget.Status.Clusters[0].AchievedPercent = 0
+	incumbent.capacityTarget.Status.Clusters[0].AvailableReplicas = 0
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+	)
+
+	// No expected actions: the fast path must not write anything.
+	f.run()
+}
+
 func workingOnContenderCapacity(percent int, wg *sync.WaitGroup, t *testing.T) {
 	namespace := "test-namespace"
 	incumbentName, contenderName := "test-incumbent", "test-contender"
@@ -2138,3 +2242,53 @@ func TestShouldNotProducePatches(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+// TestChildUpdateBurstCoalesces checks that a burst of child status updates
+// results in a single queued reconcile for the owning release, rather than
+// one per update.
+func TestChildUpdateBurstCoalesces(t *testing.T) {
+	f := newFixture(t)
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, 0)
+	f.recorder = record.NewFakeRecorder(42)
+	controller := f.newController()
+
+	ct := &shipper.CapacityTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: shippertesting.TestNamespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: shipper.SchemeGroupVersion.String(),
+					Kind:       "Release",
+					Name:       "test-release",
+				},
+			},
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		controller.enqueueCapacityTargetDebounced(ct)
+	}
+
+	if controller.releaseWorkqueue.Len() != 0 {
+		t.Fatalf("expected the debounced enqueues to be delayed, but %d keys are already queued",
+			controller.releaseWorkqueue.Len())
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	wait.PollUntil(
+		10*time.Millisecond,
+		func() (bool, error) { return controller.releaseWorkqueue.Len() >= 1, nil },
+		stopCh,
+	)
+
+	// Give any non-coalesced enqueues a chance to land before counting.
+	time.Sleep(2 * childUpdateDebounce)
+
+	if qlen := controller.releaseWorkqueue.Len(); qlen != 1 {
+		t.Fatalf("expected the burst of child updates to coalesce into 1 queued reconcile, got %d", qlen)
+	}
+}
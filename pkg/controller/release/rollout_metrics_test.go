@@ -0,0 +1,112 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippermetrics "github.com/bookingcom/shipper/pkg/metrics/prometheus"
+)
+
+// TestRolloutMetricsOnCompletion drives a release across the completion
+// boundary and checks that the outcome counter and duration histogram are
+// observed exactly once, even when the finished release is re-synced.
+func TestRolloutMetricsOnCompletion(t *testing.T) {
+	c := &Controller{rolloutMetrics: shippermetrics.NewRolloutMetrics()}
+
+	oldRel := buildReleaseForMetrics("test-app", time.Now().Add(-30*time.Minute))
+	newRel := oldRel.DeepCopy()
+	newRel.Status.Conditions = []shipper.ReleaseCondition{
+		{Type: shipper.ReleaseConditionTypeComplete, Status: corev1.ConditionTrue},
+	}
+
+	c.recordRolloutTransitions(oldRel, newRel)
+	// A re-sync of the already-complete release must not count again.
+	c.recordRolloutTransitions(newRel, newRel.DeepCopy())
+
+	outcomes := counterValue(t, c.rolloutMetrics.Outcomes.WithLabelValues("test-app", shippermetrics.OutcomeCompleted))
+	if outcomes != 1 {
+		t.Errorf("expected 1 completed outcome, got %v", outcomes)
+	}
+
+	count, sum := histogramValue(t, c.rolloutMetrics.Duration.WithLabelValues("test-app"))
+	if count != 1 {
+		t.Errorf("expected 1 duration observation, got %d", count)
+	}
+	if sum < (29 * time.Minute).Seconds() {
+		t.Errorf("expected a rollout duration of roughly 30 minutes, got %v seconds", sum)
+	}
+}
+
+// TestRolloutMetricsOnStepPatch checks that moving the target step counts as
+// an intervention, and that moving it below the achieved step also counts as
+// a rollback outcome.
+func TestRolloutMetricsOnStepPatch(t *testing.T) {
+	c := &Controller{rolloutMetrics: shippermetrics.NewRolloutMetrics()}
+
+	oldRel := buildReleaseForMetrics("test-app", time.Now())
+	oldRel.Spec.TargetStep = 2
+	oldRel.Status.AchievedStep = &shipper.AchievedStep{Step: 2}
+
+	newRel := oldRel.DeepCopy()
+	newRel.Spec.TargetStep = 0
+
+	c.recordRolloutTransitions(oldRel, newRel)
+
+	patches := counterValue(t, c.rolloutMetrics.StepPatches.WithLabelValues("test-app"))
+	if patches != 1 {
+		t.Errorf("expected 1 step patch, got %v", patches)
+	}
+
+	rollbacks := counterValue(t, c.rolloutMetrics.Outcomes.WithLabelValues("test-app", shippermetrics.OutcomeRolledBack))
+	if rollbacks != 1 {
+		t.Errorf("expected 1 rolled back outcome, got %v", rollbacks)
+	}
+}
+
+func buildReleaseForMetrics(appName string, createdAt time.Time) *shipper.Release {
+	return &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              appName + "-deadbeef-0",
+			Namespace:         "test-namespace",
+			CreationTimestamp: metav1.NewTime(createdAt),
+			Labels: map[string]string{
+				shipper.AppLabel: appName,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: shipper.SchemeGroupVersion.String(),
+					Kind:       "Application",
+					Name:       appName,
+				},
+			},
+		},
+	}
+}
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	t.Helper()
+
+	var metric dto.Metric
+	if err := counter.Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+
+	return metric.GetCounter().GetValue()
+}
+
+func histogramValue(t *testing.T, observer prometheus.Observer) (uint64, float64) {
+	t.Helper()
+
+	var metric dto.Metric
+	if err := observer.(prometheus.Histogram).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+
+	return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+}
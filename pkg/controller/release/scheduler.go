@@ -105,27 +105,36 @@ func (s *Scheduler) ScheduleRelease(rel *shipper.Release) (*shipper.Release, err
 		return nil, shippererrors.NewUnrecoverableError(fmt.Errorf("release %q clusters have not been chosen yet", metaKey))
 	}
 
-	replicaCount, err := s.fetchChartAndExtractReplicaCount(rel)
-	if err != nil {
-		return nil, err
-	}
+	// Install-only releases get no capacity or traffic orchestration at
+	// all: only the installation target exists for them, and their charts
+	// don't have to render a Deployment for a replica count either.
+	if releaseutil.ReleaseInstallOnly(rel) {
+		if _, err := s.CreateOrUpdateInstallationTarget(rel); err != nil {
+			return nil, err
+		}
+	} else {
+		replicaCount, err := s.fetchChartAndExtractReplicaCount(rel)
+		if err != nil {
+			return nil, err
+		}
 
-	releaseErrors := shippererrors.NewMultiError()
+		releaseErrors := shippererrors.NewMultiError()
 
-	if _, err := s.CreateOrUpdateInstallationTarget(rel); err != nil {
-		releaseErrors.Append(err)
-	}
+		if _, err := s.CreateOrUpdateInstallationTarget(rel); err != nil {
+			releaseErrors.Append(err)
+		}
 
-	if _, err := s.CreateOrUpdateTrafficTarget(rel); err != nil {
-		releaseErrors.Append(err)
-	}
+		if _, err := s.CreateOrUpdateTrafficTarget(rel); err != nil {
+			releaseErrors.Append(err)
+		}
 
-	if _, err := s.CreateOrUpdateCapacityTarget(rel, replicaCount); err != nil {
-		releaseErrors.Append(err)
-	}
+		if _, err := s.CreateOrUpdateCapacityTarget(rel, replicaCount); err != nil {
+			releaseErrors.Append(err)
+		}
 
-	if releaseErrors.Any() {
-		return nil, releaseErrors.Flatten()
+		if releaseErrors.Any() {
+			return nil, releaseErrors.Flatten()
+		}
 	}
 
 	if !releaseutil.ReleaseInstalled(rel) && !releaseutil.ReleaseScheduled(rel) && !releaseutil.ReleaseComplete(rel) {
@@ -236,19 +245,36 @@ func setCapacityTargetClusters(ct *shipper.CapacityTarget, clusters []string, to
 	ct.Spec.Clusters = capacityTargetClusters
 }
 
-func setTrafficTargetClusters(tt *shipper.TrafficTarget, clusters []string) {
+func setTrafficTargetClusters(tt *shipper.TrafficTarget, clusters []string, verifyOnly map[string]bool) {
 	trafficTargetClusters := make([]shipper.ClusterTrafficTarget, 0, len(clusters))
 	for _, cluster := range clusters {
 		trafficTargetClusters = append(
 			trafficTargetClusters,
 			shipper.ClusterTrafficTarget{
-				Name:   cluster,
-				Weight: 0,
+				Name:       cluster,
+				Weight:     0,
+				VerifyOnly: verifyOnly[cluster],
 			})
 	}
 	tt.Spec.Clusters = trafficTargetClusters
 }
 
+// verifyOnlyClusters returns, for the given cluster names, which of them are
+// marked verify-only in their Cluster spec.
+func (s *Scheduler) verifyOnlyClusters(clusters []string) (map[string]bool, error) {
+	verifyOnly := make(map[string]bool, len(clusters))
+	for _, name := range clusters {
+		cluster, err := s.clusterLister.Get(name)
+		if err != nil {
+			return nil, shippererrors.NewKubeclientGetError("", name, err).
+				WithShipperKind("Cluster")
+		}
+		verifyOnly[name] = cluster.Spec.VerifyOnly
+	}
+
+	return verifyOnly, nil
+}
+
 func (s *Scheduler) CreateOrUpdateInstallationTarget(rel *shipper.Release) (*shipper.InstallationTarget, error) {
 	clusters := getReleaseClusters(rel)
 
@@ -270,6 +296,11 @@ func (s *Scheduler) CreateOrUpdateInstallationTarget(rel *shipper.Release) (*shi
 				},
 			},
 		}
+		if rel.GetAnnotations()[shipper.InstallationAdoptExistingObjectsAnnotation] == shipper.True {
+			it.Annotations = map[string]string{
+				shipper.InstallationAdoptExistingObjectsAnnotation: shipper.True,
+			}
+		}
 		setInstallationTargetClusters(it, clusters)
 
 		updIt, err := s.clientset.ShipperV1alpha1().InstallationTargets(rel.GetNamespace()).Create(it)
@@ -408,6 +439,11 @@ func (s *Scheduler) CreateOrUpdateCapacityTarget(rel *shipper.Release, totalRepl
 func (s *Scheduler) CreateOrUpdateTrafficTarget(rel *shipper.Release) (*shipper.TrafficTarget, error) {
 	clusters := getReleaseClusters(rel)
 
+	verifyOnly, err := s.verifyOnlyClusters(clusters)
+	if err != nil {
+		return nil, err
+	}
+
 	tt, err := s.trafficTargetLister.TrafficTargets(rel.GetNamespace()).Get(rel.GetName())
 	if err != nil {
 		if !errors.IsNotFound(err) {
@@ -426,7 +462,7 @@ func (s *Scheduler) CreateOrUpdateTrafficTarget(rel *shipper.Release) (*shipper.
 				},
 			},
 		}
-		setTrafficTargetClusters(tt, clusters)
+		setTrafficTargetClusters(tt, clusters, verifyOnly)
 
 		updTt, err := s.clientset.ShipperV1alpha1().TrafficTargets(rel.GetNamespace()).Create(tt)
 		if err != nil {
@@ -462,7 +498,7 @@ func (s *Scheduler) CreateOrUpdateTrafficTarget(rel *shipper.Release) (*shipper.
 		glog.Infof("Updating TrafficTarget %q clusters to %s",
 			controller.MetaKey(tt),
 			strings.Join(clusters, ","))
-		setTrafficTargetClusters(tt, clusters)
+		setTrafficTargetClusters(tt, clusters, verifyOnly)
 		updTt, err := s.clientset.ShipperV1alpha1().TrafficTargets(rel.GetNamespace()).Update(tt)
 		if err != nil {
 			glog.Errorf("Failed to update TrafficTarget %q clusters: %s",
@@ -505,6 +541,16 @@ func computeTargetClusters(rel *shipper.Release, clusterList []*shipper.Cluster)
 		return nil, err
 	}
 
+	// A clusterSelector narrows the candidates further by Cluster labels,
+	// generalizing region/capability matching to arbitrary expressions.
+	var clusterSelector labels.Selector
+	if sel := rel.Spec.Environment.ClusterRequirements.ClusterSelector; sel != nil {
+		clusterSelector, err = metav1.LabelSelectorAsSelector(sel)
+		if err != nil {
+			return nil, shippererrors.NewInvalidClusterSelectorError(err)
+		}
+	}
+
 	prefList := buildPrefList(app, clusterList)
 	// This algo could probably build up hashes instead of doing linear searches,
 	// but these data sets are so tiny (1-20 items) that it'd only be useful for
@@ -519,12 +565,19 @@ func computeTargetClusters(rel *shipper.Release, clusterList []*shipper.Cluster)
 
 		matchedRegion := 0
 		for _, cluster := range prefList {
-			if cluster.Spec.Scheduler.Unschedulable {
+			if cluster.Spec.Scheduler.Unschedulable || cluster.Spec.Decommission {
 				continue
 			}
 
 			if cluster.Spec.Region == region.Name {
 				matchedRegion++
+
+				// Like an unmet capability, a label mismatch keeps the
+				// cluster out of the capable set without hiding it from
+				// the region count.
+				if clusterSelector != nil && !clusterSelector.Matches(labels.Set(cluster.Labels)) {
+					continue
+				}
 				capabilityMatch := 0
 				for _, requiredCapability := range requiredCapabilities {
 					for _, providedCapability := range cluster.Spec.Capabilities {
@@ -592,12 +645,35 @@ func validateClusterRequirements(requirements shipper.ClusterRequirements) error
 }
 
 func setReleaseClusters(rel *shipper.Release, clusters []*shipper.Cluster) {
-	clusterNames := make([]string, 0, len(clusters))
-	for _, cluster := range clusters {
+	sortedClusters := append([]*shipper.Cluster(nil), clusters...)
+	sort.Slice(sortedClusters, func(i, j int) bool {
+		return sortedClusters[i].Name < sortedClusters[j].Name
+	})
+
+	requiredCapabilities := rel.Spec.Environment.ClusterRequirements.Capabilities
+
+	clusterNames := make([]string, 0, len(sortedClusters))
+	chosenClusters := make([]shipper.ChosenCluster, 0, len(sortedClusters))
+	for _, cluster := range sortedClusters {
 		clusterNames = append(clusterNames, cluster.Name)
+
+		reason := fmt.Sprintf("matched region %q", cluster.Spec.Region)
+		if len(requiredCapabilities) > 0 {
+			reason = fmt.Sprintf("%s and capabilities %q", reason, strings.Join(requiredCapabilities, ","))
+		}
+		if sel := rel.Spec.Environment.ClusterRequirements.ClusterSelector; sel != nil {
+			reason = fmt.Sprintf("%s and selector %q", reason, metav1.FormatLabelSelector(sel))
+		}
+
+		chosenClusters = append(chosenClusters, shipper.ChosenCluster{
+			Name:   cluster.Name,
+			Region: cluster.Spec.Region,
+			Reason: reason,
+		})
 	}
-	sort.Strings(clusterNames)
+
 	rel.Annotations[shipper.ReleaseClustersAnnotation] = strings.Join(clusterNames, ",")
+	rel.Status.Clusters = chosenClusters
 }
 
 func (s *Scheduler) fetchChartAndExtractReplicaCount(rel *shipper.Release) (int32, error) {
@@ -628,7 +704,17 @@ func extractReplicasFromChartForRel(chart *helmchart.Chart, rel *shipper.Release
 	}
 
 	applicationName := owners[0].Name
-	rendered, err := shipperchart.Render(chart, applicationName, rel.Namespace, rel.Spec.Environment.Values)
+	values, err := shipperchart.EnvironmentValues(chart, &rel.Spec.Environment)
+	if err != nil {
+		return 0, shippererrors.NewBrokenChartError(
+			rel.Spec.Environment.Chart.Name,
+			rel.Spec.Environment.Chart.Version,
+			rel.Spec.Environment.Chart.RepoURL,
+			err,
+		)
+	}
+
+	rendered, err := shipperchart.Render(chart, applicationName, rel.Namespace, values)
 	if err != nil {
 		return 0, shippererrors.NewBrokenChartError(
 			rel.Spec.Environment.Chart.Name,
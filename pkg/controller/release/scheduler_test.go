@@ -18,6 +18,7 @@ import (
 	shipperchart "github.com/bookingcom/shipper/pkg/chart"
 	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
 )
@@ -323,6 +324,18 @@ func TestSchedule(t *testing.T) {
 	// the client in alphabetical order.
 	expected := release.DeepCopy()
 	expected.Annotations[shipper.ReleaseClustersAnnotation] = clusterA.GetName() + "," + clusterB.GetName()
+	expected.Status.Clusters = []shipper.ChosenCluster{
+		{
+			Name:   clusterA.GetName(),
+			Region: shippertesting.TestRegion,
+			Reason: fmt.Sprintf("matched region %q", shippertesting.TestRegion),
+		},
+		{
+			Name:   clusterB.GetName(),
+			Region: shippertesting.TestRegion,
+			Reason: fmt.Sprintf("matched region %q", shippertesting.TestRegion),
+		},
+	}
 
 	relWithConditions := expected.DeepCopy()
 
@@ -362,6 +375,13 @@ func TestScheduleSkipsUnschedulable(t *testing.T) {
 	// clusters containing the schedulable cluster we've added to the client.
 	expected := release.DeepCopy()
 	expected.Annotations[shipper.ReleaseClustersAnnotation] = clusterA.GetName()
+	expected.Status.Clusters = []shipper.ChosenCluster{
+		{
+			Name:   clusterA.GetName(),
+			Region: shippertesting.TestRegion,
+			Reason: fmt.Sprintf("matched region %q", shippertesting.TestRegion),
+		},
+	}
 
 	relWithConditions := expected.DeepCopy()
 
@@ -384,6 +404,47 @@ func TestScheduleSkipsUnschedulable(t *testing.T) {
 	shippertesting.CheckActions(expectedActions, filteredActions, t)
 }
 
+// TestForceRescheduleMovesReleaseOffDecommissioningCluster schedules a release
+// on a cluster that is then marked for decommission, and checks that a forced
+// re-choose moves the release to a cluster that is still in service before the
+// decommissioning one can go away.
+func TestForceRescheduleMovesReleaseOffDecommissioningCluster(t *testing.T) {
+	// Fixtures
+	clusterA := buildCluster("minikube-a")
+	clusterB := buildCluster("minikube-b")
+	clusterB.Spec.Decommission = true
+	release := buildRelease()
+	release.Annotations[shipper.ReleaseClustersAnnotation] = clusterB.GetName()
+	fixtures := []runtime.Object{clusterA, clusterB, release}
+
+	// The release should end up annotated with the remaining in-service
+	// cluster only.
+	expected := release.DeepCopy()
+	expected.Annotations[shipper.ReleaseClustersAnnotation] = clusterA.GetName()
+	expected.Status.Clusters = []shipper.ChosenCluster{
+		{
+			Name:   clusterA.GetName(),
+			Region: shippertesting.TestRegion,
+			Reason: fmt.Sprintf("matched region %q", shippertesting.TestRegion),
+		},
+	}
+
+	expectedActions := []kubetesting.Action{
+		kubetesting.NewUpdateAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			release.GetNamespace(),
+			expected),
+	}
+
+	c, clientset := newScheduler(fixtures)
+	if _, err := c.ChooseClusters(release.DeepCopy(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	filteredActions := filterActions(clientset.Actions(), []string{"update"}, []string{"releases"})
+	shippertesting.CheckActions(expectedActions, filteredActions, t)
+}
+
 // TestCreateAssociatedObjects checks whether the associated object set is being
 // created while a release is being scheduled. In a normal case scenario, all 3
 // objects do not exist by the moment of scheduling, therefore 3 extra create
@@ -740,7 +801,7 @@ func TestCreateAssociatedObjectsDuplicateTrafficTargetSameOwner(t *testing.T) {
 			},
 		},
 	}
-	setTrafficTargetClusters(traffictarget, []string{cluster.Name})
+	setTrafficTargetClusters(traffictarget, []string{cluster.Name}, nil)
 	fixtures := []runtime.Object{cluster, release, traffictarget}
 
 	// Expected release and actions. Even with an existing traffictarget
@@ -1175,3 +1236,63 @@ func TestComputeTargetClusters(t *testing.T) {
 		passingCase,
 	)
 }
+
+// TestComputeTargetClustersBySelector exercises the clusterSelector
+// requirement: clusters are narrowed by their labels with standard selector
+// syntax, on top of the usual region matching.
+func TestComputeTargetClustersBySelector(t *testing.T) {
+	buildLabeledCluster := func(name int, region string, clusterLabels map[string]string) *shipper.Cluster {
+		cluster := generateClusterForTestCase(name, shipper.ClusterSpec{Region: region})
+		cluster.Labels = clusterLabels
+		return cluster
+	}
+
+	clusterList := []*shipper.Cluster{
+		buildLabeledCluster(0, "matches", map[string]string{"tier": "gold", "gpu": "true"}),
+		buildLabeledCluster(1, "matches", map[string]string{"tier": "silver", "gpu": "true"}),
+		buildLabeledCluster(2, "matches", map[string]string{"tier": "gold"}),
+	}
+
+	release := generateReleaseForTestCase(shipper.ClusterRequirements{
+		Regions: []shipper.RegionRequirement{{Name: "matches"}},
+		ClusterSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"gold"}},
+				{Key: "gpu", Operator: metav1.LabelSelectorOpExists},
+			},
+		},
+	})
+
+	chosen, err := computeTargetClusters(release, clusterList)
+	if err != nil {
+		t.Fatalf("computing target clusters failed: %s", err)
+	}
+
+	if len(chosen) != 1 || chosen[0].Name != "cluster-0" {
+		names := make([]string, 0, len(chosen))
+		for _, cluster := range chosen {
+			names = append(names, cluster.Name)
+		}
+		t.Errorf("expected only cluster-0 to match the selector, got %q", strings.Join(names, ","))
+	}
+
+	// With no cluster satisfying the selector the region has candidates
+	// but none are capable, which is an error.
+	release.Spec.Environment.ClusterRequirements.ClusterSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"tier": "platinum"},
+	}
+	if _, err := computeTargetClusters(release, clusterList); err == nil {
+		t.Errorf("expected an error when no cluster matches the selector")
+	}
+
+	// A selector that doesn't parse is rejected outright.
+	release.Spec.Environment.ClusterRequirements.ClusterSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: "Frobnicate"},
+		},
+	}
+	_, err = computeTargetClusters(release, clusterList)
+	if _, ok := err.(shippererrors.InvalidClusterSelectorError); !ok {
+		t.Errorf("expected an InvalidClusterSelectorError for a malformed selector, got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package release
+
+// instabilityEventCount sums the recent instability events the contender's
+// clusters report in their capacity target status.
+func (s *Executor) instabilityEventCount() int32 {
+	var total int32
+	for _, cluster := range s.contender.capacityTarget.Status.Clusters {
+		total += cluster.InstabilityEvents
+	}
+
+	return total
+}
+
+// stabilityExceeded reports whether the strategy's stability policy is
+// currently holding the rollout back: the contender's pods emitted more
+// recent crash/restart/OOM events than the policy allows. The capacity
+// controller ages events out of the count, so the pause lifts on its own
+// once the burst subsides.
+func (s *Executor) stabilityExceeded() (int32, bool) {
+	policy := s.strategy.StabilityPolicy
+	if policy == nil {
+		return 0, false
+	}
+
+	events := s.instabilityEventCount()
+
+	return events, events > policy.MaxEvents
+}
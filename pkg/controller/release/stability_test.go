@@ -0,0 +1,102 @@
+package release
+
+import (
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/conditions"
+)
+
+// buildStabilityExecutor returns an executor for a contender at the last step
+// of a two-step strategy that allows at most 3 recent instability events.
+func buildStabilityExecutor() *Executor {
+	executor := buildFinalStepExecutor()
+	executor.strategy.FinalStepPolicy = nil
+	executor.strategy.StabilityPolicy = &shipper.StabilityPolicy{MaxEvents: 3}
+	executor.contender.release.Spec.Environment.Strategy.FinalStepPolicy = nil
+
+	return executor
+}
+
+// TestStabilityPolicyPausesOnEventBurst feeds the contender a burst of pod
+// restart events through its capacity status and checks that the rollout
+// pauses with WaitingForStability instead of finishing the step.
+func TestStabilityPolicyPausesOnEventBurst(t *testing.T) {
+	executor := buildStabilityExecutor()
+	executor.contender.capacityTarget.Status.Clusters[0].InstabilityEvents = 5
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusPatch := releaseStatusPatch(t, patches)
+
+	if hasCompleteCondition(statusPatch.NewStatus) {
+		t.Error("expected the release to stay incomplete during an event burst")
+	}
+
+	if state := statusPatch.NewStatus.Strategy.State.WaitingForStability; state != shipper.StrategyStateTrue {
+		t.Errorf("expected WaitingForStability to be True, got %q", state)
+	}
+
+	var reason string
+	for _, condition := range statusPatch.NewStatus.Strategy.Conditions {
+		if condition.Type == shipper.StrategyConditionContenderAchievedCapacity {
+			reason = condition.Reason
+		}
+	}
+	if reason != conditions.WaitingForStability {
+		t.Errorf("expected the capacity condition to carry reason %q, got %q", conditions.WaitingForStability, reason)
+	}
+}
+
+// TestStabilityPolicyResumesAfterBurstSubsides checks that a count back under
+// the threshold lets the rollout finish the step.
+func TestStabilityPolicyResumesAfterBurstSubsides(t *testing.T) {
+	executor := buildStabilityExecutor()
+	executor.contender.capacityTarget.Status.Clusters[0].InstabilityEvents = 2
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusPatch := releaseStatusPatch(t, patches)
+
+	if !hasCompleteCondition(statusPatch.NewStatus) {
+		t.Error("expected the release to complete once the burst subsided")
+	}
+
+	if state := statusPatch.NewStatus.Strategy.State.WaitingForStability; state != shipper.StrategyStateFalse {
+		t.Errorf("expected WaitingForStability to be False, got %q", state)
+	}
+}
+
+// TestCommandStatusExplainsHeldAdvance checks the glanceable command summary:
+// a targetStep advance held by an unmet stability check says why the command
+// isn't moving, while a release with nothing in the way simply awaits the
+// next command.
+func TestCommandStatusExplainsHeldAdvance(t *testing.T) {
+	executor := buildStabilityExecutor()
+	executor.contender.capacityTarget.Status.Clusters[0].InstabilityEvents = 5
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusPatch := releaseStatusPatch(t, patches)
+
+	expected := "command accepted but held because: 5 recent instability events exceed the policy's 3"
+	if got := statusPatch.NewStatus.Strategy.CommandStatus; got != expected {
+		t.Errorf("expected command status %q, got %q", expected, got)
+	}
+
+	// With no hold in the way, an achieved step just awaits the operator.
+	executor = buildStabilityExecutor()
+	state := shipper.ReleaseStrategyState{WaitingForCommand: shipper.StrategyStateTrue}
+	if got := executor.commandStatus(state); got != "awaiting command" {
+		t.Errorf("expected an idle release to report %q, got %q", "awaiting command", got)
+	}
+}
@@ -22,11 +22,23 @@ type TrafficTargetOutdatedResult struct {
 	NewSpec *shipper.TrafficTargetSpec
 }
 
+type InstallationTargetOutdatedResult struct {
+	Name    string
+	NewSpec *shipper.InstallationTargetSpec
+}
+
 type ReleaseUpdateResult struct {
 	Name      string
 	NewStatus *shipper.ReleaseStatus
 }
 
+// ReleaseRollbackResult moves a release's target step, used when a final-step
+// policy decides to roll the release back on its own.
+type ReleaseRollbackResult struct {
+	Name       string
+	TargetStep int32
+}
+
 func (c *CapacityTargetOutdatedResult) PatchSpec() (string, schema.GroupVersionKind, []byte) {
 	patch := make(map[string]interface{})
 	patch["spec"] = c.NewSpec
@@ -49,6 +61,17 @@ func (c *TrafficTargetOutdatedResult) PatchSpec() (string, schema.GroupVersionKi
 	}, b
 }
 
+func (c *InstallationTargetOutdatedResult) PatchSpec() (string, schema.GroupVersionKind, []byte) {
+	patch := make(map[string]interface{})
+	patch["spec"] = c.NewSpec
+	b, _ := json.Marshal(patch)
+	return c.Name, schema.GroupVersionKind{
+		Group:   shipper.SchemeGroupVersion.Group,
+		Version: shipper.SchemeGroupVersion.Version,
+		Kind:    "InstallationTarget",
+	}, b
+}
+
 func (r *ReleaseUpdateResult) PatchSpec() (string, schema.GroupVersionKind, []byte) {
 	patch := make(map[string]interface{})
 	patch["status"] = r.NewStatus
@@ -59,3 +82,17 @@ func (r *ReleaseUpdateResult) PatchSpec() (string, schema.GroupVersionKind, []by
 		Kind:    "Release",
 	}, b
 }
+
+func (r *ReleaseRollbackResult) PatchSpec() (string, schema.GroupVersionKind, []byte) {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"targetStep": r.TargetStep,
+		},
+	}
+	b, _ := json.Marshal(patch)
+	return r.Name, schema.GroupVersionKind{
+		Group:   shipper.SchemeGroupVersion.Group,
+		Version: shipper.SchemeGroupVersion.Version,
+		Kind:    "Release",
+	}, b
+}
@@ -0,0 +1,210 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+	"github.com/bookingcom/shipper/pkg/util/replicas"
+)
+
+// TestStrategyTransitionsDeliverWatchEvents walks a strategy step through each
+// of its transitions and asserts that every one of them lands as a Release
+// update a Watch on the fake clientset delivers. UIs follow rollouts by
+// watching, so no transition may live only in the controller's memory.
+//
+// The generated fake clientset's watch isn't fed by its object tracker, and
+// its tracker doesn't react to patch actions at all, so each stage prepends a
+// reactor that applies the patch and forwards the patched Release to the
+// watcher, exactly like the apiserver would.
+func TestStrategyTransitionsDeliverWatchEvents(t *testing.T) {
+	namespace := "test-namespace"
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	totalReplicaCount := int32(10)
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	achievedContenderCapacity := func(contender *releaseInfo) {
+		contender.capacityTarget.Spec.Clusters[0].Percent = 50
+		contender.capacityTarget.Spec.Clusters[0].TotalReplicaCount = totalReplicaCount
+		contender.capacityTarget.Status.Clusters[0].AchievedPercent = 50
+		contender.capacityTarget.Status.Clusters[0].AvailableReplicas = int32(replicas.CalculateDesiredReplicaCount(uint(totalReplicaCount), 50))
+	}
+	achievedContenderTraffic := func(contender *releaseInfo) {
+		contender.trafficTarget.Spec.Clusters[0].Weight = 50
+		contender.trafficTarget.Status.Clusters[0].AchievedTraffic = 50
+	}
+	achievedIncumbentTraffic := func(incumbent *releaseInfo) {
+		incumbent.trafficTarget.Spec.Clusters[0].Weight = 50
+		incumbent.trafficTarget.Status.Clusters[0].AchievedTraffic = 50
+	}
+	achievedIncumbentCapacity := func(incumbent *releaseInfo) {
+		incumbent.capacityTarget.Spec.Clusters[0].Percent = 50
+		incumbent.capacityTarget.Status.Clusters[0].AchievedPercent = 50
+		incumbent.capacityTarget.Status.Clusters[0].AvailableReplicas = int32(replicas.CalculateDesiredReplicaCount(uint(totalReplicaCount), 50))
+	}
+
+	stages := []struct {
+		name         string
+		setup        func(contender, incumbent *releaseInfo)
+		expectTarget bool
+	}{
+		{
+			name: "installation pending",
+			setup: func(contender, incumbent *releaseInfo) {
+				contender.installationTarget.Status.Clusters = nil
+			},
+		},
+		{
+			name:         "contender capacity pending",
+			setup:        func(contender, incumbent *releaseInfo) {},
+			expectTarget: true,
+		},
+		{
+			name: "contender traffic pending",
+			setup: func(contender, incumbent *releaseInfo) {
+				achievedContenderCapacity(contender)
+			},
+			expectTarget: true,
+		},
+		{
+			name: "incumbent traffic pending",
+			setup: func(contender, incumbent *releaseInfo) {
+				achievedContenderCapacity(contender)
+				achievedContenderTraffic(contender)
+			},
+			expectTarget: true,
+		},
+		{
+			name: "incumbent capacity pending",
+			setup: func(contender, incumbent *releaseInfo) {
+				achievedContenderCapacity(contender)
+				achievedContenderTraffic(contender)
+				achievedIncumbentTraffic(incumbent)
+			},
+			expectTarget: true,
+		},
+		{
+			name: "step achieved",
+			setup: func(contender, incumbent *releaseInfo) {
+				achievedContenderCapacity(contender)
+				achievedContenderTraffic(contender)
+				achievedIncumbentTraffic(incumbent)
+				achievedIncumbentCapacity(incumbent)
+			},
+		},
+	}
+
+	for _, stage := range stages {
+		f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+		contender := f.buildContender(namespace, contenderName, totalReplicaCount)
+		incumbent := f.buildIncumbent(namespace, incumbentName, totalReplicaCount)
+
+		contender.release.Annotations[shipper.ReleaseClustersAnnotation] = cluster.GetName()
+		cond := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
+		releaseutil.SetReleaseCondition(&contender.release.Status, *cond)
+		contender.release.Spec.TargetStep = 1
+
+		stage.setup(contender, incumbent)
+
+		f.addObjects(
+			contender.release.DeepCopy(),
+			contender.installationTarget.DeepCopy(),
+			contender.capacityTarget.DeepCopy(),
+			contender.trafficTarget.DeepCopy(),
+
+			incumbent.release.DeepCopy(),
+			incumbent.installationTarget.DeepCopy(),
+			incumbent.capacityTarget.DeepCopy(),
+			incumbent.trafficTarget.DeepCopy(),
+		)
+
+		f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+		f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, 0)
+		f.recorder = record.NewFakeRecorder(42)
+		controller := f.newController()
+
+		stopCh := make(chan struct{})
+		f.informerFactory.Start(stopCh)
+		f.informerFactory.WaitForCacheSync(stopCh)
+
+		// Apply release patches to the tracker's copy and forward the
+		// result to a watcher served to new Watch calls; the generated
+		// fake's object tracker doesn't react to patch actions itself.
+		watcher := watch.NewRaceFreeFake()
+		releaseLister := f.informerFactory.Shipper().V1alpha1().Releases().Lister()
+		f.clientset.PrependReactor("patch", "releases", func(action kubetesting.Action) (bool, runtime.Object, error) {
+			patchAction := action.(kubetesting.PatchAction)
+			rel, err := releaseLister.Releases(patchAction.GetNamespace()).Get(patchAction.GetName())
+			if err != nil {
+				return true, nil, err
+			}
+			rel = rel.DeepCopy()
+			if err := json.Unmarshal(patchAction.GetPatch(), rel); err != nil {
+				return true, nil, err
+			}
+			watcher.Modify(rel.DeepCopy())
+			return true, rel, nil
+		})
+		f.clientset.PrependWatchReactor("releases", kubetesting.DefaultWatchReactor(watcher, nil))
+
+		w, err := f.clientset.ShipperV1alpha1().Releases(namespace).Watch(metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("stage %q: %s", stage.name, err)
+		}
+
+		appKey := fmt.Sprintf("%s/%s", namespace, app.GetName())
+		if err := controller.syncOneApplicationHandler(appKey); err != nil {
+			t.Fatalf("stage %q: %s", stage.name, err)
+		}
+
+		var rel *shipper.Release
+		select {
+		case ev := <-w.ResultChan():
+			var ok bool
+			if rel, ok = ev.Object.(*shipper.Release); !ok {
+				t.Fatalf("stage %q: watch delivered a %T, not a Release", stage.name, ev.Object)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("stage %q: transition produced no Release update for the watcher", stage.name)
+		}
+
+		if rel.Name != contenderName {
+			t.Errorf("stage %q: expected an update of %q, got %q", stage.name, contenderName, rel.Name)
+		}
+
+		if stage.expectTarget {
+			if rel.Status.AchievingStep == nil || rel.Status.AchievingStep.Step != 1 {
+				t.Errorf("stage %q: expected the delivered update to surface achieving step 1, got %v",
+					stage.name, rel.Status.AchievingStep)
+			}
+		}
+
+		if stage.name == "step achieved" {
+			if rel.Status.AchievedStep == nil || rel.Status.AchievedStep.Step != 1 {
+				t.Errorf("stage %q: expected achieved step 1 in the delivered update, got %v",
+					stage.name, rel.Status.AchievedStep)
+			}
+			if rel.Status.AchievingStep != nil {
+				t.Errorf("stage %q: expected no achieving step once the target step is achieved, got %v",
+					stage.name, rel.Status.AchievingStep)
+			}
+		}
+
+		w.Stop()
+		close(stopCh)
+	}
+}
@@ -0,0 +1,193 @@
+package release
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// waveNow exists so tests can control time when exercising bake periods.
+var waveNow = time.Now
+
+// waveGate captures how far through the strategy's waves the contender has
+// moved toward the current target step, and which clusters may move right
+// now.
+type waveGate struct {
+	// activeClusters are the clusters allowed to move to the target step:
+	// those of completed waves plus the wave currently rolling out.
+	activeClusters map[string]bool
+	// currentWave names the wave rolling out, for status reporting.
+	currentWave string
+	// bakeUntil is the end of the bake period gating currentWave, if any.
+	bakeUntil *metav1.Time
+	// baking is true while currentWave is held back by the previous
+	// wave's bake period; nothing moves until it ends.
+	baking bool
+}
+
+// buildWaveGate works out which wave should be rolling out, based on which
+// waves have already achieved the target step's contender weights and on the
+// bake period recorded in the release's status. It returns nil when the
+// strategy defines no waves or every wave has achieved the step.
+func (s *Executor) buildWaveGate(strategyStep shipper.RolloutStrategyStep) *waveGate {
+	waves := s.strategy.Waves
+	if len(waves) == 0 {
+		return nil
+	}
+
+	var storedWave string
+	var storedBakeUntil *metav1.Time
+	if s.contender.release.Status.Strategy != nil {
+		storedWave = s.contender.release.Status.Strategy.CurrentWave
+		storedBakeUntil = s.contender.release.Status.Strategy.WaveBakeUntil
+	}
+
+	gate := &waveGate{activeClusters: make(map[string]bool)}
+	for i, wave := range waves {
+		clusters := s.waveClusterSet(waves, i)
+
+		if s.waveAchieved(clusters, strategyStep) {
+			for name := range clusters {
+				gate.activeClusters[name] = true
+			}
+			continue
+		}
+
+		gate.currentWave = wave.Name
+
+		// A wave beyond the first starts only once the previous wave's
+		// bake period has passed. Record its end the first time we see
+		// this wave pending, and hold everything until then.
+		if i > 0 && waves[i-1].BakeTime.Duration > 0 {
+			bakeUntil := storedBakeUntil
+			if storedWave != wave.Name || bakeUntil == nil {
+				t := metav1.NewTime(waveNow().Add(waves[i-1].BakeTime.Duration))
+				bakeUntil = &t
+			}
+
+			gate.bakeUntil = bakeUntil
+			if waveNow().Before(bakeUntil.Time) {
+				gate.baking = true
+				return gate
+			}
+		}
+
+		for name := range clusters {
+			gate.activeClusters[name] = true
+		}
+		return gate
+	}
+
+	// Every wave has achieved the step; no gating left to do.
+	return nil
+}
+
+// waveClusterSet returns the clusters belonging to wave i. Clusters the
+// strategy doesn't assign to any wave move with the last wave.
+func (s *Executor) waveClusterSet(waves []shipper.RolloutWave, i int) map[string]bool {
+	set := make(map[string]bool, len(waves[i].Clusters))
+	for _, name := range waves[i].Clusters {
+		set[name] = true
+	}
+
+	if i == len(waves)-1 {
+		named := make(map[string]bool)
+		for _, wave := range waves {
+			for _, name := range wave.Clusters {
+				named[name] = true
+			}
+		}
+		for _, spec := range s.contender.capacityTarget.Spec.Clusters {
+			if !named[spec.Name] {
+				set[spec.Name] = true
+			}
+		}
+	}
+
+	return set
+}
+
+// waveAchieved reports whether every cluster in the set has achieved the
+// target step's contender capacity and traffic.
+func (s *Executor) waveAchieved(clusters map[string]bool, strategyStep shipper.RolloutStrategyStep) bool {
+	ct := filterCapacityTarget(s.contender.capacityTarget, clusters)
+	if achieved, _, _ := checkCapacity(ct, uint(strategyStep.Capacity.Contender)); !achieved {
+		return false
+	}
+
+	tt := filterTrafficTarget(s.contender.trafficTarget, clusters)
+	achieved, _, _ := checkTraffic(tt, uint32(strategyStep.Traffic.Contender), trafficTierWeights(strategyStep.TrafficTiers, true), contenderTrafficComparison)
+	return achieved
+}
+
+// bakeRemaining returns how long the current bake period still has to run,
+// or zero when the rollout isn't baking.
+func (s *Executor) bakeRemaining() time.Duration {
+	if s.gate == nil || !s.gate.baking {
+		return 0
+	}
+	return s.gate.bakeUntil.Sub(waveNow())
+}
+
+// filterCapacityTarget returns a capacity target holding only the spec and
+// status entries for the given clusters.
+func filterCapacityTarget(ct *shipper.CapacityTarget, clusters map[string]bool) *shipper.CapacityTarget {
+	filtered := &shipper.CapacityTarget{}
+	for _, spec := range ct.Spec.Clusters {
+		if clusters[spec.Name] {
+			filtered.Spec.Clusters = append(filtered.Spec.Clusters, spec)
+		}
+	}
+	for _, status := range ct.Status.Clusters {
+		if clusters[status.Name] {
+			filtered.Status.Clusters = append(filtered.Status.Clusters, status)
+		}
+	}
+	return filtered
+}
+
+// filterTrafficTarget returns a traffic target holding only the spec and
+// status entries for the given clusters.
+func filterTrafficTarget(tt *shipper.TrafficTarget, clusters map[string]bool) *shipper.TrafficTarget {
+	filtered := &shipper.TrafficTarget{}
+	for _, spec := range tt.Spec.Clusters {
+		if clusters[spec.Name] {
+			filtered.Spec.Clusters = append(filtered.Spec.Clusters, spec)
+		}
+	}
+	for _, status := range tt.Status.Clusters {
+		if clusters[status.Name] {
+			filtered.Status.Clusters = append(filtered.Status.Clusters, status)
+		}
+	}
+	return filtered
+}
+
+// mergeInactiveCapacityClusters folds the current spec entries for clusters
+// outside the active waves back into newSpec, since target specs are replaced
+// wholesale when patched.
+func mergeInactiveCapacityClusters(newSpec *shipper.CapacityTargetSpec, current shipper.CapacityTargetSpec, active map[string]bool) *shipper.CapacityTargetSpec {
+	merged := &shipper.CapacityTargetSpec{Clusters: newSpec.Clusters}
+	for _, spec := range current.Clusters {
+		if !active[spec.Name] {
+			merged.Clusters = append(merged.Clusters, spec)
+		}
+	}
+	sortCapacityClusters(merged)
+	return merged
+}
+
+// mergeInactiveTrafficClusters is mergeInactiveCapacityClusters for traffic
+// targets.
+func mergeInactiveTrafficClusters(newSpec *shipper.TrafficTargetSpec, current shipper.TrafficTargetSpec, active map[string]bool) *shipper.TrafficTargetSpec {
+	merged := &shipper.TrafficTargetSpec{Clusters: newSpec.Clusters}
+	for _, spec := range current.Clusters {
+		if !active[spec.Name] {
+			merged.Clusters = append(merged.Clusters, spec)
+		}
+	}
+	sortTrafficClusters(merged)
+	return merged
+}
@@ -0,0 +1,229 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// buildWaveExecutor returns an executor for a contender rolling out a
+// one-step strategy over two waves with a bake period between them.
+func buildWaveExecutor(aPercent, bPercent int32, aWeight, bWeight uint32, aTraffic, bTraffic uint32) *Executor {
+	strategy := shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "full on",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+			},
+		},
+		Waves: []shipper.RolloutWave{
+			{
+				Name:     "wave-1",
+				Clusters: []string{"cluster-a"},
+				BakeTime: metav1.Duration{Duration: time.Hour},
+			},
+			{
+				Name:     "wave-2",
+				Clusters: []string{"cluster-b"},
+			},
+		},
+	}
+
+	release := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: 0,
+			Environment: shipper.ReleaseEnvironment{
+				Strategy: &strategy,
+			},
+		},
+	}
+
+	installationTarget := &shipper.InstallationTarget{
+		Spec: shipper.InstallationTargetSpec{
+			Clusters: []string{"cluster-a", "cluster-b"},
+		},
+		Status: shipper.InstallationTargetStatus{
+			Clusters: []*shipper.ClusterInstallationStatus{
+				{Name: "cluster-a", Status: shipper.ReleasePhaseInstalled},
+				{Name: "cluster-b", Status: shipper.ReleasePhaseInstalled},
+			},
+		},
+	}
+
+	capacityTarget := &shipper.CapacityTarget{
+		Spec: shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "cluster-a", Percent: aPercent, TotalReplicaCount: 10},
+				{Name: "cluster-b", Percent: bPercent, TotalReplicaCount: 10},
+			},
+		},
+		Status: shipper.CapacityTargetStatus{
+			Clusters: []shipper.ClusterCapacityStatus{
+				{Name: "cluster-a", AchievedPercent: aPercent, AvailableReplicas: aPercent / 10},
+				{Name: "cluster-b", AchievedPercent: bPercent, AvailableReplicas: bPercent / 10},
+			},
+		},
+	}
+
+	trafficTarget := &shipper.TrafficTarget{
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "cluster-a", Weight: aWeight},
+				{Name: "cluster-b", Weight: bWeight},
+			},
+		},
+		Status: shipper.TrafficTargetStatus{
+			Clusters: []*shipper.ClusterTrafficStatus{
+				{Name: "cluster-a", AchievedTraffic: aTraffic},
+				{Name: "cluster-b", AchievedTraffic: bTraffic},
+			},
+		},
+	}
+
+	return &Executor{
+		contender: &releaseInfo{
+			release:            release,
+			installationTarget: installationTarget,
+			capacityTarget:     capacityTarget,
+			trafficTarget:      trafficTarget,
+		},
+		recorder: record.NewFakeRecorder(42),
+		strategy: strategy,
+	}
+}
+
+// TestWavesFirstWaveMovesAlone checks that with neither wave at the target
+// step, only the first wave's cluster is moved while the second wave's
+// cluster keeps its current spec.
+func TestWavesFirstWaveMovesAlone(t *testing.T) {
+	executor := buildWaveExecutor(0, 0, 0, 0, 0, 0)
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var capacityPatch *CapacityTargetOutdatedResult
+	var releasePatch *ReleaseUpdateResult
+	for _, patch := range patches {
+		switch p := patch.(type) {
+		case *CapacityTargetOutdatedResult:
+			capacityPatch = p
+		case *ReleaseUpdateResult:
+			releasePatch = p
+		}
+	}
+
+	if capacityPatch == nil {
+		t.Fatal("expected a capacity target patch moving the first wave")
+	}
+
+	expectedClusters := []shipper.ClusterCapacityTarget{
+		{Name: "cluster-a", Percent: 100, TotalReplicaCount: 10},
+		{Name: "cluster-b", Percent: 0, TotalReplicaCount: 10},
+	}
+
+	if len(capacityPatch.NewSpec.Clusters) != 2 ||
+		capacityPatch.NewSpec.Clusters[0] != expectedClusters[0] ||
+		capacityPatch.NewSpec.Clusters[1] != expectedClusters[1] {
+		t.Errorf("expected capacity spec clusters %v, got %v", expectedClusters, capacityPatch.NewSpec.Clusters)
+	}
+
+	if releasePatch == nil {
+		t.Fatal("expected a release status patch reporting the current wave")
+	}
+
+	if releasePatch.NewStatus.Strategy.CurrentWave != "wave-1" {
+		t.Errorf("expected current wave %q, got %q", "wave-1", releasePatch.NewStatus.Strategy.CurrentWave)
+	}
+
+	if releasePatch.NewStatus.Strategy.WaveBakeUntil != nil {
+		t.Errorf("expected no bake period before the first wave, got %v", releasePatch.NewStatus.Strategy.WaveBakeUntil)
+	}
+}
+
+// TestWavesBakeBetweenWaves checks that once the first wave achieves the
+// step, the second wave is held back for the first wave's bake time, with the
+// countdown reported in status.
+func TestWavesBakeBetweenWaves(t *testing.T) {
+	t0 := time.Now()
+	waveNow = func() time.Time { return t0 }
+	defer func() { waveNow = time.Now }()
+
+	executor := buildWaveExecutor(100, 0, 100, 0, 100, 0)
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patches) != 1 {
+		t.Fatalf("expected only a release status patch while baking, got %d patches", len(patches))
+	}
+
+	releasePatch, ok := patches[0].(*ReleaseUpdateResult)
+	if !ok {
+		t.Fatalf("expected a release status patch, got %T", patches[0])
+	}
+
+	if releasePatch.NewStatus.Strategy.CurrentWave != "wave-2" {
+		t.Errorf("expected current wave %q, got %q", "wave-2", releasePatch.NewStatus.Strategy.CurrentWave)
+	}
+
+	bakeUntil := releasePatch.NewStatus.Strategy.WaveBakeUntil
+	if bakeUntil == nil || !bakeUntil.Time.Equal(t0.Add(time.Hour)) {
+		t.Errorf("expected the bake period to end at %v, got %v", t0.Add(time.Hour), bakeUntil)
+	}
+
+	if remaining := executor.bakeRemaining(); remaining != time.Hour {
+		t.Errorf("expected one hour of bake time remaining, got %v", remaining)
+	}
+}
+
+// TestWavesSecondWaveMovesAfterBake checks that once the recorded bake period
+// has passed, the second wave's cluster moves to the target step.
+func TestWavesSecondWaveMovesAfterBake(t *testing.T) {
+	executor := buildWaveExecutor(100, 0, 100, 0, 100, 0)
+
+	bakeUntil := metav1.NewTime(time.Now().Add(-time.Minute))
+	executor.contender.release.Status.Strategy = &shipper.ReleaseStrategyStatus{
+		CurrentWave:   "wave-2",
+		WaveBakeUntil: &bakeUntil,
+	}
+
+	patches, _, err := executor.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var capacityPatch *CapacityTargetOutdatedResult
+	for _, patch := range patches {
+		if p, ok := patch.(*CapacityTargetOutdatedResult); ok {
+			capacityPatch = p
+		}
+	}
+
+	if capacityPatch == nil {
+		t.Fatal("expected a capacity target patch moving the second wave")
+	}
+
+	expectedClusters := []shipper.ClusterCapacityTarget{
+		{Name: "cluster-a", Percent: 100, TotalReplicaCount: 10},
+		{Name: "cluster-b", Percent: 100, TotalReplicaCount: 10},
+	}
+
+	if len(capacityPatch.NewSpec.Clusters) != 2 ||
+		capacityPatch.NewSpec.Clusters[0] != expectedClusters[0] ||
+		capacityPatch.NewSpec.Clusters[1] != expectedClusters[1] {
+		t.Errorf("expected capacity spec clusters %v, got %v", expectedClusters, capacityPatch.NewSpec.Clusters)
+	}
+}
@@ -0,0 +1,400 @@
+package rolloutgroup
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	clientset "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
+	informers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	listers "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+const (
+	AgentName = "rolloutgroup-controller"
+
+	// maxRetries is the number of times a rollout group will be retried
+	// before we drop it out of the workqueue. The number is chosen with the
+	// default rate limiter in mind. This results in the following backoff
+	// times: 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s,
+	// 5.1s, 10.2s.
+	maxRetries = 11
+)
+
+// Controller is a Kubernetes controller that keeps the contender releases of
+// a rollout group's member Applications advancing in lockstep: no member may
+// target a step until every member has achieved the step before it. A member
+// commanded ahead of the group has its target step held back, with the
+// operator's intent parked in an annotation and restored once the group is
+// level.
+type Controller struct {
+	shipperClientset clientset.Interface
+
+	appLister listers.ApplicationLister
+	appSynced cache.InformerSynced
+
+	releaseLister listers.ReleaseLister
+	releaseSynced cache.InformerSynced
+
+	workqueue workqueue.RateLimitingInterface
+
+	recorder record.EventRecorder
+}
+
+// member is one Application of a rollout group together with its contender
+// release and the step that release has achieved (-1 before the first step).
+type member struct {
+	app      *shipper.Application
+	release  *shipper.Release
+	achieved int32
+}
+
+// NewController returns a new RolloutGroup controller.
+func NewController(
+	shipperClientset clientset.Interface,
+	shipperInformerFactory informers.SharedInformerFactory,
+	recorder record.EventRecorder,
+) *Controller {
+	appInformer := shipperInformerFactory.Shipper().V1alpha1().Applications()
+	releaseInformer := shipperInformerFactory.Shipper().V1alpha1().Releases()
+
+	c := &Controller{
+		shipperClientset: shipperClientset,
+
+		appLister: appInformer.Lister(),
+		appSynced: appInformer.Informer().HasSynced,
+
+		releaseLister: releaseInformer.Lister(),
+		releaseSynced: releaseInformer.Informer().HasSynced,
+
+		workqueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "rolloutgroup_controller_groups"),
+
+		recorder: recorder,
+	}
+
+	appInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueueApplicationGroup,
+		UpdateFunc: func(_, new interface{}) {
+			c.enqueueApplicationGroup(new)
+		},
+		DeleteFunc: c.enqueueApplicationGroup,
+	})
+
+	// Releases carry the target and achieved steps the group coordinates
+	// on, so their changes re-sync the owning app's group.
+	releaseInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueueReleaseGroup,
+		UpdateFunc: func(_, new interface{}) {
+			c.enqueueReleaseGroup(new)
+		},
+		DeleteFunc: c.enqueueReleaseGroup,
+	})
+
+	return c
+}
+
+// Run starts RolloutGroup controller workers and blocks until stopCh is
+// closed.
+func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
+	defer runtime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	glog.V(2).Info("Starting RolloutGroup controller")
+	defer glog.V(2).Info("Shutting down RolloutGroup controller")
+
+	if !cache.WaitForCacheSync(stopCh, c.appSynced, c.releaseSynced) {
+		runtime.HandleError(fmt.Errorf("failed to sync caches for the RolloutGroup controller"))
+		return
+	}
+
+	for i := 0; i < threadiness; i++ {
+		go wait.Until(c.rolloutGroupWorker, time.Second, stopCh)
+	}
+
+	glog.V(2).Info("Started RolloutGroup controller")
+
+	<-stopCh
+}
+
+func (c *Controller) rolloutGroupWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *Controller) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+	if shutdown {
+		return false
+	}
+
+	defer c.workqueue.Done(obj)
+
+	var (
+		key string
+		ok  bool
+	)
+
+	if key, ok = obj.(string); !ok {
+		c.workqueue.Forget(obj)
+		runtime.HandleError(fmt.Errorf("invalid object key (will retry: false): %#v", obj))
+		return true
+	}
+
+	shouldRetry := false
+	err := c.syncRolloutGroup(key)
+
+	if err != nil {
+		shouldRetry = shippererrors.ShouldRetry(err)
+		runtime.HandleError(fmt.Errorf("error syncing rollout group %q (will retry: %t): %s", key, shouldRetry, err.Error()))
+	}
+
+	if shouldRetry {
+		if c.workqueue.NumRequeues(key) >= maxRetries {
+			// Drop the group's key out of the workqueue and thus reset its
+			// backoff. This limits the time a "broken" object can hog a
+			// worker.
+			glog.Warningf("rollout group %q has been retried too many times, dropping from the queue", key)
+			c.workqueue.Forget(key)
+
+			return true
+		}
+
+		c.workqueue.AddRateLimited(key)
+
+		return true
+	}
+
+	shipperhealth.RecordSuccess(AgentName)
+	glog.V(4).Infof("Successfully synced rollout group %q", key)
+	c.workqueue.Forget(obj)
+
+	return true
+}
+
+func (c *Controller) enqueueApplicationGroup(obj interface{}) {
+	app, ok := obj.(*shipper.Application)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Application: %#v", obj))
+		return
+	}
+
+	if group := app.Annotations[shipper.RolloutGroupAnnotation]; group != "" {
+		c.workqueue.Add(fmt.Sprintf("%s/%s", app.Namespace, group))
+	}
+}
+
+func (c *Controller) enqueueReleaseGroup(obj interface{}) {
+	rel, ok := obj.(*shipper.Release)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Release: %#v", obj))
+		return
+	}
+
+	appName, ok := rel.Labels[shipper.AppLabel]
+	if !ok {
+		return
+	}
+
+	app, err := c.appLister.Applications(rel.Namespace).Get(appName)
+	if err != nil {
+		// The app is gone or the cache is catching up; either way there
+		// is no group to coordinate.
+		return
+	}
+
+	c.enqueueApplicationGroup(app)
+}
+
+func (c *Controller) syncRolloutGroup(key string) error {
+	ns, group, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return shippererrors.NewUnrecoverableError(err)
+	}
+
+	members, err := c.groupMembers(ns, group)
+	if err != nil {
+		return err
+	}
+
+	// A group of one gates nothing.
+	if len(members) < 2 {
+		return nil
+	}
+
+	// No member may target further than one step past the slowest member's
+	// achieved step: everyone reaches a step before anyone moves beyond it.
+	minAchieved := members[0].achieved
+	for _, m := range members[1:] {
+		if m.achieved < minAchieved {
+			minAchieved = m.achieved
+		}
+	}
+	stepCap := minAchieved + 1
+
+	progress := groupProgress(members)
+
+	for _, m := range members {
+		if err := c.syncMember(group, m, stepCap, progress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncMember holds one member's target step at the group's cap, restores a
+// parked command once the group has caught up, and keeps the group progress
+// annotation current.
+func (c *Controller) syncMember(group string, m member, stepCap int32, progress string) error {
+	rel := m.release
+
+	// The step the operator wants is the spec's target step, or the one
+	// parked in the annotation while a previous sync held it back.
+	desired := rel.Spec.TargetStep
+	if saved, ok := rel.Annotations[shipper.RolloutGroupDesiredStepAnnotation]; ok {
+		if parsed, err := strconv.ParseInt(saved, 10, 32); err == nil && int32(parsed) > desired {
+			desired = int32(parsed)
+		}
+	}
+
+	allowed := desired
+	if allowed > stepCap {
+		allowed = stepCap
+	}
+
+	relCopy := rel.DeepCopy()
+	changed := false
+
+	if relCopy.Annotations == nil {
+		relCopy.Annotations = map[string]string{}
+	}
+
+	if allowed != relCopy.Spec.TargetStep {
+		relCopy.Spec.TargetStep = allowed
+		changed = true
+	}
+
+	if desired > allowed {
+		if saved := strconv.Itoa(int(desired)); relCopy.Annotations[shipper.RolloutGroupDesiredStepAnnotation] != saved {
+			relCopy.Annotations[shipper.RolloutGroupDesiredStepAnnotation] = saved
+			changed = true
+		}
+	} else if _, ok := relCopy.Annotations[shipper.RolloutGroupDesiredStepAnnotation]; ok {
+		delete(relCopy.Annotations, shipper.RolloutGroupDesiredStepAnnotation)
+		changed = true
+	}
+
+	if relCopy.Annotations[shipper.RolloutGroupProgressAnnotation] != progress {
+		relCopy.Annotations[shipper.RolloutGroupProgressAnnotation] = progress
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	_, err := c.shipperClientset.ShipperV1alpha1().Releases(relCopy.Namespace).Update(relCopy)
+	if err != nil {
+		return shippererrors.NewKubeclientUpdateError(relCopy, err).
+			WithShipperKind("Release")
+	}
+
+	if desired > allowed {
+		c.recorder.Eventf(rel, corev1.EventTypeNormal, "RolloutGroupHeld",
+			"Holding Release %q at step %d: rollout group %q advances to step %d only once every member achieves step %d",
+			rel.Name, allowed, group, desired, stepCap)
+	} else if allowed > rel.Spec.TargetStep {
+		c.recorder.Eventf(rel, corev1.EventTypeNormal, "RolloutGroupResumed",
+			"Rollout group %q caught up; Release %q resumes toward step %d",
+			group, rel.Name, allowed)
+	}
+
+	return nil
+}
+
+// groupMembers returns the group's member apps that have a contender release,
+// sorted by app name for deterministic progress reports.
+func (c *Controller) groupMembers(ns, group string) ([]member, error) {
+	apps, err := c.appLister.Applications(ns).List(labels.Everything())
+	if err != nil {
+		return nil, shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Application"),
+			ns, labels.Everything(), err)
+	}
+
+	var members []member
+	for _, app := range apps {
+		if app.Annotations[shipper.RolloutGroupAnnotation] != group {
+			continue
+		}
+
+		contender, err := c.contenderForApplication(app)
+		if err != nil {
+			return nil, err
+		}
+		if contender == nil {
+			continue
+		}
+
+		achieved := int32(-1)
+		if contender.Status.AchievedStep != nil {
+			achieved = contender.Status.AchievedStep.Step
+		}
+
+		members = append(members, member{app: app, release: contender, achieved: achieved})
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].app.Name < members[j].app.Name
+	})
+
+	return members, nil
+}
+
+// contenderForApplication returns the app's latest-generation release, or nil
+// when the app has none yet.
+func (c *Controller) contenderForApplication(app *shipper.Application) (*shipper.Release, error) {
+	selector := labels.Set{shipper.AppLabel: app.Name}.AsSelector()
+	rels, err := c.releaseLister.Releases(app.Namespace).List(selector)
+	if err != nil {
+		return nil, shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Release"),
+			app.Namespace, selector, err)
+	}
+
+	if len(rels) == 0 {
+		return nil, nil
+	}
+
+	return releaseutil.SortByGenerationDescending(rels)[0], nil
+}
+
+// groupProgress renders each member's achieved step in one glanceable line,
+// e.g. "api: 1; worker: 0". Members short of their first step show "none".
+func groupProgress(members []member) string {
+	parts := make([]string, 0, len(members))
+	for _, m := range members {
+		if m.achieved < 0 {
+			parts = append(parts, fmt.Sprintf("%s: none", m.app.Name))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s: %d", m.app.Name, m.achieved))
+		}
+	}
+
+	return strings.Join(parts, "; ")
+}
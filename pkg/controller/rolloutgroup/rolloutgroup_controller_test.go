@@ -0,0 +1,164 @@
+package rolloutgroup
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+// TestLaggingMemberHoldsGroup puts two apps in one rollout group: "api" has
+// achieved step 1 and was commanded to step 2, while "worker" is still
+// working toward step 1 from an achieved step 0. The api release is held at
+// step 1 with the operator's command parked in an annotation, and both
+// members get the group progress annotation.
+func TestLaggingMemberHoldsGroup(t *testing.T) {
+	f := newFixture(t)
+
+	apiApp := buildApplication("api", "pair")
+	workerApp := buildApplication("worker", "pair")
+
+	apiRel := buildContenderRelease("api", 1, 2)
+	workerRel := buildContenderRelease("worker", 0, 1)
+
+	f.objects = append(f.objects, apiApp, workerApp, apiRel, workerRel)
+
+	progress := "api: 1; worker: 0"
+
+	heldApi := apiRel.DeepCopy()
+	heldApi.Spec.TargetStep = 1
+	heldApi.Annotations[shipper.RolloutGroupDesiredStepAnnotation] = "2"
+	heldApi.Annotations[shipper.RolloutGroupProgressAnnotation] = progress
+	f.expectReleaseUpdate(heldApi)
+
+	annotatedWorker := workerRel.DeepCopy()
+	annotatedWorker.Annotations[shipper.RolloutGroupProgressAnnotation] = progress
+	f.expectReleaseUpdate(annotatedWorker)
+
+	f.run()
+}
+
+// TestGroupCaughtUpRestoresParkedCommand checks the other side of the gate:
+// once every member has achieved step 1, a release holding a parked command
+// for step 2 resumes toward it and the annotation is cleared.
+func TestGroupCaughtUpRestoresParkedCommand(t *testing.T) {
+	f := newFixture(t)
+
+	apiApp := buildApplication("api", "pair")
+	workerApp := buildApplication("worker", "pair")
+
+	progress := "api: 1; worker: 1"
+
+	apiRel := buildContenderRelease("api", 1, 1)
+	apiRel.Annotations[shipper.RolloutGroupDesiredStepAnnotation] = "2"
+	apiRel.Annotations[shipper.RolloutGroupProgressAnnotation] = "api: 1; worker: 0"
+	workerRel := buildContenderRelease("worker", 1, 1)
+	workerRel.Annotations[shipper.RolloutGroupProgressAnnotation] = "api: 1; worker: 0"
+
+	f.objects = append(f.objects, apiApp, workerApp, apiRel, workerRel)
+
+	resumedApi := apiRel.DeepCopy()
+	resumedApi.Spec.TargetStep = 2
+	delete(resumedApi.Annotations, shipper.RolloutGroupDesiredStepAnnotation)
+	resumedApi.Annotations[shipper.RolloutGroupProgressAnnotation] = progress
+	f.expectReleaseUpdate(resumedApi)
+
+	annotatedWorker := workerRel.DeepCopy()
+	annotatedWorker.Annotations[shipper.RolloutGroupProgressAnnotation] = progress
+	f.expectReleaseUpdate(annotatedWorker)
+
+	f.run()
+}
+
+func buildApplication(name, group string) *shipper.Application {
+	return &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: shippertesting.TestNamespace,
+			Annotations: map[string]string{
+				shipper.RolloutGroupAnnotation: group,
+			},
+		},
+	}
+}
+
+func buildContenderRelease(appName string, achievedStep, targetStep int32) *shipper.Release {
+	return &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName + "-deadbeef-0",
+			Namespace: shippertesting.TestNamespace,
+			Labels: map[string]string{
+				shipper.AppLabel:     appName,
+				shipper.ReleaseLabel: appName + "-deadbeef-0",
+			},
+			Annotations: map[string]string{
+				shipper.ReleaseGenerationAnnotation: "0",
+			},
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: targetStep,
+		},
+		Status: shipper.ReleaseStatus{
+			AchievedStep: &shipper.AchievedStep{Step: achievedStep},
+		},
+	}
+}
+
+type fixture struct {
+	t       *testing.T
+	client  *shipperfake.Clientset
+	actions []kubetesting.Action
+	objects []runtime.Object
+}
+
+func newFixture(t *testing.T) *fixture {
+	return &fixture{t: t}
+}
+
+func (f *fixture) newController() (*Controller, shipperinformers.SharedInformerFactory) {
+	f.client = shipperfake.NewSimpleClientset(f.objects...)
+
+	const noResyncPeriod time.Duration = 0
+	shipperInformerFactory := shipperinformers.NewSharedInformerFactory(f.client, noResyncPeriod)
+
+	c := NewController(f.client, shipperInformerFactory, record.NewFakeRecorder(42))
+
+	return c, shipperInformerFactory
+}
+
+func (f *fixture) run() {
+	c, i := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	i.Start(stopCh)
+	i.WaitForCacheSync(stopCh)
+
+	wait.PollUntil(
+		10*time.Millisecond,
+		func() (bool, error) { return c.workqueue.Len() >= 1, nil },
+		stopCh,
+	)
+
+	c.processNextWorkItem()
+
+	actual := shippertesting.FilterActions(f.client.Actions())
+	shippertesting.CheckActions(f.actions, actual, f.t)
+}
+
+func (f *fixture) expectReleaseUpdate(rel *shipper.Release) {
+	gvr := shipper.SchemeGroupVersion.WithResource("releases")
+	action := kubetesting.NewUpdateAction(gvr, rel.GetNamespace(), rel)
+
+	f.actions = append(f.actions, action)
+}
@@ -0,0 +1,21 @@
+package traffic
+
+import (
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// CloudLBBackend reports the traffic weights a cloud load balancer has
+// actually assigned to each release's backends. On clusters fronted by a
+// cloud load balancer, pod label membership alone doesn't prove traffic
+// assignment: a pod can carry the traffic label while the load balancer's
+// target group still reports it unhealthy or unregistered. Clusters opt into
+// a backend by naming it in the ClusterTrafficBackendAnnotation on their
+// Cluster object; for those clusters the backend's view replaces the
+// pod-membership computation when reporting AchievedTraffic.
+type CloudLBBackend interface {
+	// AchievedWeights returns the weight the load balancer has achieved
+	// for each release participating in the given TrafficTarget's
+	// application, keyed by release name, in the same units as the
+	// TrafficTarget spec weights.
+	AchievedWeights(clusterName string, tt *shipper.TrafficTarget) (map[string]uint32, error)
+}
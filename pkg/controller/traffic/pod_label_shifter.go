@@ -12,6 +12,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	corev1informer "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
@@ -23,9 +25,32 @@ type podLabelShifter struct {
 	namespace             string
 	serviceSelector       labels.Selector
 	clusterReleaseWeights clusterReleaseWeights
+
+	// tiers is the sorted union of service tiers named by any traffic
+	// target's ServiceWeights; empty means the classic single-Service
+	// mode. clusterTierWeights carries the per-tier release weights,
+	// keyed cluster -> tier -> release.
+	tiers              []string
+	clusterTierWeights clusterTierWeights
+
+	// preferWarmPods records, per release, whether its TrafficTarget
+	// opted into enabling the longest-ready pods first on partial ramps.
+	preferWarmPods map[string]bool
 }
 
 type clusterReleaseWeights map[string]map[string]uint32
+type clusterTierWeights map[string]map[string]map[string]uint32
+
+// trafficGate is one independently shifted slice of the app's traffic: a
+// production Service, the pod label its selector gates on, and the
+// per-release weights to program for it.
+type trafficGate struct {
+	tier            string
+	serviceName     string
+	trafficLabel    string
+	trafficSelector map[string]string
+	releaseWeights  map[string]uint32
+}
 
 func newPodLabelShifter(
 	appName string,
@@ -33,7 +58,7 @@ func newPodLabelShifter(
 	trafficTargets []*shipper.TrafficTarget,
 ) (*podLabelShifter, error) {
 
-	weights, err := buildClusterReleaseWeights(trafficTargets)
+	weights, tierWeights, tiers, err := buildClusterReleaseWeights(trafficTargets)
 	if err != nil {
 		return nil, err
 	}
@@ -43,11 +68,21 @@ func newPodLabelShifter(
 		shipper.LBLabel:  shipper.LBForProduction,
 	}
 
+	preferWarmPods := make(map[string]bool)
+	for _, tt := range trafficTargets {
+		if release, ok := tt.Labels[shipper.ReleaseLabel]; ok {
+			preferWarmPods[release] = tt.Annotations[shipper.TrafficPreferWarmPodsAnnotation] == shipper.True
+		}
+	}
+
 	return &podLabelShifter{
 		appName:               appName,
 		namespace:             namespace,
 		serviceSelector:       labels.Set(serviceSelector).AsSelector(),
 		clusterReleaseWeights: weights,
+		tiers:                 tiers,
+		clusterTierWeights:    tierWeights,
+		preferWarmPods:        preferWarmPods,
 	}, nil
 }
 
@@ -60,14 +95,17 @@ func (p *podLabelShifter) Clusters() []string {
 	return clusters
 }
 
+// SyncCluster programs every traffic gate of the application on the given
+// cluster. It returns the canonical achieved weight per release, plus (in
+// tiered mode) the per-tier breakdown, keyed tier -> release.
 func (p *podLabelShifter) SyncCluster(
 	cluster string,
 	clientset kubernetes.Interface,
 	informer corev1informer.PodInformer,
-) (map[string]uint32, []error, error) {
+) (map[string]uint32, map[string]map[string]uint32, []error, error) {
 	releaseWeights, ok := p.clusterReleaseWeights[cluster]
 	if !ok {
-		return nil, nil, shippererrors.NewMissingTrafficWeightsForClusterError(
+		return nil, nil, nil, shippererrors.NewMissingTrafficWeightsForClusterError(
 			p.namespace, p.appName, cluster)
 	}
 
@@ -76,21 +114,42 @@ func (p *podLabelShifter) SyncCluster(
 
 	svcList, err := servicesClient.List(metav1.ListOptions{LabelSelector: p.serviceSelector.String()})
 	if err != nil {
-		return nil, nil, shippererrors.NewKubeclientListError(
+		return nil, nil, nil, shippererrors.NewKubeclientListError(
 			corev1.SchemeGroupVersion.WithKind("Service"),
 			p.namespace, p.serviceSelector, err)
-	} else if n := len(svcList.Items); n != 1 {
-		return nil, nil,
-			shippererrors.NewTargetClusterWrongServiceCountError(
-				cluster, p.serviceSelector, p.namespace, n)
 	}
 
-	prodSvc := svcList.Items[0]
-	trafficSelector := prodSvc.Spec.Selector
-	if trafficSelector == nil {
-		return nil, nil,
-			shippererrors.NewTargetClusterServiceMissesSelectorError(
-				cluster, p.namespace, prodSvc.Name)
+	gates, err := p.buildTrafficGates(cluster, svcList.Items, releaseWeights)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// If another application's production Service has a selector that can
+	// match the same pods, both apps would flip the traffic label on each
+	// other's pods. Refuse to shift anything until the selectors are
+	// scoped apart; the app label, which shipper sets on the selectors of
+	// the Services it installs, is enough to disambiguate.
+	lbSelector := labels.Set{shipper.LBLabel: shipper.LBForProduction}.AsSelector()
+	lbSvcList, err := servicesClient.List(metav1.ListOptions{LabelSelector: lbSelector.String()})
+	if err != nil {
+		return nil, nil, nil, shippererrors.NewKubeclientListError(
+			corev1.SchemeGroupVersion.WithKind("Service"),
+			p.namespace, lbSelector, err)
+	}
+	for _, svc := range lbSvcList.Items {
+		otherApp, ok := svc.Labels[shipper.AppLabel]
+		if !ok || otherApp == p.appName {
+			continue
+		}
+		if len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		for _, gate := range gates {
+			if selectorsOverlap(gate.trafficSelector, svc.Spec.Selector) {
+				return nil, nil, nil, shippererrors.NewServiceOwnershipConflictError(
+					cluster, p.namespace, gate.serviceName, p.appName, svc.Name, otherApp)
+			}
+		}
 	}
 
 	nsPodLister := informer.Lister().Pods(p.namespace)
@@ -98,12 +157,160 @@ func (p *podLabelShifter) SyncCluster(
 	appSelector := labels.Set{shipper.AppLabel: p.appName}.AsSelector()
 	pods, err := nsPodLister.List(appSelector)
 	if err != nil {
-		return nil, nil, shippererrors.NewKubeclientListError(
+		return nil, nil, nil, shippererrors.NewKubeclientListError(
 			corev1.SchemeGroupVersion.WithKind("Pod"),
 			p.namespace, appSelector, err)
 	}
 
 	totalPods := len(pods)
+	errors := []error{}
+	tierAchieved := map[string]map[string]uint32{}
+	for _, gate := range gates {
+		gateAchieved, gateErrors, err := p.shiftGate(gate, podsClient, nsPodLister, totalPods)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		errors = append(errors, gateErrors...)
+		tierAchieved[gate.tier] = gateAchieved
+	}
+
+	if len(p.tiers) == 0 {
+		// Single-Service mode: the only gate's result is the answer.
+		return tierAchieved[""], nil, errors, nil
+	}
+
+	return foldGateWeights(releaseWeights, gates, tierAchieved), tierAchieved, errors, nil
+}
+
+// buildTrafficGates maps the application's production Services to traffic
+// gates. Without tiers this demands exactly one Service, as before. With
+// tiers, each Service's LBTierLabel names its tier (absent means the default
+// tier), every tier named by a TrafficTarget must have a Service, and each
+// tier shifts its own "<PodTrafficStatusLabel>-<tier>" pod label.
+func (p *podLabelShifter) buildTrafficGates(cluster string, services []corev1.Service, releaseWeights map[string]uint32) ([]trafficGate, error) {
+	if len(p.tiers) == 0 {
+		if n := len(services); n != 1 {
+			return nil, shippererrors.NewTargetClusterWrongServiceCountError(
+				cluster, p.serviceSelector, p.namespace, n)
+		}
+
+		svc := services[0]
+		if svc.Spec.Selector == nil {
+			return nil, shippererrors.NewTargetClusterServiceMissesSelectorError(
+				cluster, p.namespace, svc.Name)
+		}
+
+		return []trafficGate{{
+			tier:            "",
+			serviceName:     svc.Name,
+			trafficLabel:    shipper.PodTrafficStatusLabel,
+			trafficSelector: svc.Spec.Selector,
+			releaseWeights:  releaseWeights,
+		}}, nil
+	}
+
+	tierServices := map[string]corev1.Service{}
+	for _, svc := range services {
+		tier := svc.Labels[shipper.LBTierLabel]
+		if _, dup := tierServices[tier]; dup {
+			return nil, shippererrors.NewTargetClusterWrongServiceCountError(
+				cluster, p.tierSelector(tier), p.namespace, 2)
+		}
+		tierServices[tier] = svc
+	}
+
+	for _, tier := range p.tiers {
+		if _, ok := tierServices[tier]; !ok {
+			return nil, shippererrors.NewTargetClusterWrongServiceCountError(
+				cluster, p.tierSelector(tier), p.namespace, 0)
+		}
+	}
+
+	tiers := make([]string, 0, len(tierServices))
+	for tier := range tierServices {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+
+	gates := make([]trafficGate, 0, len(tiers))
+	for _, tier := range tiers {
+		svc := tierServices[tier]
+		if svc.Spec.Selector == nil {
+			return nil, shippererrors.NewTargetClusterServiceMissesSelectorError(
+				cluster, p.namespace, svc.Name)
+		}
+
+		trafficLabel := shipper.PodTrafficStatusLabel
+		if tier != "" {
+			trafficLabel = fmt.Sprintf("%s-%s", shipper.PodTrafficStatusLabel, tier)
+		}
+
+		// Tiers nobody named in a TrafficTarget (typically the default
+		// tier's Service) follow the plain per-release weights.
+		weights := p.clusterTierWeights[cluster][tier]
+		if weights == nil {
+			weights = releaseWeights
+		}
+
+		gates = append(gates, trafficGate{
+			tier:            tier,
+			serviceName:     svc.Name,
+			trafficLabel:    trafficLabel,
+			trafficSelector: svc.Spec.Selector,
+			releaseWeights:  weights,
+		})
+	}
+
+	return gates, nil
+}
+
+func (p *podLabelShifter) tierSelector(tier string) labels.Selector {
+	return labels.Set{
+		shipper.AppLabel:    p.appName,
+		shipper.LBLabel:     shipper.LBForProduction,
+		shipper.LBTierLabel: tier,
+	}.AsSelector()
+}
+
+// foldGateWeights folds per-gate achievements into the single canonical
+// weight per release that the strategy compares against the spec: a release
+// reports its full weight only once every gate achieved that release's
+// target for it, and otherwise its most lagging gate's progress, scaled to
+// the release's weight.
+func foldGateWeights(releaseWeights map[string]uint32, gates []trafficGate, tierAchieved map[string]map[string]uint32) map[string]uint32 {
+	folded := make(map[string]uint32, len(releaseWeights))
+	for release, weight := range releaseWeights {
+		fraction := 1.0
+		for _, gate := range gates {
+			target := gate.releaseWeights[release]
+			if target == 0 {
+				// A zero target is trivially achieved.
+				continue
+			}
+			f := float64(tierAchieved[gate.tier][release]) / float64(target)
+			if f > 1 {
+				f = 1
+			}
+			if f < fraction {
+				fraction = f
+			}
+		}
+		folded[release] = uint32(round(fraction * float64(weight)))
+	}
+	return folded
+}
+
+// shiftGate enables and disables the gate's traffic label on the app's pods
+// until each release's share matches its weight for this gate.
+func (p *podLabelShifter) shiftGate(
+	gate trafficGate,
+	podsClient corev1client.PodInterface,
+	nsPodLister corev1listers.PodNamespaceLister,
+	totalPods int,
+) (map[string]uint32, []error, error) {
+	trafficSelector := gate.trafficSelector
+	releaseWeights := gate.releaseWeights
+
 	var totalWeight uint32 = 0
 	for _, weight := range releaseWeights {
 		totalWeight += weight
@@ -145,8 +352,8 @@ func (p *podLabelShifter) SyncCluster(
 			for i := 0; i < excess; i++ {
 				pod := trafficPods[i].DeepCopy()
 
-				if value, ok := pod.Labels[shipper.PodTrafficStatusLabel]; !ok || value == shipper.Enabled {
-					patch := patchPodTrafficStatusLabel(pod, shipper.Disabled)
+				if value, ok := pod.Labels[gate.trafficLabel]; !ok || value == shipper.Enabled {
+					patch := patchPodTrafficLabel(pod, gate.trafficLabel, shipper.Disabled)
 					_, err := podsClient.Patch(pod.Name, types.JSONPatchType, patch)
 					if err != nil {
 						err = shippererrors.NewKubeclientPatchError(p.namespace, pod.Name, err).
@@ -173,11 +380,25 @@ func (p *podLabelShifter) SyncCluster(
 				continue
 			}
 
+			// With the warm-pod preference on, enable the pods that
+			// have been ready longest first; pods that were never
+			// ready go last. Otherwise keep the lister's order.
+			if p.preferWarmPods[release] {
+				sort.SliceStable(idlePods, func(a, b int) bool {
+					aTime, aReady := podReadySince(idlePods[a])
+					bTime, bReady := podReadySince(idlePods[b])
+					if aReady != bReady {
+						return aReady
+					}
+					return aTime.Before(&bTime)
+				})
+			}
+
 			for i := 0; i < missing; i++ {
 				pod := idlePods[i].DeepCopy()
 
-				if value, ok := pod.Labels[shipper.PodTrafficStatusLabel]; !ok || ok && value == shipper.Disabled {
-					patch := patchPodTrafficStatusLabel(pod, shipper.Enabled)
+				if value, ok := pod.Labels[gate.trafficLabel]; !ok || ok && value == shipper.Disabled {
+					patch := patchPodTrafficLabel(pod, gate.trafficLabel, shipper.Enabled)
 					_, err := podsClient.Patch(pod.Name, types.JSONPatchType, patch)
 					if err != nil {
 						err = shippererrors.NewKubeclientPatchError(p.namespace, pod.Name, err).
@@ -198,6 +419,28 @@ func (p *podLabelShifter) SyncCluster(
 	return achievedWeights, errors, nil
 }
 
+// podReadySince returns the time the pod's Ready condition last transitioned
+// to True, and false if the pod isn't ready.
+func podReadySince(pod *corev1.Pod) (metav1.Time, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return condition.LastTransitionTime, true
+		}
+	}
+	return metav1.Time{}, false
+}
+
+// selectorsOverlap reports whether two label selectors can match the same
+// pod: they overlap unless they disagree on the value of some shared key.
+func selectorsOverlap(a, b map[string]string) bool {
+	for key, aValue := range a {
+		if bValue, ok := b[key]; ok && bValue != aValue {
+			return false
+		}
+	}
+	return true
+}
+
 func getsTraffic(pod *corev1.Pod, trafficSelectors map[string]string) bool {
 	for key, trafficValue := range trafficSelectors {
 		podValue, ok := pod.Labels[key]
@@ -217,12 +460,12 @@ type PatchOperation struct {
 	Value string `json:"value"`
 }
 
-// patchPodTrafficStatusLabel returns a JSON Patch that modifies the
-// PodTrafficStatusLabel value of a given Pod.
-func patchPodTrafficStatusLabel(pod *corev1.Pod, value string) []byte {
+// patchPodTrafficLabel returns a JSON Patch that modifies the given traffic
+// label's value on a Pod.
+func patchPodTrafficLabel(pod *corev1.Pod, label, value string) []byte {
 	var op string
 
-	if _, ok := pod.Labels[shipper.PodTrafficStatusLabel]; ok {
+	if _, ok := pod.Labels[label]; ok {
 		op = "replace"
 	} else {
 		op = "add"
@@ -231,7 +474,7 @@ func patchPodTrafficStatusLabel(pod *corev1.Pod, value string) []byte {
 	patchList := []PatchOperation{
 		{
 			Op:    op,
-			Path:  fmt.Sprintf("/metadata/labels/%s", shipper.PodTrafficStatusLabel),
+			Path:  fmt.Sprintf("/metadata/labels/%s", label),
 			Value: value,
 		},
 	}
@@ -282,20 +525,38 @@ func calculateReleasePodTarget(releasePods int, releaseWeight uint32, totalPods
 		}
 	}
 */
-func buildClusterReleaseWeights(trafficTargets []*shipper.TrafficTarget) (clusterReleaseWeights, error) {
+func buildClusterReleaseWeights(trafficTargets []*shipper.TrafficTarget) (clusterReleaseWeights, clusterTierWeights, []string, error) {
 	clusterReleases := map[string]map[string]uint32{}
+	clusterTiers := map[string]map[string]map[string]uint32{}
 	releaseTT := map[string]*shipper.TrafficTarget{}
 
+	// The union of tiers any traffic target names: a release that doesn't
+	// mention a tier still participates in it with its plain Weight, so
+	// per-tier totals stay comparable across releases.
+	tierSet := map[string]struct{}{}
+	for _, tt := range trafficTargets {
+		for _, cluster := range tt.Spec.Clusters {
+			for tier := range cluster.ServiceWeights {
+				tierSet[tier] = struct{}{}
+			}
+		}
+	}
+	tiers := make([]string, 0, len(tierSet))
+	for tier := range tierSet {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+
 	for _, tt := range trafficTargets {
 		release, ok := tt.Labels[shipper.ReleaseLabel]
 		if !ok {
 			err := shippererrors.NewMissingShipperLabelError(tt, shipper.ReleaseLabel)
-			return nil, err
+			return nil, nil, nil, err
 		}
 
 		existingTT, ok := releaseTT[release]
 		if ok {
-			return nil, shippererrors.NewMultipleTrafficTargetsForReleaseError(
+			return nil, nil, nil, shippererrors.NewMultipleTrafficTargetsForReleaseError(
 				tt.Namespace, release, []string{tt.Name, existingTT.Name})
 		}
 		releaseTT[release] = tt
@@ -306,11 +567,41 @@ func buildClusterReleaseWeights(trafficTargets []*shipper.TrafficTarget) (cluste
 				weights = map[string]uint32{}
 				clusterReleases[cluster.Name] = weights
 			}
-			weights[release] += cluster.Weight
+
+			// A suspended cluster keeps the release installed but serves
+			// none of its traffic: its weight counts as zero until the
+			// flag is cleared.
+			weight := cluster.Weight
+			if cluster.Suspended {
+				weight = 0
+			}
+			weights[release] += weight
+
+			for _, tier := range tiers {
+				tierWeights, ok := clusterTiers[cluster.Name]
+				if !ok {
+					tierWeights = map[string]map[string]uint32{}
+					clusterTiers[cluster.Name] = tierWeights
+				}
+				releaseWeights, ok := tierWeights[tier]
+				if !ok {
+					releaseWeights = map[string]uint32{}
+					tierWeights[tier] = releaseWeights
+				}
+
+				tierWeight, ok := cluster.ServiceWeights[tier]
+				if !ok {
+					tierWeight = cluster.Weight
+				}
+				if cluster.Suspended {
+					tierWeight = 0
+				}
+				releaseWeights[release] += tierWeight
+			}
 		}
 	}
 
-	return clusterReleaseWeights(clusterReleases), nil
+	return clusterReleaseWeights(clusterReleases), clusterTierWeights(clusterTiers), tiers, nil
 }
 
 func round(num float64) int {
@@ -2,6 +2,7 @@ package traffic
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 	"encoding/json"
@@ -207,6 +208,99 @@ func TestWeightCalculatedForJustOneApplication(t *testing.T) {
 	f.run(expectedWeightsByName)
 }
 
+// TestWarmPodsPreferredForPartialWeight tests that a TrafficTarget carrying
+// the prefer-warm-pods annotation enables traffic on the pods that have been
+// ready longest first when only a subset can be enabled for its weight.
+func TestWarmPodsPreferredForPartialWeight(t *testing.T) {
+	f := newPodLabelShifterFixture(t, "warm pods first")
+
+	// release-a can only enable a subset of its pods for this weight:
+	// 1/4 of 20 total pods = 5 out of its 10.
+	f.addTrafficTarget("release-a", 1)
+	f.trafficTargets[0].Annotations = map[string]string{
+		shipper.TrafficPreferWarmPodsAnnotation: shipper.True,
+	}
+	f.addTrafficTarget("release-b", 3)
+	f.addPods("release-b", 10)
+
+	// release-a's pod i became ready i minutes ago, so the pods with the
+	// highest indices have been ready longest and should take the ramp.
+	base := metav1.Now()
+	for i, pod := range newReleasePods("release-a", 10) {
+		pod.Status.Conditions = []corev1.PodCondition{
+			{
+				Type:               corev1.PodReady,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(base.Add(-time.Duration(i) * time.Minute)),
+			},
+		}
+		f.objects = append(f.objects, pod)
+		f.pods = append(f.pods, pod)
+	}
+
+	f.addService()
+
+	// 5/20 pods * 4 total weight = 1 for release-a; release-b is capped at
+	// its 10 pods, and 10/20 * 4 = 2.
+	if !f.run(map[string]uint32{"release-a": 1, "release-b": 2}) {
+		return
+	}
+
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("release-a-%d", i)
+		pod, err := f.informers.Core().V1().Pods().Lister().Pods(shippertesting.TestNamespace).Get(name)
+		if err != nil {
+			t.Fatalf("could not find pod %q in informer: %s", name, err)
+		}
+
+		enabled := pod.Labels[shipper.PodTrafficStatusLabel] == shipper.Enabled
+		expected := i >= 5
+		if enabled != expected {
+			t.Errorf("pod %q (ready %d minutes ago): traffic enabled %t, expected %t", name, i, enabled, expected)
+		}
+	}
+}
+
+// TestServiceTierTrafficGates shifts an internal and an external service
+// tier at different rates in the same step: the contender takes 50% of the
+// internal traffic but only 10% of the external traffic.
+func TestServiceTierTrafficGates(t *testing.T) {
+	f := newPodLabelShifterFixture(t, "service tier gates")
+
+	f.addTrafficTarget("release-a", 50)
+	f.trafficTargets[0].Spec.Clusters[0].ServiceWeights = map[string]uint32{
+		"internal": 50,
+		"external": 90,
+	}
+	f.addTrafficTarget("release-b", 50)
+	f.trafficTargets[1].Spec.Clusters[0].ServiceWeights = map[string]uint32{
+		"internal": 50,
+		"external": 10,
+	}
+
+	f.addPods("release-a", 10)
+	f.addPods("release-b", 10)
+
+	f.addTierService("internal")
+	f.addTierService("external")
+
+	// Internal: both releases weigh 50, so each enables all 10 of its
+	// pods. External: release-b gets 10/100 * 20 pods = 2, achieving its
+	// 10; release-a's 18 are capped at its 10 pods, achieving 50 of its
+	// 90 target. Folded, release-b reports its full weight of 50 and
+	// release-a its most lagging gate: 50/90 of its weight of 50 = 28.
+	if !f.run(map[string]uint32{"release-a": 28, "release-b": 50}) {
+		return
+	}
+
+	internalLabel := fmt.Sprintf("%s-internal", shipper.PodTrafficStatusLabel)
+	externalLabel := fmt.Sprintf("%s-external", shipper.PodTrafficStatusLabel)
+	f.checkPodsWithLabel("release-a", internalLabel, 10)
+	f.checkPodsWithLabel("release-b", internalLabel, 10)
+	f.checkPodsWithLabel("release-a", externalLabel, 10)
+	f.checkPodsWithLabel("release-b", externalLabel, 2)
+}
+
 func clusterSyncTestCase(
 	t *testing.T,
 	name string,
@@ -319,6 +413,49 @@ func (f *podLabelShifterFixture) addService() {
 	f.objects = append(f.objects, svc)
 }
 
+// addTierService adds a production Service for a named service tier, whose
+// selector gates on that tier's traffic label.
+func (f *podLabelShifterFixture) addTierService(tier string) {
+	labels := map[string]string{
+		shipper.AppLabel:    testApplicationName,
+		shipper.LBLabel:     shipper.LBForProduction,
+		shipper.LBTierLabel: tier,
+	}
+
+	trafficLabel := fmt.Sprintf("%s-%s", shipper.PodTrafficStatusLabel, tier)
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", testServiceName, tier),
+			Namespace: shippertesting.TestNamespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{
+				shipper.AppLabel: testApplicationName,
+				trafficLabel:     shipper.Enabled,
+			},
+		},
+	}
+
+	f.svc = svc
+	f.objects = append(f.objects, svc)
+}
+
+func (f *podLabelShifterFixture) checkPodsWithLabel(release, label string, expectedCount int) {
+	count := 0
+	for _, pod := range f.pods {
+		if pod.Labels[shipper.ReleaseLabel] != release {
+			continue
+		}
+		if pod.Labels[label] == shipper.Enabled {
+			count++
+		}
+	}
+	if count != expectedCount {
+		f.Errorf("expected %d %q pods with label %q enabled, but got %d", expectedCount, release, label, count)
+	}
+}
+
 // buildPodPatchReactionFunc returns a ReactionFunc specialized in poorly patch
 // Pods for the scope of the pod label shifter tests.
 //
@@ -346,10 +483,11 @@ func buildPodPatchReactionFunc(informers kubeinformers.SharedInformerFactory) cl
 				// For this particular situation, we don't care whether it is an
 				// add or replace op, although JSON Patch *requires* the key to
 				// exist in order to issue a replace; that's the reason that
-				// patchPodTrafficStatusLabel determines the operation based on
-				// the presence of the PodTrafficStatusLabel.
-				if p.Path == fmt.Sprintf("/metadata/labels/%s", shipper.PodTrafficStatusLabel) {
-					pod.Labels[shipper.PodTrafficStatusLabel] = p.Value
+				// patchPodTrafficLabel determines the operation based on
+				// the presence of the traffic label.
+				const labelsPrefix = "/metadata/labels/"
+				if strings.HasPrefix(p.Path, labelsPrefix) {
+					pod.Labels[strings.TrimPrefix(p.Path, labelsPrefix)] = p.Value
 				}
 			}
 
@@ -392,7 +530,7 @@ func (f *podLabelShifterFixture) run(expectedWeights map[string]uint32) bool {
 		return false
 	}
 
-	achievedWeights, errs, _ :=
+	achievedWeights, _, errs, _ :=
 		shifter.SyncCluster(testClusterName, f.client, informers.Core().V1().Pods())
 
 	for _, err := range errs {
@@ -9,6 +9,7 @@ import (
 	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -16,6 +17,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
@@ -25,6 +27,7 @@ import (
 	"github.com/bookingcom/shipper/pkg/clusterclientstore"
 	"github.com/bookingcom/shipper/pkg/conditions"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	shipperhealth "github.com/bookingcom/shipper/pkg/health"
 )
 
 const AgentName = "traffic-controller"
@@ -43,6 +46,9 @@ type Controller struct {
 	clusterClientStore   clusterclientstore.Interface
 	trafficTargetsLister listers.TrafficTargetLister
 	trafficTargetsSynced cache.InformerSynced
+	clusterLister        listers.ClusterLister
+	clusterSynced        cache.InformerSynced
+	cloudLBBackends      map[string]CloudLBBackend
 	workqueue            workqueue.RateLimitingInterface
 	recorder             record.EventRecorder
 }
@@ -53,10 +59,12 @@ func NewController(
 	shipperInformerFactory informers.SharedInformerFactory,
 	store *clusterclientstore.Store,
 	recorder record.EventRecorder,
+	cloudLBBackends map[string]CloudLBBackend,
 ) *Controller {
 
 	// Obtain references to shared index informers for the TrafficTarget type.
 	trafficTargetInformer := shipperInformerFactory.Shipper().V1alpha1().TrafficTargets()
+	clusterInformer := shipperInformerFactory.Shipper().V1alpha1().Clusters()
 
 	controller := &Controller{
 		shipperclientset:   shipperclientset,
@@ -64,6 +72,9 @@ func NewController(
 
 		trafficTargetsLister: trafficTargetInformer.Lister(),
 		trafficTargetsSynced: trafficTargetInformer.Informer().HasSynced,
+		clusterLister:        clusterInformer.Lister(),
+		clusterSynced:        clusterInformer.Informer().HasSynced,
+		cloudLBBackends:      cloudLBBackends,
 		workqueue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "traffic_controller_traffictargets"),
 		recorder:             recorder,
 	}
@@ -97,7 +108,7 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 	glog.V(2).Info("Starting Traffic controller")
 	defer glog.V(2).Info("Shutting down Traffic controller")
 
-	if ok := cache.WaitForCacheSync(stopCh, c.trafficTargetsSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.trafficTargetsSynced, c.clusterSynced); !ok {
 		runtime.HandleError(fmt.Errorf("failed to wait for caches to sync"))
 		return
 	}
@@ -159,6 +170,7 @@ func (c *Controller) processNextWorkItem() bool {
 	}
 
 	c.workqueue.Forget(obj)
+	shipperhealth.RecordSuccess(AgentName)
 	glog.V(4).Infof("Successfully synced TrafficTarget %q", key)
 
 	return true
@@ -208,9 +220,17 @@ func (c *Controller) syncHandler(key string) error {
 	}
 
 	var statuses []*shipper.ClusterTrafficStatus
+	clusterErrors := shippererrors.NewMultiError()
+
+	suspended := make(map[string]bool, len(syncingTT.Spec.Clusters))
+	for _, specCluster := range syncingTT.Spec.Clusters {
+		suspended[specCluster.Name] = specCluster.Suspended
+	}
+
 	for _, cluster := range shifter.Clusters() {
 		var achievedReleaseWeight uint32
 		var achievedWeights map[string]uint32
+		var achievedTierWeights map[string]map[string]uint32
 		var clientset kubernetes.Interface
 		var clusterConditions []shipper.ClusterTrafficCondition
 		var errs []error
@@ -241,10 +261,11 @@ func (c *Controller) syncHandler(key string) error {
 				clusterStatus.Conditions,
 				shipper.ClusterConditionTypeOperational,
 				corev1.ConditionFalse,
-				conditions.ServerError,
+				reasonForOperationalCondition(err),
 				err.Error())
 
 			clusterStatus.Status = err.Error()
+			clusterErrors.Append(err)
 			continue
 		}
 		informerFactory, err = c.clusterClientStore.GetInformerFactory(cluster)
@@ -260,17 +281,20 @@ func (c *Controller) syncHandler(key string) error {
 				clusterStatus.Conditions,
 				shipper.ClusterConditionTypeOperational,
 				corev1.ConditionFalse,
-				conditions.ServerError,
+				reasonForOperationalCondition(err),
 				err.Error())
 
 			clusterStatus.Status = err.Error()
+			clusterErrors.Append(err)
 			continue
 		}
 
-		achievedWeights, errs, err =
+		achievedWeights, achievedTierWeights, errs, err =
 			shifter.SyncCluster(cluster, clientset, informerFactory.Core().V1().Pods())
 
 		if err != nil {
+			clusterStatus.Status = err.Error()
+
 			switch err.(type) {
 			case shippererrors.TargetClusterServiceError:
 				clusterStatus.Conditions = conditions.SetTrafficCondition(
@@ -279,6 +303,13 @@ func (c *Controller) syncHandler(key string) error {
 					corev1.ConditionFalse,
 					conditions.MissingService,
 					err.Error())
+			case shippererrors.ServiceOwnershipConflictError:
+				clusterStatus.Conditions = conditions.SetTrafficCondition(
+					clusterStatus.Conditions,
+					shipper.ClusterConditionTypeReady,
+					corev1.ConditionFalse,
+					conditions.ServiceOwnershipConflict,
+					err.Error())
 			case shippererrors.KubeclientError:
 				clusterStatus.Conditions = conditions.SetTrafficCondition(
 					clusterStatus.Conditions,
@@ -302,18 +333,61 @@ func (c *Controller) syncHandler(key string) error {
 					err.Error())
 			}
 		} else {
+			backend, backendErr := c.cloudLBBackendForCluster(cluster)
+			if backendErr == nil && backend != nil {
+				// The load balancer's own view of achieved weights
+				// trumps the pod-membership computation for clusters
+				// that opted in.
+				var lbWeights map[string]uint32
+				lbWeights, backendErr = backend.AchievedWeights(cluster, syncingTT)
+				if backendErr == nil {
+					achievedWeights = lbWeights
+				}
+			}
+
+			if backendErr != nil {
+				clusterStatus.Conditions = conditions.SetTrafficCondition(
+					clusterStatus.Conditions,
+					shipper.ClusterConditionTypeReady,
+					corev1.ConditionFalse,
+					conditions.TrafficBackendError,
+					backendErr.Error())
+
+				clusterStatus.Status = backendErr.Error()
+				clusterErrors.Append(backendErr)
+				continue
+			}
+
 			// If the resulting map is missing the release we're working on, there's a
 			// significant bug in our code.
 			achievedReleaseWeight = achievedWeights[syncingReleaseName]
 			clusterStatus.AchievedTraffic = achievedReleaseWeight
+			if len(achievedTierWeights) > 0 {
+				serviceTraffic := make(map[string]uint32, len(achievedTierWeights))
+				for tier, weights := range achievedTierWeights {
+					serviceTraffic[tier] = weights[syncingReleaseName]
+				}
+				clusterStatus.AchievedServiceTraffic = serviceTraffic
+			}
 			if len(errs) == 0 {
-				clusterStatus.Conditions = conditions.SetTrafficCondition(
-					clusterStatus.Conditions,
-					shipper.ClusterConditionTypeReady,
-					corev1.ConditionTrue,
-					"", "")
-
-				clusterStatus.Status = "Synced"
+				if suspended[cluster] {
+					clusterStatus.Conditions = conditions.SetTrafficCondition(
+						clusterStatus.Conditions,
+						shipper.ClusterConditionTypeReady,
+						corev1.ConditionFalse,
+						conditions.ClusterSuspended,
+						"cluster is suspended; serving zero traffic")
+
+					clusterStatus.Status = "Suspended"
+				} else {
+					clusterStatus.Conditions = conditions.SetTrafficCondition(
+						clusterStatus.Conditions,
+						shipper.ClusterConditionTypeReady,
+						corev1.ConditionTrue,
+						"", "")
+
+					clusterStatus.Status = "Synced"
+				}
 			} else {
 				results := make([]string, 0, len(errs))
 				for _, err := range errs {
@@ -356,10 +430,16 @@ func (c *Controller) syncHandler(key string) error {
 		Clusters: filteredStatuses,
 	}
 
-	_, err = c.shipperclientset.ShipperV1alpha1().TrafficTargets(namespace).Update(ttCopy)
-	if err != nil {
-		return shippererrors.NewKubeclientUpdateError(ttCopy, err).
-			WithShipperKind("TrafficTarget")
+	if err := c.updateTrafficTargetStatus(ttCopy); err != nil {
+		clusterErrors.Append(shippererrors.NewKubeclientUpdateError(ttCopy, err).
+			WithShipperKind("TrafficTarget"))
+	}
+
+	// Clusters that couldn't be reached through the client store got their
+	// transient condition recorded in the status update above; returning the
+	// errors here lets the workqueue retry them with backoff.
+	if clusterErrors.Any() {
+		return clusterErrors.Flatten()
 	}
 
 	// TODO(btyler): don't record "success" if it wasn't a total success: this
@@ -370,6 +450,74 @@ func (c *Controller) syncHandler(key string) error {
 	return nil
 }
 
+// updateTrafficTargetStatus writes a traffic target's computed status,
+// retrying resource version conflicts by re-applying the status to a fresh
+// copy of the object. Concurrent reconciles shouldn't surface transient
+// conflicts as errors, and blind retries would clobber the winner's spec; the
+// re-sync the update enqueues recomputes the status from the fresh spec
+// anyway.
+func (c *Controller) updateTrafficTargetStatus(tt *shipper.TrafficTarget) error {
+	status := tt.Status
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		_, updateErr := c.shipperclientset.ShipperV1alpha1().TrafficTargets(tt.Namespace).Update(tt)
+		if updateErr == nil || !kerrors.IsConflict(updateErr) {
+			return updateErr
+		}
+
+		fresh, getErr := c.shipperclientset.ShipperV1alpha1().TrafficTargets(tt.Namespace).Get(tt.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+
+		fresh = fresh.DeepCopy()
+		fresh.Status = status
+		tt = fresh
+
+		return updateErr
+	})
+}
+
+// cloudLBBackendForCluster returns the cloud load balancer backend the given
+// cluster opted into via the ClusterTrafficBackendAnnotation, or nil if the
+// cluster relies on pod label membership alone. The Cluster lookup is skipped
+// entirely when no backends are registered with this controller.
+func (c *Controller) cloudLBBackendForCluster(clusterName string) (CloudLBBackend, error) {
+	if len(c.cloudLBBackends) == 0 {
+		return nil, nil
+	}
+
+	cluster, err := c.clusterLister.Get(clusterName)
+	if err != nil {
+		return nil, shippererrors.NewKubeclientGetError("", clusterName, err).
+			WithShipperKind("Cluster")
+	}
+
+	backendName, ok := cluster.Annotations[shipper.ClusterTrafficBackendAnnotation]
+	if !ok || backendName == "" {
+		return nil, nil
+	}
+
+	backend, ok := c.cloudLBBackends[backendName]
+	if !ok {
+		return nil, shippererrors.NewUnrecoverableError(fmt.Errorf(
+			"cluster %q requests traffic backend %q, but no such backend is registered",
+			clusterName, backendName))
+	}
+
+	return backend, nil
+}
+
+// reasonForOperationalCondition distinguishes clusters the client store
+// hasn't produced a client for yet, which is a transient state, from genuine
+// server errors.
+func reasonForOperationalCondition(err error) string {
+	if shippererrors.IsClusterClientStoreError(err) {
+		return conditions.TargetClusterClientError
+	}
+	return conditions.ServerError
+}
+
 // enqueueTrafficTarget takes a TrafficTarget resource and converts it into a
 // namespace/name string which is then put onto the work queue. This method
 // should *not* be passed resources of any type other than TrafficTarget.
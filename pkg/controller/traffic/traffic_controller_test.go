@@ -2,14 +2,18 @@ package traffic
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
@@ -21,6 +25,7 @@ import (
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
 	"github.com/bookingcom/shipper/pkg/clusterclientstore"
 	"github.com/bookingcom/shipper/pkg/conditions"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
 )
 
@@ -111,6 +116,546 @@ func TestExtraClustersNoExtraStatuses(t *testing.T) {
 	f.run()
 }
 
+// TestSuspendedClusterServesZeroTraffic suspends one of two clusters and
+// checks that its pods are drained from the load balancer and its achieved
+// traffic reported as zero, while the other cluster keeps serving its weight.
+func TestSuspendedClusterServesZeroTraffic(t *testing.T) {
+	f := newFixture(t)
+	app := "test-app"
+	release := "test-app-1234"
+
+	clusterA := f.newCluster()
+	clusterB := f.newCluster()
+
+	clusterA.AddOne(buildService(app))
+	clusterB.AddOne(buildService(app))
+
+	// clusterA's pod is in the load balancer: it matches the production
+	// service's selector.
+	const withTraffic = true
+	podsA := buildPods(app, release, 1, withTraffic)
+	podA := podsA[0].(*corev1.Pod)
+	podA.Labels[trafficLabel] = trafficValue
+	clusterA.AddMany(podsA)
+
+	const noTraffic = false
+	podsB := buildPods(app, release, 1, noTraffic)
+	clusterB.AddMany(podsB)
+
+	tt := buildTrafficTarget(
+		app, release,
+		map[string]uint32{
+			clusterA.Name: 10,
+			clusterB.Name: 10,
+		},
+	)
+	for i := range tt.Spec.Clusters {
+		if tt.Spec.Clusters[i].Name == clusterA.Name {
+			tt.Spec.Clusters[i].Suspended = true
+		}
+	}
+
+	f.addTrafficTarget(tt)
+
+	gvr := corev1.SchemeGroupVersion.WithResource("pods")
+
+	// The suspended cluster's pod is taken out of the load balancer.
+	drainPatch := fmt.Sprintf(`[{"op":"replace","path":"/metadata/labels/%s","value":"%s"}]`, shipper.PodTrafficStatusLabel, shipper.Disabled)
+	clusterA.Expect(kubetesting.NewPatchAction(gvr, shippertesting.TestNamespace, podA.Name, []byte(drainPatch)))
+
+	// The other cluster's pod is added as usual.
+	podB := podsB[0].(*corev1.Pod)
+	enablePatch := fmt.Sprintf(`[{"op":"replace","path":"/metadata/labels/%s","value":"%s"}]`, shipper.PodTrafficStatusLabel, shipper.Enabled)
+	clusterB.Expect(kubetesting.NewPatchAction(gvr, shippertesting.TestNamespace, podB.Name, []byte(enablePatch)))
+
+	updatedTT := tt.DeepCopy()
+	updatedTT.Status.Clusters = []*shipper.ClusterTrafficStatus{
+		{
+			Name:            clusterA.Name,
+			AchievedTraffic: 0,
+			Status:          "Suspended",
+			Conditions: []shipper.ClusterTrafficCondition{
+				{
+					Type:   shipper.ClusterConditionTypeOperational,
+					Status: corev1.ConditionTrue,
+				},
+				{
+					Type:    shipper.ClusterConditionTypeReady,
+					Status:  corev1.ConditionFalse,
+					Reason:  conditions.ClusterSuspended,
+					Message: "cluster is suspended; serving zero traffic",
+				},
+			},
+		},
+		{
+			Name:            clusterB.Name,
+			AchievedTraffic: 10,
+			Status:          "Synced",
+			Conditions: []shipper.ClusterTrafficCondition{
+				{
+					Type:   shipper.ClusterConditionTypeOperational,
+					Status: corev1.ConditionTrue,
+				},
+				{
+					Type:   shipper.ClusterConditionTypeReady,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+	}
+
+	f.expectTrafficTargetUpdate(updatedTT)
+	f.run()
+}
+
+func TestMissingProductionServiceReportedPerCluster(t *testing.T) {
+	f := newFixture(t)
+	app := "test-app"
+	release := "test-app-1234"
+
+	// clusterA is misconfigured: it has pods but no production-labeled
+	// Service. clusterB is fine.
+	clusterA := f.newCluster()
+	clusterB := f.newCluster()
+
+	clusterB.AddOne(buildService(app))
+
+	const withTraffic = true
+	clusterA.AddMany(buildPods(app, release, 1, withTraffic))
+	clusterB.AddMany(buildPods(app, release, 1, withTraffic))
+
+	tt := buildTrafficTarget(
+		app, release,
+		map[string]uint32{
+			clusterA.Name: 10,
+			clusterB.Name: 10,
+		},
+	)
+
+	f.addTrafficTarget(tt)
+
+	serviceSelector := labels.Set{
+		shipper.AppLabel: app,
+		shipper.LBLabel:  shipper.LBForProduction,
+	}.AsSelector()
+	missingServiceErr := shippererrors.NewTargetClusterWrongServiceCountError(
+		clusterA.Name, serviceSelector, shippertesting.TestNamespace, 0)
+
+	updatedTT := tt.DeepCopy()
+	updatedTT.Status.Clusters = []*shipper.ClusterTrafficStatus{
+		{
+			Name:   clusterA.Name,
+			Status: missingServiceErr.Error(),
+			Conditions: []shipper.ClusterTrafficCondition{
+				{
+					Type:   shipper.ClusterConditionTypeOperational,
+					Status: corev1.ConditionTrue,
+				},
+				{
+					Type:    shipper.ClusterConditionTypeReady,
+					Status:  corev1.ConditionFalse,
+					Reason:  conditions.MissingService,
+					Message: missingServiceErr.Error(),
+				},
+			},
+		},
+		{
+			Name:            clusterB.Name,
+			AchievedTraffic: 10,
+			Status:          "Synced",
+			Conditions: []shipper.ClusterTrafficCondition{
+				{
+					Type:   shipper.ClusterConditionTypeOperational,
+					Status: corev1.ConditionTrue,
+				},
+				{
+					Type:   shipper.ClusterConditionTypeReady,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+	}
+
+	f.expectTrafficTargetUpdate(updatedTT)
+	f.run()
+}
+
+// TestOverlappingServiceSelectorsRaiseConflict runs two applications whose
+// production Services have overlapping selectors in the same namespace and
+// checks that neither app touches any pods and both report the ownership
+// conflict condition instead.
+func TestOverlappingServiceSelectorsRaiseConflict(t *testing.T) {
+	f := newFixture(t)
+	appA := "app-a"
+	appB := "app-b"
+	releaseA := "app-a-1234"
+	releaseB := "app-b-1234"
+	cluster := f.newCluster()
+
+	// buildService gives every app's Service the same selector, so the two
+	// apps' production Services can select each other's pods.
+	cluster.AddOne(buildService(appA))
+	cluster.AddOne(buildService(appB))
+
+	const noTraffic = false
+	cluster.AddMany(buildPods(appA, releaseA, 1, noTraffic))
+	cluster.AddMany(buildPods(appB, releaseB, 1, noTraffic))
+
+	ttA := buildTrafficTarget(
+		appA, releaseA,
+		map[string]uint32{
+			cluster.Name: 10,
+		},
+	)
+
+	ttB := buildTrafficTarget(
+		appB, releaseB,
+		map[string]uint32{
+			cluster.Name: 10,
+		},
+	)
+
+	f.addTrafficTarget(ttA)
+	f.addTrafficTarget(ttB)
+
+	conflictErrA := shippererrors.NewServiceOwnershipConflictError(
+		cluster.Name, shippertesting.TestNamespace, appA+"-prod", appA, appB+"-prod", appB)
+	conflictErrB := shippererrors.NewServiceOwnershipConflictError(
+		cluster.Name, shippertesting.TestNamespace, appB+"-prod", appB, appA+"-prod", appA)
+
+	updatedA := ttA.DeepCopy()
+	updatedA.Status.Clusters = buildConflictStatus(cluster.Name, conflictErrA)
+
+	updatedB := ttB.DeepCopy()
+	updatedB.Status.Clusters = buildConflictStatus(cluster.Name, conflictErrB)
+
+	// No pod patches are expected: the conflict stops the sync before any
+	// traffic labels get flipped.
+	f.expectTrafficTargetUpdate(updatedA)
+	f.expectTrafficTargetUpdate(updatedB)
+	f.run()
+}
+
+// emptyClientStore mimics a cluster client store that hasn't built any
+// clients yet: every lookup fails with ClusterNotInStoreError, like a running
+// store with an empty cache. The real store's lookups block until it is
+// started, so a not-yet-running store can't stand in for this state.
+type emptyClientStore struct{}
+
+func (emptyClientStore) AddSubscriptionCallback(clusterclientstore.SubscriptionRegisterFunc) {}
+func (emptyClientStore) AddEventHandlerCallback(clusterclientstore.EventHandlerRegisterFunc) {}
+
+func (emptyClientStore) GetClient(clusterName string, ua string) (kubernetes.Interface, error) {
+	return nil, shippererrors.NewClusterNotInStoreError(clusterName)
+}
+
+func (emptyClientStore) GetInformerFactory(clusterName string) (kubeinformers.SharedInformerFactory, error) {
+	return nil, shippererrors.NewClusterNotInStoreError(clusterName)
+}
+
+// TestClusterNotReadyRequeuesUntilClientAvailable checks that a reconcile
+// which runs before the cluster client store has a client for a cluster
+// reports a transient condition and requeues with backoff, then syncs to
+// completion once the store is ready.
+func TestClusterNotReadyRequeuesUntilClientAvailable(t *testing.T) {
+	f := newFixture(t)
+	app := "test-app"
+	release := "test-app-1234"
+	cluster := f.newCluster()
+	cluster.AddOne(buildService(app))
+
+	const noTraffic = false
+	pods := buildPods(app, release, 1, noTraffic)
+	cluster.AddMany(pods)
+
+	tt := buildTrafficTarget(
+		app, release,
+		map[string]uint32{
+			cluster.Name: 10,
+		},
+	)
+
+	f.addTrafficTarget(tt)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	client, controller, store, informer := f.newController(stopCh)
+
+	var handlerErrors []error
+	runtimeutil.ErrorHandlers = []func(error){
+		func(err error) {
+			handlerErrors = append(handlerErrors, err)
+		},
+	}
+
+	informer.Start(stopCh)
+	informer.WaitForCacheSync(stopCh)
+
+	wait.PollUntil(
+		10*time.Millisecond,
+		func() (bool, error) { return controller.workqueue.Len() >= 1, nil },
+		stopCh,
+	)
+
+	// The store has no client for the cluster yet. The first sync should
+	// record the transient condition and ask for a retry instead of
+	// dropping the TrafficTarget or skipping the cluster.
+	controller.clusterClientStore = emptyClientStore{}
+	controller.processNextWorkItem()
+
+	if len(handlerErrors) != 1 {
+		t.Fatalf("expected the first sync to return an error, got %d", len(handlerErrors))
+	}
+
+	if !strings.Contains(handlerErrors[0].Error(), "will retry: true") {
+		t.Errorf("expected the first sync error to be retryable, got: %s", handlerErrors[0])
+	}
+
+	notReadyErr := shippererrors.NewClusterNotInStoreError(cluster.Name)
+	notReadyTT := tt.DeepCopy()
+	notReadyTT.Status.Clusters = []*shipper.ClusterTrafficStatus{
+		{
+			Name:   cluster.Name,
+			Status: notReadyErr.Error(),
+			Conditions: []shipper.ClusterTrafficCondition{
+				{
+					Type:    shipper.ClusterConditionTypeOperational,
+					Status:  corev1.ConditionFalse,
+					Reason:  conditions.TargetClusterClientError,
+					Message: notReadyErr.Error(),
+				},
+			},
+		},
+	}
+
+	// Once the store has built a client for the cluster, the rate limited
+	// requeue should pick the TrafficTarget back up and sync it fully.
+	controller.clusterClientStore = store
+	go store.Run(stopCh)
+
+	wait.PollUntil(
+		10*time.Millisecond,
+		func() (bool, error) {
+			_, err := store.GetClient(cluster.Name, AgentName)
+			return err == nil, nil
+		},
+		stopCh,
+	)
+
+	wait.PollUntil(
+		10*time.Millisecond,
+		func() (bool, error) { return controller.workqueue.Len() >= 1, nil },
+		stopCh,
+	)
+
+	controller.processNextWorkItem()
+
+	if len(handlerErrors) != 1 {
+		t.Fatalf("expected the second sync to succeed, got: %v", handlerErrors[1:])
+	}
+
+	updatedTT := tt.DeepCopy()
+	updatedTT.Status.Clusters = buildTotalSuccessStatus(updatedTT)
+
+	pod := pods[0].(*corev1.Pod)
+	gvr := corev1.SchemeGroupVersion.WithResource("pods")
+	patchString := fmt.Sprintf(`[{"op":"replace","path":"/metadata/labels/%s","value":"%s"}]`, shipper.PodTrafficStatusLabel, shipper.Enabled)
+	cluster.Expect(kubetesting.NewPatchAction(gvr, shippertesting.TestNamespace, pod.Name, []byte(patchString)))
+
+	f.expectTrafficTargetUpdate(notReadyTT)
+	f.expectTrafficTargetUpdate(updatedTT)
+
+	actual := shippertesting.FilterActions(client.Actions())
+	shippertesting.CheckActions(f.actions, actual, t)
+
+	shippertesting.CheckClusterClientActions(store, f.clusters, AgentName, t)
+}
+
+// TestStatusUpdateConflictRetried injects a resource version conflict into
+// the first status update and checks that the controller refetches the
+// TrafficTarget and retries the write instead of surfacing the conflict as a
+// sync error.
+func TestStatusUpdateConflictRetried(t *testing.T) {
+	f := newFixture(t)
+	app := "test-app"
+	release := "test-app-1234"
+	cluster := f.newCluster()
+	cluster.AddOne(buildService(app))
+
+	const noTraffic = false
+	pods := buildPods(app, release, 1, noTraffic)
+	cluster.AddMany(pods)
+
+	tt := buildTrafficTarget(
+		app, release,
+		map[string]uint32{
+			cluster.Name: 10,
+		},
+	)
+
+	f.addTrafficTarget(tt)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	client, controller, store, informer := f.newController(stopCh)
+
+	gvr := shipper.SchemeGroupVersion.WithResource("traffictargets")
+	conflicted := false
+	client.PrependReactor("update", "traffictargets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		if conflicted {
+			return false, nil, nil
+		}
+		conflicted = true
+		return true, nil, kerrors.NewConflict(gvr.GroupResource(), tt.Name, fmt.Errorf("the object has been modified"))
+	})
+
+	var handlerErrors []error
+	runtimeutil.ErrorHandlers = []func(error){
+		func(err error) {
+			handlerErrors = append(handlerErrors, err)
+		},
+	}
+
+	go store.Run(stopCh)
+
+	wait.PollUntil(
+		10*time.Millisecond,
+		func() (bool, error) {
+			_, err := store.GetClient(cluster.Name, AgentName)
+			return err == nil, nil
+		},
+		stopCh,
+	)
+
+	informer.Start(stopCh)
+	informer.WaitForCacheSync(stopCh)
+
+	wait.PollUntil(
+		10*time.Millisecond,
+		func() (bool, error) { return controller.workqueue.Len() >= 1, nil },
+		stopCh,
+	)
+
+	controller.processNextWorkItem()
+
+	if len(handlerErrors) != 0 {
+		t.Fatalf("expected the sync to absorb the conflict, got: %v", handlerErrors)
+	}
+
+	updatedTT := tt.DeepCopy()
+	updatedTT.Status.Clusters = buildTotalSuccessStatus(updatedTT)
+
+	pod := pods[0].(*corev1.Pod)
+	podGVR := corev1.SchemeGroupVersion.WithResource("pods")
+	patchString := fmt.Sprintf(`[{"op":"replace","path":"/metadata/labels/%s","value":"%s"}]`, shipper.PodTrafficStatusLabel, shipper.Enabled)
+	cluster.Expect(kubetesting.NewPatchAction(podGVR, shippertesting.TestNamespace, pod.Name, []byte(patchString)))
+
+	// The conflicted update is followed by a refetch and a second update
+	// carrying the same computed status.
+	f.expectTrafficTargetUpdate(updatedTT)
+	f.actions = append(f.actions, kubetesting.NewGetAction(gvr, tt.GetNamespace(), tt.GetName()))
+	f.expectTrafficTargetUpdate(updatedTT)
+
+	actual := shippertesting.FilterActions(client.Actions())
+	shippertesting.CheckActions(f.actions, actual, t)
+
+	shippertesting.CheckClusterClientActions(store, f.clusters, AgentName, t)
+}
+
+// fakeCloudLBBackend is a CloudLBBackend that reports canned weights.
+type fakeCloudLBBackend struct {
+	weights map[string]uint32
+	calls   int
+}
+
+func (b *fakeCloudLBBackend) AchievedWeights(clusterName string, tt *shipper.TrafficTarget) (map[string]uint32, error) {
+	b.calls++
+	return b.weights, nil
+}
+
+// TestCloudLBBackendReportsAchievedTraffic annotates a cluster with a traffic
+// backend and checks that the backend's weights, not the pod label
+// computation, end up in the cluster's AchievedTraffic.
+func TestCloudLBBackendReportsAchievedTraffic(t *testing.T) {
+	f := newFixture(t)
+	app := "test-app"
+	release := "test-app-1234"
+	cluster := f.newCluster()
+	cluster.AddOne(buildService(app))
+
+	// The pod is already in the load balancer, so the pod label
+	// computation alone would report the full spec weight of 10.
+	const withTraffic = true
+	pods := buildPods(app, release, 1, withTraffic)
+	cluster.AddMany(pods)
+
+	backend := &fakeCloudLBBackend{
+		weights: map[string]uint32{release: 7},
+	}
+	f.cloudLBBackends = map[string]CloudLBBackend{"cloud-lb": backend}
+	f.objects = append(f.objects, buildCluster(cluster.Name, "cloud-lb"))
+
+	tt := buildTrafficTarget(
+		app, release,
+		map[string]uint32{
+			cluster.Name: 10,
+		},
+	)
+
+	f.addTrafficTarget(tt)
+	updatedTT := tt.DeepCopy()
+	updatedTT.Status.Clusters = buildTotalSuccessStatus(updatedTT)
+	updatedTT.Status.Clusters[0].AchievedTraffic = 7
+
+	f.expectTrafficTargetUpdate(updatedTT)
+	f.run()
+
+	if backend.calls != 1 {
+		t.Errorf("expected the cloud LB backend to be queried once, got %d calls", backend.calls)
+	}
+}
+
+// TestUnannotatedClusterIgnoresCloudLBBackends checks that a cluster without
+// the traffic backend annotation keeps reporting pod-membership weights even
+// when backends are registered with the controller.
+func TestUnannotatedClusterIgnoresCloudLBBackends(t *testing.T) {
+	f := newFixture(t)
+	app := "test-app"
+	release := "test-app-1234"
+	cluster := f.newCluster()
+	cluster.AddOne(buildService(app))
+
+	const withTraffic = true
+	pods := buildPods(app, release, 1, withTraffic)
+	cluster.AddMany(pods)
+
+	backend := &fakeCloudLBBackend{
+		weights: map[string]uint32{release: 7},
+	}
+	f.cloudLBBackends = map[string]CloudLBBackend{"cloud-lb": backend}
+	f.objects = append(f.objects, buildCluster(cluster.Name, ""))
+
+	tt := buildTrafficTarget(
+		app, release,
+		map[string]uint32{
+			cluster.Name: 10,
+		},
+	)
+
+	f.addTrafficTarget(tt)
+	updatedTT := tt.DeepCopy()
+	updatedTT.Status.Clusters = buildTotalSuccessStatus(updatedTT)
+
+	f.expectTrafficTargetUpdate(updatedTT)
+	f.run()
+
+	if backend.calls != 0 {
+		t.Errorf("expected the cloud LB backend not to be queried, got %d calls", backend.calls)
+	}
+}
+
 type fixture struct {
 	t *testing.T
 
@@ -120,6 +665,8 @@ type fixture struct {
 	actions []kubetesting.Action
 
 	clusters []*shippertesting.ClusterFixture
+
+	cloudLBBackends map[string]CloudLBBackend
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -167,7 +714,7 @@ func (f *fixture) newController(
 
 	shipperInformerFactory := shipperinformers.NewSharedInformerFactory(client, shippertesting.NoResyncPeriod)
 	c := NewController(
-		client, shipperInformerFactory, store, record.NewFakeRecorder(42),
+		client, shipperInformerFactory, store, record.NewFakeRecorder(42), f.cloudLBBackends,
 	)
 
 	return client, c, store, shipperInformerFactory
@@ -281,6 +828,43 @@ func buildTotalSuccessStatus(tt *shipper.TrafficTarget) []*shipper.ClusterTraffi
 	return clusterStatuses
 }
 
+func buildConflictStatus(clusterName string, conflictErr error) []*shipper.ClusterTrafficStatus {
+	return []*shipper.ClusterTrafficStatus{
+		{
+			Name:   clusterName,
+			Status: conflictErr.Error(),
+			Conditions: []shipper.ClusterTrafficCondition{
+				{
+					Type:   shipper.ClusterConditionTypeOperational,
+					Status: corev1.ConditionTrue,
+				},
+				{
+					Type:    shipper.ClusterConditionTypeReady,
+					Status:  corev1.ConditionFalse,
+					Reason:  conditions.ServiceOwnershipConflict,
+					Message: conflictErr.Error(),
+				},
+			},
+		},
+	}
+}
+
+func buildCluster(name, trafficBackend string) *shipper.Cluster {
+	cluster := &shipper.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+
+	if trafficBackend != "" {
+		cluster.Annotations = map[string]string{
+			shipper.ClusterTrafficBackendAnnotation: trafficBackend,
+		}
+	}
+
+	return cluster
+}
+
 func buildService(app string) runtime.Object {
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
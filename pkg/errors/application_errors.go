@@ -20,3 +20,28 @@ func IsApplicationAnnotationNotFoundError(err error) bool {
 	_, ok := err.(*ApplicationAnnotationError)
 	return ok
 }
+
+type InvalidReleaseNameTemplateError struct {
+	appName  string
+	template string
+	err      error
+}
+
+func (e *InvalidReleaseNameTemplateError) Error() string {
+	return fmt.Sprintf(`release name template %q in application %q is invalid: %s`, e.template, e.appName, e.err)
+}
+
+// ShouldRetry is false because a broken template stays broken until the user
+// edits the Application, which enqueues it again anyway.
+func (e *InvalidReleaseNameTemplateError) ShouldRetry() bool {
+	return false
+}
+
+func NewInvalidReleaseNameTemplateError(appName, template string, err error) error {
+	return &InvalidReleaseNameTemplateError{appName: appName, template: template, err: err}
+}
+
+func IsInvalidReleaseNameTemplateError(err error) bool {
+	_, ok := err.(*InvalidReleaseNameTemplateError)
+	return ok
+}
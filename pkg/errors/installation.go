@@ -82,3 +82,95 @@ func IsIncompleteReleaseError(err error) bool {
 }
 
 // Incomplete release should not retry
+
+// NamespaceTerminatingError means the target namespace is stuck in the
+// Terminating phase, so nothing can be installed into it until whatever holds
+// its finalizers lets go. Retrying doesn't help; an operator has to unblock
+// the namespace.
+type NamespaceTerminatingError struct {
+	namespace  string
+	finalizers []string
+}
+
+func (e NamespaceTerminatingError) Error() string {
+	return fmt.Sprintf(
+		"namespace %q is terminating; blocked on finalizers %v",
+		e.namespace, e.finalizers)
+}
+
+func (e NamespaceTerminatingError) ShouldRetry() bool {
+	return false
+}
+
+func NewNamespaceTerminatingError(namespace string, finalizers []string) NamespaceTerminatingError {
+	return NamespaceTerminatingError{namespace: namespace, finalizers: finalizers}
+}
+
+func IsNamespaceTerminatingError(err error) bool {
+	_, ok := err.(NamespaceTerminatingError)
+	return ok
+}
+
+// ChartDigestMismatchError means the chart repo now serves different bytes
+// for a version than the digest the release has pinned. Retrying won't help:
+// either the repository content or the pin has to change.
+type ChartDigestMismatchError struct {
+	chartName    string
+	chartVersion string
+	pinned       string
+	resolved     string
+}
+
+func (e ChartDigestMismatchError) Error() string {
+	return fmt.Sprintf(
+		"chart %s-%s resolved to digest %s but the release has pinned %s; refusing to install different bytes under the same version",
+		e.chartName, e.chartVersion, e.resolved, e.pinned)
+}
+
+func (e ChartDigestMismatchError) ShouldRetry() bool {
+	return false
+}
+
+func NewChartDigestMismatchError(chartName, chartVersion, pinned, resolved string) ChartDigestMismatchError {
+	return ChartDigestMismatchError{
+		chartName:    chartName,
+		chartVersion: chartVersion,
+		pinned:       pinned,
+		resolved:     resolved,
+	}
+}
+
+func IsChartDigestMismatchError(err error) bool {
+	_, ok := err.(ChartDigestMismatchError)
+	return ok
+}
+
+// SingletonConflictError means the chart renders a cluster-scoped object that
+// another running release still owns: applying this release's copy would
+// clobber the other's mid-rollout. Retrying won't help; the object needs
+// install-once semantics (rendered by only one release, or managed outside
+// the chart).
+type SingletonConflictError struct {
+	kind         string
+	name         string
+	ownerRelease string
+}
+
+func (e SingletonConflictError) Error() string {
+	return fmt.Sprintf(
+		"cluster-scoped object %s %q is owned by running release %q; refusing to clobber a shared singleton — give it install-once semantics by rendering it from a single release or managing it outside the chart",
+		e.kind, e.name, e.ownerRelease)
+}
+
+func (e SingletonConflictError) ShouldRetry() bool {
+	return false
+}
+
+func NewSingletonConflictError(kind, name, ownerRelease string) SingletonConflictError {
+	return SingletonConflictError{kind: kind, name: name, ownerRelease: ownerRelease}
+}
+
+func IsSingletonConflictError(err error) bool {
+	_, ok := err.(SingletonConflictError)
+	return ok
+}
@@ -106,6 +106,28 @@ func NewNoRegionsSpecifiedError() NoRegionsSpecifiedError {
 	return NoRegionsSpecifiedError{}
 }
 
+type UnknownRegionError struct {
+	region    string
+	available []string
+}
+
+func (e UnknownRegionError) Error() string {
+	return fmt.Sprintf("no clusters in region %q; available: %v", e.region, e.available)
+}
+
+func (e UnknownRegionError) ShouldRetry() bool {
+	return false
+}
+
+func NewUnknownRegionError(region string, available []string) UnknownRegionError {
+	return UnknownRegionError{region: region, available: available}
+}
+
+func IsUnknownRegionError(err error) bool {
+	_, ok := err.(UnknownRegionError)
+	return ok
+}
+
 type NotEnoughClustersInRegionError struct {
 	region    string
 	required  int
@@ -268,6 +290,24 @@ func NewDuplicateCapabilityRequirementError(capability string) DuplicateCapabili
 	}
 }
 
+type InvalidClusterSelectorError struct {
+	err error
+}
+
+func (e InvalidClusterSelectorError) Error() string {
+	return fmt.Sprintf("invalid clusterSelector in clusterRequirements: %s", e.err)
+}
+
+func (e InvalidClusterSelectorError) ShouldRetry() bool {
+	return false
+}
+
+func NewInvalidClusterSelectorError(err error) InvalidClusterSelectorError {
+	return InvalidClusterSelectorError{
+		err: err,
+	}
+}
+
 type NotWorkingOnStrategyError struct {
 	contenderReleaseKey string
 }
@@ -125,6 +125,36 @@ func NewMultipleTrafficTargetsForReleaseError(ns, releaseName string, ttNames []
 	}
 }
 
+type ServiceOwnershipConflictError struct {
+	clusterName      string
+	ns               string
+	serviceName      string
+	appName          string
+	otherServiceName string
+	otherAppName     string
+}
+
+func (e ServiceOwnershipConflictError) Error() string {
+	return fmt.Sprintf(
+		`cluster error (%q): service %s/%s of app %q has a selector that overlaps with service %q of app %q; scope both selectors with the %q label so neither app shifts the other's traffic`,
+		e.clusterName, e.ns, e.serviceName, e.appName, e.otherServiceName, e.otherAppName, shipper.AppLabel)
+}
+
+func (e ServiceOwnershipConflictError) ShouldRetry() bool {
+	return false
+}
+
+func NewServiceOwnershipConflictError(clusterName, ns, serviceName, appName, otherServiceName, otherAppName string) ServiceOwnershipConflictError {
+	return ServiceOwnershipConflictError{
+		clusterName:      clusterName,
+		ns:               ns,
+		serviceName:      serviceName,
+		appName:          appName,
+		otherServiceName: otherServiceName,
+		otherAppName:     otherAppName,
+	}
+}
+
 type TargetClusterMathError struct {
 	releaseName  string
 	idlePodCount int
@@ -0,0 +1,91 @@
+// Package health tracks when each controller last completed a successful
+// sync, and serves that information over HTTP so that a wedged controller
+// (workqueue processing stalled) fails its liveness probe and gets restarted.
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default is the process-wide tracker that controllers report to from their
+// processNextWorkItem loops.
+var Default = NewTracker()
+
+// RecordSuccess records a successful sync for the named controller on the
+// Default tracker.
+func RecordSuccess(controller string) {
+	Default.RecordSuccess(controller)
+}
+
+// Tracker records the time of the last successful sync per controller.
+type Tracker struct {
+	mu          sync.Mutex
+	lastSuccess map[string]time.Time
+
+	// now is swappable for tests.
+	now func() time.Time
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		lastSuccess: make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// Register announces a controller to the tracker before it has synced
+// anything. Registration counts as a success so that a freshly started
+// controller gets a full threshold worth of grace before it can be reported
+// unhealthy.
+func (t *Tracker) Register(controller string) {
+	t.RecordSuccess(controller)
+}
+
+// RecordSuccess records a successful sync for the named controller.
+func (t *Tracker) RecordSuccess(controller string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastSuccess[controller] = t.now()
+}
+
+// Handler returns an HTTP handler that answers 200 while every registered
+// controller has synced successfully within the given threshold, and 503 as
+// soon as any controller has gone stale. The response body lists every
+// controller with the age of its last success, one per line.
+func (t *Tracker) Handler(threshold time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		now := t.now()
+
+		controllers := make([]string, 0, len(t.lastSuccess))
+		for controller := range t.lastSuccess {
+			controllers = append(controllers, controller)
+		}
+		sort.Strings(controllers)
+
+		healthy := true
+		body := ""
+		for _, controller := range controllers {
+			age := now.Sub(t.lastSuccess[controller])
+			status := "ok"
+			if age > threshold {
+				healthy = false
+				status = "stale"
+			}
+			body += fmt.Sprintf("%s: %s (%s)\n", controller, age, status)
+		}
+
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		fmt.Fprint(w, body)
+	}
+}
@@ -0,0 +1,48 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandlerReportsStaleControllers(t *testing.T) {
+	now := time.Unix(0, 0)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+
+	tracker.Register("installation-controller")
+	tracker.Register("capacity-controller")
+
+	threshold := time.Minute
+
+	// Both controllers are fresh: healthy.
+	if code := probe(tracker, threshold); code != http.StatusOK {
+		t.Errorf("expected %d for fresh controllers, got %d", http.StatusOK, code)
+	}
+
+	// One controller keeps syncing while the other stalls.
+	now = now.Add(45 * time.Second)
+	tracker.RecordSuccess("capacity-controller")
+
+	now = now.Add(30 * time.Second)
+
+	// installation-controller is now 75s stale, over the threshold.
+	if code := probe(tracker, threshold); code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d for a stalled controller, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	// Once it syncs again, the endpoint recovers.
+	tracker.RecordSuccess("installation-controller")
+	if code := probe(tracker, threshold); code != http.StatusOK {
+		t.Errorf("expected %d after recovery, got %d", http.StatusOK, code)
+	}
+}
+
+func probe(tracker *Tracker, threshold time.Duration) int {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	tracker.Handler(threshold)(rec, req)
+	return rec.Code
+}
@@ -0,0 +1,51 @@
+package prometheus
+
+import (
+	"context"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// traceIDLabel is the OpenMetrics exemplar label carrying the trace ID, so
+// operators can jump from a slow observation straight to the trace behind it.
+const traceIDLabel = "trace_id"
+
+type traceIDKey struct{}
+
+// ContextWithTraceID returns a context carrying the given trace ID, to be
+// threaded from wherever a reconcile's trace is started down to the metric
+// observations made along the way.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID carried by the context, or the
+// empty string when there is none.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// exemplarObserver is implemented by observers that can attach OpenMetrics
+// exemplars to an observation; client_golang histograms grew this in v1.4.
+// It's asserted rather than depended on so observations keep working against
+// clients that predate it.
+type exemplarObserver interface {
+	ObserveWithExemplar(value float64, exemplar prom.Labels)
+}
+
+// ObserveWithTrace records value on the observer, attaching the context's
+// trace ID as an exemplar when there is one and the observer supports
+// exemplars. Without a trace ID, or against a client without exemplar
+// support (the currently vendored one has none), it degrades to a plain
+// observation.
+func ObserveWithTrace(ctx context.Context, observer prom.Observer, value float64) {
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		if eo, ok := observer.(exemplarObserver); ok {
+			eo.ObserveWithExemplar(value, prom.Labels{traceIDLabel: traceID})
+			return
+		}
+	}
+
+	observer.Observe(value)
+}
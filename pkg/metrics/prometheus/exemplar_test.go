@@ -0,0 +1,78 @@
+package prometheus
+
+import (
+	"context"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// exemplarRecordingObserver implements the optional exemplar interface so the
+// test can see exactly what ObserveWithTrace hands to a client that supports
+// exemplars.
+type exemplarRecordingObserver struct {
+	value    float64
+	exemplar prom.Labels
+	plain    bool
+}
+
+func (o *exemplarRecordingObserver) Observe(value float64) {
+	o.value = value
+	o.plain = true
+}
+
+func (o *exemplarRecordingObserver) ObserveWithExemplar(value float64, exemplar prom.Labels) {
+	o.value = value
+	o.exemplar = exemplar
+}
+
+// TestObserveWithTraceRecordsExemplar checks that an observation made with a
+// trace ID in the context reaches an exemplar-capable observer as an exemplar
+// carrying that trace ID, and that the same call without a trace ID stays a
+// plain observation.
+func TestObserveWithTraceRecordsExemplar(t *testing.T) {
+	observer := &exemplarRecordingObserver{}
+	ctx := ContextWithTraceID(context.Background(), "4bf92f3577b34da6")
+
+	ObserveWithTrace(ctx, observer, 1.5)
+
+	if observer.plain {
+		t.Error("expected the observation to go through the exemplar path, got a plain one")
+	}
+	if observer.value != 1.5 {
+		t.Errorf("expected an observation of 1.5, got %v", observer.value)
+	}
+	if got := observer.exemplar[traceIDLabel]; got != "4bf92f3577b34da6" {
+		t.Errorf("expected an exemplar with the current trace ID, got %q", got)
+	}
+
+	observer = &exemplarRecordingObserver{}
+	ObserveWithTrace(context.Background(), observer, 2.5)
+
+	if !observer.plain || observer.exemplar != nil {
+		t.Errorf("expected a plain observation without a trace ID, got exemplar %v", observer.exemplar)
+	}
+}
+
+// TestObserveWithTraceDegradesWithoutExemplarSupport runs the helper against
+// a real histogram from the vendored client, which predates exemplars: the
+// observation must still land.
+func TestObserveWithTraceDegradesWithoutExemplarSupport(t *testing.T) {
+	histogram := prom.NewHistogram(prom.HistogramOpts{Name: "test_seconds"})
+	ctx := ContextWithTraceID(context.Background(), "4bf92f3577b34da6")
+
+	ObserveWithTrace(ctx, histogram, 3.5)
+
+	var metric dto.Metric
+	if err := histogram.Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := metric.GetHistogram().GetSampleCount(); count != 1 {
+		t.Errorf("expected 1 observation, got %d", count)
+	}
+	if sum := metric.GetHistogram().GetSampleSum(); sum != 3.5 {
+		t.Errorf("expected a sample sum of 3.5, got %v", sum)
+	}
+}
@@ -0,0 +1,51 @@
+package prometheus
+
+import (
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Terminal rollout outcomes recorded in RolloutMetrics.Outcomes.
+const (
+	OutcomeCompleted  = "completed"
+	OutcomeRolledBack = "rolled_back"
+	OutcomeFailed     = "failed"
+)
+
+// RolloutMetrics records how rollouts end: each release's terminal outcome, how
+// long it took to go full on, and how many times an operator patched the
+// target step along the way. These complement the per-reconcile workqueue
+// metrics, which say nothing about whether a rollout actually landed.
+type RolloutMetrics struct {
+	Outcomes    *prom.CounterVec
+	Duration    *prom.HistogramVec
+	StepPatches *prom.CounterVec
+}
+
+func NewRolloutMetrics() *RolloutMetrics {
+	return &RolloutMetrics{
+		Outcomes: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: ns,
+			Subsystem: rolloutSubsys,
+			Name:      "outcomes_total",
+			Help:      "How many rollouts reached each terminal outcome (completed, rolled_back, failed), per application",
+		}, []string{"app", "outcome"}),
+		Duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: ns,
+			Subsystem: rolloutSubsys,
+			Name:      "duration_seconds",
+			Help:      "How long completed rollouts took from release creation to full on, per application",
+			// Rollouts with bake periods routinely run for hours.
+			Buckets: []float64{60, 300, 900, 1800, 3600, 7200, 14400, 28800, 86400},
+		}, []string{"app"}),
+		StepPatches: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: ns,
+			Subsystem: rolloutSubsys,
+			Name:      "step_patches_total",
+			Help:      "How many times a release's target step was patched, per application",
+		}, []string{"app"}),
+	}
+}
+
+func (m *RolloutMetrics) GetMetrics() []prom.Collector {
+	return []prom.Collector{m.Outcomes, m.Duration, m.StepPatches}
+}
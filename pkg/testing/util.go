@@ -124,16 +124,22 @@ func FilterActions(actions []kubetesting.Action) []kubetesting.Action {
 		for _, v := range []string{"list", "watch"} {
 			for _, r := range []string{
 				"applications",
+				"applicationsets",
 				"shipmentorders",
 				"releases",
+				"strategies",
 				"clusters",
 				"secrets",
 				"installationtargets",
 				"traffictargets",
 				"capacitytargets",
 				"deployments",
+				"daemonsets",
+				"statefulsets",
 				"services",
 				"pods",
+				"poddisruptionbudgets",
+				"events",
 			} {
 				if action.Matches(v, r) {
 					return true
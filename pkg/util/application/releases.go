@@ -21,12 +21,45 @@ func GetContender(appName string, rels []*shipper.Release) (*shipper.Release, er
 // An incumbent release is the first release in this slice that is considered
 // completed.
 func GetIncumbent(appName string, rels []*shipper.Release) (*shipper.Release, error) {
+	if incumbent := SelectIncumbent(nil, rels); incumbent != nil {
+		return incumbent, nil
+	}
+	return nil, errors.NewIncumbentNotFoundError(appName)
+}
+
+// SelectIncumbent deterministically selects the incumbent among the given
+// releases: the most recent completed release other than the contender.
+// Releases that were scheduled but never completed -- superseded or aborted
+// mid-rollout -- never qualify, no matter where an abort left them in the
+// history, so every controller picking an incumbent through this function
+// lands on the same release. A nil contender excludes nothing. The slice is
+// expected to be sorted by descending generation. Returns nil when no
+// release qualifies.
+func SelectIncumbent(contender *shipper.Release, rels []*shipper.Release) *shipper.Release {
 	for _, r := range rels {
+		if contender != nil && r.GetName() == contender.GetName() {
+			continue
+		}
 		if releaseutil.ReleaseComplete(r) {
+			return r
+		}
+	}
+	return nil
+}
+
+// GetRollbackTarget returns the release a rollback of the given application
+// should target. A completed release marked known good takes precedence over
+// the incumbent, so operators can designate a particular release as safe
+// instead of relying on simple history order. The mark is ignored on releases
+// that never completed. The slice is expected to be sorted by descending
+// generation.
+func GetRollbackTarget(appName string, rels []*shipper.Release) (*shipper.Release, error) {
+	for _, r := range rels {
+		if releaseutil.IsKnownGood(r) && releaseutil.ReleaseComplete(r) {
 			return r, nil
 		}
 	}
-	return nil, errors.NewIncumbentNotFoundError(appName)
+	return GetIncumbent(appName, rels)
 }
 
 // ReleasesToApplicationHistory transforms the given Release slice into a
@@ -40,3 +73,29 @@ func ReleasesToApplicationHistory(releases []*shipper.Release) []string {
 	}
 	return names
 }
+
+// TrimApplicationHistory caps the given history (sorted by ascending
+// generation, as produced by ReleasesToApplicationHistory) at limit entries
+// by dropping the oldest ones. The incumbent's entry is never dropped: if
+// trimming would remove it, it is kept in place of the oldest surviving
+// entry. A limit below 1 leaves the history untouched.
+func TrimApplicationHistory(history []string, limit int, incumbent string) []string {
+	if limit < 1 || len(history) <= limit {
+		return history
+	}
+
+	trimmed := history[len(history)-limit:]
+	if incumbent == "" {
+		return trimmed
+	}
+
+	for _, name := range trimmed {
+		if name == incumbent {
+			return trimmed
+		}
+	}
+
+	// The incumbent fell off the front; keep it at the expense of the
+	// oldest surviving entry.
+	return append([]string{incumbent}, trimmed[1:]...)
+}
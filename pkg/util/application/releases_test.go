@@ -0,0 +1,194 @@
+package application
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// buildReleaseForApp returns a release, optionally completed and optionally
+// marked known good. Callers assemble slices in descending generation order.
+func buildReleaseForApp(name string, complete, knownGood bool) *shipper.Release {
+	rel := &shipper.Release{}
+	rel.Name = name
+
+	if complete {
+		releaseutil.SetReleaseCondition(&rel.Status, *releaseutil.NewReleaseCondition(
+			shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", ""))
+	}
+
+	if knownGood {
+		rel.Annotations = map[string]string{
+			shipper.ReleaseKnownGoodAnnotation: shipper.True,
+		}
+	}
+
+	return rel
+}
+
+func TestGetRollbackTargetPrefersKnownGood(t *testing.T) {
+	rels := []*shipper.Release{
+		buildReleaseForApp("0.0.3", false, false),
+		buildReleaseForApp("0.0.2", true, false),
+		buildReleaseForApp("0.0.1", true, true),
+	}
+
+	target, err := GetRollbackTarget("test-app", rels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The incumbent is 0.0.2, but 0.0.1 carries the known-good mark.
+	if target.Name != "0.0.1" {
+		t.Fatalf("expected rollback target %q, got %q", "0.0.1", target.Name)
+	}
+}
+
+func TestGetRollbackTargetIgnoresIncompleteKnownGood(t *testing.T) {
+	rels := []*shipper.Release{
+		buildReleaseForApp("0.0.3", false, false),
+		buildReleaseForApp("0.0.2", true, false),
+		buildReleaseForApp("0.0.1", false, true),
+	}
+
+	target, err := GetRollbackTarget("test-app", rels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 0.0.1 never completed, so its mark is invalid and the incumbent
+	// wins.
+	if target.Name != "0.0.2" {
+		t.Fatalf("expected rollback target %q, got %q", "0.0.2", target.Name)
+	}
+}
+
+func TestGetRollbackTargetFallsBackToIncumbent(t *testing.T) {
+	rels := []*shipper.Release{
+		buildReleaseForApp("0.0.2", false, false),
+		buildReleaseForApp("0.0.1", true, false),
+	}
+
+	target, err := GetRollbackTarget("test-app", rels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if target.Name != "0.0.1" {
+		t.Fatalf("expected rollback target %q, got %q", "0.0.1", target.Name)
+	}
+}
+
+// TestSelectIncumbent table-tests the deterministic incumbent selection
+// against the history shapes aborts and supersessions leave behind. Slices
+// are in descending generation order, newest first.
+func TestSelectIncumbent(t *testing.T) {
+	tests := []struct {
+		name      string
+		contender string
+		rels      []*shipper.Release
+		expected  string
+	}{
+		{
+			name:      "stable after completed rollouts",
+			contender: "0.0.3",
+			rels: []*shipper.Release{
+				buildReleaseForApp("0.0.3", true, false),
+				buildReleaseForApp("0.0.2", true, false),
+				buildReleaseForApp("0.0.1", true, false),
+			},
+			expected: "0.0.2",
+		},
+		{
+			name:      "mid rollout",
+			contender: "0.0.3",
+			rels: []*shipper.Release{
+				buildReleaseForApp("0.0.3", false, false),
+				buildReleaseForApp("0.0.2", true, false),
+				buildReleaseForApp("0.0.1", true, false),
+			},
+			expected: "0.0.2",
+		},
+		{
+			name:      "abort left an unfinished release behind the contender",
+			contender: "0.0.3",
+			rels: []*shipper.Release{
+				buildReleaseForApp("0.0.3", false, false),
+				buildReleaseForApp("0.0.2", false, false),
+				buildReleaseForApp("0.0.1", true, false),
+			},
+			expected: "0.0.1",
+		},
+		{
+			name:      "chain of superseded rollouts",
+			contender: "0.0.4",
+			rels: []*shipper.Release{
+				buildReleaseForApp("0.0.4", false, false),
+				buildReleaseForApp("0.0.3", false, false),
+				buildReleaseForApp("0.0.2", false, false),
+				buildReleaseForApp("0.0.1", true, false),
+			},
+			expected: "0.0.1",
+		},
+		{
+			name:      "first rollout",
+			contender: "0.0.1",
+			rels: []*shipper.Release{
+				buildReleaseForApp("0.0.1", false, false),
+			},
+			expected: "",
+		},
+		{
+			name:      "contender is the only completed release",
+			contender: "0.0.1",
+			rels: []*shipper.Release{
+				buildReleaseForApp("0.0.1", true, false),
+			},
+			expected: "",
+		},
+		{
+			name:      "nothing ever completed",
+			contender: "0.0.3",
+			rels: []*shipper.Release{
+				buildReleaseForApp("0.0.3", false, false),
+				buildReleaseForApp("0.0.2", false, false),
+				buildReleaseForApp("0.0.1", false, false),
+			},
+			expected: "",
+		},
+		{
+			name:      "nil contender excludes nothing",
+			contender: "",
+			rels: []*shipper.Release{
+				buildReleaseForApp("0.0.2", true, false),
+				buildReleaseForApp("0.0.1", true, false),
+			},
+			expected: "0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contender *shipper.Release
+			for _, rel := range tt.rels {
+				if rel.Name == tt.contender {
+					contender = rel
+				}
+			}
+
+			incumbent := SelectIncumbent(contender, tt.rels)
+
+			got := ""
+			if incumbent != nil {
+				got = incumbent.Name
+			}
+
+			if got != tt.expected {
+				t.Fatalf("expected incumbent %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package application
+
+import (
+	"fmt"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/chart"
+	"github.com/bookingcom/shipper/pkg/errors"
+)
+
+// MigrateRepoURL rewrites the application template's chart repo URL according
+// to the given old-to-new URL mapping. The rewrite only happens after the
+// chart has been verified to resolve at the new URL with fetch; a failed
+// resolution leaves the application untouched and returns an error. It
+// returns whether the application was modified.
+func MigrateRepoURL(app *shipper.Application, mapping map[string]string, fetch chart.FetchFunc) (bool, error) {
+	oldURL := app.Spec.Template.Chart.RepoURL
+	newURL, ok := mapping[oldURL]
+	if !ok || newURL == oldURL {
+		return false, nil
+	}
+
+	candidate := app.Spec.Template.Chart
+	candidate.RepoURL = newURL
+	if _, err := fetch(candidate); err != nil {
+		return false, errors.NewRecoverableError(fmt.Errorf(
+			"cannot migrate chart repo for Application %q from %q to %q: chart %s-%s does not resolve at the new URL: %s",
+			app.Name, oldURL, newURL, candidate.Name, candidate.Version, err))
+	}
+
+	app.Spec.Template.Chart.RepoURL = newURL
+	return true, nil
+}
@@ -0,0 +1,100 @@
+package application
+
+import (
+	"fmt"
+	"testing"
+
+	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+const (
+	oldRepoURL = "https://charts.example.com/old"
+	newRepoURL = "https://charts.example.com/new"
+)
+
+func buildAppWithRepoURL(url string) *shipper.Application {
+	app := &shipper.Application{}
+	app.Name = "test-app"
+	app.Spec.Template.Chart = shipper.Chart{
+		Name:    "simple",
+		Version: "0.0.1",
+		RepoURL: url,
+	}
+	return app
+}
+
+func TestMigrateRepoURLVerifiesResolutionBeforeRewrite(t *testing.T) {
+	app := buildAppWithRepoURL(oldRepoURL)
+	mapping := map[string]string{oldRepoURL: newRepoURL}
+
+	var fetchedURL string
+	fetch := func(chart shipper.Chart) (*helmchart.Chart, error) {
+		fetchedURL = chart.RepoURL
+		// The application must not have been rewritten yet at
+		// resolution time.
+		if app.Spec.Template.Chart.RepoURL != oldRepoURL {
+			t.Fatal("application was rewritten before the chart resolved at the new URL")
+		}
+		return &helmchart.Chart{}, nil
+	}
+
+	migrated, err := MigrateRepoURL(app, mapping, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !migrated {
+		t.Fatal("expected the application to be migrated")
+	}
+
+	if fetchedURL != newRepoURL {
+		t.Fatalf("chart resolution was checked against %q, expected %q", fetchedURL, newRepoURL)
+	}
+
+	if app.Spec.Template.Chart.RepoURL != newRepoURL {
+		t.Fatalf("application repo URL is %q, expected %q", app.Spec.Template.Chart.RepoURL, newRepoURL)
+	}
+}
+
+func TestMigrateRepoURLKeepsOldURLOnFailedResolution(t *testing.T) {
+	app := buildAppWithRepoURL(oldRepoURL)
+	mapping := map[string]string{oldRepoURL: newRepoURL}
+
+	fetch := func(chart shipper.Chart) (*helmchart.Chart, error) {
+		return nil, fmt.Errorf("boom")
+	}
+
+	migrated, err := MigrateRepoURL(app, mapping, fetch)
+	if err == nil {
+		t.Fatal("expected an error when the chart does not resolve at the new URL")
+	}
+
+	if migrated {
+		t.Fatal("application should not have been migrated")
+	}
+
+	if app.Spec.Template.Chart.RepoURL != oldRepoURL {
+		t.Fatalf("application repo URL is %q, expected it to keep %q", app.Spec.Template.Chart.RepoURL, oldRepoURL)
+	}
+}
+
+func TestMigrateRepoURLIgnoresUnmappedURLs(t *testing.T) {
+	app := buildAppWithRepoURL("https://charts.example.com/other")
+	mapping := map[string]string{oldRepoURL: newRepoURL}
+
+	fetch := func(chart shipper.Chart) (*helmchart.Chart, error) {
+		t.Fatal("no resolution should happen for unmapped URLs")
+		return nil, nil
+	}
+
+	migrated, err := MigrateRepoURL(app, mapping, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if migrated {
+		t.Fatal("application should not have been migrated")
+	}
+}
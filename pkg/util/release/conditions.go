@@ -73,6 +73,48 @@ func ReleaseProgressing(release *shipper.Release) bool {
 	return !(ReleaseComplete(release))
 }
 
+// PendingCompletionGates returns the completion gates configured on the
+// release's strategy that are not satisfied yet: each gate names a condition
+// that must be present on the release with status True. Releases without
+// gates get an empty result, preserving pure step-based completion.
+func PendingCompletionGates(release *shipper.Release) []string {
+	strategy := release.Spec.Environment.Strategy
+	if strategy == nil {
+		return nil
+	}
+
+	var pending []string
+	for _, gate := range strategy.CompletionGates {
+		cond := GetReleaseCondition(release.Status, shipper.ReleaseConditionType(gate.ConditionType))
+		if cond == nil || cond.Status != corev1.ConditionTrue {
+			pending = append(pending, gate.ConditionType)
+		}
+	}
+
+	return pending
+}
+
+// ReleaseRollingBack reports whether a release is moving backwards through its
+// strategy: its target step is behind a step it already achieved. Rolling
+// forward and sitting at the target step both return false.
+func ReleaseRollingBack(release *shipper.Release) bool {
+	achievedStep := release.Status.AchievedStep
+	return achievedStep != nil && release.Spec.TargetStep < achievedStep.Step
+}
+
+// ReleaseFailed reports whether any of the release's conditions is explicitly
+// False, meaning a controller attempted to act on the release and reported
+// failure. Conditions that are merely absent don't count: a release that
+// hasn't been scheduled yet is pending, not failed.
+func ReleaseFailed(release *shipper.Release) bool {
+	for _, c := range release.Status.Conditions {
+		if c.Status == corev1.ConditionFalse {
+			return true
+		}
+	}
+	return false
+}
+
 func filterOutCondition(conditions []shipper.ReleaseCondition, condType shipper.ReleaseConditionType) []shipper.ReleaseCondition {
 	var newConditions []shipper.ReleaseCondition
 	for _, c := range conditions {
@@ -0,0 +1,81 @@
+package release
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// buildReleaseInState returns a release with the given target and achieved
+// steps and conditions. A negative achievedStep means no step was achieved
+// yet.
+func buildReleaseInState(targetStep, achievedStep int32, conditions ...shipper.ReleaseCondition) *shipper.Release {
+	rel := &shipper.Release{}
+	rel.Spec.TargetStep = targetStep
+
+	if achievedStep >= 0 {
+		rel.Status.AchievedStep = &shipper.AchievedStep{Step: achievedStep}
+	}
+
+	for _, condition := range conditions {
+		SetReleaseCondition(&rel.Status, condition)
+	}
+
+	return rel
+}
+
+func TestReleaseStatePredicates(t *testing.T) {
+	completeCondition := *NewReleaseCondition(
+		shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", "")
+	failedScheduleCondition := *NewReleaseCondition(
+		shipper.ReleaseConditionTypeScheduled, corev1.ConditionFalse, "NoRegionsSpecified", "")
+
+	tests := []struct {
+		name        string
+		release     *shipper.Release
+		complete    bool
+		rollingBack bool
+		failed      bool
+	}{
+		{
+			name:     "complete",
+			release:  buildReleaseInState(2, 2, completeCondition),
+			complete: true,
+		},
+		{
+			name:    "rolling forward",
+			release: buildReleaseInState(2, 1),
+		},
+		{
+			name:        "rolling back",
+			release:     buildReleaseInState(0, 2, completeCondition),
+			complete:    true,
+			rollingBack: true,
+		},
+		{
+			name:    "failed to schedule",
+			release: buildReleaseInState(0, -1, failedScheduleCondition),
+			failed:  true,
+		},
+		{
+			name:    "brand new, no status",
+			release: buildReleaseInState(0, -1),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ReleaseComplete(test.release); got != test.complete {
+				t.Errorf("ReleaseComplete: expected %t, got %t", test.complete, got)
+			}
+			if got := ReleaseRollingBack(test.release); got != test.rollingBack {
+				t.Errorf("ReleaseRollingBack: expected %t, got %t", test.rollingBack, got)
+			}
+			if got := ReleaseFailed(test.release); got != test.failed {
+				t.Errorf("ReleaseFailed: expected %t, got %t", test.failed, got)
+			}
+		})
+	}
+}
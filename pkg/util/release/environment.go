@@ -9,5 +9,14 @@ func HasEmptyEnvironment(rel *shipper.Release) bool {
 		rel.Spec.Environment.Values == nil &&
 		rel.Spec.Environment.Strategy == nil &&
 		len(rel.Spec.Environment.ClusterRequirements.Regions) == 0 &&
-		len(rel.Spec.Environment.ClusterRequirements.Capabilities) == 0
+		len(rel.Spec.Environment.ClusterRequirements.Capabilities) == 0 &&
+		rel.Spec.Environment.ClusterRequirements.ClusterSelector == nil
+}
+
+// ReleaseInstallOnly reports whether the release's strategy is install-only:
+// no capacity or traffic orchestration, complete as soon as the chart is
+// installed.
+func ReleaseInstallOnly(rel *shipper.Release) bool {
+	strategy := rel.Spec.Environment.Strategy
+	return strategy != nil && strategy.InstallOnly
 }
@@ -0,0 +1,49 @@
+package release
+
+import (
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// WantsFastRollback reports whether a release opted into fast rollback: on a
+// backward targetStep move, jump straight to the target step's capacity and
+// traffic instead of stepping down symmetrically.
+func WantsFastRollback(rel *shipper.Release) bool {
+	return rel.Annotations[shipper.ReleaseFastRollbackAnnotation] == shipper.True
+}
+
+// IsKnownGood reports whether an operator marked a release as the designated
+// safe rollback target for its application.
+func IsKnownGood(rel *shipper.Release) bool {
+	return rel.Annotations[shipper.ReleaseKnownGoodAnnotation] == shipper.True
+}
+
+// RetainsIncumbent reports whether the given contender still holds its
+// incumbent's installed objects in place for a fast rollback: true while the
+// contender's strategy declares an incumbent retention and the contender
+// completed less than that duration ago.
+func RetainsIncumbent(contender *shipper.Release, now time.Time) bool {
+	strategy := contender.Spec.Environment.Strategy
+	if strategy == nil || strategy.IncumbentRetention == nil {
+		return false
+	}
+
+	complete := GetReleaseCondition(contender.Status, shipper.ReleaseConditionTypeComplete)
+	if complete == nil || complete.Status != corev1.ConditionTrue {
+		return false
+	}
+
+	return now.Before(complete.LastTransitionTime.Add(strategy.IncumbentRetention.Duration))
+}
+
+// TrafficApprovedForStep reports whether an operator approved the traffic
+// half of the given strategy step, for steps that split their command gate
+// with ConfirmTraffic.
+func TrafficApprovedForStep(rel *shipper.Release, step int32) bool {
+	approved, ok := rel.Annotations[shipper.ReleaseApprovedTrafficAnnotation]
+	return ok && approved == strconv.Itoa(int(step))
+}
@@ -0,0 +1,76 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func buildContenderWithRetention(retention *metav1.Duration, completedAt time.Time, complete bool) *shipper.Release {
+	rel := &shipper.Release{
+		Spec: shipper.ReleaseSpec{
+			Environment: shipper.ReleaseEnvironment{
+				Strategy: &shipper.RolloutStrategy{
+					IncumbentRetention: retention,
+				},
+			},
+		},
+	}
+
+	status := corev1.ConditionFalse
+	if complete {
+		status = corev1.ConditionTrue
+	}
+
+	rel.Status.Conditions = []shipper.ReleaseCondition{
+		{
+			Type:               shipper.ReleaseConditionTypeComplete,
+			Status:             status,
+			LastTransitionTime: metav1.NewTime(completedAt),
+		},
+	}
+
+	return rel
+}
+
+func TestRetainsIncumbent(t *testing.T) {
+	now := time.Now()
+	hour := &metav1.Duration{Duration: time.Hour}
+
+	tests := []struct {
+		name     string
+		rel      *shipper.Release
+		expected bool
+	}{
+		{
+			name:     "no retention declared",
+			rel:      buildContenderWithRetention(nil, now, true),
+			expected: false,
+		},
+		{
+			name:     "within the retention window",
+			rel:      buildContenderWithRetention(hour, now.Add(-30*time.Minute), true),
+			expected: true,
+		},
+		{
+			name:     "retention expired",
+			rel:      buildContenderWithRetention(hour, now.Add(-2*time.Hour), true),
+			expected: false,
+		},
+		{
+			name:     "contender not complete yet",
+			rel:      buildContenderWithRetention(hour, now, false),
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		if got := RetainsIncumbent(test.rel, now); got != test.expected {
+			t.Errorf("%s: expected RetainsIncumbent to return %t, got %t", test.name, test.expected, got)
+		}
+	}
+}
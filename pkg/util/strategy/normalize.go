@@ -0,0 +1,67 @@
+package strategy
+
+import (
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// fullOnStep is the canonical final step: the contender gets all capacity and
+// all traffic, the incumbent none.
+func fullOnStep() shipper.RolloutStrategyStep {
+	return shipper.RolloutStrategyStep{
+		Name:     "full on",
+		Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+		Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+	}
+}
+
+// stepIsZero reports whether a step leaves all its values unset. Legacy
+// single-step strategies did this, relying on Shipper treating the lone step
+// as "full on".
+func stepIsZero(step shipper.RolloutStrategyStep) bool {
+	return step.Capacity.Incumbent == 0 && step.Capacity.Contender == 0 &&
+		step.Traffic.Incumbent == 0 && step.Traffic.Contender == 0
+}
+
+// NormalizeLegacy upgrades legacy or under-specified strategies to canonical
+// form instead of leaving them to be rejected by Validate: a missing or empty
+// strategy becomes a single full-on step, a lone all-zero step (the old
+// implicit "full on") gets its values filled in, and a strategy that never
+// hands everything over to the contender gets a canonical final step
+// appended. Genuinely broken strategies, such as ones with decreasing
+// contender values, are left alone for validation to reject.
+//
+// The input is never mutated; the second return value reports whether the
+// returned strategy differs from the input.
+func NormalizeLegacy(strategy *shipper.RolloutStrategy) (*shipper.RolloutStrategy, bool) {
+	if strategy == nil || len(strategy.Steps) == 0 {
+		normalized := &shipper.RolloutStrategy{
+			Steps: []shipper.RolloutStrategyStep{fullOnStep()},
+		}
+		if strategy != nil {
+			strategy.DeepCopyInto(normalized)
+			normalized.Steps = []shipper.RolloutStrategyStep{fullOnStep()}
+		}
+
+		return normalized, true
+	}
+
+	normalized := strategy.DeepCopy()
+	changed := false
+
+	if len(normalized.Steps) == 1 && stepIsZero(normalized.Steps[0]) {
+		name := normalized.Steps[0].Name
+		normalized.Steps[0] = fullOnStep()
+		if name != "" {
+			normalized.Steps[0].Name = name
+		}
+		changed = true
+	}
+
+	last := normalized.Steps[len(normalized.Steps)-1]
+	if last.Capacity.Contender != 100 || last.Traffic.Contender != 100 {
+		normalized.Steps = append(normalized.Steps, fullOnStep())
+		changed = true
+	}
+
+	return normalized, changed
+}
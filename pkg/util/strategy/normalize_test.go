@@ -0,0 +1,141 @@
+package strategy
+
+import (
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// TestNormalizeLegacyShapes converts several legacy strategy shapes and checks
+// that each comes out canonical and passes validation.
+func TestNormalizeLegacyShapes(t *testing.T) {
+	tests := []struct {
+		name          string
+		strategy      *shipper.RolloutStrategy
+		expectedSteps int
+	}{
+		{
+			name:          "missing strategy",
+			strategy:      nil,
+			expectedSteps: 1,
+		},
+		{
+			name:          "strategy without steps",
+			strategy:      &shipper.RolloutStrategy{},
+			expectedSteps: 1,
+		},
+		{
+			name: "implicit full on",
+			strategy: &shipper.RolloutStrategy{
+				Steps: []shipper.RolloutStrategyStep{
+					{Name: "full on"},
+				},
+			},
+			expectedSteps: 1,
+		},
+		{
+			name: "lone staging step",
+			strategy: &shipper.RolloutStrategy{
+				Steps: []shipper.RolloutStrategyStep{
+					{
+						Name:     "staging",
+						Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+						Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+					},
+				},
+			},
+			expectedSteps: 2,
+		},
+		{
+			name: "vanguard without handover",
+			strategy: &shipper.RolloutStrategy{
+				Steps: []shipper.RolloutStrategyStep{
+					{
+						Name:     "staging",
+						Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+						Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+					},
+					{
+						Name:     "50/50",
+						Capacity: shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+						Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+					},
+				},
+			},
+			expectedSteps: 3,
+		},
+	}
+
+	for _, test := range tests {
+		normalized, changed := NormalizeLegacy(test.strategy)
+		if !changed {
+			t.Errorf("%s: expected normalization to change the strategy", test.name)
+		}
+
+		if len(normalized.Steps) != test.expectedSteps {
+			t.Errorf("%s: expected %d steps after normalization, got %d",
+				test.name, test.expectedSteps, len(normalized.Steps))
+		}
+
+		if err := Validate(normalized); err != nil {
+			t.Errorf("%s: normalized strategy is still invalid: %s", test.name, err)
+		}
+	}
+}
+
+// TestNormalizeLegacyKeepsStepName checks that filling in an implicit full-on
+// step does not lose the name the user gave it.
+func TestNormalizeLegacyKeepsStepName(t *testing.T) {
+	strategy := &shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{Name: "big bang"},
+		},
+	}
+
+	normalized, _ := NormalizeLegacy(strategy)
+
+	if normalized.Steps[0].Name != "big bang" {
+		t.Errorf("expected the step to keep its name, got %q", normalized.Steps[0].Name)
+	}
+}
+
+// TestNormalizeLegacyLeavesCanonicalStrategiesAlone checks that a strategy
+// which is already valid passes through untouched.
+func TestNormalizeLegacyLeavesCanonicalStrategiesAlone(t *testing.T) {
+	strategy, err := GenerateLinearStrategy(3, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	normalized, changed := NormalizeLegacy(strategy)
+	if changed {
+		t.Errorf("expected a canonical strategy to pass through unchanged, got %+v", normalized)
+	}
+}
+
+// TestNormalizeLegacyLeavesBrokenStrategiesAlone checks that strategies
+// normalization cannot repair, like decreasing contender values, are left for
+// validation to reject.
+func TestNormalizeLegacyLeavesBrokenStrategiesAlone(t *testing.T) {
+	strategy := &shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "50/50",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+			},
+			{
+				Name:     "backwards",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 100},
+			},
+		},
+	}
+	strategy.Steps[1].Capacity.Contender = 25
+
+	normalized, _ := NormalizeLegacy(strategy)
+
+	if err := Validate(normalized); err == nil {
+		t.Error("expected a strategy with decreasing contender capacity to stay invalid")
+	}
+}
@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"fmt"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// ValidateStepOverrides checks that every per-region override in the strategy
+// refers to a region present in the given ClusterRequirements, and that no
+// region is overridden twice within the same step.
+func ValidateStepOverrides(strategy *shipper.RolloutStrategy, reqs shipper.ClusterRequirements) error {
+	if strategy == nil {
+		return nil
+	}
+
+	knownRegions := make(map[string]struct{}, len(reqs.Regions))
+	for _, region := range reqs.Regions {
+		knownRegions[region.Name] = struct{}{}
+	}
+
+	for i, step := range strategy.Steps {
+		seen := make(map[string]struct{}, len(step.Overrides))
+		for _, override := range step.Overrides {
+			if _, ok := knownRegions[override.Region]; !ok {
+				return fmt.Errorf("step %d (%q): override region %q is not listed in clusterRequirements", i, step.Name, override.Region)
+			}
+
+			if _, ok := seen[override.Region]; ok {
+				return fmt.Errorf("step %d (%q): region %q is overridden more than once", i, step.Name, override.Region)
+			}
+			seen[override.Region] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// StepValuesForRegion resolves the effective capacity and traffic values of a
+// step for the clusters of a given region, applying any override the step
+// carries for that region.
+func StepValuesForRegion(step shipper.RolloutStrategyStep, region string) (capacity, traffic shipper.RolloutStrategyStepValue) {
+	capacity, traffic = step.Capacity, step.Traffic
+
+	for _, override := range step.Overrides {
+		if override.Region != region {
+			continue
+		}
+
+		if override.Capacity != nil {
+			capacity = *override.Capacity
+		}
+		if override.Traffic != nil {
+			traffic = *override.Traffic
+		}
+	}
+
+	return capacity, traffic
+}
@@ -0,0 +1,75 @@
+package strategy
+
+import (
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func buildStrategyWithCanaryTrafficOverride() *shipper.RolloutStrategy {
+	return &shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "canary traffic",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+				Overrides: []shipper.RolloutStrategyStepOverride{
+					{
+						Region:  "eu-west",
+						Traffic: &shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+					},
+				},
+			},
+			{
+				Name:     "full on",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+			},
+		},
+	}
+}
+
+func TestValidateStepOverrides(t *testing.T) {
+	strategy := buildStrategyWithCanaryTrafficOverride()
+
+	reqs := shipper.ClusterRequirements{
+		Regions: []shipper.RegionRequirement{{Name: "eu-west"}, {Name: "us-east"}},
+	}
+
+	if err := ValidateStepOverrides(strategy, reqs); err != nil {
+		t.Errorf("expected overrides to validate, got: %s", err)
+	}
+
+	// A region absent from clusterRequirements must be rejected.
+	badReqs := shipper.ClusterRequirements{
+		Regions: []shipper.RegionRequirement{{Name: "us-east"}},
+	}
+
+	if err := ValidateStepOverrides(strategy, badReqs); err == nil {
+		t.Errorf("expected an error for an unknown override region, got none")
+	}
+}
+
+func TestStepValuesForRegion(t *testing.T) {
+	strategy := buildStrategyWithCanaryTrafficOverride()
+	step := strategy.Steps[0]
+
+	// The canary region gets the overridden traffic but the step-wide
+	// capacity.
+	capacity, traffic := StepValuesForRegion(step, "eu-west")
+	if capacity.Contender != 50 {
+		t.Errorf("expected contender capacity 50 in eu-west, got %d", capacity.Contender)
+	}
+	if traffic.Contender != 50 {
+		t.Errorf("expected contender traffic 50 in eu-west, got %d", traffic.Contender)
+	}
+
+	// Any other region gets the step-wide values.
+	capacity, traffic = StepValuesForRegion(step, "us-east")
+	if capacity.Contender != 50 {
+		t.Errorf("expected contender capacity 50 in us-east, got %d", capacity.Contender)
+	}
+	if traffic.Contender != 0 {
+		t.Errorf("expected contender traffic 0 in us-east, got %d", traffic.Contender)
+	}
+}
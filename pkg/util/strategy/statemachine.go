@@ -0,0 +1,121 @@
+package strategy
+
+import (
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// ReleaseTargetState reports which of a release's target objects have
+// achieved the current target step.
+type ReleaseTargetState struct {
+	AchievedInstallation bool
+	AchievedCapacity     bool
+	AchievedTraffic      bool
+}
+
+// State is the input to the strategy state machine: the contender's and
+// (optionally) incumbent's target states at the current target step.
+type State struct {
+	Contender ReleaseTargetState
+	// Incumbent is nil for the very first release of an application.
+	Incumbent *ReleaseTargetState
+	// Ordering decides whether capacity leads traffic (the default) or the
+	// other way around; see shipper.RolloutStrategyOrdering.
+	Ordering shipper.RolloutStrategyOrdering
+}
+
+// Action is the next thing the release controller should drive to move the
+// rollout toward the target step. The rollout is strictly sequential, so
+// there is always exactly one next action.
+type Action string
+
+const (
+	// ActionWaitForInstallation means the contender's chart isn't
+	// installed everywhere yet; nothing else may proceed.
+	ActionWaitForInstallation Action = "WaitForInstallation"
+
+	ActionAdjustContenderCapacity Action = "AdjustContenderCapacity"
+	ActionAdjustContenderTraffic  Action = "AdjustContenderTraffic"
+	ActionAdjustIncumbentTraffic  Action = "AdjustIncumbentTraffic"
+	ActionAdjustIncumbentCapacity Action = "AdjustIncumbentCapacity"
+
+	// ActionNone means every phase has achieved the target step: the step
+	// can be marked achieved and, unless it is the last one, the rollout
+	// waits for a command.
+	ActionNone Action = "None"
+)
+
+// NextAction returns the next pending action for the given state, mirroring
+// the order in which the release controller drives a rollout: contender
+// installation, contender capacity, contender traffic, incumbent traffic,
+// incumbent capacity. A TrafficFirst ordering swaps the capacity and traffic
+// phases on both sides, so the contender receives traffic before scaling up
+// and the incumbent scales down before shedding traffic. The same sequence
+// drives rollbacks; only the target step differs.
+func NextAction(s State) Action {
+	if !s.Contender.AchievedInstallation {
+		return ActionWaitForInstallation
+	}
+
+	contenderPhases := []Action{ActionAdjustContenderCapacity, ActionAdjustContenderTraffic}
+	incumbentPhases := []Action{ActionAdjustIncumbentTraffic, ActionAdjustIncumbentCapacity}
+	if s.Ordering == shipper.StrategyOrderingTrafficFirst {
+		contenderPhases = []Action{ActionAdjustContenderTraffic, ActionAdjustContenderCapacity}
+		incumbentPhases = []Action{ActionAdjustIncumbentCapacity, ActionAdjustIncumbentTraffic}
+	}
+
+	for _, action := range contenderPhases {
+		if !s.Contender.achieved(action) {
+			return action
+		}
+	}
+
+	if s.Incumbent != nil {
+		for _, action := range incumbentPhases {
+			if !s.Incumbent.achieved(action) {
+				return action
+			}
+		}
+	}
+
+	return ActionNone
+}
+
+// achieved reports whether the phase driven by the given action has achieved
+// the target step.
+func (r ReleaseTargetState) achieved(action Action) bool {
+	switch action {
+	case ActionAdjustContenderCapacity, ActionAdjustIncumbentCapacity:
+		return r.AchievedCapacity
+	default:
+		return r.AchievedTraffic
+	}
+}
+
+// DesiredState projects the machine's input onto the user-facing
+// Release.Status.Strategy.State block. It is equivalent to what
+// StrategyConditionsMap.AsReleaseStrategyState computes from conditions, but
+// takes the achievement booleans directly so it can be exercised without
+// building condition objects.
+func DesiredState(s State, isLastStep bool) shipper.ReleaseStrategyState {
+	state := shipper.ReleaseStrategyState{
+		WaitingForCapacity:     shipper.StrategyStateFalse,
+		WaitingForCommand:      shipper.StrategyStateFalse,
+		WaitingForInstallation: shipper.StrategyStateFalse,
+		WaitingForTraffic:      shipper.StrategyStateFalse,
+	}
+
+	switch NextAction(s) {
+	case ActionWaitForInstallation:
+		state.WaitingForInstallation = shipper.StrategyStateTrue
+	case ActionAdjustContenderCapacity, ActionAdjustIncumbentCapacity:
+		state.WaitingForCapacity = shipper.StrategyStateTrue
+	case ActionAdjustContenderTraffic, ActionAdjustIncumbentTraffic:
+		state.WaitingForTraffic = shipper.StrategyStateTrue
+	case ActionNone:
+		if !isLastStep {
+			state.WaitingForCommand = shipper.StrategyStateTrue
+		}
+	}
+
+	return state
+}
@@ -0,0 +1,271 @@
+package strategy
+
+import (
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+type stateMachineTestCase struct {
+	name           string
+	state          State
+	isLastStep     bool
+	expectedAction Action
+	expectedState  shipper.ReleaseStrategyState
+}
+
+func checkStateMachine(t *testing.T, tests []stateMachineTestCase) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if action := NextAction(tt.state); action != tt.expectedAction {
+				t.Errorf("wrong action: expected %q, got %q", tt.expectedAction, action)
+			}
+
+			if state := DesiredState(tt.state, tt.isLastStep); state != tt.expectedState {
+				t.Errorf("wrong strategy state: expected %v, got %v", tt.expectedState, state)
+			}
+		})
+	}
+}
+
+// TestStateMachineForward walks a contender with an incumbent through a full
+// forward rollout step, phase by phase.
+func TestStateMachineForward(t *testing.T) {
+	idle := ReleaseTargetState{AchievedInstallation: true, AchievedCapacity: true, AchievedTraffic: true}
+
+	checkStateMachine(t, []stateMachineTestCase{
+		{
+			name:           "installation pending",
+			state:          State{Incumbent: &idle},
+			expectedAction: ActionWaitForInstallation,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateTrue,
+				WaitingForCapacity:     shipper.StrategyStateFalse,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name: "contender capacity pending",
+			state: State{
+				Contender: ReleaseTargetState{AchievedInstallation: true},
+				Incumbent: &idle,
+			},
+			expectedAction: ActionAdjustContenderCapacity,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateTrue,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name: "contender traffic pending",
+			state: State{
+				Contender: ReleaseTargetState{AchievedInstallation: true, AchievedCapacity: true},
+				Incumbent: &ReleaseTargetState{AchievedInstallation: true},
+			},
+			expectedAction: ActionAdjustContenderTraffic,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateFalse,
+				WaitingForTraffic:      shipper.StrategyStateTrue,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name: "incumbent traffic pending",
+			state: State{
+				Contender: idle,
+				Incumbent: &ReleaseTargetState{AchievedInstallation: true},
+			},
+			expectedAction: ActionAdjustIncumbentTraffic,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateFalse,
+				WaitingForTraffic:      shipper.StrategyStateTrue,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name: "incumbent capacity pending",
+			state: State{
+				Contender: idle,
+				Incumbent: &ReleaseTargetState{AchievedInstallation: true, AchievedTraffic: true},
+			},
+			expectedAction: ActionAdjustIncumbentCapacity,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateTrue,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name:           "step achieved, waiting for command",
+			state:          State{Contender: idle, Incumbent: &idle},
+			expectedAction: ActionNone,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateFalse,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateTrue,
+			},
+		},
+	})
+}
+
+// TestStateMachineBackward covers a rollback: the contender already satisfies
+// the (lower) target step, and the incumbent has to regain traffic and then
+// capacity, in that order.
+func TestStateMachineBackward(t *testing.T) {
+	idle := ReleaseTargetState{AchievedInstallation: true, AchievedCapacity: true, AchievedTraffic: true}
+
+	checkStateMachine(t, []stateMachineTestCase{
+		{
+			name: "incumbent regains traffic first",
+			state: State{
+				Contender: idle,
+				Incumbent: &ReleaseTargetState{AchievedInstallation: true},
+			},
+			expectedAction: ActionAdjustIncumbentTraffic,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateFalse,
+				WaitingForTraffic:      shipper.StrategyStateTrue,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name: "incumbent regains capacity next",
+			state: State{
+				Contender: idle,
+				Incumbent: &ReleaseTargetState{AchievedInstallation: true, AchievedTraffic: true},
+			},
+			expectedAction: ActionAdjustIncumbentCapacity,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateTrue,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name:           "rollback settled",
+			state:          State{Contender: idle, Incumbent: &idle},
+			expectedAction: ActionNone,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateFalse,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateTrue,
+			},
+		},
+	})
+}
+
+// TestStateMachineAbortRecovery covers the all-clear case exercised by the
+// e2e rollout-abort scenario: after the aborted contender is gone, the
+// remaining release is alone at the last step with nothing left to do.
+func TestStateMachineAbortRecovery(t *testing.T) {
+	idle := ReleaseTargetState{AchievedInstallation: true, AchievedCapacity: true, AchievedTraffic: true}
+
+	checkStateMachine(t, []stateMachineTestCase{
+		{
+			name:           "no incumbent, last step, all clear",
+			state:          State{Contender: idle},
+			isLastStep:     true,
+			expectedAction: ActionNone,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateFalse,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name: "no incumbent, capacity still recovering",
+			state: State{
+				Contender: ReleaseTargetState{AchievedInstallation: true, AchievedTraffic: true},
+			},
+			isLastStep:     true,
+			expectedAction: ActionAdjustContenderCapacity,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateTrue,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+	})
+}
+
+// TestStateMachineOrdering asserts that the two orderings produce different
+// intermediate strategy states for the same input: with capacity-first a
+// freshly installed contender waits for capacity, with traffic-first it waits
+// for traffic, and the incumbent phases are mirrored.
+func TestStateMachineOrdering(t *testing.T) {
+	idle := ReleaseTargetState{AchievedInstallation: true, AchievedCapacity: true, AchievedTraffic: true}
+
+	checkStateMachine(t, []stateMachineTestCase{
+		{
+			name: "capacity-first contender",
+			state: State{
+				Contender: ReleaseTargetState{AchievedInstallation: true},
+				Incumbent: &idle,
+				Ordering:  shipper.StrategyOrderingCapacityFirst,
+			},
+			expectedAction: ActionAdjustContenderCapacity,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateTrue,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name: "traffic-first contender",
+			state: State{
+				Contender: ReleaseTargetState{AchievedInstallation: true},
+				Incumbent: &idle,
+				Ordering:  shipper.StrategyOrderingTrafficFirst,
+			},
+			expectedAction: ActionAdjustContenderTraffic,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateFalse,
+				WaitingForTraffic:      shipper.StrategyStateTrue,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name: "capacity-first incumbent sheds traffic first",
+			state: State{
+				Contender: idle,
+				Incumbent: &ReleaseTargetState{AchievedInstallation: true},
+			},
+			expectedAction: ActionAdjustIncumbentTraffic,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateFalse,
+				WaitingForTraffic:      shipper.StrategyStateTrue,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+		{
+			name: "traffic-first incumbent scales down first",
+			state: State{
+				Contender: idle,
+				Incumbent: &ReleaseTargetState{AchievedInstallation: true},
+				Ordering:  shipper.StrategyOrderingTrafficFirst,
+			},
+			expectedAction: ActionAdjustIncumbentCapacity,
+			expectedState: shipper.ReleaseStrategyState{
+				WaitingForInstallation: shipper.StrategyStateFalse,
+				WaitingForCapacity:     shipper.StrategyStateTrue,
+				WaitingForTraffic:      shipper.StrategyStateFalse,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		},
+	})
+}
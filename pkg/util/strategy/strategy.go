@@ -0,0 +1,144 @@
+package strategy
+
+import (
+	"fmt"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// GenerateLinearStrategy produces a RolloutStrategy with the given number of
+// evenly spaced steps, always finishing with the contender at 100% capacity
+// and 100% traffic and the incumbent at 0/0.
+//
+// If trafficLeadsCapacity is false (the usual, safer ordering) traffic for
+// the contender trails capacity by one step, so pods are always in place
+// before they receive load. If it is true, the relationship is inverted,
+// which is useful for workloads that need to observe traffic before scaling.
+func GenerateLinearStrategy(steps int, trafficLeadsCapacity bool) (*shipper.RolloutStrategy, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("cannot generate a linear strategy with %d steps: need at least 1", steps)
+	}
+
+	strategy := &shipper.RolloutStrategy{
+		Steps: make([]shipper.RolloutStrategyStep, 0, steps),
+	}
+
+	for i := 1; i <= steps; i++ {
+		lead := int32(i * 100 / steps)
+		lag := int32((i - 1) * 100 / steps)
+		// The last step always pins both values to 100 so that the
+		// strategy is complete no matter how the steps divide.
+		if i == steps {
+			lag = 100
+		}
+
+		capacity, traffic := lead, lag
+		if trafficLeadsCapacity {
+			capacity, traffic = lag, lead
+		}
+
+		strategy.Steps = append(strategy.Steps, shipper.RolloutStrategyStep{
+			Name: fmt.Sprintf("capacity %d, traffic %d", capacity, traffic),
+			Capacity: shipper.RolloutStrategyStepValue{
+				Incumbent: 100 - capacity,
+				Contender: capacity,
+			},
+			Traffic: shipper.RolloutStrategyStepValue{
+				Incumbent: 100 - traffic,
+				Contender: traffic,
+			},
+		})
+	}
+
+	return strategy, nil
+}
+
+// Validate checks that a RolloutStrategy has at least one step, a known
+// ordering, that all values are percentages between 0 and 100, that contender
+// values never decrease from one step to the next, and that the final step
+// hands everything over to the contender.
+func Validate(strategy *shipper.RolloutStrategy) error {
+	if strategy == nil || len(strategy.Steps) == 0 {
+		return fmt.Errorf("strategy has no steps")
+	}
+
+	switch strategy.Ordering {
+	case "", shipper.StrategyOrderingCapacityFirst, shipper.StrategyOrderingTrafficFirst:
+	default:
+		return fmt.Errorf("unknown ordering %q: must be %q or %q",
+			strategy.Ordering, shipper.StrategyOrderingCapacityFirst, shipper.StrategyOrderingTrafficFirst)
+	}
+
+	var prevCapacity, prevTraffic int32
+	for i, step := range strategy.Steps {
+		for _, v := range []int32{step.Capacity.Incumbent, step.Capacity.Contender, step.Traffic.Incumbent, step.Traffic.Contender} {
+			if v < 0 || v > 100 {
+				return fmt.Errorf("step %d (%q): value %d is not a percentage between 0 and 100", i, step.Name, v)
+			}
+		}
+
+		if step.Capacity.Contender < prevCapacity {
+			return fmt.Errorf("step %d (%q): contender capacity decreased from %d to %d", i, step.Name, prevCapacity, step.Capacity.Contender)
+		}
+
+		if step.Traffic.Contender < prevTraffic {
+			return fmt.Errorf("step %d (%q): contender traffic decreased from %d to %d", i, step.Name, prevTraffic, step.Traffic.Contender)
+		}
+
+		prevCapacity = step.Capacity.Contender
+		prevTraffic = step.Traffic.Contender
+	}
+
+	last := strategy.Steps[len(strategy.Steps)-1]
+	if last.Capacity.Contender != 100 || last.Traffic.Contender != 100 {
+		return fmt.Errorf("final step (%q) must give the contender 100%% capacity and traffic, got %d/%d",
+			last.Name, last.Capacity.Contender, last.Traffic.Contender)
+	}
+
+	return nil
+}
+
+// ForRegion returns the strategy releases should use on clusters in the given
+// region. The first tier listing the region wins; regions not covered by any
+// tier fall back to defaultStrategy. This lets one application roll out with
+// different strategies per cluster tier, e.g. all-in for staging and vanguard
+// for production.
+func ForRegion(tiers []shipper.StrategyTier, defaultStrategy *shipper.RolloutStrategy, region string) *shipper.RolloutStrategy {
+	for _, tier := range tiers {
+		for _, r := range tier.Regions {
+			if r == region {
+				return tier.Strategy
+			}
+		}
+	}
+	return defaultStrategy
+}
+
+// ValidateTiers checks every strategy tier independently: each tier needs a
+// name, at least one region, no region claimed by two tiers, and a strategy
+// that passes Validate.
+func ValidateTiers(tiers []shipper.StrategyTier) error {
+	seenRegions := map[string]string{}
+	for i, tier := range tiers {
+		if tier.Name == "" {
+			return fmt.Errorf("tier %d has no name", i)
+		}
+
+		if len(tier.Regions) == 0 {
+			return fmt.Errorf("tier %q has no regions", tier.Name)
+		}
+
+		for _, region := range tier.Regions {
+			if other, ok := seenRegions[region]; ok {
+				return fmt.Errorf("region %q is claimed by both tier %q and tier %q", region, other, tier.Name)
+			}
+			seenRegions[region] = tier.Name
+		}
+
+		if err := Validate(tier.Strategy); err != nil {
+			return fmt.Errorf("tier %q: %s", tier.Name, err)
+		}
+	}
+
+	return nil
+}
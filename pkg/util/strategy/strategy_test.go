@@ -0,0 +1,141 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestGenerateLinearStrategy(t *testing.T) {
+	for _, steps := range []int{1, 5, 10} {
+		for _, trafficLeadsCapacity := range []bool{false, true} {
+			strategy, err := GenerateLinearStrategy(steps, trafficLeadsCapacity)
+			if err != nil {
+				t.Fatalf("GenerateLinearStrategy(%d, %t): %s", steps, trafficLeadsCapacity, err)
+			}
+
+			if len(strategy.Steps) != steps {
+				t.Errorf("GenerateLinearStrategy(%d, %t): got %d steps", steps, trafficLeadsCapacity, len(strategy.Steps))
+			}
+
+			if err := Validate(strategy); err != nil {
+				t.Errorf("GenerateLinearStrategy(%d, %t) produced an invalid strategy: %s", steps, trafficLeadsCapacity, err)
+			}
+
+			for i, step := range strategy.Steps {
+				if step.Capacity.Incumbent+step.Capacity.Contender != 100 {
+					t.Errorf("step %d: capacity does not sum to 100: %+v", i, step.Capacity)
+				}
+				if step.Traffic.Incumbent+step.Traffic.Contender != 100 {
+					t.Errorf("step %d: traffic does not sum to 100: %+v", i, step.Traffic)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateLinearStrategyRejectsZeroSteps(t *testing.T) {
+	if _, err := GenerateLinearStrategy(0, false); err == nil {
+		t.Errorf("expected an error for 0 steps, got none")
+	}
+}
+
+func TestStrategyTiers(t *testing.T) {
+	allIn := &shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "full on",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+			},
+		},
+	}
+
+	vanguard := &shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "staging",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+			},
+			{
+				Name:     "50/50",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+			},
+			{
+				Name:     "full on",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+			},
+		},
+	}
+
+	tiers := []shipper.StrategyTier{
+		{Name: "staging", Regions: []string{"staging-eu"}, Strategy: allIn},
+		{Name: "prod", Regions: []string{"eu-west", "us-east"}, Strategy: vanguard},
+	}
+
+	if err := ValidateTiers(tiers); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ForRegion(tiers, vanguard, "staging-eu"); got != allIn {
+		t.Errorf("expected the staging tier's all-in strategy for region staging-eu, got %v", got)
+	}
+
+	if got := ForRegion(tiers, vanguard, "us-east"); got != vanguard {
+		t.Errorf("expected the prod tier's vanguard strategy for region us-east, got %v", got)
+	}
+
+	if got := ForRegion(tiers, vanguard, "ap-south"); got != vanguard {
+		t.Errorf("expected the default strategy for an uncovered region, got %v", got)
+	}
+}
+
+func TestValidateTiersRejectsBrokenTier(t *testing.T) {
+	broken := &shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "half-hearted",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+			},
+		},
+	}
+
+	tiers := []shipper.StrategyTier{
+		{Name: "prod", Regions: []string{"eu-west"}, Strategy: broken},
+	}
+
+	err := ValidateTiers(tiers)
+	if err == nil {
+		t.Fatal("expected an error for a tier whose final step doesn't hand over to the contender")
+	}
+
+	if !strings.Contains(err.Error(), `tier "prod"`) {
+		t.Errorf("expected the error to name the offending tier, got %q", err)
+	}
+}
+
+func TestValidateTiersRejectsDoubleClaimedRegion(t *testing.T) {
+	allIn := &shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "full on",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+			},
+		},
+	}
+
+	tiers := []shipper.StrategyTier{
+		{Name: "staging", Regions: []string{"eu-west"}, Strategy: allIn},
+		{Name: "prod", Regions: []string{"eu-west"}, Strategy: allIn},
+	}
+
+	if err := ValidateTiers(tiers); err == nil {
+		t.Fatal("expected an error when two tiers claim the same region")
+	}
+}
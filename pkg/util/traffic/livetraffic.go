@@ -0,0 +1,65 @@
+package traffic
+
+import (
+	"sort"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// ReleaseTraffic is one release's live share of a single cluster's traffic.
+type ReleaseTraffic struct {
+	Release        string
+	AchievedWeight uint32
+	// Percent is the release's share of the cluster's total achieved
+	// weight, rounded to the nearest whole percent. Weights are relative,
+	// so this is what "how much traffic does it get" actually means.
+	Percent int
+}
+
+// LiveTraffic answers "which release serves traffic where" for a single
+// application: given the app's TrafficTargets, it returns, per cluster, the
+// releases currently receiving traffic there, with their achieved weights
+// and percentage shares. Mid-rollout this yields an incumbent+contender
+// split; releases at zero achieved weight are omitted. Entries sort by
+// descending share, then by release name.
+func LiveTraffic(trafficTargets []*shipper.TrafficTarget) map[string][]ReleaseTraffic {
+	totals := make(map[string]uint32)
+	for _, tt := range trafficTargets {
+		for _, cluster := range tt.Status.Clusters {
+			totals[cluster.Name] += cluster.AchievedTraffic
+		}
+	}
+
+	live := make(map[string][]ReleaseTraffic)
+	for _, tt := range trafficTargets {
+		release := tt.Labels[shipper.ReleaseLabel]
+		if release == "" {
+			// TrafficTargets are named after their release.
+			release = tt.Name
+		}
+
+		for _, cluster := range tt.Status.Clusters {
+			if cluster.AchievedTraffic == 0 {
+				continue
+			}
+
+			total := totals[cluster.Name]
+			live[cluster.Name] = append(live[cluster.Name], ReleaseTraffic{
+				Release:        release,
+				AchievedWeight: cluster.AchievedTraffic,
+				Percent:        int((uint64(cluster.AchievedTraffic)*200 + uint64(total)) / (2 * uint64(total))),
+			})
+		}
+	}
+
+	for _, releases := range live {
+		sort.Slice(releases, func(a, b int) bool {
+			if releases[a].AchievedWeight != releases[b].AchievedWeight {
+				return releases[a].AchievedWeight > releases[b].AchievedWeight
+			}
+			return releases[a].Release < releases[b].Release
+		})
+	}
+
+	return live
+}
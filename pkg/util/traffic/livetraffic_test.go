@@ -0,0 +1,62 @@
+package traffic
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func buildTrafficTarget(release string, achieved map[string]uint32) *shipper.TrafficTarget {
+	tt := &shipper.TrafficTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      release,
+			Namespace: "test-namespace",
+			Labels: map[string]string{
+				shipper.AppLabel:     "test-app",
+				shipper.ReleaseLabel: release,
+			},
+		},
+	}
+
+	for cluster, weight := range achieved {
+		tt.Status.Clusters = append(tt.Status.Clusters, &shipper.ClusterTrafficStatus{
+			Name:            cluster,
+			AchievedTraffic: weight,
+		})
+	}
+
+	return tt
+}
+
+// TestLiveTrafficMidRollout aggregates a mid-rollout app: eu-west splits
+// traffic between incumbent and contender, us-east still serves the
+// incumbent alone, and the contender's zero-weight entry there is omitted.
+func TestLiveTrafficMidRollout(t *testing.T) {
+	incumbent := buildTrafficTarget("test-app-deadbeef-0", map[string]uint32{
+		"eu-west": 70,
+		"us-east": 100,
+	})
+	contender := buildTrafficTarget("test-app-cafebabe-1", map[string]uint32{
+		"eu-west": 30,
+		"us-east": 0,
+	})
+
+	live := LiveTraffic([]*shipper.TrafficTarget{incumbent, contender})
+
+	expected := map[string][]ReleaseTraffic{
+		"eu-west": {
+			{Release: "test-app-deadbeef-0", AchievedWeight: 70, Percent: 70},
+			{Release: "test-app-cafebabe-1", AchievedWeight: 30, Percent: 30},
+		},
+		"us-east": {
+			{Release: "test-app-deadbeef-0", AchievedWeight: 100, Percent: 100},
+		},
+	}
+
+	if !reflect.DeepEqual(live, expected) {
+		t.Errorf("wrong live traffic: expected %v, got %v", expected, live)
+	}
+}
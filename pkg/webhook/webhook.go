@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"mime"
 	"net/http"
+	"strings"
 
 	"github.com/golang/glog"
 
@@ -16,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	strategyutil "github.com/bookingcom/shipper/pkg/util/strategy"
 )
 
 type Webhook struct {
@@ -76,6 +78,7 @@ func (c *Webhook) Run(stopCh <-chan struct{}) {
 func (c *Webhook) initializeHandlers() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/validate", adaptHandler(c.validateHandlerFunc))
+	mux.HandleFunc("/mutate", adaptHandler(c.mutateHandlerFunc))
 	return mux
 }
 
@@ -175,3 +178,188 @@ func (c *Webhook) validateHandlerFunc(review *admission_v1beta1.AdmissionReview)
 		Allowed: true,
 	}
 }
+
+// TargetStepAuditEntry records one change of a Release's spec.targetStep,
+// including who requested it. Controllers can't see the requesting user, so
+// the webhook captures it from the admission request's userInfo.
+type TargetStepAuditEntry struct {
+	User     string       `json:"user"`
+	FromStep int32        `json:"fromStep"`
+	ToStep   int32        `json:"toStep"`
+	Time     meta_v1.Time `json:"time"`
+}
+
+// auditNow is stubbed in tests for deterministic audit entries.
+var auditNow = meta_v1.Now
+
+// mutateHandlerFunc dispatches to the per-kind mutations: Releases get an
+// audit entry stamped on spec.targetStep changes, Applications get legacy
+// strategies normalized. Everything else is allowed through untouched.
+func (c *Webhook) mutateHandlerFunc(review *admission_v1beta1.AdmissionReview) *admission_v1beta1.AdmissionResponse {
+	switch review.Request.Kind.Kind {
+	case "Release":
+		return c.mutateRelease(review)
+	case "Application":
+		return c.mutateApplication(review)
+	}
+
+	return &admission_v1beta1.AdmissionResponse{Allowed: true}
+}
+
+// mutateRelease stamps an audit entry on Releases whose spec.targetStep
+// changed, recording the requesting user in the release's audit annotation.
+func (c *Webhook) mutateRelease(review *admission_v1beta1.AdmissionReview) *admission_v1beta1.AdmissionResponse {
+	request := review.Request
+	allowed := &admission_v1beta1.AdmissionResponse{Allowed: true}
+
+	if len(request.OldObject.Raw) == 0 {
+		return allowed
+	}
+
+	var newRelease, oldRelease shipper.Release
+	if err := json.Unmarshal(request.Object.Raw, &newRelease); err != nil {
+		return &admission_v1beta1.AdmissionResponse{
+			Result: &meta_v1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+	if err := json.Unmarshal(request.OldObject.Raw, &oldRelease); err != nil {
+		return &admission_v1beta1.AdmissionResponse{
+			Result: &meta_v1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	if newRelease.Spec.TargetStep == oldRelease.Spec.TargetStep {
+		return allowed
+	}
+
+	var entries []TargetStepAuditEntry
+	if existing, ok := newRelease.Annotations[shipper.ReleaseTargetStepAuditAnnotation]; ok {
+		// A malformed audit list shouldn't block the rollout; start over.
+		if err := json.Unmarshal([]byte(existing), &entries); err != nil {
+			glog.Warningf("Release %s/%s has a malformed audit annotation, resetting it: %s",
+				newRelease.Namespace, newRelease.Name, err)
+			entries = nil
+		}
+	}
+
+	entries = append(entries, TargetStepAuditEntry{
+		User:     request.UserInfo.Username,
+		FromStep: oldRelease.Spec.TargetStep,
+		ToStep:   newRelease.Spec.TargetStep,
+		Time:     auditNow(),
+	})
+
+	value, err := json.Marshal(entries)
+	if err != nil {
+		return &admission_v1beta1.AdmissionResponse{
+			Result: &meta_v1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	// "/" in annotation keys must be escaped as "~1" in JSON patch paths.
+	escapedKey := strings.Replace(shipper.ReleaseTargetStepAuditAnnotation, "/", "~1", -1)
+
+	var patch []map[string]interface{}
+	if newRelease.Annotations == nil {
+		patch = append(patch, map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations",
+			"value": map[string]string{shipper.ReleaseTargetStepAuditAnnotation: string(value)},
+		})
+	} else {
+		patch = append(patch, map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations/" + escapedKey,
+			"value": string(value),
+		})
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return &admission_v1beta1.AdmissionResponse{
+			Result: &meta_v1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	patchType := admission_v1beta1.PatchTypeJSONPatch
+
+	return &admission_v1beta1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patchBytes,
+		PatchType: &patchType,
+	}
+}
+
+// mutateApplication upgrades legacy, under-specified rollout strategies to
+// canonical form so that stricter strategy validation doesn't start rejecting
+// existing Applications. An annotation is stamped alongside the rewrite so
+// operators can tell which objects were upgraded.
+func (c *Webhook) mutateApplication(review *admission_v1beta1.AdmissionReview) *admission_v1beta1.AdmissionResponse {
+	request := review.Request
+
+	var app shipper.Application
+	if err := json.Unmarshal(request.Object.Raw, &app); err != nil {
+		return &admission_v1beta1.AdmissionResponse{
+			Result: &meta_v1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	normalized, changed := strategyutil.NormalizeLegacy(app.Spec.Template.Strategy)
+	if !changed {
+		return &admission_v1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	// JSON patch "add" replaces existing object members, so the same op
+	// works whether or not the template already carried a strategy.
+	patch := []map[string]interface{}{
+		{
+			"op":    "add",
+			"path":  "/spec/template/strategy",
+			"value": normalized,
+		},
+	}
+
+	// "/" in annotation keys must be escaped as "~1" in JSON patch paths.
+	escapedKey := strings.Replace(shipper.AppStrategyNormalizedAnnotation, "/", "~1", -1)
+
+	if app.Annotations == nil {
+		patch = append(patch, map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations",
+			"value": map[string]string{shipper.AppStrategyNormalizedAnnotation: shipper.True},
+		})
+	} else {
+		patch = append(patch, map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations/" + escapedKey,
+			"value": shipper.True,
+		})
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return &admission_v1beta1.AdmissionResponse{
+			Result: &meta_v1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	patchType := admission_v1beta1.PatchTypeJSONPatch
+
+	return &admission_v1beta1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patchBytes,
+		PatchType: &patchType,
+	}
+}
@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+
+	admission_v1beta1 "k8s.io/api/admission/v1beta1"
+	authentication_v1 "k8s.io/api/authentication/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func buildAuditReview(t *testing.T, oldRelease, newRelease *shipper.Release, username string) *admission_v1beta1.AdmissionReview {
+	oldRaw, err := json.Marshal(oldRelease)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRaw, err := json.Marshal(newRelease)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &admission_v1beta1.AdmissionReview{
+		Request: &admission_v1beta1.AdmissionRequest{
+			Kind:      meta_v1.GroupVersionKind{Kind: "Release"},
+			Object:    runtime.RawExtension{Raw: newRaw},
+			OldObject: runtime.RawExtension{Raw: oldRaw},
+			UserInfo: authentication_v1.UserInfo{
+				Username: username,
+			},
+		},
+	}
+}
+
+func buildReleaseWithTargetStep(step int32) *shipper.Release {
+	return &shipper.Release{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: step,
+		},
+	}
+}
+
+// TestMutateRecordsTargetStepAudit checks that a patch moving spec.targetStep
+// produces a JSON patch recording the requesting user in the release's audit
+// annotation.
+func TestMutateRecordsTargetStepAudit(t *testing.T) {
+	auditNow = func() meta_v1.Time { return meta_v1.Time{} }
+	defer func() { auditNow = meta_v1.Now }()
+
+	c := NewWebhook("localhost", "9443", "", "")
+	review := buildAuditReview(t, buildReleaseWithTargetStep(0), buildReleaseWithTargetStep(2), "jane@example.com")
+
+	response := c.mutateHandlerFunc(review)
+	if !response.Allowed {
+		t.Fatalf("expected the request to be allowed, got %v", response.Result)
+	}
+
+	if response.Patch == nil {
+		t.Fatal("expected a patch recording the audit entry, got none")
+	}
+
+	var patch []struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(response.Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patch) != 1 || patch[0].Op != "add" || patch[0].Path != "/metadata/annotations" {
+		t.Fatalf("expected a single add on /metadata/annotations, got %v", patch)
+	}
+
+	annotations, ok := patch[0].Value.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected the patch value to be an annotations map, got %v", patch[0].Value)
+	}
+
+	var entries []TargetStepAuditEntry
+	if err := json.Unmarshal([]byte(annotations[shipper.ReleaseTargetStepAuditAnnotation].(string)), &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []TargetStepAuditEntry{
+		{User: "jane@example.com", FromStep: 0, ToStep: 2},
+	}
+
+	if len(entries) != 1 || entries[0] != expected[0] {
+		t.Errorf("expected audit entries %v, got %v", expected, entries)
+	}
+}
+
+func buildApplicationReview(t *testing.T, app *shipper.Application) *admission_v1beta1.AdmissionReview {
+	raw, err := json.Marshal(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &admission_v1beta1.AdmissionReview{
+		Request: &admission_v1beta1.AdmissionRequest{
+			Kind:   meta_v1.GroupVersionKind{Kind: "Application"},
+			Object: runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+func buildApplicationWithStrategy(strategy *shipper.RolloutStrategy) *shipper.Application {
+	return &shipper.Application{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "test-application",
+			Namespace: "test-namespace",
+		},
+		Spec: shipper.ApplicationSpec{
+			Template: shipper.ReleaseEnvironment{
+				Strategy: strategy,
+			},
+		},
+	}
+}
+
+// TestMutateNormalizesLegacyStrategy checks that an Application with a legacy
+// all-zero lone step gets a patch rewriting the strategy to canonical form and
+// stamping the normalized annotation.
+func TestMutateNormalizesLegacyStrategy(t *testing.T) {
+	c := NewWebhook("localhost", "9443", "", "")
+	app := buildApplicationWithStrategy(&shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{Name: "full on"},
+		},
+	})
+
+	response := c.mutateHandlerFunc(buildApplicationReview(t, app))
+	if !response.Allowed {
+		t.Fatalf("expected the request to be allowed, got %v", response.Result)
+	}
+
+	if response.Patch == nil {
+		t.Fatal("expected a patch normalizing the strategy, got none")
+	}
+
+	var patch []struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(response.Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patch) != 2 || patch[0].Path != "/spec/template/strategy" || patch[1].Path != "/metadata/annotations" {
+		t.Fatalf("expected patches on the strategy and the annotations, got %v", patch)
+	}
+
+	strategyRaw, err := json.Marshal(patch[0].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var normalized shipper.RolloutStrategy
+	if err := json.Unmarshal(strategyRaw, &normalized); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(normalized.Steps) != 1 ||
+		normalized.Steps[0].Capacity.Contender != 100 ||
+		normalized.Steps[0].Traffic.Contender != 100 {
+		t.Errorf("expected a canonical full-on step, got %+v", normalized.Steps)
+	}
+
+	annotations, ok := patch[1].Value.(map[string]interface{})
+	if !ok || annotations[shipper.AppStrategyNormalizedAnnotation] != shipper.True {
+		t.Errorf("expected the %s annotation to be stamped, got %v",
+			shipper.AppStrategyNormalizedAnnotation, patch[1].Value)
+	}
+}
+
+// TestMutateIgnoresCanonicalStrategy checks that Applications whose strategy
+// already hands everything over to the contender pass through without a patch.
+func TestMutateIgnoresCanonicalStrategy(t *testing.T) {
+	c := NewWebhook("localhost", "9443", "", "")
+	app := buildApplicationWithStrategy(&shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "full on",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 0, Contender: 100},
+			},
+		},
+	})
+
+	response := c.mutateHandlerFunc(buildApplicationReview(t, app))
+	if !response.Allowed {
+		t.Fatalf("expected the request to be allowed, got %v", response.Result)
+	}
+
+	if response.Patch != nil {
+		t.Errorf("expected no patch for a canonical strategy, got %s", response.Patch)
+	}
+}
+
+// TestMutateIgnoresUnchangedTargetStep checks that updates which don't move
+// spec.targetStep pass through without a patch.
+func TestMutateIgnoresUnchangedTargetStep(t *testing.T) {
+	c := NewWebhook("localhost", "9443", "", "")
+	review := buildAuditReview(t, buildReleaseWithTargetStep(1), buildReleaseWithTargetStep(1), "jane@example.com")
+
+	response := c.mutateHandlerFunc(review)
+	if !response.Allowed {
+		t.Fatalf("expected the request to be allowed, got %v", response.Result)
+	}
+
+	if response.Patch != nil {
+		t.Errorf("expected no patch for an unchanged targetStep, got %s", response.Patch)
+	}
+}
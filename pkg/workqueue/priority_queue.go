@@ -0,0 +1,168 @@
+// Package workqueue provides a priority-aware variant of client-go's
+// rate-limiting workqueue, so controllers can reconcile important objects
+// (e.g. production-tier releases) ahead of a backlog of less important ones.
+package workqueue
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// PriorityFunc returns the priority of a queued item; higher values dequeue
+// first. It is consulted at dequeue time, so a priority derived from cluster
+// state (say, a lister lookup) stays current while the item waits.
+type PriorityFunc func(item interface{}) int
+
+// PriorityQueue implements workqueue.RateLimitingInterface with the same
+// deduplication and processing semantics as the default implementation, but
+// dequeues the highest-priority item first; items of equal priority keep
+// their FIFO order.
+type PriorityQueue struct {
+	cond *sync.Cond
+
+	queue        []interface{}
+	dirty        map[interface{}]struct{}
+	processing   map[interface{}]struct{}
+	shuttingDown bool
+
+	priorityFor PriorityFunc
+	rateLimiter workqueue.RateLimiter
+}
+
+// NewPriorityRateLimitingQueue returns a rate-limiting workqueue that
+// dequeues items in priorityFor order.
+func NewPriorityRateLimitingQueue(rateLimiter workqueue.RateLimiter, priorityFor PriorityFunc) *PriorityQueue {
+	return &PriorityQueue{
+		cond:        sync.NewCond(&sync.Mutex{}),
+		dirty:       make(map[interface{}]struct{}),
+		processing:  make(map[interface{}]struct{}),
+		priorityFor: priorityFor,
+		rateLimiter: rateLimiter,
+	}
+}
+
+// Add marks the item as needing processing, deduplicating it against items
+// already waiting or being processed, exactly like the default workqueue.
+func (q *PriorityQueue) Add(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.shuttingDown {
+		return
+	}
+	if _, ok := q.dirty[item]; ok {
+		return
+	}
+
+	q.dirty[item] = struct{}{}
+	if _, ok := q.processing[item]; ok {
+		return
+	}
+
+	q.queue = append(q.queue, item)
+	q.cond.Signal()
+}
+
+// Len returns how many items are waiting to be processed.
+func (q *PriorityQueue) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return len(q.queue)
+}
+
+// Get blocks until an item is available or the queue shuts down, and returns
+// the waiting item with the highest priority; the first-added wins among
+// equals.
+func (q *PriorityQueue) Get() (item interface{}, shutdown bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	for len(q.queue) == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if len(q.queue) == 0 {
+		// We must be shutting down.
+		return nil, true
+	}
+
+	best := 0
+	bestPriority := q.priorityFor(q.queue[0])
+	for n := 1; n < len(q.queue); n++ {
+		if priority := q.priorityFor(q.queue[n]); priority > bestPriority {
+			best, bestPriority = n, priority
+		}
+	}
+
+	item = q.queue[best]
+	q.queue = append(q.queue[:best], q.queue[best+1:]...)
+
+	q.processing[item] = struct{}{}
+	delete(q.dirty, item)
+
+	return item, false
+}
+
+// Done marks the item as no longer being processed; if it was re-added in
+// the meantime it goes back into the queue.
+func (q *PriorityQueue) Done(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	delete(q.processing, item)
+	if _, ok := q.dirty[item]; ok {
+		q.queue = append(q.queue, item)
+		q.cond.Signal()
+	}
+}
+
+// ShutDown causes the queue to stop accepting items and unblocks all
+// waiting Get calls once drained.
+func (q *PriorityQueue) ShutDown() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+// ShuttingDown reports whether ShutDown was called.
+func (q *PriorityQueue) ShuttingDown() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.shuttingDown
+}
+
+// AddAfter adds the item after the given duration has passed.
+func (q *PriorityQueue) AddAfter(item interface{}, duration time.Duration) {
+	if q.ShuttingDown() {
+		return
+	}
+
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+
+	time.AfterFunc(duration, func() {
+		q.Add(item)
+	})
+}
+
+// AddRateLimited adds the item after the rate limiter says it's ok.
+func (q *PriorityQueue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+// Forget tells the rate limiter to stop tracking the item's retries.
+func (q *PriorityQueue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+// NumRequeues returns how many times the item was rate-limited back in.
+func (q *PriorityQueue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
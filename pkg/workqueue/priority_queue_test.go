@@ -0,0 +1,80 @@
+package workqueue
+
+import (
+	"testing"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// priorities maps test items to their priority; unlisted items default to
+// zero, like an unlabeled object would.
+func priorityFromMap(priorities map[string]int) PriorityFunc {
+	return func(item interface{}) int {
+		return priorities[item.(string)]
+	}
+}
+
+// TestPriorityOrdering enqueues a mix of priorities and checks that the
+// high-priority items come out first, with FIFO order among equals.
+func TestPriorityOrdering(t *testing.T) {
+	q := NewPriorityRateLimitingQueue(
+		workqueue.DefaultControllerRateLimiter(),
+		priorityFromMap(map[string]int{
+			"prod-a":  2,
+			"prod-b":  2,
+			"staging": 1,
+		}),
+	)
+
+	q.Add("dev-a")
+	q.Add("prod-a")
+	q.Add("staging")
+	q.Add("dev-b")
+	q.Add("prod-b")
+
+	expected := []string{"prod-a", "prod-b", "staging", "dev-a", "dev-b"}
+	for _, want := range expected {
+		item, shutdown := q.Get()
+		if shutdown {
+			t.Fatal("queue shut down unexpectedly")
+		}
+		if item != want {
+			t.Errorf("expected item %q, got %q", want, item)
+		}
+		q.Done(item)
+	}
+
+	if q.Len() != 0 {
+		t.Errorf("expected an empty queue, got %d items", q.Len())
+	}
+}
+
+// TestAddWhileProcessing re-adds an item while it's being processed: it must
+// not be handed out again until Done, and then exactly once.
+func TestAddWhileProcessing(t *testing.T) {
+	q := NewPriorityRateLimitingQueue(
+		workqueue.DefaultControllerRateLimiter(),
+		priorityFromMap(nil),
+	)
+
+	q.Add("item")
+	item, _ := q.Get()
+
+	q.Add("item")
+	if q.Len() != 0 {
+		t.Fatalf("an item being processed must not re-queue before Done, got %d queued", q.Len())
+	}
+
+	q.Done(item)
+	if q.Len() != 1 {
+		t.Fatalf("expected the re-added item after Done, got %d queued", q.Len())
+	}
+
+	item, _ = q.Get()
+	q.Done(item)
+
+	q.ShutDown()
+	if _, shutdown := q.Get(); !shutdown {
+		t.Error("expected Get to report shutdown on a drained, shut-down queue")
+	}
+}